@@ -503,7 +503,7 @@ func TestResolveTypeOnInterfaceYieldsUsefulError(t *testing.T) {
 		},
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: `Runtime Object type "Human" is not a possible type for "Pet".`,
+				Message: `Runtime Object type "Human" resolved for field Query.pets with a value of Go type *graphql_test.testHuman is not a possible type for "Pet", possible types: "Cat", "Dog".`,
 				Locations: []location.SourceLocation{
 					{
 						Line:   2,
@@ -626,7 +626,7 @@ func TestResolveTypeOnUnionYieldsUsefulError(t *testing.T) {
 		},
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: `Runtime Object type "Human" is not a possible type for "Pet".`,
+				Message: `Runtime Object type "Human" resolved for field Query.pets with a value of Go type *graphql_test.testHuman is not a possible type for "Pet", possible types: "Cat", "Dog".`,
 				Locations: []location.SourceLocation{
 					{
 						Line:   2,
@@ -649,3 +649,78 @@ func TestResolveTypeOnUnionYieldsUsefulError(t *testing.T) {
 		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
 	}
 }
+
+func TestUnresolvedRuntimeTypeYieldsUsefulError(t *testing.T) {
+	petType := graphql.NewInterface(graphql.InterfaceConfig{
+		Name: "Pet",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.String},
+		},
+	})
+	dogType := graphql.NewObject(graphql.ObjectConfig{
+		Name:       "Dog",
+		Interfaces: []*graphql.Interface{petType},
+		IsTypeOf: func(p graphql.IsTypeOfParams) bool {
+			_, ok := p.Value.(*testDog)
+			return ok
+		},
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.String},
+		},
+	})
+	catType := graphql.NewObject(graphql.ObjectConfig{
+		Name:       "Cat",
+		Interfaces: []*graphql.Interface{petType},
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.String},
+		},
+	})
+	config := graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"pet": &graphql.Field{
+					Type: petType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return &testCat{"Garfield", false}, nil
+					},
+				},
+			},
+		}),
+		Types: []graphql.Type{dogType, catType},
+	}
+	schema, err := graphql.NewSchema(config)
+	if err != nil {
+		t.Fatalf("Error in schema %v", err.Error())
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ pet { name } }`,
+	})
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"pet": nil,
+		},
+		Errors: []gqlerrors.FormattedError{
+			{
+				Message: `Abstract type Pet must resolve to an Object type at runtime for field Query.pet with a value of Go type *graphql_test.testCat, received "<nil>". ` +
+					`Either Pet should provide a ResolveType function or each of its possible types ("Cat", "Dog") should provide an IsTypeOf function.`,
+				Locations: []location.SourceLocation{{Line: 1, Column: 3}},
+				Path:      []interface{}{"pet"},
+			},
+		},
+	}
+	if !testutil.EqualResults(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+
+	config.StrictAbstractTypes = true
+	_, err = graphql.NewSchema(config)
+	expectedError := `Interface Type Pet does not provide a "resolveType" function and ` +
+		`implementing Type Cat does not provide a "isTypeOf" function. ` +
+		`There is no way to resolve this implementing type during execution.`
+	if err == nil || err.Error() != expectedError {
+		t.Fatalf("Expected error: %v, got %v", expectedError, err)
+	}
+}
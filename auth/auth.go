@@ -0,0 +1,178 @@
+// Package auth provides helpers to authenticate the requests made to a GraphQL handler and to
+// restrict the fields of a schema to authenticated users.
+//
+// The bearer token of HTTP requests is put in their context by Middleware, the token sent with
+// the connection_init message of websocket connections is validated by ConnectionInit, and the
+// resolvers wrapped by Require are only called for authenticated users having the required roles.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/handler"
+)
+
+// Error codes set under the "code" key of the extensions of the errors returned by this package.
+const (
+	CodeUnauthenticated = "UNAUTHENTICATED"
+	CodeForbidden       = "FORBIDDEN"
+)
+
+// Error is an error with a code in its extensions, which is sent to the clients.
+type Error struct {
+	Message string
+	Code    string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extensions implements gqlerrors.ExtendedError.
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code}
+}
+
+// Unauthenticated returns an error with the UNAUTHENTICATED code, to reject a field requested
+// without valid credentials.
+func Unauthenticated(message string) error {
+	return &Error{Message: message, Code: CodeUnauthenticated}
+}
+
+// Forbidden returns an error with the FORBIDDEN code, to reject a field the authenticated user
+// isn't allowed to see.
+func Forbidden(message string) error {
+	return &Error{Message: message, Code: CodeForbidden}
+}
+
+type tokenKey struct{}
+
+type rolesKey struct{}
+
+// WithToken returns a copy of ctx holding the token of the request.
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFromContext returns the token put in ctx by Middleware, ConnectionInit or WithToken.
+func TokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenKey{}).(string)
+	return token, ok && token != ""
+}
+
+// WithRoles returns a copy of ctx holding the roles of the authenticated user, as checked by
+// Require. It is meant to be called once the token was validated.
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFromContext returns the roles put in ctx by WithRoles.
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
+
+// BearerToken extracts the token of the "Authorization: Bearer <token>" header of r.
+func BearerToken(r *http.Request) (string, bool) {
+	return parseBearer(r.Header.Get("Authorization"))
+}
+
+func parseBearer(authorization string) (string, bool) {
+	const prefix = "bearer "
+	if len(authorization) < len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(authorization[len(prefix):])
+	return token, token != ""
+}
+
+// Middleware puts the bearer token of the requests in their context, where it can be found with
+// TokenFromContext. Requests without a token are passed along untouched.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := BearerToken(r); ok {
+			r = r.WithContext(WithToken(r.Context(), token))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ValidateTokenFn checks a token and returns the context to use for the requests made with it,
+// usually with the roles of the user set by WithRoles. It returns an error if the token is invalid.
+type ValidateTokenFn func(ctx context.Context, token string) (context.Context, error)
+
+// ConnectionInit returns a handler.ConnectionInitFn that validates the token sent in the payload
+// of the connection_init message, under the "Authorization" (with or without the "Bearer "
+// prefix), "authToken" or "token" keys. Connections without a valid token are refused.
+func ConnectionInit(validate ValidateTokenFn) handler.ConnectionInitFn {
+	return func(ctx context.Context, payload map[string]interface{}) (context.Context, error) {
+		token := ""
+		for _, key := range []string{"Authorization", "authorization", "authToken", "token"} {
+			if value, ok := payload[key].(string); ok && value != "" {
+				token = value
+				if bearer, ok := parseBearer(value); ok {
+					token = bearer
+				}
+				break
+			}
+		}
+		if token == "" {
+			return ctx, Unauthenticated("missing authentication token")
+		}
+		ctx, err := validate(WithToken(ctx, token), token)
+		if err != nil {
+			return ctx, Unauthenticated(err.Error())
+		}
+		return ctx, nil
+	}
+}
+
+// Directive declares @auth in the schema so that it shows up in the introspection, it must be
+// added to the directives of the schema. The fields it is documented on must have their resolvers
+// wrapped with Require, which implements it.
+var Directive = graphql.NewDirective(graphql.DirectiveConfig{
+	Name:        "auth",
+	Description: "Restricts a field to authenticated users having one of the `requires` roles.",
+	Args: graphql.FieldConfigArgument{
+		"requires": &graphql.ArgumentConfig{
+			Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+			Description: "The roles allowed to see the field, any authenticated user if empty.",
+		},
+	},
+	Locations: []string{
+		graphql.DirectiveLocationFieldDefinition,
+		graphql.DirectiveLocationObject,
+	},
+})
+
+// Require wraps resolve so that it is only called for authenticated requests, i.e. with a token
+// in their context, and, if roles are given, for users having one of them. Otherwise the field
+// resolves to an UNAUTHENTICATED or FORBIDDEN error. A nil resolve uses the default resolver.
+func Require(resolve graphql.FieldResolveFn, roles ...string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if _, ok := TokenFromContext(p.Context); !ok {
+			return nil, Unauthenticated("authentication required")
+		}
+		if len(roles) != 0 && !hasRole(RolesFromContext(p.Context), roles) {
+			return nil, Forbidden("not allowed to access " + p.Info.ParentType.Name() + "." + p.Info.FieldName)
+		}
+		if resolve == nil {
+			return graphql.DefaultResolveFn(p)
+		}
+		return resolve(p)
+	}
+}
+
+func hasRole(userRoles []string, roles []string) bool {
+	for _, userRole := range userRoles {
+		for _, role := range roles {
+			if userRole == role {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/auth"
+)
+
+func TestMiddleware_PutsBearerTokenInContext(t *testing.T) {
+	tests := []struct {
+		authorization string
+		token         string
+		ok            bool
+	}{
+		{"Bearer abc", "abc", true},
+		{"bearer  abc ", "abc", true},
+		{"Basic abc", "", false},
+		{"Bearer ", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		var token string
+		var ok bool
+		h := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok = auth.TokenFromContext(r.Context())
+		}))
+		req := httptest.NewRequest("GET", "/graphql", nil)
+		req.Header.Set("Authorization", test.authorization)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if token != test.token || ok != test.ok {
+			t.Fatalf("expected token %q (%v) for %q, got %q (%v)", test.token, test.ok, test.authorization, token, ok)
+		}
+	}
+}
+
+func TestConnectionInit_ValidatesToken(t *testing.T) {
+	initFn := auth.ConnectionInit(func(ctx context.Context, token string) (context.Context, error) {
+		if token != "secret" {
+			return ctx, errors.New("invalid token")
+		}
+		return auth.WithRoles(ctx, "admin"), nil
+	})
+
+	ctx, err := initFn(context.Background(), map[string]interface{}{"Authorization": "Bearer secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token, _ := auth.TokenFromContext(ctx); token != "secret" {
+		t.Fatalf("expected the token in the context, got %q", token)
+	}
+	if roles := auth.RolesFromContext(ctx); !reflect.DeepEqual(roles, []string{"admin"}) {
+		t.Fatalf("expected the roles in the context, got %v", roles)
+	}
+
+	for _, payload := range []map[string]interface{}{nil, {"authToken": "wrong"}} {
+		_, err := initFn(context.Background(), payload)
+		var authErr *auth.Error
+		if !errors.As(err, &authErr) || authErr.Code != auth.CodeUnauthenticated {
+			t.Fatalf("expected an unauthenticated error for %v, got %v", payload, err)
+		}
+	}
+}
+
+func TestRequire_RejectsFields(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"public": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return "public", nil
+					},
+				},
+				"secret": &graphql.Field{
+					Type: graphql.String,
+					Resolve: auth.Require(func(p graphql.ResolveParams) (interface{}, error) {
+						return "secret", nil
+					}, "admin"),
+				},
+			},
+		}),
+		Directives: append(graphql.SpecifiedDirectives, auth.Directive),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ctx    context.Context
+		secret interface{}
+		code   string
+	}{
+		{context.Background(), nil, auth.CodeUnauthenticated},
+		{auth.WithRoles(auth.WithToken(context.Background(), "t"), "user"), nil, auth.CodeForbidden},
+		{auth.WithRoles(auth.WithToken(context.Background(), "t"), "admin"), "secret", ""},
+	}
+	for _, test := range tests {
+		result := graphql.Do(graphql.Params{
+			Schema:        schema,
+			RequestString: `{ public secret }`,
+			Context:       test.ctx,
+		})
+		expected := map[string]interface{}{"public": "public", "secret": test.secret}
+		if !reflect.DeepEqual(result.Data, expected) {
+			t.Fatalf("expected %v, got %v", expected, result.Data)
+		}
+		if test.code == "" {
+			if len(result.Errors) != 0 {
+				t.Fatalf("unexpected errors: %v", result.Errors)
+			}
+			continue
+		}
+		if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != test.code {
+			t.Fatalf("expected a %s error, got %v", test.code, result.Errors)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/fiatjaf/graphql/gqlerrors"
+)
+
+// AuthorizeFn decides if a field may be resolved, see Field.Authorize. It is given the params the
+// resolver would be called with, and returns an error to deny the access.
+type AuthorizeFn func(ctx context.Context, p ResolveParams) error
+
+// forbiddenError is the error reported for fields denied by their AuthorizeFn, when it didn't
+// return an error with its own extensions.
+type forbiddenError struct {
+	err error
+}
+
+func (e *forbiddenError) Error() string {
+	return e.err.Error()
+}
+
+func (e *forbiddenError) Unwrap() error {
+	return e.err
+}
+
+// Extensions implements gqlerrors.ExtendedError.
+func (e *forbiddenError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "FORBIDDEN"}
+}
+
+// authorize calls the AuthorizeFn of fieldDef, if any, and returns the error to report for the
+// field if the access is denied.
+func authorize(ctx context.Context, fieldDef *FieldDefinition, p ResolveParams) error {
+	if fieldDef.Authorize == nil {
+		return nil
+	}
+	err := fieldDef.Authorize(ctx, p)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(gqlerrors.ExtendedError); ok {
+		return err
+	}
+	return &forbiddenError{err: err}
+}
@@ -0,0 +1,96 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/language/location"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+type roleKey struct{}
+
+type codedError struct{}
+
+func (codedError) Error() string { return "log in first" }
+
+func (codedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "UNAUTHENTICATED"}
+}
+
+func TestAuthorize_NullsDeniedFieldsWithoutResolvingThem(t *testing.T) {
+	resolved := map[string]bool{}
+	resolve := func(value string) graphql.FieldResolveFn {
+		return func(p graphql.ResolveParams) (interface{}, error) {
+			resolved[p.Info.FieldName] = true
+			return value, nil
+		}
+	}
+	requireRole := func(role string) graphql.AuthorizeFn {
+		return func(ctx context.Context, p graphql.ResolveParams) error {
+			if ctx.Value(roleKey{}) != role {
+				return errors.New("not allowed to see " + p.Info.FieldName)
+			}
+			return nil
+		}
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"public": &graphql.Field{Type: graphql.String, Resolve: resolve("public")},
+				"user":   &graphql.Field{Type: graphql.String, Resolve: resolve("user"), Authorize: requireRole("user")},
+				"admin":  &graphql.Field{Type: graphql.String, Resolve: resolve("admin"), Authorize: requireRole("admin")},
+				"session": &graphql.Field{
+					Type:    graphql.String,
+					Resolve: resolve("session"),
+					Authorize: func(ctx context.Context, p graphql.ResolveParams) error {
+						return codedError{}
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ public user admin session }`,
+		Context:       context.WithValue(context.Background(), roleKey{}, "user"),
+	})
+	expectedData := map[string]interface{}{
+		"public":  "public",
+		"user":    "user",
+		"admin":   nil,
+		"session": nil,
+	}
+	if !reflect.DeepEqual(expectedData, result.Data) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedData, result.Data))
+	}
+	expectedErrors := []gqlerrors.FormattedError{
+		{
+			Message:    "not allowed to see admin",
+			Locations:  []location.SourceLocation{{Line: 1, Column: 15}},
+			Path:       []interface{}{"admin"},
+			Extensions: map[string]interface{}{"code": "FORBIDDEN"},
+		},
+		{
+			Message:    "log in first",
+			Locations:  []location.SourceLocation{{Line: 1, Column: 21}},
+			Path:       []interface{}{"session"},
+			Extensions: map[string]interface{}{"code": "UNAUTHENTICATED"},
+		},
+	}
+	if !testutil.EqualFormattedErrors(expectedErrors, result.Errors) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedErrors, result.Errors))
+	}
+	if !reflect.DeepEqual(map[string]bool{"public": true, "user": true}, resolved) {
+		t.Fatalf("denied fields were resolved: %v", resolved)
+	}
+}
@@ -82,6 +82,41 @@ func ListSchemaWithXItems(x int) graphql.Schema {
 	return colorSchema
 }
 
+// DefaultResolveListSchemaWithXItems is like ListSchemaWithXItems, but the fields of the colors are
+// resolved from the struct fields by the default resolver.
+func DefaultResolveListSchemaWithXItems(x int) graphql.Schema {
+	list := generateXListItems(x)
+
+	color := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Color",
+		Description: "A color",
+		Fields: graphql.Fields{
+			"hex": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"r":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"g":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"b":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"colors": {
+				Type: graphql.NewList(color),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return list, nil
+				},
+			},
+		},
+	})
+
+	colorSchema, _ := graphql.NewSchema(graphql.SchemaConfig{
+		Query: queryType,
+	})
+
+	return colorSchema
+}
+
 var colors []color
 
 func init() {
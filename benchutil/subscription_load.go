@@ -0,0 +1,327 @@
+package benchutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/handler"
+	"github.com/gorilla/websocket"
+)
+
+// SubscriptionLoad describes a load on the subscription path of the handler: Clients websocket
+// connections each subscribing SubscriptionsPerClient times to a field whose events are
+// published to all the subscriptions at once.
+type SubscriptionLoad struct {
+	Clients                int
+	SubscriptionsPerClient int
+
+	// EventInterval is the time between two published events. When it is zero an event is
+	// published once the previous one was received by all the subscriptions.
+	EventInterval time.Duration
+
+	// Payload is the size in bytes of a string sent with each event, to weigh the results.
+	Payload int
+
+	// Config is the configuration of the handler, whose Schema and WebSocket are set by the load.
+	Config handler.Config
+
+	// Timeout is how long the subscriptions are waited for, 10 seconds if zero.
+	Timeout time.Duration
+}
+
+// SubscriptionLoadResult is the outcome of RunSubscriptionLoad.
+type SubscriptionLoadResult struct {
+	// Events is the number of events received by the subscriptions.
+	Events int
+
+	// Missed is the number of events published but not received, dropped by the subscriptions
+	// lagging behind.
+	Missed int
+
+	// Duration is the time from the first published event to the last received one.
+	Duration time.Duration
+
+	// Latencies are the times between the publication and the reception of the events, sorted.
+	Latencies []time.Duration
+}
+
+// Percentile returns the latency below which are p percent of the events.
+func (r *SubscriptionLoadResult) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(r.Latencies)-1) * p / 100)
+	return r.Latencies[i]
+}
+
+// Throughput returns the number of events received per second.
+func (r *SubscriptionLoadResult) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Events) / r.Duration.Seconds()
+}
+
+// quietPeriod is how long without receiving events after which the missing ones are missed.
+const quietPeriod = 100 * time.Millisecond
+
+// RunSubscriptionLoad serves the handler of load, connects its clients, subscribes them, and
+// then publishes events to all the subscriptions, returning the latencies measured by the
+// clients, end to end from the publication of each event to the reception of its result.
+//
+// The subscriptions are ready once they received a first warm-up event, which isn't measured.
+// It fails when they aren't ready before the Timeout.
+func RunSubscriptionLoad(load SubscriptionLoad, events int) (*SubscriptionLoadResult, error) {
+	if load.Timeout <= 0 {
+		load.Timeout = 10 * time.Second
+	}
+	subscriptions := load.Clients * load.SubscriptionsPerClient
+	source := &eventSource{}
+	schema, err := subscriptionLoadSchema(source, strings.Repeat("x", load.Payload))
+	if err != nil {
+		return nil, err
+	}
+	config := load.Config
+	config.Schema = &schema
+	config.WebSocket = true
+	server := httptest.NewServer(handler.New(&config))
+	defer server.Close()
+
+	received := &loadRecorder{ready: map[string]struct{}{}}
+	var readers sync.WaitGroup
+	var conns []*websocket.Conn
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+		readers.Wait()
+	}()
+	for i := 0; i < load.Clients; i++ {
+		conn, err := dialLoadClient("ws"+strings.TrimPrefix(server.URL, "http"), load.SubscriptionsPerClient)
+		if err != nil {
+			return nil, err
+		}
+		conns = append(conns, conn)
+		readers.Add(1)
+		go func(client int) {
+			defer readers.Done()
+			received.read(conn, client)
+		}(i)
+	}
+
+	deadline := time.Now().Add(load.Timeout)
+	for received.readyCount() < subscriptions {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("only %d of %d subscriptions are ready", received.readyCount(), subscriptions)
+		}
+		source.publish(true)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	start := time.Now()
+	received.start(start)
+	for i := 0; i < events; i++ {
+		source.publish(false)
+		if load.EventInterval > 0 {
+			time.Sleep(load.EventInterval)
+		} else {
+			received.wait((i+1)*subscriptions, time.Now(), load.Timeout)
+		}
+	}
+	received.wait(events*subscriptions, time.Now(), load.Timeout)
+
+	result := received.result()
+	result.Missed = events*subscriptions - result.Events
+	return result, nil
+}
+
+// subscriptionLoadSchema is a schema whose "event" subscription sends the events of source, as
+// the nanoseconds of their publication time, or "warm-up" for the warm-up events.
+func subscriptionLoadSchema(source *eventSource, payload string) (graphql.Schema, error) {
+	event := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Event",
+		Fields: graphql.Fields{
+			"published": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"payload":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"event": &graphql.Field{
+					Type: graphql.NewNonNull(event),
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						return source.subscribe(), nil
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						published := warmUp
+						if nanos := p.Source.(int64); nanos > 0 {
+							published = strconv.FormatInt(nanos, 10)
+						}
+						return map[string]interface{}{
+							"published": published,
+							"payload":   payload,
+						}, nil
+					},
+				},
+			},
+		}),
+	})
+}
+
+// warmUp is the publication time of the warm-up events.
+const warmUp = "warm-up"
+
+// eventSource publishes events to all its subscribers.
+type eventSource struct {
+	mutex       sync.Mutex
+	subscribers []chan interface{}
+}
+
+func (s *eventSource) subscribe() chan interface{} {
+	events := make(chan interface{}, 64)
+	s.mutex.Lock()
+	s.subscribers = append(s.subscribers, events)
+	s.mutex.Unlock()
+	return events
+}
+
+// publish sends the current time, or a warm-up event, to all the subscribers, dropping it for
+// those lagging behind.
+func (s *eventSource) publish(warmUp bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	event := int64(0)
+	if !warmUp {
+		event = time.Now().UnixNano()
+	}
+	for _, events := range s.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// dialLoadClient opens a websocket connection subscribing n times to the events.
+func dialLoadClient(url string, n int) (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.WriteJSON(handler.GraphQLWSMessage{Type: "connection_init"}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var ack handler.GraphQLWSMessage
+	if err := conn.ReadJSON(&ack); err != nil || ack.Type != "connection_ack" {
+		conn.Close()
+		return nil, fmt.Errorf("connection not acknowledged: %v", err)
+	}
+	payload, _ := json.Marshal(handler.GraphQLWSSubscriptionPayload{
+		Query: `subscription { event { published payload } }`,
+	})
+	for i := 0; i < n; i++ {
+		err := conn.WriteJSON(handler.GraphQLWSMessage{ID: strconv.Itoa(i), Type: "subscribe", Payload: payload})
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// loadRecorder records the latencies of the events received by the clients.
+type loadRecorder struct {
+	mutex     sync.Mutex
+	ready     map[string]struct{}
+	started   time.Time
+	latencies []time.Duration
+	received  time.Time
+}
+
+// read records the events received by client on conn until it is closed.
+func (r *loadRecorder) read(conn *websocket.Conn, client int) {
+	for {
+		var msg struct {
+			ID      string `json:"id"`
+			Type    string `json:"type"`
+			Payload struct {
+				Data struct {
+					Event struct {
+						Published string `json:"published"`
+					} `json:"event"`
+				} `json:"data"`
+			} `json:"payload"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		if msg.Type != "next" {
+			continue
+		}
+		now := time.Now()
+		r.mutex.Lock()
+		if msg.Payload.Data.Event.Published == warmUp {
+			r.ready[strconv.Itoa(client)+"/"+msg.ID] = struct{}{}
+		} else if published, err := strconv.ParseInt(msg.Payload.Data.Event.Published, 10, 64); err == nil {
+			r.latencies = append(r.latencies, now.Sub(time.Unix(0, published)))
+			r.received = now
+		}
+		r.mutex.Unlock()
+	}
+}
+
+func (r *loadRecorder) readyCount() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.ready)
+}
+
+func (r *loadRecorder) start(started time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.started, r.received = started, started
+}
+
+// wait waits until n events were received, for at most timeout after published, and returns early
+// when none were received for the quietPeriod, the missing ones having been dropped.
+func (r *loadRecorder) wait(n int, published time.Time, timeout time.Duration) {
+	for {
+		r.mutex.Lock()
+		count, received := len(r.latencies), r.received
+		r.mutex.Unlock()
+		if received.Before(published) {
+			received = published
+		}
+		now := time.Now()
+		if count >= n || now.Sub(published) > timeout || now.Sub(received) > quietPeriod {
+			return
+		}
+		time.Sleep(10 * time.Microsecond)
+	}
+}
+
+func (r *loadRecorder) result() *SubscriptionLoadResult {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	latencies := append([]time.Duration(nil), r.latencies...)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return &SubscriptionLoadResult{
+		Events:    len(latencies),
+		Duration:  r.received.Sub(r.started),
+		Latencies: latencies,
+	}
+}
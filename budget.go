@@ -0,0 +1,77 @@
+package graphql
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Budget bounds the number of executions, or of field resolutions, running at the same time, see
+// SchemaConfig.ExecutionBudget and SchemaConfig.FieldBudget. Those above the limit wait for a slot,
+// or until their context is done. A Budget can be shared by several schemas.
+type Budget struct {
+	slots   chan struct{}
+	waiting int64
+}
+
+// BudgetStats is a snapshot of the usage of a Budget.
+type BudgetStats struct {
+	// Capacity is the maximum number of slots in use at the same time.
+	Capacity int
+	// Running is the number of slots in use.
+	Running int
+	// Waiting is the number of callers queued for a slot.
+	Waiting int
+}
+
+// NewBudget returns a Budget allowing up to capacity slots in use at the same time.
+func NewBudget(capacity int) *Budget {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Budget{slots: make(chan struct{}, capacity)}
+}
+
+// Acquire takes a slot, waiting for one to be released if there are none left. It returns the
+// error of ctx if ctx is done first, in which case no slot was taken.
+func (b *Budget) Acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	atomic.AddInt64(&b.waiting, 1)
+	defer atomic.AddInt64(&b.waiting, -1)
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release gives back a slot taken by Acquire.
+func (b *Budget) Release() {
+	<-b.slots
+}
+
+// Stats returns the current usage of the budget, e.g. to export the depth of its queue as a metric.
+func (b *Budget) Stats() BudgetStats {
+	return BudgetStats{
+		Capacity: cap(b.slots),
+		Running:  len(b.slots),
+		Waiting:  int(atomic.LoadInt64(&b.waiting)),
+	}
+}
+
+// resolveWithBudget calls resolveFn in a slot of the budget, if there is one.
+func resolveWithBudget(budget *Budget, resolveFn FieldResolveFn, p ResolveParams) (interface{}, error) {
+	if budget == nil {
+		return resolveFn(p)
+	}
+	if err := budget.Acquire(p.Context); err != nil {
+		return nil, err
+	}
+	defer budget.Release()
+	return resolveFn(p)
+}
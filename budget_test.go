@@ -0,0 +1,92 @@
+package graphql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+)
+
+func budgetSchema(t *testing.T, config graphql.SchemaConfig, release chan struct{}, started chan struct{}) graphql.Schema {
+	config.Query = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"slow": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					started <- struct{}{}
+					<-release
+					return "done", nil
+				},
+			},
+		},
+	})
+	schema, err := graphql.NewSchema(config)
+	if err != nil {
+		t.Fatalf("Error in schema %v", err.Error())
+	}
+	return schema
+}
+
+func waitForBudget(t *testing.T, budget *graphql.Budget, expected graphql.BudgetStats) {
+	for i := 0; i < 100; i++ {
+		if budget.Stats() == expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected budget stats %+v, got %+v", expected, budget.Stats())
+}
+
+func TestExecutionBudget_BoundsConcurrentExecutions(t *testing.T) {
+	budget := graphql.NewBudget(1)
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	schema := budgetSchema(t, graphql.SchemaConfig{ExecutionBudget: budget}, release, started)
+
+	first := graphql.DoAsync(graphql.Params{Schema: schema, RequestString: `{ slow }`})
+	<-started
+	second := graphql.DoAsync(graphql.Params{Schema: schema, RequestString: `{ slow }`})
+	waitForBudget(t, budget, graphql.BudgetStats{Capacity: 1, Running: 1, Waiting: 1})
+
+	// a request whose context is done while waiting fails without being executed
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ slow }`, Context: ctx})
+	if len(result.Errors) != 1 || result.Errors[0].Message != context.DeadlineExceeded.Error() {
+		t.Fatalf("expected the deadline error, got %v", result.Errors)
+	}
+
+	release <- struct{}{}
+	if result := <-first; result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	<-started
+	release <- struct{}{}
+	if result := <-second; result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	waitForBudget(t, budget, graphql.BudgetStats{Capacity: 1, Running: 0, Waiting: 0})
+}
+
+func TestFieldBudget_BoundsConcurrentResolvers(t *testing.T) {
+	budget := graphql.NewBudget(1)
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	schema := budgetSchema(t, graphql.SchemaConfig{FieldBudget: budget}, release, started)
+
+	first := graphql.DoAsync(graphql.Params{Schema: schema, RequestString: `{ slow }`})
+	second := graphql.DoAsync(graphql.Params{Schema: schema, RequestString: `{ slow }`})
+	<-started
+	waitForBudget(t, budget, graphql.BudgetStats{Capacity: 1, Running: 1, Waiting: 1})
+
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+	for _, results := range []chan *graphql.Result{first, second} {
+		if result := <-results; result.HasErrors() {
+			t.Fatalf("unexpected errors: %v", result.Errors)
+		}
+	}
+}
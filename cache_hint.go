@@ -0,0 +1,102 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// CacheHint tells how long the value of a field can be cached, see Field.CacheHint. The response
+// to an operation can be cached for the shortest MaxAge of its fields, and only privately, for the
+// user who requested it, if any of them is Private.
+type CacheHint struct {
+	MaxAge  time.Duration
+	Private bool
+}
+
+// CacheHintFromDirectives returns the CacheHint declared by the
+// @cacheControl(maxAge: Int, scope: PUBLIC | PRIVATE) directive of the definition of a field,
+// maxAge being in seconds, and nil if it has none.
+func CacheHintFromDirectives(directives []*ast.Directive) *CacheHint {
+	for _, directive := range directives {
+		if directive.Name == nil || directive.Name.Value != "cacheControl" {
+			continue
+		}
+		hint := &CacheHint{}
+		for _, arg := range directive.Arguments {
+			if arg.Name == nil {
+				continue
+			}
+			switch arg.Name.Value {
+			case "maxAge":
+				maxAge, _ := intLiteral(arg.Value)
+				hint.MaxAge = time.Duration(maxAge) * time.Second
+			case "scope":
+				scope, ok := arg.Value.(*ast.EnumValue)
+				hint.Private = ok && scope.Value == "PRIVATE"
+			}
+		}
+		return hint
+	}
+	return nil
+}
+
+// CachePolicy computes the CacheHint of a response from the hints of the fields resolved by its
+// execution, see WithCachePolicy. The fields without a CacheHint don't restrict it when they are
+// scalars or enums, or lists of them, below other fields, as their value comes with their parent,
+// while the others make the response uncacheable.
+type CachePolicy struct {
+	mutex      sync.Mutex
+	hint       CacheHint
+	restricted bool
+}
+
+type cachePolicyKey struct{}
+
+// WithCachePolicy returns a context computing the CachePolicy of the response of the operation
+// executed with it.
+func WithCachePolicy(ctx context.Context) (context.Context, *CachePolicy) {
+	policy := &CachePolicy{}
+	return context.WithValue(ctx, cachePolicyKey{}, policy), policy
+}
+
+// RestrictCacheHint restricts the CacheHint of the response being computed with ctx, e.g. by a
+// resolver whose value can only be cached for less time than its field tells. It does nothing
+// when the response has no CachePolicy.
+func RestrictCacheHint(ctx context.Context, hint CacheHint) {
+	if policy, ok := ctx.Value(cachePolicyKey{}).(*CachePolicy); ok {
+		policy.Restrict(hint)
+	}
+}
+
+// Restrict lowers the MaxAge of the policy to the one of hint if it is shorter, and makes it
+// private if hint is.
+func (p *CachePolicy) Restrict(hint CacheHint) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.restricted || hint.MaxAge < p.hint.MaxAge {
+		p.hint.MaxAge = hint.MaxAge
+	}
+	p.hint.Private = p.hint.Private || hint.Private
+	p.restricted = true
+}
+
+// Hint returns the CacheHint of the response, and false if it can't be cached.
+func (p *CachePolicy) Hint() (CacheHint, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.hint, p.restricted && p.hint.MaxAge > 0
+}
+
+// restrictCachePolicy restricts policy by the CacheHint of a field, or by its lack of one.
+func restrictCachePolicy(policy *CachePolicy, fieldDef *FieldDefinition, path *ResponsePath) {
+	if fieldDef.CacheHint != nil {
+		policy.Restrict(*fieldDef.CacheHint)
+		return
+	}
+	if _, isLeaf := GetNamed(fieldDef.Type).(Leaf); !isLeaf || path.Prev == nil {
+		policy.Restrict(CacheHint{})
+	}
+}
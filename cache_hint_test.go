@@ -0,0 +1,75 @@
+package graphql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+)
+
+func TestCachePolicy(t *testing.T) {
+	postType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.Fields{
+			"title": &graphql.Field{Type: graphql.String},
+			"tags":  &graphql.Field{Type: graphql.NewList(graphql.String)},
+			"views": &graphql.Field{
+				Type: graphql.Int,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					graphql.RestrictCacheHint(p.Context, graphql.CacheHint{MaxAge: 10 * time.Second})
+					return 42, nil
+				},
+			},
+			"author": &graphql.Field{Type: graphql.String, CacheHint: &graphql.CacheHint{MaxAge: time.Hour, Private: true}},
+			"related": &graphql.Field{
+				Type: graphql.NewList(graphql.NewObject(graphql.ObjectConfig{
+					Name:   "Related",
+					Fields: graphql.Fields{"title": &graphql.Field{Type: graphql.String}},
+				})),
+			},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"post": &graphql.Field{
+					Type:      postType,
+					CacheHint: &graphql.CacheHint{MaxAge: time.Minute},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return map[string]interface{}{"title": "GraphQL", "related": []interface{}{}}, nil
+					},
+				},
+				"version": &graphql.Field{Type: graphql.String},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatalf("wrong result, unexpected errors: %v", err.Error())
+	}
+
+	tests := []struct {
+		query     string
+		hint      graphql.CacheHint
+		cacheable bool
+	}{
+		{`{ post { title tags } }`, graphql.CacheHint{MaxAge: time.Minute}, true},
+		{`{ post { title views } }`, graphql.CacheHint{MaxAge: 10 * time.Second}, true},
+		{`{ post { author } }`, graphql.CacheHint{MaxAge: time.Minute, Private: true}, true},
+		// root fields and objects without a hint can't be cached
+		{`{ post { title } version }`, graphql.CacheHint{}, false},
+		{`{ post { related { title } } }`, graphql.CacheHint{}, false},
+	}
+	for _, test := range tests {
+		ctx, policy := graphql.WithCachePolicy(context.Background())
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: test.query, Context: ctx})
+		if len(result.Errors) > 0 {
+			t.Fatalf("wrong result, unexpected errors: %v", result.Errors)
+		}
+		hint, cacheable := policy.Hint()
+		if cacheable != test.cacheable || (cacheable && hint != test.hint) {
+			t.Errorf("expected the hint %+v (%v) for %s, got %+v (%v)", test.hint, test.cacheable, test.query, hint, cacheable)
+		}
+	}
+}
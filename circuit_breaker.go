@@ -0,0 +1,186 @@
+package graphql
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets the resolvers be called, counting their errors.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails the fields, or gives them their fallback value, without calling the
+	// resolvers.
+	CircuitOpen
+	// CircuitHalfOpen lets a few calls try if the resolvers work again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// ErrCircuitOpen is the error of the fields whose circuit is open, when it has no fallback.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerConfig configures a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// Threshold is the rate of errors, between 0 and 1, above which the circuit opens.
+	Threshold float64
+
+	// MinRequests is the number of calls in a window below which the circuit doesn't open
+	// whatever the rate of errors, 1 if zero.
+	MinRequests int
+
+	// Window is how long the calls are counted for, before the counts start over. When it is zero
+	// the calls are counted since the circuit closed.
+	Window time.Duration
+
+	// OpenTimeout is how long the circuit stays open before being half-open.
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is the number of calls let through at the same time while the circuit is
+	// half-open, 1 if zero. The circuit closes when one of them succeeds and opens again when one
+	// of them fails.
+	HalfOpenRequests int
+
+	// Fallback, when set, resolves the fields while the circuit is open, e.g. to a cached or a
+	// default value, instead of failing them with ErrCircuitOpen.
+	Fallback FieldResolveFn
+}
+
+// CircuitBreaker stops calling the resolvers of a flaky upstream once too many of them fail,
+// failing their fields at once, or degrading them to a fallback value, until the upstream works
+// again. Its Middleware is added to the fields backed by the upstream with
+// Schema.InstrumentField, for a field or all the fields of a type:
+//
+//	breaker := graphql.NewCircuitBreaker(graphql.CircuitBreakerConfig{
+//		Threshold:   0.5,
+//		MinRequests: 20,
+//		Window:      10 * time.Second,
+//		OpenTimeout: 30 * time.Second,
+//	})
+//	schema.InstrumentField("Query", "recommendations", breaker.Middleware)
+//
+// The fields instrumented with the same CircuitBreaker share its state.
+type CircuitBreaker struct {
+	config CircuitBreakerConfig
+
+	mutex         sync.Mutex
+	state         CircuitState
+	windowStart   time.Time
+	calls         int
+	failures      int
+	openedAt      time.Time
+	halfOpenCalls int
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker.
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.MinRequests < 1 {
+		config.MinRequests = 1
+	}
+	if config.HalfOpenRequests < 1 {
+		config.HalfOpenRequests = 1
+	}
+	return &CircuitBreaker{config: config, windowStart: time.Now()}
+}
+
+// State returns the current state of the circuit.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.halfOpenIfTimedOut(time.Now())
+	return cb.state
+}
+
+// Middleware is the FieldMiddleware calling next through the circuit breaker.
+func (cb *CircuitBreaker) Middleware(next FieldResolveFn) FieldResolveFn {
+	return func(p ResolveParams) (interface{}, error) {
+		state, allowed := cb.allow()
+		if !allowed {
+			if cb.config.Fallback != nil {
+				return cb.config.Fallback(p)
+			}
+			return nil, ErrCircuitOpen
+		}
+		value, err := next(p)
+		cb.record(state, err == nil)
+		return value, err
+	}
+}
+
+// allow tells if a call may go through, and in which state of the circuit.
+func (cb *CircuitBreaker) allow() (CircuitState, bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.halfOpenIfTimedOut(time.Now())
+	switch cb.state {
+	case CircuitOpen:
+		return cb.state, false
+	case CircuitHalfOpen:
+		if cb.halfOpenCalls >= cb.config.HalfOpenRequests {
+			return cb.state, false
+		}
+		cb.halfOpenCalls++
+	}
+	return cb.state, true
+}
+
+// record counts the outcome of a call made in the given state of the circuit.
+func (cb *CircuitBreaker) record(state CircuitState, success bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	now := time.Now()
+	if state == CircuitHalfOpen {
+		if cb.state != CircuitHalfOpen {
+			return
+		}
+		if success {
+			cb.close(now)
+		} else {
+			cb.open(now)
+		}
+		return
+	}
+	if cb.state != CircuitClosed {
+		return
+	}
+
+	if cb.config.Window > 0 && now.Sub(cb.windowStart) >= cb.config.Window {
+		cb.windowStart, cb.calls, cb.failures = now, 0, 0
+	}
+	cb.calls++
+	if !success {
+		cb.failures++
+	}
+	if cb.calls >= cb.config.MinRequests && float64(cb.failures)/float64(cb.calls) > cb.config.Threshold {
+		cb.open(now)
+	}
+}
+
+func (cb *CircuitBreaker) open(now time.Time) {
+	cb.state = CircuitOpen
+	cb.openedAt = now
+}
+
+func (cb *CircuitBreaker) close(now time.Time) {
+	cb.state = CircuitClosed
+	cb.windowStart, cb.calls, cb.failures = now, 0, 0
+}
+
+func (cb *CircuitBreaker) halfOpenIfTimedOut(now time.Time) {
+	if cb.state == CircuitOpen && now.Sub(cb.openedAt) >= cb.config.OpenTimeout {
+		cb.state = CircuitHalfOpen
+		cb.halfOpenCalls = 0
+	}
+}
@@ -0,0 +1,95 @@
+package graphql_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	healthy := false
+	calls := 0
+	upstream := func(p graphql.ResolveParams) (interface{}, error) {
+		calls++
+		if !healthy {
+			return nil, errors.New("upstream unavailable")
+		}
+		return "fresh", nil
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"recommendations": &graphql.Field{Type: graphql.String, Resolve: upstream},
+				"trending":        &graphql.Field{Type: graphql.String, Resolve: upstream},
+				"local": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return "local", nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatalf("wrong result, unexpected errors: %v", err.Error())
+	}
+	breaker := graphql.NewCircuitBreaker(graphql.CircuitBreakerConfig{
+		Threshold:   0.5,
+		MinRequests: 2,
+		OpenTimeout: 50 * time.Millisecond,
+		Fallback: func(p graphql.ResolveParams) (interface{}, error) {
+			return "fallback", nil
+		},
+	})
+	for _, field := range []string{"recommendations", "trending"} {
+		if err := schema.InstrumentField("Query", field, breaker.Middleware); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	query := func(expected map[string]interface{}, expectedErrors int) {
+		t.Helper()
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ recommendations trending local }`})
+		if !reflect.DeepEqual(result.Data, expected) || len(result.Errors) != expectedErrors {
+			t.Fatalf("expected %v with %d errors, got %+v", expected, expectedErrors, result)
+		}
+	}
+
+	// the failures open the circuit, shared by the instrumented fields
+	query(map[string]interface{}{"recommendations": nil, "trending": nil, "local": "local"}, 2)
+	if breaker.State() != graphql.CircuitOpen {
+		t.Fatalf("expected the circuit to be open, got %v", breaker.State())
+	}
+	query(map[string]interface{}{"recommendations": "fallback", "trending": "fallback", "local": "local"}, 0)
+	if calls != 2 {
+		t.Fatalf("expected the upstream not to be called while the circuit is open, got %d calls", calls)
+	}
+
+	// once half-open, a successful call closes it
+	time.Sleep(60 * time.Millisecond)
+	if breaker.State() != graphql.CircuitHalfOpen {
+		t.Fatalf("expected the circuit to be half-open, got %v", breaker.State())
+	}
+	healthy = true
+	query(map[string]interface{}{"recommendations": "fresh", "trending": "fresh", "local": "local"}, 0)
+	if breaker.State() != graphql.CircuitClosed {
+		t.Fatalf("expected the circuit to be closed, got %v", breaker.State())
+	}
+}
+
+func TestCircuitBreaker_FailsWithoutFallback(t *testing.T) {
+	breaker := graphql.NewCircuitBreaker(graphql.CircuitBreakerConfig{OpenTimeout: time.Minute})
+	resolve := breaker.Middleware(func(p graphql.ResolveParams) (interface{}, error) {
+		return nil, errors.New("upstream unavailable")
+	})
+	if _, err := resolve(graphql.ResolveParams{}); err == nil || err == graphql.ErrCircuitOpen {
+		t.Fatalf("expected the error of the upstream, got %v", err)
+	}
+	if _, err := resolve(graphql.ResolveParams{}); err != graphql.ErrCircuitOpen {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+// Package client is a client for GraphQL servers: it sends queries and mutations over HTTP, with
+// support for file uploads and persisted queries, and subscriptions over websockets, speaking both
+// the graphql-transport-ws and the legacy graphql-ws protocols.
+//
+// It is useful to test schemas served by the handler package and to delegate parts of a schema to
+// another server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/fiatjaf/graphql/gqlerrors"
+)
+
+// Request is a GraphQL request.
+type Request struct {
+	Query         string                 `json:"query,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+
+	// Files are uploaded along with the request, following the GraphQL multipart request spec.
+	// They are keyed by the path of the variable they are the value of, e.g. "variables.file" or
+	// "variables.files.0", that variable should be set to nil.
+	Files map[string]Upload `json:"-"`
+}
+
+// Upload is a file sent with a request.
+type Upload struct {
+	Filename    string
+	ContentType string
+	Content     io.Reader
+}
+
+// Response is the response of a GraphQL server.
+type Response struct {
+	Data       json.RawMessage            `json:"data"`
+	Errors     []gqlerrors.FormattedError `json:"errors,omitempty"`
+	Extensions map[string]interface{}     `json:"extensions,omitempty"`
+}
+
+// Errors are the errors of a response, returned by Client.Query and Client.Mutate when there are
+// any. The data received along with them is still decoded.
+type Errors []gqlerrors.FormattedError
+
+func (errs Errors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Client sends requests to a GraphQL server over HTTP.
+type Client struct {
+	// URL is the endpoint of the server.
+	URL string
+
+	// HTTPClient is used to send the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	// Header is added to every request, e.g. for the "Authorization" header.
+	Header http.Header
+
+	// PersistedQueries makes the client send the hash of the queries instead of the queries
+	// themselves, following the automatic persisted queries protocol: the query is only sent if
+	// the server doesn't know its hash yet. The requests uploading files send it right away, as
+	// their files can only be read once.
+	PersistedQueries bool
+}
+
+// New returns a client for the server at url.
+func New(url string) *Client {
+	return &Client{URL: url, Header: http.Header{}}
+}
+
+// Do sends req and returns the response of the server. An error is returned if the request
+// couldn't be sent or the response decoded, the GraphQL errors are in the response.
+func (c *Client) Do(ctx context.Context, req *Request) (*Response, error) {
+	if !c.PersistedQueries || req.Query == "" {
+		return c.send(ctx, req)
+	}
+
+	hash := sha256.Sum256([]byte(req.Query))
+	persisted := *req
+	persisted.Query = ""
+	persisted.Extensions = map[string]interface{}{}
+	for k, v := range req.Extensions {
+		persisted.Extensions[k] = v
+	}
+	persisted.Extensions["persistedQuery"] = map[string]interface{}{
+		"version":    1,
+		"sha256Hash": hex.EncodeToString(hash[:]),
+	}
+
+	if len(req.Files) > 0 {
+		// the files would be consumed by a request the server may refuse
+		persisted.Query = req.Query
+		return c.send(ctx, &persisted)
+	}
+
+	resp, err := c.send(ctx, &persisted)
+	if err != nil || !persistedQueryNotFound(resp) {
+		return resp, err
+	}
+
+	// the server doesn't know the query yet, send it along with its hash so it is stored
+	persisted.Query = req.Query
+	return c.send(ctx, &persisted)
+}
+
+func persistedQueryNotFound(resp *Response) bool {
+	for _, err := range resp.Errors {
+		if err.Message == "PersistedQueryNotFound" || err.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) send(ctx context.Context, req *Request) (*Response, error) {
+	body, contentType, err := encodeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, c.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq = httpReq.WithContext(ctx)
+	for key, values := range c.Header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.Header.Set("Accept", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	b, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var resp Response
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response with status %d: %w", httpResp.StatusCode, err)
+	}
+	return &resp, nil
+}
+
+// encodeRequest encodes req as JSON, or as a multipart form when it has files.
+func encodeRequest(req *Request) (io.Reader, string, error) {
+	operations, err := json.Marshal(req)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(req.Files) == 0 {
+		return bytes.NewReader(operations), "application/json", nil
+	}
+
+	paths := make([]string, 0, len(req.Files))
+	for path := range req.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	fileMap := make(map[string][]string, len(paths))
+	for i, path := range paths {
+		fileMap[fmt.Sprint(i)] = []string{path}
+	}
+	mapJSON, err := json.Marshal(fileMap)
+	if err != nil {
+		return nil, "", err
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := writer.WriteField("operations", string(operations)); err != nil {
+		return nil, "", err
+	}
+	if err := writer.WriteField("map", string(mapJSON)); err != nil {
+		return nil, "", err
+	}
+	for i, path := range paths {
+		file := req.Files[path]
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%d"; filename="%s"`, i, file.Filename))
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := io.Copy(part, file.Content); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body, writer.FormDataContentType(), nil
+}
+
+// Query sends a query and decodes its data into out, which is typically a pointer to a struct
+// with fields tagged like the JSON of the response. The errors of the response are returned as
+// Errors, after decoding the data received along with them.
+func (c *Client) Query(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	return c.Run(ctx, &Request{Query: query, Variables: variables}, out)
+}
+
+// Mutate is Query for mutations.
+func (c *Client) Mutate(ctx context.Context, mutation string, variables map[string]interface{}, out interface{}) error {
+	return c.Run(ctx, &Request{Query: mutation, Variables: variables}, out)
+}
+
+// Run sends req and decodes the data of its response into out, like Query.
+func (c *Client) Run(ctx context.Context, req *Request, out interface{}) error {
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	return resp.Decode(out)
+}
+
+// Decode decodes the data of the response into out and returns the errors of the response, if
+// any, as Errors.
+func (resp *Response) Decode(out interface{}) error {
+	if out != nil && len(resp.Data) != 0 && string(resp.Data) != "null" {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			return err
+		}
+	}
+	if len(resp.Errors) != 0 {
+		return Errors(resp.Errors)
+	}
+	return nil
+}
+
+// Do sends req with c and returns the data of the response decoded into a T, along with the
+// errors of the response as Errors.
+func Do[T any](ctx context.Context, c *Client, req *Request) (T, error) {
+	var data T
+	err := c.Run(ctx, req, &data)
+	return data, err
+}
@@ -0,0 +1,263 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/client"
+	"github.com/fiatjaf/graphql/handler"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+func TestClient_DecodesData(t *testing.T) {
+	server := httptest.NewServer(handler.New(&handler.Config{Schema: &testutil.StarWarsSchema}))
+	defer server.Close()
+	c := client.New(server.URL)
+
+	var data struct {
+		Hero struct {
+			Name string `json:"name"`
+		} `json:"hero"`
+	}
+	err := c.Query(context.Background(), `query ($episode: Episode) { hero(episode: $episode) { name } }`,
+		map[string]interface{}{"episode": "EMPIRE"}, &data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.Hero.Name != "Luke Skywalker" {
+		t.Fatalf("expected Luke Skywalker, got %q", data.Hero.Name)
+	}
+
+	human, err := client.Do[map[string]map[string]string](context.Background(), c, &client.Request{
+		Query: `{ human(id: "1002") { name } }`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if human["human"]["name"] != "Han Solo" {
+		t.Fatalf("expected Han Solo, got %v", human)
+	}
+}
+
+func TestClient_ReturnsErrors(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"ok": &graphql.Field{
+					Type: graphql.Boolean,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return true, nil
+					},
+				},
+				"fail": &graphql.Field{
+					Type: graphql.Boolean,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return nil, errors.New("failed")
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler.New(&handler.Config{Schema: &schema}))
+	defer server.Close()
+
+	var data map[string]interface{}
+	err = client.New(server.URL).Query(context.Background(), `{ ok fail }`, nil, &data)
+	var errs client.Errors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expected an error, got %v", err)
+	}
+	if errs[0].Message != "failed" || !reflect.DeepEqual(errs[0].Path, []interface{}{"fail"}) {
+		t.Fatalf("unexpected error: %v", errs[0])
+	}
+	expected := map[string]interface{}{"ok": true, "fail": nil}
+	if !reflect.DeepEqual(data, expected) {
+		t.Fatalf("expected the partial data to be decoded, got %v", data)
+	}
+}
+
+func TestClient_PersistedQueries(t *testing.T) {
+	stored := map[string]string{}
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+
+		hash := body["extensions"].(map[string]interface{})["persistedQuery"].(map[string]interface{})["sha256Hash"].(string)
+		if query, ok := body["query"].(string); ok {
+			stored[hash] = query
+		}
+		if _, ok := stored[hash]; !ok {
+			w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer server.Close()
+	c := client.New(server.URL)
+	c.PersistedQueries = true
+
+	for i := 0; i < 2; i++ {
+		var data struct{ OK bool }
+		if err := c.Query(context.Background(), `{ ok }`, nil, &data); err != nil || !data.OK {
+			t.Fatalf("unexpected result: %v, %v", data, err)
+		}
+	}
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(requests))
+	}
+	for i, withQuery := range []bool{false, true, false} {
+		if _, ok := requests[i]["query"]; ok != withQuery {
+			t.Fatalf("expected request %d to have the query: %v, got %v", i, withQuery, requests[i])
+		}
+	}
+}
+
+func TestClient_UploadsFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("expected a multipart request: %v", err)
+			return
+		}
+		if operations := r.FormValue("operations"); !strings.Contains(operations, `"file":null`) ||
+			!strings.Contains(operations, `upload(file: $file)`) {
+			t.Errorf("unexpected operations: %s", operations)
+		}
+		if fileMap := r.FormValue("map"); fileMap != `{"0":["variables.file"]}` {
+			t.Errorf("unexpected map: %s", fileMap)
+		}
+		file, header, err := r.FormFile("0")
+		if err != nil {
+			t.Errorf("expected a file: %v", err)
+			return
+		}
+		content, _ := ioutil.ReadAll(file)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"upload": header.Filename + ":" + string(content)},
+		})
+	}))
+	defer server.Close()
+
+	// the query is sent with the files even when the persisted queries are used, they can't be
+	// sent again
+	persisted := client.New(server.URL)
+	persisted.PersistedQueries = true
+	for _, c := range []*client.Client{client.New(server.URL), persisted} {
+		data, err := client.Do[map[string]string](context.Background(), c, &client.Request{
+			Query:     `mutation ($file: Upload!) { upload(file: $file) }`,
+			Variables: map[string]interface{}{"file": nil},
+			Files: map[string]client.Upload{
+				"variables.file": {Filename: "a.txt", Content: strings.NewReader("hello")},
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if data["upload"] != "a.txt:hello" {
+			t.Fatalf("unexpected result: %v", data)
+		}
+	}
+}
+
+func countdownSchema(t *testing.T) graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"countdown": &graphql.Field{
+					Type: graphql.Int,
+					Args: graphql.FieldConfigArgument{
+						"from": &graphql.ArgumentConfig{Type: graphql.Int},
+					},
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						c := make(chan interface{})
+						go func() {
+							defer close(c)
+							for i := p.Args["from"].(int); i >= 0; i-- {
+								select {
+								case <-p.Context.Done():
+									return
+								case c <- i:
+								}
+							}
+						}()
+						return c, nil
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestWebSocketClient_Subscribe(t *testing.T) {
+	schema := countdownSchema(t)
+	server := httptest.NewServer(handler.New(&handler.Config{Schema: &schema, WebSocket: true}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	for _, subprotocol := range []string{handler.SubprotocolGraphQLTransportWS, handler.SubprotocolGraphQLWS} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		c, err := client.DialWebSocket(ctx, url, client.WebSocketOptions{Subprotocols: []string{subprotocol}})
+		if err != nil {
+			t.Fatalf("failed to dial with %s: %v", subprotocol, err)
+		}
+		defer c.Close()
+
+		responses, err := c.Subscribe(ctx, &client.Request{
+			Query:     `subscription ($from: Int) { countdown(from: $from) }`,
+			Variables: map[string]interface{}{"from": 2},
+		})
+		if err != nil {
+			t.Fatalf("failed to subscribe with %s: %v", subprotocol, err)
+		}
+		var counts []int
+		for resp := range responses {
+			var data struct{ Countdown int }
+			if err := resp.Decode(&data); err != nil {
+				t.Fatalf("unexpected error with %s: %v", subprotocol, err)
+			}
+			counts = append(counts, data.Countdown)
+		}
+		if !reflect.DeepEqual(counts, []int{2, 1, 0}) {
+			t.Fatalf("unexpected responses with %s: %v", subprotocol, counts)
+		}
+
+		responses, err = c.Subscribe(ctx, &client.Request{Query: `subscription { unknown }`})
+		if err != nil {
+			t.Fatalf("failed to subscribe with %s: %v", subprotocol, err)
+		}
+		resp := <-responses
+		if len(resp.Errors) != 1 {
+			t.Fatalf("expected an error with %s, got %v", subprotocol, resp)
+		}
+		if _, more := <-responses; more {
+			t.Fatalf("expected the subscription to end after an error with %s", subprotocol)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/handler"
+	"github.com/gorilla/websocket"
+)
+
+// ErrConnectionClosed is sent with the last response of the subscriptions running when their
+// websocket connection is closed.
+var ErrConnectionClosed = errors.New("websocket connection closed")
+
+// WebSocketOptions configures a websocket connection.
+type WebSocketOptions struct {
+	// Header is sent with the handshake request.
+	Header http.Header
+
+	// Subprotocols are offered to the server, in order of preference. Both
+	// handler.SubprotocolGraphQLTransportWS and handler.SubprotocolGraphQLWS are offered if empty.
+	Subprotocols []string
+
+	// InitPayload is sent with the connection_init message, e.g. to authenticate.
+	InitPayload map[string]interface{}
+
+	// NetDialContext, when set, opens the network connection instead of a net.Dialer, e.g. to
+	// connect to an in-memory server as graphqltest.Server does.
+	NetDialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// WebSocketClient runs subscriptions over a websocket connection, with either the
+// graphql-transport-ws or the legacy graphql-ws protocol as negotiated with the server.
+type WebSocketClient struct {
+	conn   *websocket.Conn
+	legacy bool
+
+	writeMutex sync.Mutex
+
+	mutex         sync.Mutex
+	nextID        int
+	subscriptions map[string]*subscription
+	closed        bool
+}
+
+type subscription struct {
+	ctx       context.Context
+	responses chan *Response
+
+	// mutex prevents the channel from being closed while a response is sent to it
+	mutex  sync.Mutex
+	closed bool
+}
+
+// send sends resp to the subscription, unless it is stopped meanwhile.
+func (sub *subscription) send(resp *Response) {
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	if sub.closed {
+		return
+	}
+	select {
+	case sub.responses <- resp:
+	case <-sub.ctx.Done():
+	}
+}
+
+func (sub *subscription) close() {
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	sub.closed = true
+	close(sub.responses)
+}
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// DialWebSocket connects to the server at url (with the ws or wss scheme), initializes the
+// connection and waits for the server to acknowledge it. An error is returned if the server
+// refuses the connection.
+func DialWebSocket(ctx context.Context, url string, opts WebSocketOptions) (*WebSocketClient, error) {
+	subprotocols := opts.Subprotocols
+	if len(subprotocols) == 0 {
+		subprotocols = []string{handler.SubprotocolGraphQLTransportWS, handler.SubprotocolGraphQLWS}
+	}
+	dialer := websocket.Dialer{Subprotocols: subprotocols, NetDialContext: opts.NetDialContext}
+	conn, _, err := dialer.DialContext(ctx, url, opts.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &WebSocketClient{
+		conn:          conn,
+		legacy:        conn.Subprotocol() == handler.SubprotocolGraphQLWS,
+		subscriptions: map[string]*subscription{},
+	}
+	if err := c.init(opts.InitPayload); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go c.readMessages()
+	return c, nil
+}
+
+// init sends the connection_init message and waits for its acknowledgement.
+func (c *WebSocketClient) init(payload map[string]interface{}) error {
+	msg := wsMessage{Type: "connection_init"}
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		msg.Payload = b
+	}
+	if err := c.write(msg); err != nil {
+		return err
+	}
+
+	for {
+		var msg wsMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				return fmt.Errorf("connection refused: %s", closeErr.Text)
+			}
+			return err
+		}
+		switch msg.Type {
+		case "connection_ack":
+			return nil
+		case "connection_error", "error":
+			if errs := decodeErrors(msg.Payload); len(errs) != 0 {
+				return fmt.Errorf("connection refused: %w", errs)
+			}
+			return errors.New("connection refused")
+		}
+	}
+}
+
+// Subscribe starts the subscription, or other operation, of req. Its responses are sent to the
+// returned channel, which is closed once the server completes the operation or ctx is done. The
+// subscription is stopped on the server when ctx is done.
+func (c *WebSocketClient) Subscribe(ctx context.Context, req *Request) (<-chan *Response, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return nil, ErrConnectionClosed
+	}
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	sub := &subscription{ctx: ctx, responses: make(chan *Response)}
+	c.subscriptions[id] = sub
+	c.mutex.Unlock()
+
+	msgType := "subscribe"
+	if c.legacy {
+		msgType = "start"
+	}
+	if err := c.write(wsMessage{ID: id, Type: msgType, Payload: payload}); err != nil {
+		c.remove(id)
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		if c.remove(id) {
+			msgType := "complete"
+			if c.legacy {
+				msgType = "stop"
+			}
+			c.write(wsMessage{ID: id, Type: msgType})
+		}
+	}()
+	return sub.responses, nil
+}
+
+// Close closes the connection, which completes all its subscriptions.
+func (c *WebSocketClient) Close() error {
+	c.writeMutex.Lock()
+	c.conn.WriteMessage(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	c.writeMutex.Unlock()
+	return c.conn.Close()
+}
+
+func (c *WebSocketClient) write(msg wsMessage) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	return c.conn.WriteJSON(msg)
+}
+
+// remove forgets the subscription with the given id and closes its channel. It returns false if
+// the subscription was already removed.
+func (c *WebSocketClient) remove(id string) bool {
+	c.mutex.Lock()
+	sub, ok := c.subscriptions[id]
+	delete(c.subscriptions, id)
+	c.mutex.Unlock()
+	if ok {
+		sub.close()
+	}
+	return ok
+}
+
+// send sends resp to the subscription with the given id, unless it is stopped meanwhile.
+func (c *WebSocketClient) send(id string, resp *Response) {
+	c.mutex.Lock()
+	sub, ok := c.subscriptions[id]
+	c.mutex.Unlock()
+	if ok {
+		sub.send(resp)
+	}
+}
+
+func (c *WebSocketClient) readMessages() {
+	defer func() {
+		c.mutex.Lock()
+		c.closed = true
+		subscriptions := c.subscriptions
+		c.subscriptions = map[string]*subscription{}
+		c.mutex.Unlock()
+		for _, sub := range subscriptions {
+			sub.send(&Response{Errors: []gqlerrors.FormattedError{{Message: ErrConnectionClosed.Error()}}})
+			sub.close()
+		}
+	}()
+
+	for {
+		var msg wsMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case "next", "data":
+			var resp Response
+			if err := json.Unmarshal(msg.Payload, &resp); err != nil {
+				resp = Response{Errors: []gqlerrors.FormattedError{{Message: err.Error()}}}
+			}
+			c.send(msg.ID, &resp)
+		case "error":
+			c.send(msg.ID, &Response{Errors: decodeErrors(msg.Payload)})
+			c.remove(msg.ID)
+		case "complete":
+			c.remove(msg.ID)
+		case "ping":
+			c.write(wsMessage{Type: "pong"})
+		}
+	}
+}
+
+// decodeErrors decodes the payload of an error message, which is a list of errors with the
+// graphql-transport-ws protocol and a single error with the legacy one.
+func decodeErrors(payload json.RawMessage) Errors {
+	payload = bytes.TrimSpace(payload)
+	if len(payload) == 0 || string(payload) == "null" {
+		return nil
+	}
+	var errs Errors
+	if payload[0] == '[' {
+		if err := json.Unmarshal(payload, &errs); err != nil {
+			return Errors{{Message: err.Error()}}
+		}
+		return errs
+	}
+	var formatted gqlerrors.FormattedError
+	if err := json.Unmarshal(payload, &formatted); err != nil {
+		return Errors{{Message: err.Error()}}
+	}
+	return Errors{formatted}
+}
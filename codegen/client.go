@@ -0,0 +1,429 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/parser"
+	"github.com/fiatjaf/graphql/language/printer"
+	"github.com/fiatjaf/graphql/language/source"
+	"github.com/fiatjaf/graphql/mock"
+)
+
+// GenerateClient returns the formatted Go code of the operations of the document operations, sent
+// to a server whose schema has the SDL sdl. For each operation, named after it, it generates the
+// struct of its variables, the structs of the data of its response, the constant of its document
+// and a function sending it with a client.Client, or running it with a client.WebSocketClient for
+// the subscriptions. The enums and input objects used by the operations are generated too.
+//
+// The fields selected by fragments on narrower types than the type of their selection set are
+// optional, as are the nullable fields: they are pointers, slices or interface{} values. The
+// values of the custom scalars are interface{} values, as decoded from JSON.
+func GenerateClient(sdl, operations string, config Config) ([]byte, error) {
+	schema, err := mock.NewSchema(sdl, mock.Config{})
+	if err != nil {
+		return nil, err
+	}
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(operations), Name: config.Source}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result := graphql.ValidateDocument(&schema, document, nil); !result.IsValid {
+		return nil, result.Errors[0]
+	}
+	if config.Package == "" {
+		return nil, fmt.Errorf("no package name for the generated code")
+	}
+	g := &clientGenerator{
+		config:    config,
+		schema:    schema,
+		fragments: map[string]*ast.FragmentDefinition{},
+		enums:     map[string]*graphql.Enum{},
+		inputs:    map[string]*graphql.InputObject{},
+	}
+	var definitions []*ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch def := definition.(type) {
+		case *ast.OperationDefinition:
+			if def.Name == nil {
+				return nil, fmt.Errorf("anonymous operations can't be generated, they must be named")
+			}
+			definitions = append(definitions, def)
+		case *ast.FragmentDefinition:
+			g.fragments[def.Name.Value] = def
+		}
+	}
+	if len(definitions) == 0 {
+		return nil, fmt.Errorf("no operations in the document")
+	}
+	for _, def := range definitions {
+		g.operation(def)
+	}
+	code, err := format.Source(g.generate())
+	if err != nil {
+		return nil, fmt.Errorf("formatting the generated code: %v", err)
+	}
+	return code, nil
+}
+
+type clientGenerator struct {
+	config    Config
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+
+	// buf holds the code of the operations, generated before the enums and input objects they
+	// use, by type name
+	buf    bytes.Buffer
+	enums  map[string]*graphql.Enum
+	inputs map[string]*graphql.InputObject
+}
+
+func (g *clientGenerator) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+func (g *clientGenerator) generate() []byte {
+	operations := g.buf.Bytes()
+	g.buf = bytes.Buffer{}
+	header := "// Code generated by graphql-codegen. DO NOT EDIT."
+	if g.config.Source != "" {
+		header = fmt.Sprintf("// Code generated by graphql-codegen from %s. DO NOT EDIT.", g.config.Source)
+	}
+	g.p("%s\n\npackage %s\n", header, g.config.Package)
+	g.p("import (")
+	g.p("%q\n", "context")
+	g.p("%q", "github.com/fiatjaf/graphql/client")
+	g.p(")\n")
+
+	for _, name := range sortedKeys(g.enums) {
+		enum := g.enums[name]
+		g.description(enum.Description())
+		g.p("type %s string\n", goName(name))
+		values := enum.Values()
+		sort.Slice(values, func(i, j int) bool { return values[i].Name < values[j].Name })
+		g.p("const (")
+		for _, value := range values {
+			g.description(value.Description)
+			g.p("%s %s = %q", enumConstant(name, value.Name), goName(name), value.Name)
+		}
+		g.p(")\n")
+	}
+	for _, name := range sortedKeys(g.inputs) {
+		input := g.inputs[name]
+		fields := input.Fields()
+		g.description(input.Description())
+		g.p("type %s struct {", goName(name))
+		for _, fieldName := range sortedKeys(fields) {
+			field := fields[fieldName]
+			tag := fieldName
+			if _, ok := field.Type.(*graphql.NonNull); !ok {
+				tag += ",omitempty"
+			}
+			g.description(field.Description())
+			g.p("%s %s `json:%q`", goName(fieldName), g.goType(field.Type, "", false), tag)
+		}
+		g.p("}\n")
+	}
+	g.buf.Write(operations)
+	return g.buf.Bytes()
+}
+
+func (g *clientGenerator) description(description string) {
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(description, "\n") {
+		g.p("// %s", line)
+	}
+}
+
+func (g *clientGenerator) operation(def *ast.OperationDefinition) {
+	name := goName(def.Name.Value)
+	var root *graphql.Object
+	switch def.Operation {
+	case ast.OperationTypeMutation:
+		root = g.schema.MutationType()
+	case ast.OperationTypeSubscription:
+		root = g.schema.SubscriptionType()
+	default:
+		root = g.schema.QueryType()
+	}
+
+	if len(def.VariableDefinitions) > 0 {
+		g.p("// %sVariables are the variables of the %s %s.", name, def.Operation, def.Name.Value)
+		g.p("type %sVariables struct {", name)
+		for _, variable := range def.VariableDefinitions {
+			g.p("%s %s `json:%q`", goName(variable.Variable.Name.Value), g.goType(g.typeFromAST(variable.Type), "", false), variable.Variable.Name.Value)
+		}
+		g.p("}\n")
+	}
+	g.p("// %sResponse is the data of the response of the %s %s.", name, def.Operation, def.Name.Value)
+	g.selectionStructs(name+"Response", root, def.SelectionSet.Selections)
+
+	g.p("// %sDocument is the document of the %s %s.", name, def.Operation, def.Name.Value)
+	g.p("const %sDocument = %s\n", name, quote(g.document(def)))
+
+	params := "ctx context.Context, c *client.Client"
+	if def.Operation == ast.OperationTypeSubscription {
+		params = "ctx context.Context, c *client.WebSocketClient"
+	}
+	if len(def.VariableDefinitions) > 0 {
+		params += fmt.Sprintf(", variables %sVariables", name)
+	}
+	request := func() {
+		if len(def.VariableDefinitions) == 0 {
+			g.p("req := &client.Request{Query: %sDocument, OperationName: %q}", name, def.Name.Value)
+			return
+		}
+		g.p("req := &client.Request{Query: %sDocument, OperationName: %q, Variables: map[string]interface{}{}}", name, def.Name.Value)
+		for _, variable := range def.VariableDefinitions {
+			variableName := variable.Variable.Name.Value
+			// the null variables are left out so the default values apply
+			if _, ok := variable.Type.(*ast.NonNull); ok {
+				g.p("req.Variables[%q] = variables.%s", variableName, goName(variableName))
+				continue
+			}
+			g.p("if variables.%s != nil {", goName(variableName))
+			g.p("req.Variables[%q] = variables.%s", variableName, goName(variableName))
+			g.p("}")
+		}
+	}
+
+	if def.Operation != ast.OperationTypeSubscription {
+		g.p("// %s sends the %s %s with c. The data received along with the errors of the", name, def.Operation, def.Name.Value)
+		g.p("// response, returned as client.Errors, is returned too.")
+		g.p("func %s(%s) (*%sResponse, error) {", name, params, name)
+		request()
+		g.p("data := &%sResponse{}", name)
+		g.p("err := c.Run(ctx, req, data)")
+		g.p("return data, err")
+		g.p("}\n")
+		return
+	}
+
+	g.p("// %sEvent is an event of the subscription %s: its data, and the errors of its", name, def.Name.Value)
+	g.p("// response as client.Errors.")
+	g.p("type %sEvent struct {", name)
+	g.p("Data *%sResponse", name)
+	g.p("Err error")
+	g.p("}\n")
+	g.p("// %s runs the subscription %s with c. Its events are sent to the returned channel,", name, def.Name.Value)
+	g.p("// which is closed once the server completes the subscription or ctx is done.")
+	g.p("func %s(%s) (<-chan %sEvent, error) {", name, params, name)
+	request()
+	g.p("responses, err := c.Subscribe(ctx, req)")
+	g.p("if err != nil {")
+	g.p("return nil, err")
+	g.p("}")
+	g.p("events := make(chan %sEvent)", name)
+	g.p("go func() {")
+	g.p("defer close(events)")
+	g.p("for resp := range responses {")
+	g.p("event := %sEvent{Data: &%sResponse{}}", name, name)
+	g.p("event.Err = resp.Decode(event.Data)")
+	g.p("select {")
+	g.p("case events <- event:")
+	g.p("case <-ctx.Done():")
+	g.p("return")
+	g.p("}")
+	g.p("}")
+	g.p("}()")
+	g.p("return events, nil")
+	g.p("}\n")
+}
+
+// selectedField is a field selected in a selection set, along with the selections of all its
+// occurrences.
+type selectedField struct {
+	name       string
+	definition *graphql.FieldDefinition
+	selections []ast.Selection
+
+	// optional tells if the field is only selected by fragments on narrower types
+	optional bool
+}
+
+// collectFields collects the fields selected by selections on parent, in the order they're
+// selected.
+func (g *clientGenerator) collectFields(parent graphql.Type, selections []ast.Selection, optional bool, fields *[]*selectedField) {
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			name := selection.Name.Value
+			if selection.Alias != nil {
+				name = selection.Alias.Value
+			}
+			var subselections []ast.Selection
+			if selection.SelectionSet != nil {
+				subselections = selection.SelectionSet.Selections
+			}
+			var field *selectedField
+			for _, collected := range *fields {
+				if collected.name == name {
+					field = collected
+				}
+			}
+			if field == nil {
+				field = &selectedField{name: name, definition: fieldDefinition(parent, selection.Name.Value), optional: optional}
+				*fields = append(*fields, field)
+			}
+			field.optional = field.optional && optional
+			field.selections = append(field.selections, subselections...)
+		case *ast.InlineFragment:
+			condition := parent
+			if selection.TypeCondition != nil {
+				condition = g.schema.Type(selection.TypeCondition.Name.Value)
+			}
+			g.collectFields(condition, selection.SelectionSet.Selections, optional || narrower(parent, condition), fields)
+		case *ast.FragmentSpread:
+			fragment := g.fragments[selection.Name.Value]
+			condition := g.schema.Type(fragment.TypeCondition.Name.Value)
+			g.collectFields(condition, fragment.SelectionSet.Selections, optional || narrower(parent, condition), fields)
+		}
+	}
+}
+
+// narrower tells if the fragments on condition only apply to some of the values of parent.
+func narrower(parent, condition graphql.Type) bool {
+	_, ok := parent.(*graphql.Object)
+	return !ok && condition.Name() != parent.Name()
+}
+
+func fieldDefinition(parent graphql.Type, name string) *graphql.FieldDefinition {
+	if name == "__typename" {
+		return &graphql.FieldDefinition{Name: name, Type: graphql.NewNonNull(graphql.String)}
+	}
+	switch parent := parent.(type) {
+	case *graphql.Object:
+		return parent.Fields()[name]
+	case *graphql.Interface:
+		return parent.Fields()[name]
+	}
+	return nil
+}
+
+// selectionStructs generates the struct named name of the fields selected by selections on
+// parent, followed by the structs of its fields of composite types.
+func (g *clientGenerator) selectionStructs(name string, parent graphql.Type, selections []ast.Selection) {
+	var fields []*selectedField
+	g.collectFields(parent, selections, false, &fields)
+	g.p("type %s struct {", name)
+	for _, field := range fields {
+		g.p("%s %s `json:%q`", goName(field.name), g.goType(field.definition.Type, name+goName(field.name), field.optional), field.name)
+	}
+	g.p("}\n")
+	for _, field := range fields {
+		if len(field.selections) > 0 {
+			g.selectionStructs(name+goName(field.name), namedOf(field.definition.Type), field.selections)
+		}
+	}
+}
+
+// goType returns the Go type of the values of type t, structName naming the struct of the
+// composite types.
+func (g *clientGenerator) goType(t graphql.Type, structName string, optional bool) string {
+	pointer := "*"
+	if nonNull, ok := t.(*graphql.NonNull); ok {
+		t = nonNull.OfType
+		if !optional {
+			pointer = ""
+		}
+	}
+	switch t := t.(type) {
+	case *graphql.List:
+		return "[]" + g.goType(t.OfType, structName, false)
+	case *graphql.Scalar:
+		if goType := builtinScalars[t.Name()]; goType != "" {
+			return pointer + goType
+		}
+		return "interface{}"
+	case *graphql.Enum:
+		g.enums[t.Name()] = t
+		return pointer + goName(t.Name())
+	case *graphql.InputObject:
+		if _, ok := g.inputs[t.Name()]; !ok {
+			g.inputs[t.Name()] = t
+			for _, field := range t.Fields() {
+				g.goType(field.Type, "", false)
+			}
+		}
+		return pointer + goName(t.Name())
+	}
+	return "*" + structName
+}
+
+func namedOf(t graphql.Type) graphql.Type {
+	switch t := t.(type) {
+	case *graphql.NonNull:
+		return namedOf(t.OfType)
+	case *graphql.List:
+		return namedOf(t.OfType)
+	}
+	return t
+}
+
+func (g *clientGenerator) typeFromAST(t ast.Type) graphql.Type {
+	switch t := t.(type) {
+	case *ast.NonNull:
+		return graphql.NewNonNull(g.typeFromAST(t.Type))
+	case *ast.List:
+		return graphql.NewList(g.typeFromAST(t.Type))
+	}
+	return g.schema.Type(namedType(t))
+}
+
+// document returns the document of the operation def, with the fragments it uses.
+func (g *clientGenerator) document(def *ast.OperationDefinition) string {
+	definitions := []string{printer.Print(def).(string)}
+	used := map[string]bool{}
+	var spread func(selectionSet *ast.SelectionSet)
+	spread = func(selectionSet *ast.SelectionSet) {
+		if selectionSet == nil {
+			return
+		}
+		for _, selection := range selectionSet.Selections {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				spread(selection.SelectionSet)
+			case *ast.InlineFragment:
+				spread(selection.SelectionSet)
+			case *ast.FragmentSpread:
+				if used[selection.Name.Value] {
+					continue
+				}
+				used[selection.Name.Value] = true
+				fragment := g.fragments[selection.Name.Value]
+				definitions = append(definitions, printer.Print(fragment).(string))
+				spread(fragment.SelectionSet)
+			}
+		}
+	}
+	spread(def.SelectionSet)
+	return strings.Join(definitions, "\n\n")
+}
+
+// quote returns the Go literal of s, a raw string literal unless it has backquotes.
+func quote(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,58 @@
+// Command graphql-codegen generates the Go types and resolver interfaces of the SDL of a schema,
+// see package codegen. It is meant to be run by go generate:
+//
+//	//go:generate go run github.com/fiatjaf/graphql/codegen/cmd/graphql-codegen -schema schema.graphql -out schema_gen.go
+//
+// The package of the generated code is the one being generated unless -package is given. With
+// -operations, the client code of the operations of a file is generated instead, see
+// codegen.GenerateClient:
+//
+//	//go:generate go run github.com/fiatjaf/graphql/codegen/cmd/graphql-codegen -schema schema.graphql -operations operations.graphql -out operations_gen.go
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/fiatjaf/graphql/codegen"
+)
+
+func main() {
+	schema := flag.String("schema", "schema.graphql", "the SDL file of the schema")
+	out := flag.String("out", "schema_gen.go", "the file written with the generated code")
+	operations := flag.String("operations", "", "the file of the operations to generate client code for")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "the package of the generated code")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("graphql-codegen: ")
+
+	sdl, err := ioutil.ReadFile(*schema)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var code []byte
+	if *operations == "" {
+		code, err = codegen.Generate(string(sdl), codegen.Config{
+			Package: *pkg,
+			Source:  filepath.Base(*schema),
+		})
+	} else {
+		var document []byte
+		if document, err = ioutil.ReadFile(*operations); err != nil {
+			log.Fatal(err)
+		}
+		code, err = codegen.GenerateClient(string(sdl), string(document), codegen.Config{
+			Package: *pkg,
+			Source:  filepath.Base(*operations),
+		})
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
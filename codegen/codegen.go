@@ -0,0 +1,998 @@
+// Package codegen generates Go code from the SDL of a schema: a model struct for every object
+// type, an input struct for every input object, constants for the values of the enums, a Go
+// interface for every interface and union, and resolver interfaces for the fields that can't be
+// read from the models. The generated NewSchema builds the executable schema from an
+// implementation of the resolvers, converting the arguments to their Go types, so the resolvers
+// neither type-assert p.Args nor return interface{} values:
+//
+//	//go:generate go run github.com/fiatjaf/graphql/codegen/cmd/graphql-codegen -schema schema.graphql -out schema_gen.go -package api
+//
+// The fields of the root types and the fields with arguments are resolved by the resolvers, the
+// other fields are read from the models. The values of the object types are pointers to their
+// models, the nullable scalars, enums and input objects are pointers, and the values of the
+// custom scalars are serialized as they are. The fields with @cost or @listSize directives get
+// the Complexity of their graphql.Cost.
+//
+// GenerateClient generates the other side: typed functions sending the operations of a document
+// with the client package, to call a GraphQL server from Go.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/parser"
+	"github.com/fiatjaf/graphql/language/source"
+)
+
+// Config configures the generated code.
+type Config struct {
+	// Package is the name of the package of the generated code.
+	Package string
+
+	// Source names the SDL in the header of the generated code, e.g. its file name.
+	Source string
+}
+
+// Generate returns the formatted Go code generated from sdl, the type definitions of a schema in
+// the GraphQL schema definition language. The root types are the ones given by its schema
+// definition, or else the types named Query, Mutation and Subscription. Directive definitions are
+// ignored.
+func Generate(sdl string, config Config) ([]byte, error) {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(sdl), Name: config.Source}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if config.Package == "" {
+		return nil, fmt.Errorf("no package name for the generated code")
+	}
+	g := &generator{
+		config: config,
+		rootTypes: map[string]string{
+			ast.OperationTypeQuery:        "Query",
+			ast.OperationTypeMutation:     "Mutation",
+			ast.OperationTypeSubscription: "Subscription",
+		},
+		definitions:   map[string]ast.Node{},
+		abstractTypes: map[string][]string{},
+		possibleTypes: map[string][]string{},
+		inputs:        map[string]ast.Type{},
+	}
+	if err := g.collect(document); err != nil {
+		return nil, err
+	}
+	g.generate()
+	code, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting the generated code: %v", err)
+	}
+	return code, nil
+}
+
+type generator struct {
+	config Config
+	buf    bytes.Buffer
+
+	// rootTypes are the names of the root types by operation
+	rootTypes map[string]string
+
+	// the definitions by type name, and in the order of the SDL by kind
+	definitions  map[string]ast.Node
+	scalars      []*ast.ScalarDefinition
+	enums        []*ast.EnumDefinition
+	inputObjects []*ast.InputObjectDefinition
+	interfaces   []*ast.InterfaceDefinition
+	objects      []*ast.ObjectDefinition
+	unions       []*ast.UnionDefinition
+
+	// abstractTypes are the names of the interfaces and unions of each object type, and
+	// possibleTypes the names of the object types of each interface and union
+	abstractTypes map[string][]string
+	possibleTypes map[string][]string
+
+	// inputs are the types of the arguments and input fields converted by a generated function,
+	// by the name of the function
+	inputs map[string]ast.Type
+}
+
+func (g *generator) collect(document *ast.Document) error {
+	var extensions []*ast.ObjectDefinition
+	for _, definition := range document.Definitions {
+		var name *ast.Name
+		switch def := definition.(type) {
+		case *ast.SchemaDefinition:
+			for _, operationType := range def.OperationTypes {
+				g.rootTypes[operationType.Operation] = operationType.Type.Name.Value
+			}
+			continue
+		case *ast.TypeExtensionDefinition:
+			extensions = append(extensions, def.Definition)
+			continue
+		case *ast.ScalarDefinition:
+			name = def.Name
+			g.scalars = append(g.scalars, def)
+		case *ast.EnumDefinition:
+			name = def.Name
+			g.enums = append(g.enums, def)
+		case *ast.InputObjectDefinition:
+			name = def.Name
+			g.inputObjects = append(g.inputObjects, def)
+		case *ast.InterfaceDefinition:
+			name = def.Name
+			g.interfaces = append(g.interfaces, def)
+		case *ast.ObjectDefinition:
+			name = def.Name
+			g.objects = append(g.objects, def)
+			for _, iface := range def.Interfaces {
+				g.abstractTypes[def.Name.Value] = append(g.abstractTypes[def.Name.Value], iface.Name.Value)
+				g.possibleTypes[iface.Name.Value] = append(g.possibleTypes[iface.Name.Value], def.Name.Value)
+			}
+		case *ast.UnionDefinition:
+			name = def.Name
+			g.unions = append(g.unions, def)
+			for _, member := range def.Types {
+				g.abstractTypes[member.Name.Value] = append(g.abstractTypes[member.Name.Value], def.Name.Value)
+				g.possibleTypes[def.Name.Value] = append(g.possibleTypes[def.Name.Value], member.Name.Value)
+			}
+		default:
+			continue
+		}
+		if _, ok := g.definitions[name.Value]; ok || builtinScalars[name.Value] != "" {
+			return fmt.Errorf("duplicate type %s", name.Value)
+		}
+		g.definitions[name.Value] = definition
+	}
+	for _, extension := range extensions {
+		object, ok := g.definitions[extension.Name.Value].(*ast.ObjectDefinition)
+		if !ok {
+			return fmt.Errorf("extension of unknown object type %s", extension.Name.Value)
+		}
+		object.Fields = append(object.Fields, extension.Fields...)
+	}
+	if _, ok := g.definitions[g.rootTypes[ast.OperationTypeQuery]].(*ast.ObjectDefinition); !ok {
+		return fmt.Errorf("no query type %q in the SDL", g.rootTypes[ast.OperationTypeQuery])
+	}
+	for _, def := range g.objects {
+		for _, field := range def.Fields {
+			if err := g.checkTypes(def.Name.Value+"."+field.Name.Value, field.Type, field.Arguments); err != nil {
+				return err
+			}
+		}
+	}
+	for _, def := range g.interfaces {
+		for _, field := range def.Fields {
+			if err := g.checkTypes(def.Name.Value+"."+field.Name.Value, field.Type, field.Arguments); err != nil {
+				return err
+			}
+		}
+	}
+	for _, def := range g.inputObjects {
+		if err := g.checkTypes(def.Name.Value, nil, def.Fields); err != nil {
+			return err
+		}
+	}
+	for _, def := range g.unions {
+		for _, member := range def.Types {
+			if _, ok := g.definitions[member.Name.Value].(*ast.ObjectDefinition); !ok {
+				return fmt.Errorf("member %s of the union %s isn't an object type", member.Name.Value, def.Name.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// checkTypes returns an error when the type of the field named coordinate, or the type of one of
+// its arguments, isn't defined.
+func (g *generator) checkTypes(coordinate string, t ast.Type, args []*ast.InputValueDefinition) error {
+	if t != nil {
+		if name := namedType(t); builtinScalars[name] == "" && g.definitions[name] == nil {
+			return fmt.Errorf("unknown type %s of %s", name, coordinate)
+		}
+	}
+	for _, arg := range args {
+		if name := namedType(arg.Type); builtinScalars[name] == "" && g.definitions[name] == nil {
+			return fmt.Errorf("unknown type %s of %s.%s", name, coordinate, arg.Name.Value)
+		}
+	}
+	return nil
+}
+
+// builtinScalars are the Go types of the built-in scalars.
+var builtinScalars = map[string]string{
+	"Int":     "int",
+	"Float":   "float64",
+	"String":  "string",
+	"Boolean": "bool",
+	"ID":      "string",
+}
+
+func (g *generator) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+func (g *generator) generate() {
+	header := "// Code generated by graphql-codegen. DO NOT EDIT."
+	if g.config.Source != "" {
+		header = fmt.Sprintf("// Code generated by graphql-codegen from %s. DO NOT EDIT.", g.config.Source)
+	}
+	g.p("%s\n\npackage %s\n", header, g.config.Package)
+	g.p("import (")
+	g.p("%q", "context")
+	if len(g.scalars) > 0 {
+		g.p("%q", "strconv")
+	}
+	g.p("\n%q", "github.com/fiatjaf/graphql")
+	if len(g.scalars) > 0 {
+		g.p("%q", "github.com/fiatjaf/graphql/language/ast")
+	}
+	g.p(")\n")
+
+	for _, def := range g.enums {
+		g.enum(def)
+	}
+	for _, def := range g.inputObjects {
+		g.inputObject(def)
+	}
+	for _, def := range g.interfaces {
+		g.abstract(def.Name.Value, def.Description)
+	}
+	for _, def := range g.unions {
+		g.abstract(def.Name.Value, def.Description)
+	}
+	for _, def := range g.objects {
+		if !g.isRoot(def.Name.Value) {
+			g.model(def)
+		}
+	}
+	for _, def := range g.objects {
+		g.resolver(def)
+	}
+	g.resolvers()
+	g.newSchema()
+	g.inputFuncs()
+	if len(g.scalars) > 0 {
+		g.parseLiteral()
+	}
+}
+
+func (g *generator) isRoot(typeName string) bool {
+	for _, name := range g.rootTypes {
+		if name == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) enum(def *ast.EnumDefinition) {
+	name := goName(def.Name.Value)
+	g.comment(def.Description)
+	g.p("type %s string\n", name)
+	g.p("const (")
+	for _, value := range def.Values {
+		g.comment(value.Description)
+		g.p("%s %s = %q", enumConstant(def.Name.Value, value.Name.Value), name, value.Name.Value)
+	}
+	g.p(")\n")
+}
+
+func (g *generator) inputObject(def *ast.InputObjectDefinition) {
+	g.comment(def.Description)
+	g.p("type %s struct {", goName(def.Name.Value))
+	for _, field := range def.Fields {
+		g.comment(field.Description)
+		g.p("%s %s `json:%q`", goName(field.Name.Value), g.inputType(field.Type), field.Name.Value)
+	}
+	g.p("}\n")
+}
+
+// abstract generates the Go interface of an interface or union, implemented by the models of its
+// object types.
+func (g *generator) abstract(name string, description *ast.StringValue) {
+	g.comment(description)
+	g.p("type %s interface {", goName(name))
+	g.p("Is%s()", goName(name))
+	g.p("}\n")
+}
+
+func (g *generator) model(def *ast.ObjectDefinition) {
+	name := goName(def.Name.Value)
+	g.comment(def.Description)
+	g.p("type %s struct {", name)
+	for _, field := range def.Fields {
+		if len(field.Arguments) > 0 {
+			continue
+		}
+		g.comment(field.Description)
+		g.p("%s %s `json:%q`", goName(field.Name.Value), g.outputType(field.Type), field.Name.Value)
+	}
+	g.p("}\n")
+	for _, abstract := range g.abstractTypes[def.Name.Value] {
+		g.p("func (*%s) Is%s() {}\n", name, goName(abstract))
+	}
+}
+
+// resolvedFields returns the fields of an object type resolved by its resolver.
+func (g *generator) resolvedFields(def *ast.ObjectDefinition) []*ast.FieldDefinition {
+	if g.isRoot(def.Name.Value) {
+		return def.Fields
+	}
+	var fields []*ast.FieldDefinition
+	for _, field := range def.Fields {
+		if len(field.Arguments) > 0 {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func (g *generator) resolver(def *ast.ObjectDefinition) {
+	fields := g.resolvedFields(def)
+	if len(fields) == 0 {
+		return
+	}
+	typeName := def.Name.Value
+	for _, field := range fields {
+		if len(field.Arguments) == 0 {
+			continue
+		}
+		g.p("// %s are the arguments of %s.%s.", argsType(typeName, field), typeName, field.Name.Value)
+		g.p("type %s struct {", argsType(typeName, field))
+		for _, arg := range field.Arguments {
+			g.comment(arg.Description)
+			g.p("%s %s `json:%q`", goName(arg.Name.Value), g.inputType(arg.Type), arg.Name.Value)
+		}
+		g.p("}\n")
+	}
+	g.p("// %sResolver resolves the fields of %s.", goName(typeName), typeName)
+	g.p("type %sResolver interface {", goName(typeName))
+	for _, field := range fields {
+		g.comment(field.Description)
+		params := "ctx context.Context"
+		if !g.isRoot(typeName) {
+			params += ", obj *" + goName(typeName)
+		}
+		if len(field.Arguments) > 0 {
+			params += ", args " + argsType(typeName, field)
+		}
+		result := g.outputType(field.Type)
+		if typeName == g.rootTypes[ast.OperationTypeSubscription] {
+			result = "<-chan " + result
+		}
+		g.p("%s(%s) (%s, error)", goName(field.Name.Value), params, result)
+	}
+	g.p("}\n")
+}
+
+func (g *generator) resolvers() {
+	g.p("// Resolvers resolves the fields of the schema that aren't read from the models.")
+	g.p("type Resolvers interface {")
+	for _, def := range g.objects {
+		if len(g.resolvedFields(def)) > 0 {
+			g.p("%s() %sResolver", goName(def.Name.Value), goName(def.Name.Value))
+		}
+	}
+	g.p("}\n")
+}
+
+func (g *generator) newSchema() {
+	g.p("// NewSchema builds the executable schema resolved by resolvers.")
+	g.p("func NewSchema(resolvers Resolvers) (graphql.Schema, error) {")
+	g.p("var (")
+	for _, def := range g.scalars {
+		g.p("%s *graphql.Scalar", typeVar(def.Name.Value))
+	}
+	for _, def := range g.enums {
+		g.p("%s *graphql.Enum", typeVar(def.Name.Value))
+	}
+	for _, def := range g.inputObjects {
+		g.p("%s *graphql.InputObject", typeVar(def.Name.Value))
+	}
+	for _, def := range g.interfaces {
+		g.p("%s *graphql.Interface", typeVar(def.Name.Value))
+	}
+	for _, def := range g.objects {
+		g.p("%s *graphql.Object", typeVar(def.Name.Value))
+	}
+	for _, def := range g.unions {
+		g.p("%s *graphql.Union", typeVar(def.Name.Value))
+	}
+	g.p(")")
+
+	// the types are created in an order letting each of them refer to the ones it needs, while
+	// fields are defined by thunks so types can refer to each other
+	for _, def := range g.scalars {
+		g.p("%s = graphql.NewScalar(graphql.ScalarConfig{", typeVar(def.Name.Value))
+		g.p("Name: %q,", def.Name.Value)
+		g.description(def.Description)
+		g.p("Serialize: func(value interface{}) interface{} { return value },")
+		g.p("ParseValue: func(value interface{}) interface{} { return value },")
+		g.p("ParseLiteral: parseLiteral,")
+		g.p("})")
+	}
+	for _, def := range g.enums {
+		g.p("%s = graphql.NewEnum(graphql.EnumConfig{", typeVar(def.Name.Value))
+		g.p("Name: %q,", def.Name.Value)
+		g.description(def.Description)
+		g.p("Values: graphql.EnumValueConfigMap{")
+		for _, value := range def.Values {
+			g.p("%q: &graphql.EnumValueConfig{", value.Name.Value)
+			g.p("Value: %s,", enumConstant(def.Name.Value, value.Name.Value))
+			g.description(value.Description)
+			g.deprecationReason(value.Directives)
+			g.p("},")
+		}
+		g.p("},")
+		g.p("})")
+	}
+	for _, def := range g.inputObjects {
+		g.p("%s = graphql.NewInputObject(graphql.InputObjectConfig{", typeVar(def.Name.Value))
+		g.p("Name: %q,", def.Name.Value)
+		g.description(def.Description)
+		g.p("Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap {")
+		g.p("return graphql.InputObjectConfigFieldMap{")
+		for _, field := range def.Fields {
+			g.p("%q: &graphql.InputObjectFieldConfig{", field.Name.Value)
+			g.p("Type: %s,", g.typeRef(field.Type))
+			g.defaultValue(field)
+			g.description(field.Description)
+			g.p("},")
+		}
+		g.p("}")
+		g.p("}),")
+		g.p("})")
+	}
+	for _, def := range g.interfaces {
+		g.p("%s = graphql.NewInterface(graphql.InterfaceConfig{", typeVar(def.Name.Value))
+		g.p("Name: %q,", def.Name.Value)
+		g.description(def.Description)
+		g.p("Fields: graphql.FieldsThunk(func() graphql.Fields {")
+		g.p("return graphql.Fields{")
+		for _, field := range def.Fields {
+			g.field(def.Name.Value, field, false)
+		}
+		g.p("}")
+		g.p("}),")
+		g.resolveType(def.Name.Value)
+		g.p("})")
+	}
+	for _, def := range g.objects {
+		g.p("%s = graphql.NewObject(graphql.ObjectConfig{", typeVar(def.Name.Value))
+		g.p("Name: %q,", def.Name.Value)
+		g.description(def.Description)
+		if len(def.Interfaces) > 0 {
+			interfaces := make([]string, len(def.Interfaces))
+			for i, iface := range def.Interfaces {
+				interfaces[i] = typeVar(iface.Name.Value)
+			}
+			g.p("Interfaces: graphql.InterfacesThunk(func() []*graphql.Interface {")
+			g.p("return []*graphql.Interface{%s}", strings.Join(interfaces, ", "))
+			g.p("}),")
+		}
+		g.p("Fields: graphql.FieldsThunk(func() graphql.Fields {")
+		g.p("return graphql.Fields{")
+		for _, field := range def.Fields {
+			g.field(def.Name.Value, field, true)
+		}
+		g.p("}")
+		g.p("}),")
+		g.p("})")
+	}
+	for _, def := range g.unions {
+		members := make([]string, len(def.Types))
+		for i, member := range def.Types {
+			members[i] = typeVar(member.Name.Value)
+		}
+		g.p("%s = graphql.NewUnion(graphql.UnionConfig{", typeVar(def.Name.Value))
+		g.p("Name: %q,", def.Name.Value)
+		g.description(def.Description)
+		g.p("Types: []*graphql.Object{%s},", strings.Join(members, ", "))
+		g.resolveType(def.Name.Value)
+		g.p("})")
+	}
+
+	g.p("return graphql.NewSchema(graphql.SchemaConfig{")
+	for _, operation := range []string{ast.OperationTypeQuery, ast.OperationTypeMutation, ast.OperationTypeSubscription} {
+		if _, ok := g.definitions[g.rootTypes[operation]].(*ast.ObjectDefinition); ok {
+			g.p("%s: %s,", goName(operation), typeVar(g.rootTypes[operation]))
+		}
+	}
+	var types []string
+	for _, def := range g.scalars {
+		types = append(types, typeVar(def.Name.Value))
+	}
+	for _, def := range g.enums {
+		types = append(types, typeVar(def.Name.Value))
+	}
+	for _, def := range g.inputObjects {
+		types = append(types, typeVar(def.Name.Value))
+	}
+	for _, def := range g.interfaces {
+		types = append(types, typeVar(def.Name.Value))
+	}
+	for _, def := range g.objects {
+		if !g.isRoot(def.Name.Value) {
+			types = append(types, typeVar(def.Name.Value))
+		}
+	}
+	for _, def := range g.unions {
+		types = append(types, typeVar(def.Name.Value))
+	}
+	if len(types) > 0 {
+		g.p("Types: []graphql.Type{%s},", strings.Join(types, ", "))
+	}
+	g.p("})")
+	g.p("}\n")
+}
+
+// field generates the definition of a field of the type typeName, resolved when it is a field of
+// an object type.
+func (g *generator) field(typeName string, field *ast.FieldDefinition, resolved bool) {
+	g.p("%q: &graphql.Field{", field.Name.Value)
+	g.p("Type: %s,", g.typeRef(field.Type))
+	if len(field.Arguments) > 0 {
+		g.p("Args: graphql.FieldConfigArgument{")
+		for _, arg := range field.Arguments {
+			g.p("%q: &graphql.ArgumentConfig{", arg.Name.Value)
+			g.p("Type: %s,", g.typeRef(arg.Type))
+			g.defaultValue(arg)
+			g.description(arg.Description)
+			g.p("},")
+		}
+		g.p("},")
+	}
+	g.description(field.Description)
+	g.deprecationReason(field.Directives)
+	g.complexity(field.Directives)
+	if !resolved {
+		g.p("},")
+		return
+	}
+
+	if !g.isRoot(typeName) && len(field.Arguments) == 0 {
+		g.p("Resolve: func(p graphql.ResolveParams) (interface{}, error) {")
+		g.p("return p.Source.(*%s).%s, nil", goName(typeName), goName(field.Name.Value))
+		g.p("},")
+		g.p("},")
+		return
+	}
+	args := []string{"p.Context"}
+	if !g.isRoot(typeName) {
+		args = append(args, fmt.Sprintf("p.Source.(*%s)", goName(typeName)))
+	}
+	if len(field.Arguments) > 0 {
+		values := make([]string, len(field.Arguments))
+		for i, arg := range field.Arguments {
+			values[i] = fmt.Sprintf("%s: %s(p.Args[%q]),", goName(arg.Name.Value), g.inputFunc(arg.Type), arg.Name.Value)
+		}
+		args = append(args, fmt.Sprintf("%s{\n%s\n}", argsType(typeName, field), strings.Join(values, "\n")))
+	}
+	call := fmt.Sprintf("resolvers.%s().%s(%s)", goName(typeName), goName(field.Name.Value), strings.Join(args, ", "))
+	if typeName != g.rootTypes[ast.OperationTypeSubscription] {
+		g.p("Resolve: func(p graphql.ResolveParams) (interface{}, error) {")
+		g.p("return %s", call)
+		g.p("},")
+		g.p("},")
+		return
+	}
+
+	// the events of a subscription are the root values of its executions
+	g.p("Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {")
+	g.p("events, err := %s", call)
+	g.p("if err != nil {")
+	g.p("return nil, err")
+	g.p("}")
+	g.p("forwarded := make(chan interface{})")
+	g.p("go func() {")
+	g.p("defer close(forwarded)")
+	g.p("for event := range events {")
+	g.p("select {")
+	g.p("case forwarded <- event:")
+	g.p("case <-p.Context.Done():")
+	g.p("return")
+	g.p("}")
+	g.p("}")
+	g.p("}()")
+	g.p("return forwarded, nil")
+	g.p("},")
+	g.p("Resolve: func(p graphql.ResolveParams) (interface{}, error) {")
+	g.p("return p.Source, nil")
+	g.p("},")
+	g.p("},")
+}
+
+// resolveType generates the ResolveType of an interface or union, telling the object type of a
+// value by the type of its model.
+func (g *generator) resolveType(typeName string) {
+	g.p("ResolveType: func(p graphql.ResolveTypeParams) *graphql.Object {")
+	g.p("switch p.Value.(type) {")
+	for _, object := range g.possibleTypes[typeName] {
+		g.p("case *%s:", goName(object))
+		g.p("return %s", typeVar(object))
+	}
+	g.p("}")
+	g.p("return nil")
+	g.p("},")
+}
+
+func (g *generator) comment(description *ast.StringValue) {
+	if description == nil || description.Value == "" {
+		return
+	}
+	for _, line := range strings.Split(description.Value, "\n") {
+		g.p("// %s", line)
+	}
+}
+
+func (g *generator) description(description *ast.StringValue) {
+	if description != nil && description.Value != "" {
+		g.p("Description: %q,", description.Value)
+	}
+}
+
+func (g *generator) deprecationReason(directives []*ast.Directive) {
+	for _, directive := range directives {
+		if directive.Name == nil || directive.Name.Value != graphql.DeprecatedDirective.Name {
+			continue
+		}
+		reason := graphql.DefaultDeprecationReason
+		for _, arg := range directive.Arguments {
+			if value, ok := arg.Value.(*ast.StringValue); ok && arg.Name != nil && arg.Name.Value == "reason" {
+				reason = value.Value
+			}
+		}
+		g.p("DeprecationReason: %q,", reason)
+	}
+}
+
+// complexity generates the Complexity of a field declared by its @cost and @listSize directives.
+func (g *generator) complexity(directives []*ast.Directive) {
+	cost, ok := graphql.CostFromDirectives(directives)
+	if !ok {
+		return
+	}
+	g.p("Complexity: graphql.Cost{")
+	g.p("Complexity: %d,", cost.Complexity)
+	if len(cost.Multipliers) > 0 {
+		g.p("Multipliers: %#v,", cost.Multipliers)
+	}
+	if len(cost.SlicingArguments) > 0 {
+		g.p("SlicingArguments: %#v,", cost.SlicingArguments)
+	}
+	if cost.AssumedSize != 0 {
+		g.p("AssumedSize: %d,", cost.AssumedSize)
+	}
+	g.p("}.ComplexityFn(),")
+}
+
+func (g *generator) defaultValue(def *ast.InputValueDefinition) {
+	if def.DefaultValue != nil {
+		g.p("DefaultValue: %s,", g.goValue(def.DefaultValue, def.Type))
+	}
+}
+
+// goValue returns the Go expression of the value of the literal value of type t, as the
+// arguments of type t are given to the resolvers.
+func (g *generator) goValue(value ast.Value, t ast.Type) string {
+	if _, ok := value.(*ast.NullValue); ok {
+		return "nil"
+	}
+	switch t := t.(type) {
+	case *ast.NonNull:
+		return g.goValue(value, t.Type)
+	case *ast.List:
+		list, ok := value.(*ast.ListValue)
+		if !ok {
+			return fmt.Sprintf("[]interface{}{%s}", g.goValue(value, t.Type))
+		}
+		values := make([]string, len(list.Values))
+		for i, item := range list.Values {
+			values[i] = g.goValue(item, t.Type)
+		}
+		return fmt.Sprintf("[]interface{}{%s}", strings.Join(values, ", "))
+	}
+	name := namedType(t)
+	switch def := g.definitions[name].(type) {
+	case *ast.EnumDefinition:
+		if value, ok := value.(*ast.EnumValue); ok {
+			return enumConstant(name, value.Value)
+		}
+	case *ast.InputObjectDefinition:
+		object, ok := value.(*ast.ObjectValue)
+		if !ok {
+			break
+		}
+		fieldTypes := map[string]ast.Type{}
+		for _, field := range def.Fields {
+			fieldTypes[field.Name.Value] = field.Type
+		}
+		fields := make([]string, 0, len(object.Fields))
+		for _, field := range object.Fields {
+			if fieldType, ok := fieldTypes[field.Name.Value]; ok {
+				fields = append(fields, fmt.Sprintf("%q: %s", field.Name.Value, g.goValue(field.Value, fieldType)))
+			}
+		}
+		return fmt.Sprintf("map[string]interface{}{%s}", strings.Join(fields, ", "))
+	}
+	switch value := value.(type) {
+	case *ast.IntValue:
+		if name == "Float" {
+			return fmt.Sprintf("float64(%s)", value.Value)
+		}
+		return value.Value
+	case *ast.FloatValue:
+		return value.Value
+	case *ast.StringValue:
+		return strconv.Quote(value.Value)
+	case *ast.BooleanValue:
+		return strconv.FormatBool(value.Value)
+	case *ast.EnumValue:
+		return strconv.Quote(value.Value)
+	case *ast.ListValue:
+		values := make([]string, len(value.Values))
+		for i, item := range value.Values {
+			values[i] = g.goValue(item, &ast.Named{Name: &ast.Name{Value: name}})
+		}
+		return fmt.Sprintf("[]interface{}{%s}", strings.Join(values, ", "))
+	case *ast.ObjectValue:
+		fields := make([]string, len(value.Fields))
+		for i, field := range value.Fields {
+			fields[i] = fmt.Sprintf("%q: %s", field.Name.Value, g.goValue(field.Value, &ast.Named{Name: &ast.Name{Value: name}}))
+		}
+		return fmt.Sprintf("map[string]interface{}{%s}", strings.Join(fields, ", "))
+	}
+	return "nil"
+}
+
+// typeRef returns the Go expression of the graphql.Type of t.
+func (g *generator) typeRef(t ast.Type) string {
+	switch t := t.(type) {
+	case *ast.NonNull:
+		return fmt.Sprintf("graphql.NewNonNull(%s)", g.typeRef(t.Type))
+	case *ast.List:
+		return fmt.Sprintf("graphql.NewList(%s)", g.typeRef(t.Type))
+	}
+	name := namedType(t)
+	if builtinScalars[name] != "" {
+		return "graphql." + name
+	}
+	return typeVar(name)
+}
+
+// outputType returns the Go type of the values of the fields of type t.
+func (g *generator) outputType(t ast.Type) string {
+	nullable := t
+	if nonNull, ok := t.(*ast.NonNull); ok {
+		nullable = nonNull.Type
+	}
+	if list, ok := nullable.(*ast.List); ok {
+		return "[]" + g.outputType(list.Type)
+	}
+	name := namedType(t)
+	switch g.definitions[name].(type) {
+	case *ast.ObjectDefinition:
+		return "*" + goName(name)
+	case *ast.InterfaceDefinition, *ast.UnionDefinition:
+		return goName(name)
+	}
+	return g.inputType(t)
+}
+
+// inputType returns the Go type of the values of the arguments and input fields of type t.
+func (g *generator) inputType(t ast.Type) string {
+	pointer := "*"
+	if nonNull, ok := t.(*ast.NonNull); ok {
+		pointer = ""
+		t = nonNull.Type
+	}
+	if list, ok := t.(*ast.List); ok {
+		return "[]" + g.inputType(list.Type)
+	}
+	name := namedType(t)
+	if goType := builtinScalars[name]; goType != "" {
+		return pointer + goType
+	}
+	if _, ok := g.definitions[name].(*ast.ScalarDefinition); ok {
+		return "interface{}"
+	}
+	return pointer + goName(name)
+}
+
+// inputFunc returns the name of the generated function converting the values of type t given by
+// graphql to their Go type, generating it along with the ones it calls.
+func (g *generator) inputFunc(t ast.Type) string {
+	if nonNull, ok := t.(*ast.NonNull); ok {
+		if list, ok := nonNull.Type.(*ast.List); ok {
+			// the lists are nil when null
+			t = list
+		}
+	}
+	name := "input" + inputFuncSuffix(t)
+	if _, ok := g.inputs[name]; ok {
+		return name
+	}
+	g.inputs[name] = t
+	switch t := t.(type) {
+	case *ast.NonNull:
+		if def, ok := g.definitions[namedType(t)].(*ast.InputObjectDefinition); ok {
+			for _, field := range def.Fields {
+				g.inputFunc(field.Type)
+			}
+		}
+	case *ast.List:
+		g.inputFunc(t.Type)
+	default:
+		if _, ok := g.definitions[namedType(t)].(*ast.ScalarDefinition); !ok {
+			g.inputFunc(&ast.NonNull{Type: t})
+		}
+	}
+	return name
+}
+
+func inputFuncSuffix(t ast.Type) string {
+	switch t := t.(type) {
+	case *ast.NonNull:
+		return goName(namedType(t))
+	case *ast.List:
+		return "ListOf" + inputFuncSuffix(t.Type)
+	}
+	return "Optional" + goName(namedType(t))
+}
+
+func (g *generator) inputFuncs() {
+	names := make([]string, 0, len(g.inputs))
+	for name := range g.inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := g.inputs[name]
+		goType := g.inputType(t)
+		g.p("func %s(value interface{}) %s {", name, goType)
+		switch t := t.(type) {
+		case *ast.List:
+			g.p("values, ok := value.([]interface{})")
+			g.p("if !ok {")
+			g.p("return nil")
+			g.p("}")
+			g.p("list := make(%s, len(values))", goType)
+			g.p("for i, value := range values {")
+			g.p("list[i] = %s(value)", g.inputFunc(t.Type))
+			g.p("}")
+			g.p("return list")
+		case *ast.NonNull:
+			def, ok := g.definitions[namedType(t)].(*ast.InputObjectDefinition)
+			if !ok {
+				g.p("v, _ := value.(%s)", goType)
+				g.p("return v")
+				break
+			}
+			g.p("object, _ := value.(map[string]interface{})")
+			g.p("return %s{", goType)
+			for _, field := range def.Fields {
+				g.p("%s: %s(object[%q]),", goName(field.Name.Value), g.inputFunc(field.Type), field.Name.Value)
+			}
+			g.p("}")
+		default:
+			if _, ok := g.definitions[namedType(t)].(*ast.ScalarDefinition); ok {
+				g.p("return value")
+				break
+			}
+			g.p("if value == nil {")
+			g.p("return nil")
+			g.p("}")
+			g.p("v := %s(value)", g.inputFunc(&ast.NonNull{Type: t}))
+			g.p("return &v")
+		}
+		g.p("}\n")
+	}
+}
+
+// parseLiteral generates the ParseLiteral of the custom scalars, returning the Go value of a
+// literal.
+func (g *generator) parseLiteral() {
+	g.p(`func parseLiteral(valueAST ast.Value) interface{} {
+	switch value := valueAST.(type) {
+	case *ast.IntValue:
+		if i, err := strconv.Atoi(value.Value); err == nil {
+			return i
+		}
+	case *ast.FloatValue:
+		if f, err := strconv.ParseFloat(value.Value, 64); err == nil {
+			return f
+		}
+	case *ast.StringValue:
+		return value.Value
+	case *ast.BooleanValue:
+		return value.Value
+	case *ast.EnumValue:
+		return value.Value
+	case *ast.ListValue:
+		values := make([]interface{}, len(value.Values))
+		for i, item := range value.Values {
+			values[i] = parseLiteral(item)
+		}
+		return values
+	case *ast.ObjectValue:
+		fields := map[string]interface{}{}
+		for _, field := range value.Fields {
+			fields[field.Name.Value] = parseLiteral(field.Value)
+		}
+		return fields
+	}
+	return nil
+}`)
+}
+
+func namedType(t ast.Type) string {
+	switch t := t.(type) {
+	case *ast.NonNull:
+		return namedType(t.Type)
+	case *ast.List:
+		return namedType(t.Type)
+	case *ast.Named:
+		return t.Name.Value
+	}
+	return ""
+}
+
+func argsType(typeName string, field *ast.FieldDefinition) string {
+	return goName(typeName) + goName(field.Name.Value) + "Args"
+}
+
+func enumConstant(enumName, value string) string {
+	return goName(enumName) + goName(strings.ToLower(value))
+}
+
+// typeVar returns the name of the variable holding the type named name in NewSchema.
+func typeVar(name string) string {
+	return strings.ToLower(name[:1]) + name[1:] + "Type"
+}
+
+// initialisms are the words written in upper case in Go names.
+var initialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "id": true, "json": true, "uri": true, "url": true, "uuid": true,
+}
+
+// goName returns the exported Go name of a GraphQL name, e.g. UserID for user_id or userId.
+func goName(name string) string {
+	var words []string
+	start := 0
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '_':
+			words = append(words, string(runes[start:i]))
+			start = i + 1
+		case i > start && unicode.IsUpper(r) && unicode.IsLower(runes[i-1]):
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	words = append(words, string(runes[start:]))
+	var b strings.Builder
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	if b.Len() == 0 {
+		return "X" + name
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package codegen_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/fiatjaf/graphql/codegen"
+)
+
+func TestGenerate_MatchesTheGeneratedExample(t *testing.T) {
+	sdl, err := ioutil.ReadFile("internal/example/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := ioutil.ReadFile("internal/example/schema_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := codegen.Generate(string(sdl), codegen.Config{Package: "example", Source: "schema.graphql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(code) != string(generated) {
+		t.Fatalf("the generated example is out of date, run go generate in internal/example")
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	tests := map[string]string{
+		"type User { id: ID }":                              `no query type "Query" in the SDL`,
+		"type Query { user: User }":                         "unknown type User of Query.user",
+		"type Query { users(filter: Filter): ID }":          "unknown type Filter of Query.users.filter",
+		"type Query { id: ID } union U = Query | Int":       "member Int of the union U isn't an object type",
+		"type Query { id: ID } type Query { name: String }": "duplicate type Query",
+	}
+	for sdl, expected := range tests {
+		_, err := codegen.Generate(sdl, codegen.Config{Package: "api"})
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected the error %q for %q, got %v", expected, sdl, err)
+		}
+	}
+}
+
+func TestGenerateClient_MatchesTheGeneratedExample(t *testing.T) {
+	sdl, err := ioutil.ReadFile("internal/example/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	operations, err := ioutil.ReadFile("internal/exampleclient/operations.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := ioutil.ReadFile("internal/exampleclient/operations_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := codegen.GenerateClient(string(sdl), string(operations), codegen.Config{Package: "exampleclient", Source: "operations.graphql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(code) != string(generated) {
+		t.Fatalf("the generated example client is out of date, run go generate in internal/exampleclient")
+	}
+}
+
+func TestGenerateClient_Errors(t *testing.T) {
+	sdl := "type Query { name: String }"
+	tests := map[string]string{
+		"{ name }":                     "anonymous operations can't be generated, they must be named",
+		"query Name { nam }":           `Cannot query field "nam" on type "Query". Did you mean "name"?`,
+		"fragment F on Query { name }": `Fragment "F" is never used.`,
+	}
+	for operations, expected := range tests {
+		_, err := codegen.GenerateClient(sdl, operations, codegen.Config{Package: "api"})
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected the error %q for %q, got %v", expected, operations, err)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package example is the code generated from schema.graphql, and resolvers implementing it to
+// test the generated code.
+package example
+
+//go:generate go run github.com/fiatjaf/graphql/codegen/cmd/graphql-codegen -schema schema.graphql -out schema_gen.go
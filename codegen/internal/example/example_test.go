@@ -0,0 +1,112 @@
+package example_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/codegen/internal/example"
+)
+
+func newSchema(t *testing.T) (graphql.Schema, *example.MemoryResolvers) {
+	email := "ada@example.com"
+	r := &example.MemoryResolvers{
+		Users: []*example.User{
+			{ID: "1", Name: "Ada", Email: &email, Role: example.RoleAdmin, CreatedAt: "1815-12-10"},
+			{ID: "2", Name: "Alan", Role: example.RoleReadOnly},
+		},
+		Created: make(chan *example.User, 1),
+	}
+	schema, err := example.NewSchema(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema, r
+}
+
+func TestNewSchema_ResolvesWithTheGeneratedTypes(t *testing.T) {
+	schema, r := newSchema(t)
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		RequestString: `{
+			user(id: "1") { id name email role createdAt friends(first: 1) { name email } }
+			node(id: "g1") { id ... on Group { title members { name } } }
+			search(text: "Ada") { __typename ... on User { name } ... on Group { title } }
+		}`,
+	})
+	if len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+	expected := map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":        "1",
+			"name":      "Ada",
+			"email":     "ada@example.com",
+			"role":      "ADMIN",
+			"createdAt": "1815-12-10",
+			"friends":   []interface{}{map[string]interface{}{"name": "Alan", "email": nil}},
+		},
+		"node": map[string]interface{}{
+			"id":      "g1",
+			"title":   "Admins",
+			"members": []interface{}{map[string]interface{}{"name": "Ada"}},
+		},
+		"search": []interface{}{
+			map[string]interface{}{"__typename": "User", "name": "Alan"},
+			map[string]interface{}{"__typename": "Group", "title": "Ada"},
+		},
+	}
+	if !reflect.DeepEqual(result.Data, expected) {
+		t.Fatalf("unexpected result %v", result.Data)
+	}
+
+	// the default values are converted like the arguments
+	result = graphql.Do(graphql.Params{Schema: schema, RequestString: `{ users { id } }`})
+	if len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+	expectedFilter := &example.UserFilter{Roles: []example.Role{example.RoleAdmin}}
+	if !reflect.DeepEqual(r.Filter, expectedFilter) {
+		t.Fatalf("expected the filter %+v, got %+v", expectedFilter, r.Filter)
+	}
+	result = graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  `query($filter: UserFilter) { users(filter: $filter) { id } }`,
+		VariableValues: map[string]interface{}{"filter": map[string]interface{}{"name": "Ada", "roles": []interface{}{"GUEST"}}},
+	})
+	if len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+	name, minAge := "Ada", 18
+	expectedFilter = &example.UserFilter{Name: &name, Roles: []example.Role{example.RoleGuest}, MinAge: &minAge}
+	if !reflect.DeepEqual(r.Filter, expectedFilter) {
+		t.Fatalf("expected the filter %+v, got %+v", expectedFilter, r.Filter)
+	}
+}
+
+func TestNewSchema_SubscribesToTheResolverChannels(t *testing.T) {
+	schema, _ := newSchema(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := graphql.Subscribe(graphql.Params{
+		Schema:        schema,
+		RequestString: `subscription { userCreated { name role } }`,
+		Context:       ctx,
+	})
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `mutation { createUser(input: {name: "Grace"}) { id role } }`,
+	})
+	expected := map[string]interface{}{"createUser": map[string]interface{}{"id": "3", "role": "READ_ONLY"}}
+	if len(result.Errors) != 0 || !reflect.DeepEqual(result.Data, expected) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	event := <-results
+	expected = map[string]interface{}{"userCreated": map[string]interface{}{"name": "Grace", "role": "READ_ONLY"}}
+	if len(event.Errors) != 0 || !reflect.DeepEqual(event.Data, expected) {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
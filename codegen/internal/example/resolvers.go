@@ -0,0 +1,76 @@
+package example
+
+import (
+	"context"
+	"fmt"
+)
+
+// MemoryResolvers resolves the schema with users held in memory.
+type MemoryResolvers struct {
+	Users []*User
+
+	// Created receives the users created, for the subscriptions
+	Created chan *User
+
+	// Filter is the filter of the last users query
+	Filter *UserFilter
+}
+
+func (r *MemoryResolvers) Query() QueryResolver               { return queryResolver{r} }
+func (r *MemoryResolvers) Mutation() MutationResolver         { return mutationResolver{r} }
+func (r *MemoryResolvers) Subscription() SubscriptionResolver { return subscriptionResolver{r} }
+func (r *MemoryResolvers) User() UserResolver                 { return userResolver{r} }
+
+type queryResolver struct{ *MemoryResolvers }
+
+func (r queryResolver) User(ctx context.Context, args QueryUserArgs) (*User, error) {
+	for _, user := range r.MemoryResolvers.Users {
+		if user.ID == args.ID {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r queryResolver) Node(ctx context.Context, args QueryNodeArgs) (Node, error) {
+	if args.ID == "g1" {
+		return &Group{ID: "g1", Title: "Admins", Members: r.MemoryResolvers.Users[:1]}, nil
+	}
+	return r.User(ctx, QueryUserArgs{ID: args.ID})
+}
+
+func (r queryResolver) Users(ctx context.Context, args QueryUsersArgs) ([]*User, error) {
+	r.MemoryResolvers.Filter = args.Filter
+	return r.MemoryResolvers.Users, nil
+}
+
+func (r queryResolver) Search(ctx context.Context, args QuerySearchArgs) ([]SearchResult, error) {
+	return []SearchResult{r.MemoryResolvers.Users[1], &Group{ID: "g1", Title: args.Text}}, nil
+}
+
+type mutationResolver struct{ *MemoryResolvers }
+
+func (r mutationResolver) CreateUser(ctx context.Context, args MutationCreateUserArgs) (*User, error) {
+	user := &User{ID: fmt.Sprint(len(r.MemoryResolvers.Users) + 1), Name: args.Input.Name, Role: *args.Input.Role}
+	r.MemoryResolvers.Users = append(r.MemoryResolvers.Users, user)
+	r.MemoryResolvers.Created <- user
+	return user, nil
+}
+
+type subscriptionResolver struct{ *MemoryResolvers }
+
+func (r subscriptionResolver) UserCreated(ctx context.Context) (<-chan *User, error) {
+	return r.MemoryResolvers.Created, nil
+}
+
+type userResolver struct{ *MemoryResolvers }
+
+func (r userResolver) Friends(ctx context.Context, obj *User, args UserFriendsArgs) ([]*User, error) {
+	var friends []*User
+	for _, user := range r.MemoryResolvers.Users {
+		if user != obj && len(friends) < *args.First {
+			friends = append(friends, user)
+		}
+	}
+	return friends, nil
+}
@@ -0,0 +1,5 @@
+// Package exampleclient is the client code generated from operations.graphql, sent to the server
+// of the example schema.
+package exampleclient
+
+//go:generate go run github.com/fiatjaf/graphql/codegen/cmd/graphql-codegen -schema ../example/schema.graphql -operations operations.graphql -out operations_gen.go
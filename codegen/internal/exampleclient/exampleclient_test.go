@@ -0,0 +1,104 @@
+package exampleclient_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql/client"
+	"github.com/fiatjaf/graphql/codegen/internal/example"
+	"github.com/fiatjaf/graphql/codegen/internal/exampleclient"
+	"github.com/fiatjaf/graphql/handler"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	email := "ada@example.com"
+	schema, err := example.NewSchema(&example.MemoryResolvers{
+		Users: []*example.User{
+			{ID: "1", Name: "Ada", Email: &email, Role: example.RoleAdmin},
+			{ID: "2", Name: "Alan", Role: example.RoleReadOnly},
+		},
+		Created: make(chan *example.User, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(handler.New(&handler.Config{Schema: &schema, WebSocket: true}))
+}
+
+func TestGeneratedClient_SendsTypedOperations(t *testing.T) {
+	server := newServer(t)
+	defer server.Close()
+	c := client.New(server.URL)
+	ctx := context.Background()
+
+	first := 1
+	user, err := exampleclient.GetUser(ctx, c, exampleclient.GetUserVariables{ID: "1", First: &first})
+	if err != nil {
+		t.Fatal(err)
+	}
+	email := "ada@example.com"
+	expectedUser := &exampleclient.GetUserResponse{
+		User: &exampleclient.GetUserResponseUser{
+			ID:      "1",
+			Name:    "Ada",
+			Email:   &email,
+			Role:    exampleclient.RoleAdmin,
+			Friends: []*exampleclient.GetUserResponseUserFriends{{Name: "Alan"}},
+		},
+	}
+	if !reflect.DeepEqual(user, expectedUser) {
+		t.Fatalf("unexpected response %+v", user.User)
+	}
+
+	search, err := exampleclient.Search(ctx, c, exampleclient.SearchVariables{Text: "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, role, title := "Alan", exampleclient.RoleReadOnly, "Ada"
+	expectedSearch := &exampleclient.SearchResponse{
+		Search: []*exampleclient.SearchResponseSearch{
+			{Typename: "User", Name: &name, Role: &role},
+			{Typename: "Group", Title: &title},
+		},
+		Node: &exampleclient.SearchResponseNode{ID: "g1"},
+	}
+	if !reflect.DeepEqual(search, expectedSearch) {
+		t.Fatalf("unexpected response %+v", search)
+	}
+}
+
+func TestGeneratedClient_RunsTypedSubscriptions(t *testing.T) {
+	server := newServer(t)
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ws, err := client.DialWebSocket(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), client.WebSocketOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	events, err := exampleclient.UserCreated(ctx, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := exampleclient.CreateUser(ctx, client.New(server.URL), exampleclient.CreateUserVariables{
+		Input: exampleclient.NewUser{Name: "Grace"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &exampleclient.CreateUserResponseNewUser{ID: "3", Name: "Grace", Role: exampleclient.RoleReadOnly}
+	if !reflect.DeepEqual(created.NewUser, expected) {
+		t.Fatalf("unexpected response %+v", created.NewUser)
+	}
+
+	event := <-events
+	if event.Err != nil || event.Data.UserCreated == nil || event.Data.UserCreated.Name != "Grace" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
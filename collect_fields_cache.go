@@ -0,0 +1,124 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/kinds"
+	"github.com/fiatjaf/graphql/language/visitor"
+)
+
+// collectFieldsCache memoizes the sub-fields collected for the selections of a document across its
+// executions, see SchemaConfig.CollectFieldsCacheSize. The collected fields are shared by the
+// executions, so they must not be changed.
+type collectFieldsCache struct {
+	mutex   sync.Mutex
+	size    int
+	count   int
+	entries map[collectFieldsCacheKey][]collectFieldsCacheEntry
+}
+
+// collectFieldsCacheKey identifies the collections of a field for a runtime type. The included
+// fields depend on the values of the variables of the @skip and @include directives, so they are
+// part of the key.
+type collectFieldsCacheKey struct {
+	runtimeType *Object
+	field       *ast.Field
+	variables   string
+}
+
+// collectFieldsCacheEntry is a collection of the sub-fields of the fields merged in fieldASTs.
+type collectFieldsCacheEntry struct {
+	fieldASTs []*ast.Field
+	fields    map[string][]*ast.Field
+}
+
+func newCollectFieldsCache(size int) *collectFieldsCache {
+	return &collectFieldsCache{
+		size:    size,
+		entries: map[collectFieldsCacheKey][]collectFieldsCacheEntry{},
+	}
+}
+
+func (c *collectFieldsCache) get(key collectFieldsCacheKey, fieldASTs []*ast.Field) (map[string][]*ast.Field, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, entry := range c.entries[key] {
+		if sameFields(entry.fieldASTs, fieldASTs) {
+			return entry.fields, true
+		}
+	}
+	return nil, false
+}
+
+// put adds a collection to the cache, which is emptied first when it is full.
+func (c *collectFieldsCache) put(key collectFieldsCacheKey, fieldASTs []*ast.Field, fields map[string][]*ast.Field) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.count >= c.size {
+		c.entries = map[collectFieldsCacheKey][]collectFieldsCacheEntry{}
+		c.count = 0
+	}
+	c.entries[key] = append(c.entries[key], collectFieldsCacheEntry{
+		fieldASTs: append([]*ast.Field(nil), fieldASTs...),
+		fields:    fields,
+	})
+	c.count++
+}
+
+func sameFields(a, b []*ast.Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// skipIncludeVariables returns a fingerprint of the values of the variables used by the @skip and
+// @include directives of the operation and the fragments of the execution.
+func skipIncludeVariables(eCtx *executionContext) string {
+	names := map[string]bool{}
+	collect := &visitor.VisitorOptions{
+		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+			kinds.Directive: {
+				Kind: func(p visitor.VisitFuncParams) (string, interface{}) {
+					directive, ok := p.Node.(*ast.Directive)
+					if !ok || directive.Name == nil ||
+						(directive.Name.Value != SkipDirective.Name && directive.Name.Value != IncludeDirective.Name) {
+						return visitor.ActionSkip, nil
+					}
+					for _, argument := range directive.Arguments {
+						if variable, ok := argument.Value.(*ast.Variable); ok && variable.Name != nil {
+							names[variable.Name.Value] = true
+						}
+					}
+					return visitor.ActionSkip, nil
+				},
+			},
+		},
+	}
+	if eCtx.Operation != nil {
+		visitor.Visit(eCtx.Operation, collect, nil)
+	}
+	for _, fragment := range eCtx.Fragments {
+		visitor.Visit(fragment, collect, nil)
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	var fingerprint strings.Builder
+	for _, name := range sorted {
+		fmt.Fprintf(&fingerprint, "%s=%v;", name, eCtx.VariableValues[name])
+	}
+	return fingerprint.String()
+}
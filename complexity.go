@@ -0,0 +1,304 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// ComplexityFn computes the cost of a field from its coerced arguments and the cost of the
+// fields selected below it, e.g. `childComplexity * args["first"].(int)` for a list field.
+// Fields without a ComplexityFn cost 1 plus the cost of their selections.
+type ComplexityFn func(childComplexity int, args map[string]interface{}) int
+
+// Cost is the complexity of a field declared in the SDL with the @cost and @listSize directives:
+//
+//	users(first: Int, filter: UserFilter): [User] @cost(complexity: 2, multipliers: ["first"])
+//	search(text: String, limit: Int): [Result] @listSize(assumedSize: 50, slicingArguments: ["limit"])
+type Cost struct {
+	// Complexity is the cost of the field itself, the complexity argument of @cost or 1.
+	Complexity int
+
+	// Multipliers are the arguments multiplying the cost of the field and of its selections, the
+	// multipliers argument of @cost. Integer arguments multiply it by their value and list
+	// arguments by their length, while missing or null arguments are ignored. Negative values
+	// count as 0.
+	Multipliers []string
+
+	// SlicingArguments are the arguments giving the size of the list returned by the field, the
+	// slicingArguments argument of @listSize. The first one given multiplies the cost.
+	SlicingArguments []string
+
+	// AssumedSize multiplies the cost when none of the SlicingArguments is given, if it isn't
+	// zero. It is the assumedSize argument of @listSize.
+	AssumedSize int
+}
+
+// CostFromDirectives returns the Cost declared by the @cost and @listSize directives of the
+// definition of a field, and false if it has neither.
+func CostFromDirectives(directives []*ast.Directive) (Cost, bool) {
+	cost := Cost{Complexity: 1}
+	found := false
+	for _, directive := range directives {
+		if directive.Name == nil || (directive.Name.Value != "cost" && directive.Name.Value != "listSize") {
+			continue
+		}
+		found = true
+		for _, arg := range directive.Arguments {
+			if arg.Name == nil {
+				continue
+			}
+			switch directive.Name.Value + "." + arg.Name.Value {
+			case "cost.complexity":
+				cost.Complexity, _ = intLiteral(arg.Value)
+			case "cost.multipliers":
+				cost.Multipliers = stringLiterals(arg.Value)
+			case "listSize.slicingArguments":
+				cost.SlicingArguments = stringLiterals(arg.Value)
+			case "listSize.assumedSize":
+				cost.AssumedSize, _ = intLiteral(arg.Value)
+			}
+		}
+	}
+	return cost, found
+}
+
+// ComplexityFn returns the ComplexityFn of the field, whose cost is its own complexity plus the
+// one of its selections, multiplied by its multipliers and list size.
+func (c Cost) ComplexityFn() ComplexityFn {
+	return func(childComplexity int, args map[string]interface{}) int {
+		multiplier := 1
+		for _, name := range c.Multipliers {
+			if n, ok := costMultiplier(args[name]); ok {
+				multiplier = multiplyCost(multiplier, n)
+			}
+		}
+		size, sliced := 0, false
+		for _, name := range c.SlicingArguments {
+			if size, sliced = costMultiplier(args[name]); sliced {
+				break
+			}
+		}
+		if !sliced {
+			size = c.AssumedSize
+		}
+		if size > 0 || sliced {
+			multiplier = multiplyCost(multiplier, size)
+		}
+		return multiplyCost(addCost(c.Complexity, childComplexity), multiplier)
+	}
+}
+
+// costMultiplier is the factor an argument value multiplies a cost by, 0 for negative values so
+// that they can't cancel the cost of other fields.
+func costMultiplier(value interface{}) (int, bool) {
+	switch value := value.(type) {
+	case int:
+		if value < 0 {
+			return 0, true
+		}
+		return value, true
+	case []interface{}:
+		return len(value), true
+	}
+	return 0, false
+}
+
+func intLiteral(value ast.Value) (int, bool) {
+	if value, ok := value.(*ast.IntValue); ok {
+		if n, err := strconv.Atoi(value.Value); err == nil {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// stringLiterals returns the strings of a list of string literals, or of a single one as it is
+// coerced to a list.
+func stringLiterals(value ast.Value) []string {
+	switch value := value.(type) {
+	case *ast.StringValue:
+		return []string{value.Value}
+	case *ast.ListValue:
+		var values []string
+		for _, item := range value.Values {
+			if item, ok := item.(*ast.StringValue); ok {
+				values = append(values, item.Value)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// CostExtension is what is reported under the "cost" key of the result extensions when
+// Params.MaxCost or Params.RateLimitFn is set.
+type CostExtension struct {
+	// RequestedQueryCost is the cost computed for the operation.
+	RequestedQueryCost int `json:"requestedQueryCost"`
+
+	// MaximumAvailable is the maximum cost allowed for a single operation, if there is one.
+	MaximumAvailable int `json:"maximumAvailable,omitempty"`
+
+	// Remaining is the remaining cost budget of the client, only known when rate limiting is used.
+	Remaining *int `json:"remaining,omitempty"`
+}
+
+// QueryCost computes the cost of the operation of p.AST selected by p.OperationName, with the
+// variables in p.Args. Fragments are expanded and fields excluded by @skip or @include are not
+// counted. An error is returned if the operation can't be found or the variables are invalid.
+func QueryCost(p ExecuteParams) (int, error) {
+	cost, _, err := queryCost(p)
+	return cost, err
+}
+
+// queryCost is QueryCost, also returning the operation whose cost was computed.
+func queryCost(p ExecuteParams) (int, ast.Definition, error) {
+	exeContext, err := buildExecutionContext(buildExecutionCtxParams{
+		Schema:        p.Schema,
+		Root:          p.Root,
+		AST:           p.AST,
+		OperationName: p.OperationName,
+		Args:          p.Args,
+		Context:       p.Context,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	operationType, err := getOperationRootType(exeContext.Schema, exeContext.Operation)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	info := ResolveInfo{
+		Schema:         exeContext.Schema,
+		Fragments:      exeContext.Fragments,
+		Operation:      exeContext.Operation,
+		VariableValues: exeContext.VariableValues,
+		inclusions:     exeContext.inclusions,
+	}
+	root := &ast.Field{SelectionSet: exeContext.Operation.GetSelectionSet()}
+	cost := selectedFieldsCost(info.selectedFieldTree([]*ast.Field{root}, operationType))
+	return cost, exeContext.Operation, nil
+}
+
+func selectedFieldsCost(fields SelectedFields) int {
+	cost := 0
+	for _, field := range fields {
+		childComplexity := selectedFieldsCost(field.Selections)
+		if field.Definition != nil && field.Definition.Complexity != nil {
+			cost = addCost(cost, field.Definition.Complexity(childComplexity, field.Args))
+		} else {
+			cost = addCost(cost, addCost(1, childComplexity))
+		}
+	}
+	return cost
+}
+
+// maxQueryCost caps the costs, so that the sums and products of the costs of the fields can't
+// overflow and wrap around below the maximum cost.
+const maxQueryCost = math.MaxInt32
+
+// addCost returns a+b capped at maxQueryCost, the negative costs counting as 0.
+func addCost(a, b int) int {
+	a, b = clampCost(a), clampCost(b)
+	if a > maxQueryCost-b {
+		return maxQueryCost
+	}
+	return a + b
+}
+
+// multiplyCost returns a*b capped at maxQueryCost, the negative costs counting as 0.
+func multiplyCost(a, b int) int {
+	a, b = clampCost(a), clampCost(b)
+	if b != 0 && a > maxQueryCost/b {
+		return maxQueryCost
+	}
+	return a * b
+}
+
+func clampCost(cost int) int {
+	if cost < 0 {
+		return 0
+	}
+	if cost > maxQueryCost {
+		return maxQueryCost
+	}
+	return cost
+}
+
+// RateLimitFn is called with the name and the cost of every operation after it was validated and
+// its cost computed, but before it is executed. If it returns an error the operation is not
+// executed and the error is sent to the client, along with its extensions if it implements
+// gqlerrors.ExtendedError. The remaining budget of the client can be reported to it under
+// extensions.cost with ReportRemainingCost.
+type RateLimitFn func(ctx context.Context, operationName string, cost int) error
+
+type remainingCostKey struct{}
+
+// ReportRemainingCost reports the remaining cost budget of the client under extensions.cost, it
+// is meant to be called by a RateLimitFn with the context it was given.
+func ReportRemainingCost(ctx context.Context, remaining int) {
+	if holder, ok := ctx.Value(remainingCostKey{}).(**int); ok {
+		*holder = &remaining
+	}
+}
+
+// checkQueryCost computes the cost of the operation when p.MaxCost or p.RateLimitFn is set, rejects
+// it if it is above the maximum and runs the rate limiter. The returned extension is nil when the
+// cost wasn't computed; invalid variables are left to be reported by the execution.
+func checkQueryCost(p *Params, AST *ast.Document) (*CostExtension, gqlerrors.FormattedErrors) {
+	if p.MaxCost <= 0 && p.RateLimitFn == nil {
+		return nil, nil
+	}
+
+	cost, operation, err := queryCost(ExecuteParams{
+		Schema:        p.Schema,
+		Root:          p.RootObject,
+		AST:           AST,
+		OperationName: p.OperationName,
+		Args:          p.VariableValues,
+		Context:       p.Context,
+	})
+	if err != nil {
+		return nil, nil
+	}
+
+	OperationContextFromContext(p.Context).Complexity = cost
+	costExt := &CostExtension{
+		RequestedQueryCost: cost,
+		MaximumAvailable:   p.MaxCost,
+	}
+	if p.MaxCost > 0 && cost > p.MaxCost {
+		return costExt, gqlerrors.FormatErrors(fmt.Errorf(
+			"Query has a cost of %d, which exceeds the maximum cost of %d.", cost, p.MaxCost))
+	}
+
+	if p.RateLimitFn != nil {
+		operationName := p.OperationName
+		if operation, ok := operation.(*ast.OperationDefinition); ok && operationName == "" && operation.GetName() != nil {
+			operationName = operation.GetName().Value
+		}
+		ctx := context.WithValue(p.Context, remainingCostKey{}, &costExt.Remaining)
+		if err := p.RateLimitFn(ctx, operationName, cost); err != nil {
+			return costExt, gqlerrors.FormatErrors(gqlerrors.NewError(err.Error(), nil, "", nil, []int{}, err))
+		}
+	}
+	return costExt, nil
+}
+
+// addCostExtension reports the computed cost under the "cost" key of the result extensions.
+func addCostExtension(result *Result, costExt *CostExtension) {
+	if costExt == nil {
+		return
+	}
+	if result.Extensions == nil {
+		result.Extensions = map[string]interface{}{}
+	}
+	result.Extensions["cost"] = costExt
+}
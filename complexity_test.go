@@ -0,0 +1,185 @@
+package graphql_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+func complexitySchema(t *testing.T) graphql.Schema {
+	authorType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Author",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.String},
+		},
+	})
+	postType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.Fields{
+			"title":  &graphql.Field{Type: graphql.String},
+			"author": &graphql.Field{Type: authorType},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"posts": &graphql.Field{
+					Type: graphql.NewList(postType),
+					Args: graphql.FieldConfigArgument{
+						"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+					},
+					Complexity: func(childComplexity int, args map[string]interface{}) int {
+						return 1 + childComplexity*args["first"].(int)
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return []interface{}{}, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatalf("wrong result, unexpected errors: %v", err.Error())
+	}
+	return schema
+}
+
+func TestQueryCost(t *testing.T) {
+	schema := complexitySchema(t)
+
+	tests := []struct {
+		query     string
+		variables map[string]interface{}
+		cost      int
+	}{
+		{`{ posts { title } }`, nil, 11},
+		{`{ posts(first: 2) { title author { name } } }`, nil, 7},
+		{`query Q($n: Int) { posts(first: $n) { title } }`, map[string]interface{}{"n": 5}, 6},
+		{`query Q($skip: Boolean!) { posts(first: 2) { title author @skip(if: $skip) { name } } }`,
+			map[string]interface{}{"skip": true}, 3},
+		{`{ posts(first: 2) { ...P } } fragment P on Post { title }`, nil, 3},
+	}
+	for _, test := range tests {
+		cost, err := graphql.QueryCost(graphql.ExecuteParams{
+			Schema: schema,
+			AST:    testutil.TestParse(t, test.query),
+			Args:   test.variables,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", test.query, err)
+		}
+		if cost != test.cost {
+			t.Fatalf("expected cost %d for %s, got %d", test.cost, test.query, cost)
+		}
+	}
+}
+
+func TestQueryCost_ReportedInExtensions(t *testing.T) {
+	result := graphql.Do(graphql.Params{
+		Schema:        complexitySchema(t),
+		RequestString: `{ posts(first: 2) { title } }`,
+		MaxCost:       100,
+	})
+	if len(result.Errors) > 0 {
+		t.Fatalf("wrong result, unexpected errors: %v", result.Errors)
+	}
+	expected := &graphql.CostExtension{
+		RequestedQueryCost: 3,
+		MaximumAvailable:   100,
+	}
+	if !reflect.DeepEqual(result.Extensions["cost"], expected) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result.Extensions["cost"]))
+	}
+}
+
+func TestQueryCost_RejectsOperationsAboveMaxCost(t *testing.T) {
+	result := graphql.Do(graphql.Params{
+		Schema:        complexitySchema(t),
+		RequestString: `{ posts(first: 50) { title author { name } } }`,
+		MaxCost:       100,
+	})
+	if result.Data != nil {
+		t.Fatalf("expected no data, got %v", result.Data)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Message != "Query has a cost of 151, which exceeds the maximum cost of 100." {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	expected := &graphql.CostExtension{
+		RequestedQueryCost: 151,
+		MaximumAvailable:   100,
+	}
+	if !reflect.DeepEqual(result.Extensions["cost"], expected) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result.Extensions["cost"]))
+	}
+}
+
+func TestQueryCost_NotReportedWithoutMaxCost(t *testing.T) {
+	result := graphql.Do(graphql.Params{
+		Schema:        complexitySchema(t),
+		RequestString: `{ posts(first: 50) { title author { name } } }`,
+	})
+	if len(result.Errors) > 0 {
+		t.Fatalf("wrong result, unexpected errors: %v", result.Errors)
+	}
+	if _, ok := result.Extensions["cost"]; ok {
+		t.Fatalf("expected no cost extension, got %v", result.Extensions)
+	}
+}
+
+type rateLimitedError struct{}
+
+func (rateLimitedError) Error() string { return "rate limit exceeded" }
+
+func (rateLimitedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "RATE_LIMITED"}
+}
+
+func TestQueryCost_RateLimitFn(t *testing.T) {
+	budget := 10
+	rateLimitFn := func(ctx context.Context, operationName string, cost int) error {
+		if operationName != "Posts" {
+			t.Fatalf("expected operation name Posts, got %q", operationName)
+		}
+		if cost > budget {
+			return rateLimitedError{}
+		}
+		budget -= cost
+		graphql.ReportRemainingCost(ctx, budget)
+		return nil
+	}
+	schema := complexitySchema(t)
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `query Posts { posts(first: 2) { title author { name } } }`,
+		RateLimitFn:   rateLimitFn,
+	})
+	if len(result.Errors) > 0 {
+		t.Fatalf("wrong result, unexpected errors: %v", result.Errors)
+	}
+	remaining := 3
+	expected := &graphql.CostExtension{
+		RequestedQueryCost: 7,
+		Remaining:          &remaining,
+	}
+	if !reflect.DeepEqual(result.Extensions["cost"], expected) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result.Extensions["cost"]))
+	}
+
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `query Posts { posts(first: 2) { title author { name } } author: posts(first: 1) { title } }`,
+		RateLimitFn:   rateLimitFn,
+	})
+	if result.Data != nil {
+		t.Fatalf("expected no data, got %v", result.Data)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Message != "rate limit exceeded" ||
+		!reflect.DeepEqual(result.Errors[0].Extensions, map[string]interface{}{"code": "RATE_LIMITED"}) {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+}
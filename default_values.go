@@ -0,0 +1,41 @@
+package graphql
+
+import (
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/printer"
+)
+
+// defineDefaultValue returns the value of the default value of a definition of the type ttype,
+// and its literal when it was given as one, see ArgumentConfig.DefaultValue.
+func defineDefaultValue(defaultValue interface{}, ttype Input) (interface{}, ast.Value) {
+	literal, ok := defaultValue.(ast.Value)
+	if !ok {
+		return defaultValue, nil
+	}
+	if _, ok := literal.(*ast.NullValue); ok {
+		return nil, literal
+	}
+	return valueFromAST(literal, ttype, nil), literal
+}
+
+// defaultValueLiteral returns the literal of the default value of a definition of the type ttype,
+// nil if it has none: the literal it was given as, if any.
+func defaultValueLiteral(defaultValue interface{}, literal ast.Value, ttype Input) ast.Value {
+	if literal != nil {
+		return literal
+	}
+	if isNullish(defaultValue) {
+		return nil
+	}
+	return astFromValue(defaultValue, ttype)
+}
+
+// printDefaultValue returns the default value of a definition as introspected, a GraphQL literal,
+// nil if it has none.
+func printDefaultValue(defaultValue interface{}, literal ast.Value, ttype Input) interface{} {
+	valueAST := defaultValueLiteral(defaultValue, literal, ttype)
+	if valueAST == nil {
+		return nil
+	}
+	return printer.Print(valueAST)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"time"
 
 	"github.com/fiatjaf/graphql/language/ast"
 )
@@ -395,6 +396,10 @@ type ObjectConfig struct {
 	Fields      interface{} `json:"fields"`
 	IsTypeOf    IsTypeOfFn  `json:"isTypeOf"`
 	Description string      `json:"description"`
+
+	// Internal hides the type, and the fields of its type, from the introspection and PrintSchema,
+	// and from the requests without internal access, see WithInternalAccess.
+	Internal bool `json:"-"`
 }
 
 type FieldsThunk func() Fields
@@ -443,7 +448,7 @@ func (gt *Object) Name() string {
 }
 
 func (gt *Object) Description() string {
-	return ""
+	return gt.PrivateDescription
 }
 
 func (gt *Object) String() string {
@@ -556,12 +561,20 @@ func defineFieldMap(ttype Named, fieldMap Fields) (FieldDefinitionMap, error) {
 			return resultFieldMap, err
 		}
 		fieldDef := &FieldDefinition{
-			Name:              fieldName,
-			Description:       field.Description,
-			Type:              field.Type,
-			Resolve:           field.Resolve,
-			Subscribe:         field.Subscribe,
-			DeprecationReason: field.DeprecationReason,
+			Name:               fieldName,
+			Description:        field.Description,
+			Type:               field.Type,
+			Resolve:            field.Resolve,
+			Subscribe:          field.Subscribe,
+			Complexity:         field.Complexity,
+			DeprecationReason:  field.DeprecationReason,
+			Authorize:          field.Authorize,
+			ResolveOnSubscribe: field.ResolveOnSubscribe,
+			Internal:           field.Internal,
+			Cache:              field.Cache,
+			CacheHint:          field.CacheHint,
+			Timeout:            field.Timeout,
+			Retry:              field.Retry,
 		}
 
 		fieldDef.Args = []*Argument{}
@@ -585,8 +598,8 @@ func defineFieldMap(ttype Named, fieldMap Fields) (FieldDefinitionMap, error) {
 				PrivateName:        argName,
 				PrivateDescription: arg.Description,
 				Type:               arg.Type,
-				DefaultValue:       arg.DefaultValue,
 			}
+			fieldArg.DefaultValue, fieldArg.defaultLiteral = defineDefaultValue(arg.DefaultValue, arg.Type)
 			fieldDef.Args = append(fieldDef.Args, fieldArg)
 		}
 		resultFieldMap[fieldName] = fieldDef
@@ -627,6 +640,9 @@ type ResolveInfo struct {
 	RootValue      interface{}
 	Operation      ast.Definition
 	VariableValues map[string]interface{}
+
+	// inclusions are the evaluations of the @skip and @include directives of the request
+	inclusions *inclusions
 }
 
 type Fields map[string]*Field
@@ -637,14 +653,50 @@ type Field struct {
 	Args              FieldConfigArgument        `json:"args"`
 	Resolve           FieldResolveFn             `json:"-"`
 	Subscribe         SubscriptionFieldResolveFn `json:"-"`
+	Complexity        ComplexityFn               `json:"-"`
 	DeprecationReason string                     `json:"deprecationReason"`
 	Description       string                     `json:"description"`
+
+	// Authorize, when set, is called before Resolve (or Subscribe) with the same params. When it
+	// returns an error the resolver isn't called, the field is null and the error is reported at
+	// its path, with the FORBIDDEN code unless the error has its own extensions.
+	Authorize AuthorizeFn `json:"-"`
+
+	// ResolveOnSubscribe makes a subscription to the field send a first result as soon as it is
+	// subscribed, before the events of its source: Resolve is called with the root value as the
+	// source, typically to return the current state that the events update. See IsInitialEvent.
+	ResolveOnSubscribe bool `json:"-"`
+
+	// Internal hides the field from the introspection and PrintSchema: it is only executed for the
+	// requests with internal access, see WithInternalAccess, for the others it doesn't exist. It is
+	// meant for the fields of migration shims or only queried by a gateway.
+	Internal bool `json:"-"`
+
+	// Cache, when set, makes the values returned by Resolve be reused within a request, and across
+	// requests with the FieldCacheStore of the schema, see FieldCache.
+	Cache *FieldCache `json:"-"`
+
+	// CacheHint, when set, tells how long the responses with the field can be cached, see
+	// CachePolicy.
+	CacheHint *CacheHint `json:"-"`
+
+	// Timeout, when above zero, is how long each call of Resolve may take: its context is done
+	// then, and the field fails with an error wrapping ErrResolverTimeout.
+	Timeout time.Duration `json:"-"`
+
+	// Retry, when set, makes Resolve be called again when it fails, see RetryPolicy. The error of
+	// the last call reports the number of calls under the "attempts" key of its extensions.
+	Retry *RetryPolicy `json:"-"`
 }
 
 type FieldConfigArgument map[string]*ArgumentConfig
 
 type ArgumentConfig struct {
-	Type         Input       `json:"type"`
+	Type Input `json:"type"`
+
+	// DefaultValue is the value of the argument when it isn't given. It can also be given as a
+	// literal, an ast.Value like the default values parsed from an SDL, which is then introspected
+	// and printed as is.
 	DefaultValue interface{} `json:"defaultValue"`
 	Description  string      `json:"description"`
 }
@@ -652,13 +704,21 @@ type ArgumentConfig struct {
 type (
 	FieldDefinitionMap map[string]*FieldDefinition
 	FieldDefinition    struct {
-		Name              string                     `json:"name"`
-		Description       string                     `json:"description"`
-		Type              Output                     `json:"type"`
-		Args              []*Argument                `json:"args"`
-		Resolve           FieldResolveFn             `json:"-"`
-		Subscribe         SubscriptionFieldResolveFn `json:"-"`
-		DeprecationReason string                     `json:"deprecationReason"`
+		Name               string                     `json:"name"`
+		Description        string                     `json:"description"`
+		Type               Output                     `json:"type"`
+		Args               []*Argument                `json:"args"`
+		Resolve            FieldResolveFn             `json:"-"`
+		Subscribe          SubscriptionFieldResolveFn `json:"-"`
+		Complexity         ComplexityFn               `json:"-"`
+		DeprecationReason  string                     `json:"deprecationReason"`
+		Authorize          AuthorizeFn                `json:"-"`
+		ResolveOnSubscribe bool                       `json:"-"`
+		Internal           bool                       `json:"-"`
+		Cache              *FieldCache                `json:"-"`
+		CacheHint          *CacheHint                 `json:"-"`
+		Timeout            time.Duration              `json:"-"`
+		Retry              *RetryPolicy               `json:"-"`
 	}
 )
 
@@ -674,6 +734,9 @@ type Argument struct {
 	Type               Input       `json:"type"`
 	DefaultValue       interface{} `json:"defaultValue"`
 	PrivateDescription string      `json:"description"`
+
+	// defaultLiteral is the literal DefaultValue was given as, if any
+	defaultLiteral ast.Value
 }
 
 func (st *Argument) Name() string {
@@ -724,6 +787,9 @@ type InterfaceConfig struct {
 	Fields      interface{} `json:"fields"`
 	ResolveType ResolveTypeFn
 	Description string `json:"description"`
+
+	// Internal hides the type like ObjectConfig.Internal.
+	Internal bool `json:"-"`
 }
 
 // ResolveTypeParams Params for ResolveTypeFn()
@@ -844,6 +910,9 @@ type UnionConfig struct {
 	Types       interface{} `json:"types"`
 	ResolveType ResolveTypeFn
 	Description string `json:"description"`
+
+	// Internal hides the type like ObjectConfig.Internal.
+	Internal bool `json:"-"`
 }
 
 func NewUnion(config UnionConfig) *Union {
@@ -965,6 +1034,7 @@ type Enum struct {
 	values       []*EnumValueDefinition
 	valuesLookup map[interface{}]*EnumValueDefinition
 	nameLookup   map[string]*EnumValueDefinition
+	valuesType   reflect.Type
 
 	err error
 }
@@ -981,6 +1051,10 @@ type EnumConfig struct {
 	Name        string             `json:"name"`
 	Values      EnumValueConfigMap `json:"values"`
 	Description string             `json:"description"`
+
+	// Strict makes the fields of the enum type fail with an error when they resolve to a value
+	// that isn't one of the internal values of the enum, instead of resolving to null.
+	Strict bool `json:"-"`
 }
 type EnumValueDefinition struct {
 	Name              string      `json:"name"`
@@ -1054,12 +1128,55 @@ func (gt *Enum) Serialize(value interface{}) interface{} {
 	} else if kind == reflect.Ptr {
 		v = reflect.Indirect(reflect.ValueOf(v)).Interface()
 	}
+	// values such as slices and maps can't be looked up, nor be internal values
+	if rv := reflect.ValueOf(v); rv.IsValid() && !rv.Type().Comparable() {
+		return nil
+	}
 	if enumValue, ok := gt.getValueLookup()[v]; ok {
 		return enumValue.Name
 	}
+	if v, ok := gt.convertValue(v); ok {
+		if enumValue, ok := gt.getValueLookup()[v]; ok {
+			return enumValue.Name
+		}
+	}
+	if stringer, ok := v.(fmt.Stringer); ok {
+		if enumValue, ok := gt.getNameLookup()[stringer.String()]; ok {
+			return enumValue.Name
+		}
+	}
 	return nil
 }
 
+// convertValue converts value to the Go type of the internal values of the enum, when they all
+// have the same type, e.g. an int to the int-based type of iota constants. Only values of the
+// same kind (integers, floats or strings) are converted.
+func (gt *Enum) convertValue(value interface{}) (interface{}, bool) {
+	gt.getValueLookup()
+	rv := reflect.ValueOf(value)
+	if gt.valuesType == nil || !rv.IsValid() || rv.Type() == gt.valuesType {
+		return nil, false
+	}
+	if enumValueKind(rv.Kind()) != enumValueKind(gt.valuesType.Kind()) {
+		return nil, false
+	}
+	return rv.Convert(gt.valuesType).Interface(), true
+}
+
+// enumValueKind groups the kinds of the internal values that can be converted to each other.
+func enumValueKind(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	}
+	return ""
+}
+
 func (gt *Enum) ParseValue(value interface{}) interface{} {
 	var v string
 
@@ -1107,10 +1224,20 @@ func (gt *Enum) getValueLookup() map[interface{}]*EnumValueDefinition {
 		return gt.valuesLookup
 	}
 	valuesLookup := map[interface{}]*EnumValueDefinition{}
-	for _, value := range gt.Values() {
+	var valuesType reflect.Type
+	for i, value := range gt.Values() {
 		valuesLookup[value.Value] = value
+		if valueType := reflect.TypeOf(value.Value); i == 0 {
+			valuesType = valueType
+		} else if valueType != valuesType {
+			valuesType = nil
+		}
+	}
+	if valuesType != nil && enumValueKind(valuesType.Kind()) == "" {
+		valuesType = nil
 	}
 	gt.valuesLookup = valuesLookup
+	gt.valuesType = valuesType
 	return gt.valuesLookup
 }
 
@@ -1153,7 +1280,10 @@ type InputObject struct {
 	err        error
 }
 type InputObjectFieldConfig struct {
-	Type         Input       `json:"type"`
+	Type Input `json:"type"`
+
+	// DefaultValue is the value of the field when it isn't given, or its literal like
+	// ArgumentConfig.DefaultValue.
 	DefaultValue interface{} `json:"defaultValue"`
 	Description  string      `json:"description"`
 }
@@ -1162,6 +1292,9 @@ type InputObjectField struct {
 	Type               Input       `json:"type"`
 	DefaultValue       interface{} `json:"defaultValue"`
 	PrivateDescription string      `json:"description"`
+
+	// defaultLiteral is the literal DefaultValue was given as, if any
+	defaultLiteral ast.Value
 }
 
 func (st *InputObjectField) Name() string {
@@ -1244,6 +1377,12 @@ func (gt *InputObject) defineFieldMap() InputObjectFieldMap {
 		resultFieldMap[fieldName] = field
 	}
 	gt.init = true
+
+	// the literals are converted once the fields are defined, as they may be of this very type
+	gt.fields = resultFieldMap
+	for _, field := range resultFieldMap {
+		field.DefaultValue, field.defaultLiteral = defineDefaultValue(field.DefaultValue, field.Type)
+	}
 	return resultFieldMap
 }
 
@@ -0,0 +1,62 @@
+package graphql_test
+
+import (
+	"go/build"
+	"strings"
+	"testing"
+)
+
+// corePackages are the packages that must build for js/wasm and TinyGo, for executing schemas in
+// browsers.
+var corePackages = []string{
+	"github.com/fiatjaf/graphql",
+	"github.com/fiatjaf/graphql/gqlerrors",
+	"github.com/fiatjaf/graphql/language/parser",
+	"github.com/fiatjaf/graphql/language/printer",
+	"github.com/fiatjaf/graphql/language/visitor",
+}
+
+func TestCorePackages_DontImportServerPackages(t *testing.T) {
+	for _, test := range []struct {
+		tags      []string
+		forbidden []string
+	}{
+		{nil, []string{"net", "net/http", "github.com/gorilla/websocket"}},
+		{[]string{"tinygo"}, []string{"net", "net/http", "github.com/gorilla/websocket", "runtime/pprof"}},
+	} {
+		ctx := build.Default
+		ctx.GOOS, ctx.GOARCH, ctx.BuildTags = "js", "wasm", test.tags
+
+		importedBy := map[string]string{}
+		var visit func(path, parent string)
+		visit = func(path, parent string) {
+			if _, ok := importedBy[path]; ok || path == "C" || path == "unsafe" {
+				return
+			}
+			importedBy[path] = parent
+			pkg, err := ctx.Import(path, ".", 0)
+			if err != nil {
+				t.Fatalf("failed to import %s: %v", path, err)
+			}
+			for _, imported := range pkg.Imports {
+				visit(imported, path)
+			}
+		}
+		for _, path := range corePackages {
+			visit(path, "")
+		}
+
+		for _, path := range test.forbidden {
+			parent, ok := importedBy[path]
+			if !ok {
+				continue
+			}
+			chain := []string{path}
+			for parent != "" {
+				chain = append(chain, parent)
+				parent = importedBy[parent]
+			}
+			t.Errorf("%s imported with tags %v by %s", path, test.tags, strings.Join(chain[1:], " <- "))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package graphql
+
+import "strings"
+
+// Descriptions are the descriptions of the types, fields, arguments, enum values and directives of
+// a schema by their schema coordinates: "Type", "Type.field", "Type.field(argument:)",
+// "Enum.VALUE", "Input.field", "@directive" and "@directive(argument:)". They are typically
+// generated from the doc comments of the Go code defining the schema, by a go:generate tool
+// writing them to a file of its package, see SchemaConfig.Descriptions.
+type Descriptions map[string]string
+
+// describe gives the definitions of schema without a description theirs in descriptions.
+func (descriptions Descriptions) describe(schema *Schema) {
+	describe := func(current *string, coordinate string) {
+		if *current != "" {
+			return
+		}
+		if description, ok := descriptions[coordinate]; ok {
+			*current = description
+		}
+	}
+	describeArgs := func(args []*Argument, coordinate string) {
+		for _, arg := range args {
+			describe(&arg.PrivateDescription, coordinate+"("+arg.PrivateName+":)")
+		}
+	}
+	describeFields := func(fields FieldDefinitionMap, typeName string) {
+		for name, field := range fields {
+			describe(&field.Description, typeName+"."+name)
+			describeArgs(field.Args, typeName+"."+name)
+		}
+	}
+
+	for name, ttype := range schema.TypeMap() {
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+		switch ttype := ttype.(type) {
+		case *Scalar:
+			describe(&ttype.PrivateDescription, name)
+		case *Object:
+			describe(&ttype.PrivateDescription, name)
+			describeFields(ttype.Fields(), name)
+		case *Interface:
+			describe(&ttype.PrivateDescription, name)
+			describeFields(ttype.Fields(), name)
+		case *Union:
+			describe(&ttype.PrivateDescription, name)
+		case *Enum:
+			describe(&ttype.PrivateDescription, name)
+			for _, value := range ttype.Values() {
+				describe(&value.Description, name+"."+value.Name)
+			}
+		case *InputObject:
+			describe(&ttype.PrivateDescription, name)
+			for fieldName, field := range ttype.Fields() {
+				describe(&field.PrivateDescription, name+"."+fieldName)
+			}
+		}
+	}
+	for _, directive := range schema.Directives() {
+		describe(&directive.Description, "@"+directive.Name)
+		describeArgs(directive.Args, "@"+directive.Name)
+	}
+}
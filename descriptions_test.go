@@ -0,0 +1,69 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+)
+
+func TestSchemaConfig_Descriptions(t *testing.T) {
+	role := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Role",
+		Values: graphql.EnumValueConfigMap{
+			"ADMIN": &graphql.EnumValueConfig{Value: "ADMIN"},
+		},
+	})
+	user := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.String, Description: "Written by hand."},
+			"role": &graphql.Field{Type: role},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"user": &graphql.Field{
+					Type: user,
+					Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.ID}},
+				},
+			},
+		}),
+		Descriptions: graphql.Descriptions{
+			"User":            "A user of the service.",
+			"User.name":       "Overridden by the field's own description.",
+			"User.role":       "What the user can do.",
+			"Role.ADMIN":      "Can do anything.",
+			"Query.user":      "Finds a user.",
+			"Query.user(id:)": "The id of the user.",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := schema.QueryType().Fields()["user"]
+	userType := schema.Type("User").(*graphql.Object)
+	descriptions := map[string]string{
+		"User":            userType.Description(),
+		"User.name":       userType.Fields()["name"].Description,
+		"User.role":       userType.Fields()["role"].Description,
+		"Role.ADMIN":      schema.Type("Role").(*graphql.Enum).Values()[0].Description,
+		"Query.user":      query.Description,
+		"Query.user(id:)": query.Args[0].Description(),
+	}
+	expected := map[string]string{
+		"User":            "A user of the service.",
+		"User.name":       "Written by hand.",
+		"User.role":       "What the user can do.",
+		"Role.ADMIN":      "Can do anything.",
+		"Query.user":      "Finds a user.",
+		"Query.user(id:)": "The id of the user.",
+	}
+	for coordinate, description := range expected {
+		if descriptions[coordinate] != description {
+			t.Fatalf("expected the description %q for %s, got %q", description, coordinate, descriptions[coordinate])
+		}
+	}
+}
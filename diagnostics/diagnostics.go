@@ -0,0 +1,169 @@
+// Package diagnostics reports, in development, the patterns of the requests that make them slow,
+// under the "diagnostics" extension of their results.
+//
+// It detects the N+1 problem: a resolver called for every item of a list, each call loading its
+// value on its own, like the avatar of each of 250 users. Such resolvers should batch their loads,
+// with a DataLoader, and return thunks so the loads of the items are made together:
+//
+//	schema, _ := graphql.NewSchema(graphql.SchemaConfig{
+//		Query:      queryType,
+//		Extensions: []graphql.Extension{diagnostics.New()},
+//	})
+//
+// The results then have extensions like
+//
+//	{"diagnostics": [{"kind": "N_PLUS_ONE", "coordinate": "User.avatar", "count": 250, ...}]}
+//
+// It counts every resolver call of every request, so it isn't meant to be enabled in production.
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
+)
+
+// DefaultThreshold is the number of calls of a resolver in a request from which it is reported,
+// unless the Detector has another Threshold.
+const DefaultThreshold = 10
+
+// KindNPlusOne is the kind of the diagnostics of the resolvers called too many times in a request.
+const KindNPlusOne = "N_PLUS_ONE"
+
+// Diagnostic is a pattern found in a request, as reported in its result.
+type Diagnostic struct {
+	Kind string `json:"kind"`
+	// Coordinate is the field whose resolver is concerned, as "Type.field".
+	Coordinate string `json:"coordinate"`
+	// Count is the number of calls of the resolver that didn't return a thunk.
+	Count int `json:"count"`
+	// Message describes the pattern, e.g. "User.avatar resolved 250 times in one request".
+	Message string `json:"message"`
+	// Suggestion tells how to fix it.
+	Suggestion string `json:"suggestion"`
+}
+
+// Detector is a graphql.Extension reporting the resolvers called at least Threshold times in a
+// request, counted by field coordinate. Only the fields with a Resolve function are counted: the
+// default resolver reads the value of the source, which can't cause the N+1 problem, and the
+// resolvers returning thunks (func() (interface{}, error)) are considered batched already.
+type Detector struct {
+	// Threshold is the number of calls from which a resolver is reported, DefaultThreshold if it
+	// isn't above zero.
+	Threshold int
+}
+
+// New returns a Detector with the DefaultThreshold.
+func New() *Detector {
+	return &Detector{Threshold: DefaultThreshold}
+}
+
+type requestKey struct{}
+
+// request counts the calls of the resolvers of a request.
+type request struct {
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+func (d *Detector) threshold() int {
+	if d.Threshold > 0 {
+		return d.Threshold
+	}
+	return DefaultThreshold
+}
+
+// Name implements graphql.Extension.
+func (d *Detector) Name() string {
+	return "diagnostics"
+}
+
+// Init implements graphql.Extension.
+func (d *Detector) Init(ctx context.Context, p *graphql.Params) context.Context {
+	return context.WithValue(ctx, requestKey{}, &request{counts: map[string]int{}})
+}
+
+// ParseDidStart implements graphql.Extension.
+func (d *Detector) ParseDidStart(ctx context.Context) (context.Context, graphql.ParseFinishFunc) {
+	return ctx, func(error) {}
+}
+
+// ValidationDidStart implements graphql.Extension.
+func (d *Detector) ValidationDidStart(ctx context.Context) (context.Context, graphql.ValidationFinishFunc) {
+	return ctx, func([]gqlerrors.FormattedError) {}
+}
+
+// ExecutionDidStart implements graphql.Extension.
+func (d *Detector) ExecutionDidStart(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+	return ctx, func(*graphql.Result) {}
+}
+
+// ResolveFieldDidStart implements graphql.Extension. The call is counted when the resolver
+// returns, once it is known whether it returned a thunk.
+func (d *Detector) ResolveFieldDidStart(ctx context.Context, info *graphql.ResolveInfo) (context.Context, graphql.ResolveFieldFinishFunc) {
+	req, ok := ctx.Value(requestKey{}).(*request)
+	if !ok || !hasResolver(info) {
+		return ctx, func(interface{}, error) {}
+	}
+	coordinate := info.ParentType.Name() + "." + info.FieldName
+	return ctx, func(result interface{}, err error) {
+		if _, isThunk := result.(func() (interface{}, error)); isThunk {
+			return
+		}
+		req.mutex.Lock()
+		req.counts[coordinate]++
+		req.mutex.Unlock()
+	}
+}
+
+// hasResolver tells if the field of info has a Resolve function, the introspection fields
+// excepted.
+func hasResolver(info *graphql.ResolveInfo) bool {
+	object, ok := info.ParentType.(*graphql.Object)
+	if !ok || strings.HasPrefix(object.Name(), "__") || strings.HasPrefix(info.FieldName, "__") {
+		return false
+	}
+	field, ok := object.Fields()[info.FieldName]
+	return ok && field.Resolve != nil
+}
+
+// HasResult implements graphql.Extension.
+func (d *Detector) HasResult() bool {
+	return true
+}
+
+// GetResult implements graphql.Extension. It returns the diagnostics of the request of ctx, the
+// most called resolvers first.
+func (d *Detector) GetResult(ctx context.Context) interface{} {
+	diagnostics := []Diagnostic{}
+	req, ok := ctx.Value(requestKey{}).(*request)
+	if !ok {
+		return diagnostics
+	}
+	req.mutex.Lock()
+	defer req.mutex.Unlock()
+	for coordinate, count := range req.counts {
+		if count < d.threshold() {
+			continue
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Kind:       KindNPlusOne,
+			Coordinate: coordinate,
+			Count:      count,
+			Message:    fmt.Sprintf("%s resolved %d times in one request", coordinate, count),
+			Suggestion: fmt.Sprintf("Batch the loads of %s with a DataLoader, returning a thunk from its resolver so that they are made together.", coordinate),
+		})
+	}
+	sort.Slice(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Count != diagnostics[j].Count {
+			return diagnostics[i].Count > diagnostics[j].Count
+		}
+		return diagnostics[i].Coordinate < diagnostics[j].Coordinate
+	})
+	return diagnostics
+}
@@ -0,0 +1,75 @@
+package diagnostics_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/diagnostics"
+)
+
+func TestDetector_ReportsResolversCalledForEveryItem(t *testing.T) {
+	detector := diagnostics.New()
+	detector.Threshold = 5
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.String},
+			"avatar": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return "avatar.png", nil
+				},
+			},
+			"email": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return func() (interface{}, error) { return "user@example.com", nil }, nil
+				},
+			},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"users": &graphql.Field{
+					Type: graphql.NewList(userType),
+					Args: graphql.FieldConfigArgument{"count": &graphql.ArgumentConfig{Type: graphql.Int}},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						users := []interface{}{}
+						for i := 0; i < p.Args["count"].(int); i++ {
+							users = append(users, map[string]interface{}{"name": "user"})
+						}
+						return users, nil
+					},
+				},
+			},
+		}),
+		Extensions: []graphql.Extension{detector},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ users(count: 8) { name avatar email } }`})
+	if result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	expected := []diagnostics.Diagnostic{{
+		Kind:       diagnostics.KindNPlusOne,
+		Coordinate: "User.avatar",
+		Count:      8,
+		Message:    "User.avatar resolved 8 times in one request",
+		Suggestion: "Batch the loads of User.avatar with a DataLoader, returning a thunk from its resolver so that they are made together.",
+	}}
+	if !reflect.DeepEqual(expected, result.Extensions["diagnostics"]) {
+		t.Fatalf("expected the diagnostics %v, got %v", expected, result.Extensions["diagnostics"])
+	}
+
+	// the calls are counted per request
+	result = graphql.Do(graphql.Params{Schema: schema, RequestString: `{ users(count: 4) { avatar } }`})
+	if diagnostics, ok := result.Extensions["diagnostics"].([]diagnostics.Diagnostic); !ok || len(diagnostics) != 0 {
+		t.Fatalf("expected no diagnostics, got %v", result.Extensions["diagnostics"])
+	}
+}
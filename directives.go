@@ -77,12 +77,13 @@ func NewDirective(config DirectiveConfig) *Directive {
 		if dir.err = assertValidName(argName); dir.err != nil {
 			return dir
 		}
-		args = append(args, &Argument{
+		arg := &Argument{
 			PrivateName:        argName,
 			PrivateDescription: argConfig.Description,
 			Type:               argConfig.Type,
-			DefaultValue:       argConfig.DefaultValue,
-		})
+		}
+		arg.DefaultValue, arg.defaultLiteral = defineDefaultValue(argConfig.DefaultValue, argConfig.Type)
+		args = append(args, arg)
 	}
 
 	dir.Name = config.Name
@@ -0,0 +1,86 @@
+package graphql
+
+import (
+	"sync"
+
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/parser"
+	"github.com/fiatjaf/graphql/language/source"
+)
+
+// DocumentCache caches the documents parsed and validated by Do and DoAsync, by query, so that the
+// queries sent again, like the subscriptions of clients reconnecting, aren't parsed nor validated
+// again. See Params.DocumentCache.
+//
+// A cache must only be used with one schema and one set of validation rules. The documents are
+// validated again when MaxNodes is set, since its validation depends on the variables.
+type DocumentCache struct {
+	mutex   sync.Mutex
+	size    int
+	entries map[string]*cachedDocument
+}
+
+// cachedDocument is a document parsed from a query, and the warnings of its validation if it was
+// validated.
+type cachedDocument struct {
+	document  *ast.Document
+	validated bool
+	warnings  []gqlerrors.FormattedError
+}
+
+// NewDocumentCache returns a DocumentCache holding up to size documents. It is emptied when full.
+func NewDocumentCache(size int) *DocumentCache {
+	return &DocumentCache{
+		size:    size,
+		entries: map[string]*cachedDocument{},
+	}
+}
+
+// Len returns the number of documents in the cache.
+func (c *DocumentCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
+
+func (c *DocumentCache) get(query string) (*cachedDocument, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	cached, ok := c.entries[query]
+	return cached, ok
+}
+
+// put adds a document to the cache, which is emptied first when it is full.
+func (c *DocumentCache) put(query string, cached *cachedDocument) {
+	if c == nil || c.size <= 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.entries[query]; !ok && len(c.entries) >= c.size {
+		c.entries = map[string]*cachedDocument{}
+	}
+	c.entries[query] = cached
+}
+
+// Parse returns the document of query, from the cache or else parsed and added to it, to be
+// validated by the first request executing it. It is meant for the servers that need to know the
+// operations of the requests before executing them, e.g. to tell queries from subscriptions. A nil
+// cache parses query every time.
+func (c *DocumentCache) Parse(query string) (*ast.Document, error) {
+	if cached, ok := c.get(query); ok {
+		return cached.document, nil
+	}
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.put(query, &cachedDocument{document: document})
+	return document, nil
+}
@@ -0,0 +1,92 @@
+package graphql_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/language/visitor"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+func TestDocumentCache_SkipsParsingAndValidatingCachedQueries(t *testing.T) {
+	validations := 0
+	countingRule := func(context *graphql.ValidationContext) *graphql.ValidationRuleInstance {
+		validations++
+		return &graphql.ValidationRuleInstance{VisitorOpts: &visitor.VisitorOptions{}}
+	}
+	cache := graphql.NewDocumentCache(2)
+	do := func(query string, maxNodes int) *graphql.Result {
+		return graphql.Do(graphql.Params{
+			Schema:          testutil.StarWarsSchema,
+			RequestString:   query,
+			ValidationRules: []graphql.ValidationRuleFn{countingRule},
+			MaxNodes:        maxNodes,
+			DocumentCache:   cache,
+		})
+	}
+
+	expected := &graphql.Result{Data: map[string]interface{}{"hero": map[string]interface{}{"name": "R2-D2"}}}
+	for i := 0; i < 3; i++ {
+		if result := do(`{ hero { name } }`, 0); !reflect.DeepEqual(result, expected) {
+			t.Fatalf("unexpected result %v", result)
+		}
+	}
+	if validations != 1 || cache.Len() != 1 {
+		t.Fatalf("expected the query to be validated once and cached, got %v validations and %v documents", validations, cache.Len())
+	}
+
+	// invalid queries aren't cached, and MaxNodes makes the documents be validated again
+	for i := 0; i < 2; i++ {
+		if result := do(`{ hero { unknown } }`, 0); len(result.Errors) == 0 {
+			t.Fatalf("expected an error")
+		}
+	}
+	do(`{ hero { name } }`, 10)
+	if validations != 4 || cache.Len() != 1 {
+		t.Fatalf("expected 4 validations and 1 document, got %v and %v", validations, cache.Len())
+	}
+
+	// the cache is emptied when full
+	do(`{ hero { id } }`, 0)
+	do(`{ hero { id name } }`, 0)
+	if cache.Len() != 1 {
+		t.Fatalf("expected the cache to be emptied, got %v documents", cache.Len())
+	}
+}
+
+func TestDocumentCache_ParseCachesDocumentsToValidate(t *testing.T) {
+	validations := 0
+	countingRule := func(context *graphql.ValidationContext) *graphql.ValidationRuleInstance {
+		validations++
+		return &graphql.ValidationRuleInstance{VisitorOpts: &visitor.VisitorOptions{}}
+	}
+	cache := graphql.NewDocumentCache(2)
+	first, err := cache.Parse(`{ hero { name } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second, _ := cache.Parse(`{ hero { name } }`); second != first {
+		t.Fatalf("expected the document to be parsed once")
+	}
+	if _, err := cache.Parse(`{ hero {`); err == nil || cache.Len() != 1 {
+		t.Fatalf("expected a syntax error and the document not to be cached, got %v and %v documents", err, cache.Len())
+	}
+
+	// the parsed documents are validated by the first request executing them
+	expected := &graphql.Result{Data: map[string]interface{}{"hero": map[string]interface{}{"name": "R2-D2"}}}
+	for i := 0; i < 2; i++ {
+		result := graphql.Do(graphql.Params{
+			Schema:          testutil.StarWarsSchema,
+			RequestString:   `{ hero { name } }`,
+			ValidationRules: []graphql.ValidationRuleFn{countingRule},
+			DocumentCache:   cache,
+		})
+		if !reflect.DeepEqual(result, expected) {
+			t.Fatalf("unexpected result %v", result)
+		}
+	}
+	if validations != 1 {
+		t.Fatalf("expected the document to be validated once, got %v validations", validations)
+	}
+}
@@ -1,6 +1,7 @@
 package graphql_test
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -306,7 +307,7 @@ func TestTypeSystem_EnumValues_DoesNotAcceptInternalValueAsEnumVariable(t *testi
 		Data: nil,
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: "Variable \"$color\" got invalid value 2.\nExpected type \"Color\", found \"2\".",
+				Message: `Variable "$color" expected value of type "Color!", found 2.`,
 				Locations: []location.SourceLocation{
 					{Line: 1, Column: 12},
 				},
@@ -477,3 +478,117 @@ func TestTypeSystem_EnumValues_EnumValueMayBeNilPointer(t *testing.T) {
 		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
 	}
 }
+
+type testEpisode int
+
+const (
+	testNewHope testEpisode = iota + 4
+	testEmpire
+	testJedi
+)
+
+func (e testEpisode) String() string {
+	if e < testNewHope || e > testJedi {
+		return fmt.Sprintf("testEpisode(%d)", int(e))
+	}
+	return [...]string{"NEWHOPE", "EMPIRE", "JEDI"}[e-testNewHope]
+}
+
+type testRole string
+
+func TestTypeSystem_EnumValues_MapsGoConstants(t *testing.T) {
+	episodeType := graphql.NewEnum(graphql.EnumConfig{
+		Name:   "Episode",
+		Values: graphql.StringerEnumValues(testNewHope, testEmpire, testJedi),
+		Strict: true,
+	})
+	roleType := graphql.NewEnum(graphql.EnumConfig{
+		Name:   "Role",
+		Values: graphql.EnumValues(map[string]testRole{"ADMIN": "admin", "USER": "user"}),
+	})
+	var received []interface{}
+	echo := func(p graphql.ResolveParams) (interface{}, error) {
+		received = append(received, p.Args["value"])
+		return p.Args["value"], nil
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"episode": &graphql.Field{
+					Type:    episodeType,
+					Args:    graphql.FieldConfigArgument{"value": &graphql.ArgumentConfig{Type: episodeType}},
+					Resolve: echo,
+				},
+				"role": &graphql.Field{
+					Type:    roleType,
+					Args:    graphql.FieldConfigArgument{"value": &graphql.ArgumentConfig{Type: roleType}},
+					Resolve: echo,
+				},
+				"episodeFromInt": &graphql.Field{
+					Type: episodeType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return 6, nil
+					},
+				},
+				"roleFromString": &graphql.Field{
+					Type: roleType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return "user", nil
+					},
+				},
+				"unknownEpisode": &graphql.Field{
+					Type: episodeType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return testEpisode(42), nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  `query ($role: Role) { episode(value: EMPIRE) role(value: $role) episodeFromInt roleFromString unknownEpisode }`,
+		VariableValues: map[string]interface{}{"role": "ADMIN"},
+	})
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"episode":        "EMPIRE",
+			"role":           "ADMIN",
+			"episodeFromInt": "JEDI",
+			"roleFromString": "USER",
+			"unknownEpisode": nil,
+		},
+		Errors: []gqlerrors.FormattedError{
+			{
+				Message:   `Enum "Episode" cannot represent value of Go type graphql_test.testEpisode: testEpisode(42).`,
+				Locations: []location.SourceLocation{{Line: 1, Column: 95}},
+				Path:      []interface{}{"unknownEpisode"},
+			},
+		},
+	}
+	if !testutil.EqualResults(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+	if expected := []interface{}{testEmpire, testRole("admin")}; !reflect.DeepEqual(expected, received) {
+		t.Fatalf("Unexpected arguments, Diff: %v", testutil.Diff(expected, received))
+	}
+}
+
+func TestTypeSystem_EnumValues_DoesNotSerializeUncomparableValues(t *testing.T) {
+	colorType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Color",
+		Values: graphql.EnumValueConfigMap{
+			"RED": &graphql.EnumValueConfig{Value: 0},
+		},
+	})
+	for _, value := range []interface{}{[]interface{}{0}, map[string]interface{}{"RED": 0}} {
+		if serialized := colorType.Serialize(value); serialized != nil {
+			t.Fatalf("expected %v not to be serialized, got %v", value, serialized)
+		}
+	}
+}
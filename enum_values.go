@@ -0,0 +1,40 @@
+package graphql
+
+import "fmt"
+
+// EnumValues returns the values of an EnumConfig from Go constants, keyed by the names of their
+// enum values. The enum serializes the constants, and the values of the same kind convertible to
+// their type, to their names, and parses the names back to the constants:
+//
+//	type Episode int
+//
+//	const (
+//		NewHope Episode = iota + 4
+//		Empire
+//	)
+//
+//	var episodeEnum = graphql.NewEnum(graphql.EnumConfig{
+//		Name:   "Episode",
+//		Values: graphql.EnumValues(map[string]Episode{"NEWHOPE": NewHope, "EMPIRE": Empire}),
+//		Strict: true,
+//	})
+func EnumValues[T comparable](values map[string]T) EnumValueConfigMap {
+	configMap := EnumValueConfigMap{}
+	for name, value := range values {
+		configMap[name] = &EnumValueConfig{Value: value}
+	}
+	return configMap
+}
+
+// StringerEnumValues is like EnumValues for constants implementing fmt.Stringer, the names of
+// their enum values being the ones returned by their String method.
+func StringerEnumValues[T interface {
+	comparable
+	fmt.Stringer
+}](values ...T) EnumValueConfigMap {
+	configMap := EnumValueConfigMap{}
+	for _, value := range values {
+		configMap[value.String()] = &EnumValueConfig{Value: value}
+	}
+	return configMap
+}
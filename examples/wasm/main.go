@@ -0,0 +1,77 @@
+//go:build js && wasm
+
+// This example executes GraphQL queries in the browser. Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o graphql.wasm ./examples/wasm
+//
+// or with TinyGo, for a much smaller binary,
+//
+//	tinygo build -o graphql.wasm -target wasm ./examples/wasm
+//
+// and load it with the wasm_exec.js of the same toolchain. It defines a graphql(query, variables)
+// function returning the JSON encoded result.
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/fiatjaf/graphql"
+)
+
+var todos = []map[string]interface{}{
+	{"id": "1", "text": "Write a schema", "done": true},
+	{"id": "2", "text": "Run it in the browser", "done": false},
+}
+
+func main() {
+	todoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Todo",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"text": &graphql.Field{Type: graphql.String},
+			"done": &graphql.Field{Type: graphql.Boolean},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"todos": &graphql.Field{
+					Type: graphql.NewList(todoType),
+					Args: graphql.FieldConfigArgument{
+						"done": &graphql.ArgumentConfig{Type: graphql.Boolean},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						done, filtered := p.Args["done"].(bool)
+						var result []map[string]interface{}
+						for _, todo := range todos {
+							if !filtered || todo["done"] == done {
+								result = append(result, todo)
+							}
+						}
+						return result, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	js.Global().Set("graphql", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		params := graphql.Params{Schema: schema}
+		if len(args) > 0 {
+			params.RequestString = args[0].String()
+		}
+		if len(args) > 1 && args[1].Type() == js.TypeString {
+			json.Unmarshal([]byte(args[1].String()), &params.VariableValues)
+		}
+		result, _ := json.Marshal(graphql.Do(params))
+		return string(result)
+	}))
+
+	// the functions defined above are only called while the program runs
+	select {}
+}
@@ -0,0 +1,100 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// ExecutionEventKind is the kind of an ExecutionEvent.
+type ExecutionEventKind string
+
+const (
+	// ExecutionEventOperationStart is emitted when the execution of an operation starts, once its
+	// variables are coerced.
+	ExecutionEventOperationStart ExecutionEventKind = "operationStart"
+	// ExecutionEventOperationEnd is emitted when the execution of an operation ends.
+	ExecutionEventOperationEnd ExecutionEventKind = "operationEnd"
+	// ExecutionEventFieldStart is emitted before the resolver of a field is called.
+	ExecutionEventFieldStart ExecutionEventKind = "fieldStart"
+	// ExecutionEventFieldEnd is emitted when the resolver of a field returns, before its value is
+	// completed. The thunks it returns are called later.
+	ExecutionEventFieldEnd ExecutionEventKind = "fieldEnd"
+	// ExecutionEventError is emitted when an error is recorded for a field, which gets null.
+	ExecutionEventError ExecutionEventKind = "error"
+	// ExecutionEventNullBubbled is emitted when a non-null field or list item gets null, or fails,
+	// and again for every non-null position holding it that the null propagates to. Its Error is
+	// the error which caused the null.
+	ExecutionEventNullBubbled ExecutionEventKind = "nullBubbled"
+)
+
+// ExecutionEvent is an event of the execution of an operation, see WithExecutionEvents.
+type ExecutionEvent struct {
+	Kind ExecutionEventKind
+	Time time.Time
+
+	// Operation and Variables are those of the executed operation, set on the operation events.
+	Operation *ast.OperationDefinition
+	Variables map[string]interface{}
+
+	// Path is the path of the field or list item of the field, error and null events.
+	Path []interface{}
+	// ParentType and FieldName identify the field of the field events.
+	ParentType string
+	FieldName  string
+
+	// Error is the error of the error events, and of the field end events whose resolver failed.
+	Error error
+}
+
+// ExecutionEventFn receives the events of the executions of a request. It is called from the
+// goroutines executing the request, possibly at the same time from several of them.
+type ExecutionEventFn func(event ExecutionEvent)
+
+type executionEventsKey struct{}
+
+// WithExecutionEvents returns a context whose executions emit their events to fn, e.g. to
+// profile a request, draw its flamegraph or record it to replay it. The executions of the other
+// requests are left as they are. To receive the events on a channel, fn sends them to it.
+func WithExecutionEvents(ctx context.Context, fn ExecutionEventFn) context.Context {
+	return context.WithValue(ctx, executionEventsKey{}, fn)
+}
+
+// executionEvents returns the ExecutionEventFn of ctx, nil if it has none.
+func executionEvents(ctx context.Context) ExecutionEventFn {
+	fn, _ := ctx.Value(executionEventsKey{}).(ExecutionEventFn)
+	return fn
+}
+
+// emitFieldEvent emits an event of the field at path, if the execution emits its events.
+func (eCtx *executionContext) emitFieldEvent(kind ExecutionEventKind, parentType *Object, fieldName string, path *ResponsePath, err error) {
+	if eCtx.events == nil {
+		return
+	}
+	eCtx.events(ExecutionEvent{
+		Kind:       kind,
+		Time:       time.Now(),
+		Path:       path.AsArray(),
+		ParentType: parentType.Name(),
+		FieldName:  fieldName,
+		Error:      err,
+	})
+}
+
+// emitPathEvent emits an event of path, if the execution emits its events.
+func (eCtx *executionContext) emitPathEvent(kind ExecutionEventKind, path *ResponsePath, err error) {
+	if eCtx.events == nil {
+		return
+	}
+	eCtx.events(ExecutionEvent{Kind: kind, Time: time.Now(), Path: path.AsArray(), Error: err})
+}
+
+// emitOperationEvent emits an event of the operation, if the execution emits its events.
+func (eCtx *executionContext) emitOperationEvent(kind ExecutionEventKind) {
+	if eCtx.events == nil {
+		return
+	}
+	operation, _ := eCtx.Operation.(*ast.OperationDefinition)
+	eCtx.events(ExecutionEvent{Kind: kind, Time: time.Now(), Operation: operation, Variables: eCtx.VariableValues})
+}
@@ -0,0 +1,100 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+)
+
+func TestWithExecutionEvents_EmitsTheEventsOfTheRequest(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"hello": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{"name": &graphql.ArgumentConfig{Type: graphql.String}},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return "world", nil
+					},
+				},
+				"fails": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return nil, errors.New("failed")
+					},
+				},
+				"numbers": &graphql.Field{
+					Type: graphql.NewList(graphql.NewNonNull(graphql.Int)),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return []interface{}{1, nil}, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mutex sync.Mutex
+	var events []graphql.ExecutionEvent
+	ctx := graphql.WithExecutionEvents(context.Background(), func(event graphql.ExecutionEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, event)
+	})
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  `query Q($name: String = "") { hello(name: $name) fails numbers }`,
+		VariableValues: map[string]interface{}{"name": "x"},
+		Context:        ctx,
+	})
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %v", result.Errors)
+	}
+
+	if len(events) == 0 || events[0].Kind != graphql.ExecutionEventOperationStart ||
+		events[len(events)-1].Kind != graphql.ExecutionEventOperationEnd {
+		t.Fatalf("expected the events to be enclosed by the operation events, got %v", events)
+	}
+	start := events[0]
+	if start.Operation == nil || start.Operation.Name.Value != "Q" ||
+		!reflect.DeepEqual(start.Variables, map[string]interface{}{"name": "x"}) {
+		t.Fatalf("unexpected operation start event %+v", start)
+	}
+
+	described := map[string]bool{}
+	for i, event := range events {
+		if i > 0 && event.Time.Before(events[i-1].Time) {
+			t.Fatalf("expected the events in order, got %v", events)
+		}
+		description := fmt.Sprintf("%s %v", event.Kind, event.Path)
+		if event.FieldName != "" {
+			description += " " + event.ParentType + "." + event.FieldName
+		}
+		if event.Error != nil {
+			description += ": " + event.Error.Error()
+		}
+		described[description] = true
+	}
+	expected := []string{
+		"fieldStart [hello] Query.hello",
+		"fieldEnd [hello] Query.hello",
+		"fieldStart [fails] Query.fails",
+		"fieldEnd [fails] Query.fails: failed",
+		"error [fails]: failed",
+		"nullBubbled [numbers 1]: Cannot return null for non-nullable field Query.numbers.",
+		"error [numbers]: Cannot return null for non-nullable field Query.numbers.",
+	}
+	for _, description := range expected {
+		if !described[description] {
+			t.Errorf("expected the event %q, got %v", description, described)
+		}
+	}
+}
@@ -7,11 +7,28 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/fiatjaf/graphql/gqlerrors"
 	"github.com/fiatjaf/graphql/language/ast"
 )
 
+// BeginMutationFn is called with the context of the execution before the root fields of a mutation
+// are executed, e.g. to begin a database transaction. The root fields are resolved with the
+// returned context, and once they all were commit is called with the first error of a root field
+// that ended up null, raised by it or by one of its subfields, or nil if none failed, so the
+// transaction can be committed or rolled back. The error returned by commit is added to the errors
+// of the result.
+type BeginMutationFn func(ctx context.Context) (txCtx context.Context, commit func(error) error)
+
+// RedactFn is called with the value of every field of the result once it is completed, with the
+// ResolveInfo of the field giving its path and types, and returns the value to put in the result
+// instead, e.g. to mask personal data from the users not allowed to see it. The value is the one
+// sent to the client: serialized for scalars and enums, and a map or a list for objects and lists.
+// Null values are not passed to it, and replacing the value of a non-null field with nil isn't
+// checked.
+type RedactFn func(ctx context.Context, value interface{}, info ResolveInfo) interface{}
+
 type ExecuteParams struct {
 	Schema        Schema
 	Root          interface{}
@@ -22,16 +39,27 @@ type ExecuteParams struct {
 	// Context may be provided to pass application-specific per-request
 	// information to resolve functions.
 	Context context.Context
+
+	// BeginMutation, when set, is called around the execution of the root fields of a mutation
+	// instead of the one of the schema.
+	BeginMutation BeginMutationFn
+
+	// Redact, when set, can replace the values of the fields of the result, see RedactFn.
+	Redact RedactFn
+
+	// PreserveFieldOrder makes the data be encoded in the order of the query, see
+	// Params.PreserveFieldOrder.
+	PreserveFieldOrder bool
 }
 
 func Execute(p ExecuteParams) (result *Result) {
 	// Use background context if no context was provided
-	ctx := p.Context
-	if ctx == nil {
-		ctx = context.Background()
+	if p.Context == nil {
+		p.Context = context.Background()
 	}
 	// run executionDidStart functions from extensions
 	extErrs, executionFinishFn := handleExtensionsExecutionDidStart(&p)
+	ctx := p.Context
 	if len(extErrs) != 0 {
 		return &Result{
 			Errors: extErrs,
@@ -47,9 +75,20 @@ func Execute(p ExecuteParams) (result *Result) {
 		addExtensionResults(&p, result)
 	}()
 
+	// wait for a slot of the schema's execution budget, released once the execution is over
+	budget := p.Schema.executionBudget
+	if budget != nil {
+		if err := budget.Acquire(ctx); err != nil {
+			return &Result{Errors: gqlerrors.FormatErrors(err)}
+		}
+	}
+
 	resultChannel := make(chan *Result, 2)
 
-	go func() {
+	Go(ctx, "execute", func() {
+		if budget != nil {
+			defer budget.Release()
+		}
 		result := &Result{}
 
 		exeContext, err := buildExecutionContext(buildExecutionCtxParams{
@@ -60,19 +99,28 @@ func Execute(p ExecuteParams) (result *Result) {
 			Args:          p.Args,
 			Result:        result,
 			Context:       p.Context,
+			BeginMutation: p.BeginMutation,
+			Redact:        p.Redact,
 		})
 		if err != nil {
-			result.Errors = append(result.Errors, gqlerrors.FormatError(err.(error)))
+			result.Errors = append(result.Errors, formatVariableErrors(err)...)
 			resultChannel <- result
 			return
 		}
 
-		resultChannel <- executeOperation(executeOperationParams{
+		exeContext.emitOperationEvent(ExecutionEventOperationStart)
+		result = executeOperation(executeOperationParams{
 			ExecutionContext: exeContext,
 			Root:             p.Root,
 			Operation:        exeContext.Operation,
 		})
-	}()
+		exeContext.emitOperationEvent(ExecutionEventOperationEnd)
+		if p.PreserveFieldOrder {
+			result.fieldOrder = newFieldOrder(exeContext.Operation.GetSelectionSet(), exeContext.Fragments)
+		}
+		addFieldCacheExtension(result, exeContext.fieldCache)
+		resultChannel <- result
+	})
 
 	select {
 	case <-ctx.Done():
@@ -92,6 +140,8 @@ type buildExecutionCtxParams struct {
 	Args          map[string]interface{}
 	Result        *Result
 	Context       context.Context
+	BeginMutation BeginMutationFn
+	Redact        RedactFn
 }
 
 type executionContext struct {
@@ -102,6 +152,24 @@ type executionContext struct {
 	VariableValues map[string]interface{}
 	Errors         []gqlerrors.FormattedError
 	Context        context.Context
+	BeginMutation  BeginMutationFn
+	Redact         RedactFn
+
+	// subFields caches the sub-fields collected by collectSubFields
+	subFields map[subFieldsKey]map[string][]*ast.Field
+	// skipIncludeVariables is the fingerprint of the variables of the @skip and @include directives,
+	// computed once the schema's collectFieldsCache needs it
+	skipIncludeVariables *string
+	// inclusions are the evaluations of the @skip and @include directives of the request
+	inclusions *inclusions
+	// fieldCache holds the values of the cached fields, once one is resolved
+	fieldCache *fieldCache
+	// cachePolicy is restricted by the fields resolved, when the context has one
+	cachePolicy *CachePolicy
+	// cancelled is set once the error of the cancelled context of the execution is reported
+	cancelled bool
+	// events receives the events of the execution, when the context has an ExecutionEventFn
+	events ExecutionEventFn
 }
 
 func buildExecutionContext(p buildExecutionCtxParams) (*executionContext, error) {
@@ -146,7 +214,17 @@ func buildExecutionContext(p buildExecutionCtxParams) (*executionContext, error)
 	eCtx.Root = p.Root
 	eCtx.Operation = operation
 	eCtx.VariableValues = variableValues
+	eCtx.inclusions = newInclusions(variableValues)
 	eCtx.Context = p.Context
+	if p.Context != nil {
+		eCtx.cachePolicy, _ = p.Context.Value(cachePolicyKey{}).(*CachePolicy)
+		eCtx.events = executionEvents(p.Context)
+	}
+	eCtx.BeginMutation = p.BeginMutation
+	eCtx.Redact = p.Redact
+	if eCtx.BeginMutation == nil {
+		eCtx.BeginMutation = p.Schema.beginMutation
+	}
 	return eCtx, nil
 }
 
@@ -176,7 +254,10 @@ func executeOperation(p executeOperationParams) *Result {
 	}
 
 	if p.Operation.GetOperation() == ast.OperationTypeMutation {
-		return executeFieldsSerially(executeFieldsParams)
+		if p.ExecutionContext.BeginMutation == nil {
+			return executeFieldsSerially(executeFieldsParams)
+		}
+		return executeMutationInTransaction(executeFieldsParams)
 	}
 	return executeFields(executeFieldsParams)
 }
@@ -264,6 +345,38 @@ func executeFieldsSerially(p executeFieldsParams) *Result {
 	}
 }
 
+// executeMutationInTransaction executes the root fields of a mutation serially between the calls
+// to the BeginMutation hook and the commit function it returns.
+func executeMutationInTransaction(p executeFieldsParams) *Result {
+	eCtx := p.ExecutionContext
+	txCtx, commit := eCtx.BeginMutation(eCtx.Context)
+	if txCtx != nil {
+		eCtx.Context = txCtx
+	}
+
+	result := executeFieldsSerially(p)
+
+	// the mutation failed when a root field ended up null because of an error raised by it or
+	// by one of its subfields, the paths of which start with its response name
+	data, _ := result.Data.(map[string]interface{})
+	var rootErr error
+	for _, err := range result.Errors {
+		if len(err.Path) == 0 {
+			continue
+		}
+		if responseName, ok := err.Path[0].(string); ok && data[responseName] == nil {
+			rootErr = err
+			break
+		}
+	}
+	if commit != nil {
+		if err := commit(rootErr); err != nil {
+			result.Errors = append(result.Errors, gqlerrors.FormatError(err))
+		}
+	}
+	return result
+}
+
 // Implements the "Evaluating selection sets" section of the spec for "read" mode.
 func executeFields(p executeFieldsParams) *Result {
 	finalResults := executeSubFields(p)
@@ -413,7 +526,7 @@ func collectFields(p collectFieldsParams) (fields map[string][]*ast.Field) {
 	for _, iSelection := range p.SelectionSet.Selections {
 		switch selection := iSelection.(type) {
 		case *ast.Field:
-			if !shouldIncludeNode(p.ExeContext, selection.Directives) {
+			if !p.ExeContext.inclusions.includes(selection, selection.Directives) {
 				continue
 			}
 			name := getFieldEntryKey(selection)
@@ -423,7 +536,7 @@ func collectFields(p collectFieldsParams) (fields map[string][]*ast.Field) {
 			fields[name] = append(fields[name], selection)
 		case *ast.InlineFragment:
 
-			if !shouldIncludeNode(p.ExeContext, selection.Directives) ||
+			if !p.ExeContext.inclusions.includes(selection, selection.Directives) ||
 				!doesFragmentConditionMatch(p.ExeContext, selection, p.RuntimeType) {
 				continue
 			}
@@ -441,7 +554,7 @@ func collectFields(p collectFieldsParams) (fields map[string][]*ast.Field) {
 				fragName = selection.Name.Value
 			}
 			if visited, ok := p.VisitedFragmentNames[fragName]; (ok && visited) ||
-				!shouldIncludeNode(p.ExeContext, selection.Directives) {
+				!p.ExeContext.inclusions.includes(selection, selection.Directives) {
 				continue
 			}
 			p.VisitedFragmentNames[fragName] = true
@@ -470,7 +583,7 @@ func collectFields(p collectFieldsParams) (fields map[string][]*ast.Field) {
 
 // Determines if a field should be included based on the @include and @skip
 // directives, where @skip has higher precedence than @include.
-func shouldIncludeNode(eCtx *executionContext, directives []*ast.Directive) bool {
+func shouldIncludeNode(variableValues map[string]interface{}, directives []*ast.Directive) bool {
 	var (
 		skipAST, includeAST *ast.Directive
 		argValues           map[string]interface{}
@@ -488,13 +601,13 @@ func shouldIncludeNode(eCtx *executionContext, directives []*ast.Directive) bool
 	}
 	// precedence: skipAST > includeAST
 	if skipAST != nil {
-		argValues = getArgumentValues(SkipDirective.Args, skipAST.Arguments, eCtx.VariableValues)
+		argValues = getArgumentValues(SkipDirective.Args, skipAST.Arguments, variableValues)
 		if skipIf, ok := argValues["if"].(bool); ok && skipIf {
 			return false // excluded selectionSet's fields
 		}
 	}
 	if includeAST != nil {
-		argValues = getArgumentValues(IncludeDirective.Args, includeAST.Arguments, eCtx.VariableValues)
+		argValues = getArgumentValues(IncludeDirective.Args, includeAST.Arguments, variableValues)
 		if includeIf, ok := argValues["if"].(bool); ok && !includeIf {
 			return false // excluded selectionSet's fields
 		}
@@ -577,6 +690,7 @@ func handleFieldError(
 ) {
 	lerr := NewLocatedErrorWithPath(err, fieldNodes, path.AsArray())
 	eCtx.Errors = append(eCtx.Errors, gqlerrors.FormatError(lerr))
+	eCtx.emitPathEvent(ExecutionEventError, path, lerr)
 }
 
 // Resolves the field on the given source object. In particular, this
@@ -604,15 +718,38 @@ func resolveField(
 		return nil, resultState
 	}
 	returnType = fieldDef.Type
+
+	// once the context is done, e.g. when the client disconnected, no more resolvers are called and
+	// the error is reported once
+	if err := eCtx.Context.Err(); err != nil {
+		if !eCtx.cancelled {
+			eCtx.cancelled = true
+			handleFieldError(err, FieldASTsToNodeASTs(fieldASTs), path, returnType, eCtx)
+		}
+		return nil, resultState
+	}
+	if eCtx.cachePolicy != nil {
+		restrictCachePolicy(eCtx.cachePolicy, fieldDef, path)
+	}
 	resolveFn := fieldDef.Resolve
 	if resolveFn == nil {
 		resolveFn = DefaultResolveFn
 	}
+	if fieldDef.Timeout > 0 || fieldDef.Retry != nil {
+		resolveFn = withResolvePolicy(fieldDef, resolveFn)
+	}
 
 	// Build a map of arguments from the field.arguments AST, using the
 	// variables scope to fulfill any variable references.
 	// TODO: find a way to memoize, in case this field is within a List type.
 	args := getArgumentValues(fieldDef.Args, fieldAST.Arguments, eCtx.VariableValues)
+	if eCtx.Schema.idCodec != nil {
+		var err error
+		if args, err = decodeArgumentIDs(eCtx.Schema.idCodec, fieldDef.Args, args); err != nil {
+			handleFieldError(err, FieldASTsToNodeASTs(fieldASTs), path, returnType, eCtx)
+			return nil, resultState
+		}
+	}
 
 	info := ResolveInfo{
 		FieldName:      fieldName,
@@ -625,25 +762,46 @@ func resolveField(
 		RootValue:      eCtx.Root,
 		Operation:      eCtx.Operation,
 		VariableValues: eCtx.VariableValues,
+		inclusions:     eCtx.inclusions,
 	}
 
-	extErrs, resolveFieldFinishFn := handleExtensionsResolveFieldDidStart(eCtx.Schema.extensions, eCtx, &info)
+	if err := authorize(eCtx.Context, fieldDef, ResolveParams{
+		Source:  source,
+		Args:    args,
+		Info:    info,
+		Context: eCtx.Context,
+	}); err != nil {
+		handleFieldError(err, FieldASTsToNodeASTs(fieldASTs), path, returnType, eCtx)
+		return nil, resultState
+	}
+
+	extErrs, fieldCtx, resolveFieldFinishFn := handleExtensionsResolveFieldDidStart(eCtx.Schema.extensions, eCtx.Context, &info)
 	if len(extErrs) != 0 {
 		eCtx.Errors = append(eCtx.Errors, extErrs...)
 	}
 
 	var resolveFnError error
-	result, resolveFnError = resolveFn(ResolveParams{
+	resolveParams := ResolveParams{
 		Source:  source,
 		Args:    args,
 		Info:    info,
-		Context: eCtx.Context,
-	})
+		Context: fieldCtx,
+	}
+	eCtx.emitFieldEvent(ExecutionEventFieldStart, parentType, fieldName, path, nil)
+	if fieldDef.Cache != nil {
+		result, resolveFnError = resolveCached(eCtx, fieldDef.Cache, resolveFn, resolveParams)
+	} else {
+		result, resolveFnError = resolveWithBudget(eCtx.Schema.fieldBudget, resolveFn, resolveParams)
+	}
+	eCtx.emitFieldEvent(ExecutionEventFieldEnd, parentType, fieldName, path, resolveFnError)
 	extErrs = resolveFieldFinishFn(result, resolveFnError)
 	if len(extErrs) != 0 {
 		eCtx.Errors = append(eCtx.Errors, extErrs...)
 	}
 	if resolveFnError != nil {
+		if _, ok := returnType.(*NonNull); ok {
+			eCtx.emitPathEvent(ExecutionEventNullBubbled, path, resolveFnError)
+		}
 		handleFieldError(resolveFnError, FieldASTsToNodeASTs(fieldASTs), path, returnType, eCtx)
 		return nil, resultState
 	}
@@ -652,9 +810,27 @@ func resolveField(
 	if err != nil {
 		handleFieldError(err, FieldASTsToNodeASTs(fieldASTs), path, returnType, eCtx)
 	}
+	if eCtx.Redact != nil && completed != nil {
+		completed = eCtx.Redact(eCtx.Context, completed, info)
+	}
 	return completed, resultState
 }
 
+// thunkCompletion defers the completion of a thunk. It is kept apart from completeValue so that the
+// closure doesn't move the arguments of every completeValue call to the heap.
+func thunkCompletion(
+	eCtx *executionContext,
+	returnType Type,
+	fieldASTs []*ast.Field,
+	info ResolveInfo,
+	path *ResponsePath,
+	result interface{},
+) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		return completeThunkValueCatchingError(eCtx, returnType, fieldASTs, info, path, result)
+	}
+}
+
 func completeValue(
 	eCtx *executionContext,
 	returnType Type,
@@ -664,17 +840,19 @@ func completeValue(
 	result interface{},
 ) (interface{}, error) {
 	resultVal := reflect.ValueOf(result)
-	if resultVal.IsValid() && resultVal.Kind() == reflect.Func {
-		return func() (interface{}, error) {
-			return completeThunkValueCatchingError(eCtx, returnType, fieldASTs, info, path, result)
-		}, nil
+	if resultVal.IsValid() && resultVal.Kind() == reflect.Func && !isListIterator(result) {
+		return thunkCompletion(eCtx, returnType, fieldASTs, info, path, result), nil
 	}
 
+	// null the unset sql.Null* and NullableValuer values, and complete the value of the set ones
+	result = unwrapNullable(result)
+
 	// If field type is NonNull, complete for inner type, and throw field error
 	// if result is null.
 	if returnType, ok := returnType.(*NonNull); ok {
 		completed, err := completeValue(eCtx, returnType.OfType, fieldASTs, info, path, result)
 		if err != nil {
+			eCtx.emitPathEvent(ExecutionEventNullBubbled, path, err)
 			return nil, err
 		}
 		if completed == nil {
@@ -683,6 +861,7 @@ func completeValue(
 				FieldASTsToNodeASTs(fieldASTs),
 				path.AsArray(),
 			)
+			eCtx.emitPathEvent(ExecutionEventNullBubbled, path, err)
 			return nil, err
 		}
 		return completed, nil
@@ -701,10 +880,18 @@ func completeValue(
 	// If field type is a leaf type, Scalar or Enum, serialize to a valid value,
 	// returning null if serialization is not possible.
 	if returnType, ok := returnType.(*Scalar); ok {
-		return completeLeafValue(returnType, result), nil
+		completed := completeLeafValue(returnType, result)
+		if returnType == ID && completed != nil && eCtx.Schema.idCodec != nil {
+			return encodeID(eCtx.Schema.idCodec, info.ParentType.Name(), completed), nil
+		}
+		return completed, nil
 	}
 	if returnType, ok := returnType.(*Enum); ok {
-		return completeLeafValue(returnType, result), nil
+		completed := completeLeafValue(returnType, result)
+		if completed == nil && returnType.enumConfig.Strict {
+			return nil, fmt.Errorf(`Enum "%v" cannot represent value of Go type %T: %v.`, returnType, result, result)
+		}
+		return completed, nil
 	}
 
 	// If field type is an abstract type, Interface or Union, determine the
@@ -789,8 +976,9 @@ func completeAbstractValue(
 
 	err := invariantf(
 		runtimeType != nil,
-		`Abstract type %v must resolve to an Object type at runtime for field %v.%v with value "%v", received "%v".`,
-		returnType, info.ParentType, info.FieldName, result, runtimeType,
+		`Abstract type %v must resolve to an Object type at runtime for field %v.%v with a value of Go type %T, received "%v". `+
+			`Either %v should provide a ResolveType function or each of its possible types (%v) should provide an IsTypeOf function.`,
+		returnType, info.ParentType, info.FieldName, result, runtimeType, returnType, possibleTypeNames(&eCtx.Schema, returnType),
 	)
 	if err != nil {
 		return nil, err
@@ -799,13 +987,25 @@ func completeAbstractValue(
 	if !eCtx.Schema.IsPossibleType(returnType, runtimeType) {
 		return nil,
 			gqlerrors.NewFormattedError(
-				fmt.Sprintf(`Runtime Object type "%v" is not a possible type for "%v".`, runtimeType, returnType),
+				fmt.Sprintf(`Runtime Object type "%v" resolved for field %v.%v with a value of Go type %T is not a possible type for "%v", possible types: %v.`,
+					runtimeType, info.ParentType, info.FieldName, result, returnType, possibleTypeNames(&eCtx.Schema, returnType)),
 			)
 	}
 
 	return completeObjectValue(eCtx, runtimeType, fieldASTs, info, path, result)
 }
 
+// possibleTypeNames lists the quoted names of the possible types of abstractType, for error
+// messages.
+func possibleTypeNames(schema *Schema, abstractType Abstract) string {
+	names := []string{}
+	for _, possibleType := range schema.PossibleTypes(abstractType) {
+		names = append(names, fmt.Sprintf(`"%v"`, possibleType.Name()))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
 // completeObjectValue complete an Object value by executing all sub-selections.
 func completeObjectValue(
 	eCtx *executionContext,
@@ -832,6 +1032,60 @@ func completeObjectValue(
 	}
 
 	// Collect sub-fields to execute to complete this value.
+	subFieldASTs := collectSubFields(eCtx, returnType, fieldASTs)
+	executeFieldsParams := executeFieldsParams{
+		ExecutionContext: eCtx,
+		ParentType:       returnType,
+		Source:           result,
+		Fields:           subFieldASTs,
+		Path:             path,
+	}
+	return executeSubFields(executeFieldsParams), nil
+}
+
+// completeLeafValue complete a leaf value (Scalar / Enum) by serializing to a valid value, returning nil if serialization is not possible.
+func completeLeafValue(returnType Leaf, result interface{}) interface{} {
+	serializedResult := returnType.Serialize(result)
+	if isNullish(serializedResult) {
+		return nil
+	}
+	return serializedResult
+}
+
+// subFieldsKey identifies the fields of a selection collected for a runtime type. The items of a
+// list share the same fieldASTs slice, so its first element and length identify it.
+type subFieldsKey struct {
+	runtimeType *Object
+	fieldASTs   **ast.Field
+	length      int
+}
+
+// collectSubFields collects the sub-fields of fieldASTs for the runtime type, once per request: the
+// items of a list reuse the fields collected for the first one.
+func collectSubFields(eCtx *executionContext, runtimeType *Object, fieldASTs []*ast.Field) map[string][]*ast.Field {
+	var key subFieldsKey
+	if len(fieldASTs) > 0 {
+		key = subFieldsKey{runtimeType, &fieldASTs[0], len(fieldASTs)}
+		if subFieldASTs, ok := eCtx.subFields[key]; ok {
+			return subFieldASTs
+		}
+	}
+
+	// the collections of the previous executions of the document are kept by the schema, if enabled
+	cache := eCtx.Schema.collectFieldsCache
+	var cacheKey collectFieldsCacheKey
+	if cache != nil && len(fieldASTs) > 0 {
+		if eCtx.skipIncludeVariables == nil {
+			variables := skipIncludeVariables(eCtx)
+			eCtx.skipIncludeVariables = &variables
+		}
+		cacheKey = collectFieldsCacheKey{runtimeType, fieldASTs[0], *eCtx.skipIncludeVariables}
+		if subFieldASTs, ok := cache.get(cacheKey, fieldASTs); ok {
+			eCtx.storeSubFields(key, subFieldASTs)
+			return subFieldASTs
+		}
+	}
+
 	subFieldASTs := map[string][]*ast.Field{}
 	visitedFragmentNames := map[string]bool{}
 	for _, fieldAST := range fieldASTs {
@@ -842,7 +1096,7 @@ func completeObjectValue(
 		if selectionSet != nil {
 			innerParams := collectFieldsParams{
 				ExeContext:           eCtx,
-				RuntimeType:          returnType,
+				RuntimeType:          runtimeType,
 				SelectionSet:         selectionSet,
 				Fields:               subFieldASTs,
 				VisitedFragmentNames: visitedFragmentNames,
@@ -850,23 +1104,21 @@ func completeObjectValue(
 			subFieldASTs = collectFields(innerParams)
 		}
 	}
-	executeFieldsParams := executeFieldsParams{
-		ExecutionContext: eCtx,
-		ParentType:       returnType,
-		Source:           result,
-		Fields:           subFieldASTs,
-		Path:             path,
+
+	if len(fieldASTs) > 0 {
+		eCtx.storeSubFields(key, subFieldASTs)
+		if cache != nil {
+			cache.put(cacheKey, fieldASTs, subFieldASTs)
+		}
 	}
-	return executeSubFields(executeFieldsParams), nil
+	return subFieldASTs
 }
 
-// completeLeafValue complete a leaf value (Scalar / Enum) by serializing to a valid value, returning nil if serialization is not possible.
-func completeLeafValue(returnType Leaf, result interface{}) interface{} {
-	serializedResult := returnType.Serialize(result)
-	if isNullish(serializedResult) {
-		return nil
+func (eCtx *executionContext) storeSubFields(key subFieldsKey, subFieldASTs map[string][]*ast.Field) {
+	if eCtx.subFields == nil {
+		eCtx.subFields = map[subFieldsKey]map[string][]*ast.Field{}
 	}
-	return serializedResult
+	eCtx.subFields[key] = subFieldASTs
 }
 
 // completeListValue complete a list value by completing each item in the list with the inner type
@@ -878,6 +1130,43 @@ func completeListValue(
 	path *ResponsePath,
 	result interface{},
 ) (interface{}, error) {
+	itemType := returnType.OfType
+	completeItem := func(i int, val interface{}) (interface{}, error) {
+		fieldPath := path.WithKey(i)
+		completedItem, err := completeValue(eCtx, itemType, fieldASTs, info, fieldPath, val)
+		if err != nil {
+			if _, ok := itemType.(*NonNull); ok {
+				return nil, err
+			}
+			// the error of a nullable item only nulls that item
+			handleFieldError(err, FieldASTsToNodeASTs(fieldASTs), fieldPath, itemType, eCtx)
+			completedItem = nil
+		}
+		return completedItem, nil
+	}
+
+	// channels and iterators are completed item by item as they produce them
+	if isListStream(result) {
+		completedResults := []interface{}{}
+		var err error
+		eachListItem(eCtx.Context, result, func(val interface{}) bool {
+			var completedItem interface{}
+			completedItem, err = completeItem(len(completedResults), val)
+			if err != nil {
+				return false
+			}
+			completedResults = append(completedResults, completedItem)
+			return true
+		})
+		if err == nil {
+			err = eCtx.Context.Err()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return completedResults, nil
+	}
+
 	resultVal := reflect.ValueOf(result)
 	if resultVal.Kind() == reflect.Ptr {
 		resultVal = resultVal.Elem()
@@ -894,21 +1183,64 @@ func completeListValue(
 		return nil, err
 	}
 
-	itemType := returnType.OfType
 	completedResults := make([]interface{}, 0, resultVal.Len())
 	for i := 0; i < resultVal.Len(); i++ {
-		val := resultVal.Index(i).Interface()
-		fieldPath := path.WithKey(i)
-		completedItem, err := completeValue(eCtx, itemType, fieldASTs, info, fieldPath, val)
+		completedItem, err := completeItem(i, resultVal.Index(i).Interface())
 		if err != nil {
 			return nil, err
 		}
-
 		completedResults = append(completedResults, completedItem)
 	}
 	return completedResults, nil
 }
 
+// isListIterator reports whether value is an iterator function like
+// func(yield func(item interface{}) bool), for items of any type.
+func isListIterator(value interface{}) bool {
+	t := reflect.TypeOf(value)
+	if t == nil || t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 0 {
+		return false
+	}
+	yield := t.In(0)
+	return yield.Kind() == reflect.Func && yield.NumIn() == 1 &&
+		yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool
+}
+
+// isListStream reports whether value is a channel or an iterator function whose items can be
+// completed as they are received, without holding the whole list in memory.
+func isListStream(value interface{}) bool {
+	t := reflect.TypeOf(value)
+	if t != nil && t.Kind() == reflect.Chan {
+		return t.ChanDir()&reflect.RecvDir != 0
+	}
+	return isListIterator(value)
+}
+
+// eachListItem calls fn with each item received from a channel or yielded by an iterator
+// function, until fn returns false, the channel is closed, the iterator returns or ctx is done.
+func eachListItem(ctx context.Context, value interface{}, fn func(item interface{}) bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Chan {
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: v},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		}
+		for {
+			chosen, item, ok := reflect.Select(cases)
+			if chosen != 0 || !ok || !fn(item.Interface()) {
+				return
+			}
+		}
+	}
+
+	done := false
+	yield := reflect.MakeFunc(v.Type().In(0), func(args []reflect.Value) []reflect.Value {
+		done = done || ctx.Err() != nil || !fn(args[0].Interface())
+		return []reflect.Value{reflect.ValueOf(!done)}
+	})
+	v.Call([]reflect.Value{yield})
+}
+
 // defaultResolveTypeFn If a resolveType function is not given, then a default resolve behavior is
 // used which tests each possible type for the abstract type by calling
 // isTypeOf for the object being coerced, returning the first type that matches.
@@ -936,6 +1268,46 @@ type FieldResolver interface {
 	Resolve(p ResolveParams) (interface{}, error)
 }
 
+// structFieldKey identifies the lookup of a GraphQL field name in a struct type.
+type structFieldKey struct {
+	structType reflect.Type
+	fieldName  string
+}
+
+// structFieldIndexes caches the results of structFieldIndex.
+var structFieldIndexes sync.Map // structFieldKey -> int
+
+// structFieldIndex returns the index of the field of the struct type resolving the GraphQL field
+// name, or -1: the first one whose name matches case insensitively or whose "json" or "graphql" tag
+// names it. The lookups are cached, so resolving many values of the same type doesn't scan and
+// parse the tags of its fields each time.
+func structFieldIndex(t reflect.Type, fieldName string) int {
+	key := structFieldKey{t, fieldName}
+	if i, ok := structFieldIndexes.Load(key); ok {
+		return i.(int)
+	}
+
+	index := -1
+	for i := 0; i < t.NumField(); i++ {
+		typeField := t.Field(i)
+		// try matching the field name first
+		if strings.EqualFold(typeField.Name, fieldName) {
+			index = i
+			break
+		}
+		checkTag := func(tagName string) bool {
+			name, _, _ := strings.Cut(typeField.Tag.Get(tagName), ",")
+			return name == fieldName
+		}
+		if checkTag("json") || checkTag("graphql") {
+			index = i
+			break
+		}
+	}
+	structFieldIndexes.Store(key, index)
+	return index
+}
+
 // DefaultResolveFn If a resolve function is not given, then a default resolve behavior is used
 // which takes the property of the source object of the same name as the field
 // and returns it as the result, or if it's a function, returns the result
@@ -956,30 +1328,8 @@ func DefaultResolveFn(p ResolveParams) (interface{}, error) {
 	}
 
 	if sourceVal.Type().Kind() == reflect.Struct {
-		for i := 0; i < sourceVal.NumField(); i++ {
-			valueField := sourceVal.Field(i)
-			typeField := sourceVal.Type().Field(i)
-			// try matching the field name first
-			if strings.EqualFold(typeField.Name, p.Info.FieldName) {
-				return valueField.Interface(), nil
-			}
-			tag := typeField.Tag
-			checkTag := func(tagName string) bool {
-				t := tag.Get(tagName)
-				tOptions := strings.Split(t, ",")
-				if len(tOptions) == 0 {
-					return false
-				}
-				if tOptions[0] != p.Info.FieldName {
-					return false
-				}
-				return true
-			}
-			if checkTag("json") || checkTag("graphql") {
-				return valueField.Interface(), nil
-			} else {
-				continue
-			}
+		if i := structFieldIndex(sourceVal.Type(), p.Info.FieldName); i >= 0 {
+			return sourceVal.Field(i).Interface(), nil
 		}
 		return nil, nil
 	}
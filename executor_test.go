@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -1891,6 +1892,66 @@ func TestContextDeadline(t *testing.T) {
 	}
 }
 
+func TestContextCancellation_StopsCallingResolvers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls int32
+	done := make(chan struct{})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"noop": &graphql.Field{Type: graphql.String}},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"first": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						// e.g. the client disconnected
+						cancel()
+						return "first", nil
+					},
+				},
+				"second": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						atomic.AddInt32(&calls, 1)
+						return "second", nil
+					},
+				},
+				"last": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						defer close(done)
+						atomic.AddInt32(&calls, 1)
+						return "last", nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error, got: %v", err)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: "mutation { first second last }",
+		Context:       ctx,
+	})
+	if len(result.Errors) != 1 || result.Errors[0].Message != context.Canceled.Error() {
+		t.Fatalf("expected the cancellation error, got %+v", result)
+	}
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 0 {
+		t.Fatalf("expected no resolver to be called after the cancellation, got %d calls", calls)
+	}
+}
+
 func TestThunkResultsProcessedCorrectly(t *testing.T) {
 	barType := graphql.NewObject(graphql.ObjectConfig{
 		Name: "Bar",
@@ -2394,3 +2455,180 @@ func TestQuery_OriginalErrorPanic(t *testing.T) {
 		t.Fatalf("unexpected error: %v", reflect.TypeOf(err))
 	}
 }
+
+func TestRedactReplacesFieldValues(t *testing.T) {
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"name":  &graphql.Field{Type: graphql.String},
+			"email": &graphql.Field{Type: graphql.String},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"users": &graphql.Field{
+					Type: graphql.NewList(userType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return []interface{}{
+							map[string]interface{}{"name": "Alice", "email": "alice@example.com"},
+							map[string]interface{}{"name": "Bob"},
+						}, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var redacted []string
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ users { name mail: email } }`,
+		Redact: func(ctx context.Context, value interface{}, info graphql.ResolveInfo) interface{} {
+			if info.ParentType.Name() == "User" && info.FieldName == "email" {
+				redacted = append(redacted, fmt.Sprintf("%v %v", info.Path.AsArray(), info.ReturnType))
+				return "***"
+			}
+			return value
+		},
+	})
+	if len(result.Errors) != 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	expected := map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"name": "Alice", "mail": "***"},
+			map[string]interface{}{"name": "Bob", "mail": nil},
+		},
+	}
+	if !reflect.DeepEqual(expected, result.Data) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result.Data))
+	}
+	if !reflect.DeepEqual([]string{"[users 0 mail] String"}, redacted) {
+		t.Fatalf("Unexpected redacted fields: %v", redacted)
+	}
+}
+
+func TestInstrumentFieldWrapsResolvers(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"a": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return "a", nil
+					},
+				},
+				"b": &graphql.Field{Type: graphql.String},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	suffix := func(suffix string) graphql.FieldMiddleware {
+		return func(next graphql.FieldResolveFn) graphql.FieldResolveFn {
+			return func(p graphql.ResolveParams) (interface{}, error) {
+				value, err := next(p)
+				return fmt.Sprintf("%v%v", value, suffix), err
+			}
+		}
+	}
+	if err := schema.InstrumentField("Query", "", suffix("-all")); err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.InstrumentField("Query", "a", suffix("-a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.InstrumentField("Query", "c", suffix("-c")); err == nil || err.Error() != `Cannot instrument "Query.c": no such field.` {
+		t.Fatalf("expected an error for an unknown field, got %v", err)
+	}
+	if err := schema.InstrumentField("String", "", suffix("-s")); err == nil {
+		t.Fatal("expected an error for a scalar type")
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ a b }`,
+		RootObject:    map[string]interface{}{"b": "b"},
+	})
+	expected := map[string]interface{}{"a": "a-all-a", "b": "b-all"}
+	if !reflect.DeepEqual(expected, result.Data) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result.Data))
+	}
+}
+
+func TestCollectFieldsCacheSize_ReusesCollectionsAcrossExecutions(t *testing.T) {
+	type dog struct{ Name string }
+	type cat struct{ Name string }
+	dogType := graphql.NewObject(graphql.ObjectConfig{
+		Name:     "Dog",
+		Fields:   graphql.Fields{"name": &graphql.Field{Type: graphql.String}, "barks": &graphql.Field{Type: graphql.Boolean}},
+		IsTypeOf: func(p graphql.IsTypeOfParams) bool { _, ok := p.Value.(dog); return ok },
+	})
+	catType := graphql.NewObject(graphql.ObjectConfig{
+		Name:     "Cat",
+		Fields:   graphql.Fields{"name": &graphql.Field{Type: graphql.String}},
+		IsTypeOf: func(p graphql.IsTypeOfParams) bool { _, ok := p.Value.(cat); return ok },
+	})
+	petType := graphql.NewUnion(graphql.UnionConfig{
+		Name:  "Pet",
+		Types: []*graphql.Object{dogType, catType},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"pets": &graphql.Field{
+					Type: graphql.NewList(petType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return []interface{}{dog{"Odie"}, cat{"Garfield"}, dog{"Snoopy"}}, nil
+					},
+				},
+			},
+		}),
+		CollectFieldsCacheSize: 10,
+	})
+	if err != nil {
+		t.Fatalf("Error in schema %v", err.Error())
+	}
+
+	doc := testutil.TestParse(t, `query ($withName: Boolean!) {
+		pets {
+			... on Dog { barks name @include(if: $withName) }
+			...CatName
+		}
+	}
+	fragment CatName on Cat { name @include(if: $withName) }`)
+
+	for _, withName := range []bool{true, false, true} {
+		dogData := map[string]interface{}{"barks": nil}
+		catData := map[string]interface{}{}
+		if withName {
+			dogData = map[string]interface{}{"barks": nil, "name": "Odie"}
+			catData = map[string]interface{}{"name": "Garfield"}
+		}
+		snoopyData := map[string]interface{}{"barks": nil}
+		if withName {
+			snoopyData["name"] = "Snoopy"
+		}
+		expected := &graphql.Result{
+			Data: map[string]interface{}{
+				"pets": []interface{}{dogData, catData, snoopyData},
+			},
+		}
+		result := graphql.Execute(graphql.ExecuteParams{
+			Schema: schema,
+			AST:    doc,
+			Args:   map[string]interface{}{"withName": withName},
+		})
+		if !reflect.DeepEqual(expected, result) {
+			t.Fatalf("withName %v: unexpected result, Diff: %v", withName, testutil.Diff(expected, result))
+		}
+	}
+}
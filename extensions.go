@@ -191,19 +191,28 @@ func handleExtensionsExecutionDidStart(p *ExecuteParams) ([]gqlerrors.FormattedE
 	}
 }
 
-// handleResolveFieldDidStart handles the notification of the extensions about the start of a resolve function
+// noResolveFieldFinish is the finish handler of fields resolved without extensions.
+func noResolveFieldFinish(interface{}, error) []gqlerrors.FormattedError { return nil }
+
+// handleResolveFieldDidStart handles the notification of the extensions about the start of a resolve function.
+// The context returned by the extensions is scoped to this field only: it is handed to the field's resolver
+// but it doesn't leak into the execution context shared with the other fields.
 func handleExtensionsResolveFieldDidStart(
 	exts []Extension,
-	p *executionContext,
+	ctx context.Context,
 	i *ResolveInfo) (
 	[]gqlerrors.FormattedError,
+	context.Context,
 	resolveFieldFinishFuncHandler,
 ) {
+	if len(exts) == 0 {
+		return nil, ctx, noResolveFieldFinish
+	}
 	fs := map[string]ResolveFieldFinishFunc{}
 	errs := gqlerrors.FormattedErrors{}
-	for _, ext := range p.Schema.extensions {
+	for _, ext := range exts {
 		var (
-			ctx      context.Context
+			extCtx   context.Context
 			finishFn ResolveFieldFinishFunc
 		)
 		// catch panic from an extension's resolveFieldDidStart function
@@ -213,13 +222,13 @@ func handleExtensionsResolveFieldDidStart(
 					errs = append(errs, gqlerrors.FormatError(fmt.Errorf("%s.ResolveFieldDidStart: %v", ext.Name(), r.(error))))
 				}
 			}()
-			ctx, finishFn = ext.ResolveFieldDidStart(p.Context, i)
+			extCtx, finishFn = ext.ResolveFieldDidStart(ctx, i)
 			// update context
-			p.Context = ctx
+			ctx = extCtx
 			fs[ext.Name()] = finishFn
 		}()
 	}
-	return errs, func(val interface{}, err error) []gqlerrors.FormattedError {
+	return errs, ctx, func(val interface{}, err error) []gqlerrors.FormattedError {
 		extErrs := gqlerrors.FormattedErrors{}
 		for name, finishFn := range fs {
 			func() {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/fiatjaf/graphql"
@@ -373,6 +374,157 @@ func TestExtensionGetResultPanic(t *testing.T) {
 	}
 }
 
+type extCtxKey string
+
+func TestExtensionInitWithoutContext(t *testing.T) {
+	ext := newtestExt("testExt")
+	ext.initFn = func(ctx context.Context, p *graphql.Params) context.Context {
+		return context.WithValue(ctx, extCtxKey("init"), "ok")
+	}
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Type",
+			Fields: graphql.Fields{
+				"a": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Context.Value(extCtxKey("init")), nil
+					},
+				},
+			},
+		}),
+		Extensions: []graphql.Extension{ext},
+	})
+	if err != nil {
+		t.Fatalf("Error in schema %v", err.Error())
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `query Example { a }`,
+	})
+
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"a": "ok",
+		},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+}
+
+func TestExtensionResolveFieldDidStartContextIsScopedToField(t *testing.T) {
+	ext := newtestExt("testExt")
+	ext.resolveFieldDidStartFn = func(ctx context.Context, i *graphql.ResolveInfo) (context.Context, graphql.ResolveFieldFinishFunc) {
+		return context.WithValue(ctx, extCtxKey(i.FieldName), i.FieldName), func(v interface{}, err error) {
+		}
+	}
+
+	resolveSeen := func(p graphql.ResolveParams) (interface{}, error) {
+		seen := ""
+		for _, name := range []string{"a", "b"} {
+			if v, ok := p.Context.Value(extCtxKey(name)).(string); ok {
+				seen += v
+			}
+		}
+		return seen, nil
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Type",
+			Fields: graphql.Fields{
+				"a": &graphql.Field{Type: graphql.String, Resolve: resolveSeen},
+				"b": &graphql.Field{Type: graphql.String, Resolve: resolveSeen},
+			},
+		}),
+		Extensions: []graphql.Extension{ext},
+	})
+	if err != nil {
+		t.Fatalf("Error in schema %v", err.Error())
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `query Example { a b }`,
+		Context:       context.Background(),
+	})
+
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"a": "a",
+			"b": "b",
+		},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+}
+
+func TestExtensionHooksOnSubscription(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	record := func(hook string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[hook]++
+	}
+
+	ext := newtestExt("testExt")
+	ext.parseDidStartFn = func(ctx context.Context) (context.Context, graphql.ParseFinishFunc) {
+		record("ParseDidStart")
+		return ctx, func(err error) {}
+	}
+	ext.validationDidStartFn = func(ctx context.Context) (context.Context, graphql.ValidationFinishFunc) {
+		record("ValidationDidStart")
+		return ctx, func([]gqlerrors.FormattedError) {}
+	}
+	ext.executionDidStartFn = func(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+		record("ExecutionDidStart")
+		return ctx, func(r *graphql.Result) {}
+	}
+	ext.resolveFieldDidStartFn = func(ctx context.Context, i *graphql.ResolveInfo) (context.Context, graphql.ResolveFieldFinishFunc) {
+		record("ResolveFieldDidStart:" + i.FieldName)
+		return ctx, func(v interface{}, err error) {}
+	}
+
+	schema := makeSubscriptionSchema(t, graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"sub": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+				Subscribe: makeSubscribeToStringFunction([]string{"a", "b"}),
+			},
+		},
+	})
+	schema.AddExtensions(ext)
+
+	var results []*graphql.Result
+	for res := range graphql.Subscribe(graphql.Params{
+		Schema:        schema,
+		RequestString: `subscription { sub }`,
+	}) {
+		results = append(results, res)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Unexpected number of results: %d", len(results))
+	}
+
+	expected := map[string]int{
+		"ParseDidStart":            1,
+		"ValidationDidStart":       1,
+		"ExecutionDidStart":        2,
+		"ResolveFieldDidStart:sub": 3, // once for the event stream and once per event
+	}
+	if !reflect.DeepEqual(expected, calls) {
+		t.Fatalf("Unexpected hook calls, Diff: %v", testutil.Diff(expected, calls))
+	}
+}
+
 func newtestExt(name string) *testExt {
 	ext := &testExt{
 		name: name,
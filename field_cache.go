@@ -0,0 +1,184 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// FieldCache makes the values returned by the resolver of a field be reused instead of calling it
+// again, see Field.Cache. The values are reused within a request, and across requests for TTL when
+// the schema has a FieldCacheStore. Only the values returned without an error are cached, and they
+// are still completed for each selection, so caching is meant for hot, expensive fields, typically
+// leaves.
+type FieldCache struct {
+	// TTL is how long the values are kept by the FieldCacheStore of the schema. When it is zero,
+	// or the schema has no store, the values are only reused within a request.
+	TTL time.Duration
+
+	// KeyFn returns the key of the value of the field, or false for the value not to be cached. By
+	// default the key is made of the parent type, the name and the arguments of the field, which
+	// ignores the source: fields of objects whose value depends on the object need a KeyFn, e.g.
+	// returning the ID of the source.
+	KeyFn func(p ResolveParams) (string, bool)
+}
+
+// FieldCacheFromDirectives returns the FieldCache declared by the @cacheControl(maxAge: Int)
+// directive of the definition of a field, maxAge being its TTL in seconds, and nil if it has none.
+func FieldCacheFromDirectives(directives []*ast.Directive) *FieldCache {
+	for _, directive := range directives {
+		if directive.Name == nil || directive.Name.Value != "cacheControl" {
+			continue
+		}
+		cache := &FieldCache{}
+		for _, arg := range directive.Arguments {
+			if arg.Name != nil && arg.Name.Value == "maxAge" {
+				maxAge, _ := intLiteral(arg.Value)
+				cache.TTL = time.Duration(maxAge) * time.Second
+			}
+		}
+		return cache
+	}
+	return nil
+}
+
+// FieldCacheStore keeps the values of the cached fields across requests, see
+// SchemaConfig.FieldCacheStore. It must be safe for concurrent use, and may be backed by a shared
+// cache as long as it can hold the values returned by the resolvers.
+type FieldCacheStore interface {
+	// Get returns the value stored under key, and false if there is none or it expired.
+	Get(ctx context.Context, key string) (interface{}, bool)
+
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration)
+}
+
+// FieldCacheStats is what is reported under the "fieldCache" key of the result extensions when
+// cached fields were resolved.
+type FieldCacheStats struct {
+	// Hits is the number of values found in the cache, within the request or in the store.
+	Hits int `json:"hits"`
+
+	// Misses is the number of values resolved because they weren't in the cache.
+	Misses int `json:"misses"`
+}
+
+// NewFieldCacheStore returns a FieldCacheStore keeping up to size values in memory. It is emptied
+// when full.
+func NewFieldCacheStore(size int) FieldCacheStore {
+	return &memoryFieldCacheStore{
+		size:    size,
+		entries: map[string]fieldCacheEntry{},
+	}
+}
+
+type memoryFieldCacheStore struct {
+	mutex   sync.Mutex
+	size    int
+	entries map[string]fieldCacheEntry
+}
+
+type fieldCacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+func (s *memoryFieldCacheStore) Get(ctx context.Context, key string) (interface{}, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (s *memoryFieldCacheStore) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	if s.size <= 0 {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.entries[key]; !ok && len(s.entries) >= s.size {
+		s.entries = map[string]fieldCacheEntry{}
+	}
+	s.entries[key] = fieldCacheEntry{value: value, expires: time.Now().Add(ttl)}
+}
+
+// fieldCache holds the values of the cached fields resolved by an execution.
+type fieldCache struct {
+	values map[string]interface{}
+	stats  FieldCacheStats
+}
+
+// resolveCached calls resolveFn in a slot of the budget unless the value of the field is in the
+// cache of the execution or in the store of the schema, and caches the value it returns.
+func resolveCached(eCtx *executionContext, cache *FieldCache, resolveFn FieldResolveFn, p ResolveParams) (interface{}, error) {
+	keyFn := cache.KeyFn
+	if keyFn == nil {
+		keyFn = defaultFieldCacheKey
+	}
+	key, ok := keyFn(p)
+	if !ok {
+		return resolveWithBudget(eCtx.Schema.fieldBudget, resolveFn, p)
+	}
+	if eCtx.fieldCache == nil {
+		eCtx.fieldCache = &fieldCache{values: map[string]interface{}{}}
+	}
+	if value, ok := eCtx.fieldCache.values[key]; ok {
+		eCtx.fieldCache.stats.Hits++
+		return value, nil
+	}
+	store := eCtx.Schema.fieldCacheStore
+	if store != nil && cache.TTL > 0 {
+		if value, ok := store.Get(p.Context, key); ok {
+			eCtx.fieldCache.stats.Hits++
+			eCtx.fieldCache.values[key] = value
+			return value, nil
+		}
+	}
+
+	eCtx.fieldCache.stats.Misses++
+	value, err := resolveWithBudget(eCtx.Schema.fieldBudget, resolveFn, p)
+	if err != nil {
+		return value, err
+	}
+	eCtx.fieldCache.values[key] = value
+	if store != nil && cache.TTL > 0 {
+		store.Set(p.Context, key, value, cache.TTL)
+	}
+	return value, nil
+}
+
+// defaultFieldCacheKey is the parent type, the name and the JSON of the arguments of the field.
+func defaultFieldCacheKey(p ResolveParams) (string, bool) {
+	args, err := json.Marshal(p.Args)
+	if err != nil {
+		return "", false
+	}
+	key := p.Info.FieldName
+	if p.Info.ParentType != nil {
+		key = p.Info.ParentType.Name() + "." + key
+	}
+	return key + string(args), true
+}
+
+// addFieldCacheExtension reports the hits and misses of the cached fields under the "fieldCache"
+// key of the result extensions, if there were any.
+func addFieldCacheExtension(result *Result, cache *fieldCache) {
+	if cache == nil {
+		return
+	}
+	if result.Extensions == nil {
+		result.Extensions = map[string]interface{}{}
+	}
+	stats := cache.stats
+	result.Extensions["fieldCache"] = &stats
+}
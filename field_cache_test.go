@@ -0,0 +1,153 @@
+package graphql_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+// fieldCacheSchema returns a schema whose fields are cached as told by cache, counting the calls
+// of their resolvers in calls.
+func fieldCacheSchema(t *testing.T, cache *graphql.FieldCache, store graphql.FieldCacheStore, calls *int) graphql.Schema {
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{Type: graphql.String},
+			"score": &graphql.Field{
+				Type:  graphql.Int,
+				Cache: cache,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					*calls++
+					return len(p.Source.(map[string]interface{})["id"].(string)), nil
+				},
+			},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"rate": &graphql.Field{
+					Type:  graphql.Float,
+					Args:  graphql.FieldConfigArgument{"currency": &graphql.ArgumentConfig{Type: graphql.String}},
+					Cache: cache,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						*calls++
+						if p.Args["currency"] == "XXX" {
+							return nil, errors.New("unknown currency")
+						}
+						return 1.5, nil
+					},
+				},
+				"users": &graphql.Field{
+					Type: graphql.NewList(userType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return []interface{}{
+							map[string]interface{}{"id": "a"},
+							map[string]interface{}{"id": "bb"},
+							map[string]interface{}{"id": "a"},
+						}, nil
+					},
+				},
+			},
+		}),
+		FieldCacheStore: store,
+	})
+	if err != nil {
+		t.Fatalf("wrong result, unexpected errors: %v", err.Error())
+	}
+	return schema
+}
+
+func TestFieldCache_ReusesValuesWithinARequest(t *testing.T) {
+	calls := 0
+	schema := fieldCacheSchema(t, &graphql.FieldCache{}, nil, &calls)
+
+	query := `{ a: rate(currency: "EUR") b: rate(currency: "EUR") c: rate(currency: "USD") }`
+	for i := 0; i < 2; i++ {
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: query})
+		if len(result.Errors) > 0 {
+			t.Fatalf("wrong result, unexpected errors: %v", result.Errors)
+		}
+		expected := map[string]interface{}{"a": 1.5, "b": 1.5, "c": 1.5}
+		if !reflect.DeepEqual(result.Data, expected) {
+			t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result.Data))
+		}
+		expectedStats := &graphql.FieldCacheStats{Hits: 1, Misses: 2}
+		if !reflect.DeepEqual(result.Extensions["fieldCache"], expectedStats) {
+			t.Fatalf("Unexpected stats, Diff: %v", testutil.Diff(expectedStats, result.Extensions["fieldCache"]))
+		}
+	}
+	// without a store nothing is kept across requests
+	if calls != 4 {
+		t.Fatalf("expected 4 calls of the resolver, got %d", calls)
+	}
+}
+
+func TestFieldCache_KeepsValuesInTheStore(t *testing.T) {
+	calls := 0
+	schema := fieldCacheSchema(t, &graphql.FieldCache{TTL: time.Minute}, graphql.NewFieldCacheStore(10), &calls)
+
+	for i := 0; i < 3; i++ {
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ rate(currency: "EUR") }`})
+		if len(result.Errors) > 0 {
+			t.Fatalf("wrong result, unexpected errors: %v", result.Errors)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single call of the resolver, got %d", calls)
+	}
+
+	// errors aren't cached
+	for i := 0; i < 2; i++ {
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ rate(currency: "XXX") }`})
+		if len(result.Errors) != 1 {
+			t.Fatalf("expected an error, got %+v", result)
+		}
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls of the resolver, got %d", calls)
+	}
+}
+
+func TestFieldCache_KeyFn(t *testing.T) {
+	calls := 0
+	schema := fieldCacheSchema(t, &graphql.FieldCache{
+		KeyFn: func(p graphql.ResolveParams) (string, bool) {
+			source, ok := p.Source.(map[string]interface{})
+			if !ok {
+				return "", false
+			}
+			return fmt.Sprintf("score:%v", source["id"]), true
+		},
+	}, nil, &calls)
+
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ users { id score } }`})
+	expected := map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"id": "a", "score": 1},
+			map[string]interface{}{"id": "bb", "score": 2},
+			map[string]interface{}{"id": "a", "score": 1},
+		},
+	}
+	if len(result.Errors) > 0 || !reflect.DeepEqual(result.Data, expected) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls of the resolver, got %d", calls)
+	}
+}
+
+func TestFieldCache_NotReportedWithoutCachedFields(t *testing.T) {
+	calls := 0
+	schema := fieldCacheSchema(t, nil, nil, &calls)
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ rate }`})
+	if _, ok := result.Extensions["fieldCache"]; ok {
+		t.Fatalf("unexpected extensions %v", result.Extensions)
+	}
+}
@@ -0,0 +1,86 @@
+package graphql
+
+import (
+	"sort"
+
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// fieldOrder is the order of the fields of the objects of a result, as they were selected by the
+// query, see Params.PreserveFieldOrder. The fields of every type condition are merged, each
+// response key being ordered by its first occurrence.
+type fieldOrder struct {
+	keys     []string
+	children map[string]*fieldOrder
+}
+
+// newFieldOrder returns the order of the fields selected by selectionSet.
+func newFieldOrder(selectionSet *ast.SelectionSet, fragments map[string]ast.Definition) *fieldOrder {
+	order := &fieldOrder{children: map[string]*fieldOrder{}}
+	order.add(selectionSet, fragments, map[string]bool{})
+	return order
+}
+
+// add appends the fields of selectionSet that aren't in the order yet, visited holding the
+// fragments being expanded.
+func (o *fieldOrder) add(selectionSet *ast.SelectionSet, fragments map[string]ast.Definition, visited map[string]bool) {
+	if selectionSet == nil {
+		return
+	}
+	for _, selection := range selectionSet.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			key := getFieldEntryKey(selection)
+			child, ok := o.children[key]
+			if !ok {
+				child = &fieldOrder{children: map[string]*fieldOrder{}}
+				o.children[key] = child
+				o.keys = append(o.keys, key)
+			}
+			child.add(selection.SelectionSet, fragments, map[string]bool{})
+		case *ast.InlineFragment:
+			o.add(selection.SelectionSet, fragments, visited)
+		case *ast.FragmentSpread:
+			if selection.Name == nil || visited[selection.Name.Value] {
+				continue
+			}
+			fragment, ok := fragments[selection.Name.Value].(*ast.FragmentDefinition)
+			if !ok {
+				continue
+			}
+			visited[selection.Name.Value] = true
+			o.add(fragment.SelectionSet, fragments, visited)
+			delete(visited, selection.Name.Value)
+		}
+	}
+}
+
+// sortKeys returns the keys of an object of the result in the selection order. The keys it
+// doesn't know of, if any, come last, sorted.
+func (o *fieldOrder) sortKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for _, key := range o.keys {
+		if _, ok := object[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == len(object) {
+		return keys
+	}
+	var unknown []string
+	for key := range object {
+		if _, ok := o.children[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return append(keys, unknown...)
+}
+
+// child returns the order of the fields of the values of key, which is nil if unknown.
+func (o *fieldOrder) child(key string) *fieldOrder {
+	if o == nil {
+		return nil
+	}
+	return o.children[key]
+}
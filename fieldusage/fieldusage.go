@@ -0,0 +1,153 @@
+// Package fieldusage counts the operations using each field of a schema, so the fields that are
+// still used can be told apart from those that can be removed once deprecated.
+//
+// A Collector is an extension of the schema:
+//
+//	usage := fieldusage.New()
+//	schema, _ := graphql.NewSchema(graphql.SchemaConfig{
+//		Query:      queryType,
+//		Extensions: []graphql.Extension{usage},
+//	})
+//	...
+//	counts := usage.Snapshot() // e.g. {"Query.user": 42, "User.name": 40}
+package fieldusage
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/kinds"
+	"github.com/fiatjaf/graphql/language/visitor"
+)
+
+// Collector is a graphql.Extension counting, for every field coordinate ("Type.field"), the
+// executed operations selecting it, including through the fragments they spread. A field is
+// counted once per operation, however many times it is selected or resolved, and whether or not
+// it is skipped by a directive. The introspection fields aren't counted.
+type Collector struct {
+	mutex  sync.Mutex
+	counts map[string]int64
+}
+
+// New returns an empty Collector.
+func New() *Collector {
+	return &Collector{counts: map[string]int64{}}
+}
+
+// Snapshot returns the number of operations that used each field since the Collector was created
+// or reset, by field coordinate.
+func (c *Collector) Snapshot() map[string]int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	snapshot := make(map[string]int64, len(c.counts))
+	for coordinate, count := range c.counts {
+		snapshot[coordinate] = count
+	}
+	return snapshot
+}
+
+// Reset forgets the counts, e.g. after they were saved.
+func (c *Collector) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.counts = map[string]int64{}
+}
+
+type executionKey struct{}
+
+// execution makes the fields of an execution be counted only once.
+type execution struct {
+	once sync.Once
+}
+
+// count counts the fields used by the operation of info.
+func (c *Collector) count(info *graphql.ResolveInfo) {
+	document := &ast.Document{Definitions: []ast.Node{info.Operation}}
+	for _, fragment := range info.Fragments {
+		document.Definitions = append(document.Definitions, fragment)
+	}
+	operation, ok := info.Operation.(*ast.OperationDefinition)
+	if !ok {
+		return
+	}
+	spread := graphql.OperationFragments(operation, graphql.FragmentDependencies(document))
+
+	used := map[string]bool{}
+	typeInfo := graphql.NewTypeInfo(&graphql.TypeInfoConfig{Schema: &info.Schema})
+	collect := visitor.VisitWithTypeInfo(typeInfo, &visitor.VisitorOptions{
+		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+			kinds.Field: {
+				Kind: func(p visitor.VisitFuncParams) (string, interface{}) {
+					field, ok := p.Node.(*ast.Field)
+					parentType := typeInfo.ParentType()
+					if ok && field.Name != nil && parentType != nil &&
+						!strings.HasPrefix(field.Name.Value, "__") && !strings.HasPrefix(parentType.Name(), "__") {
+						used[parentType.Name()+"."+field.Name.Value] = true
+					}
+					return visitor.ActionNoChange, nil
+				},
+			},
+		},
+	})
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok &&
+			(fragment.Name == nil || !spread[fragment.Name.Value]) {
+			continue
+		}
+		visitor.Visit(definition, collect, nil)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for coordinate := range used {
+		c.counts[coordinate]++
+	}
+}
+
+// Name implements graphql.Extension.
+func (c *Collector) Name() string {
+	return "FieldUsage"
+}
+
+// Init implements graphql.Extension.
+func (c *Collector) Init(ctx context.Context, p *graphql.Params) context.Context {
+	return ctx
+}
+
+// ParseDidStart implements graphql.Extension.
+func (c *Collector) ParseDidStart(ctx context.Context) (context.Context, graphql.ParseFinishFunc) {
+	return ctx, func(error) {}
+}
+
+// ValidationDidStart implements graphql.Extension.
+func (c *Collector) ValidationDidStart(ctx context.Context) (context.Context, graphql.ValidationFinishFunc) {
+	return ctx, func([]gqlerrors.FormattedError) {}
+}
+
+// ExecutionDidStart implements graphql.Extension.
+func (c *Collector) ExecutionDidStart(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+	return context.WithValue(ctx, executionKey{}, &execution{}), func(*graphql.Result) {}
+}
+
+// ResolveFieldDidStart implements graphql.Extension. The fields of an operation are counted when
+// its first field is resolved, that's when the operation is known.
+func (c *Collector) ResolveFieldDidStart(ctx context.Context, info *graphql.ResolveInfo) (context.Context, graphql.ResolveFieldFinishFunc) {
+	if execution, ok := ctx.Value(executionKey{}).(*execution); ok {
+		execution.once.Do(func() { c.count(info) })
+	}
+	return ctx, func(interface{}, error) {}
+}
+
+// HasResult implements graphql.Extension.
+func (c *Collector) HasResult() bool {
+	return false
+}
+
+// GetResult implements graphql.Extension.
+func (c *Collector) GetResult(context.Context) interface{} {
+	return nil
+}
@@ -0,0 +1,72 @@
+package fieldusage_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/fieldusage"
+)
+
+func TestCollector(t *testing.T) {
+	usage := fieldusage.New()
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"name":     &graphql.Field{Type: graphql.String},
+			"nickname": &graphql.Field{Type: graphql.String, DeprecationReason: "Use name."},
+			"email":    &graphql.Field{Type: graphql.String},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"users": &graphql.Field{
+					Type: graphql.NewList(userType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return []interface{}{
+							map[string]interface{}{"name": "a", "nickname": "A"},
+							map[string]interface{}{"name": "b", "nickname": "B"},
+						}, nil
+					},
+				},
+			},
+		}),
+		Extensions: []graphql.Extension{usage},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := []struct {
+		query         string
+		operationName string
+	}{
+		{`{ users { name } }`, ""},
+		{`query A { users { ...Names } } query B { users { ...Emails } }
+		fragment Names on User { name nickname } fragment Emails on User { email }`, "A"},
+		{`{ users { __typename name @skip(if: true) } }`, ""},
+		{`{ __schema { queryType { name } } }`, ""},
+	}
+	for _, request := range requests {
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: request.query, OperationName: request.operationName})
+		if result.HasErrors() {
+			t.Fatalf("unexpected errors: %v", result.Errors)
+		}
+	}
+
+	expected := map[string]int64{
+		"Query.users":   3,
+		"User.name":     3,
+		"User.nickname": 1,
+	}
+	if snapshot := usage.Snapshot(); !reflect.DeepEqual(expected, snapshot) {
+		t.Fatalf("expected %v, got %v", expected, snapshot)
+	}
+
+	usage.Reset()
+	if snapshot := usage.Snapshot(); len(snapshot) != 0 {
+		t.Fatalf("expected no usage after Reset, got %v", snapshot)
+	}
+}
@@ -0,0 +1,182 @@
+package graphql_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+)
+
+var fuzzEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "FuzzEnum",
+	Values: graphql.EnumValueConfigMap{
+		"ONE": &graphql.EnumValueConfig{Value: 1},
+		"TWO": &graphql.EnumValueConfig{Value: "two"},
+	},
+})
+
+var fuzzInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "FuzzInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"int":    &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		"list":   &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		"enum":   &graphql.InputObjectFieldConfig{Type: fuzzEnum, DefaultValue: "two"},
+		"float":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Float)},
+		"nested": &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewList(graphql.ID))},
+	},
+})
+
+// fuzzInputTypes are the types of the values coerced by FuzzCoerceValue, and the names of their
+// variables.
+var fuzzInputTypes = []struct {
+	name  string
+	ttype graphql.Input
+}{
+	{"Int", graphql.Int},
+	{"Float", graphql.Float},
+	{"String", graphql.String},
+	{"Boolean", graphql.Boolean},
+	{"ID", graphql.ID},
+	{"DateTime", graphql.DateTime},
+	{"FuzzEnum", fuzzEnum},
+	{"[Int!]", graphql.NewList(graphql.NewNonNull(graphql.Int))},
+	{"[[FuzzEnum]]!", graphql.NewNonNull(graphql.NewList(graphql.NewList(fuzzEnum)))},
+	{"FuzzInput", fuzzInput},
+	{"[FuzzInput!]", graphql.NewList(graphql.NewNonNull(fuzzInput))},
+}
+
+var fuzzCoerceSchema = func() graphql.Schema {
+	fields := graphql.Fields{}
+	for i, input := range fuzzInputTypes {
+		fields[fmt.Sprintf("echo%d", i)] = &graphql.Field{
+			Type: graphql.String,
+			Args: graphql.FieldConfigArgument{"v": &graphql.ArgumentConfig{Type: input.ttype}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return fmt.Sprintf("%v", p.Args["v"]), nil
+			},
+		}
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: fields}),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}()
+
+// FuzzCoerceValue checks that no value, sent as a JSON variable or written as a literal, makes
+// the coercion of the input types panic.
+func FuzzCoerceValue(f *testing.F) {
+	for _, seed := range []struct {
+		ttype uint8
+		value string
+	}{
+		{0, `1`},
+		{0, `2147483648`},
+		{1, `1.5e300`},
+		{2, `"a"`},
+		{3, `true`},
+		{4, `123`},
+		{5, `"2017-01-07T13:04:05Z"`},
+		{6, `"ONE"`},
+		{7, `[1, null, 3]`},
+		{8, `[["ONE"], null, ["TWO", "THREE"]]`},
+		{9, `{"int": 1, "list": ["a"], "float": 1, "nested": [[1, "2"]]}`},
+		{10, `[{"float": -0}, {}]`},
+	} {
+		f.Add(seed.ttype, seed.value)
+	}
+
+	f.Fuzz(func(t *testing.T, ttype uint8, value string) {
+		input := fuzzInputTypes[int(ttype)%len(fuzzInputTypes)]
+		field := fmt.Sprintf("echo%d", int(ttype)%len(fuzzInputTypes))
+
+		var variable interface{}
+		if json.Unmarshal([]byte(value), &variable) == nil {
+			graphql.Do(graphql.Params{
+				Schema:         fuzzCoerceSchema,
+				RequestString:  fmt.Sprintf(`query ($v: %s) { %s(v: $v) }`, input.name, field),
+				VariableValues: map[string]interface{}{"v": variable},
+			})
+		}
+		graphql.Do(graphql.Params{
+			Schema:        fuzzCoerceSchema,
+			RequestString: fmt.Sprintf(`{ %s(v: %s) }`, field, value),
+		})
+	})
+}
+
+var fuzzCompleteSchema = func() graphql.Schema {
+	value := func(p graphql.ResolveParams) (interface{}, error) {
+		return p.Source, nil
+	}
+	var object *graphql.Object
+	object = graphql.NewObject(graphql.ObjectConfig{
+		Name: "FuzzObject",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"int":      &graphql.Field{Type: graphql.Int, Resolve: value},
+				"float":    &graphql.Field{Type: graphql.Float, Resolve: value},
+				"string":   &graphql.Field{Type: graphql.String, Resolve: value},
+				"boolean":  &graphql.Field{Type: graphql.Boolean, Resolve: value},
+				"id":       &graphql.Field{Type: graphql.ID, Resolve: value},
+				"dateTime": &graphql.Field{Type: graphql.DateTime, Resolve: value},
+				"enum":     &graphql.Field{Type: fuzzEnum, Resolve: value},
+				"nonNull":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: value},
+				"list":     &graphql.Field{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Resolve: value},
+				"nested":   &graphql.Field{Type: graphql.NewList(graphql.NewList(object)), Resolve: value},
+				"object":   &graphql.Field{Type: object, Resolve: value},
+				"field":    &graphql.Field{Type: graphql.String},
+			}
+		}),
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"value": &graphql.Field{Type: object},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}()
+
+// FuzzCompleteValue checks that no value returned by the resolvers, as decoded from JSON, makes
+// their completion to the output types panic.
+func FuzzCompleteValue(f *testing.F) {
+	for _, seed := range []string{
+		`1`,
+		`"a"`,
+		`null`,
+		`[1, "2", null]`,
+		`{"field": "a"}`,
+		`[[{"field": 1}], null, [null]]`,
+		`1e100`,
+		`{"": []}`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		var root interface{}
+		if json.Unmarshal([]byte(value), &root) != nil {
+			return
+		}
+		graphql.Do(graphql.Params{
+			Schema: fuzzCompleteSchema,
+			RequestString: `{
+				value {
+					int float string boolean id dateTime enum nonNull list field
+					nested { int list field nested { field } }
+					object { object { string } }
+				}
+			}`,
+			RootObject: map[string]interface{}{"value": root},
+		})
+	})
+}
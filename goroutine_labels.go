@@ -0,0 +1,16 @@
+//go:build !tinygo
+
+package graphql
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// labelGoroutine labels the current goroutine in the profiles of runtime/pprof.
+func labelGoroutine(ctx context.Context, info GoroutineInfo) {
+	pprof.SetGoroutineLabels(pprof.WithLabels(ctx, pprof.Labels(
+		"graphql.goroutine", info.Label,
+		"graphql.operation", info.OperationName,
+	)))
+}
@@ -0,0 +1,8 @@
+//go:build tinygo
+
+package graphql
+
+import "context"
+
+// labelGoroutine does nothing, as TinyGo has no runtime/pprof.
+func labelGoroutine(ctx context.Context, info GoroutineInfo) {}
@@ -0,0 +1,86 @@
+package graphql
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// GoroutineInfo describes a goroutine spawned by the package, or by the handler, while goroutine
+// tracking is enabled, see SetGoroutineTracking.
+type GoroutineInfo struct {
+	// Label tells what the goroutine does, e.g. "execute" or "websocket reader".
+	Label string
+
+	// OperationName is the name of the operation of the request that spawned the goroutine, as
+	// known by its RequestInfo when the goroutine started, if any.
+	OperationName string
+
+	// Started is when the goroutine started.
+	Started time.Time
+}
+
+// Age returns how long the goroutine has been running.
+func (g GoroutineInfo) Age() time.Duration {
+	return time.Since(g.Started)
+}
+
+var (
+	goroutineTracking int32
+	goroutineIDs      uint64
+	goroutines        sync.Map // uint64 -> GoroutineInfo
+)
+
+// SetGoroutineTracking enables or disables the tracking of the goroutines spawned by the package
+// and by the handler, a debug mode to diagnose the goroutines outliving their request: the
+// running ones are listed by Goroutines, and they are labeled with their Label and OperationName
+// under the "graphql.goroutine" and "graphql.operation" keys of the goroutine profile of
+// runtime/pprof, except with TinyGo. Tests can fail when goroutines leak with
+// testutil.CheckGoroutines.
+func SetGoroutineTracking(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&goroutineTracking, 1)
+	} else {
+		atomic.StoreInt32(&goroutineTracking, 0)
+	}
+}
+
+// Goroutines returns a snapshot of the tracked goroutines still running, the oldest first. It is
+// empty when goroutine tracking isn't enabled.
+func Goroutines() []GoroutineInfo {
+	var running []GoroutineInfo
+	goroutines.Range(func(key, value interface{}) bool {
+		running = append(running, value.(GoroutineInfo))
+		return true
+	})
+	sort.Slice(running, func(i, j int) bool {
+		return running[i].Started.Before(running[j].Started)
+	})
+	return running
+}
+
+// Go runs fn in a new goroutine, tracked under label when goroutine tracking is enabled. It is
+// used by the package and the handler for their goroutines, the operation being the one of the
+// RequestInfo of ctx, which may be nil.
+func Go(ctx context.Context, label string, fn func()) {
+	if atomic.LoadInt32(&goroutineTracking) == 0 {
+		go fn()
+		return
+	}
+
+	info := GoroutineInfo{Label: label, Started: time.Now()}
+	if ctx == nil {
+		ctx = context.Background()
+	} else if requestInfo := RequestInfoFromContext(ctx); requestInfo != nil {
+		info.OperationName = requestInfo.OperationName
+	}
+	id := atomic.AddUint64(&goroutineIDs, 1)
+	goroutines.Store(id, info)
+	go func() {
+		defer goroutines.Delete(id)
+		labelGoroutine(ctx, info)
+		fn()
+	}()
+}
@@ -0,0 +1,128 @@
+package graphql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+func goroutinesTestSchema(t *testing.T) graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"hello": &graphql.Field{Type: graphql.String},
+			},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"ticks": &graphql.Field{
+					Type: graphql.Int,
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						ticks := make(chan interface{})
+						go func() {
+							defer close(ticks)
+							for i := 0; ; i++ {
+								select {
+								case ticks <- i:
+								case <-p.Context.Done():
+									return
+								}
+							}
+						}()
+						return ticks, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestGoroutines_ListsRunningOnes(t *testing.T) {
+	testutil.CheckGoroutines(t)
+	schema := goroutinesTestSchema(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := graphql.DoAsync(graphql.Params{
+		Schema:        schema,
+		RequestString: `subscription Ticks { ticks }`,
+		OperationName: "Ticks",
+		Context:       ctx,
+	})
+	<-results
+
+	labels := map[string]string{}
+	for _, goroutine := range graphql.Goroutines() {
+		labels[goroutine.Label] = goroutine.OperationName
+	}
+	if operation, ok := labels["subscription"]; !ok || operation != "Ticks" {
+		t.Fatalf("expected a subscription goroutine of Ticks, got %v", labels)
+	}
+
+	cancel()
+	for range results {
+	}
+}
+
+func TestGoroutines_NotTrackedByDefault(t *testing.T) {
+	schema := goroutinesTestSchema(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := graphql.DoAsync(graphql.Params{
+		Schema:        schema,
+		RequestString: `subscription { ticks }`,
+		Context:       ctx,
+	})
+	<-results
+	if goroutines := graphql.Goroutines(); len(goroutines) != 0 {
+		t.Fatalf("expected no tracked goroutines, got %v", goroutines)
+	}
+	cancel()
+	for range results {
+	}
+}
+
+func TestCheckGoroutines_ReportsLeaks(t *testing.T) {
+	check := &recordingTB{TB: t}
+	testutil.CheckGoroutines(check)
+
+	release := make(chan struct{})
+	graphql.Go(context.Background(), "stuck", func() { <-release })
+	check.cleanup()
+	close(release)
+
+	if !check.failed {
+		t.Fatal("expected the goroutine outliving the test to be reported")
+	}
+}
+
+// recordingTB records the failures and the cleanups of a test instead of applying them.
+type recordingTB struct {
+	testing.TB
+	failed   bool
+	cleanups []func()
+}
+
+func (tb *recordingTB) Helper() {}
+
+func (tb *recordingTB) Errorf(format string, args ...interface{}) {
+	tb.failed = true
+}
+
+func (tb *recordingTB) Cleanup(fn func()) {
+	tb.cleanups = append(tb.cleanups, fn)
+}
+
+func (tb *recordingTB) cleanup() {
+	for _, fn := range tb.cleanups {
+		fn()
+	}
+}
@@ -0,0 +1,120 @@
+package gqlerrors
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fiatjaf/graphql/language/location"
+	"github.com/fiatjaf/graphql/language/source"
+)
+
+// CodeFrame renders the excerpt of s around the byte offset position for developer-facing error
+// displays: a header with the line, the column and message, then the lines around the position
+// with a caret under its column.
+//
+//	3:17  Expected Name, found }
+//
+//	  2 |   hero {
+//	> 3 |     friends { }
+//	    |               ^
+//	  4 |   }
+func CodeFrame(s *source.Source, position int, message string) string {
+	loc := location.GetLocation(s, position)
+	frame := fmt.Sprintf("%d:%d  %s\n", loc.Line, loc.Column, message)
+	if s == nil {
+		return frame
+	}
+
+	lines := splitLines(string(s.Body))
+	first, last := loc.Line-1, loc.Line+1
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	width := len(fmt.Sprintf("%d", last))
+	frame += "\n"
+	for line := first; line <= last; line++ {
+		marker := " "
+		if line == loc.Line {
+			marker = ">"
+		}
+		frame += strings.TrimRight(fmt.Sprintf("%s %*d | %s", marker, width, line, printLine(lines[line-1])), " ") + "\n"
+		if line == loc.Line {
+			frame += fmt.Sprintf("  %s | %s^\n", strings.Repeat(" ", width), caretPadding(lines[line-1], loc.Column))
+		}
+	}
+	return frame
+}
+
+// CodeFrame renders the excerpts of the source at each position of the error, as CodeFrame does,
+// or its locations only when it has no source.
+func (g *Error) CodeFrame() string {
+	message := errorDescription(g.Message)
+	if len(g.Positions) == 0 {
+		return message + "\n"
+	}
+	frames := []string{}
+	for _, position := range g.Positions {
+		frames = append(frames, CodeFrame(g.Source, position, message))
+	}
+	return strings.Join(frames, "\n")
+}
+
+// CodeFrame renders the excerpts of the source of the error like Error.CodeFrame, or its locations
+// and message when it doesn't come from an Error holding the source.
+func (g FormattedError) CodeFrame() string {
+	if err, ok := g.originalError.(*Error); ok {
+		return err.CodeFrame()
+	}
+	if len(g.Locations) == 0 {
+		return g.Message + "\n"
+	}
+	frames := []string{}
+	for _, loc := range g.Locations {
+		frames = append(frames, fmt.Sprintf("%d:%d  %s\n", loc.Line, loc.Column, g.Message))
+	}
+	return strings.Join(frames, "")
+}
+
+// Positions returns the byte offsets in the source of the locations of the error, when it comes
+// from an Error.
+func (g FormattedError) Positions() []int {
+	if err, ok := g.originalError.(*Error); ok {
+		return err.Positions
+	}
+	return nil
+}
+
+// errorDescription returns the first line of message, without the source name and location
+// prefixing the syntax errors.
+func errorDescription(message string) string {
+	if i := strings.IndexAny(message, "\r\n"); i >= 0 {
+		message = message[:i]
+	}
+	if strings.HasPrefix(message, "Syntax Error ") {
+		if i := strings.Index(message, ") "); i >= 0 {
+			message = message[i+2:]
+		}
+	}
+	return message
+}
+
+// caretPadding returns the spaces before the caret pointing at the column of line, as printed by
+// printLine, which escapes the control characters.
+func caretPadding(line string, column int) string {
+	width := 0
+	for _, r := range line {
+		if column <= 1 {
+			break
+		}
+		column--
+		width += len([]rune(printCharCode(r)))
+	}
+	return strings.Repeat(" ", width+column-1)
+}
+
+func splitLines(body string) []string {
+	return strings.Split(strings.Replace(strings.Replace(body, "\r\n", "\n", -1), "\r", "\n", -1), "\n")
+}
@@ -72,6 +72,9 @@ func newError(
 			break
 		}
 	}
+	// the locations of nodes are computed in their own source, as they may come from several ones,
+	// e.g. when they're definitions of several SDL files
+	var nodeLocations []*ast.Location
 	if len(positions) == 0 && len(nodes) > 0 {
 		for _, node := range nodes {
 			if node == nil || reflect.ValueOf(node).IsNil() {
@@ -81,11 +84,16 @@ func newError(
 				continue
 			}
 			positions = append(positions, node.GetLoc().Start)
+			nodeLocations = append(nodeLocations, node.GetLoc())
 		}
 	}
 	locations := []location.SourceLocation{}
-	for _, pos := range positions {
-		loc := location.GetLocation(source, pos)
+	for i, pos := range positions {
+		positionSource := source
+		if i < len(nodeLocations) && nodeLocations[i].Source != nil {
+			positionSource = nodeLocations[i].Source
+		}
+		loc := location.GetLocation(positionSource, pos)
 		locations = append(locations, loc)
 	}
 	return &Error{
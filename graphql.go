@@ -2,6 +2,9 @@ package graphql
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/fiatjaf/graphql/gqlerrors"
 	"github.com/fiatjaf/graphql/language/ast"
@@ -9,6 +12,15 @@ import (
 	"github.com/fiatjaf/graphql/language/source"
 )
 
+// AllowOperationFn tells if the operation with the given name, empty for anonymous operations,
+// may be executed. Operations that aren't allowed are rejected before being validated.
+type AllowOperationFn func(ctx context.Context, operationName string) bool
+
+// AllowOperationTypeFn returns the error rejecting an operation of the given type, "query",
+// "mutation" or "subscription", or nil if it may be executed. Operations that aren't allowed are
+// rejected before being validated.
+type AllowOperationTypeFn func(ctx context.Context, operationType string) error
+
 type Params struct {
 	// The GraphQL type system to use when validating and executing a query.
 	Schema Schema
@@ -32,10 +44,55 @@ type Params struct {
 	// Context may be provided to pass application-specific per-request
 	// information to resolve functions.
 	Context context.Context
+
+	// MaxCost enables the complexity analysis of the operation when above zero: operations
+	// costing more than MaxCost are rejected before execution, and the computed cost is
+	// reported under extensions.cost. See Field.Complexity for how the cost is computed.
+	MaxCost int
+
+	// MaxNodes enables MaxNodesRule when above zero: operations that could return more nodes
+	// than MaxNodes, going by their page size arguments, are rejected by the validation.
+	MaxNodes int
+
+	// ValidationRules are validated in addition to SpecifiedRules, see Rule for writing them.
+	// Rules wrapped with WithSeverity(SeverityWarning, rule) don't fail the request, what they
+	// report is returned under extensions.warnings.
+	ValidationRules []ValidationRuleFn
+
+	// AllowOperationFn, when set, is called after parsing with the name of the operation to
+	// execute, see AllowOperationFn.
+	AllowOperationFn AllowOperationFn
+
+	// AllowOperationTypeFn, when set, is called after parsing with the type of the operation to
+	// execute, see AllowOperationTypeFn.
+	AllowOperationTypeFn AllowOperationTypeFn
+
+	// RateLimitFn, when set, is called with the cost of the operation before it is executed, see
+	// RateLimitFn.
+	RateLimitFn RateLimitFn
+
+	// Redact, when set, can replace the values of the fields of the result before it is sent, see
+	// RedactFn.
+	Redact RedactFn
+
+	// DocumentCache, when set, caches the document of RequestString once it is parsed and
+	// validated, see DocumentCache.
+	DocumentCache *DocumentCache
+
+	// PreserveFieldOrder makes the fields of the objects of the data be encoded in JSON in the
+	// order they were selected in the query, as the spec requires, instead of sorted by name. The
+	// handler sets it unless configured otherwise, see handler.Config.SortFields. It is off by
+	// default here as the order is kept in the result, which then differs from a result with the
+	// same data built by hand, as compared with reflect.DeepEqual.
+	PreserveFieldOrder bool
+
+	// VisibilityFn, when set, hides the fields and types of the schema it tells aren't visible to
+	// the request from its introspection and validation, see VisibilityFn.
+	VisibilityFn VisibilityFn
 }
 
-// DoChannel performs both sync and asynchronous operations (subscriptions), it returns a channel
-// of results instead of a single result
+// DoChannel performs both sync and asynchronous operations (subscriptions and live queries), it
+// returns a channel of results instead of a single result
 func DoAsync(p Params) chan *Result {
 	return do(p, false)
 }
@@ -47,55 +104,230 @@ func Do(p Params) *Result {
 }
 
 func do(p Params, skipSubscriptions bool) chan *Result {
+	wrapResult := func(result *Result) chan *Result {
+		singleEventChannel := make(chan *Result)
+		Go(p.Context, "result", func() {
+			defer close(singleEventChannel)
+			singleEventChannel <- result
+		})
+		return singleEventChannel
+	}
+
+	AST, warnings, costExt, refused := prepare(&p)
+	if refused != nil {
+		return wrapResult(refused)
+	}
+
+	OperationContextFromContext(p.Context).setVariables(p.Schema, p.VariableValues)
+
+	params := ExecuteParams{
+		Schema:        p.Schema,
+		Root:          p.RootObject,
+		AST:           AST,
+		OperationName: p.OperationName,
+		Args:          p.VariableValues,
+		Context:       p.Context,
+		Redact:        p.Redact,
+
+		PreserveFieldOrder: p.PreserveFieldOrder,
+	}
+
+	// without a single operation to select, the execution fails with the appropriate error
+	operation := selectedOperation(AST, p.OperationName)
+	if !skipSubscriptions && operation != nil && operation.Operation == ast.OperationTypeSubscription {
+		return warnFirstResult(p.Context, ExecuteSubscription(params), warnings)
+	} else if !skipSubscriptions && isLiveQuery(AST, p.OperationName) {
+		return warnFirstResult(p.Context, executeLiveQuery(params), warnings)
+	} else {
+		singleEventChannel := make(chan *Result)
+		Go(p.Context, "do", func() {
+			defer close(singleEventChannel)
+			result := Execute(params)
+			addCostExtension(result, costExt)
+			addWarningsExtension(result, warnings)
+			singleEventChannel <- result
+		})
+		return singleEventChannel
+	}
+}
+
+// CheckRequest runs the checks Do runs before executing the operation of p: parsing, validation,
+// AllowOperationFn, AllowOperationTypeFn, MaxCost and RateLimitFn. It returns the result of the
+// request when it is refused, or nil when its operation may be executed.
+func CheckRequest(p Params) *Result {
+	_, _, _, refused := prepare(&p)
+	return refused
+}
+
+// prepare parses and validates p.RequestString and checks its cost, returning the result of the
+// request instead when it is refused.
+func prepare(p *Params) (*ast.Document, []gqlerrors.FormattedError, *CostExtension, *Result) {
+	AST, warnings, errs := parseAndValidate(p)
+	if len(errs) != 0 {
+		result := &Result{Errors: errs}
+		addWarningsExtension(result, warnings)
+		return nil, nil, nil, result
+	}
+
+	costExt, errs := checkQueryCost(p, AST)
+	if len(errs) != 0 {
+		result := &Result{Errors: errs}
+		addCostExtension(result, costExt)
+		addWarningsExtension(result, warnings)
+		return nil, nil, nil, result
+	}
+	return AST, warnings, costExt, nil
+}
+
+// addWarningsExtension reports the validation warnings under extensions.warnings.
+func addWarningsExtension(result *Result, warnings []gqlerrors.FormattedError) {
+	if len(warnings) == 0 {
+		return
+	}
+	if result.Extensions == nil {
+		result.Extensions = map[string]interface{}{}
+	}
+	result.Extensions["warnings"] = warnings
+}
+
+// warnFirstResult forwards the results of results, the validation warnings being added to the
+// first one.
+func warnFirstResult(ctx context.Context, results chan *Result, warnings []gqlerrors.FormattedError) chan *Result {
+	if len(warnings) == 0 {
+		return results
+	}
+	warnedResults := make(chan *Result)
+	Go(ctx, "warnings", func() {
+		defer close(warnedResults)
+		for result := range results {
+			addWarningsExtension(result, warnings)
+			warnings = nil
+			select {
+			case warnedResults <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return warnedResults
+}
+
+// parseAndValidate runs the extensions' init, parse and validation hooks around parsing and
+// validating p.RequestString. p.Context is updated with the contexts returned by the extensions.
+// The warnings reported by the validation are returned along with the document.
+func parseAndValidate(p *Params) (*ast.Document, []gqlerrors.FormattedError, gqlerrors.FormattedErrors) {
 	source := source.NewSource(&source.Source{
 		Body: []byte(p.RequestString),
 		Name: "GraphQL request",
 	})
 
-	wrapErr := func(gqlerr gqlerrors.FormattedErrors) chan *Result {
-		singleEventChannel := make(chan *Result)
-		go func() {
-			singleEventChannel <- &Result{Errors: gqlerr}
-		}()
-		return singleEventChannel
+	// extensions get to derive their own contexts from this one, so make sure there is one
+	if p.Context == nil {
+		p.Context = context.Background()
+	}
+	if RequestInfoFromContext(p.Context) == nil {
+		p.Context = WithRequestInfo(p.Context, &RequestInfo{})
+	}
+	if OperationContextFromContext(p.Context) == nil {
+		p.Context = WithOperationContext(p.Context, &OperationContext{})
+	}
+	if operationContext := OperationContextFromContext(p.Context); operationContext.Started.IsZero() {
+		operationContext.Started = time.Now()
+	}
+	if p.VisibilityFn != nil {
+		p.Context = withVisibility(p.Context, p.VisibilityFn)
 	}
 
 	// run init on the extensions
-	extErrs := handleExtensionsInits(&p)
+	extErrs := handleExtensionsInits(p)
 	if len(extErrs) != 0 {
-		return wrapErr(extErrs)
+		return nil, nil, extErrs
 	}
 
-	extErrs, parseFinishFn := handleExtensionsParseDidStart(&p)
+	extErrs, parseFinishFn := handleExtensionsParseDidStart(p)
 	if len(extErrs) != 0 {
-		return wrapErr(extErrs)
+		return nil, nil, extErrs
 	}
 
-	// parse the source
-	AST, err := parser.Parse(parser.ParseParams{Source: source})
+	// parse the source, unless its document is cached
+	cached, isCached := p.DocumentCache.get(p.RequestString)
+	var AST *ast.Document
+	var err error
+	if isCached {
+		AST = cached.document
+	} else {
+		AST, err = parser.Parse(parser.ParseParams{Source: source})
+	}
 	if err != nil {
 		// run parseFinishFuncs for extensions
 		extErrs = parseFinishFn(err)
 
 		// merge the errors from extensions and the original error from parser
 		extErrs = append(extErrs, gqlerrors.FormatErrors(err)...)
-		return wrapErr(extErrs)
+		return nil, nil, extErrs
 	}
 
 	// run parseFinish functions for extensions
 	extErrs = parseFinishFn(err)
 	if len(extErrs) != 0 {
-		return wrapErr(extErrs)
+		return nil, nil, extErrs
+	}
+
+	RequestInfoFromContext(p.Context).setOperation(AST, p.OperationName)
+	OperationContextFromContext(p.Context).setDocument(AST, p.OperationName)
+
+	if p.AllowOperationFn != nil {
+		if operation := selectedOperation(AST, p.OperationName); operation != nil {
+			operationName := ""
+			if operation.Name != nil {
+				operationName = operation.Name.Value
+			}
+			if !p.AllowOperationFn(p.Context, operationName) {
+				if operationName == "" {
+					return nil, nil, gqlerrors.FormatErrors(errors.New("Anonymous operations are not allowed."))
+				}
+				return nil, nil, gqlerrors.FormatErrors(fmt.Errorf(`Operation "%s" is not allowed.`, operationName))
+			}
+		}
+	}
+
+	if p.AllowOperationTypeFn != nil {
+		if operation := selectedOperation(AST, p.OperationName); operation != nil {
+			if err := p.AllowOperationTypeFn(p.Context, operation.Operation); err != nil {
+				return nil, nil, gqlerrors.FormatErrors(err)
+			}
+		}
 	}
 
 	// notify extensions about the start of the validation
-	extErrs, validationFinishFn := handleExtensionsValidationDidStart(&p)
+	extErrs, validationFinishFn := handleExtensionsValidationDidStart(p)
 	if len(extErrs) != 0 {
-		return wrapErr(extErrs)
+		return nil, nil, extErrs
 	}
 
-	// validate document
-	validationResult := ValidateDocument(&p.Schema, AST, nil)
+	// validate document, unless it was validated already, which doesn't tell if it is valid for
+	// what the request can see. The documents are only cached as validated when they were for the
+	// requests without internal access, which see less than the others.
+	var validationResult ValidationResult
+	if isCached && cached.validated && p.MaxNodes == 0 && p.VisibilityFn == nil {
+		validationResult = ValidationResult{IsValid: true, Warnings: cached.warnings}
+	} else {
+		rules := SpecifiedRules
+		if len(p.ValidationRules) > 0 {
+			rules = append(rules[:len(rules):len(rules)], p.ValidationRules...)
+		}
+		if p.MaxNodes > 0 {
+			rules = append(rules[:len(rules):len(rules)], MaxNodesRule(p.MaxNodes, p.VariableValues))
+		}
+		validationResult = validateDocument(&p.Schema, AST, rules, requestVisibility(p.Context, &p.Schema))
+		if validationResult.IsValid && (!isCached || !cached.validated) {
+			p.DocumentCache.put(p.RequestString, &cachedDocument{
+				document:  AST,
+				validated: p.MaxNodes == 0 && p.VisibilityFn == nil && !HasInternalAccess(p.Context),
+				warnings:  validationResult.Warnings,
+			})
+		}
+	}
 
 	if !validationResult.IsValid {
 		// run validation finish functions for extensions
@@ -103,33 +335,36 @@ func do(p Params, skipSubscriptions bool) chan *Result {
 
 		// merge the errors from extensions and the original error from parser
 		extErrs = append(extErrs, validationResult.Errors...)
-		return wrapErr(extErrs)
+		return nil, validationResult.Warnings, extErrs
 	}
 
 	// run the validationFinishFuncs for extensions
 	extErrs = validationFinishFn(validationResult.Errors)
 	if len(extErrs) != 0 {
-		return wrapErr(extErrs)
+		return nil, validationResult.Warnings, extErrs
 	}
 
-	params := ExecuteParams{
-		Schema:        p.Schema,
-		Root:          p.RootObject,
-		AST:           AST,
-		OperationName: p.OperationName,
-		Args:          p.VariableValues,
-		Context:       p.Context,
-	}
+	return AST, validationResult.Warnings, nil
+}
 
-	if !skipSubscriptions &&
-		len(AST.Definitions) > 0 &&
-		AST.Definitions[0].(*ast.OperationDefinition).Operation == "subscription" {
-		return ExecuteSubscription(params)
-	} else {
-		singleEventChannel := make(chan *Result)
-		go func() {
-			singleEventChannel <- Execute(params)
-		}()
-		return singleEventChannel
+// selectedOperation returns the operation of the document selected by operationName, or the only
+// operation of the document when operationName is empty.
+func selectedOperation(document *ast.Document, operationName string) *ast.OperationDefinition {
+	var selected *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" {
+			if selected != nil {
+				// ambiguous, reported by the execution
+				return nil
+			}
+			selected = operation
+		} else if operation.Name != nil && operation.Name.Value == operationName {
+			return operation
+		}
 	}
+	return selected
 }
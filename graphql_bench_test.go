@@ -40,10 +40,21 @@ func BenchmarkListQuery_100K(b *testing.B) {
 	nItemsListQueryBenchmark(100 * 1000)(b)
 }
 
+// Benchmark a list of struct items resolved by the default resolver.
+func BenchmarkDefaultResolveListQuery_100(b *testing.B) {
+	nItemsListQueryBenchmarkWithSchema(benchutil.DefaultResolveListSchemaWithXItems(100))(b)
+}
+
+func BenchmarkDefaultResolveListQuery_1K(b *testing.B) {
+	nItemsListQueryBenchmarkWithSchema(benchutil.DefaultResolveListSchemaWithXItems(1000))(b)
+}
+
 func nItemsListQueryBenchmark(x int) func(b *testing.B) {
-	return func(b *testing.B) {
-		schema := benchutil.ListSchemaWithXItems(x)
+	return nItemsListQueryBenchmarkWithSchema(benchutil.ListSchemaWithXItems(x))
+}
 
+func nItemsListQueryBenchmarkWithSchema(schema graphql.Schema) func(b *testing.B) {
+	return func(b *testing.B) {
 		bench := B{
 			Query: `
 				query {
@@ -1,11 +1,14 @@
 package graphql_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"reflect"
 	"testing"
 
 	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
 	"github.com/fiatjaf/graphql/testutil"
 )
 
@@ -266,3 +269,237 @@ func TestEmptyStringIsNotNull(t *testing.T) {
 		t.Errorf("wrong result, query: %v, graphql result diff: %v", query, testutil.Diff(expected, result))
 	}
 }
+
+func TestResultClone(t *testing.T) {
+	result := graphql.Do(graphql.Params{
+		Schema:        testutil.StarWarsSchema,
+		RequestString: `{ hero { name friends { name } } }`,
+	})
+	result.Errors = gqlerrors.FormatErrors(gqlerrors.NewErrorWithPath("boom", nil, "", nil, []int{}, []interface{}{"hero"}, nil))
+	result.Extensions = map[string]interface{}{"cost": map[string]interface{}{"requestedQueryCost": 3}}
+
+	clone := result.Clone()
+	if !reflect.DeepEqual(result, clone) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(result, clone))
+	}
+
+	hero := clone.Data.(map[string]interface{})["hero"].(map[string]interface{})
+	hero["name"] = "Luke"
+	hero["friends"].([]interface{})[0].(map[string]interface{})["name"] = "Leia"
+	clone.Errors[0].Path[0] = "villain"
+	clone.Extensions["cost"].(map[string]interface{})["requestedQueryCost"] = 10
+
+	original := result.Data.(map[string]interface{})["hero"].(map[string]interface{})
+	if original["name"] != "R2-D2" {
+		t.Fatalf("expected original hero name to be untouched, got %v", original["name"])
+	}
+	if name := original["friends"].([]interface{})[0].(map[string]interface{})["name"]; name != "Luke Skywalker" {
+		t.Fatalf("expected original friend name to be untouched, got %v", name)
+	}
+	if result.Errors[0].Path[0] != "hero" {
+		t.Fatalf("expected original error path to be untouched, got %v", result.Errors[0].Path)
+	}
+	if cost := result.Extensions["cost"].(map[string]interface{})["requestedQueryCost"]; cost != 3 {
+		t.Fatalf("expected original extensions to be untouched, got %v", cost)
+	}
+}
+
+func TestResultMarshalJSONTo(t *testing.T) {
+	result := graphql.Do(graphql.Params{
+		Schema:        testutil.StarWarsSchema,
+		RequestString: `{ hero { name friends { name } } }`,
+	})
+	result.Errors = gqlerrors.FormatErrors(gqlerrors.NewErrorWithPath("<boom>", nil, "", nil, []int{}, []interface{}{"hero"}, nil))
+
+	expected, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		if err := result.MarshalJSONTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != string(expected) {
+			t.Fatalf("expected %s, got %s", expected, buf.String())
+		}
+	}
+}
+
+func TestResultStreamJSONTo(t *testing.T) {
+	result := graphql.Do(graphql.Params{
+		Schema:        testutil.StarWarsSchema,
+		RequestString: `{ hero { name appearsIn friends { name } } empty: human(id: "none") { name } }`,
+	})
+	result.Data.(map[string]interface{})["nested"] = map[string]interface{}{
+		"<escaped>": []interface{}{},
+		"none":      []interface{}(nil),
+		"empty":     map[string]interface{}{},
+	}
+	result.Errors = gqlerrors.FormatErrors(gqlerrors.NewErrorWithPath("<boom>", nil, "", nil, []int{}, []interface{}{"hero"}, nil))
+	result.Extensions = map[string]interface{}{"cost": 3}
+
+	for _, result := range []*graphql.Result{result, {}} {
+		expected, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := result.StreamJSONTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != string(expected) {
+			t.Fatalf("expected %s, got %s", expected, buf.String())
+		}
+	}
+}
+
+func TestSetResultEncoding(t *testing.T) {
+	var marshalled int
+	graphql.SetResultEncoding(graphql.ResultEncoding{
+		OmitDataOnRequestErrors: true,
+		ErrorsFirst:             true,
+		Marshal: func(v interface{}) ([]byte, error) {
+			marshalled++
+			return json.Marshal(v)
+		},
+	})
+	defer graphql.SetResultEncoding(graphql.ResultEncoding{})
+
+	requestError := graphql.Do(graphql.Params{
+		Schema:        testutil.StarWarsSchema,
+		RequestString: `{ hero { name `,
+	})
+	fieldError := &graphql.Result{
+		Errors: gqlerrors.FormatErrors(gqlerrors.NewErrorWithPath("boom", nil, "", nil, []int{}, []interface{}{"hero"}, nil)),
+	}
+	tests := []struct {
+		result   *graphql.Result
+		expected string
+	}{
+		{requestError, `{"errors":[{"message":"Syntax Error GraphQL request (1:15) Expected Name, found EOF\n\n1: { hero { name \n                 ^\n","locations":[{"line":1,"column":15}]}]}`},
+		{fieldError, `{"errors":[{"message":"boom","locations":[],"path":["hero"]}],"data":null}`},
+		{&graphql.Result{Data: map[string]interface{}{"a": 1}}, `{"data":{"a":1}}`},
+	}
+	for _, test := range tests {
+		b, err := json.Marshal(test.result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.expected {
+			t.Fatalf("expected %s, got %s", test.expected, b)
+		}
+		var buf bytes.Buffer
+		if err := test.result.StreamJSONTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.expected {
+			t.Fatalf("expected %s streamed, got %s", test.expected, buf.String())
+		}
+	}
+	if marshalled == 0 {
+		t.Fatalf("expected the values to be encoded by the configured Marshal")
+	}
+}
+
+func TestPreserveFieldOrder(t *testing.T) {
+	result := graphql.Do(graphql.Params{
+		Schema:             testutil.StarWarsSchema,
+		RequestString:      `{ hero { name ...F } } fragment F on Character { id friends { name } ... on Droid { primaryFunction } }`,
+		PreserveFieldOrder: true,
+	})
+	expected := `{"data":{"hero":{"name":"R2-D2","id":"2001","friends":[{"name":"Luke Skywalker"},{"name":"Han Solo"},{"name":"Leia Organa"}],"primaryFunction":"Astromech"}}}`
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected {
+		t.Fatalf("expected %s, got %s", expected, b)
+	}
+	var buf bytes.Buffer
+	if err := result.Clone().StreamJSONTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != expected {
+		t.Fatalf("expected %s streamed, got %s", expected, buf.String())
+	}
+}
+
+func TestAllowOperationFn(t *testing.T) {
+	allowOperationFn := func(ctx context.Context, operationName string) bool {
+		return operationName == "HeroNameQuery"
+	}
+	query := `
+		query HeroNameQuery { hero { name } }
+		query HeroFriendsQuery { hero { friends { name } } }
+	`
+
+	result := graphql.Do(graphql.Params{
+		Schema:           testutil.StarWarsSchema,
+		RequestString:    query,
+		OperationName:    "HeroNameQuery",
+		AllowOperationFn: allowOperationFn,
+	})
+	if len(result.Errors) > 0 {
+		t.Fatalf("wrong result, unexpected errors: %v", result.Errors)
+	}
+
+	result = graphql.Do(graphql.Params{
+		Schema:           testutil.StarWarsSchema,
+		RequestString:    query,
+		OperationName:    "HeroFriendsQuery",
+		AllowOperationFn: allowOperationFn,
+	})
+	if result.Data != nil || len(result.Errors) != 1 || result.Errors[0].Message != `Operation "HeroFriendsQuery" is not allowed.` {
+		t.Fatalf("unexpected result: %v", result)
+	}
+
+	result = graphql.Do(graphql.Params{
+		Schema:           testutil.StarWarsSchema,
+		RequestString:    `{ hero { name } }`,
+		AllowOperationFn: allowOperationFn,
+	})
+	if result.Data != nil || len(result.Errors) != 1 || result.Errors[0].Message != `Anonymous operations are not allowed.` {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
+
+func TestRequestInfo(t *testing.T) {
+	var info graphql.RequestInfo
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"a": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						info = *graphql.RequestInfoFromContext(p.Context)
+						return "a", nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	graphql.Do(graphql.Params{Schema: schema, RequestString: `{ a }`})
+	if expected := (graphql.RequestInfo{OperationType: "query"}); info != expected {
+		t.Fatalf("expected %+v, got %+v", expected, info)
+	}
+
+	// the client is told by the context, and the operation is filled in
+	requestInfo := &graphql.RequestInfo{ClientName: "web", ClientVersion: "1.2"}
+	graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `query First { a } query Second { a }`,
+		OperationName: "Second",
+		Context:       graphql.WithRequestInfo(context.Background(), requestInfo),
+	})
+	expected := graphql.RequestInfo{ClientName: "web", ClientVersion: "1.2", OperationName: "Second", OperationType: "query"}
+	if info != expected || *requestInfo != expected {
+		t.Fatalf("expected %+v, got %+v and %+v", expected, info, *requestInfo)
+	}
+}
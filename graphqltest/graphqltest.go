@@ -0,0 +1,277 @@
+// Package graphqltest provides helpers to test schemas in process: operations are run against a
+// schema and their results checked with fluent assertions or compared to golden files, and the
+// results of subscriptions are collected with timeouts. A Server runs a whole handler over
+// in-memory connections, for integration tests using the client package.
+//
+//	graphqltest.Query(t, schema, `{ hero { name } }`, nil).
+//		ExpectNoErrors().
+//		ExpectPath("hero.name", "R2-D2")
+package graphqltest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+)
+
+var update = flag.Bool("graphqltest.update", false, "update the golden files of graphqltest snapshots")
+
+// DefaultTimeout is how long a subscription waits for its next result.
+var DefaultTimeout = 5 * time.Second
+
+// Response is the result of an operation, with assertions that report their failures to the test
+// and return the response so they can be chained.
+type Response struct {
+	*graphql.Result
+	t testing.TB
+}
+
+// Do runs the operation described by p and returns its response.
+func Do(t testing.TB, p graphql.Params) *Response {
+	t.Helper()
+	return &Response{Result: graphql.Do(p), t: t}
+}
+
+// Query runs query, or any other operation, against schema with the given variables.
+func Query(t testing.TB, schema graphql.Schema, query string, variables map[string]interface{}) *Response {
+	t.Helper()
+	return Do(t, graphql.Params{
+		Schema:         schema,
+		RequestString:  query,
+		VariableValues: variables,
+	})
+}
+
+// ExpectNoErrors fails the test if the response has errors.
+func (r *Response) ExpectNoErrors() *Response {
+	r.t.Helper()
+	if r.HasErrors() {
+		r.t.Errorf("expected no errors, got %v", r.Errors)
+	}
+	return r
+}
+
+// ExpectData fails the test unless the data of the response is equal to expected, which is
+// compared after being encoded to JSON, so it can be a map or a struct, or a string of JSON.
+func (r *Response) ExpectData(expected interface{}) *Response {
+	r.t.Helper()
+	if s, ok := expected.(string); ok {
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			r.t.Errorf("invalid expected data %q: %v", s, err)
+			return r
+		}
+		expected = v
+	} else {
+		expected = normalize(r.t, expected)
+	}
+	r.expectEqual("data", normalize(r.t, r.Data), expected)
+	return r
+}
+
+// ExpectPath fails the test unless the value at path in the data of the response, made of field
+// names and list indexes separated by dots like "hero.friends.0.name", is equal to expected,
+// which is compared after being encoded to JSON.
+func (r *Response) ExpectPath(path string, expected interface{}) *Response {
+	r.t.Helper()
+	value := normalize(r.t, r.Data)
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			field, ok := v[key]
+			if !ok {
+				r.t.Errorf("no %q field at %s in %v", key, path, r.Data)
+				return r
+			}
+			value = field
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				r.t.Errorf("no %q index at %s in %v", key, path, r.Data)
+				return r
+			}
+			value = v[i]
+		default:
+			r.t.Errorf("no %q key at %s in %v", key, path, r.Data)
+			return r
+		}
+	}
+	r.expectEqual(path, value, normalize(r.t, expected))
+	return r
+}
+
+// ExpectErrorCode fails the test unless one of the errors of the response has code under the
+// "code" key of its extensions.
+func (r *Response) ExpectErrorCode(code string) *Response {
+	r.t.Helper()
+	for _, err := range r.Errors {
+		if err.Extensions["code"] == code {
+			return r
+		}
+	}
+	r.t.Errorf("expected an error with the %s code, got %v", code, r.Errors)
+	return r
+}
+
+// ExpectErrorMessage fails the test unless one of the errors of the response has message.
+func (r *Response) ExpectErrorMessage(message string) *Response {
+	r.t.Helper()
+	for _, err := range r.Errors {
+		if err.Message == message {
+			return r
+		}
+	}
+	r.t.Errorf("expected an error with the message %q, got %v", message, r.Errors)
+	return r
+}
+
+// MatchSnapshot fails the test unless the response, encoded as indented JSON, is equal to the
+// golden file testdata/<name>.golden.json. The file is written when the tests are run with the
+// -graphqltest.update flag, a missing file fails the test otherwise.
+func (r *Response) MatchSnapshot(name string) *Response {
+	r.t.Helper()
+	actual, err := json.MarshalIndent(r.Result, "", "  ")
+	if err != nil {
+		r.t.Errorf("failed to encode the response: %v", err)
+		return r
+	}
+	actual = append(actual, '\n')
+
+	path := filepath.Join("testdata", name+".golden.json")
+	expected, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !*update {
+		r.t.Errorf("snapshot %s missing, run with -graphqltest.update", path)
+		return r
+	}
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			r.t.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+			return r
+		}
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			r.t.Errorf("failed to write %s: %v", path, err)
+			return r
+		}
+		r.t.Logf("wrote snapshot %s", path)
+		return r
+	}
+	if err != nil {
+		r.t.Errorf("failed to read %s: %v", path, err)
+		return r
+	}
+	if !bytes.Equal(expected, actual) {
+		r.t.Errorf("response doesn't match snapshot %s, run with -graphqltest.update to update it\nexpected:\n%s\nactual:\n%s", path, expected, actual)
+	}
+	return r
+}
+
+func (r *Response) expectEqual(what string, actual interface{}, expected interface{}) {
+	r.t.Helper()
+	if !reflect.DeepEqual(actual, expected) {
+		r.t.Errorf("unexpected %s:\nexpected: %v\nactual:   %v", what, expected, actual)
+	}
+}
+
+// normalize encodes v to JSON and decodes it back, so that values of different Go types
+// representing the same JSON are equal.
+func normalize(t testing.TB, v interface{}) interface{} {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Errorf("failed to encode %v: %v", v, err)
+		return nil
+	}
+	var normalized interface{}
+	json.Unmarshal(b, &normalized)
+	return normalized
+}
+
+// Subscription collects the results of a subscription, or any other operation run with
+// graphql.DoAsync.
+type Subscription struct {
+	// Timeout is how long Next waits for a result, DefaultTimeout initially.
+	Timeout time.Duration
+
+	t       testing.TB
+	results chan *graphql.Result
+	cancel  context.CancelFunc
+}
+
+// Subscribe runs the subscription described by p with graphql.DoAsync. It is stopped when the
+// test ends or Close is called.
+func Subscribe(t testing.TB, p graphql.Params) *Subscription {
+	t.Helper()
+	if p.Context == nil {
+		p.Context = context.Background()
+	}
+	ctx, cancel := context.WithCancel(p.Context)
+	p.Context = ctx
+	t.Cleanup(cancel)
+	return &Subscription{
+		Timeout: DefaultTimeout,
+		t:       t,
+		results: graphql.DoAsync(p),
+		cancel:  cancel,
+	}
+}
+
+// Next waits for the next result of the subscription. The test is stopped if there is none within
+// the timeout or the subscription ended.
+func (s *Subscription) Next() *Response {
+	s.t.Helper()
+	select {
+	case result, ok := <-s.results:
+		if !ok {
+			s.t.Fatalf("expected a result, but the subscription ended")
+			return nil
+		}
+		return &Response{Result: result, t: s.t}
+	case <-time.After(s.Timeout):
+		s.t.Fatalf("timed out after %v waiting for a result", s.Timeout)
+		return nil
+	}
+}
+
+// Collect waits for the next n results of the subscription, failing the test like Next.
+func (s *Subscription) Collect(n int) []*Response {
+	s.t.Helper()
+	responses := make([]*Response, 0, n)
+	for i := 0; i < n; i++ {
+		response := s.Next()
+		if response == nil {
+			break
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// ExpectDone fails the test unless the subscription ends, without sending more results, within
+// the timeout.
+func (s *Subscription) ExpectDone() {
+	s.t.Helper()
+	select {
+	case result, ok := <-s.results:
+		if ok {
+			s.t.Errorf("expected the subscription to end, got %v", result)
+		}
+	case <-time.After(s.Timeout):
+		s.t.Errorf("timed out after %v waiting for the subscription to end", s.Timeout)
+	}
+}
+
+// Close stops the subscription.
+func (s *Subscription) Close() {
+	s.cancel()
+}
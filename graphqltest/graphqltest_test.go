@@ -0,0 +1,173 @@
+package graphqltest_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/client"
+	"github.com/fiatjaf/graphql/graphqltest"
+	"github.com/fiatjaf/graphql/handler"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+// recorder is a testing.TB recording the failures of the assertions instead of failing the test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+func TestResponse_Assertions(t *testing.T) {
+	graphqltest.Query(t, testutil.StarWarsSchema, `query ($id: String!) { human(id: $id) { name friends { name } } }`,
+		map[string]interface{}{"id": "1002"}).
+		ExpectNoErrors().
+		ExpectPath("human.name", "Han Solo").
+		ExpectPath("human.friends.2.name", "R2-D2").
+		ExpectData(`{"human": {"name": "Han Solo", "friends": [
+			{"name": "Luke Skywalker"}, {"name": "Leia Organa"}, {"name": "R2-D2"}
+		]}}`)
+
+	graphqltest.Query(t, testutil.StarWarsSchema, `{ hero { unknown } }`, nil).
+		ExpectErrorMessage(`Cannot query field "unknown" on type "Character".`)
+}
+
+func TestResponse_ReportsFailures(t *testing.T) {
+	r := &recorder{TB: t}
+	graphqltest.Query(r, testutil.StarWarsSchema, `{ hero { name } }`, nil).
+		ExpectNoErrors().
+		ExpectPath("hero.name", "Luke Skywalker").
+		ExpectPath("hero.friends", nil).
+		ExpectData(map[string]interface{}{"hero": nil}).
+		ExpectErrorCode("FORBIDDEN")
+
+	if len(r.failures) != 4 {
+		t.Fatalf("expected 4 failures, got %d: %v", len(r.failures), r.failures)
+	}
+	for i, expected := range []string{"unexpected hero.name", `no "friends" field`, "unexpected data", "FORBIDDEN code"} {
+		if !strings.Contains(r.failures[i], expected) {
+			t.Fatalf("expected failure %d to contain %q, got %q", i, expected, r.failures[i])
+		}
+	}
+}
+
+func TestResponse_MatchSnapshot(t *testing.T) {
+	graphqltest.Query(t, testutil.StarWarsSchema, `{ hero { name appearsIn } }`, nil).
+		MatchSnapshot("hero")
+
+	r := &recorder{TB: t}
+	graphqltest.Query(r, testutil.StarWarsSchema, `{ hero(episode: EMPIRE) { name appearsIn } }`, nil).
+		MatchSnapshot("hero")
+	if len(r.failures) != 1 || !strings.Contains(r.failures[0], "doesn't match snapshot") {
+		t.Fatalf("expected the snapshot not to match, got %v", r.failures)
+	}
+
+	r = &recorder{TB: t}
+	graphqltest.Query(r, testutil.StarWarsSchema, `{ hero { name } }`, nil).
+		MatchSnapshot("missing")
+	if len(r.failures) != 1 || !strings.Contains(r.failures[0], "snapshot testdata/missing.golden.json missing, run with -graphqltest.update") {
+		t.Fatalf("expected the missing snapshot to fail, got %v", r.failures)
+	}
+}
+
+// countdownSchema is a schema whose "countdown" subscription sends 2, 1 and 0.
+func countdownSchema(t *testing.T) graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"countdown": &graphql.Field{
+					Type: graphql.Int,
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						c := make(chan interface{})
+						go func() {
+							defer close(c)
+							for i := 2; i >= 0; i-- {
+								select {
+								case <-p.Context.Done():
+									return
+								case c <- i:
+								}
+							}
+						}()
+						return c, nil
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestSubscription_CollectsResults(t *testing.T) {
+	schema := countdownSchema(t)
+
+	sub := graphqltest.Subscribe(t, graphql.Params{Schema: schema, RequestString: `subscription { countdown }`})
+	sub.Next().ExpectNoErrors().ExpectPath("countdown", 2)
+	responses := sub.Collect(2)
+	responses[0].ExpectPath("countdown", 1)
+	responses[1].ExpectPath("countdown", 0)
+	sub.ExpectDone()
+
+	r := &recorder{TB: t}
+	sub = graphqltest.Subscribe(r, graphql.Params{Schema: schema, RequestString: `subscription { countdown }`})
+	sub.Timeout = 10 * time.Millisecond
+	sub.ExpectDone()
+	if len(r.failures) != 1 || !strings.Contains(r.failures[0], "expected the subscription to end") {
+		t.Fatalf("expected a failure, got %v", r.failures)
+	}
+	sub.Close()
+}
+
+func TestServer_ServesOverInMemoryConnections(t *testing.T) {
+	schema := countdownSchema(t)
+	server := graphqltest.NewServer(t, handler.New(&handler.Config{Schema: &schema, WebSocket: true}))
+
+	var out struct{ Ok *bool }
+	if err := server.Client().Query(context.Background(), `{ ok }`, nil, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Ok != nil {
+		t.Fatalf("expected ok to be null, got %v", *out.Ok)
+	}
+
+	ws := server.DialWebSocket(t, client.WebSocketOptions{})
+	responses, err := ws.Subscribe(context.Background(), &client.Request{Query: `subscription { countdown }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received []string
+	for response := range responses {
+		if len(response.Errors) != 0 {
+			t.Fatalf("unexpected errors: %v", response.Errors)
+		}
+		received = append(received, string(response.Data))
+	}
+	expected := []string{`{"countdown":2}`, `{"countdown":1}`, `{"countdown":0}`}
+	if !reflect.DeepEqual(received, expected) {
+		t.Fatalf("expected %v, got %v", expected, received)
+	}
+}
@@ -0,0 +1,118 @@
+package graphqltest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fiatjaf/graphql/client"
+)
+
+// Server serves an http.Handler, typically a handler.Handler, over in-memory connections made
+// with net.Pipe, so that tests can go through HTTP and the websocket protocols without binding a
+// TCP port:
+//
+//	server := graphqltest.NewServer(t, handler.New(&handler.Config{Schema: &schema, WebSocket: true}))
+//	ws := server.DialWebSocket(t, client.WebSocketOptions{})
+//	responses, err := ws.Subscribe(ctx, &client.Request{Query: `subscription { ticks }`})
+type Server struct {
+	// URL is the HTTP URL of the server, whose host is only known to its clients.
+	URL string
+
+	listener *pipeListener
+	server   *http.Server
+}
+
+// NewServer starts serving h, until the test ends.
+func NewServer(t testing.TB, h http.Handler) *Server {
+	t.Helper()
+	s := &Server{
+		URL: "http://graphqltest/",
+		listener: &pipeListener{
+			conns:  make(chan net.Conn),
+			closed: make(chan struct{}),
+		},
+		server: &http.Server{Handler: h},
+	}
+	go s.server.Serve(s.listener)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Dial opens a connection to the server, whatever network and addr are. It is the DialContext of
+// the transport of the HTTP client of Client.
+func (s *Server) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	return s.listener.dial(ctx)
+}
+
+// Client returns a client sending its requests to the server.
+func (s *Server) Client() *client.Client {
+	c := client.New(s.URL)
+	c.HTTPClient = &http.Client{Transport: &http.Transport{DialContext: s.Dial}}
+	return c
+}
+
+// DialWebSocket opens a websocket connection to the server with opts, failing the test if it is
+// refused. It is closed when the test ends.
+func (s *Server) DialWebSocket(t testing.TB, opts client.WebSocketOptions) *client.WebSocketClient {
+	t.Helper()
+	opts.NetDialContext = s.Dial
+	ws, err := client.DialWebSocket(context.Background(), "ws"+strings.TrimPrefix(s.URL, "http"), opts)
+	if err != nil {
+		t.Fatalf("failed to open a websocket connection: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+// Close stops the server and closes its connections.
+func (s *Server) Close() {
+	s.server.Close()
+	s.listener.Close()
+}
+
+// pipeListener is a net.Listener accepting the connections opened with its dial method.
+type pipeListener struct {
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *pipeListener) dial(ctx context.Context) (net.Conn, error) {
+	serverConn, clientConn := net.Pipe()
+	select {
+	case l.conns <- serverConn:
+		return clientConn, nil
+	case <-l.closed:
+		return nil, errors.New("graphqltest: server closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "graphqltest" }
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCORSAllowedHeaders are the headers that the cross-origin requests may have when
+// CORS.AllowedHeaders is empty: those of the JSON requests, of the clients, of the CSRF prevention
+// and of the traces.
+var DefaultCORSAllowedHeaders = []string{
+	"Accept",
+	"Authorization",
+	"Content-Type",
+	ClientNameHeader,
+	ClientVersionHeader,
+	"X-Apollo-Operation-Name",
+	"Apollo-Require-Preflight",
+	"traceparent",
+	"tracestate",
+}
+
+// CORS configures the Cross-Origin Resource Sharing of a handler, see Config.CORS. The same
+// origins are allowed to open websocket connections.
+type CORS struct {
+	// AllowedOrigins are the origins allowed to send requests, as matched by path.Match, like
+	// "https://example.com" or "https://*.example.com". "*" allows any origin.
+	AllowedOrigins []string
+
+	// AllowedMethods are the methods of the requests, GET and POST if empty.
+	AllowedMethods []string
+
+	// AllowedHeaders are the headers the requests may have, DefaultCORSAllowedHeaders if empty.
+	// "*" allows any header.
+	AllowedHeaders []string
+
+	// ExposedHeaders are the headers of the responses that the pages can read, besides the
+	// CORS-safelisted ones.
+	ExposedHeaders []string
+
+	// AllowCredentials lets the requests be sent with the cookies and credentials of the user.
+	AllowCredentials bool
+
+	// MaxAge, when above zero, is how long the browsers may cache the responses to preflight
+	// requests.
+	MaxAge time.Duration
+}
+
+// allowsOrigin tells if requests may be sent from origin.
+func (c *CORS) allowsOrigin(origin string) bool {
+	for _, pattern := range c.AllowedOrigins {
+		if pattern == "*" {
+			return true
+		}
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(origin)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsMethod tells if requests may be sent with method.
+func (c *CORS) allowsMethod(method string) bool {
+	if len(c.AllowedMethods) == 0 {
+		return method == http.MethodGet || method == http.MethodPost
+	}
+	for _, allowed := range c.AllowedMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders tells if requests may have headers, a comma-separated list.
+func (c *CORS) allowsHeaders(headers string) bool {
+	allowedHeaders := c.AllowedHeaders
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = DefaultCORSAllowedHeaders
+	}
+	for _, header := range strings.Split(headers, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		allowed := false
+		for _, allowedHeader := range allowedHeaders {
+			if allowedHeader == "*" || strings.EqualFold(allowedHeader, header) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// handle sets the CORS headers of the response to r, and answers it if it is a preflight request,
+// in which case it returns true.
+func (c *CORS) handle(w http.ResponseWriter, r *http.Request) bool {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	origin := r.Header.Get("Origin")
+	requestMethod := r.Header.Get("Access-Control-Request-Method")
+	preflight := r.Method == http.MethodOptions && requestMethod != ""
+	if preflight {
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+	}
+
+	if origin == "" || !c.allowsOrigin(origin) {
+		if preflight {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return preflight
+	}
+
+	allowedOrigin := origin
+	if !c.AllowCredentials && len(c.AllowedOrigins) == 1 && c.AllowedOrigins[0] == "*" {
+		allowedOrigin = "*"
+	}
+
+	if !preflight {
+		header.Set("Access-Control-Allow-Origin", allowedOrigin)
+		if c.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if len(c.ExposedHeaders) != 0 {
+			header.Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+		}
+		return false
+	}
+
+	// the browser doesn't send the request if the preflight response doesn't allow it
+	requestHeaders := r.Header.Get("Access-Control-Request-Headers")
+	if c.allowsMethod(requestMethod) && c.allowsHeaders(requestHeaders) {
+		header.Set("Access-Control-Allow-Origin", allowedOrigin)
+		header.Set("Access-Control-Allow-Methods", strings.ToUpper(requestMethod))
+		if requestHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", requestHeaders)
+		}
+		if c.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if c.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.FormatInt(int64(c.MaxAge/time.Second), 10))
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// checkOrigin tells if a websocket connection may be opened by r: requests without an Origin
+// header aren't sent by browsers, the others must come from an allowed origin.
+func (c *CORS) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || c.allowsOrigin(origin)
+}
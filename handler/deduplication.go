@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+)
+
+// Deduplication makes the identical queries requested with HTTP at the same time be executed
+// once, see Config.Deduplication: the requests arriving while a query with the same text,
+// operation name, variables, HTTP method and key is being executed wait for its result instead
+// of executing it again. This protects expensive queries from a thundering herd, e.g. of clients
+// refreshing at once.
+//
+// The waiting requests share the result of the first one, so they must be allowed to see it: the
+// KeyFn must tell apart the requests whose results may differ, e.g. by their user.
+type Deduplication struct {
+	// KeyFn returns the key of the requests that may share their results, e.g. the hash of their
+	// auth token, or "" for a request that must not share its result. When it is nil the requests
+	// are keyed on their Authorization and Cookie headers, so the requests without them aren't
+	// deduplicated: a KeyFn returning a constant deduplicates the queries of every request.
+	KeyFn func(r *http.Request) string
+
+	// Timeout, when above zero, is how long the requests wait for the result of an identical
+	// query before executing theirs, and after which no other requests join it.
+	Timeout time.Duration
+
+	mutex   sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is the execution of a query shared by identical requests.
+type flight struct {
+	started time.Time
+	done    chan struct{}
+
+	// result is the result of the execution, nil if it can't be shared
+	result *graphql.Result
+}
+
+// do executes params, or waits for the result of an identical query being executed.
+func (d *Deduplication) do(ctx context.Context, r *http.Request, opts *RequestOptions, params graphql.Params) *graphql.Result {
+	key := ""
+	if d.KeyFn != nil {
+		key = d.KeyFn(r)
+	} else if authorization, cookie := r.Header.Get("Authorization"), r.Header.Get("Cookie"); authorization != "" || cookie != "" {
+		key = responseCacheKey(authorization, cookie)
+	}
+	if key == "" {
+		// the requests that may share the result can't be told
+		return graphql.Do(params)
+	}
+	variables, _ := json.Marshal(opts.Variables)
+	key = responseCacheKey(key, r.Method, opts.Query, opts.OperationName, string(variables))
+
+	d.mutex.Lock()
+	if d.flights == nil {
+		d.flights = map[string]*flight{}
+	}
+	shared, ok := d.flights[key]
+	if ok && (d.Timeout <= 0 || time.Since(shared.started) < d.Timeout) {
+		d.mutex.Unlock()
+		if result := shared.wait(ctx, d.Timeout-time.Since(shared.started), d.Timeout > 0); result != nil {
+			return result.Clone()
+		}
+		return graphql.Do(params)
+	}
+	own := &flight{started: time.Now(), done: make(chan struct{})}
+	d.flights[key] = own
+	d.mutex.Unlock()
+
+	result := graphql.Do(params)
+	// the result of an execution cancelled by its own request isn't shared
+	if ctx.Err() == nil {
+		own.result = result.Clone()
+	}
+	close(own.done)
+	d.mutex.Lock()
+	if d.flights[key] == own {
+		delete(d.flights, key)
+	}
+	d.mutex.Unlock()
+	return result
+}
+
+// wait returns the result of the flight, or nil if it can't be shared or isn't there before the
+// timeout, when there is one, or before ctx is done.
+func (f *flight) wait(ctx context.Context, timeout time.Duration, hasTimeout bool) *graphql.Result {
+	var expired <-chan time.Time
+	if hasTimeout {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case <-f.done:
+		return f.result
+	case <-expired:
+		return nil
+	case <-ctx.Done():
+		return nil
+	}
+}
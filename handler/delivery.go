@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/fiatjaf/graphql"
+)
+
+// DeliveryExtension is the extension of the subscribe messages of the websocket connections that
+// opts a subscription into a delivery mode reducing the size of the results sent for it, either
+// DeliveryDedupe or DeliveryPatch:
+//
+//	{"query": "subscription { ... }", "extensions": {"delivery": "patch"}}
+const DeliveryExtension = "delivery"
+
+const (
+	// DeliveryDedupe skips the results identical to the previous result of the subscription.
+	DeliveryDedupe = "dedupe"
+
+	// DeliveryPatch skips the identical results too, and sends the others as JSON merge patches
+	// (RFC 7386) of the previous result, in payloads like {"patch": {"data": {"price": 12}}}. The
+	// first result is sent whole, as are the results whose changes a merge patch can't describe,
+	// that is those setting members of objects to null.
+	DeliveryPatch = "patch"
+)
+
+// resultDelivery holds the previous result sent for a subscription that opted into a delivery mode.
+type resultDelivery struct {
+	mode string
+
+	// previous is the JSON encoding of the previous result, previousValue its decoded value
+	previous      []byte
+	previousValue interface{}
+}
+
+// newResultDelivery returns the delivery of the results of a subscription with the given
+// extensions, or nil if they don't opt into one.
+func newResultDelivery(extensions map[string]interface{}) *resultDelivery {
+	mode, _ := extensions[DeliveryExtension].(string)
+	if mode != DeliveryDedupe && mode != DeliveryPatch {
+		return nil
+	}
+	return &resultDelivery{mode: mode}
+}
+
+// payload returns the payload to send for result, or false if nothing needs to be sent.
+func (d *resultDelivery) payload(result *graphql.Result) (json.RawMessage, bool, error) {
+	var buf bytes.Buffer
+	if err := result.MarshalJSONTo(&buf); err != nil {
+		return nil, false, err
+	}
+	encoded := buf.Bytes()
+	if d.previous != nil && bytes.Equal(encoded, d.previous) {
+		return nil, false, nil
+	}
+	if d.mode == DeliveryDedupe {
+		d.previous = encoded
+		return encoded, true, nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false, err
+	}
+	previousValue := d.previousValue
+	first := d.previous == nil
+	d.previous, d.previousValue = encoded, value
+	if first {
+		return encoded, true, nil
+	}
+
+	patch, ok := mergePatch(previousValue, value)
+	if !ok {
+		return encoded, true, nil
+	}
+	if members, _ := patch.(map[string]interface{}); len(members) == 0 {
+		// the results only differ in how they were encoded
+		return nil, false, nil
+	}
+	b, err := json.Marshal(map[string]interface{}{"patch": patch})
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+// mergePatch returns the JSON merge patch turning from into to, or false if there is none because
+// to sets members of objects to null, which a merge patch would remove instead.
+func mergePatch(from, to interface{}) (interface{}, bool) {
+	fromObject, fromIsObject := from.(map[string]interface{})
+	toObject, toIsObject := to.(map[string]interface{})
+	if !fromIsObject || !toIsObject {
+		// values other than objects are replaced as a whole
+		if hasNullMember(to) {
+			return nil, false
+		}
+		return to, true
+	}
+
+	patch := map[string]interface{}{}
+	for key := range fromObject {
+		if _, ok := toObject[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	for key, value := range toObject {
+		previous, ok := fromObject[key]
+		if ok && reflect.DeepEqual(previous, value) {
+			continue
+		}
+		if value == nil {
+			return nil, false
+		}
+		if !ok {
+			previous = nil
+		}
+		memberPatch, ok := mergePatch(previous, value)
+		if !ok {
+			return nil, false
+		}
+		patch[key] = memberPatch
+	}
+	return patch, true
+}
+
+// hasNullMember tells if value is an object with a null member, at any depth within objects: a
+// merge patch replaces lists as they are, but merges objects.
+func hasNullMember(value interface{}) bool {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, member := range object {
+		if member == nil || hasNullMember(member) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// ETagOperationFn is called with the name of the query operations requested with GET when
+// Config.ETags is set, and returns false for those whose responses must not get an ETag.
+type ETagOperationFn func(ctx context.Context, operationName string) bool
+
+type responseVersionsKey struct{}
+
+// responseVersions collects the versions declared with ResponseVersion during an execution.
+type responseVersions struct {
+	mutex    sync.Mutex
+	versions []string
+}
+
+// ResponseVersion declares the version of the data the response being computed depends on, e.g.
+// "user:1:v42". When versions are declared the ETag of the response is derived from them and from
+// the request instead of from the encoded response, so they must cover all the data of the
+// response. It is meant to be called by resolvers with the context they were given, and does
+// nothing when the response doesn't get an ETag.
+func ResponseVersion(ctx context.Context, versions ...string) {
+	declared, ok := ctx.Value(responseVersionsKey{}).(*responseVersions)
+	if !ok {
+		return
+	}
+	declared.mutex.Lock()
+	defer declared.mutex.Unlock()
+	declared.versions = append(declared.versions, versions...)
+}
+
+// withResponseVersions returns a context collecting the versions declared with ResponseVersion.
+func withResponseVersions(ctx context.Context) (context.Context, *responseVersions) {
+	declared := &responseVersions{}
+	return context.WithValue(ctx, responseVersionsKey{}, declared), declared
+}
+
+// etag returns the strong ETag of the request with the declared versions, or "" if there are none.
+func (declared *responseVersions) etag(opts *RequestOptions) string {
+	declared.mutex.Lock()
+	versions := append([]string(nil), declared.versions...)
+	declared.mutex.Unlock()
+	if len(versions) == 0 {
+		return ""
+	}
+	sort.Strings(versions)
+
+	variables, _ := json.Marshal(opts.Variables)
+	hash := sha256.New()
+	for _, part := range append([]string{opts.Query, opts.OperationName, string(variables)}, versions...) {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// bodyETag returns the strong ETag of a response body.
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified sets the ETag header of the response and, if the If-None-Match header of the
+// request matches it, answers with the 304 status and returns true.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == etag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// queryOperationName returns the name of operation if it is a query, the only operations whose
+// responses get ETags.
+func queryOperationName(operation *ast.OperationDefinition) (string, bool) {
+	if operation == nil || operation.Operation != ast.OperationTypeQuery {
+		return "", false
+	}
+	if operation.Name == nil {
+		return "", true
+	}
+	return operation.Name.Value, true
+}
+
+// requestOperation returns the operation of the document of the request that it executes, nil if
+// the document is invalid or doesn't tell which one. The document is parsed through cache, where
+// the execution of the request then finds it.
+func requestOperation(cache *graphql.DocumentCache, opts *RequestOptions) *ast.OperationDefinition {
+	document, err := cache.Parse(opts.Query)
+	if err != nil {
+		return nil
+	}
+	var found *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if opts.OperationName == "" {
+			if found != nil {
+				return nil
+			}
+			found = operation
+		} else if operation.Name != nil && operation.Name.Value == opts.OperationName {
+			found = operation
+		}
+	}
+	return found
+}
@@ -3,9 +3,20 @@ package handler
 import (
 	"context"
 	"net/http"
+	"path"
+	"time"
 
 	"github.com/fiatjaf/graphql"
 	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/parser"
+)
+
+const (
+	// ClientNameHeader and ClientVersionHeader are the headers of the requests naming the client
+	// that sent them, reported in the graphql.RequestInfo of the requests.
+	ClientNameHeader    = "apollographql-client-name"
+	ClientVersionHeader = "apollographql-client-version"
 )
 
 const (
@@ -14,24 +25,88 @@ const (
 	ContentTypeFormURLEncoded = "application/x-www-form-urlencoded"
 )
 
+// ResultCallbackFn is called with the result of every HTTP request and the response body sent, if
+// it wasn't streamed. The client and operation of the request are available in ctx, see
+// graphql.RequestInfoFromContext, along with the parsed operation, its coerced variables and its
+// complexity, see graphql.OperationContextFromContext, which are left empty when the result was
+// shared by an identical request or came from the ResponseCache. The requests whose client
+// disconnected get a result with a single error with the CodeClientDisconnected code.
 type ResultCallbackFn func(ctx context.Context, params *graphql.Params, result *graphql.Result, responseBody []byte)
 
+// ConnectionInitFn is called with the payload of the connection_init message of every websocket
+// connection, before it is acknowledged, and returns the context used for the operations of the
+// connection. If it returns an error the connection is refused: a connection_error message is sent
+// on the legacy protocol, the connection is closed with the 4403 code on graphql-transport-ws.
+type ConnectionInitFn func(ctx context.Context, payload map[string]interface{}) (context.Context, error)
+
+// RequestDidArriveFn is called with the request options of every incoming operation, on both the HTTP
+// and the websocket paths, before the query is parsed. It may rewrite the query, variables or operation
+// name in place. If it returns an error the operation is not executed and the error is sent to the client.
+type RequestDidArriveFn func(ctx context.Context, opts *RequestOptions) error
+
+// LogRequestFn is called with the log-safe representation of every operation about to be
+// executed, on both the HTTP and the websocket paths, see graphql.SanitizeRequest. The query is
+// left empty when it can't be parsed.
+type LogRequestFn func(ctx context.Context, request *graphql.SanitizedRequest)
+
+// RolesFn returns the roles of the user of the request of ctx.
+type RolesFn func(ctx context.Context) []string
+
 type Handler struct {
-	Schema                 *graphql.Schema
-	ModifyContextOnHeaders func(ctx context.Context, headers map[string]string) context.Context
-	pretty                 bool
-	graphiql               bool
-	playground             bool
-	websocket              bool
-	rootObjectFn           RootObjectFn
-	resultCallbackFn       ResultCallbackFn
-	formatErrorFn          func(err error) gqlerrors.FormattedError
+	Schema                   *graphql.Schema
+	ModifyContextOnHeaders   func(ctx context.Context, headers map[string]string) context.Context
+	pretty                   bool
+	graphiql                 bool
+	playground               bool
+	websocket                bool
+	rootObjectFn             RootObjectFn
+	operationRootObjectFn    OperationRootObjectFn
+	forbidGETQueries         bool
+	forbidWebSocketMutations bool
+	csrfPrevention           bool
+	csrfPreventionHeaders    []string
+	cors                     *CORS
+	resultCallbackFn         ResultCallbackFn
+	requestDidArriveFn       RequestDidArriveFn
+	logRequestFn             LogRequestFn
+	scrubVariableFn          graphql.ScrubVariableFn
+	connectionInitFn         ConnectionInitFn
+	allowOperationFn         graphql.AllowOperationFn
+	serialOperations         bool
+	maxCost                  int
+	maxNodes                 int
+	multiplexer              *subscriptionMultiplexer
+	rateLimitFn              graphql.RateLimitFn
+	formatErrorFn            func(err error) gqlerrors.FormattedError
+	streamResponse           bool
+	maxResponseSize          int64
+	etags                    bool
+	etagOperationFn          ETagOperationFn
+	tracePropagator          TracePropagator
+	persistedQueries         PersistedQueryStore
+	persistedQueriesOnly     bool
+	rolesFn                  RolesFn
+	documentCache            *graphql.DocumentCache
+	connectionCacheSize      int
+	wsReadBufferSize         int
+	wsWriteBufferSize        int
+	resumeTokens             *resumeTokens
+	subscriptionEventFn      SubscriptionEventFn
+	sortFields               bool
+	schemaSelection          *schemaSelection
+	visibilityFn             graphql.VisibilityFn
+	responseCache            *ResponseCache
+	deduplication            *Deduplication
 }
 
 type RequestOptions struct {
 	Query         string                 `json:"query" url:"query" schema:"query"`
 	Variables     map[string]interface{} `json:"variables" url:"variables" schema:"variables"`
 	OperationName string                 `json:"operationName" url:"operationName" schema:"operationName"`
+	Extensions    map[string]interface{} `json:"extensions" url:"extensions" schema:"extensions"`
+
+	// ID is the id of a persisted query, sent instead of the query, see Config.PersistedQueries.
+	ID string `json:"id" url:"id" schema:"id"`
 }
 
 // a workaround for getting`variables` as a JSON string
@@ -39,10 +114,15 @@ type requestOptionsCompatibility struct {
 	Query         string `json:"query" url:"query" schema:"query"`
 	Variables     string `json:"variables" url:"variables" schema:"variables"`
 	OperationName string `json:"operationName" url:"operationName" schema:"operationName"`
+	Extensions    string `json:"extensions" url:"extensions" schema:"extensions"`
+	ID            string `json:"id" url:"id" schema:"id"`
 }
 
 // ServeHTTP provides an entrypoint into executing graphQL queries.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.cors != nil && h.cors.handle(w, r) {
+		return
+	}
 	if r.Header.Get("Upgrade") == "websocket" && h.websocket {
 		h.ContextWebsocketHandler(context.Background(), w, r)
 	} else {
@@ -53,15 +133,171 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // RootObjectFn allows a user to generate a RootObject per request
 type RootObjectFn func(ctx context.Context, r *http.Request) map[string]interface{}
 
+// OperationRootObjectFn generates the RootObject of every operation, given the request options of
+// the operation. r is the request of the operation, or the one that opened its websocket connection.
+type OperationRootObjectFn func(ctx context.Context, r *http.Request, opts *RequestOptions) map[string]interface{}
+
 type Config struct {
-	Schema           *graphql.Schema
-	Pretty           bool
-	GraphiQL         bool
-	Playground       bool
-	WebSocket        bool
-	RootObjectFn     RootObjectFn
-	ResultCallbackFn ResultCallbackFn
-	FormatErrorFn    func(err error) gqlerrors.FormattedError
+	Schema             *graphql.Schema
+	Pretty             bool
+	GraphiQL           bool
+	Playground         bool
+	WebSocket          bool
+	RootObjectFn       RootObjectFn
+	ResultCallbackFn   ResultCallbackFn
+	RequestDidArriveFn RequestDidArriveFn
+	ConnectionInitFn   ConnectionInitFn
+	MaxCost            int
+	MaxNodes           int
+	RateLimitFn        graphql.RateLimitFn
+	FormatErrorFn      func(err error) gqlerrors.FormattedError
+
+	// MultiplexSubscriptions makes identical subscriptions (same query, operation name, variables
+	// and Authorization and Cookie headers) share a single execution whose results are sent to all
+	// of them. Every subscriber is validated and checked by AllowOperationFn, MaxCost and
+	// RateLimitFn before it joins, but the execution gets the context and root object of the first
+	// subscriber, so this must only be enabled when the results of the subscriptions don't depend
+	// on them otherwise. It is ignored when VisibilityFn or ConnectionInitFn is set, or when
+	// ModifyContextOnHeaders is, as the subscriptions would depend on them.
+	MultiplexSubscriptions bool
+
+	// AllowedOperations restricts the operations that can be executed to those whose name matches
+	// one of these patterns, as matched by path.Match (e.g. "Public*"). Anonymous operations are
+	// only allowed by the "*" pattern. No restriction applies when it is empty.
+	AllowedOperations []string
+
+	// AllowOperationFn, when set, must also allow an operation for it to be executed.
+	AllowOperationFn graphql.AllowOperationFn
+
+	// SerialWebSocketOperations makes the queries and mutations sent on a websocket connection run
+	// one after the other, in the order they were received, instead of concurrently. Subscriptions
+	// and live queries still run concurrently, as they run until they are stopped.
+	SerialWebSocketOperations bool
+
+	// StreamResponse makes the HTTP responses be sent in chunks as they are encoded instead of
+	// being encoded in memory first, which is how very large results should be sent. Pretty is
+	// ignored then, and ResultCallbackFn gets no response body.
+	StreamResponse bool
+
+	// MaxResponseSize, when above zero, is the maximum size in bytes of the HTTP responses: an
+	// error is sent instead of a larger response. When the response is streamed it may already be
+	// partly sent when it exceeds the size, it is aborted then.
+	MaxResponseSize int64
+
+	// ETags makes the responses of the queries requested with GET get a strong ETag, computed from
+	// the encoded response or from the versions declared with ResponseVersion, and be answered
+	// with the 304 status when the request's If-None-Match header matches it. Responses with
+	// errors don't get an ETag, nor streamed responses unless versions were declared.
+	ETags bool
+
+	// ETagOperationFn, when set, is called to tell if the responses of an operation get an ETag.
+	ETagOperationFn ETagOperationFn
+
+	// TracePropagator extracts the distributed trace of the requests into the context of their
+	// operations. DefaultTracePropagator is used when it is nil, NoTracePropagator disables it.
+	TracePropagator TracePropagator
+
+	// PersistedQueries, when set, holds the queries that the requests can send the id of instead
+	// of the query itself: in their "id" member, or as the sha256Hash of their persistedQuery
+	// extension. See LoadManifest to load them from manifest files.
+	PersistedQueries PersistedQueryStore
+
+	// PersistedQueriesOnly makes the handler only execute the queries of PersistedQueries, so that
+	// it is an allowlist of the operations of the clients.
+	PersistedQueriesOnly bool
+
+	// RolesFn returns the roles of the user of a request, like auth.RolesFromContext. The roles
+	// restrict the persisted operations that can be executed, see PersistedOperation.Roles.
+	RolesFn RolesFn
+
+	// DocumentCacheSize, when above zero, is the number of documents parsed and validated that
+	// are cached for all the requests, see graphql.DocumentCache.
+	DocumentCacheSize int
+
+	// ConnectionDocumentCacheSize, when above zero, is the number of documents parsed and
+	// validated that are cached for the operations of each websocket connection, so that the
+	// clients subscribing again and again with the same queries don't get them parsed and
+	// validated each time. These caches are used instead of the one of DocumentCacheSize.
+	ConnectionDocumentCacheSize int
+
+	// WebSocketReadBufferSize and WebSocketWriteBufferSize, when above zero, are the sizes in
+	// bytes of the I/O buffers of the websocket connections, 1024 by default. Messages larger
+	// than the write buffer are written in several frames, so raising it helps connections
+	// receiving large results, at the cost of memory per connection; see the subscription
+	// benchmarks of the handler.
+	WebSocketReadBufferSize  int
+	WebSocketWriteBufferSize int
+
+	// ResumeTokenTTL, when above zero, makes the websocket connections be issued a resume token,
+	// sent as {"resumeToken": "..."} in the payload of connection_ack. A client reconnecting with
+	// the token in the payload of its connection_init gets the subscriptions of its previous
+	// connection restored under their ids, without subscribing again, if the connection closed
+	// less than ResumeTokenTTL ago. The subscriptions run with the context of the new connection.
+	ResumeTokenTTL time.Duration
+
+	// SubscriptionEventFn, when set, is called with the events of the lifecycle of the
+	// subscriptions of the websocket connections: when they start, deliver results, fail,
+	// complete or are cancelled, and on every ping of their connection while they run.
+	SubscriptionEventFn SubscriptionEventFn
+
+	// SortFields makes the fields of the responses be sorted by name, which is cheaper, instead of
+	// in the order they were selected in the queries, as the spec requires, see
+	// graphql.Params.PreserveFieldOrder.
+	SortFields bool
+
+	// OperationRootObjectFn, when set, is used instead of RootObjectFn to generate the RootObject
+	// of the operations. Both are called for the operations of the websocket connections too, with
+	// the request that opened the connection.
+	OperationRootObjectFn OperationRootObjectFn
+
+	// ForbidGETQueries rejects the queries sent with GET requests, with the OPERATION_NOT_ALLOWED
+	// code, so that they must be POSTed. Mutations are never allowed in GET requests.
+	ForbidGETQueries bool
+
+	// ForbidWebSocketMutations rejects the mutations sent over websocket connections, with the
+	// OPERATION_NOT_ALLOWED code, so that they must be sent with HTTP requests.
+	ForbidWebSocketMutations bool
+
+	// CSRFPrevention refuses, with the 400 status, the requests that browsers may send from the
+	// pages of other sites without a preflight: the GET requests and those with a simple content
+	// type, unless they have one of the CSRFPreventionHeaders. Clients must then set a JSON content
+	// type or one of the headers. The pages of GraphiQL and Playground are still served.
+	CSRFPrevention bool
+
+	// CSRFPreventionHeaders are the headers that make the requests pass the CSRF prevention,
+	// DefaultCSRFPreventionHeaders if empty.
+	CSRFPreventionHeaders []string
+
+	// CORS, when set, makes ServeHTTP answer the preflight requests and set the CORS headers of
+	// the responses, and refuse the websocket connections opened from origins not allowed.
+	CORS *CORS
+
+	// SchemaSelectorFn, when set, selects the schema of every request instead of Schema, e.g. the
+	// schema of its tenant or the admin schema for the requests to an admin path. The websocket
+	// connections execute all their operations against the schema selected for the request that
+	// opened them.
+	SchemaSelectorFn SchemaSelectorFn
+
+	// VisibilityFn, when set, hides from every request the fields and types of the schema that it
+	// tells aren't visible to it, e.g. after the roles of its user, see graphql.VisibilityFn.
+	VisibilityFn graphql.VisibilityFn
+
+	// ResponseCache, when set, caches the responses to the queries requested with HTTP, see
+	// ResponseCache. The cached responses are sent without executing the query, so neither
+	// RateLimitFn nor the resolvers are called for them, but they are only sent for the queries
+	// that AllowOperationFn, AllowedOperations and ForbidGETQueries let execute. Responses are
+	// buffered rather than streamed when it is set. Nothing is cached when VisibilityFn is set, nor
+	// for the requests with internal access, see graphql.WithInternalAccess.
+	ResponseCache *ResponseCache
+
+	// LogRequestFn, when set, is called with the log-safe representation of every operation, whose
+	// variables are scrubbed with ScrubVariableFn, or replaced by their type when it is nil.
+	LogRequestFn    LogRequestFn
+	ScrubVariableFn graphql.ScrubVariableFn
+
+	// Deduplication, when set, makes the identical queries requested with HTTP at the same time be
+	// executed once, sharing their result, see Deduplication.
+	Deduplication *Deduplication
 }
 
 func NewConfig() *Config {
@@ -79,18 +315,141 @@ func New(p *Config) *Handler {
 		p = NewConfig()
 	}
 
-	if p.Schema == nil {
+	if p.Schema == nil && p.SchemaSelectorFn == nil {
 		panic("undefined GraphQL schema")
 	}
 
+	var selection *schemaSelection
+	if p.SchemaSelectorFn != nil {
+		selection = &schemaSelection{selectorFn: p.SchemaSelectorFn, cacheSize: p.DocumentCacheSize}
+	}
+
+	var multiplexer *subscriptionMultiplexer
+	if p.MultiplexSubscriptions && p.VisibilityFn == nil && p.ConnectionInitFn == nil {
+		multiplexer = newSubscriptionMultiplexer()
+	}
+
+	var resumeTokens *resumeTokens
+	if p.ResumeTokenTTL > 0 {
+		resumeTokens = newResumeTokens(p.ResumeTokenTTL)
+	}
+
+	var documentCache *graphql.DocumentCache
+	if p.DocumentCacheSize > 0 {
+		documentCache = graphql.NewDocumentCache(p.DocumentCacheSize)
+	}
+
+	allowOperationFn := p.AllowOperationFn
+	if len(p.AllowedOperations) != 0 {
+		allowOperationFn = allowOperations(p.AllowedOperations, p.AllowOperationFn)
+	}
+
 	return &Handler{
-		Schema:           p.Schema,
-		pretty:           p.Pretty,
-		graphiql:         p.GraphiQL,
-		websocket:        p.WebSocket,
-		playground:       p.Playground,
-		rootObjectFn:     p.RootObjectFn,
-		resultCallbackFn: p.ResultCallbackFn,
-		formatErrorFn:    p.FormatErrorFn,
+		Schema:                   p.Schema,
+		pretty:                   p.Pretty,
+		graphiql:                 p.GraphiQL,
+		websocket:                p.WebSocket,
+		playground:               p.Playground,
+		rootObjectFn:             p.RootObjectFn,
+		operationRootObjectFn:    p.OperationRootObjectFn,
+		forbidGETQueries:         p.ForbidGETQueries,
+		forbidWebSocketMutations: p.ForbidWebSocketMutations,
+		csrfPrevention:           p.CSRFPrevention,
+		csrfPreventionHeaders:    p.CSRFPreventionHeaders,
+		cors:                     p.CORS,
+		schemaSelection:          selection,
+		visibilityFn:             p.VisibilityFn,
+		responseCache:            p.ResponseCache,
+		deduplication:            p.Deduplication,
+		resultCallbackFn:         p.ResultCallbackFn,
+		requestDidArriveFn:       p.RequestDidArriveFn,
+		logRequestFn:             p.LogRequestFn,
+		scrubVariableFn:          p.ScrubVariableFn,
+		connectionInitFn:         p.ConnectionInitFn,
+		allowOperationFn:         allowOperationFn,
+		serialOperations:         p.SerialWebSocketOperations,
+		maxCost:                  p.MaxCost,
+		maxNodes:                 p.MaxNodes,
+		multiplexer:              multiplexer,
+		rateLimitFn:              p.RateLimitFn,
+		formatErrorFn:            p.FormatErrorFn,
+		streamResponse:           p.StreamResponse,
+		maxResponseSize:          p.MaxResponseSize,
+		etags:                    p.ETags,
+		etagOperationFn:          p.ETagOperationFn,
+		tracePropagator:          p.TracePropagator,
+		persistedQueries:         p.PersistedQueries,
+		persistedQueriesOnly:     p.PersistedQueriesOnly,
+		rolesFn:                  p.RolesFn,
+		documentCache:            documentCache,
+		connectionCacheSize:      p.ConnectionDocumentCacheSize,
+		wsReadBufferSize:         p.WebSocketReadBufferSize,
+		wsWriteBufferSize:        p.WebSocketWriteBufferSize,
+		resumeTokens:             resumeTokens,
+		subscriptionEventFn:      p.SubscriptionEventFn,
+		sortFields:               p.SortFields,
+	}
+}
+
+// rootObject returns the RootObject of an operation of r, nil if no function generates it.
+func (h *Handler) rootObject(ctx context.Context, r *http.Request, opts *RequestOptions) map[string]interface{} {
+	if h.operationRootObjectFn != nil {
+		return h.operationRootObjectFn(ctx, r, opts)
+	}
+	if h.rootObjectFn != nil {
+		return h.rootObjectFn(ctx, r)
+	}
+	return nil
+}
+
+// clientRequestInfo returns the RequestInfo naming the client that sent r.
+func clientRequestInfo(r *http.Request) *graphql.RequestInfo {
+	return &graphql.RequestInfo{
+		ClientName:    r.Header.Get(ClientNameHeader),
+		ClientVersion: r.Header.Get(ClientVersionHeader),
+	}
+}
+
+// logRequest calls the LogRequestFn, if any, with the log-safe representation of the operation
+// of opts.
+func (h *Handler) logRequest(ctx context.Context, opts *RequestOptions) {
+	if h.logRequestFn == nil {
+		return
+	}
+	document, err := parser.Parse(parser.ParseParams{Source: opts.Query})
+	if err != nil {
+		document = ast.NewDocument(nil)
+	}
+	request := graphql.SanitizeRequest(document, opts.OperationName, opts.Variables, h.scrubVariableFn)
+	if err != nil {
+		request.Query = ""
+	}
+	h.logRequestFn(ctx, request)
+}
+
+// formatErrors rewrites the errors of result with the configured FormatErrorFn, if any. result is
+// left untouched as it may be shared with other connections, a copy is returned instead.
+func (h *Handler) formatErrors(result *graphql.Result) *graphql.Result {
+	if formatErrorFn := h.formatErrorFn; formatErrorFn != nil && len(result.Errors) > 0 {
+		formatted := *result
+		formatted.Errors = make([]gqlerrors.FormattedError, len(result.Errors))
+		for i, formattedError := range result.Errors {
+			formatted.Errors[i] = formatErrorFn(formattedError.OriginalError())
+		}
+		return &formatted
+	}
+	return result
+}
+
+// allowOperations returns an AllowOperationFn allowing the operations whose name matches one of
+// patterns and that are allowed by allowOperationFn, if it isn't nil.
+func allowOperations(patterns []string, allowOperationFn graphql.AllowOperationFn) graphql.AllowOperationFn {
+	return func(ctx context.Context, operationName string) bool {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, operationName); matched {
+				return allowOperationFn == nil || allowOperationFn(ctx, operationName)
+			}
+		}
+		return false
 	}
 }
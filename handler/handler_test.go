@@ -2,14 +2,22 @@ package handler_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/fiatjaf/graphql"
 	"github.com/fiatjaf/graphql/gqlerrors"
@@ -289,3 +297,1165 @@ func TestHandler_BasicQuery_WithFormatErrorFn(t *testing.T) {
 		t.Fatalf("wrong result, graphql result diff: %v", testutil.Diff(expected, result))
 	}
 }
+
+func TestHandler_RequestDidArriveFn_RewritesRequest(t *testing.T) {
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"hero": map[string]interface{}{
+				"name": "Luke Skywalker",
+			},
+		},
+	}
+	queryString := `query=query HeroNameQuery($episode: Episode) { hero(episode: $episode) { name } }`
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/graphql?%v", queryString), nil)
+
+	h := handler.New(&handler.Config{
+		Schema: &testutil.StarWarsSchema,
+		RequestDidArriveFn: func(ctx context.Context, opts *handler.RequestOptions) error {
+			opts.Variables = map[string]interface{}{"episode": "EMPIRE"}
+			return nil
+		},
+	})
+	result, resp := executeTest(t, h, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("unexpected server response %v", resp.Code)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("wrong result, graphql result diff: %v", testutil.Diff(expected, result))
+	}
+}
+
+func TestHandler_RequestDidArriveFn_AbortsRequest(t *testing.T) {
+	expected := &graphql.Result{
+		Errors: []gqlerrors.FormattedError{
+			{Message: "request rejected", Locations: []location.SourceLocation{}},
+		},
+	}
+	queryString := `query=query HeroNameQuery { hero { name } }`
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/graphql?%v", queryString), nil)
+
+	h := handler.New(&handler.Config{
+		Schema: &testutil.StarWarsSchema,
+		RequestDidArriveFn: func(ctx context.Context, opts *handler.RequestOptions) error {
+			return errors.New("request rejected")
+		},
+	})
+	result, resp := executeTest(t, h, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("unexpected server response %v", resp.Code)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("wrong result, graphql result diff: %v", testutil.Diff(expected, result))
+	}
+}
+
+func TestHandler_RateLimitFn_RejectsRequest(t *testing.T) {
+	expected := &graphql.Result{
+		Errors: []gqlerrors.FormattedError{
+			{Message: "HeroNameQuery costs too much: 2", Locations: []location.SourceLocation{}},
+		},
+		Extensions: map[string]interface{}{
+			"cost": map[string]interface{}{"requestedQueryCost": float64(2)},
+		},
+	}
+	queryString := `query=query HeroNameQuery { hero { name } }`
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/graphql?%v", queryString), nil)
+
+	h := handler.New(&handler.Config{
+		Schema: &testutil.StarWarsSchema,
+		RateLimitFn: func(ctx context.Context, operationName string, cost int) error {
+			return fmt.Errorf("%s costs too much: %d", operationName, cost)
+		},
+	})
+	result, resp := executeTest(t, h, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("unexpected server response %v", resp.Code)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("wrong result, graphql result diff: %v", testutil.Diff(expected, result))
+	}
+}
+
+func TestHandler_AllowedOperations(t *testing.T) {
+	h := handler.New(&handler.Config{
+		Schema:            &testutil.StarWarsSchema,
+		AllowedOperations: []string{"Hero*"},
+		AllowOperationFn: func(ctx context.Context, operationName string) bool {
+			return operationName != "HeroSecretQuery"
+		},
+	})
+
+	tests := []struct {
+		query   string
+		message string
+	}{
+		{`query HeroNameQuery { hero { name } }`, ""},
+		{`query HeroSecretQuery { hero { name } }`, `Operation "HeroSecretQuery" is not allowed.`},
+		{`query InternalQuery { hero { name } }`, `Operation "InternalQuery" is not allowed.`},
+		{`{ hero { name } }`, `Anonymous operations are not allowed.`},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/graphql?query="+url.QueryEscape(test.query), nil)
+		result, _ := executeTest(t, h, req)
+		if test.message == "" {
+			if len(result.Errors) > 0 {
+				t.Fatalf("wrong result, unexpected errors: %v", result.Errors)
+			}
+			continue
+		}
+		if len(result.Errors) != 1 || result.Errors[0].Message != test.message {
+			t.Fatalf("expected error %q for %s, got %v", test.message, test.query, result.Errors)
+		}
+	}
+}
+
+func itemsSchema(t *testing.T) *graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"items": &graphql.Field{
+					Type: graphql.NewList(graphql.String),
+					Args: graphql.FieldConfigArgument{
+						"count": &graphql.ArgumentConfig{Type: graphql.Int},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						items := make([]interface{}, p.Args["count"].(int))
+						for i := range items {
+							items[i] = strings.Repeat(fmt.Sprint(i%10), 100)
+						}
+						return items, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &schema
+}
+
+func TestHandler_StreamResponse(t *testing.T) {
+	schema := itemsSchema(t)
+	h := handler.New(&handler.Config{
+		Schema:         schema,
+		StreamResponse: true,
+	})
+
+	query := `{ items(count: 2000) }`
+	req, _ := http.NewRequest("GET", "/graphql?query="+url.QueryEscape(query), nil)
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	expected, _ := json.Marshal(graphql.Do(graphql.Params{Schema: *schema, RequestString: query}))
+	if resp.Body.String() != string(expected) {
+		t.Fatalf("expected the streamed response to be the encoded result, got %d bytes instead of %d", resp.Body.Len(), len(expected))
+	}
+	if !resp.Flushed {
+		t.Fatalf("expected the response to be flushed")
+	}
+}
+
+func TestHandler_MaxResponseSize(t *testing.T) {
+	schema := itemsSchema(t)
+	message := "the response exceeds the maximum size of 10000 bytes"
+	for _, stream := range []bool{false, true} {
+		h := handler.New(&handler.Config{
+			Schema:          schema,
+			StreamResponse:  stream,
+			MaxResponseSize: 10000,
+		})
+
+		req, _ := http.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{ items(count: 10) }`), nil)
+		result, _ := executeTest(t, h, req)
+		if len(result.Errors) > 0 {
+			t.Fatalf("stream %v: unexpected errors: %v", stream, result.Errors)
+		}
+
+		req, _ = http.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{ items(count: 200) }`), nil)
+		result, _ = executeTest(t, h, req)
+		if len(result.Errors) != 1 || result.Errors[0].Message != message {
+			t.Fatalf("stream %v: expected error %q, got %v", stream, message, result.Errors)
+		}
+	}
+
+	// a streamed response exceeding the size after its first chunk was sent is aborted
+	server := httptest.NewServer(handler.New(&handler.Config{
+		Schema:          schema,
+		StreamResponse:  true,
+		MaxResponseSize: 100000,
+	}))
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/graphql?query=" + url.QueryEscape(`{ items(count: 2000) }`))
+	if err == nil {
+		_, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err == nil {
+		t.Fatalf("expected the response to be aborted")
+	}
+}
+
+func TestHandler_ETags(t *testing.T) {
+	h := handler.New(&handler.Config{
+		Schema: &testutil.StarWarsSchema,
+		ETags:  true,
+		ETagOperationFn: func(ctx context.Context, operationName string) bool {
+			return operationName != "Uncached"
+		},
+	})
+	get := func(query, ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/graphql?query="+url.QueryEscape(query), nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := get(`query Hero { hero { name } }`, "")
+	etag := resp.Header().Get("ETag")
+	if resp.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected a response with an ETag, got %v %q", resp.Code, etag)
+	}
+	if resp := get(`query Hero { hero { name } }`, `"other", `+etag); resp.Code != http.StatusNotModified || resp.Body.Len() != 0 {
+		t.Fatalf("expected a 304 response without a body, got %v %q", resp.Code, resp.Body.String())
+	}
+	if resp := get(`query Hero { hero { id } }`, etag); resp.Code != http.StatusOK || resp.Header().Get("ETag") == etag {
+		t.Fatalf("expected another response with another ETag, got %v %q", resp.Code, resp.Header().Get("ETag"))
+	}
+
+	// operations disabled by ETagOperationFn and responses with errors get no ETag
+	for _, query := range []string{`query Uncached { hero { name } }`, `{ hero { unknown } }`} {
+		if resp := get(query, etag); resp.Code != http.StatusOK || resp.Header().Get("ETag") != "" {
+			t.Fatalf("expected no ETag for %s, got %v %q", query, resp.Code, resp.Header().Get("ETag"))
+		}
+	}
+
+	// nor do POST requests
+	req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "query Hero { hero { name } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-None-Match", etag)
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK || resp.Header().Get("ETag") != "" {
+		t.Fatalf("expected no ETag for POST requests, got %v %q", resp.Code, resp.Header().Get("ETag"))
+	}
+}
+
+func TestHandler_ETags_ResponseVersion(t *testing.T) {
+	version := "v1"
+	calls := 0
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"now": &graphql.Field{
+					Type: graphql.Int,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						handler.ResponseVersion(p.Context, version)
+						calls++
+						return calls, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{Schema: &schema, ETags: true, StreamResponse: true})
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{ now }`), nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		return resp
+	}
+
+	etag := get("").Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("expected the streamed response to get an ETag from its version")
+	}
+	// the body changed, but not the version
+	if resp := get(etag); resp.Code != http.StatusNotModified {
+		t.Fatalf("expected a 304 response, got %v", resp.Code)
+	}
+	version = "v2"
+	if resp := get(etag); resp.Code != http.StatusOK || resp.Header().Get("ETag") == etag {
+		t.Fatalf("expected a new response with a new ETag, got %v %q", resp.Code, resp.Header().Get("ETag"))
+	}
+}
+
+func TestHandler_ResultCallbackFn_RequestInfo(t *testing.T) {
+	var info *graphql.RequestInfo
+	h := handler.New(&handler.Config{
+		Schema: &testutil.StarWarsSchema,
+		ResultCallbackFn: func(ctx context.Context, params *graphql.Params, result *graphql.Result, responseBody []byte) {
+			info = graphql.RequestInfoFromContext(ctx)
+		},
+	})
+	req, _ := http.NewRequest("GET", "/graphql?query="+url.QueryEscape(`query HeroNameQuery { hero { name } }`), nil)
+	req.Header.Set(handler.ClientNameHeader, "ios")
+	req.Header.Set(handler.ClientVersionHeader, "3.1.0")
+	executeTest(t, h, req)
+
+	expected := &graphql.RequestInfo{
+		ClientName:    "ios",
+		ClientVersion: "3.1.0",
+		OperationName: "HeroNameQuery",
+		OperationType: "query",
+	}
+	if !reflect.DeepEqual(expected, info) {
+		t.Fatalf("expected %+v, got %+v", expected, info)
+	}
+}
+
+func TestHandler_ResultCallbackFn_OperationContext(t *testing.T) {
+	var operation *graphql.OperationContext
+	h := handler.New(&handler.Config{
+		Schema:  &testutil.StarWarsSchema,
+		MaxCost: 100,
+		ResultCallbackFn: func(ctx context.Context, params *graphql.Params, result *graphql.Result, responseBody []byte) {
+			operation = graphql.OperationContextFromContext(ctx)
+		},
+	})
+	query := `query HeroNameQuery($episode: Episode = JEDI) { hero(episode: $episode) { name } }`
+	req, _ := http.NewRequest("GET", "/graphql?query="+url.QueryEscape(query), nil)
+	started := time.Now()
+	executeTest(t, h, req)
+
+	if operation == nil {
+		t.Fatal("expected an OperationContext")
+	}
+	if operation.OperationName != "HeroNameQuery" || operation.OperationType != "query" {
+		t.Fatalf("unexpected operation %q of type %q", operation.OperationName, operation.OperationType)
+	}
+	if operation.Operation == nil || operation.Document == nil || operation.Document.Definitions[0] != operation.Operation {
+		t.Fatalf("expected the parsed operation, got %v", operation.Operation)
+	}
+	if expected := map[string]interface{}{"episode": 6}; !reflect.DeepEqual(operation.Variables, expected) {
+		t.Fatalf("expected variables %v, got %v", expected, operation.Variables)
+	}
+	if operation.Complexity != 2 {
+		t.Fatalf("expected a complexity of 2, got %d", operation.Complexity)
+	}
+	if operation.Started.Before(started) || time.Since(operation.Started) > time.Second {
+		t.Fatalf("unexpected start time %v", operation.Started)
+	}
+}
+
+func TestHandler_LogRequestFn(t *testing.T) {
+	var logged []*graphql.SanitizedRequest
+	h := handler.New(&handler.Config{
+		Schema: &testutil.StarWarsSchema,
+		LogRequestFn: func(ctx context.Context, request *graphql.SanitizedRequest) {
+			logged = append(logged, request)
+		},
+		ScrubVariableFn: graphql.KeepVariables("episode"),
+	})
+	query := `query HeroNameQuery($episode: Episode, $id: String!) { hero(episode: $episode) { name } human(id: $id) { name } }`
+	body, _ := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": map[string]interface{}{"episode": "JEDI", "id": "1000"},
+	})
+	req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	executeTest(t, h, req)
+	req, _ = http.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ hero(password: ", "variables": {"password": "hunter2"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	executeTest(t, h, req)
+
+	expected := []*graphql.SanitizedRequest{
+		{
+			Query: `query HeroNameQuery($episode: Episode, $id: String!) {
+  hero(episode: $episode) {
+    name
+  }
+  human(id: $id) {
+    name
+  }
+}
+`,
+			Variables: map[string]interface{}{"episode": "JEDI", "id": "<String!>"},
+		},
+		{Variables: map[string]interface{}{"password": "<redacted>"}},
+	}
+	if !reflect.DeepEqual(expected, logged) {
+		t.Fatalf("expected the logged requests %#v, got %#v", expected, logged)
+	}
+}
+
+func TestHandler_TracePropagation(t *testing.T) {
+	var outgoing *http.Request
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"call": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						outgoing, _ = http.NewRequest("GET", "http://downstream", nil)
+						handler.InjectTraceHeaders(p.Context, outgoing)
+						return p.Context.Value("propagated"), nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	h := handler.New(&handler.Config{Schema: &schema})
+	req, _ := http.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{ call }`), nil)
+	req.Header.Set("traceparent", traceParent)
+	req.Header.Set("tracestate", "vendor=value")
+	req.Header.Set("X-B3-Sampled", "1")
+	executeTest(t, h, req)
+	for name, value := range map[string]string{"traceparent": traceParent, "tracestate": "vendor=value", "X-B3-Sampled": "1"} {
+		if outgoing.Header.Get(name) != value {
+			t.Fatalf("expected the %v header to be %q, got %q", name, value, outgoing.Header.Get(name))
+		}
+	}
+
+	// a malformed traceparent is dropped with its tracestate
+	req.Header.Set("traceparent", "garbage")
+	executeTest(t, h, req)
+	if outgoing.Header.Get("traceparent") != "" || outgoing.Header.Get("tracestate") != "" || outgoing.Header.Get("X-B3-Sampled") != "1" {
+		t.Fatalf("expected only the B3 headers to be propagated, got %v", outgoing.Header)
+	}
+
+	// the propagator can be replaced
+	h = handler.New(&handler.Config{
+		Schema: &schema,
+		TracePropagator: handler.TracePropagatorFunc(func(ctx context.Context, header http.Header) context.Context {
+			return context.WithValue(ctx, "propagated", header.Get("X-Trace"))
+		}),
+	})
+	req, _ = http.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{ call }`), nil)
+	req.Header.Set("X-Trace", "abc")
+	req.Header.Set("traceparent", traceParent)
+	result, _ := executeTest(t, h, req)
+	if call := result.Data.(map[string]interface{})["call"]; call != "abc" || len(outgoing.Header) != 0 {
+		t.Fatalf("expected the custom propagator to be used, got %v and %v", call, outgoing.Header)
+	}
+}
+
+func TestHandler_PersistedQueries(t *testing.T) {
+	dir := t.TempDir()
+	relayPath := dir + "/relay.json"
+	apolloPath := dir + "/apollo.json"
+	ioutil.WriteFile(relayPath, []byte(`{
+		"hero": "query Hero { hero { name } }",
+		"cached": {"query": "{ hero { id } }", "cacheTTL": 60}
+	}`), 0o644)
+	ioutil.WriteFile(apolloPath, []byte(`{
+		"format": "apollo-persisted-query-manifest",
+		"version": 1,
+		"operations": [{"id": "abc123", "name": "Luke", "type": "query", "body": "query Luke { hero(episode: EMPIRE) { name } }"}]
+	}`), 0o644)
+	manifest, err := handler.LoadManifest(relayPath, apolloPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &handler.Config{Schema: &testutil.StarWarsSchema, PersistedQueries: manifest}
+	post := func(h *handler.Handler, body string) *graphql.Result {
+		req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		result, _ := executeTest(t, h, req)
+		return result
+	}
+	expectCode := func(result *graphql.Result, code string) {
+		t.Helper()
+		if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != code {
+			t.Fatalf("expected a %v error, got %v", code, result.Errors)
+		}
+	}
+
+	h := handler.New(config)
+	if result := post(h, `{"id": "hero"}`); !reflect.DeepEqual(result.Data, map[string]interface{}{"hero": map[string]interface{}{"name": "R2-D2"}}) {
+		t.Fatalf("unexpected result %v", result)
+	}
+	extensions := `"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "abc123"}}`
+	if result := post(h, `{`+extensions+`}`); !reflect.DeepEqual(result.Data, map[string]interface{}{"hero": map[string]interface{}{"name": "Luke Skywalker"}}) {
+		t.Fatalf("unexpected result %v", result)
+	}
+	req, _ := http.NewRequest("GET", "/graphql?id=hero", nil)
+	if result, _ := executeTest(t, h, req); len(result.Errors) != 0 || result.Data == nil {
+		t.Fatalf("expected the persisted query to be executed with GET, got %v", result)
+	}
+	if operation, _ := manifest.PersistedQuery(context.Background(), "cached"); operation.CacheTTL != time.Minute {
+		t.Fatalf("expected the cache TTL of the Relay operation, got %v", operation)
+	}
+	expectCode(post(h, `{"id": "unknown"}`), handler.CodePersistedQueryNotFound)
+	// other queries are executed unless only persisted queries are allowed
+	if result := post(h, `{"query": "{ hero { id } }"}`); len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+	config.PersistedQueriesOnly = true
+	h = handler.New(config)
+	expectCode(post(h, `{"query": "{ hero { id } }"}`), handler.CodePersistedQueryRequired)
+	if result := post(h, `{"id": "hero"}`); len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+
+	// the manifests are reloaded when they change
+	ctx, cancel := context.WithCancel(context.Background())
+	watching := make(chan struct{})
+	go func() {
+		defer close(watching)
+		manifest.Watch(ctx, time.Millisecond, func(err error) { t.Error(err) })
+	}()
+	defer func() {
+		cancel()
+		<-watching
+	}()
+	ioutil.WriteFile(dir+"/new.json", []byte(`{"luke": "{ hero(episode: EMPIRE) { name } }"}`), 0o644)
+	os.Chtimes(dir+"/new.json", time.Now(), time.Now().Add(time.Hour))
+	os.Rename(dir+"/new.json", relayPath)
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if _, ok := manifest.PersistedQuery(ctx, "luke"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the manifest to be reloaded")
+		}
+	}
+	expectCode(post(h, `{"id": "hero"}`), handler.CodePersistedQueryNotFound)
+}
+
+func TestParseManifest_Invalid(t *testing.T) {
+	for _, manifest := range []string{
+		`[]`,
+		`{"hero": 1}`,
+		`{"hero": {"cacheTTL": 60}}`,
+		`{"format": "apollo-persisted-query-manifest", "version": 2, "operations": []}`,
+	} {
+		if _, err := handler.ParseManifest([]byte(manifest)); err == nil {
+			t.Fatalf("expected %s to be invalid", manifest)
+		}
+	}
+}
+
+func TestHandler_PersistedQueries_CacheTTLAndRoles(t *testing.T) {
+	operations, err := handler.ParseManifest([]byte(`{
+		"format": "apollo-persisted-query-manifest",
+		"version": 1,
+		"operations": [
+			{"id": "hero", "body": "{ hero { name } }", "cacheTTL": 60},
+			{"id": "luke", "body": "{ hero(episode: EMPIRE) { name } }", "cacheTTL": 30, "roles": ["admin"]},
+			{"id": "failing", "body": "{ hero { unknown } }", "cacheTTL": 60}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{
+		Schema: &testutil.StarWarsSchema,
+		PersistedQueries: storeFunc(func(ctx context.Context, id string) (*handler.PersistedOperation, bool) {
+			operation, ok := operations[id]
+			return operation, ok
+		}),
+		RolesFn: func(ctx context.Context) []string {
+			roles, _ := ctx.Value("roles").([]string)
+			return roles
+		},
+	})
+	get := func(id string, roles ...string) (*graphql.Result, *httptest.ResponseRecorder) {
+		req, _ := http.NewRequest("GET", "/graphql?id="+id, nil)
+		req = req.WithContext(context.WithValue(req.Context(), "roles", roles))
+		return executeTest(t, h, req)
+	}
+
+	if result, resp := get("hero"); len(result.Errors) != 0 || resp.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Fatalf("expected a cacheable response, got %v and %q", result.Errors, resp.Header().Get("Cache-Control"))
+	}
+	if result, resp := get("failing"); len(result.Errors) == 0 || resp.Header().Get("Cache-Control") != "" {
+		t.Fatalf("expected an uncacheable error, got %v and %q", result.Errors, resp.Header().Get("Cache-Control"))
+	}
+	if result, resp := get("luke", "admin"); len(result.Errors) != 0 || resp.Header().Get("Cache-Control") != "private, max-age=30" {
+		t.Fatalf("expected a privately cacheable response, got %v and %q", result.Errors, resp.Header().Get("Cache-Control"))
+	}
+	if result, _ := get("luke", "user"); len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != handler.CodeForbidden {
+		t.Fatalf("expected a FORBIDDEN error, got %v", result.Errors)
+	}
+}
+
+func TestHandler_PersistedQueries_DefaultVariables(t *testing.T) {
+	operations, err := handler.ParseManifest([]byte(`{
+		"hero": {
+			"query": "query Hero($episode: Episode) { hero(episode: $episode) { name } }",
+			"defaultVariables": {"episode": "EMPIRE"}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{
+		Schema: &testutil.StarWarsSchema,
+		PersistedQueries: storeFunc(func(ctx context.Context, id string) (*handler.PersistedOperation, bool) {
+			operation, ok := operations[id]
+			return operation, ok
+		}),
+	})
+
+	for _, test := range []struct {
+		variables string
+		expected  string
+	}{
+		{"", "Luke Skywalker"},
+		{`{"episode": "JEDI"}`, "R2-D2"},
+		{`{"other": true}`, "Luke Skywalker"},
+	} {
+		target := "/graphql?id=hero"
+		if test.variables != "" {
+			target += "&variables=" + url.QueryEscape(test.variables)
+		}
+		req, _ := http.NewRequest("GET", target, nil)
+		result, _ := executeTest(t, h, req)
+		expected := map[string]interface{}{"hero": map[string]interface{}{"name": test.expected}}
+		if len(result.Errors) != 0 || !reflect.DeepEqual(expected, result.Data) {
+			t.Fatalf("expected %v with the variables %s, got %v and %v", expected, test.variables, result.Data, result.Errors)
+		}
+	}
+	if expected := map[string]interface{}{"episode": "EMPIRE"}; !reflect.DeepEqual(expected, operations["hero"].DefaultVariables) {
+		t.Fatalf("expected the default variables to be left unchanged, got %v", operations["hero"].DefaultVariables)
+	}
+}
+
+type storeFunc func(ctx context.Context, id string) (*handler.PersistedOperation, bool)
+
+func (fn storeFunc) PersistedQuery(ctx context.Context, id string) (*handler.PersistedOperation, bool) {
+	return fn(ctx, id)
+}
+
+func TestHandler_OperationTypesPerTransport(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"value": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return "value", nil
+				}},
+			},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"write": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return "written", nil
+				}},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		config   handler.Config
+		method   string
+		query    string
+		expected string
+	}{
+		{handler.Config{}, "GET", "mutation { write }", "Mutations are not allowed in GET requests."},
+		{handler.Config{}, "POST", "mutation { write }", ""},
+		{handler.Config{}, "GET", "{ value }", ""},
+		{handler.Config{ForbidGETQueries: true}, "GET", "{ value }", "Queries are not allowed in GET requests."},
+		{handler.Config{ForbidGETQueries: true}, "POST", "{ value }", ""},
+	}
+	for _, test := range tests {
+		test.config.Schema = &schema
+		h := handler.New(&test.config)
+		var req *http.Request
+		if test.method == "GET" {
+			req, _ = http.NewRequest("GET", "/graphql?query="+url.QueryEscape(test.query), nil)
+		} else {
+			req, _ = http.NewRequest("POST", "/graphql", strings.NewReader(test.query))
+			req.Header.Set("Content-Type", handler.ContentTypeGraphQL)
+		}
+		result, _ := executeTest(t, h, req)
+		if test.expected == "" {
+			if len(result.Errors) != 0 || result.Data == nil {
+				t.Fatalf("%s %s: unexpected errors %v", test.method, test.query, result.Errors)
+			}
+			continue
+		}
+		if len(result.Errors) != 1 || result.Errors[0].Message != test.expected ||
+			result.Errors[0].Extensions["code"] != handler.CodeOperationNotAllowed || result.Data != nil {
+			t.Fatalf("%s %s: expected the error %q, got %v", test.method, test.query, test.expected, result.Errors)
+		}
+	}
+}
+
+func TestHandler_CSRFPrevention(t *testing.T) {
+	tests := []struct {
+		config  handler.Config
+		method  string
+		headers map[string]string
+		blocked bool
+	}{
+		{handler.Config{}, "GET", nil, false},
+		{handler.Config{CSRFPrevention: true}, "GET", nil, true},
+		{handler.Config{CSRFPrevention: true}, "GET", map[string]string{"Apollo-Require-Preflight": "true"}, false},
+		{handler.Config{CSRFPrevention: true}, "GET", map[string]string{"X-Apollo-Operation-Name": "Hero"}, false},
+		{handler.Config{CSRFPrevention: true}, "POST", map[string]string{"Content-Type": "application/json"}, false},
+		{handler.Config{CSRFPrevention: true}, "POST", map[string]string{"Content-Type": "text/plain; charset=utf-8"}, true},
+		{handler.Config{CSRFPrevention: true, CSRFPreventionHeaders: []string{"X-Requested-With"}}, "GET",
+			map[string]string{"X-Requested-With": "fetch"}, false},
+		{handler.Config{CSRFPrevention: true, CSRFPreventionHeaders: []string{"X-Requested-With"}}, "GET",
+			map[string]string{"Apollo-Require-Preflight": "true"}, true},
+	}
+	for i, test := range tests {
+		test.config.Schema = &testutil.StarWarsSchema
+		h := handler.New(&test.config)
+		var req *http.Request
+		if test.method == "GET" {
+			req, _ = http.NewRequest("GET", "/graphql?query="+url.QueryEscape("{ hero { name } }"), nil)
+		} else {
+			req, _ = http.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ hero { name } }"}`))
+		}
+		for name, value := range test.headers {
+			req.Header.Set(name, value)
+		}
+		result, resp := executeTest(t, h, req)
+		if !test.blocked {
+			if resp.Code != http.StatusOK || len(result.Errors) != 0 {
+				t.Fatalf("%d: unexpected response %d %v", i, resp.Code, result.Errors)
+			}
+			continue
+		}
+		if resp.Code != http.StatusBadRequest || len(result.Errors) != 1 ||
+			result.Errors[0].Extensions["code"] != handler.CodeBadRequest || result.Data != nil {
+			t.Fatalf("%d: expected the request to be blocked, got %d %v", i, resp.Code, result.Errors)
+		}
+	}
+}
+
+func TestHandler_CORS(t *testing.T) {
+	h := handler.New(&handler.Config{
+		Schema: &testutil.StarWarsSchema,
+		CORS: &handler.CORS{
+			AllowedOrigins:   []string{"https://example.com", "https://*.example.org"},
+			AllowCredentials: true,
+			MaxAge:           time.Minute,
+		},
+	})
+
+	tests := []struct {
+		origin, method, headers string
+		allowed                 bool
+	}{
+		{"https://example.com", "POST", "content-type", true},
+		{"https://app.example.org", "POST", "Content-Type, Authorization", true},
+		{"https://example.net", "POST", "content-type", false},
+		{"https://example.com", "DELETE", "", false},
+		{"https://example.com", "POST", "X-Custom", false},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest("OPTIONS", "/graphql", nil)
+		req.Header.Set("Origin", test.origin)
+		req.Header.Set("Access-Control-Request-Method", test.method)
+		if test.headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", test.headers)
+		}
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		if resp.Code != http.StatusNoContent || resp.Body.Len() != 0 {
+			t.Fatalf("%d: expected the preflight request to be answered, got %d %s", i, resp.Code, resp.Body)
+		}
+		allowOrigin := resp.Header().Get("Access-Control-Allow-Origin")
+		if !test.allowed {
+			if allowOrigin != "" {
+				t.Fatalf("%d: expected the request not to be allowed, got %v", i, resp.Header())
+			}
+			continue
+		}
+		if allowOrigin != test.origin || resp.Header().Get("Access-Control-Allow-Methods") != test.method ||
+			resp.Header().Get("Access-Control-Allow-Headers") != test.headers ||
+			resp.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+			resp.Header().Get("Access-Control-Max-Age") != "60" {
+			t.Fatalf("%d: expected the request to be allowed, got %v", i, resp.Header())
+		}
+	}
+
+	req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ hero { name } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "https://example.com")
+	result, resp := executeTest(t, h, req)
+	if resp.Code != http.StatusOK || len(result.Errors) != 0 {
+		t.Fatalf("unexpected response %d %v", resp.Code, result.Errors)
+	}
+	if resp.Header().Get("Access-Control-Allow-Origin") != "https://example.com" ||
+		resp.Header().Get("Access-Control-Allow-Credentials") != "true" || resp.Header().Get("Vary") != "Origin" {
+		t.Fatalf("expected the response to be shared with the origin, got %v", resp.Header())
+	}
+}
+
+func TestHandler_Health(t *testing.T) {
+	var readyErr error
+	h := handler.New(&handler.Config{Schema: &testutil.StarWarsSchema})
+	health := h.Health(handler.HealthConfig{
+		CheckExecution: true,
+		ReadyFn:        func(ctx context.Context) error { return readyErr },
+	})
+	check := func(path string) (int, handler.HealthStatus) {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		health.ServeHTTP(resp, req)
+		var status handler.HealthStatus
+		json.Unmarshal(resp.Body.Bytes(), &status)
+		return resp.Code, status
+	}
+
+	hash := sha256.Sum256([]byte(graphql.PrintSchema(testutil.StarWarsSchema)))
+	expected := handler.HealthStatus{Status: "ok", SchemaHash: hex.EncodeToString(hash[:])}
+	for _, path := range []string{"/healthz", "/internal/readyz"} {
+		if code, status := check(path); code != http.StatusOK || !reflect.DeepEqual(status, expected) {
+			t.Fatalf("%s: unexpected response %d %+v", path, code, status)
+		}
+	}
+
+	readyErr = errors.New("the database is down")
+	if code, status := check("/readyz"); code != http.StatusServiceUnavailable || status.Status != "unavailable" ||
+		status.Error != "the database is down" || status.SchemaHash != expected.SchemaHash {
+		t.Fatalf("unexpected response %d %+v", code, status)
+	}
+	if code, _ := check("/healthz"); code != http.StatusOK {
+		t.Fatalf("expected the liveness not to depend on ReadyFn, got %d", code)
+	}
+	if code, _ := check("/graphql"); code != http.StatusNotFound {
+		t.Fatalf("expected other paths not to be found, got %d", code)
+	}
+
+	notLoaded := handler.New(&handler.Config{Schema: &graphql.Schema{}}).Health(handler.HealthConfig{})
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	resp := httptest.NewRecorder()
+	notLoaded.ServeHTTP(resp, req)
+	if resp.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected the server without schema to be unavailable, got %d", resp.Code)
+	}
+}
+
+func TestHandler_SchemaSelectorFn(t *testing.T) {
+	adminSchema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"users": &graphql.Field{Type: graphql.Int, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return 42, nil
+				}},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{
+		SchemaSelectorFn: handler.NamedSchemas(map[string]*graphql.Schema{
+			"/graphql":       &testutil.StarWarsSchema,
+			"/admin/graphql": &adminSchema,
+		}, func(ctx context.Context, r *http.Request) string { return r.URL.Path }),
+		DocumentCacheSize: 10,
+	})
+	query := func(path, query string) *graphql.Result {
+		req, _ := http.NewRequest("GET", path+"?query="+url.QueryEscape(query), nil)
+		result, _ := executeTest(t, h, req)
+		return result
+	}
+
+	if result := query("/graphql", "{ hero { name } }"); len(result.Errors) != 0 ||
+		!reflect.DeepEqual(result.Data, map[string]interface{}{"hero": map[string]interface{}{"name": "R2-D2"}}) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result := query("/admin/graphql", "{ users }"); len(result.Errors) != 0 ||
+		!reflect.DeepEqual(result.Data, map[string]interface{}{"users": 42.0}) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// the documents validated against a schema aren't reused for the others
+	if result := query("/admin/graphql", "{ hero { name } }"); len(result.Errors) != 1 ||
+		result.Errors[0].Message != `Cannot query field "hero" on type "Query".` {
+		t.Fatalf("expected a validation error, got %+v", result)
+	}
+	if result := query("/public/graphql", "{ hero { name } }"); len(result.Errors) != 1 ||
+		result.Errors[0].Message != `Unknown schema "/public/graphql".` {
+		t.Fatalf("expected the schema to be unknown, got %+v", result)
+	}
+}
+
+func TestHandler_FieldOrder(t *testing.T) {
+	post := func(config *handler.Config) string {
+		req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ hero { name id } }"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		handler.New(config).ServeHTTP(resp, req)
+		return strings.TrimSpace(resp.Body.String())
+	}
+
+	// the fields are in the order of the query by default
+	expected := `{"data":{"hero":{"name":"R2-D2","id":"2001"}}}`
+	if body := post(&handler.Config{Schema: &testutil.StarWarsSchema}); body != expected {
+		t.Fatalf("expected %s, got %s", expected, body)
+	}
+	expected = `{"data":{"hero":{"id":"2001","name":"R2-D2"}}}`
+	if body := post(&handler.Config{Schema: &testutil.StarWarsSchema, SortFields: true}); body != expected {
+		t.Fatalf("expected %s, got %s", expected, body)
+	}
+}
+
+func TestHandler_ResponseCache(t *testing.T) {
+	calls := map[string]int{}
+	counter := func(name string, hint *graphql.CacheHint) *graphql.Field {
+		return &graphql.Field{
+			Type:      graphql.Int,
+			CacheHint: hint,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				calls[name]++
+				if name == "failing" {
+					return nil, errors.New("failed")
+				}
+				return calls[name], nil
+			},
+		}
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"public":   counter("public", &graphql.CacheHint{MaxAge: time.Minute}),
+				"private":  counter("private", &graphql.CacheHint{MaxAge: 30 * time.Second, Private: true}),
+				"uncached": counter("uncached", nil),
+				"failing":  counter("failing", &graphql.CacheHint{MaxAge: time.Minute}),
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{
+		Schema: &schema,
+		ResponseCache: &handler.ResponseCache{
+			Store: handler.NewMemoryResponseCacheStore(10),
+			SessionKeyFn: func(r *http.Request) string {
+				return r.Header.Get("Session")
+			},
+		},
+	})
+	post := func(query, session string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"query": query})
+		req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Session", session)
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// the max-age of the cached responses is what remains of it
+	tests := []struct {
+		query        string
+		session      string
+		body         string
+		cacheControl string
+	}{
+		{`{ public }`, "", `{"data":{"public":1}}`, "public, max-age=60"},
+		{`{ public }`, "a", `{"data":{"public":1}}`, "public, max-age=59"},
+		// a field without a hint makes the response uncacheable
+		{`{ public uncached }`, "", `{"data":{"public":2,"uncached":1}}`, ""},
+		{`{ public uncached }`, "", `{"data":{"public":3,"uncached":2}}`, ""},
+		// the private responses are cached per session, if there is one
+		{`{ public private }`, "a", `{"data":{"public":4,"private":1}}`, "private, max-age=30"},
+		{`{ public private }`, "a", `{"data":{"public":4,"private":1}}`, "private, max-age=29"},
+		{`{ public private }`, "b", `{"data":{"public":5,"private":2}}`, "private, max-age=30"},
+		{`{ public private }`, "", `{"data":{"public":6,"private":3}}`, "private, max-age=30"},
+		{`{ public private }`, "", `{"data":{"public":7,"private":4}}`, "private, max-age=30"},
+	}
+	for i, test := range tests {
+		resp := post(test.query, test.session)
+		body := strings.TrimSpace(resp.Body.String())
+		cacheControl := resp.Header().Get("Cache-Control")
+		if body != test.body || cacheControl != test.cacheControl {
+			t.Fatalf("%d: expected %s with %q, got %s with %q", i, test.body, test.cacheControl, body, cacheControl)
+		}
+	}
+
+	// responses with errors aren't cached
+	for i := 0; i < 2; i++ {
+		if resp := post(`{ failing }`, ""); resp.Header().Get("Cache-Control") != "" {
+			t.Fatalf("unexpected Cache-Control %q", resp.Header().Get("Cache-Control"))
+		}
+	}
+	if calls["failing"] != 2 {
+		t.Fatalf("expected 2 calls for the failing field, got %d", calls["failing"])
+	}
+}
+
+func TestHandler_ResponseCache_SkipsInternalAccess(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"secret": &graphql.Field{
+					Type:      graphql.String,
+					Internal:  true,
+					CacheHint: &graphql.CacheHint{MaxAge: time.Minute},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return "s3cr3t", nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{
+		Schema:        &schema,
+		ResponseCache: &handler.ResponseCache{Store: handler.NewMemoryResponseCacheStore(10)},
+	})
+	post := func(ctx context.Context) string {
+		req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ secret }"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		h.ContextHandler(ctx, resp, req)
+		return resp.Body.String()
+	}
+
+	if body := post(graphql.WithInternalAccess(context.Background())); !strings.Contains(body, "s3cr3t") {
+		t.Fatalf("expected the internal field for the request with internal access, got %s", body)
+	}
+	if body := post(context.Background()); strings.Contains(body, "s3cr3t") {
+		t.Fatalf("expected the internal field to be hidden from the other requests, got %s", body)
+	}
+}
+
+// deduplicatedCalls requests the same expensive query six times at once, with the Authorization
+// of authorization for each request, and returns the number of executions of the query and the
+// responses.
+func deduplicatedCalls(t *testing.T, deduplication *handler.Deduplication, authorization func(i int) string) (int32, []string) {
+	var calls int32
+	release := make(chan struct{})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"expensive": &graphql.Field{
+					Type: graphql.Int,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						<-release
+						return int(atomic.AddInt32(&calls, 1)), nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{Schema: &schema, Deduplication: deduplication})
+	post := func(auth string) string {
+		req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ expensive }"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", auth)
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		return strings.TrimSpace(resp.Body.String())
+	}
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 6)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i] = post(authorization(i))
+		}(i)
+	}
+	// the queries are executing or waiting for an identical one
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	return atomic.LoadInt32(&calls), bodies
+}
+
+func TestHandler_Deduplication(t *testing.T) {
+	twoUsers := func(i int) string { return fmt.Sprint("user", i%2) }
+	calls, _ := deduplicatedCalls(t, &handler.Deduplication{
+		KeyFn: func(r *http.Request) string {
+			return "tenant"
+		},
+	}, func(i int) string { return "" })
+	if calls != 1 {
+		t.Fatalf("expected the query to be executed once for the key, got %d executions", calls)
+	}
+
+	// the requests are keyed on their Authorization by default
+	calls, bodies := deduplicatedCalls(t, &handler.Deduplication{}, twoUsers)
+	if calls != 2 {
+		t.Fatalf("expected the query to be executed once per user, got %d executions", calls)
+	}
+	for i, body := range bodies {
+		if body != bodies[i%2] {
+			t.Fatalf("expected the requests of a user to share their result, got %s and %s", bodies[i%2], body)
+		}
+	}
+
+	// the requests waiting for longer than the timeout execute the query themselves
+	calls, _ = deduplicatedCalls(t, &handler.Deduplication{Timeout: 10 * time.Millisecond}, twoUsers)
+	if calls != 6 {
+		t.Fatalf("expected every request to execute the query after the timeout, got %d executions", calls)
+	}
+
+	// the anonymous requests aren't deduplicated by default, they may still differ
+	calls, _ = deduplicatedCalls(t, &handler.Deduplication{}, func(i int) string { return "" })
+	if calls != 6 {
+		t.Fatalf("expected every anonymous request to execute the query, got %d executions", calls)
+	}
+}
+
+func TestHandler_ClientDisconnected(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"slow": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						// the client closes the connection
+						cancel()
+						<-p.Context.Done()
+						return nil, p.Context.Err()
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var callbackResult *graphql.Result
+	h := handler.New(&handler.Config{
+		Schema: &schema,
+		ResultCallbackFn: func(ctx context.Context, params *graphql.Params, result *graphql.Result, responseBody []byte) {
+			callbackResult = result
+		},
+	})
+	req, _ := http.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{ slow }`), nil)
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req.WithContext(ctx))
+
+	if resp.Body.Len() != 0 {
+		t.Fatalf("expected no response, got %s", resp.Body.String())
+	}
+	if callbackResult == nil || len(callbackResult.Errors) != 1 ||
+		callbackResult.Errors[0].Extensions["code"] != handler.CodeClientDisconnected {
+		t.Fatalf("expected the client disconnected error, got %+v", callbackResult)
+	}
+}
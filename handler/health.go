@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/fiatjaf/graphql"
+)
+
+// HealthConfig configures the handler returned by Handler.Health.
+type HealthConfig struct {
+	// CheckExecution makes /readyz execute the query `{ __typename }` against the schema.
+	CheckExecution bool
+
+	// ReadyFn, when set, is called by /readyz to check the dependencies of the server, the server
+	// isn't ready when it returns an error.
+	ReadyFn func(ctx context.Context) error
+}
+
+// HealthStatus is the JSON body of the responses of the health endpoints.
+type HealthStatus struct {
+	// Status is "ok", or "unavailable" with a 503 status.
+	Status string `json:"status"`
+
+	// SchemaHash is the hexadecimal SHA-256 of the SDL of the schema as printed by
+	// graphql.PrintSchema, the same as the hash of schemaregistry.
+	SchemaHash string `json:"schemaHash,omitempty"`
+
+	// Error tells why the server is unavailable.
+	Error string `json:"error,omitempty"`
+}
+
+// Health returns a handler for the health checks of load balancers and deployments, answering
+// the requests whose path ends with:
+//
+//   - /healthz, with "ok" when the schema of h is loaded;
+//   - /readyz, with "ok" when the schema is loaded and the checks of config succeed.
+//
+// Both report the hash of the schema, so that a deployment can verify which schema is served.
+func (h *Handler) Health(config HealthConfig) http.Handler {
+	return &healthHandler{handler: h, config: config}
+}
+
+type healthHandler struct {
+	handler *Handler
+	config  HealthConfig
+
+	hashOnce   sync.Once
+	schemaHash string
+}
+
+func (health *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var err error
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/healthz"):
+		err = health.checkSchema()
+	case strings.HasSuffix(r.URL.Path, "/readyz"):
+		err = health.checkReady(r.Context())
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	status := HealthStatus{Status: "ok", SchemaHash: health.hash()}
+	code := http.StatusOK
+	if err != nil {
+		status.Status = "unavailable"
+		status.Error = err.Error()
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
+
+// checkSchema returns an error if the schema isn't loaded.
+func (health *healthHandler) checkSchema() error {
+	if health.handler.Schema == nil || health.handler.Schema.QueryType() == nil {
+		return errors.New("the schema is not loaded")
+	}
+	return nil
+}
+
+// checkReady returns an error if the server can't serve requests.
+func (health *healthHandler) checkReady(ctx context.Context) error {
+	if err := health.checkSchema(); err != nil {
+		return err
+	}
+	if health.config.CheckExecution {
+		result := graphql.Do(graphql.Params{
+			Schema:        *health.handler.Schema,
+			RequestString: "{ __typename }",
+			Context:       ctx,
+		})
+		if result.HasErrors() {
+			return result.Errors[0]
+		}
+	}
+	if health.config.ReadyFn != nil {
+		return health.config.ReadyFn(ctx)
+	}
+	return nil
+}
+
+// hash returns the hash of the schema, "" if it isn't loaded. It is computed once it is loaded.
+func (health *healthHandler) hash() string {
+	if health.checkSchema() != nil {
+		return ""
+	}
+	health.hashOnce.Do(func() {
+		hash := sha256.Sum256([]byte(graphql.PrintSchema(*health.handler.Schema)))
+		health.schemaHash = hex.EncodeToString(hash[:])
+	})
+	return health.schemaHash
+}
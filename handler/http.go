@@ -7,23 +7,49 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/fiatjaf/graphql"
 	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/language/ast"
 )
 
+// CodeClientDisconnected is the code of the error of the requests whose client disconnected before
+// their response was sent, set under the "code" key of its extensions. Such requests get no
+// response, their ResultCallbackFn is called with a result holding this error alone, so that they
+// can be told apart from the failures of the server.
+const CodeClientDisconnected = "CLIENT_DISCONNECTED"
+
+type clientDisconnectedError struct{}
+
+func (clientDisconnectedError) Error() string {
+	return "The client disconnected."
+}
+
+// Extensions implements gqlerrors.ExtendedError.
+func (clientDisconnectedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": CodeClientDisconnected}
+}
+
 func getFromForm(values url.Values) *RequestOptions {
 	query := values.Get("query")
-	if query != "" {
+	id := values.Get("id")
+	extensionsStr := values.Get("extensions")
+	if query != "" || id != "" || extensionsStr != "" {
 		// get variables map
 		variables := make(map[string]interface{}, len(values))
 		variablesStr := values.Get("variables")
 		json.Unmarshal([]byte(variablesStr), &variables)
 
+		var extensions map[string]interface{}
+		json.Unmarshal([]byte(extensionsStr), &extensions)
+
 		return &RequestOptions{
 			Query:         query,
 			Variables:     variables,
 			OperationName: values.Get("operationName"),
+			Extensions:    extensions,
+			ID:            id,
 		}
 	}
 
@@ -84,6 +110,7 @@ func NewRequestOptions(r *http.Request) *RequestOptions {
 			var optsCompatible requestOptionsCompatibility
 			json.Unmarshal(body, &optsCompatible)
 			json.Unmarshal([]byte(optsCompatible.Variables), &opts.Variables)
+			json.Unmarshal([]byte(optsCompatible.Extensions), &opts.Extensions)
 		}
 		return &opts
 	}
@@ -94,28 +121,95 @@ func NewRequestOptions(r *http.Request) *RequestOptions {
 func (h *Handler) ContextHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	// get query
 	opts := NewRequestOptions(r)
+	ctx = graphql.WithRequestInfo(ctx, clientRequestInfo(r))
+	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{Started: time.Now()})
+	ctx = h.extractTrace(ctx, r.Header)
+
+	var result *graphql.Result
+	persisted, err := h.loadPersistedQuery(ctx, opts)
+	schema, schemaErr := h.schema(ctx, r)
+	csrfBlocked := h.csrfPrevention && !h.preflighted(r)
+	if csrfBlocked {
+		// the request may have been sent by a page of another site, see Config.CSRFPrevention
+		result = &graphql.Result{Errors: gqlerrors.FormatErrors(csrfError{h.csrfHeaders()})}
+	} else if schemaErr != nil {
+		result = &graphql.Result{Errors: gqlerrors.FormatErrors(schemaErr)}
+	} else if err != nil {
+		result = &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	} else if h.requestDidArriveFn != nil {
+		if err := h.requestDidArriveFn(ctx, opts); err != nil {
+			result = &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+		}
+	}
+
+	// the operation is only looked up for the features telling queries apart, through the
+	// document cache that the execution then reads it from
+	var operation *ast.OperationDefinition
+	if result == nil {
+		h.logRequest(ctx, opts)
+		if h.etags || h.responseCache != nil || h.deduplication != nil {
+			operation = requestOperation(h.documentCacheFor(schema), opts)
+		}
+	}
+
+	// the responses of GET queries get an ETag, so they are only sent when the client doesn't
+	// have them already
+	var versions *responseVersions
+	if h.etags && result == nil && r.Method == http.MethodGet {
+		if operationName, ok := queryOperationName(operation); ok &&
+			(h.etagOperationFn == nil || h.etagOperationFn(ctx, operationName)) {
+			ctx, versions = withResponseVersions(ctx)
+		}
+	}
+
+	// the responses to queries are looked up in the cache, see Config.ResponseCache
+	var cacheLookup *responseCacheLookup
+	if h.responseCache != nil && result == nil {
+		if operationName, ok := queryOperationName(operation); ok && h.cacheableQuery(ctx, r, operationName) {
+			ctx, cacheLookup = h.responseCache.lookup(ctx, r, opts)
+			if cacheLookup.hit != nil {
+				result = cacheLookup.result()
+			}
+		}
+	}
 
 	// execute graphql query
 	params := graphql.Params{
-		Schema:         *h.Schema,
-		RequestString:  opts.Query,
-		VariableValues: opts.Variables,
-		OperationName:  opts.OperationName,
-		Context:        ctx,
+		RequestString:        opts.Query,
+		VariableValues:       opts.Variables,
+		OperationName:        opts.OperationName,
+		Context:              ctx,
+		MaxCost:              h.maxCost,
+		MaxNodes:             h.maxNodes,
+		RateLimitFn:          h.rateLimitFn,
+		AllowOperationFn:     h.allowOperationFn,
+		DocumentCache:        h.documentCacheFor(schema),
+		PreserveFieldOrder:   !h.sortFields,
+		AllowOperationTypeFn: h.allowHTTPOperationType(r.Method),
+		VisibilityFn:         h.visibilityFn,
 	}
-	if h.rootObjectFn != nil {
-		params.RootObject = h.rootObjectFn(ctx, r)
+	if schema != nil {
+		params.Schema = *schema
+	}
+	params.RootObject = h.rootObject(ctx, r, opts)
+	if result == nil {
+		if _, isQuery := queryOperationName(operation); isQuery && h.deduplication != nil {
+			result = h.deduplication.do(ctx, r, opts, params)
+		} else {
+			result = graphql.Do(params)
+		}
 	}
-	result := graphql.Do(params)
 
-	if formatErrorFn := h.formatErrorFn; formatErrorFn != nil && len(result.Errors) > 0 {
-		formatted := make([]gqlerrors.FormattedError, len(result.Errors))
-		for i, formattedError := range result.Errors {
-			formatted[i] = formatErrorFn(formattedError.OriginalError())
+	// the execution is cancelled when the client disconnects, and there is no one to respond to
+	if r.Context().Err() == context.Canceled {
+		if h.resultCallbackFn != nil {
+			h.resultCallbackFn(ctx, &params, &graphql.Result{Errors: gqlerrors.FormatErrors(clientDisconnectedError{})}, nil)
 		}
-		result.Errors = formatted
+		return
 	}
 
+	result = h.formatErrors(result)
+
 	if h.graphiql {
 		acceptHeader := r.Header.Get("Accept")
 		_, raw := r.URL.Query()["raw"]
@@ -136,18 +230,63 @@ func (h *Handler) ContextHandler(ctx context.Context, w http.ResponseWriter, r *
 
 	// use proper JSON Header
 	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	if csrfBlocked {
+		w.WriteHeader(http.StatusBadRequest)
+		result.MarshalJSONTo(w)
+		return
+	}
+	if cacheControl := persisted.cacheControl(); cacheControl != "" && !result.HasErrors() {
+		w.Header().Set("Cache-Control", cacheControl)
+	} else if cacheLookup != nil {
+		if cacheControl := cacheLookup.cacheControl(result); cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+	}
+
+	// the ETag is derived from the versions declared by the resolvers, or else from the body
+	etag := ""
+	bodyETagged := false
+	if versions != nil && !result.HasErrors() {
+		etag = versions.etag(opts)
+		bodyETagged = etag == "" && !h.streamResponse
+	}
 
 	var buff []byte
-	if h.pretty {
-		w.WriteHeader(http.StatusOK)
-		buff, _ = json.MarshalIndent(result, "", "\t")
+	if etag != "" && notModified(w, r, etag) {
+		// the client already has the response
+	} else if cacheLookup != nil && cacheLookup.hit != nil {
+		buff = cacheLookup.hit.Body
+		if !bodyETagged || !notModified(w, r, bodyETag(buff)) {
+			w.WriteHeader(http.StatusOK)
+			w.Write(buff)
+		}
+	} else if h.streamResponse && cacheLookup == nil {
+		streamResult(w, result, h.maxResponseSize)
+	} else if h.pretty || h.resultCallbackFn != nil || h.maxResponseSize > 0 || bodyETagged || cacheLookup != nil {
+		if h.pretty {
+			buff, _ = json.MarshalIndent(result, "", "\t")
+		} else {
+			buff, _ = json.Marshal(result)
+		}
+		if h.maxResponseSize > 0 && int64(len(buff)) > h.maxResponseSize {
+			buff, _ = json.Marshal(responseTooLarge(h.maxResponseSize))
+			w.Header().Del("Cache-Control")
+		} else {
+			if cacheLookup != nil {
+				cacheLookup.store(ctx, result, buff)
+			}
+			if bodyETagged && notModified(w, r, bodyETag(buff)) {
+				buff = nil
+			}
+		}
 
-		w.Write(buff)
+		if buff != nil {
+			w.WriteHeader(http.StatusOK)
+			w.Write(buff)
+		}
 	} else {
 		w.WriteHeader(http.StatusOK)
-		buff, _ = json.Marshal(result)
-
-		w.Write(buff)
+		result.MarshalJSONTo(w)
 	}
 
 	if h.resultCallbackFn != nil {
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/fiatjaf/graphql/gqlerrors"
+)
+
+// SubscriptionEventType is the type of a SubscriptionEvent.
+type SubscriptionEventType string
+
+const (
+	// SubscriptionStarted is reported when a subscription is received, before it is executed.
+	SubscriptionStarted SubscriptionEventType = "started"
+
+	// SubscriptionDelivered is reported for every result sent for a subscription.
+	SubscriptionDelivered SubscriptionEventType = "delivered"
+
+	// SubscriptionErrored is reported with the errors of a subscription that failed before running,
+	// nothing else is reported for it afterwards, or of a result sent for it with errors.
+	SubscriptionErrored SubscriptionEventType = "errored"
+
+	// SubscriptionCompleted is reported when the events of a subscription end.
+	SubscriptionCompleted SubscriptionEventType = "completed"
+
+	// SubscriptionCancelledByClient is reported when the client stops a subscription.
+	SubscriptionCancelledByClient SubscriptionEventType = "cancelled_by_client"
+
+	// SubscriptionCancelledByServer is reported when a subscription is stopped because its
+	// connection was closed.
+	SubscriptionCancelledByServer SubscriptionEventType = "cancelled_by_server"
+
+	// SubscriptionHeartbeat is reported for every running subscription each time the connection is
+	// pinged, so that the subscriptions that run for too long can be spotted.
+	SubscriptionHeartbeat SubscriptionEventType = "heartbeat"
+)
+
+// SubscriptionEvent is an event of the lifecycle of a subscription of a websocket connection.
+type SubscriptionEvent struct {
+	Type SubscriptionEventType
+
+	// ID is the id of the subscription in its connection.
+	ID string
+
+	// OperationName is the name of the operation, "" if it is anonymous.
+	OperationName string
+
+	// StartedAt is when the subscription was received.
+	StartedAt time.Time
+
+	// Deliveries is the number of results sent for the subscription so far.
+	Deliveries int64
+
+	// Errors are the errors of SubscriptionErrored.
+	Errors []gqlerrors.FormattedError
+}
+
+// SubscriptionEventFn is called with the events of the lifecycle of every subscription of the
+// websocket connections. The client and operation of the subscription are available in ctx, see
+// graphql.RequestInfoFromContext.
+type SubscriptionEventFn func(ctx context.Context, event SubscriptionEvent)
+
+// wsSubscription is a subscription running on a websocket connection.
+type wsSubscription struct {
+	ctx           context.Context
+	id            string
+	operationName string
+	startedAt     time.Time
+	deliveries    int64
+}
+
+// reportSubscriptionEvent calls the SubscriptionEventFn, if any, with an event of subscription.
+func (h *Handler) reportSubscriptionEvent(subscription *wsSubscription, eventType SubscriptionEventType, errs []gqlerrors.FormattedError) {
+	if h.subscriptionEventFn == nil || subscription == nil {
+		return
+	}
+	h.subscriptionEventFn(subscription.ctx, SubscriptionEvent{
+		Type:          eventType,
+		ID:            subscription.id,
+		OperationName: subscription.operationName,
+		StartedAt:     subscription.startedAt,
+		Deliveries:    atomic.LoadInt64(&subscription.deliveries),
+		Errors:        errs,
+	})
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+)
+
+// subscriptionMultiplexer shares a single execution of a subscription between all the identical
+// subscriptions (same query, operation name, variables and credentials) made to a handler: the
+// event source runs once and its results are sent to every subscriber.
+type subscriptionMultiplexer struct {
+	mutex   sync.Mutex
+	sources map[string]*sharedSubscription
+}
+
+type sharedSubscription struct {
+	subscribers map[*subscriber]struct{}
+	cancel      context.CancelFunc
+}
+
+type subscriber struct {
+	// events receives the results of the shared execution, it is closed when the execution ends
+	events chan *graphql.Result
+	done   <-chan struct{}
+}
+
+func newSubscriptionMultiplexer() *subscriptionMultiplexer {
+	return &subscriptionMultiplexer{
+		sources: map[string]*sharedSubscription{},
+	}
+}
+
+// subscribe returns the results of the subscription described by params, made with credentials,
+// starting its execution if no identical subscription is running. A subscriber joining a running
+// execution goes through the same checks as the first one, and gets only the result refusing it
+// if it fails them. The returned channel is closed when params.Context is done or when the
+// subscription ends. The execution is stopped once it has no subscribers left.
+func (m *subscriptionMultiplexer) subscribe(params graphql.Params, credentials string) chan *graphql.Result {
+	key := subscriptionKey(params, credentials)
+	sub := &subscriber{
+		events: make(chan *graphql.Result),
+		done:   params.Context.Done(),
+	}
+
+	checked := false
+	m.mutex.Lock()
+	source, ok := m.sources[key]
+	for ok && !checked {
+		// the checks run outside of the lock, as RateLimitFn and AllowOperationFn may block
+		m.mutex.Unlock()
+		if refused := graphql.CheckRequest(params); refused != nil {
+			results := make(chan *graphql.Result, 1)
+			results <- refused
+			close(results)
+			return results
+		}
+		checked = true
+		m.mutex.Lock()
+		source, ok = m.sources[key]
+	}
+	if !ok {
+		// a new execution runs the checks of its first subscriber itself
+		// the execution is shared, so it must not end with the context of the first subscriber
+		ctx, cancel := context.WithCancel(detachedContext{params.Context})
+		source = &sharedSubscription{
+			subscribers: map[*subscriber]struct{}{},
+			cancel:      cancel,
+		}
+		m.sources[key] = source
+		params.Context = ctx
+		results := graphql.DoAsync(params)
+		graphql.Go(ctx, "subscription source", func() { m.run(key, source, results) })
+	}
+	source.subscribers[sub] = struct{}{}
+	m.mutex.Unlock()
+
+	results := make(chan *graphql.Result)
+	graphql.Go(params.Context, "subscriber", func() {
+		defer m.unsubscribe(key, source, sub)
+		defer close(results)
+		for {
+			select {
+			case <-sub.done:
+				return
+			case result, more := <-sub.events:
+				if !more {
+					return
+				}
+				select {
+				case results <- result:
+				case <-sub.done:
+					return
+				}
+			}
+		}
+	})
+
+	return results
+}
+
+// run sends the results of a shared execution to its subscribers until it ends.
+func (m *subscriptionMultiplexer) run(key string, source *sharedSubscription, results chan *graphql.Result) {
+	for result := range results {
+		m.mutex.Lock()
+		subscribers := make([]*subscriber, 0, len(source.subscribers))
+		for sub := range source.subscribers {
+			subscribers = append(subscribers, sub)
+		}
+		m.mutex.Unlock()
+
+		// results are shared between subscribers, so they must be treated as read-only
+		for _, sub := range subscribers {
+			select {
+			case sub.events <- result:
+			case <-sub.done:
+			}
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.sources[key] == source {
+		delete(m.sources, key)
+	}
+	for sub := range source.subscribers {
+		delete(source.subscribers, sub)
+		close(sub.events)
+	}
+	source.cancel()
+}
+
+// unsubscribe removes a subscriber, stopping the shared execution if it was the last one.
+func (m *subscriptionMultiplexer) unsubscribe(key string, source *sharedSubscription, sub *subscriber) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := source.subscribers[sub]; !ok {
+		// the execution already ended
+		return
+	}
+	delete(source.subscribers, sub)
+	if len(source.subscribers) == 0 {
+		if m.sources[key] == source {
+			delete(m.sources, key)
+		}
+		source.cancel()
+	}
+}
+
+// subscriptionKey identifies the subscriptions that can share an execution. The subscriptions to
+// different schemas, told apart by their type maps, or made with different credentials never
+// share one.
+func subscriptionKey(params graphql.Params, credentials string) string {
+	variables, _ := json.Marshal(params.VariableValues)
+	schema := fmt.Sprintf("%p", params.Schema.TypeMap())
+	return schema + "\x00" + credentials + "\x00" + params.OperationName + "\x00" + params.RequestString + "\x00" + string(variables)
+}
+
+// detachedContext keeps the values of its parent but not its cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Error codes set under the "code" key of the extensions of the errors of the requests for
+// persisted queries.
+const (
+	CodePersistedQueryNotFound = "PERSISTED_QUERY_NOT_FOUND"
+	CodePersistedQueryRequired = "PERSISTED_QUERY_REQUIRED"
+	CodeForbidden              = "FORBIDDEN"
+)
+
+// PersistedQueryStore holds the queries persisted ahead of time, e.g. by the build of a client,
+// so that requests can send the id of a query instead of the query itself.
+type PersistedQueryStore interface {
+	PersistedQuery(ctx context.Context, id string) (operation *PersistedOperation, ok bool)
+}
+
+// PersistedOperation is a persisted query and the way its requests are handled.
+type PersistedOperation struct {
+	Query string
+
+	// CacheTTL, when above zero, is how long the responses without errors can be cached, as told
+	// by their Cache-Control header. They can only be cached privately if Roles isn't empty.
+	CacheTTL time.Duration
+
+	// Roles, when not empty, are the roles allowed to execute the operation, one of which must be
+	// returned by Config.RolesFn for the request. Other requests are rejected before the query is
+	// parsed.
+	Roles []string
+
+	// DefaultVariables are the values of the variables of the requests that don't provide them,
+	// e.g. to bound the page sizes of public operations: the variables of the requests win.
+	DefaultVariables map[string]interface{}
+}
+
+// cacheControl returns the Cache-Control header of the responses of the operation, if any.
+func (o *PersistedOperation) cacheControl() string {
+	if o == nil || o.CacheTTL <= 0 {
+		return ""
+	}
+	maxAge := fmt.Sprintf("max-age=%d", int64(o.CacheTTL/time.Second))
+	if len(o.Roles) != 0 {
+		return "private, " + maxAge
+	}
+	return "public, " + maxAge
+}
+
+type persistedQueryError struct {
+	message string
+	code    string
+}
+
+func (e *persistedQueryError) Error() string {
+	return e.message
+}
+
+// Extensions implements gqlerrors.ExtendedError.
+func (e *persistedQueryError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// persistedQueryID returns the id of the persisted query of a request: its id, as sent by Relay,
+// or the hash of its persistedQuery extension, as sent by Apollo.
+func persistedQueryID(opts *RequestOptions) string {
+	if opts.ID != "" {
+		return opts.ID
+	}
+	persistedQuery, _ := opts.Extensions["persistedQuery"].(map[string]interface{})
+	hash, _ := persistedQuery["sha256Hash"].(string)
+	return hash
+}
+
+// loadPersistedQuery sets the query of a request to the persisted query it identifies, if any, and
+// returns its operation.
+func (h *Handler) loadPersistedQuery(ctx context.Context, opts *RequestOptions) (*PersistedOperation, error) {
+	if h.persistedQueries == nil {
+		return nil, nil
+	}
+	id := persistedQueryID(opts)
+	if id == "" {
+		if h.persistedQueriesOnly {
+			return nil, &persistedQueryError{"PersistedQueryRequired", CodePersistedQueryRequired}
+		}
+		return nil, nil
+	}
+	operation, ok := h.persistedQueries.PersistedQuery(ctx, id)
+	if !ok {
+		if opts.Query != "" && !h.persistedQueriesOnly {
+			return nil, nil
+		}
+		return nil, &persistedQueryError{"PersistedQueryNotFound", CodePersistedQueryNotFound}
+	}
+	if len(operation.Roles) != 0 && !h.hasRole(ctx, operation.Roles) {
+		return nil, &persistedQueryError{"not allowed to execute the operation " + id, CodeForbidden}
+	}
+	opts.Query = operation.Query
+	if len(operation.DefaultVariables) != 0 {
+		variables := make(map[string]interface{}, len(operation.DefaultVariables)+len(opts.Variables))
+		for name, value := range operation.DefaultVariables {
+			variables[name] = value
+		}
+		for name, value := range opts.Variables {
+			variables[name] = value
+		}
+		opts.Variables = variables
+	}
+	return operation, nil
+}
+
+// hasRole tells if the request of ctx has one of roles, as returned by RolesFn.
+func (h *Handler) hasRole(ctx context.Context, roles []string) bool {
+	if h.rolesFn == nil {
+		return false
+	}
+	for _, requestRole := range h.rolesFn(ctx) {
+		for _, role := range roles {
+			if requestRole == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apolloManifestFormat is the format of Apollo's persisted query manifests.
+const apolloManifestFormat = "apollo-persisted-query-manifest"
+
+// ParseManifest parses a persisted query manifest into its operations, by id. Both Relay's
+// manifests, a JSON object mapping the ids to the queries, and Apollo's operation manifests are
+// supported.
+//
+// The operations can be given a cache TTL in seconds, the roles allowed to execute them and the
+// default values of their variables: in the "cacheTTL", "roles" and "defaultVariables" members of
+// the operations of Apollo's manifests, and in the place of the queries of Relay's manifests with
+// an object like {"query": "...", "cacheTTL": 60, "roles": ["admin"], "defaultVariables": {"first": 10}}.
+func ParseManifest(b []byte) (map[string]*PersistedOperation, error) {
+	var manifest map[string]json.RawMessage
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	var format string
+	if json.Unmarshal(manifest["format"], &format) == nil && format == apolloManifestFormat {
+		var apollo struct {
+			Version    int `json:"version"`
+			Operations []struct {
+				ID string `json:"id"`
+				manifestOperation
+			} `json:"operations"`
+		}
+		if err := json.Unmarshal(b, &apollo); err != nil {
+			return nil, fmt.Errorf("invalid Apollo manifest: %w", err)
+		}
+		if apollo.Version != 1 {
+			return nil, fmt.Errorf("unsupported Apollo manifest version %d", apollo.Version)
+		}
+		operations := make(map[string]*PersistedOperation, len(apollo.Operations))
+		for _, operation := range apollo.Operations {
+			operations[operation.ID] = operation.persistedOperation(operation.Body)
+		}
+		return operations, nil
+	}
+
+	operations := make(map[string]*PersistedOperation, len(manifest))
+	for id, raw := range manifest {
+		var query string
+		if err := json.Unmarshal(raw, &query); err == nil {
+			operations[id] = &PersistedOperation{Query: query}
+			continue
+		}
+		var operation manifestOperation
+		if err := json.Unmarshal(raw, &operation); err != nil || operation.Query == "" {
+			return nil, fmt.Errorf("invalid Relay manifest: the query %q is neither a string nor an operation", id)
+		}
+		operations[id] = operation.persistedOperation(operation.Query)
+	}
+	return operations, nil
+}
+
+// manifestOperation is an operation of a manifest: its query is the body of the operations of
+// Apollo's manifests.
+type manifestOperation struct {
+	Body             string                 `json:"body"`
+	Query            string                 `json:"query"`
+	CacheTTL         int64                  `json:"cacheTTL"`
+	Roles            []string               `json:"roles"`
+	DefaultVariables map[string]interface{} `json:"defaultVariables"`
+}
+
+func (o manifestOperation) persistedOperation(query string) *PersistedOperation {
+	return &PersistedOperation{
+		Query:            query,
+		CacheTTL:         time.Duration(o.CacheTTL) * time.Second,
+		Roles:            o.Roles,
+		DefaultVariables: o.DefaultVariables,
+	}
+}
+
+// Manifest is a PersistedQueryStore holding the operations of manifest files, see ParseManifest.
+// Watch reloads them when they change.
+type Manifest struct {
+	paths []string
+
+	mutex      sync.RWMutex
+	operations map[string]*PersistedOperation
+	modTimes   []time.Time
+}
+
+// LoadManifest loads the operations of manifest files. Their ids must not clash.
+func LoadManifest(paths ...string) (*Manifest, error) {
+	m := &Manifest{paths: paths}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PersistedQuery implements PersistedQueryStore.
+func (m *Manifest) PersistedQuery(ctx context.Context, id string) (*PersistedOperation, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	operation, ok := m.operations[id]
+	return operation, ok
+}
+
+// Reload loads the files of the manifest again. The operations are left unchanged if any of them
+// is invalid.
+func (m *Manifest) Reload() error {
+	operations := map[string]*PersistedOperation{}
+	modTimes := make([]time.Time, len(m.paths))
+	for i, path := range m.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		modTimes[i] = info.ModTime()
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fileOperations, err := ParseManifest(b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for id, operation := range fileOperations {
+			if existing, ok := operations[id]; ok && existing.Query != operation.Query {
+				return fmt.Errorf("%s: the id %q is used by another query", path, id)
+			}
+			operations[id] = operation
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.operations = operations
+	m.modTimes = modTimes
+	return nil
+}
+
+// Watch checks every interval if the files of the manifest were modified, and reloads them when
+// they were, until ctx is done. The errors of the reloads are passed to errorFn, if not nil.
+func (m *Manifest) Watch(ctx context.Context, interval time.Duration, errorFn func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !m.modified() {
+			continue
+		}
+		if err := m.Reload(); err != nil && errorFn != nil {
+			errorFn(err)
+		}
+	}
+}
+
+// modified tells if the files of the manifest were modified since they were loaded.
+func (m *Manifest) modified() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for i, path := range m.paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(m.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
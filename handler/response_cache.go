@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+)
+
+// ResponseCache caches the whole responses to the queries, see Config.ResponseCache. A response is
+// cached when it has no errors and all its fields can be cached, as computed by a
+// graphql.CachePolicy from their CacheHint, for the shortest MaxAge of its fields. It is keyed on
+// the hash of the query, the operation name and the variables, and on the session of the user if
+// any of its fields is private. The responses are sent with the Cache-Control header of their
+// hint.
+//
+// When the schema is selected by Config.SchemaSelectorFn, the sessions must tell the schemas apart
+// too, or the schemas use different stores.
+type ResponseCache struct {
+	// Store holds the cached responses, see NewMemoryResponseCacheStore and
+	// NewRedisResponseCacheStore.
+	Store ResponseCacheStore
+
+	// SessionKeyFn, when set, returns the session of the user of a request, e.g. the hash of its
+	// auth token, "" if it has none. The private responses are only cached for the requests with a
+	// session, and only sent back to the same session.
+	SessionKeyFn func(r *http.Request) string
+}
+
+// ResponseCacheStore holds encoded responses for a ResponseCache. It must be safe for concurrent
+// use.
+type ResponseCacheStore interface {
+	// Get returns the response stored under key, and false if there is none or it expired.
+	Get(ctx context.Context, key string) ([]byte, bool)
+
+	// Set stores response under key for ttl.
+	Set(ctx context.Context, key string, response []byte, ttl time.Duration)
+}
+
+// NewMemoryResponseCacheStore returns a ResponseCacheStore keeping up to size responses in memory.
+// It is emptied when full.
+func NewMemoryResponseCacheStore(size int) ResponseCacheStore {
+	return &memoryResponseCacheStore{
+		size:    size,
+		entries: map[string]memoryResponse{},
+	}
+}
+
+type memoryResponseCacheStore struct {
+	mutex   sync.Mutex
+	size    int
+	entries map[string]memoryResponse
+}
+
+type memoryResponse struct {
+	response []byte
+	expires  time.Time
+}
+
+func (s *memoryResponseCacheStore) Get(ctx context.Context, key string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return entry.response, true
+}
+
+func (s *memoryResponseCacheStore) Set(ctx context.Context, key string, response []byte, ttl time.Duration) {
+	if s.size <= 0 {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.entries[key]; !ok && len(s.entries) >= s.size {
+		s.entries = map[string]memoryResponse{}
+	}
+	s.entries[key] = memoryResponse{response: response, expires: time.Now().Add(ttl)}
+}
+
+// RedisClient is the part of a Redis client used by NewRedisResponseCacheStore, so that any client
+// library can be adapted to it, e.g. with github.com/redis/go-redis:
+//
+//	func (c adapter) Get(ctx context.Context, key string) ([]byte, error) {
+//		value, err := c.client.Get(ctx, key).Bytes()
+//		if err == redis.Nil {
+//			return nil, nil
+//		}
+//		return value, err
+//	}
+//
+//	func (c adapter) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+//		return c.client.Set(ctx, key, value, ttl).Err()
+//	}
+type RedisClient interface {
+	// Get returns the value of key, nil without an error if there is none.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set sets the value of key, expiring after ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// NewRedisResponseCacheStore returns a ResponseCacheStore keeping the responses in Redis, under
+// keys starting with prefix, so that they are shared by the instances of a service. The errors of
+// the client are handled as cache misses.
+func NewRedisResponseCacheStore(client RedisClient, prefix string) ResponseCacheStore {
+	return &redisResponseCacheStore{client: client, prefix: prefix}
+}
+
+type redisResponseCacheStore struct {
+	client RedisClient
+	prefix string
+}
+
+func (s *redisResponseCacheStore) Get(ctx context.Context, key string) ([]byte, bool) {
+	response, err := s.client.Get(ctx, s.prefix+key)
+	return response, err == nil && response != nil
+}
+
+func (s *redisResponseCacheStore) Set(ctx context.Context, key string, response []byte, ttl time.Duration) {
+	s.client.Set(ctx, s.prefix+key, response, ttl)
+}
+
+// cachedResponse is a response as it is kept in a ResponseCacheStore.
+type cachedResponse struct {
+	Body    []byte    `json:"body"`
+	Private bool      `json:"private,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+// responseCacheLookup is the lookup of the response to a query in a ResponseCache.
+type responseCacheLookup struct {
+	cache      *ResponseCache
+	publicKey  string
+	privateKey string
+	policy     *graphql.CachePolicy
+
+	// hit is the cached response, if it was found
+	hit *cachedResponse
+}
+
+// lookup looks up the response to the query of opts, returning a context computing the
+// CachePolicy of its execution in case it isn't found.
+func (c *ResponseCache) lookup(ctx context.Context, r *http.Request, opts *RequestOptions) (context.Context, *responseCacheLookup) {
+	variables, _ := json.Marshal(opts.Variables)
+	session := ""
+	if c.SessionKeyFn != nil {
+		session = c.SessionKeyFn(r)
+	}
+	lookup := &responseCacheLookup{
+		cache:     c,
+		publicKey: responseCacheKey("public", opts.Query, opts.OperationName, string(variables)),
+	}
+	if session != "" {
+		lookup.privateKey = responseCacheKey("private", session, opts.Query, opts.OperationName, string(variables))
+	}
+
+	for _, key := range []string{lookup.privateKey, lookup.publicKey} {
+		if key == "" {
+			continue
+		}
+		stored, ok := c.Store.Get(ctx, key)
+		if !ok {
+			continue
+		}
+		var cached cachedResponse
+		if json.Unmarshal(stored, &cached) == nil && time.Now().Before(cached.Expires) {
+			lookup.hit = &cached
+			return ctx, lookup
+		}
+	}
+	ctx, lookup.policy = graphql.WithCachePolicy(ctx)
+	return ctx, lookup
+}
+
+// result decodes the cached response.
+func (lookup *responseCacheLookup) result() *graphql.Result {
+	result := &graphql.Result{}
+	json.Unmarshal(lookup.hit.Body, result)
+	return result
+}
+
+// cacheControl returns the Cache-Control header of the response, "" if it can't be cached.
+func (lookup *responseCacheLookup) cacheControl(result *graphql.Result) string {
+	var maxAge time.Duration
+	private := false
+	if lookup.hit != nil {
+		maxAge, private = time.Until(lookup.hit.Expires), lookup.hit.Private
+	} else if hint, ok := lookup.policy.Hint(); ok && !result.HasErrors() {
+		maxAge, private = hint.MaxAge, hint.Private
+	}
+	if maxAge < time.Second {
+		return ""
+	}
+	if private {
+		return fmt.Sprintf("private, max-age=%d", int64(maxAge/time.Second))
+	}
+	return fmt.Sprintf("public, max-age=%d", int64(maxAge/time.Second))
+}
+
+// store stores the encoded response of an execution if it can be cached.
+func (lookup *responseCacheLookup) store(ctx context.Context, result *graphql.Result, body []byte) {
+	if lookup.hit != nil || result.HasErrors() {
+		return
+	}
+	hint, ok := lookup.policy.Hint()
+	if !ok {
+		return
+	}
+	key := lookup.publicKey
+	if hint.Private {
+		key = lookup.privateKey
+	}
+	if key == "" {
+		return
+	}
+	stored, err := json.Marshal(cachedResponse{
+		Body:    body,
+		Private: hint.Private,
+		Expires: time.Now().Add(hint.MaxAge),
+	})
+	if err == nil {
+		lookup.cache.Store.Set(ctx, key, stored, hint.MaxAge)
+	}
+}
+
+// cacheableQuery tells if the response to the query operation of r may be looked up in the
+// cache: when its execution would be allowed.
+func (h *Handler) cacheableQuery(ctx context.Context, r *http.Request, operationName string) bool {
+	if r.Method == http.MethodGet && h.forbidGETQueries {
+		return false
+	}
+	// the cached responses are sent without validating the query, so the hidden and internal
+	// fields would be sent to the requests that can't see them
+	if h.visibilityFn != nil || graphql.HasInternalAccess(ctx) {
+		return false
+	}
+	return h.allowOperationFn == nil || h.allowOperationFn(ctx, operationName)
+}
+
+// responseCacheKey is the hex encoded hash of parts.
+func responseCacheKey(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(hash, "%d:%s", len(part), part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// resumeTokens keeps the subscriptions of the websocket connections by the resume token they
+// were issued, so that a client reconnecting with the token of its previous connection gets its
+// subscriptions restored, see Config.ResumeTokenTTL.
+type resumeTokens struct {
+	ttl time.Duration
+
+	mutex       sync.Mutex
+	connections map[string]*resumableConnection
+}
+
+// resumableConnection holds the subscribe messages of the running subscriptions of a connection.
+type resumableConnection struct {
+	subscriptions map[string]GraphQLWSMessage
+	connected     bool
+	closedAt      time.Time
+}
+
+func newResumeTokens(ttl time.Duration) *resumeTokens {
+	return &resumeTokens{ttl: ttl, connections: map[string]*resumableConnection{}}
+}
+
+// resume returns the token of a new connection and the subscriptions to restore for it: those of
+// the closed connection of token, if it is still known, in which case the token is kept. Otherwise
+// a new token is issued.
+func (r *resumeTokens) resume(token string) (string, []GraphQLWSMessage) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sweep()
+
+	if connection, ok := r.connections[token]; ok && !connection.connected {
+		connection.connected = true
+		subscriptions := make([]GraphQLWSMessage, 0, len(connection.subscriptions))
+		for _, msg := range connection.subscriptions {
+			subscriptions = append(subscriptions, msg)
+		}
+		return token, subscriptions
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	token = hex.EncodeToString(b)
+	r.connections[token] = &resumableConnection{
+		subscriptions: map[string]GraphQLWSMessage{},
+		connected:     true,
+	}
+	return token, nil
+}
+
+// sweep forgets the connections closed for longer than the TTL.
+func (r *resumeTokens) sweep() {
+	for token, connection := range r.connections {
+		if !connection.connected && time.Since(connection.closedAt) > r.ttl {
+			delete(r.connections, token)
+		}
+	}
+}
+
+// add keeps the subscribe message of a subscription of the connection of token.
+func (r *resumeTokens) add(token, id string, msg GraphQLWSMessage) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if connection, ok := r.connections[token]; ok {
+		connection.subscriptions[id] = msg
+	}
+}
+
+// remove forgets a subscription that completed or was stopped by the client.
+func (r *resumeTokens) remove(token, id string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if connection, ok := r.connections[token]; ok {
+		delete(connection.subscriptions, id)
+	}
+}
+
+// close keeps the subscriptions of the connection of token for the TTL once it is closed.
+func (r *resumeTokens) close(token string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if connection, ok := r.connections[token]; ok {
+		connection.connected = false
+		connection.closedAt = time.Now()
+	}
+}
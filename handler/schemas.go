@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/fiatjaf/graphql"
+)
+
+// SchemaSelectorFn returns the schema a request is executed against, see Config.SchemaSelectorFn.
+// The schemas returned should be created once, not for every request. The error is returned to the
+// client.
+type SchemaSelectorFn func(ctx context.Context, r *http.Request) (*graphql.Schema, error)
+
+// NamedSchemas returns a SchemaSelectorFn selecting the schema of schemas named by nameFn, e.g. after
+// the tenant of the request or a part of its path. Requests naming no schema of schemas are
+// refused.
+func NamedSchemas(schemas map[string]*graphql.Schema, nameFn func(ctx context.Context, r *http.Request) string) SchemaSelectorFn {
+	return func(ctx context.Context, r *http.Request) (*graphql.Schema, error) {
+		name := nameFn(ctx, r)
+		schema, ok := schemas[name]
+		if !ok {
+			return nil, fmt.Errorf(`Unknown schema "%s".`, name)
+		}
+		return schema, nil
+	}
+}
+
+// schemaSelection holds the document caches of the schemas selected by a SchemaSelectorFn, as the
+// documents validated against a schema can't be reused for another.
+type schemaSelection struct {
+	selectorFn     SchemaSelectorFn
+	cacheSize      int
+	documentCaches sync.Map
+}
+
+// schema returns the schema of the request r.
+func (h *Handler) schema(ctx context.Context, r *http.Request) (*graphql.Schema, error) {
+	if h.schemaSelection == nil {
+		return h.Schema, nil
+	}
+	return h.schemaSelection.selectorFn(ctx, r)
+}
+
+// documentCacheFor returns the document cache shared by the requests for schema.
+func (h *Handler) documentCacheFor(schema *graphql.Schema) *graphql.DocumentCache {
+	if h.schemaSelection == nil || h.documentCache == nil {
+		return h.documentCache
+	}
+	if cache, ok := h.schemaSelection.documentCaches.Load(schema); ok {
+		return cache.(*graphql.DocumentCache)
+	}
+	cache, _ := h.schemaSelection.documentCaches.LoadOrStore(schema, graphql.NewDocumentCache(h.schemaSelection.cacheSize))
+	return cache.(*graphql.DocumentCache)
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
+)
+
+// streamChunkSize is the size of the chunks of the streamed responses.
+const streamChunkSize = 32 << 10
+
+var errResponseTooLarge = errors.New("response too large")
+
+// responseTooLarge is the result sent instead of a response larger than maxSize.
+func responseTooLarge(maxSize int64) *graphql.Result {
+	return &graphql.Result{
+		Errors: gqlerrors.FormatErrors(fmt.Errorf("the response exceeds the maximum size of %d bytes", maxSize)),
+	}
+}
+
+// chunkWriter writes the chunks of a streamed response, flushing each of them to the client. It
+// fails with errResponseTooLarge instead of writing more than maxSize bytes, when maxSize is set.
+type chunkWriter struct {
+	w       http.ResponseWriter
+	maxSize int64
+	written int64
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	if cw.maxSize > 0 && cw.written+int64(len(p)) > cw.maxSize {
+		return 0, errResponseTooLarge
+	}
+	n, err := cw.w.Write(p)
+	cw.written += int64(n)
+	if flusher, ok := cw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// streamResult writes the JSON encoding of result to w as it is encoded, in chunks. If the response
+// exceeds maxSize, or fails to be encoded, before its first chunk was sent, an error is sent instead;
+// once the response has started it is aborted, so the client sees an incomplete response.
+func streamResult(w http.ResponseWriter, result *graphql.Result, maxSize int64) {
+	cw := &chunkWriter{w: w, maxSize: maxSize}
+	bw := bufio.NewWriterSize(cw, streamChunkSize)
+	err := result.StreamJSONTo(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		return
+	}
+	if cw.written != 0 {
+		panic(http.ErrAbortHandler)
+	}
+	if errors.Is(err, errResponseTooLarge) {
+		result = responseTooLarge(maxSize)
+	} else {
+		result = &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	w.WriteHeader(http.StatusOK)
+	result.MarshalJSONTo(w)
+}
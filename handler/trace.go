@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+)
+
+// TracePropagator extracts the distributed trace a request belongs to from its headers into the
+// context of its operations, so the calls made by the resolvers can join the trace. Set
+// Config.TracePropagator to plug the propagator of a tracing library instead of the default one.
+type TracePropagator interface {
+	Extract(ctx context.Context, header http.Header) context.Context
+}
+
+// TracePropagatorFunc is a function implementing TracePropagator.
+type TracePropagatorFunc func(ctx context.Context, header http.Header) context.Context
+
+func (fn TracePropagatorFunc) Extract(ctx context.Context, header http.Header) context.Context {
+	return fn(ctx, header)
+}
+
+// DefaultTracePropagator keeps the W3C traceparent and tracestate headers and the B3 headers of
+// the requests, which TraceHeaders returns and InjectTraceHeaders copies into outgoing requests.
+// A malformed traceparent header is dropped along with tracestate.
+var DefaultTracePropagator TracePropagator = TracePropagatorFunc(extractTraceHeaders)
+
+// NoTracePropagator doesn't propagate traces.
+var NoTracePropagator TracePropagator = TracePropagatorFunc(func(ctx context.Context, header http.Header) context.Context {
+	return ctx
+})
+
+var (
+	w3cTraceHeaders = []string{"traceparent", "tracestate"}
+	b3TraceHeaders  = []string{"b3", "X-B3-TraceId", "X-B3-SpanId", "X-B3-ParentSpanId", "X-B3-Sampled", "X-B3-Flags"}
+
+	traceParentPattern = regexp.MustCompile(`^[0-9a-f]{2}-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+)
+
+// extractTrace extracts the trace of a request with the configured propagator.
+func (h *Handler) extractTrace(ctx context.Context, header http.Header) context.Context {
+	if h.tracePropagator == nil {
+		return DefaultTracePropagator.Extract(ctx, header)
+	}
+	return h.tracePropagator.Extract(ctx, header)
+}
+
+type traceHeadersKey struct{}
+
+func extractTraceHeaders(ctx context.Context, header http.Header) context.Context {
+	trace := http.Header{}
+	if traceParentPattern.MatchString(header.Get("traceparent")) {
+		for _, name := range w3cTraceHeaders {
+			if value := header.Get(name); value != "" {
+				trace.Set(name, value)
+			}
+		}
+	}
+	for _, name := range b3TraceHeaders {
+		if value := header.Get(name); value != "" {
+			trace.Set(name, value)
+		}
+	}
+	if len(trace) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, traceHeadersKey{}, trace)
+}
+
+// TraceHeaders returns the trace headers kept by DefaultTracePropagator in ctx, or nil.
+func TraceHeaders(ctx context.Context) http.Header {
+	trace, _ := ctx.Value(traceHeadersKey{}).(http.Header)
+	return trace.Clone()
+}
+
+// InjectTraceHeaders sets the trace headers kept by DefaultTracePropagator in ctx on an outgoing
+// request, e.g. one made by a resolver with the context it was given.
+func InjectTraceHeaders(ctx context.Context, req *http.Request) {
+	trace, _ := ctx.Value(traceHeadersKey{}).(http.Header)
+	for name, values := range trace {
+		req.Header[name] = append([]string(nil), values...)
+	}
+}
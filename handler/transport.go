@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// CodeOperationNotAllowed is the code of the errors of the operations whose type can't be sent over
+// the transport of their request, set under the "code" key of their extensions.
+const CodeOperationNotAllowed = "OPERATION_NOT_ALLOWED"
+
+type operationNotAllowedError string
+
+func (e operationNotAllowedError) Error() string {
+	return string(e)
+}
+
+// Extensions implements gqlerrors.ExtendedError.
+func (e operationNotAllowedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": CodeOperationNotAllowed}
+}
+
+// allowHTTPOperationType returns the AllowOperationTypeFn of the operations of the requests with
+// the given method: mutations can't be sent with GET, as they would be open to CSRF, nor queries
+// if Config.ForbidGETQueries is set.
+func (h *Handler) allowHTTPOperationType(method string) graphql.AllowOperationTypeFn {
+	if method != http.MethodGet {
+		return nil
+	}
+	return func(ctx context.Context, operationType string) error {
+		switch {
+		case operationType == ast.OperationTypeMutation:
+			return operationNotAllowedError("Mutations are not allowed in GET requests.")
+		case operationType == ast.OperationTypeQuery && h.forbidGETQueries:
+			return operationNotAllowedError("Queries are not allowed in GET requests.")
+		}
+		return nil
+	}
+}
+
+// allowWebSocketOperationType returns the AllowOperationTypeFn of the operations of the websocket
+// connections, which can't be mutations if Config.ForbidWebSocketMutations is set.
+func (h *Handler) allowWebSocketOperationType() graphql.AllowOperationTypeFn {
+	if !h.forbidWebSocketMutations {
+		return nil
+	}
+	return func(ctx context.Context, operationType string) error {
+		if operationType == ast.OperationTypeMutation {
+			return operationNotAllowedError("Mutations are not allowed over websocket connections.")
+		}
+		return nil
+	}
+}
+
+// DefaultCSRFPreventionHeaders are the headers of which one must be set on the requests that
+// browsers send without a preflight when Config.CSRFPrevention is set, the same as Apollo Server.
+var DefaultCSRFPreventionHeaders = []string{"X-Apollo-Operation-Name", "Apollo-Require-Preflight"}
+
+// CodeBadRequest is the code of the errors of the requests refused by the CSRF prevention.
+const CodeBadRequest = "BAD_REQUEST"
+
+// simpleContentTypes are the content types of the requests that browsers send cross-site without a
+// preflight.
+var simpleContentTypes = map[string]bool{
+	"application/x-www-form-urlencoded": true,
+	"multipart/form-data":               true,
+	"text/plain":                        true,
+}
+
+type csrfError struct {
+	headers []string
+}
+
+func (e csrfError) Error() string {
+	return "This operation has been blocked as a potential Cross-Site Request Forgery (CSRF). " +
+		"Please either specify a 'content-type' header (with a type that is not one of " +
+		"application/x-www-form-urlencoded, multipart/form-data, text/plain) or provide a non-empty " +
+		"value for one of the following headers: " + strings.Join(e.headers, ", ")
+}
+
+// Extensions implements gqlerrors.ExtendedError.
+func (e csrfError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": CodeBadRequest}
+}
+
+// csrfHeaders returns the headers of which one must be set on the requests without preflight.
+func (h *Handler) csrfHeaders() []string {
+	if len(h.csrfPreventionHeaders) != 0 {
+		return h.csrfPreventionHeaders
+	}
+	return DefaultCSRFPreventionHeaders
+}
+
+// preflighted tells if a browser would have sent r cross-site only after a preflight request, as
+// its content type isn't simple or it has one of the CSRF prevention headers, which browsers
+// don't let pages set without a preflight.
+func (h *Handler) preflighted(r *http.Request) bool {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !simpleContentTypes[mediaType] {
+			return true
+		}
+	}
+	for _, header := range h.csrfHeaders() {
+		if r.Header.Get(header) != "" {
+			return true
+		}
+	}
+	return false
+}
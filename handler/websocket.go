@@ -1,18 +1,21 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	syncmap "github.com/SaveTheRbtz/generic-sync-map-go"
 	"github.com/fiatjaf/graphql"
 	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/language/ast"
 	"github.com/gorilla/websocket"
 )
 
@@ -30,10 +33,24 @@ const (
 	maxMessageSize = 512000
 )
 
+const (
+	// SubprotocolGraphQLWS is the legacy subscriptions-transport-ws protocol.
+	SubprotocolGraphQLWS = "graphql-ws"
+
+	// SubprotocolGraphQLTransportWS is the graphql-ws protocol.
+	SubprotocolGraphQLTransportWS = "graphql-transport-ws"
+)
+
 type WebSocket struct {
 	conn                   *websocket.Conn
 	mutex                  sync.Mutex
 	subscriptionCancellers syncmap.MapOf[string, context.CancelFunc]
+
+	// stoppedByClient holds the ids of the operations being stopped by the client
+	stoppedByClient syncmap.MapOf[string, struct{}]
+
+	// subscriptions holds the running subscriptions, reported on every ping
+	subscriptions syncmap.MapOf[string, *wsSubscription]
 }
 
 func (ws *WebSocket) WriteJSON(any interface{}) error {
@@ -42,6 +59,42 @@ func (ws *WebSocket) WriteJSON(any interface{}) error {
 	return ws.conn.WriteJSON(any)
 }
 
+// WriteResult sends a message with the given id and type whose payload is the result, which is
+// encoded right into the message instead of being encoded first and then embedded in it.
+func (ws *WebSocket) WriteResult(id any, messageType string, result *graphql.Result) error {
+	buf := messageBufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledMessageSize {
+			buf.Reset()
+			messageBufferPool.Put(buf)
+		}
+	}()
+
+	idJSON, err := json.Marshal(id)
+	if err != nil {
+		return err
+	}
+	typeJSON, _ := json.Marshal(messageType)
+	buf.WriteString(`{"id":`)
+	buf.Write(idJSON)
+	buf.WriteString(`,"type":`)
+	buf.Write(typeJSON)
+	buf.WriteString(`,"payload":`)
+	if err := result.MarshalJSONTo(buf); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+
+	return ws.WriteMessage(websocket.TextMessage, buf.Bytes())
+}
+
+// maxPooledMessageSize is the size above which message buffers are dropped instead of pooled.
+const maxPooledMessageSize = 64 << 10
+
+var messageBufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
 func (ws *WebSocket) WriteMessage(t int, b []byte) error {
 	ws.mutex.Lock()
 	defer ws.mutex.Unlock()
@@ -52,7 +105,7 @@ var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 	CheckOrigin:     func(r *http.Request) bool { return true },
-	Subprotocols:    []string{"graphql-ws", "graphql-transport-ws"},
+	Subprotocols:    []string{SubprotocolGraphQLWS, SubprotocolGraphQLTransportWS},
 }
 
 type GraphQLWSMessage struct {
@@ -66,11 +119,31 @@ type GraphQLWSSubscriptionPayload struct {
 	Query         string         `json:"query"`
 	Variables     map[string]any `json:"variables"`
 	Extensions    map[string]any `json:"extensions"`
+	ID            string         `json:"id"`
 }
 
 // ContextHandler provides an entrypoint into executing graphQL queries and subscriptions with
 // user-provided context.
 func (h *Handler) ContextWebsocketHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	// the operations of the connection are all executed against the schema of its request
+	schema, err := h.schema(ctx, r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusBadRequest)
+		h.formatErrors(&graphql.Result{Errors: gqlerrors.FormatErrors(err)}).MarshalJSONTo(w)
+		return
+	}
+
+	upgrader := upgrader
+	if h.cors != nil {
+		upgrader.CheckOrigin = h.cors.checkOrigin
+	}
+	if h.wsReadBufferSize > 0 {
+		upgrader.ReadBufferSize = h.wsReadBufferSize
+	}
+	if h.wsWriteBufferSize > 0 {
+		upgrader.WriteBufferSize = h.wsWriteBufferSize
+	}
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("failed to upgrade websocket: %s", err.Error())
@@ -78,11 +151,35 @@ func (h *Handler) ContextWebsocketHandler(ctx context.Context, w http.ResponseWr
 	}
 	ticker := time.NewTicker(pingPeriod)
 	ws := &WebSocket{conn: conn}
+	clientInfo := clientRequestInfo(r)
+	ctx = h.extractTrace(ctx, r.Header)
+
+	documentCache := h.documentCacheFor(schema)
+	if h.connectionCacheSize > 0 {
+		documentCache = graphql.NewDocumentCache(h.connectionCacheSize)
+	}
+
+	// some clients of the legacy protocol ignore ping frames and wait for "ka" messages instead
+	legacyProtocol := conn.Subprotocol() == SubprotocolGraphQLWS
+
+	// the token of the connection when its subscriptions can be resumed, see Config.ResumeTokenTTL
+	var resumeToken atomic.Value
+	resumeToken.Store("")
+	closed := make(chan struct{})
+	var closeOnce sync.Once
 
 	terminateConnection := func() {
 		ticker.Stop()
 		conn.Close()
 
+		// the subscriptions of the connection are kept until it is resumed or they expire
+		closeOnce.Do(func() {
+			close(closed)
+			if token := resumeToken.Load().(string); token != "" {
+				h.resumeTokens.close(token)
+			}
+		})
+
 		ws.subscriptionCancellers.Range(func(id string, cancel context.CancelFunc) bool {
 			ws.subscriptionCancellers.Delete(id)
 			cancel()
@@ -90,8 +187,290 @@ func (h *Handler) ContextWebsocketHandler(ctx context.Context, w http.ResponseWr
 		})
 	}
 
+	// handleMessage handles a message of the client, turn being its place among the serialized
+	// operations of the connection when it sends one, see Config.SerialWebSocketOperations
+	var handleMessage func(message []byte, turn *serialTurn)
+	handleMessage = func(message []byte, turn *serialTurn) {
+		defer turn.end(ctx)
+
+		var msg GraphQLWSMessage
+		err := json.Unmarshal(message, &msg)
+		if err != nil {
+			b, _ := json.Marshal(err.Error())
+			ws.WriteJSON(GraphQLWSMessage{Type: "error", Payload: b})
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			// clients may send headers in this object, we can use this to modify the context
+			// this works because "connection_init" is handled once, before reading the next
+			// messages, and no operation is run before it
+			if h.ModifyContextOnHeaders != nil {
+				var headers map[string]string
+				if err := json.Unmarshal(msg.Payload, &headers); err == nil {
+					ctx = h.ModifyContextOnHeaders(ctx, headers)
+				}
+			}
+			if h.connectionInitFn != nil {
+				var payload map[string]interface{}
+				json.Unmarshal(msg.Payload, &payload)
+				initCtx, err := h.connectionInitFn(ctx, payload)
+				if err != nil {
+					if legacyProtocol {
+						b, _ := json.Marshal(gqlerrors.FormatError(err))
+						ws.WriteJSON(GraphQLWSMessage{Type: "connection_error", Payload: b})
+					} else {
+						ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4403, "Forbidden"))
+					}
+					conn.Close()
+					return
+				}
+				ctx = initCtx
+			}
+
+			var ackPayload json.RawMessage
+			var resumed []GraphQLWSMessage
+			if h.resumeTokens != nil {
+				var resume struct {
+					ResumeToken string `json:"resumeToken"`
+				}
+				json.Unmarshal(msg.Payload, &resume)
+				// the token is issued once per connection, which is only initialized once
+				token := resumeToken.Load().(string)
+				if token == "" {
+					token, resumed = h.resumeTokens.resume(resume.ResumeToken)
+					resumeToken.Store(token)
+				}
+				ackPayload, _ = json.Marshal(map[string]string{"resumeToken": token})
+			}
+
+			ws.WriteJSON(GraphQLWSMessage{Type: "connection_ack", Payload: ackPayload})
+			if legacyProtocol {
+				// clients of the legacy protocol expect a first keepalive right after the ack
+				ws.WriteJSON(GraphQLWSMessage{Type: "ka"})
+			}
+
+			// the subscriptions of the resumed connection run again under their ids
+			for _, subscription := range resumed {
+				subscription.Type = "subscribe"
+				if legacyProtocol {
+					subscription.Type = "start"
+				}
+				message, _ := json.Marshal(subscription)
+				graphql.Go(ctx, "websocket message", func() { handleMessage(message, nil) })
+			}
+
+		case "subscribe", "start":
+			// this will be "subscribe" for graphiql and "start" for playground and zebedee-app
+			dataMessageName, _ := map[string]string{
+				"subscribe": "next",
+				"start":     "data",
+			}[msg.Type]
+
+			id := fmt.Sprintf("%v", msg.ID)
+
+			// writeError ends the operation with an error message, no other message is sent
+			// for it afterwards, not even "complete"
+			writeError := func(errs []gqlerrors.FormattedError) {
+				var b []byte
+				if legacyProtocol {
+					// the legacy protocol sends a single error object
+					b, _ = json.Marshal(errs[0])
+				} else {
+					b, _ = json.Marshal(errs)
+				}
+				ws.WriteJSON(GraphQLWSMessage{ID: msg.ID, Type: "error", Payload: b})
+			}
+
+			var payload GraphQLWSSubscriptionPayload
+			err := json.Unmarshal(msg.Payload, &payload)
+			if err != nil {
+				writeError(gqlerrors.FormatErrors(err))
+				return
+			}
+
+			delivery := newResultDelivery(payload.Extensions)
+			// writeResult sends a result, unless its delivery mode skips it
+			writeResult := func(result *graphql.Result) bool {
+				// this will be "next" for graphiql and "data" for graphql-playground
+				if delivery == nil {
+					ws.WriteResult(msg.ID, dataMessageName, result)
+					return true
+				}
+				b, send, err := delivery.payload(result)
+				if err != nil {
+					b, _ = json.Marshal(&graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+				} else if !send {
+					return false
+				}
+				ws.WriteJSON(GraphQLWSMessage{ID: msg.ID, Type: dataMessageName, Payload: b})
+				return true
+			}
+
+			opts := &RequestOptions{
+				Query:         payload.Query,
+				Variables:     payload.Variables,
+				OperationName: payload.OperationName,
+				Extensions:    payload.Extensions,
+				ID:            payload.ID,
+			}
+			if _, err := h.loadPersistedQuery(ctx, opts); err != nil {
+				writeError(h.formatErrors(&graphql.Result{Errors: gqlerrors.FormatErrors(err)}).Errors)
+				return
+			}
+			if h.requestDidArriveFn != nil {
+				if err := h.requestDidArriveFn(ctx, opts); err != nil {
+					writeError(h.formatErrors(&graphql.Result{Errors: gqlerrors.FormatErrors(err)}).Errors)
+					return
+				}
+			}
+
+			h.logRequest(ctx, opts)
+			operation := requestOperation(documentCache, opts)
+			if turn != nil && isSerialOperation(operation) {
+				turn.wait()
+			} else {
+				// the operation doesn't hold the next ones until it ends
+				turn.end(ctx)
+			}
+
+			// every operation gets its own RequestInfo, from the client of the connection
+			requestInfo := *clientInfo
+			operationCtx := graphql.WithRequestInfo(ctx, &requestInfo)
+			operationCtx = graphql.WithOperationContext(operationCtx, &graphql.OperationContext{Started: time.Now()})
+			cancellableCtx, cancel := context.WithCancel(operationCtx)
+			ws.subscriptionCancellers.Store(id, cancel)
+			defer func() {
+				ws.subscriptionCancellers.Delete(id)
+				cancel()
+			}()
+
+			params := graphql.Params{
+				Schema:               *schema,
+				RequestString:        opts.Query,
+				VariableValues:       opts.Variables,
+				OperationName:        opts.OperationName,
+				Context:              cancellableCtx,
+				MaxCost:              h.maxCost,
+				MaxNodes:             h.maxNodes,
+				RateLimitFn:          h.rateLimitFn,
+				AllowOperationFn:     h.allowOperationFn,
+				DocumentCache:        documentCache,
+				PreserveFieldOrder:   !h.sortFields,
+				AllowOperationTypeFn: h.allowWebSocketOperationType(),
+				VisibilityFn:         h.visibilityFn,
+			}
+			params.RootObject = h.rootObject(cancellableCtx, r, opts)
+
+			var ch chan *graphql.Result
+			if h.multiplexer != nil && h.ModifyContextOnHeaders == nil &&
+				operation != nil && operation.Operation == ast.OperationTypeSubscription {
+				// subscriptions may share their execution with those of the same credentials
+				credentials := responseCacheKey(r.Header.Get("Authorization"), r.Header.Get("Cookie"))
+				ch = h.multiplexer.subscribe(params, credentials)
+			} else {
+				// live queries and subscriptions send many results, queries and mutations one
+				ch = graphql.DoAsync(params)
+			}
+
+			// the operation was parsed, so its type and name are known unless it is invalid
+			isSubscription := requestInfo.OperationType == ast.OperationTypeSubscription ||
+				operation != nil && operation.Operation == ast.OperationTypeSubscription
+
+			var subscription *wsSubscription
+			if isSubscription {
+				subscription = &wsSubscription{
+					ctx:           operationCtx,
+					id:            id,
+					operationName: opts.OperationName,
+					startedAt:     time.Now(),
+				}
+				if requestInfo.OperationName != "" {
+					subscription.operationName = requestInfo.OperationName
+				}
+				ws.subscriptions.Store(id, subscription)
+				defer ws.subscriptions.Delete(id)
+				h.reportSubscriptionEvent(subscription, SubscriptionStarted, nil)
+			}
+
+			// subscriptions are kept to be resumed until they end, unless the connection closes
+			token := resumeToken.Load().(string)
+			if token != "" && isSubscription {
+				h.resumeTokens.add(token, id, msg)
+				defer func() {
+					select {
+					case <-closed:
+					default:
+						h.resumeTokens.remove(token, id)
+					}
+				}()
+			}
+
+			first, failed := true, false
+			for result := range ch {
+				if cancellableCtx.Err() != nil {
+					// stopped by the client, which doesn't expect any more messages
+					continue
+				}
+				result = h.formatErrors(result)
+				if first && result.IsRequestError() {
+					// the operation failed before being executed (e.g. validation errors), the
+					// errors of its fields are sent as a result
+					writeError(result.Errors)
+					h.reportSubscriptionEvent(subscription, SubscriptionErrored, result.Errors)
+					failed = true
+					cancel()
+					continue
+				}
+				first = false
+				if writeResult(result) && subscription != nil {
+					atomic.AddInt64(&subscription.deliveries, 1)
+					h.reportSubscriptionEvent(subscription, SubscriptionDelivered, nil)
+				}
+				if result.HasErrors() {
+					h.reportSubscriptionEvent(subscription, SubscriptionErrored, result.Errors)
+				}
+			}
+
+			_, stoppedByClient := ws.stoppedByClient.Load(id)
+			ws.stoppedByClient.Delete(id)
+			switch {
+			case cancellableCtx.Err() == nil:
+				ws.WriteJSON(GraphQLWSMessage{ID: msg.ID, Type: "complete"})
+				h.reportSubscriptionEvent(subscription, SubscriptionCompleted, nil)
+			case failed:
+			case stoppedByClient:
+				h.reportSubscriptionEvent(subscription, SubscriptionCancelledByClient, nil)
+			default:
+				h.reportSubscriptionEvent(subscription, SubscriptionCancelledByServer, nil)
+			}
+
+		case "stop", "complete":
+			// this will be "stop" for the legacy protocol and "complete" for graphql-transport-ws
+			// cancel the context for this subscription such that we stop streaming graphql data into nowhere
+			if cancel, ok := ws.subscriptionCancellers.Load(fmt.Sprintf("%v", msg.ID)); ok {
+				ws.subscriptionCancellers.Delete(fmt.Sprintf("%v", msg.ID))
+				ws.stoppedByClient.Store(fmt.Sprintf("%v", msg.ID), struct{}{})
+				cancel()
+			}
+		}
+	}
+
+	// when operations are serialized, queries and mutations are run one after the other, in the
+	// order they were received: each waits for the end of the turn of the previous operation
+	var previousTurn <-chan struct{}
+	if h.serialOperations {
+		ended := make(chan struct{})
+		close(ended)
+		previousTurn = ended
+	}
+
+	// the reader and the writer are labelled with the context the connection was opened with
+	connectionCtx := ctx
+
 	// reader
-	go func() {
+	graphql.Go(connectionCtx, "websocket reader", func() {
 		defer terminateConnection()
 
 		conn.SetReadLimit(maxMessageSize)
@@ -101,6 +480,7 @@ func (h *Handler) ContextWebsocketHandler(ctx context.Context, w http.ResponseWr
 			return nil
 		})
 
+		initialized := false
 		for {
 			typ, message, err := conn.ReadMessage()
 			if err != nil {
@@ -118,109 +498,110 @@ func (h *Handler) ContextWebsocketHandler(ctx context.Context, w http.ResponseWr
 				continue
 			}
 
-			go func(message []byte) {
-				var msg GraphQLWSMessage
-				err := json.Unmarshal(message, &msg)
-				if err != nil {
-					b, _ := json.Marshal(err.Error())
-					ws.WriteJSON(GraphQLWSMessage{Type: "error", Payload: b})
+			// connection_init changes the context of the next messages, so it is handled before
+			// reading them, and only once: the operations read the context it sets
+			var peek struct {
+				ID      interface{}                  `json:"id"`
+				Type    string                       `json:"type"`
+				Payload GraphQLWSSubscriptionPayload `json:"payload"`
+			}
+			json.Unmarshal(message, &peek)
+			switch {
+			case peek.Type == "connection_init" && initialized:
+				ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4429, "Too many initialisation requests"))
+				return
+			case peek.Type == "connection_init":
+				handleMessage(message, nil)
+				initialized = true
+			case (peek.Type == "subscribe" || peek.Type == "start") && !initialized:
+				// the operations are only run once the connection is acknowledged
+				if !legacyProtocol {
+					ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4401, "Unauthorized"))
 					return
 				}
-
-				switch msg.Type {
-				case "connection_init":
-					ws.WriteJSON(GraphQLWSMessage{Type: "connection_ack"})
-
-					// clients may send headers in this object, we can use this to modify the context
-					// this works because "connection_init" is always the first message
-					if h.ModifyContextOnHeaders != nil {
-						var headers map[string]string
-						if err := json.Unmarshal(msg.Payload, &headers); err == nil {
-							ctx = h.ModifyContextOnHeaders(ctx, headers)
-						}
-					}
-
-				case "subscribe", "start":
-					// this will be "subscribe" for graphiql and "start" for playground and zebedee-app
-					dataMessageName, _ := map[string]string{
-						"subscribe": "next",
-						"start":     "data",
-					}[msg.Type]
-
-					var payload GraphQLWSSubscriptionPayload
-					err := json.Unmarshal(msg.Payload, &payload)
-					if err != nil {
-						b, _ := json.Marshal(err.Error())
-						ws.WriteJSON(GraphQLWSMessage{Type: "error", Payload: b})
-						return
-					}
-
-					cancellableCtx, cancel := context.WithCancel(ctx)
-					ws.subscriptionCancellers.Store(fmt.Sprintf("%v", msg.ID), cancel)
-
-					params := graphql.Params{
-						Schema:         *h.Schema,
-						RequestString:  payload.Query,
-						VariableValues: payload.Variables,
-						OperationName:  payload.OperationName,
-						Context:        cancellableCtx,
-					}
-
-					writeResult := func(result *graphql.Result) {
-						b, _ := json.Marshal(result)
-						ws.WriteJSON(GraphQLWSMessage{
-							ID:      msg.ID,
-							Payload: json.RawMessage(b),
-
-							// this will be "next" for graphiql and "data" for graphql-playground
-							Type: dataMessageName,
-						})
-					}
-
-					if strings.HasPrefix(strings.TrimLeft(payload.Query, " "), "subscription") {
-						// subscription
-						ch := graphql.DoAsync(params)
-						for result := range ch {
-							writeResult(result)
-						}
-					} else {
-						// query or mutation
-						result := graphql.Do(params)
-						if formatErrorFn := h.formatErrorFn; formatErrorFn != nil && len(result.Errors) > 0 {
-							formatted := make([]gqlerrors.FormattedError, len(result.Errors))
-							for i, formattedError := range result.Errors {
-								formatted[i] = formatErrorFn(formattedError.OriginalError())
-							}
-							result.Errors = formatted
-						}
-						writeResult(result)
-						cancel() // cancel the context here
-					}
-
-				case "stop":
-					// cancel the context for this subscription such that we stop streaming graphql data into nowhere
-					if cancel, ok := ws.subscriptionCancellers.Load(fmt.Sprintf("%v", msg.ID)); ok {
-						ws.subscriptionCancellers.Delete(fmt.Sprintf("%v", msg.ID))
-						cancel()
-					}
-				}
-			}(message)
+				b, _ := json.Marshal(gqlerrors.FormatError(errors.New("connection not initialized")))
+				ws.WriteJSON(GraphQLWSMessage{ID: peek.ID, Type: "error", Payload: b})
+			case previousTurn != nil && (peek.Type == "subscribe" || peek.Type == "start"):
+				// the operations are only known to be serialized once they are parsed, which
+				// happens concurrently, so every one of them takes a turn
+				turn := &serialTurn{previous: previousTurn, ended: make(chan struct{})}
+				previousTurn = turn.ended
+				graphql.Go(ctx, "websocket message", func() { handleMessage(message, turn) })
+			default:
+				graphql.Go(ctx, "websocket message", func() { handleMessage(message, nil) })
+			}
 		}
-	}()
+	})
 
 	// writer
-	go func() {
+	graphql.Go(connectionCtx, "websocket writer", func() {
 		defer terminateConnection()
 
 		for {
 			select {
+			case <-closed:
+				// the stopped ticker doesn't tick anymore
+				return
 			case <-ticker.C:
 				err := ws.WriteMessage(websocket.PingMessage, nil)
 				if err != nil {
 					log.Printf("error writing ping, closing websocket: %s", err.Error())
 					return
 				}
+				if legacyProtocol {
+					if err := ws.WriteJSON(GraphQLWSMessage{Type: "ka"}); err != nil {
+						log.Printf("error writing keepalive, closing websocket: %s", err.Error())
+						return
+					}
+				}
+				ws.subscriptions.Range(func(id string, subscription *wsSubscription) bool {
+					h.reportSubscriptionEvent(subscription, SubscriptionHeartbeat, nil)
+					return true
+				})
 			}
 		}
-	}()
+	})
+}
+
+// isSerialOperation tells if operation is a query or a mutation, which are run one after the
+// other when the operations of a connection are serialized, see Config.SerialWebSocketOperations.
+// The subscriptions and the live queries run until they are stopped, so they are run on their own,
+// as are the invalid operations, which fail right away.
+func isSerialOperation(operation *ast.OperationDefinition) bool {
+	if operation == nil || operation.Operation == ast.OperationTypeSubscription {
+		return false
+	}
+	for _, directive := range operation.Directives {
+		if directive.Name != nil && directive.Name.Value == graphql.LiveDirective.Name {
+			return false
+		}
+	}
+	return true
+}
+
+// serialTurn is the place of an operation among the serialized operations of a connection.
+type serialTurn struct {
+	// previous is closed when the turn of the previous operation ended
+	previous <-chan struct{}
+	ended    chan struct{}
+	endOnce  sync.Once
+}
+
+// wait blocks until the turn of the previous operation ended.
+func (t *serialTurn) wait() {
+	<-t.previous
+}
+
+// end ends the turn once the turn of the previous operation ended, without waiting for it. Only
+// its first call does anything.
+func (t *serialTurn) end(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	t.endOnce.Do(func() {
+		graphql.Go(ctx, "websocket turn", func() {
+			<-t.previous
+			close(t.ended)
+		})
+	})
 }
@@ -0,0 +1,72 @@
+package handler_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql/benchutil"
+	"github.com/fiatjaf/graphql/handler"
+)
+
+// Benchmark the delivery of subscription events to clients × subscriptions, each iteration
+// being an event published to all the subscriptions.
+func BenchmarkSubscriptions_1x1(b *testing.B) {
+	subscriptionsBenchmark(benchutil.SubscriptionLoad{Clients: 1, SubscriptionsPerClient: 1})(b)
+}
+
+func BenchmarkSubscriptions_10x10(b *testing.B) {
+	subscriptionsBenchmark(benchutil.SubscriptionLoad{Clients: 10, SubscriptionsPerClient: 10})(b)
+}
+
+func BenchmarkSubscriptions_100x1(b *testing.B) {
+	subscriptionsBenchmark(benchutil.SubscriptionLoad{Clients: 100, SubscriptionsPerClient: 1})(b)
+}
+
+func BenchmarkSubscriptions_1x100(b *testing.B) {
+	subscriptionsBenchmark(benchutil.SubscriptionLoad{Clients: 1, SubscriptionsPerClient: 100})(b)
+}
+
+// Benchmark events published every millisecond, whether or not the previous ones were received.
+func BenchmarkSubscriptions_10x1_1ms(b *testing.B) {
+	subscriptionsBenchmark(benchutil.SubscriptionLoad{
+		Clients:                10,
+		SubscriptionsPerClient: 1,
+		EventInterval:          time.Millisecond,
+	})(b)
+}
+
+// Benchmark large events with the websocket buffer sizes of the handler.
+func BenchmarkSubscriptions_LargeEvents(b *testing.B) {
+	for _, size := range []int{1024, 16 << 10} {
+		b.Run(fmt.Sprintf("WriteBuffer_%d", size), subscriptionsBenchmark(benchutil.SubscriptionLoad{
+			Clients:                10,
+			SubscriptionsPerClient: 1,
+			Payload:                32 << 10,
+			Config:                 handler.Config{WebSocketWriteBufferSize: size},
+		}))
+	}
+}
+
+func BenchmarkSubscriptions_Multiplexed_10x10(b *testing.B) {
+	subscriptionsBenchmark(benchutil.SubscriptionLoad{
+		Clients:                10,
+		SubscriptionsPerClient: 10,
+		Config:                 handler.Config{MultiplexSubscriptions: true},
+	})(b)
+}
+
+func subscriptionsBenchmark(load benchutil.SubscriptionLoad) func(b *testing.B) {
+	return func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		result, err := benchutil.RunSubscriptionLoad(load, b.N)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportMetric(float64(result.Percentile(50)), "p50-ns")
+		b.ReportMetric(float64(result.Percentile(99)), "p99-ns")
+		b.ReportMetric(result.Throughput(), "events/s")
+		b.ReportMetric(float64(result.Missed), "missed")
+	}
+}
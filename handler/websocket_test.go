@@ -0,0 +1,1266 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/handler"
+	"github.com/fiatjaf/graphql/testutil"
+	"github.com/gorilla/websocket"
+)
+
+// dialWebsocket starts a server for h and opens an initialized graphql-ws connection to it.
+func dialWebsocket(t *testing.T, h *handler.Handler) *websocket.Conn {
+	return dialWebsocketWithSubprotocols(t, h)
+}
+
+// dialWebsocketWithSubprotocols is dialWebsocket negotiating one of the given subprotocols.
+func dialWebsocketWithSubprotocols(t *testing.T, h *handler.Handler, subprotocols ...string) *websocket.Conn {
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+
+	dialer := websocket.Dialer{Subprotocols: subprotocols}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{Type: "connection_init"})
+	if msg := readWSMessage(t, conn); msg.Type != "connection_ack" {
+		t.Fatalf("expected connection_ack, got %q", msg.Type)
+	}
+	return conn
+}
+
+func writeWSMessage(t *testing.T, conn *websocket.Conn, msg handler.GraphQLWSMessage) {
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("failed to write message: %v", err)
+	}
+}
+
+func readWSMessage(t *testing.T, conn *websocket.Conn) handler.GraphQLWSMessage {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg handler.GraphQLWSMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+	return msg
+}
+
+func subscribePayload(t *testing.T, payload handler.GraphQLWSSubscriptionPayload) json.RawMessage {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func decodeWSResult(t *testing.T, msg handler.GraphQLWSMessage) *graphql.Result {
+	var result graphql.Result
+	if err := json.Unmarshal(msg.Payload, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	return &result
+}
+
+func TestWebsocket_RequestDidArriveFn_RewritesRequest(t *testing.T) {
+	h := handler.New(&handler.Config{
+		Schema:    &testutil.StarWarsSchema,
+		WebSocket: true,
+		RequestDidArriveFn: func(ctx context.Context, opts *handler.RequestOptions) error {
+			opts.Variables = map[string]interface{}{"episode": "EMPIRE"}
+			return nil
+		},
+	})
+	conn := dialWebsocket(t, h)
+
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:   "1",
+		Type: "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{
+			Query: `query HeroNameQuery($episode: Episode) { hero(episode: $episode) { name } }`,
+		}),
+	})
+
+	msg := readWSMessage(t, conn)
+	if msg.Type != "next" || msg.ID != "1" {
+		t.Fatalf("unexpected message %q for %v", msg.Type, msg.ID)
+	}
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"hero": map[string]interface{}{
+				"name": "Luke Skywalker",
+			},
+		},
+	}
+	if result := decodeWSResult(t, msg); !reflect.DeepEqual(result, expected) {
+		t.Fatalf("wrong result, graphql result diff: %v", testutil.Diff(expected, result))
+	}
+}
+
+// multiplexedTickSchema returns a schema whose tick subscription counts its executions in
+// subscribeCalls.
+func multiplexedTickSchema(t *testing.T, subscribeCalls *int32) *graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"tick": &graphql.Field{
+					Type: graphql.Int,
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						atomic.AddInt32(subscribeCalls, 1)
+						c := make(chan interface{})
+						go func() {
+							defer close(c)
+							for i := 0; ; i++ {
+								select {
+								case <-p.Context.Done():
+									return
+								case c <- i:
+									time.Sleep(10 * time.Millisecond)
+								}
+							}
+						}()
+						return c, nil
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &schema
+}
+
+func TestWebsocket_MultiplexSubscriptions_SharesExecution(t *testing.T) {
+	var subscribeCalls int32
+	schema := multiplexedTickSchema(t, &subscribeCalls)
+	h := handler.New(&handler.Config{
+		Schema:                 schema,
+		WebSocket:              true,
+		MultiplexSubscriptions: true,
+	})
+
+	conns := []*websocket.Conn{dialWebsocket(t, h), dialWebsocket(t, h)}
+	for _, conn := range conns {
+		writeWSMessage(t, conn, handler.GraphQLWSMessage{
+			ID:      "1",
+			Type:    "subscribe",
+			Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `subscription { tick }`}),
+		})
+	}
+	for _, conn := range conns {
+		msg := readWSMessage(t, conn)
+		if msg.Type != "next" {
+			t.Fatalf("expected next, got %q", msg.Type)
+		}
+		if result := decodeWSResult(t, msg); result.HasErrors() {
+			t.Fatalf("unexpected errors: %v", result.Errors)
+		}
+	}
+	if calls := atomic.LoadInt32(&subscribeCalls); calls != 1 {
+		t.Fatalf("expected the subscription to be executed once, got %d", calls)
+	}
+}
+
+func TestWebsocket_MultiplexSubscriptions_ChecksEverySubscriber(t *testing.T) {
+	var subscribeCalls, allowCalls int32
+	h := handler.New(&handler.Config{
+		Schema:                 multiplexedTickSchema(t, &subscribeCalls),
+		WebSocket:              true,
+		MultiplexSubscriptions: true,
+		AllowOperationFn: func(ctx context.Context, operationName string) bool {
+			// only the first subscriber is allowed
+			return atomic.AddInt32(&allowCalls, 1) == 1
+		},
+	})
+
+	subscribe := func(conn *websocket.Conn) handler.GraphQLWSMessage {
+		writeWSMessage(t, conn, handler.GraphQLWSMessage{
+			ID:      "1",
+			Type:    "subscribe",
+			Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `subscription { tick }`}),
+		})
+		return readWSMessage(t, conn)
+	}
+	if msg := subscribe(dialWebsocket(t, h)); msg.Type != "next" {
+		t.Fatalf("expected next, got %q", msg.Type)
+	} else if result := decodeWSResult(t, msg); result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	msg := subscribe(dialWebsocket(t, h))
+	if msg.Type == "next" {
+		if result := decodeWSResult(t, msg); !result.HasErrors() {
+			t.Fatalf("expected the second subscriber to be refused, got %v", result.Data)
+		}
+	} else if msg.Type != "error" {
+		t.Fatalf("expected the second subscriber to be refused, got %q", msg.Type)
+	}
+	if calls := atomic.LoadInt32(&subscribeCalls); calls != 1 {
+		t.Fatalf("expected the subscription to be executed once, got %d", calls)
+	}
+}
+
+func TestWebsocket_ClosedConnection_LeavesNoGoroutines(t *testing.T) {
+	testutil.CheckGoroutines(t)
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"tick": &graphql.Field{
+					Type: graphql.Int,
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						c := make(chan interface{})
+						go func() {
+							defer close(c)
+							for i := 0; ; i++ {
+								select {
+								case <-p.Context.Done():
+									return
+								case c <- i:
+									time.Sleep(10 * time.Millisecond)
+								}
+							}
+						}()
+						return c, nil
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{
+		Schema:    &schema,
+		WebSocket: true,
+	})
+
+	conn := dialWebsocket(t, h)
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `subscription Ticks { tick }`}),
+	})
+	if msg := readWSMessage(t, conn); msg.Type != "next" {
+		t.Fatalf("expected next, got %q", msg.Type)
+	}
+	conn.Close()
+}
+
+func TestWebsocket_LegacyProtocol_SendsKeepAlive(t *testing.T) {
+	h := handler.New(&handler.Config{
+		Schema:    &testutil.StarWarsSchema,
+		WebSocket: true,
+	})
+	conn := dialWebsocketWithSubprotocols(t, h, handler.SubprotocolGraphQLWS)
+	if conn.Subprotocol() != handler.SubprotocolGraphQLWS {
+		t.Fatalf("expected subprotocol %q, got %q", handler.SubprotocolGraphQLWS, conn.Subprotocol())
+	}
+	if msg := readWSMessage(t, conn); msg.Type != "ka" {
+		t.Fatalf("expected ka, got %q", msg.Type)
+	}
+}
+
+func TestWebsocket_TransportProtocol_DoesNotSendKeepAlive(t *testing.T) {
+	h := handler.New(&handler.Config{
+		Schema:    &testutil.StarWarsSchema,
+		WebSocket: true,
+	})
+	conn := dialWebsocketWithSubprotocols(t, h, handler.SubprotocolGraphQLTransportWS)
+
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `{ hero { name } }`}),
+	})
+	if msg := readWSMessage(t, conn); msg.Type != "next" {
+		t.Fatalf("expected next, got %q", msg.Type)
+	}
+}
+
+func TestWebsocket_CompletesOperations(t *testing.T) {
+	h := handler.New(&handler.Config{
+		Schema:    &testutil.StarWarsSchema,
+		WebSocket: true,
+	})
+	conn := dialWebsocket(t, h)
+
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `{ hero { name } }`}),
+	})
+	if msg := readWSMessage(t, conn); msg.Type != "next" || msg.ID != "1" {
+		t.Fatalf("unexpected message %q for %v", msg.Type, msg.ID)
+	}
+	if msg := readWSMessage(t, conn); msg.Type != "complete" || msg.ID != "1" {
+		t.Fatalf("unexpected message %q for %v", msg.Type, msg.ID)
+	}
+}
+
+func TestWebsocket_SendsErrorsWithOperationID(t *testing.T) {
+	h := handler.New(&handler.Config{
+		Schema:    &testutil.StarWarsSchema,
+		WebSocket: true,
+	})
+	conn := dialWebsocket(t, h)
+
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: json.RawMessage(`"not a payload"`),
+	})
+	msg := readWSMessage(t, conn)
+	if msg.Type != "error" || msg.ID != "1" {
+		t.Fatalf("unexpected message %q for %v", msg.Type, msg.ID)
+	}
+
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "2",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `{ villain { name } }`}),
+	})
+	msg = readWSMessage(t, conn)
+	if msg.Type != "error" || msg.ID != "2" {
+		t.Fatalf("unexpected message %q for %v", msg.Type, msg.ID)
+	}
+	var errs []gqlerrors.FormattedError
+	if err := json.Unmarshal(msg.Payload, &errs); err != nil || len(errs) != 1 ||
+		errs[0].Message != `Cannot query field "villain" on type "Query".` {
+		t.Fatalf("unexpected error payload %s", msg.Payload)
+	}
+
+	// no "complete" follows an error, the next message is for the next operation
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "3",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `{ hero { name } }`}),
+	})
+	if msg := readWSMessage(t, conn); msg.Type != "next" || msg.ID != "3" {
+		t.Fatalf("unexpected message %q for %v", msg.Type, msg.ID)
+	}
+}
+
+func TestWebsocket_SendsFieldErrorsAsResults(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"secret": &graphql.Field{
+					Type: graphql.String,
+					Authorize: func(ctx context.Context, p graphql.ResolveParams) error {
+						return errors.New("not allowed")
+					},
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						return make(chan interface{}), nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := dialWebsocket(t, handler.New(&handler.Config{Schema: &schema, WebSocket: true}))
+
+	// the error of the field has a path, the operation was executed
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `subscription { secret }`}),
+	})
+	msg := readWSMessage(t, conn)
+	if msg.Type != "next" || msg.ID != "1" {
+		t.Fatalf("unexpected message %q for %v: %s", msg.Type, msg.ID, msg.Payload)
+	}
+	if result := decodeWSResult(t, msg); len(result.Errors) != 1 || len(result.Errors[0].Path) == 0 {
+		t.Fatalf("expected the error of the field, got %v", result.Errors)
+	}
+	if msg := readWSMessage(t, conn); msg.Type != "complete" || msg.ID != "1" {
+		t.Fatalf("unexpected message %q for %v", msg.Type, msg.ID)
+	}
+}
+
+func TestWebsocket_LegacyProtocol_SendsSingleErrorObject(t *testing.T) {
+	h := handler.New(&handler.Config{
+		Schema:    &testutil.StarWarsSchema,
+		WebSocket: true,
+	})
+	conn := dialWebsocketWithSubprotocols(t, h, handler.SubprotocolGraphQLWS)
+	readWSMessage(t, conn) // ka
+
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "start",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `{ villain { name } }`}),
+	})
+	msg := readWSMessage(t, conn)
+	if msg.Type != "error" || msg.ID != "1" {
+		t.Fatalf("unexpected message %q for %v", msg.Type, msg.ID)
+	}
+	var err gqlerrors.FormattedError
+	if json.Unmarshal(msg.Payload, &err) != nil || err.Message != `Cannot query field "villain" on type "Query".` {
+		t.Fatalf("unexpected error payload %s", msg.Payload)
+	}
+}
+
+func TestWebsocket_ConnectionInitFn_RefusesConnection(t *testing.T) {
+	h := handler.New(&handler.Config{
+		Schema:    &testutil.StarWarsSchema,
+		WebSocket: true,
+		ConnectionInitFn: func(ctx context.Context, payload map[string]interface{}) (context.Context, error) {
+			return ctx, errors.New("invalid token")
+		},
+	})
+	server := httptest.NewServer(h)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	// graphql-transport-ws closes the connection
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	defer conn.Close()
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{Type: "connection_init"})
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, 4403) {
+		t.Fatalf("expected the connection to be closed with 4403, got %v", err)
+	}
+
+	// the legacy protocol sends a connection_error
+	legacyConn, _, err := (&websocket.Dialer{Subprotocols: []string{handler.SubprotocolGraphQLWS}}).Dial(url, nil)
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	defer legacyConn.Close()
+	writeWSMessage(t, legacyConn, handler.GraphQLWSMessage{Type: "connection_init"})
+	msg := readWSMessage(t, legacyConn)
+	var initErr gqlerrors.FormattedError
+	if msg.Type != "connection_error" || json.Unmarshal(msg.Payload, &initErr) != nil || initErr.Message != "invalid token" {
+		t.Fatalf("unexpected message %q: %s", msg.Type, msg.Payload)
+	}
+}
+
+func TestWebsocket_ConnectionInit_GatesOperations(t *testing.T) {
+	h := handler.New(&handler.Config{Schema: &testutil.StarWarsSchema, WebSocket: true})
+	server := httptest.NewServer(h)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	expectClose := func(conn *websocket.Conn, code int) {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, code) {
+			t.Fatalf("expected the connection to be closed with %d, got %v", code, err)
+		}
+	}
+	query := subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `{ hero { name } }`})
+
+	// graphql-transport-ws closes the connection on an operation sent before connection_init
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	defer conn.Close()
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{ID: "1", Type: "subscribe", Payload: query})
+	expectClose(conn, 4401)
+
+	// and on a second connection_init
+	conn = dialWebsocket(t, h)
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{Type: "connection_init"})
+	expectClose(conn, 4429)
+
+	// the legacy protocol refuses the operation
+	legacyConn, _, err := (&websocket.Dialer{Subprotocols: []string{handler.SubprotocolGraphQLWS}}).Dial(url, nil)
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	defer legacyConn.Close()
+	writeWSMessage(t, legacyConn, handler.GraphQLWSMessage{ID: "1", Type: "start", Payload: query})
+	if msg := readWSMessage(t, legacyConn); msg.Type != "error" || msg.ID != "1" {
+		t.Fatalf("unexpected message %q for %v", msg.Type, msg.ID)
+	}
+}
+
+func TestWebsocket_ConnectionInitFn_SetsContext(t *testing.T) {
+	type userKey struct{}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"user": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Context.Value(userKey{}), nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{
+		Schema:    &schema,
+		WebSocket: true,
+		ConnectionInitFn: func(ctx context.Context, payload map[string]interface{}) (context.Context, error) {
+			return context.WithValue(ctx, userKey{}, payload["token"]), nil
+		},
+	})
+	server := httptest.NewServer(h)
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	defer conn.Close()
+
+	// the operation is sent right away, without waiting for the ack
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{Type: "connection_init", Payload: json.RawMessage(`{"token":"alice"}`)})
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `{ user }`}),
+	})
+	if msg := readWSMessage(t, conn); msg.Type != "connection_ack" {
+		t.Fatalf("expected connection_ack, got %q", msg.Type)
+	}
+	expected := &graphql.Result{Data: map[string]interface{}{"user": "alice"}}
+	if result := decodeWSResult(t, readWSMessage(t, conn)); !reflect.DeepEqual(expected, result) {
+		t.Fatalf("wrong result, graphql result diff: %v", testutil.Diff(expected, result))
+	}
+}
+
+func TestWebsocket_SerialWebSocketOperations_RunsMutationsInOrder(t *testing.T) {
+	var mutex sync.Mutex
+	var order []string
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"write": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{
+						"value": &graphql.ArgumentConfig{Type: graphql.String},
+						"delay": &graphql.ArgumentConfig{Type: graphql.Int},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						time.Sleep(time.Duration(p.Args["delay"].(int)) * time.Millisecond)
+						mutex.Lock()
+						order = append(order, p.Args["value"].(string))
+						mutex.Unlock()
+						return p.Args["value"], nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{
+		Schema:                    &schema,
+		WebSocket:                 true,
+		SerialWebSocketOperations: true,
+	})
+	conn := dialWebsocket(t, h)
+
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `mutation { write(value: "first", delay: 100) }`}),
+	})
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "2",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `mutation { write(value: "second", delay: 0) }`}),
+	})
+
+	ids := []interface{}{}
+	for len(ids) < 4 {
+		msg := readWSMessage(t, conn)
+		ids = append(ids, msg.Type+":"+msg.ID.(string))
+	}
+	if expected := []interface{}{"next:1", "complete:1", "next:2", "complete:2"}; !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected messages %v, got %v", expected, ids)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if expected := []string{"first", "second"}; !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected mutations to run in order %v, got %v", expected, order)
+	}
+}
+
+func TestWebsocket_SerialWebSocketOperations_RunsSubscriptionsConcurrently(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean, Resolve: func(p graphql.ResolveParams) (interface{}, error) { return true, nil }}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"never": &graphql.Field{
+					Type: graphql.Int,
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						c := make(chan interface{})
+						go func() {
+							<-p.Context.Done()
+							close(c)
+						}()
+						return c, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := t.TempDir() + "/manifest.json"
+	ioutil.WriteFile(path, []byte(`{"never": "subscription { never }"}`), 0o644)
+	manifest, err := handler.LoadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{
+		Schema:                    &schema,
+		WebSocket:                 true,
+		SerialWebSocketOperations: true,
+		PersistedQueries:          manifest,
+	})
+	conn := dialWebsocket(t, h)
+
+	// neither subscription starts with "subscription", and neither must hold the query
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{ID: "never"}),
+	})
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "2",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: "# waits forever\nsubscription { never }"}),
+	})
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "3",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `{ ok }`}),
+	})
+	if msg := readWSMessage(t, conn); msg.Type != "next" || msg.ID != "3" {
+		t.Fatalf("expected the result of the query, got %q for %v: %s", msg.Type, msg.ID, msg.Payload)
+	}
+}
+
+func TestWebsocket_SerialWebSocketOperations_ReadsRewrittenOperations(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean, Resolve: func(p graphql.ResolveParams) (interface{}, error) { return true, nil }}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"never": &graphql.Field{
+					Type: graphql.Int,
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						c := make(chan interface{})
+						go func() {
+							<-p.Context.Done()
+							close(c)
+						}()
+						return c, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{
+		Schema:                    &schema,
+		WebSocket:                 true,
+		SerialWebSocketOperations: true,
+		RequestDidArriveFn: func(ctx context.Context, opts *handler.RequestOptions) error {
+			if opts.OperationName == "Never" {
+				opts.Query = `subscription Never { never }`
+			}
+			return nil
+		},
+	})
+	conn := dialWebsocket(t, h)
+
+	// the first operation is sent as a query but runs as a subscription, which must not hold the
+	// next query
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `query Never { ok }`, OperationName: "Never"}),
+	})
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "2",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `{ ok }`}),
+	})
+	if msg := readWSMessage(t, conn); msg.Type != "next" || msg.ID != "2" {
+		t.Fatalf("expected the result of the query, got %q for %v: %s", msg.Type, msg.ID, msg.Payload)
+	}
+}
+
+func TestWebsocket_ConnectionDocumentCacheSize_ReusesDocuments(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"operation": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return fmt.Sprintf("%p", p.Info.Operation), nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handler.New(&handler.Config{
+		Schema:                      &schema,
+		WebSocket:                   true,
+		ConnectionDocumentCacheSize: 10,
+	})
+	operation := func(conn *websocket.Conn, id string) interface{} {
+		writeWSMessage(t, conn, handler.GraphQLWSMessage{
+			ID:      id,
+			Type:    "subscribe",
+			Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `{ operation }`}),
+		})
+		msg := readWSMessage(t, conn)
+		if msg.Type != "next" || msg.ID != id {
+			t.Fatalf("unexpected message %q for %v", msg.Type, msg.ID)
+		}
+		readWSMessage(t, conn) // complete
+		return decodeWSResult(t, msg).Data.(map[string]interface{})["operation"]
+	}
+
+	conn := dialWebsocket(t, h)
+	first := operation(conn, "1")
+	if second := operation(conn, "2"); second != first {
+		t.Fatalf("expected the document to be reused by the connection, got %v and %v", first, second)
+	}
+	if other := operation(dialWebsocket(t, h), "1"); other == first {
+		t.Fatalf("expected another connection to parse the document again")
+	}
+}
+
+func TestWebsocket_ResumeTokenTTL_RestoresSubscriptions(t *testing.T) {
+	var subscribeCalls int32
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"tick": &graphql.Field{
+					Type: graphql.Int,
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						atomic.AddInt32(&subscribeCalls, 1)
+						c := make(chan interface{})
+						go func() {
+							defer close(c)
+							for i := 0; ; i++ {
+								select {
+								case <-p.Context.Done():
+									return
+								case c <- i:
+									time.Sleep(10 * time.Millisecond)
+								}
+							}
+						}()
+						return c, nil
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler.New(&handler.Config{
+		Schema:         &schema,
+		WebSocket:      true,
+		ResumeTokenTTL: time.Minute,
+	}))
+	defer server.Close()
+
+	// connect opens a connection resuming token, and returns the token it is issued
+	connect := func(token string) (*websocket.Conn, string) {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		if err != nil {
+			t.Fatalf("failed to dial websocket: %v", err)
+		}
+		payload, _ := json.Marshal(map[string]string{"resumeToken": token})
+		writeWSMessage(t, conn, handler.GraphQLWSMessage{Type: "connection_init", Payload: payload})
+		msg := readWSMessage(t, conn)
+		var ack struct {
+			ResumeToken string `json:"resumeToken"`
+		}
+		if err := json.Unmarshal(msg.Payload, &ack); msg.Type != "connection_ack" || err != nil || ack.ResumeToken == "" {
+			t.Fatalf("expected a connection_ack with a resume token, got %q %s", msg.Type, msg.Payload)
+		}
+		return conn, ack.ResumeToken
+	}
+	expectTick := func(conn *websocket.Conn, id string) {
+		t.Helper()
+		// the subscriptions started before tick meanwhile
+		msg := readWSMessage(t, conn)
+		for msg.Type == "next" && msg.ID != id {
+			msg = readWSMessage(t, conn)
+		}
+		if msg.Type != "next" {
+			t.Fatalf("expected a tick for %v, got %q for %v", id, msg.Type, msg.ID)
+		}
+	}
+
+	conn, token := connect("")
+	for _, id := range []string{"1", "2"} {
+		writeWSMessage(t, conn, handler.GraphQLWSMessage{
+			ID:      id,
+			Type:    "subscribe",
+			Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `subscription { tick }`}),
+		})
+		expectTick(conn, id)
+	}
+	// the stopped subscription isn't resumed
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{ID: "2", Type: "complete"})
+	time.Sleep(50 * time.Millisecond)
+	conn.Close()
+
+	conn, resumedToken := connect(token)
+	defer conn.Close()
+	if resumedToken != token {
+		t.Fatalf("expected the token to be kept, got %q", resumedToken)
+	}
+	expectTick(conn, "1")
+	if calls := atomic.LoadInt32(&subscribeCalls); calls != 3 {
+		t.Fatalf("expected the subscription to be executed again once, got %d executions", calls)
+	}
+
+	// a token can't be resumed while its connection is open
+	other, otherToken := connect(token)
+	defer other.Close()
+	if otherToken == token {
+		t.Fatalf("expected the token of a connected connection to be refused")
+	}
+
+	// a connection is initialized and issued a token once
+	writeWSMessage(t, other, handler.GraphQLWSMessage{Type: "connection_init"})
+	other.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := other.ReadMessage(); !websocket.IsCloseError(err, 4429) {
+		t.Fatalf("expected the connection to be closed with 4429, got %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	resumed, resumedOtherToken := connect(otherToken)
+	defer resumed.Close()
+	if resumedOtherToken != otherToken {
+		t.Fatalf("expected the token of the closed connection to be kept, got %q", resumedOtherToken)
+	}
+}
+
+func TestWebsocket_DeliveryExtension_SendsPatchesAndSkipsIdenticalResults(t *testing.T) {
+	quoteType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Quote",
+		Fields: graphql.Fields{
+			"symbol": &graphql.Field{Type: graphql.String},
+			"price":  &graphql.Field{Type: graphql.Int},
+		},
+	})
+	quotes := []map[string]interface{}{
+		{"symbol": "ACME", "price": 1},
+		{"symbol": "ACME", "price": 1},
+		{"symbol": "ACME", "price": 2},
+		{"symbol": nil, "price": 2},
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"quote": &graphql.Field{
+					Type: quoteType,
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						c := make(chan interface{})
+						go func() {
+							defer close(c)
+							for _, quote := range quotes {
+								select {
+								case <-p.Context.Done():
+									return
+								case c <- quote:
+								}
+							}
+						}()
+						return c, nil
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]string{
+		handler.DeliveryPatch: {
+			`{"data":{"quote":{"price":1,"symbol":"ACME"}}}`,
+			`{"patch":{"data":{"quote":{"price":2}}}}`,
+			`{"data":{"quote":{"price":2,"symbol":null}}}`,
+		},
+		handler.DeliveryDedupe: {
+			`{"data":{"quote":{"price":1,"symbol":"ACME"}}}`,
+			`{"data":{"quote":{"price":2,"symbol":"ACME"}}}`,
+			`{"data":{"quote":{"price":2,"symbol":null}}}`,
+		},
+	}
+	for mode, expected := range tests {
+		conn := dialWebsocket(t, handler.New(&handler.Config{Schema: &schema, WebSocket: true}))
+		writeWSMessage(t, conn, handler.GraphQLWSMessage{
+			ID:   "1",
+			Type: "subscribe",
+			Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{
+				Query:      `subscription { quote { price symbol } }`,
+				Extensions: map[string]interface{}{handler.DeliveryExtension: mode},
+			}),
+		})
+		for _, payload := range expected {
+			msg := readWSMessage(t, conn)
+			if msg.Type != "next" || string(msg.Payload) != payload {
+				t.Fatalf("%s: expected the payload %s, got %q %s", mode, payload, msg.Type, msg.Payload)
+			}
+		}
+		if msg := readWSMessage(t, conn); msg.Type != "complete" {
+			t.Fatalf("%s: expected complete, got %q %s", mode, msg.Type, msg.Payload)
+		}
+	}
+}
+
+func TestWebsocket_SubscriptionEventFn_ReportsLifecycle(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"count": &graphql.Field{
+					Type: graphql.Int,
+					Args: graphql.FieldConfigArgument{
+						"to": &graphql.ArgumentConfig{Type: graphql.Int},
+					},
+					Subscribe: func(p graphql.ResolveParams) (chan interface{}, error) {
+						to, ok := p.Args["to"].(int)
+						c := make(chan interface{})
+						go func() {
+							defer close(c)
+							for i := 0; !ok || i < to; i++ {
+								select {
+								case <-p.Context.Done():
+									return
+								case c <- i:
+									time.Sleep(10 * time.Millisecond)
+								}
+							}
+						}()
+						return c, nil
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan handler.SubscriptionEvent, 100)
+	conn := dialWebsocket(t, handler.New(&handler.Config{
+		Schema:    &schema,
+		WebSocket: true,
+		SubscriptionEventFn: func(ctx context.Context, event handler.SubscriptionEvent) {
+			events <- event
+		},
+	}))
+	expectEvents := func(id, operationName string, types ...handler.SubscriptionEventType) {
+		t.Helper()
+		for _, eventType := range types {
+			select {
+			case event := <-events:
+				if event.Type != eventType || event.ID != id || event.OperationName != operationName {
+					t.Fatalf("expected the %s event of %s %q, got %+v", eventType, id, operationName, event)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("expected the %s event of %s", eventType, id)
+			}
+		}
+	}
+
+	// completed
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `subscription Two { count(to: 2) }`}),
+	})
+	expectEvents("1", "Two", handler.SubscriptionStarted, handler.SubscriptionDelivered,
+		handler.SubscriptionDelivered, handler.SubscriptionCompleted)
+
+	// failed before running
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "2",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `subscription { nope }`}),
+	})
+	expectEvents("2", "", handler.SubscriptionStarted, handler.SubscriptionErrored)
+
+	// cancelled by the client
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "3",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `subscription { count }`}),
+	})
+	expectEvents("3", "", handler.SubscriptionStarted, handler.SubscriptionDelivered)
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{ID: "3", Type: "complete"})
+	for event := range events {
+		if event.Type != handler.SubscriptionDelivered {
+			if event.Type != handler.SubscriptionCancelledByClient || event.ID != "3" {
+				t.Fatalf("expected the subscription to be cancelled by the client, got %+v", event)
+			}
+			break
+		}
+	}
+
+	// cancelled by the server when the connection closes
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "4",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `subscription { count }`}),
+	})
+	expectEvents("4", "", handler.SubscriptionStarted, handler.SubscriptionDelivered)
+	conn.Close()
+	for event := range events {
+		if event.Type != handler.SubscriptionDelivered {
+			if event.Type != handler.SubscriptionCancelledByServer || event.ID != "4" || event.Deliveries == 0 {
+				t.Fatalf("expected the subscription to be cancelled by the server, got %+v", event)
+			}
+			break
+		}
+	}
+}
+
+func TestWebsocket_RootObjectFn_SetsRootOfOperations(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"greeting": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(map[string]interface{})["greeting"], nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configs := map[string]*handler.Config{
+		"Hello from header": {
+			Schema:    &schema,
+			WebSocket: true,
+			RootObjectFn: func(ctx context.Context, r *http.Request) map[string]interface{} {
+				return map[string]interface{}{"greeting": "Hello from " + r.Header.Get("X-From")}
+			},
+		},
+		"Hello from Greet": {
+			Schema:    &schema,
+			WebSocket: true,
+			OperationRootObjectFn: func(ctx context.Context, r *http.Request, opts *handler.RequestOptions) map[string]interface{} {
+				return map[string]interface{}{"greeting": "Hello from " + opts.OperationName}
+			},
+		},
+	}
+	for greeting, config := range configs {
+		server := httptest.NewServer(handler.New(config))
+		defer server.Close()
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), http.Header{"X-From": {"header"}})
+		if err != nil {
+			t.Fatalf("failed to dial websocket: %v", err)
+		}
+		defer conn.Close()
+		writeWSMessage(t, conn, handler.GraphQLWSMessage{Type: "connection_init"})
+		readWSMessage(t, conn)
+
+		writeWSMessage(t, conn, handler.GraphQLWSMessage{
+			ID:   "1",
+			Type: "subscribe",
+			Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{
+				Query:         `query Greet { greeting }`,
+				OperationName: "Greet",
+			}),
+		})
+		expected := &graphql.Result{Data: map[string]interface{}{"greeting": greeting}}
+		if result := decodeWSResult(t, readWSMessage(t, conn)); !reflect.DeepEqual(result, expected) {
+			t.Fatalf("wrong result, graphql result diff: %v", testutil.Diff(expected, result))
+		}
+	}
+}
+
+func TestWebsocket_ForbidWebSocketMutations_RejectsMutations(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"write": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					t.Errorf("the mutation was executed")
+					return "written", nil
+				}},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := dialWebsocket(t, handler.New(&handler.Config{
+		Schema:                   &schema,
+		WebSocket:                true,
+		ForbidWebSocketMutations: true,
+	}))
+
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `mutation { write }`}),
+	})
+	msg := readWSMessage(t, conn)
+	var errs []gqlerrors.FormattedError
+	if err := json.Unmarshal(msg.Payload, &errs); err != nil || msg.Type != "error" || len(errs) != 1 ||
+		errs[0].Extensions["code"] != handler.CodeOperationNotAllowed {
+		t.Fatalf("expected an OPERATION_NOT_ALLOWED error, got %q %s", msg.Type, msg.Payload)
+	}
+}
+
+func TestWebsocket_CORS_RejectsOriginsNotAllowed(t *testing.T) {
+	server := httptest.NewServer(handler.New(&handler.Config{
+		Schema:    &testutil.StarWarsSchema,
+		WebSocket: true,
+		CORS:      &handler.CORS{AllowedOrigins: []string{"https://example.com"}},
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://example.com"}})
+	if err != nil {
+		t.Fatalf("expected the allowed origin to connect: %v", err)
+	}
+	conn.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://example.net"}})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected the origin not allowed to be rejected, got %v", err)
+	}
+}
+
+func TestWebsocket_SchemaSelectorFn_SelectsSchemaOfConnection(t *testing.T) {
+	server := httptest.NewServer(handler.New(&handler.Config{
+		WebSocket: true,
+		SchemaSelectorFn: handler.NamedSchemas(map[string]*graphql.Schema{
+			"/graphql": &testutil.StarWarsSchema,
+		}, func(ctx context.Context, r *http.Request) string { return r.URL.Path }),
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"/admin", nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected the connection to an unknown schema to be refused, got %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"/graphql", nil)
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	defer conn.Close()
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{Type: "connection_init"})
+	if msg := readWSMessage(t, conn); msg.Type != "connection_ack" {
+		t.Fatalf("expected connection_ack, got %q", msg.Type)
+	}
+	writeWSMessage(t, conn, handler.GraphQLWSMessage{
+		ID:      "1",
+		Type:    "subscribe",
+		Payload: subscribePayload(t, handler.GraphQLWSSubscriptionPayload{Query: `{ hero(episode: EMPIRE) { name } }`}),
+	})
+	result := decodeWSResult(t, readWSMessage(t, conn))
+	if len(result.Errors) != 0 ||
+		!reflect.DeepEqual(result.Data, map[string]interface{}{"hero": map[string]interface{}{"name": "Luke Skywalker"}}) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
@@ -0,0 +1,137 @@
+package graphql
+
+import (
+	"fmt"
+)
+
+// IDCodec obfuscates the values of the ID fields of a schema, so that the internal identifiers,
+// like the numeric keys of a database, aren't exposed to the clients. The values of the ID fields
+// are encoded with the name of the object type they belong to, and the values given to the ID
+// arguments and input fields, as literals or variables, are decoded back into the internal
+// identifiers before they reach the resolvers. The default values of the ID arguments must be
+// encoded too.
+type IDCodec interface {
+	// Encode returns the value sent for the internal identifier id of an object of the type
+	// typeName.
+	Encode(typeName, id string) string
+	// Decode returns the type name and the internal identifier of a value returned by Encode, or
+	// an error if it isn't a valid ID.
+	Decode(id string) (typeName, internalID string, err error)
+}
+
+// IDCodec returns the IDCodec of the schema, nil if the IDs aren't encoded.
+func (gq *Schema) IDCodec() IDCodec {
+	return gq.idCodec
+}
+
+// encodeID encodes the serialized value of an ID field of the type typeName with codec.
+func encodeID(codec IDCodec, typeName string, serialized interface{}) interface{} {
+	id, ok := serialized.(string)
+	if !ok {
+		return serialized
+	}
+	return codec.Encode(typeName, id)
+}
+
+// decodeArgumentIDs returns args with the values of its ID arguments, and of the ID fields of its
+// input objects, decoded with codec. args is copied rather than modified.
+func decodeArgumentIDs(codec IDCodec, argDefs []*Argument, args map[string]interface{}) (map[string]interface{}, error) {
+	decoded := args
+	copied := false
+	for _, argDef := range argDefs {
+		value, ok := args[argDef.PrivateName]
+		if !ok || !hasIDs(argDef.Type, map[*InputObject]bool{}) {
+			continue
+		}
+		decodedValue, err := decodeIDs(codec, argDef.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf(`Argument "%v" has an invalid ID: %v`, argDef.PrivateName, err)
+		}
+		if !copied {
+			decoded = make(map[string]interface{}, len(args))
+			for name, value := range args {
+				decoded[name] = value
+			}
+			copied = true
+		}
+		decoded[argDef.PrivateName] = decodedValue
+	}
+	return decoded, nil
+}
+
+// hasIDs tells if the values of ttype can hold IDs, visited holding the input objects already
+// looked into.
+func hasIDs(ttype Input, visited map[*InputObject]bool) bool {
+	switch ttype := ttype.(type) {
+	case *NonNull:
+		return hasIDs(ttype.OfType, visited)
+	case *List:
+		return hasIDs(ttype.OfType, visited)
+	case *InputObject:
+		if visited[ttype] {
+			return false
+		}
+		visited[ttype] = true
+		for _, field := range ttype.Fields() {
+			if hasIDs(field.Type, visited) {
+				return true
+			}
+		}
+	case *Scalar:
+		return ttype == ID
+	}
+	return false
+}
+
+// decodeIDs returns value, coerced to ttype, with its IDs decoded with codec.
+func decodeIDs(codec IDCodec, ttype Input, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch ttype := ttype.(type) {
+	case *NonNull:
+		return decodeIDs(codec, ttype.OfType, value)
+	case *List:
+		items, ok := value.([]interface{})
+		if !ok {
+			return value, nil
+		}
+		decoded := make([]interface{}, len(items))
+		for i, item := range items {
+			decodedItem, err := decodeIDs(codec, ttype.OfType, item)
+			if err != nil {
+				return nil, err
+			}
+			decoded[i] = decodedItem
+		}
+		return decoded, nil
+	case *InputObject:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return value, nil
+		}
+		decoded := make(map[string]interface{}, len(fields))
+		for name, fieldValue := range fields {
+			if field, ok := ttype.Fields()[name]; ok {
+				decodedValue, err := decodeIDs(codec, field.Type, fieldValue)
+				if err != nil {
+					return nil, err
+				}
+				fieldValue = decodedValue
+			}
+			decoded[name] = fieldValue
+		}
+		return decoded, nil
+	case *Scalar:
+		id, ok := value.(string)
+		if ttype != ID || !ok {
+			return value, nil
+		}
+		_, internalID, err := codec.Decode(id)
+		if err != nil {
+			return nil, err
+		}
+		return internalID, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,110 @@
+package graphql_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+)
+
+// base64IDCodec encodes the IDs as the base64 encoding of "Type:id".
+type base64IDCodec struct{}
+
+func (base64IDCodec) Encode(typeName, id string) string {
+	return base64.StdEncoding.EncodeToString([]byte(typeName + ":" + id))
+}
+
+func (base64IDCodec) Decode(id string) (string, string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(id)
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return "", "", errors.New("not a global ID")
+	}
+	return parts[0], parts[1], nil
+}
+
+func TestIDCodec_EncodesAndDecodesIDs(t *testing.T) {
+	var gotArgs map[string]interface{}
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"friendIds": &graphql.Field{Type: graphql.NewList(graphql.ID)},
+			"name":      &graphql.Field{Type: graphql.String},
+		},
+	})
+	filterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UserFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"ids":  &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.ID)},
+			"name": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"user": &graphql.Field{
+					Type: userType,
+					Args: graphql.FieldConfigArgument{
+						"id":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+						"filter": &graphql.ArgumentConfig{Type: filterType},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						gotArgs = p.Args
+						return map[string]interface{}{"id": 42, "friendIds": []string{"7", "8"}, "name": "alice"}, nil
+					},
+				},
+			},
+		}),
+		IDCodec: base64IDCodec{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := base64IDCodec{}.Encode
+
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  `query ($filter: UserFilter) { user(id: "` + encode("User", "42") + `", filter: $filter) { id friendIds name } }`,
+		VariableValues: map[string]interface{}{"filter": map[string]interface{}{"ids": []interface{}{encode("User", "7")}, "name": "bob"}},
+	})
+	if result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	expectedArgs := map[string]interface{}{
+		"id":     "42",
+		"filter": map[string]interface{}{"ids": []interface{}{"7"}, "name": "bob"},
+	}
+	if !reflect.DeepEqual(expectedArgs, gotArgs) {
+		t.Fatalf("expected the decoded arguments %v, got %v", expectedArgs, gotArgs)
+	}
+	expected := map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":        encode("User", "42"),
+			"friendIds": []interface{}{encode("User", "7"), encode("User", "8")},
+			"name":      "alice",
+		},
+	}
+	if !reflect.DeepEqual(expected, result.Data) {
+		t.Fatalf("expected %v, got %v", expected, result.Data)
+	}
+
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ user(id: "` + base64.StdEncoding.EncodeToString([]byte("42")) + `") { name } }`,
+	})
+	expectedMessage := `Argument "id" has an invalid ID: not a global ID`
+	if len(result.Errors) != 1 || result.Errors[0].Message != expectedMessage {
+		t.Fatalf("expected the error %q, got %v", expectedMessage, result.Errors)
+	}
+	if result.Data.(map[string]interface{})["user"] != nil {
+		t.Fatalf("expected no user, got %v", result.Data)
+	}
+}
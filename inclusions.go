@@ -0,0 +1,42 @@
+package graphql
+
+import (
+	"sync"
+
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// inclusions memoizes the evaluation of the @skip and @include directives of the selections of a
+// request, which depends only on its variables: the fields are collected the same way by the
+// executor, the lookahead helpers of ResolveInfo and the complexity analysis, and each selection
+// is evaluated once.
+type inclusions struct {
+	variableValues map[string]interface{}
+	included       sync.Map // ast.Node -> bool
+}
+
+func newInclusions(variableValues map[string]interface{}) *inclusions {
+	return &inclusions{variableValues: variableValues}
+}
+
+// includes tells if the selection node, whose directives are directives, is included.
+func (in *inclusions) includes(node ast.Node, directives []*ast.Directive) bool {
+	if len(directives) == 0 {
+		return true
+	}
+	if included, ok := in.included.Load(node); ok {
+		return included.(bool)
+	}
+	included := shouldIncludeNode(in.variableValues, directives)
+	in.included.Store(node, included)
+	return included
+}
+
+// includes tells if the selection node, whose directives are directives, is included with the
+// variables of the request.
+func (info ResolveInfo) includes(node ast.Node, directives []*ast.Directive) bool {
+	if info.inclusions == nil {
+		return shouldIncludeNode(info.VariableValues, directives)
+	}
+	return info.inclusions.includes(node, directives)
+}
@@ -6,7 +6,6 @@ import (
 	"sort"
 
 	"github.com/fiatjaf/graphql/language/ast"
-	"github.com/fiatjaf/graphql/language/printer"
 )
 
 const (
@@ -255,21 +254,10 @@ func init() {
 					"input value.",
 				Resolve: func(p ResolveParams) (interface{}, error) {
 					if inputVal, ok := p.Source.(*Argument); ok {
-						if inputVal.DefaultValue == nil {
-							return nil, nil
-						}
-						if isNullish(inputVal.DefaultValue) {
-							return nil, nil
-						}
-						astVal := astFromValue(inputVal.DefaultValue, inputVal)
-						return printer.Print(astVal), nil
+						return printDefaultValue(inputVal.DefaultValue, inputVal.defaultLiteral, inputVal.Type), nil
 					}
 					if inputVal, ok := p.Source.(*InputObjectField); ok {
-						if inputVal.DefaultValue == nil {
-							return nil, nil
-						}
-						astVal := astFromValue(inputVal.DefaultValue, inputVal)
-						return printer.Print(astVal), nil
+						return printDefaultValue(inputVal.DefaultValue, inputVal.defaultLiteral, inputVal.Type), nil
 					}
 					return nil, nil
 				},
@@ -421,9 +409,12 @@ func init() {
 				)),
 				Resolve: func(p ResolveParams) (interface{}, error) {
 					if schema, ok := p.Source.(Schema); ok {
+						visibility := introspectionVisibility(p.Context, p.Info.Schema)
 						results := []Type{}
 						for _, ttype := range schema.TypeMap() {
-							results = append(results, ttype)
+							if visibility.typeVisible(ttype.Name()) {
+								results = append(results, ttype)
+							}
 						}
 						return results, nil
 					}
@@ -446,7 +437,8 @@ func init() {
 				Type: TypeType,
 				Resolve: func(p ResolveParams) (interface{}, error) {
 					if schema, ok := p.Source.(Schema); ok {
-						if schema.MutationType() != nil {
+						if schema.MutationType() != nil &&
+							introspectionVisibility(p.Context, p.Info.Schema).typeVisible(schema.MutationType().Name()) {
 							return schema.MutationType(), nil
 						}
 					}
@@ -459,7 +451,8 @@ func init() {
 				Type: TypeType,
 				Resolve: func(p ResolveParams) (interface{}, error) {
 					if schema, ok := p.Source.(Schema); ok {
-						if schema.SubscriptionType() != nil {
+						if schema.SubscriptionType() != nil &&
+							introspectionVisibility(p.Context, p.Info.Schema).typeVisible(schema.SubscriptionType().Name()) {
 							return schema.SubscriptionType(), nil
 						}
 					}
@@ -528,6 +521,7 @@ func init() {
 		},
 		Resolve: func(p ResolveParams) (interface{}, error) {
 			includeDeprecated, _ := p.Args["includeDeprecated"].(bool)
+			visibility := introspectionVisibility(p.Context, p.Info.Schema)
 			switch ttype := p.Source.(type) {
 			case *Object:
 				if ttype == nil {
@@ -539,6 +533,9 @@ func init() {
 					if !includeDeprecated && field.DeprecationReason != "" {
 						continue
 					}
+					if !visibility.fieldVisible(ttype.Name(), field) {
+						continue
+					}
 					fieldNames = append(fieldNames, name)
 				}
 				sort.Sort(fieldNames)
@@ -555,6 +552,9 @@ func init() {
 					if !includeDeprecated && field.DeprecationReason != "" {
 						continue
 					}
+					if !visibility.fieldVisible(ttype.Name(), field) {
+						continue
+					}
 					fields = append(fields, field)
 				}
 				return fields, nil
@@ -566,7 +566,7 @@ func init() {
 		Type: NewList(NewNonNull(TypeType)),
 		Resolve: func(p ResolveParams) (interface{}, error) {
 			if ttype, ok := p.Source.(*Object); ok {
-				return ttype.Interfaces(), nil
+				return introspectionVisibility(p.Context, p.Info.Schema).filterInterfaces(ttype.Interfaces()), nil
 			}
 			return nil, nil
 		},
@@ -574,11 +574,12 @@ func init() {
 	TypeType.AddFieldConfig("possibleTypes", &Field{
 		Type: NewList(NewNonNull(TypeType)),
 		Resolve: func(p ResolveParams) (interface{}, error) {
+			visibility := introspectionVisibility(p.Context, p.Info.Schema)
 			switch ttype := p.Source.(type) {
 			case *Interface:
-				return p.Info.Schema.PossibleTypes(ttype), nil
+				return visibility.filterObjects(p.Info.Schema.PossibleTypes(ttype)), nil
 			case *Union:
-				return p.Info.Schema.PossibleTypes(ttype), nil
+				return visibility.filterObjects(p.Info.Schema.PossibleTypes(ttype)), nil
 			}
 			return nil, nil
 		},
@@ -656,7 +657,7 @@ func init() {
 		},
 		Resolve: func(p ResolveParams) (interface{}, error) {
 			name, ok := p.Args["name"].(string)
-			if !ok {
+			if !ok || !introspectionVisibility(p.Context, p.Info.Schema).typeVisible(name) {
 				return nil, nil
 			}
 			return p.Info.Schema.Type(name), nil
@@ -732,8 +733,34 @@ func astFromValue(value interface{}, ttype Type) ast.Value {
 		return val
 	}
 
-	if valueVal.Type().Kind() == reflect.Map {
-		// TODO: implement astFromValue from Map to Value
+	// Convert Golang maps to input objects, with the fields in the order of their names.
+	if ttype, ok := ttype.(*InputObject); ok {
+		if object, ok := value.(map[string]interface{}); ok {
+			fieldMap := ttype.Fields()
+			fields := []*ast.ObjectField{}
+			for _, name := range sortedInputFieldNames(fieldMap) {
+				fieldAST := astFromValue(object[name], fieldMap[name].Type)
+				if fieldAST == nil {
+					continue
+				}
+				fields = append(fields, ast.NewObjectField(&ast.ObjectField{
+					Name:  ast.NewName(&ast.Name{Value: name}),
+					Value: fieldAST,
+				}))
+			}
+			return ast.NewObjectValue(&ast.ObjectValue{
+				Fields: fields,
+			})
+		}
+	}
+
+	// Enum values are given by their internal values, and printed by their names.
+	if ttype, ok := ttype.(*Enum); ok {
+		if name, ok := ttype.Serialize(value).(string); ok {
+			return ast.NewEnumValue(&ast.EnumValue{
+				Value: name,
+			})
+		}
 	}
 
 	if value, ok := value.(bool); ok {
@@ -741,14 +768,14 @@ func astFromValue(value interface{}, ttype Type) ast.Value {
 			Value: value,
 		})
 	}
-	if value, ok := value.(int); ok {
-		if ttype == Float {
-			return ast.NewIntValue(&ast.IntValue{
-				Value: fmt.Sprintf("%v.0", value),
-			})
-		}
+	switch valueVal.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return ast.NewIntValue(&ast.IntValue{
-			Value: fmt.Sprintf("%v", value),
+			Value: fmt.Sprintf("%v", valueVal.Int()),
+		})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ast.NewIntValue(&ast.IntValue{
+			Value: fmt.Sprintf("%v", valueVal.Uint()),
 		})
 	}
 	if value, ok := value.(float32); ok {
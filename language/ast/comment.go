@@ -0,0 +1,33 @@
+package ast
+
+// Comment is a comment of the source, kept in the AST when parsing with the KeepComments option.
+// Value is its text after the "#".
+type Comment struct {
+	Loc   *Location
+	Value string
+}
+
+// CommentedNode is a node which can hold the comments leading it in the source.
+type CommentedNode interface {
+	Node
+	GetComments() []*Comment
+}
+
+// Ensure that all node types holding comments implements CommentedNode interface
+var _ CommentedNode = (*OperationDefinition)(nil)
+var _ CommentedNode = (*FragmentDefinition)(nil)
+var _ CommentedNode = (*TypeExtensionDefinition)(nil)
+var _ CommentedNode = (*DirectiveDefinition)(nil)
+var _ CommentedNode = (*Field)(nil)
+var _ CommentedNode = (*FragmentSpread)(nil)
+var _ CommentedNode = (*InlineFragment)(nil)
+var _ CommentedNode = (*SchemaDefinition)(nil)
+var _ CommentedNode = (*ScalarDefinition)(nil)
+var _ CommentedNode = (*ObjectDefinition)(nil)
+var _ CommentedNode = (*FieldDefinition)(nil)
+var _ CommentedNode = (*InputValueDefinition)(nil)
+var _ CommentedNode = (*InterfaceDefinition)(nil)
+var _ CommentedNode = (*UnionDefinition)(nil)
+var _ CommentedNode = (*EnumDefinition)(nil)
+var _ CommentedNode = (*EnumValueDefinition)(nil)
+var _ CommentedNode = (*InputObjectDefinition)(nil)
@@ -31,6 +31,7 @@ const (
 type OperationDefinition struct {
 	Kind                string
 	Loc                 *Location
+	Comments            []*Comment
 	Operation           string
 	Name                *Name
 	VariableDefinitions []*VariableDefinition
@@ -54,6 +55,10 @@ func (op *OperationDefinition) GetLoc() *Location {
 	return op.Loc
 }
 
+func (op *OperationDefinition) GetComments() []*Comment {
+	return op.Comments
+}
+
 func (op *OperationDefinition) GetOperation() string {
 	return op.Operation
 }
@@ -78,6 +83,7 @@ func (op *OperationDefinition) GetSelectionSet() *SelectionSet {
 type FragmentDefinition struct {
 	Kind                string
 	Loc                 *Location
+	Comments            []*Comment
 	Operation           string
 	Name                *Name
 	VariableDefinitions []*VariableDefinition
@@ -93,6 +99,7 @@ func NewFragmentDefinition(fd *FragmentDefinition) *FragmentDefinition {
 	return &FragmentDefinition{
 		Kind:                kinds.FragmentDefinition,
 		Loc:                 fd.Loc,
+		Comments:            fd.Comments,
 		Operation:           fd.Operation,
 		Name:                fd.Name,
 		VariableDefinitions: fd.VariableDefinitions,
@@ -110,6 +117,10 @@ func (fd *FragmentDefinition) GetLoc() *Location {
 	return fd.Loc
 }
 
+func (fd *FragmentDefinition) GetComments() []*Comment {
+	return fd.Comments
+}
+
 func (fd *FragmentDefinition) GetOperation() string {
 	return fd.Operation
 }
@@ -155,6 +166,7 @@ func (vd *VariableDefinition) GetLoc() *Location {
 type TypeExtensionDefinition struct {
 	Kind       string
 	Loc        *Location
+	Comments   []*Comment
 	Definition *ObjectDefinition
 }
 
@@ -165,6 +177,7 @@ func NewTypeExtensionDefinition(def *TypeExtensionDefinition) *TypeExtensionDefi
 	return &TypeExtensionDefinition{
 		Kind:       kinds.TypeExtensionDefinition,
 		Loc:        def.Loc,
+		Comments:   def.Comments,
 		Definition: def.Definition,
 	}
 }
@@ -177,6 +190,10 @@ func (def *TypeExtensionDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *TypeExtensionDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *TypeExtensionDefinition) GetVariableDefinitions() []*VariableDefinition {
 	return []*VariableDefinition{}
 }
@@ -193,6 +210,7 @@ func (def *TypeExtensionDefinition) GetOperation() string {
 type DirectiveDefinition struct {
 	Kind        string
 	Loc         *Location
+	Comments    []*Comment
 	Name        *Name
 	Description *StringValue
 	Arguments   []*InputValueDefinition
@@ -206,6 +224,7 @@ func NewDirectiveDefinition(def *DirectiveDefinition) *DirectiveDefinition {
 	return &DirectiveDefinition{
 		Kind:        kinds.DirectiveDefinition,
 		Loc:         def.Loc,
+		Comments:    def.Comments,
 		Name:        def.Name,
 		Description: def.Description,
 		Arguments:   def.Arguments,
@@ -221,6 +240,10 @@ func (def *DirectiveDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *DirectiveDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *DirectiveDefinition) GetVariableDefinitions() []*VariableDefinition {
 	return []*VariableDefinition{}
 }
@@ -24,6 +24,7 @@ var (
 	_ Node = (*FloatValue)(nil)
 	_ Node = (*StringValue)(nil)
 	_ Node = (*BooleanValue)(nil)
+	_ Node = (*NullValue)(nil)
 	_ Node = (*EnumValue)(nil)
 	_ Node = (*ListValue)(nil)
 	_ Node = (*ObjectValue)(nil)
@@ -20,6 +20,7 @@ var (
 type Field struct {
 	Kind         string
 	Loc          *Location
+	Comments     []*Comment
 	Alias        *Name
 	Name         *Name
 	Arguments    []*Argument
@@ -43,6 +44,10 @@ func (f *Field) GetLoc() *Location {
 	return f.Loc
 }
 
+func (f *Field) GetComments() []*Comment {
+	return f.Comments
+}
+
 func (f *Field) GetSelectionSet() *SelectionSet {
 	return f.SelectionSet
 }
@@ -51,6 +56,7 @@ func (f *Field) GetSelectionSet() *SelectionSet {
 type FragmentSpread struct {
 	Kind       string
 	Loc        *Location
+	Comments   []*Comment
 	Name       *Name
 	Directives []*Directive
 }
@@ -62,6 +68,7 @@ func NewFragmentSpread(fs *FragmentSpread) *FragmentSpread {
 	return &FragmentSpread{
 		Kind:       kinds.FragmentSpread,
 		Loc:        fs.Loc,
+		Comments:   fs.Comments,
 		Name:       fs.Name,
 		Directives: fs.Directives,
 	}
@@ -75,6 +82,10 @@ func (fs *FragmentSpread) GetLoc() *Location {
 	return fs.Loc
 }
 
+func (fs *FragmentSpread) GetComments() []*Comment {
+	return fs.Comments
+}
+
 func (fs *FragmentSpread) GetSelectionSet() *SelectionSet {
 	return nil
 }
@@ -83,6 +94,7 @@ func (fs *FragmentSpread) GetSelectionSet() *SelectionSet {
 type InlineFragment struct {
 	Kind          string
 	Loc           *Location
+	Comments      []*Comment
 	TypeCondition *Named
 	Directives    []*Directive
 	SelectionSet  *SelectionSet
@@ -95,6 +107,7 @@ func NewInlineFragment(f *InlineFragment) *InlineFragment {
 	return &InlineFragment{
 		Kind:          kinds.InlineFragment,
 		Loc:           f.Loc,
+		Comments:      f.Comments,
 		TypeCondition: f.TypeCondition,
 		Directives:    f.Directives,
 		SelectionSet:  f.SelectionSet,
@@ -109,6 +122,10 @@ func (f *InlineFragment) GetLoc() *Location {
 	return f.Loc
 }
 
+func (f *InlineFragment) GetComments() []*Comment {
+	return f.Comments
+}
+
 func (f *InlineFragment) GetSelectionSet() *SelectionSet {
 	return f.SelectionSet
 }
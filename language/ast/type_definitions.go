@@ -46,6 +46,7 @@ var (
 type SchemaDefinition struct {
 	Kind           string
 	Loc            *Location
+	Comments       []*Comment
 	Directives     []*Directive
 	OperationTypes []*OperationTypeDefinition
 }
@@ -57,6 +58,7 @@ func NewSchemaDefinition(def *SchemaDefinition) *SchemaDefinition {
 	return &SchemaDefinition{
 		Kind:           kinds.SchemaDefinition,
 		Loc:            def.Loc,
+		Comments:       def.Comments,
 		Directives:     def.Directives,
 		OperationTypes: def.OperationTypes,
 	}
@@ -70,6 +72,10 @@ func (def *SchemaDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *SchemaDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *SchemaDefinition) GetVariableDefinitions() []*VariableDefinition {
 	return []*VariableDefinition{}
 }
@@ -114,6 +120,7 @@ func (def *OperationTypeDefinition) GetLoc() *Location {
 type ScalarDefinition struct {
 	Kind        string
 	Loc         *Location
+	Comments    []*Comment
 	Description *StringValue
 	Name        *Name
 	Directives  []*Directive
@@ -126,6 +133,7 @@ func NewScalarDefinition(def *ScalarDefinition) *ScalarDefinition {
 	return &ScalarDefinition{
 		Kind:        kinds.ScalarDefinition,
 		Loc:         def.Loc,
+		Comments:    def.Comments,
 		Description: def.Description,
 		Name:        def.Name,
 		Directives:  def.Directives,
@@ -140,6 +148,10 @@ func (def *ScalarDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *ScalarDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *ScalarDefinition) GetName() *Name {
 	return def.Name
 }
@@ -164,6 +176,7 @@ func (def *ScalarDefinition) GetDescription() *StringValue {
 type ObjectDefinition struct {
 	Kind        string
 	Loc         *Location
+	Comments    []*Comment
 	Name        *Name
 	Description *StringValue
 	Interfaces  []*Named
@@ -178,6 +191,7 @@ func NewObjectDefinition(def *ObjectDefinition) *ObjectDefinition {
 	return &ObjectDefinition{
 		Kind:        kinds.ObjectDefinition,
 		Loc:         def.Loc,
+		Comments:    def.Comments,
 		Name:        def.Name,
 		Description: def.Description,
 		Interfaces:  def.Interfaces,
@@ -194,6 +208,10 @@ func (def *ObjectDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *ObjectDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *ObjectDefinition) GetName() *Name {
 	return def.Name
 }
@@ -218,6 +236,7 @@ func (def *ObjectDefinition) GetDescription() *StringValue {
 type FieldDefinition struct {
 	Kind        string
 	Loc         *Location
+	Comments    []*Comment
 	Name        *Name
 	Description *StringValue
 	Arguments   []*InputValueDefinition
@@ -232,6 +251,7 @@ func NewFieldDefinition(def *FieldDefinition) *FieldDefinition {
 	return &FieldDefinition{
 		Kind:        kinds.FieldDefinition,
 		Loc:         def.Loc,
+		Comments:    def.Comments,
 		Name:        def.Name,
 		Description: def.Description,
 		Arguments:   def.Arguments,
@@ -248,6 +268,10 @@ func (def *FieldDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *FieldDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *FieldDefinition) GetDescription() *StringValue {
 	return def.Description
 }
@@ -256,6 +280,7 @@ func (def *FieldDefinition) GetDescription() *StringValue {
 type InputValueDefinition struct {
 	Kind         string
 	Loc          *Location
+	Comments     []*Comment
 	Name         *Name
 	Description  *StringValue
 	Type         Type
@@ -270,6 +295,7 @@ func NewInputValueDefinition(def *InputValueDefinition) *InputValueDefinition {
 	return &InputValueDefinition{
 		Kind:         kinds.InputValueDefinition,
 		Loc:          def.Loc,
+		Comments:     def.Comments,
 		Name:         def.Name,
 		Description:  def.Description,
 		Type:         def.Type,
@@ -286,6 +312,10 @@ func (def *InputValueDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *InputValueDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *InputValueDefinition) GetDescription() *StringValue {
 	return def.Description
 }
@@ -294,6 +324,7 @@ func (def *InputValueDefinition) GetDescription() *StringValue {
 type InterfaceDefinition struct {
 	Kind        string
 	Loc         *Location
+	Comments    []*Comment
 	Name        *Name
 	Description *StringValue
 	Directives  []*Directive
@@ -307,6 +338,7 @@ func NewInterfaceDefinition(def *InterfaceDefinition) *InterfaceDefinition {
 	return &InterfaceDefinition{
 		Kind:        kinds.InterfaceDefinition,
 		Loc:         def.Loc,
+		Comments:    def.Comments,
 		Name:        def.Name,
 		Description: def.Description,
 		Directives:  def.Directives,
@@ -322,6 +354,10 @@ func (def *InterfaceDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *InterfaceDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *InterfaceDefinition) GetName() *Name {
 	return def.Name
 }
@@ -346,6 +382,7 @@ func (def *InterfaceDefinition) GetDescription() *StringValue {
 type UnionDefinition struct {
 	Kind        string
 	Loc         *Location
+	Comments    []*Comment
 	Name        *Name
 	Description *StringValue
 	Directives  []*Directive
@@ -359,6 +396,7 @@ func NewUnionDefinition(def *UnionDefinition) *UnionDefinition {
 	return &UnionDefinition{
 		Kind:        kinds.UnionDefinition,
 		Loc:         def.Loc,
+		Comments:    def.Comments,
 		Name:        def.Name,
 		Description: def.Description,
 		Directives:  def.Directives,
@@ -374,6 +412,10 @@ func (def *UnionDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *UnionDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *UnionDefinition) GetName() *Name {
 	return def.Name
 }
@@ -398,6 +440,7 @@ func (def *UnionDefinition) GetDescription() *StringValue {
 type EnumDefinition struct {
 	Kind        string
 	Loc         *Location
+	Comments    []*Comment
 	Name        *Name
 	Description *StringValue
 	Directives  []*Directive
@@ -411,6 +454,7 @@ func NewEnumDefinition(def *EnumDefinition) *EnumDefinition {
 	return &EnumDefinition{
 		Kind:        kinds.EnumDefinition,
 		Loc:         def.Loc,
+		Comments:    def.Comments,
 		Name:        def.Name,
 		Description: def.Description,
 		Directives:  def.Directives,
@@ -426,6 +470,10 @@ func (def *EnumDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *EnumDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *EnumDefinition) GetName() *Name {
 	return def.Name
 }
@@ -450,6 +498,7 @@ func (def *EnumDefinition) GetDescription() *StringValue {
 type EnumValueDefinition struct {
 	Kind        string
 	Loc         *Location
+	Comments    []*Comment
 	Name        *Name
 	Description *StringValue
 	Directives  []*Directive
@@ -462,6 +511,7 @@ func NewEnumValueDefinition(def *EnumValueDefinition) *EnumValueDefinition {
 	return &EnumValueDefinition{
 		Kind:        kinds.EnumValueDefinition,
 		Loc:         def.Loc,
+		Comments:    def.Comments,
 		Name:        def.Name,
 		Description: def.Description,
 		Directives:  def.Directives,
@@ -476,6 +526,10 @@ func (def *EnumValueDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *EnumValueDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *EnumValueDefinition) GetDescription() *StringValue {
 	return def.Description
 }
@@ -484,6 +538,7 @@ func (def *EnumValueDefinition) GetDescription() *StringValue {
 type InputObjectDefinition struct {
 	Kind        string
 	Loc         *Location
+	Comments    []*Comment
 	Name        *Name
 	Description *StringValue
 	Directives  []*Directive
@@ -497,6 +552,7 @@ func NewInputObjectDefinition(def *InputObjectDefinition) *InputObjectDefinition
 	return &InputObjectDefinition{
 		Kind:        kinds.InputObjectDefinition,
 		Loc:         def.Loc,
+		Comments:    def.Comments,
 		Name:        def.Name,
 		Description: def.Description,
 		Directives:  def.Directives,
@@ -512,6 +568,10 @@ func (def *InputObjectDefinition) GetLoc() *Location {
 	return def.Loc
 }
 
+func (def *InputObjectDefinition) GetComments() []*Comment {
+	return def.Comments
+}
+
 func (def *InputObjectDefinition) GetName() *Name {
 	return def.Name
 }
@@ -18,6 +18,7 @@ var (
 	_ Value = (*FloatValue)(nil)
 	_ Value = (*StringValue)(nil)
 	_ Value = (*BooleanValue)(nil)
+	_ Value = (*NullValue)(nil)
 	_ Value = (*EnumValue)(nil)
 	_ Value = (*ListValue)(nil)
 	_ Value = (*ObjectValue)(nil)
@@ -120,6 +121,9 @@ type StringValue struct {
 	Kind  string
 	Loc   *Location
 	Value string
+
+	// Block tells if the string was written as a block string, between triple quotes.
+	Block bool
 }
 
 func NewStringValue(v *StringValue) *StringValue {
@@ -130,6 +134,7 @@ func NewStringValue(v *StringValue) *StringValue {
 		Kind:  kinds.StringValue,
 		Loc:   v.Loc,
 		Value: v.Value,
+		Block: v.Block,
 	}
 }
 
@@ -175,6 +180,34 @@ func (v *BooleanValue) GetValue() interface{} {
 	return v.Value
 }
 
+// NullValue implements Node, Value
+type NullValue struct {
+	Kind string
+	Loc  *Location
+}
+
+func NewNullValue(v *NullValue) *NullValue {
+	if v == nil {
+		v = &NullValue{}
+	}
+	return &NullValue{
+		Kind: kinds.NullValue,
+		Loc:  v.Loc,
+	}
+}
+
+func (v *NullValue) GetKind() string {
+	return v.Kind
+}
+
+func (v *NullValue) GetLoc() *Location {
+	return v.Loc
+}
+
+func (v *NullValue) GetValue() interface{} {
+	return nil
+}
+
 // EnumValue implements Node, Value
 type EnumValue struct {
 	Kind  string
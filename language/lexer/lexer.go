@@ -1,9 +1,7 @@
 package lexer
 
 import (
-	"bytes"
 	"fmt"
-	"regexp"
 	"strings"
 	"unicode/utf8"
 
@@ -34,6 +32,7 @@ const (
 	STRING
 	BLOCK_STRING
 	AMP
+	COMMENT
 )
 
 var tokenDescription = map[TokenKind]string{
@@ -57,6 +56,7 @@ var tokenDescription = map[TokenKind]string{
 	STRING:       "String",
 	BLOCK_STRING: "BlockString",
 	AMP:          "&",
+	COMMENT:      "Comment",
 }
 
 func (kind TokenKind) String() string {
@@ -89,15 +89,25 @@ type Token struct {
 	Value string
 }
 
+// Lexer returns the next token of its source, read from resetPosition, or from the end of the
+// previous token if resetPosition is 0.
 type Lexer func(resetPosition int) (Token, error)
 
+// Lex returns a Lexer reading the tokens of s. The tokens are read from the bytes of the source
+// without copying them, except for their values, and their Start and End are byte offsets in
+// s.Body.
 func Lex(s *source.Source) Lexer {
+	l := &lexer{
+		source: s,
+		body:   s.Body,
+		names:  map[string]string{},
+	}
 	var prevPosition int
 	return func(resetPosition int) (Token, error) {
 		if resetPosition == 0 {
 			resetPosition = prevPosition
 		}
-		token, err := readToken(s, resetPosition)
+		token, err := l.readToken(resetPosition)
 		if err != nil {
 			return token, err
 		}
@@ -106,80 +116,158 @@ func Lex(s *source.Source) Lexer {
 	}
 }
 
+// lexer holds the state kept between the tokens read from a source.
+type lexer struct {
+	source *source.Source
+	body   []byte
+	// names interns the values of the names, which are repeated a lot in large documents, so each
+	// of them is only allocated once.
+	names map[string]string
+	// buf is reused to build the values of the strings having escape sequences.
+	buf []byte
+}
+
+func (l *lexer) readToken(fromPosition int) (Token, error) {
+	body := l.body
+	position := positionAfterWhitespace(body, fromPosition)
+	if position >= len(body) {
+		return makeToken(EOF, position, position, ""), nil
+	}
+	c := body[position]
+
+	// SourceCharacter
+	if c < 0x0020 && c != 0x0009 && c != 0x000A && c != 0x000D {
+		return Token{}, gqlerrors.NewSyntaxError(l.source, position, fmt.Sprintf(`Invalid character %v`, printCharCode(rune(c))))
+	}
+
+	switch c {
+	// !
+	case '!':
+		return makeToken(BANG, position, position+1, ""), nil
+	// $
+	case '$':
+		return makeToken(DOLLAR, position, position+1, ""), nil
+	// &
+	case '&':
+		return makeToken(AMP, position, position+1, ""), nil
+	// (
+	case '(':
+		return makeToken(PAREN_L, position, position+1, ""), nil
+	// )
+	case ')':
+		return makeToken(PAREN_R, position, position+1, ""), nil
+	// .
+	case '.':
+		if byteAt(body, position+1) == '.' && byteAt(body, position+2) == '.' {
+			return makeToken(SPREAD, position, position+3, ""), nil
+		}
+	// :
+	case ':':
+		return makeToken(COLON, position, position+1, ""), nil
+	// =
+	case '=':
+		return makeToken(EQUALS, position, position+1, ""), nil
+	// @
+	case '@':
+		return makeToken(AT, position, position+1, ""), nil
+	// [
+	case '[':
+		return makeToken(BRACKET_L, position, position+1, ""), nil
+	// ]
+	case ']':
+		return makeToken(BRACKET_R, position, position+1, ""), nil
+	// {
+	case '{':
+		return makeToken(BRACE_L, position, position+1, ""), nil
+	// |
+	case '|':
+		return makeToken(PIPE, position, position+1, ""), nil
+	// }
+	case '}':
+		return makeToken(BRACE_R, position, position+1, ""), nil
+	// -
+	// 0-9
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return l.readNumber(position)
+	// "
+	case '"':
+		if byteAt(body, position+1) == '"' && byteAt(body, position+2) == '"' {
+			return l.readBlockString(position)
+		}
+		return l.readString(position)
+	default:
+		// A-Z _ a-z
+		if isNameStart(c) {
+			return l.readName(position), nil
+		}
+	}
+	code, _ := runeAt(body, position)
+	description := fmt.Sprintf("Unexpected character %v.", printCharCode(code))
+	return Token{}, gqlerrors.NewSyntaxError(l.source, position, description)
+}
+
 // Reads an alphanumeric + underscore name from the source.
 // [_A-Za-z][_0-9A-Za-z]*
-// position: Points to the byte position in the byte array
-// runePosition: Points to the rune position in the byte array
-func readName(source *source.Source, position, runePosition int) Token {
-	body := source.Body
-	bodyLength := len(body)
-	endByte := position + 1
-	endRune := runePosition + 1
-	for {
-		code, _ := runeAt(body, endByte)
-		if (endByte != bodyLength) &&
-			(code == '_' || // _
-				code >= '0' && code <= '9' || // 0-9
-				code >= 'A' && code <= 'Z' || // A-Z
-				code >= 'a' && code <= 'z') { // a-z
-			endByte++
-			endRune++
-			continue
-		} else {
-			break
-		}
+func (l *lexer) readName(start int) Token {
+	body := l.body
+	end := start + 1
+	for end < len(body) && (isNameStart(body[end]) || isDigit(body[end])) {
+		end++
+	}
+	return makeToken(NAME, start, end, l.intern(body[start:end]))
+}
+
+// intern returns the name as a string, allocated the first time it's read by the lexer only.
+func (l *lexer) intern(name []byte) string {
+	// the conversion in the map index doesn't allocate
+	if value, ok := l.names[string(name)]; ok {
+		return value
 	}
-	return makeToken(NAME, runePosition, endRune, string(body[position:endByte]))
+	value := string(name)
+	l.names[value] = value
+	return value
 }
 
 // Reads a number token from the source file, either a float
 // or an int depending on whether a decimal point appears.
 // Int:   -?(0|[1-9][0-9]*)
 // Float: -?(0|[1-9][0-9]*)(\.[0-9]+)?((E|e)(+|-)?[0-9]+)?
-func readNumber(s *source.Source, start int, firstCode rune, codeLength int) (Token, error) {
-	code := firstCode
-	body := s.Body
+func (l *lexer) readNumber(start int) (Token, error) {
+	body := l.body
 	position := start
 	isFloat := false
-	if code == '-' { // -
-		position += codeLength
-		code, codeLength = runeAt(body, position)
-	}
-	if code == '0' { // 0
-		position += codeLength
-		code, codeLength = runeAt(body, position)
-		if code >= '0' && code <= '9' {
+	if byteAt(body, position) == '-' { // -
+		position++
+	}
+	if byteAt(body, position) == '0' { // 0
+		position++
+		if isDigit(byteAt(body, position)) {
+			code, _ := runeAt(body, position)
 			description := fmt.Sprintf("Invalid number, unexpected digit after 0: %v.", printCharCode(code))
-			return Token{}, gqlerrors.NewSyntaxError(s, position, description)
+			return Token{}, gqlerrors.NewSyntaxError(l.source, position, description)
 		}
 	} else {
-		p, err := readDigits(s, position, code, codeLength)
+		p, err := l.readDigits(position)
 		if err != nil {
 			return Token{}, err
 		}
 		position = p
-		code, codeLength = runeAt(body, position)
 	}
-	if code == '.' { // .
+	if byteAt(body, position) == '.' { // .
 		isFloat = true
-		position += codeLength
-		code, codeLength = runeAt(body, position)
-		p, err := readDigits(s, position, code, codeLength)
+		p, err := l.readDigits(position + 1)
 		if err != nil {
 			return Token{}, err
 		}
 		position = p
-		code, codeLength = runeAt(body, position)
 	}
-	if code == 'E' || code == 'e' { // E e
+	if c := byteAt(body, position); c == 'E' || c == 'e' { // E e
 		isFloat = true
-		position += codeLength
-		code, codeLength = runeAt(body, position)
-		if code == '+' || code == '-' { // + -
-			position += codeLength
-			code, codeLength = runeAt(body, position)
+		position++
+		if c := byteAt(body, position); c == '+' || c == '-' { // + -
+			position++
 		}
-		p, err := readDigits(s, position, code, codeLength)
+		p, err := l.readDigits(position)
 		if err != nil {
 			return Token{}, err
 		}
@@ -194,183 +282,146 @@ func readNumber(s *source.Source, start int, firstCode rune, codeLength int) (To
 }
 
 // Returns the new position in the source after reading digits.
-func readDigits(s *source.Source, start int, firstCode rune, codeLength int) (int, error) {
-	body := s.Body
+func (l *lexer) readDigits(start int) (int, error) {
+	body := l.body
 	position := start
-	code := firstCode
-	if code >= '0' && code <= '9' { // 0 - 9
-		for {
-			if code >= '0' && code <= '9' { // 0 - 9
-				position += codeLength
-				code, codeLength = runeAt(body, position)
-				continue
-			} else {
-				break
-			}
-		}
-		return position, nil
+	if !isDigit(byteAt(body, position)) {
+		code, _ := runeAt(body, position)
+		description := fmt.Sprintf("Invalid number, expected digit but got: %v.", printCharCode(code))
+		return position, gqlerrors.NewSyntaxError(l.source, position, description)
 	}
-	var description string
-	description = fmt.Sprintf("Invalid number, expected digit but got: %v.", printCharCode(code))
-	return position, gqlerrors.NewSyntaxError(s, position, description)
+	for isDigit(byteAt(body, position)) {
+		position++
+	}
+	return position, nil
 }
 
-func readString(s *source.Source, start int) (Token, error) {
-	body := s.Body
+func (l *lexer) readString(start int) (Token, error) {
+	body := l.body
 	position := start + 1
-	runePosition := start + 1
 	chunkStart := position
-	var code rune
-	var n int
-	var valueBuffer bytes.Buffer
-	for {
-		code, n = runeAt(body, position)
-		if position < len(body) &&
-			// not LineTerminator
-			code != 0x000A && code != 0x000D &&
-			// not Quote (")
-			code != '"' {
-
-			// SourceCharacter
-			if code < 0x0020 && code != 0x0009 {
-				return Token{}, gqlerrors.NewSyntaxError(s, runePosition, fmt.Sprintf(`Invalid character within String: %v.`, printCharCode(code)))
+	escaped := false
+	for position < len(body) {
+		c := body[position]
+		// LineTerminator or Quote (")
+		if c == 0x000A || c == 0x000D || c == '"' {
+			break
+		}
+
+		// SourceCharacter
+		if c < 0x0020 && c != 0x0009 {
+			return Token{}, gqlerrors.NewSyntaxError(l.source, position, fmt.Sprintf(`Invalid character within String: %v.`, printCharCode(rune(c))))
+		}
+		position++
+		if c != '\\' { // \
+			continue
+		}
+
+		if !escaped {
+			escaped = true
+			l.buf = l.buf[:0]
+		}
+		l.buf = append(l.buf, body[chunkStart:position-1]...)
+		code, n := runeAt(body, position)
+		switch code {
+		case '"':
+			l.buf = append(l.buf, '"')
+		case '/':
+			l.buf = append(l.buf, '/')
+		case '\\':
+			l.buf = append(l.buf, '\\')
+		case 'b':
+			l.buf = append(l.buf, '\b')
+		case 'f':
+			l.buf = append(l.buf, '\f')
+		case 'n':
+			l.buf = append(l.buf, '\n')
+		case 'r':
+			l.buf = append(l.buf, '\r')
+		case 't':
+			l.buf = append(l.buf, '\t')
+		case 'u':
+			// Check if there are at least 4 bytes available
+			if len(body) <= position+4 {
+				return Token{}, gqlerrors.NewSyntaxError(l.source, position,
+					fmt.Sprintf("Invalid character escape sequence: "+
+						"\\u%v", string(body[position+1:])))
 			}
-			position += n
-			runePosition++
-			if code == '\\' { // \
-				valueBuffer.Write(body[chunkStart : position-1])
-				code, n = runeAt(body, position)
-				switch code {
-				case '"':
-					valueBuffer.WriteRune('"')
-					break
-				case '/':
-					valueBuffer.WriteRune('/')
-					break
-				case '\\':
-					valueBuffer.WriteRune('\\')
-					break
-				case 'b':
-					valueBuffer.WriteRune('\b')
-					break
-				case 'f':
-					valueBuffer.WriteRune('\f')
-					break
-				case 'n':
-					valueBuffer.WriteRune('\n')
-					break
-				case 'r':
-					valueBuffer.WriteRune('\r')
-					break
-				case 't':
-					valueBuffer.WriteRune('\t')
-					break
-				case 'u':
-					// Check if there are at least 4 bytes available
-					if len(body) <= position+4 {
-						return Token{}, gqlerrors.NewSyntaxError(s, runePosition,
-							fmt.Sprintf("Invalid character escape sequence: "+
-								"\\u%v", string(body[position+1:])))
-					}
-					charCode := uniCharCode(
-						rune(body[position+1]),
-						rune(body[position+2]),
-						rune(body[position+3]),
-						rune(body[position+4]),
-					)
-					if charCode < 0 {
-						return Token{}, gqlerrors.NewSyntaxError(s, runePosition,
-							fmt.Sprintf("Invalid character escape sequence: "+
-								"\\u%v", string(body[position+1:position+5])))
-					}
-					valueBuffer.WriteRune(charCode)
-					position += 4
-					runePosition += 4
-					break
-				default:
-					return Token{}, gqlerrors.NewSyntaxError(s, runePosition,
-						fmt.Sprintf(`Invalid character escape sequence: \\%c.`, code))
-				}
-				position += n
-				runePosition++
-				chunkStart = position
+			charCode := uniCharCode(
+				rune(body[position+1]),
+				rune(body[position+2]),
+				rune(body[position+3]),
+				rune(body[position+4]),
+			)
+			if charCode < 0 {
+				return Token{}, gqlerrors.NewSyntaxError(l.source, position,
+					fmt.Sprintf("Invalid character escape sequence: "+
+						"\\u%v", string(body[position+1:position+5])))
 			}
-			continue
-		} else {
-			break
+			l.buf = utf8.AppendRune(l.buf, charCode)
+			position += 4
+		default:
+			return Token{}, gqlerrors.NewSyntaxError(l.source, position,
+				fmt.Sprintf(`Invalid character escape sequence: \\%c.`, code))
 		}
+		position += n
+		chunkStart = position
+	}
+	if byteAt(body, position) != '"' { // quote (")
+		return Token{}, gqlerrors.NewSyntaxError(l.source, position, "Unterminated string.")
 	}
-	if code != '"' { // quote (")
-		return Token{}, gqlerrors.NewSyntaxError(s, runePosition, "Unterminated string.")
+	if !escaped {
+		return makeToken(STRING, start, position+1, string(body[chunkStart:position])), nil
 	}
-	stringContent := body[chunkStart:position]
-	valueBuffer.Write(stringContent)
-	value := valueBuffer.String()
-	return makeToken(STRING, start, position+1, value), nil
+	l.buf = append(l.buf, body[chunkStart:position]...)
+	return makeToken(STRING, start, position+1, string(l.buf)), nil
 }
 
 // readBlockString reads a block string token from the source file.
 //
 // """("?"?(\\"""|\\(?!=""")|[^"\\]))*"""
-func readBlockString(s *source.Source, start int) (Token, error) {
-	body := s.Body
+func (l *lexer) readBlockString(start int) (Token, error) {
+	body := l.body
 	position := start + 3
-	runePosition := start + 3
 	chunkStart := position
-	var valueBuffer bytes.Buffer
+	l.buf = l.buf[:0]
 
-	for {
-		// Stop if we've reached the end of the buffer
-		if position >= len(body) {
-			break
-		}
-
-		code, n := runeAt(body, position)
+	for position < len(body) {
+		c := body[position]
 
 		// Closing Triple-Quote (""")
-		if code == '"' {
-			x, _ := runeAt(body, position+1)
-			y, _ := runeAt(body, position+2)
-			if x == '"' && y == '"' {
-				stringContent := body[chunkStart:position]
-				valueBuffer.Write(stringContent)
-				value := blockStringValue(valueBuffer.String())
-				return makeToken(BLOCK_STRING, start, position+3, value), nil
-			}
+		if c == '"' && byteAt(body, position+1) == '"' && byteAt(body, position+2) == '"' {
+			l.buf = append(l.buf, body[chunkStart:position]...)
+			value := blockStringValue(string(l.buf))
+			return makeToken(BLOCK_STRING, start, position+3, value), nil
 		}
 
 		// SourceCharacter
-		if code < 0x0020 &&
-			code != 0x0009 &&
-			code != 0x000a &&
-			code != 0x000d {
-			return Token{}, gqlerrors.NewSyntaxError(s, runePosition, fmt.Sprintf(`Invalid character within String: %v.`, printCharCode(code)))
+		if c < 0x0020 &&
+			c != 0x0009 &&
+			c != 0x000a &&
+			c != 0x000d {
+			return Token{}, gqlerrors.NewSyntaxError(l.source, position, fmt.Sprintf(`Invalid character within String: %v.`, printCharCode(rune(c))))
 		}
 
 		// Escape Triple-Quote (\""")
-		if code == '\\' { // \
-			x, _ := runeAt(body, position+1)
-			y, _ := runeAt(body, position+2)
-			z, _ := runeAt(body, position+3)
-			if x == '"' && y == '"' && z == '"' {
-				stringContent := append(body[chunkStart:position], []byte(`"""`)...)
-				valueBuffer.Write(stringContent)
-				position += 4     // account for `"""` characters
-				runePosition += 4 // "       "   "     "
-				chunkStart = position
-				continue
-			}
+		if c == '\\' && // \
+			byteAt(body, position+1) == '"' &&
+			byteAt(body, position+2) == '"' &&
+			byteAt(body, position+3) == '"' {
+			l.buf = append(l.buf, body[chunkStart:position]...)
+			l.buf = append(l.buf, `"""`...)
+			position += 4 // account for `"""` characters
+			chunkStart = position
+			continue
 		}
 
-		position += n
-		runePosition++
+		position++
 	}
 
-	return Token{}, gqlerrors.NewSyntaxError(s, runePosition, "Unterminated string.")
+	return Token{}, gqlerrors.NewSyntaxError(l.source, position, "Unterminated string.")
 }
 
-var splitLinesRegex = regexp.MustCompile("\r\n|[\n\r]")
-
 // This implements the GraphQL spec's BlockStringValue() static algorithm.
 //
 // Produces the value of a block string from its parsed raw value, similar to
@@ -380,7 +431,7 @@ var splitLinesRegex = regexp.MustCompile("\r\n|[\n\r]")
 // Heavily borrows from: https://github.com/graphql/graphql-js/blob/8e0c599ceccfa8c40d6edf3b72ee2a71490b10e0/src/language/blockStringValue.js
 func blockStringValue(in string) string {
 	// Expand a block string's raw value into independent lines.
-	lines := splitLinesRegex.Split(in, -1)
+	lines := splitLines(in)
 
 	// Remove common indentation from all lines but first
 	commonIndent := -1
@@ -395,11 +446,13 @@ func blockStringValue(in string) string {
 		}
 	}
 	if commonIndent > 0 {
-		for i, line := range lines {
-			if commonIndent > len(line) {
+		for i := 1; i < len(lines); i++ {
+			if commonIndent > len(lines[i]) {
+				// a blank line shorter than the indentation
+				lines[i] = ""
 				continue
 			}
-			lines[i] = line[commonIndent:]
+			lines[i] = lines[i][commonIndent:]
 		}
 	}
 
@@ -410,22 +463,37 @@ func blockStringValue(in string) string {
 
 	// Remove trailing blank lines.
 	for len(lines) > 0 && lineIsBlank(lines[len(lines)-1]) {
-		i := len(lines) - 1
-		lines = append(lines[:i], lines[i+1:]...)
+		lines = lines[:len(lines)-1]
 	}
 
 	// Return a string of the lines joined with U+000A.
 	return strings.Join(lines, "\n")
 }
 
+// splitLines splits in at its line terminators: "\r\n", "\n" and "\r".
+func splitLines(in string) []string {
+	lines := make([]string, 0, strings.Count(in, "\n")+1)
+	lineStart := 0
+	for i := 0; i < len(in); i++ {
+		switch in[i] {
+		case '\r':
+			lines = append(lines, in[lineStart:i])
+			if i+1 < len(in) && in[i+1] == '\n' {
+				i++
+			}
+			lineStart = i + 1
+		case '\n':
+			lines = append(lines, in[lineStart:i])
+			lineStart = i + 1
+		}
+	}
+	return append(lines, in[lineStart:])
+}
+
 // leadingWhitespaceLen returns count of whitespace characters on given line.
 func leadingWhitespaceLen(in string) (n int) {
-	for _, ch := range in {
-		if ch == ' ' || ch == '\t' {
-			n++
-		} else {
-			break
-		}
+	for n < len(in) && (in[n] == ' ' || in[n] == '\t') {
+		n++
 	}
 	return
 }
@@ -479,100 +547,20 @@ func printCharCode(code rune) string {
 	return fmt.Sprintf(`"\\u%04X"`, code)
 }
 
-func readToken(s *source.Source, fromPosition int) (Token, error) {
-	body := s.Body
-	bodyLength := len(body)
-	position, runePosition := positionAfterWhitespace(body, fromPosition)
-	if position >= bodyLength {
-		return makeToken(EOF, position, position, ""), nil
-	}
-	code, codeLength := runeAt(body, position)
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
 
-	// SourceCharacter
-	if code < 0x0020 && code != 0x0009 && code != 0x000A && code != 0x000D {
-		return Token{}, gqlerrors.NewSyntaxError(s, runePosition, fmt.Sprintf(`Invalid character %v`, printCharCode(code)))
-	}
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
 
-	switch code {
-	// !
-	case '!':
-		return makeToken(BANG, position, position+1, ""), nil
-	// $
-	case '$':
-		return makeToken(DOLLAR, position, position+1, ""), nil
-	// &
-	case '&':
-		return makeToken(AMP, position, position+1, ""), nil
-	// (
-	case '(':
-		return makeToken(PAREN_L, position, position+1, ""), nil
-	// )
-	case ')':
-		return makeToken(PAREN_R, position, position+1, ""), nil
-	// .
-	case '.':
-		next1, _ := runeAt(body, position+1)
-		next2, _ := runeAt(body, position+2)
-		if next1 == '.' && next2 == '.' {
-			return makeToken(SPREAD, position, position+3, ""), nil
-		}
-		break
-	// :
-	case ':':
-		return makeToken(COLON, position, position+1, ""), nil
-	// =
-	case '=':
-		return makeToken(EQUALS, position, position+1, ""), nil
-	// @
-	case '@':
-		return makeToken(AT, position, position+1, ""), nil
-	// [
-	case '[':
-		return makeToken(BRACKET_L, position, position+1, ""), nil
-	// ]
-	case ']':
-		return makeToken(BRACKET_R, position, position+1, ""), nil
-	// {
-	case '{':
-		return makeToken(BRACE_L, position, position+1, ""), nil
-	// |
-	case '|':
-		return makeToken(PIPE, position, position+1, ""), nil
-	// }
-	case '}':
-		return makeToken(BRACE_R, position, position+1, ""), nil
-	// A-Z
-	case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N',
-		'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
-		return readName(s, position, runePosition), nil
-	// _
-	// a-z
-	case '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n',
-		'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
-		return readName(s, position, runePosition), nil
-	// -
-	// 0-9
-	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-		token, err := readNumber(s, position, code, codeLength)
-		if err != nil {
-			return token, err
-		}
-		return token, nil
-	// "
-	case '"':
-		var token Token
-		var err error
-		x, _ := runeAt(body, position+1)
-		y, _ := runeAt(body, position+2)
-		if x == '"' && y == '"' {
-			token, err = readBlockString(s, position)
-		} else {
-			token, err = readString(s, position)
-		}
-		return token, err
+// Gets the byte from the byte array at given position, or 0 at the end of it.
+func byteAt(body []byte, position int) byte {
+	if position < len(body) {
+		return body[position]
 	}
-	description := fmt.Sprintf("Unexpected character %v.", printCharCode(code))
-	return Token{}, gqlerrors.NewSyntaxError(s, runePosition, description)
+	return 0
 }
 
 // Gets the rune from the byte array at given byte position and it's width in bytes
@@ -592,54 +580,64 @@ func runeAt(body []byte, position int) (code rune, charWidth int) {
 }
 
 // Reads from body starting at startPosition until it finds a non-whitespace
-// or commented character, then returns the position of that character for lexing.
-// lexing.
-// Returns both byte positions and rune position
-func positionAfterWhitespace(body []byte, startPosition int) (position int, runePosition int) {
-	bodyLength := len(body)
-	position = startPosition
-	runePosition = startPosition
-	for {
-		if position < bodyLength {
-			code, n := runeAt(body, position)
-
-			// Skip Ignored
-			if code == 0xFEFF || // BOM
-				// White Space
-				code == 0x0009 || // tab
-				code == 0x0020 || // space
-				// Line Terminator
-				code == 0x000A || // new line
-				code == 0x000D || // carriage return
-				// Comma
-				code == 0x002C {
-				position += n
-				runePosition++
-			} else if code == 35 { // #
-				position += n
-				runePosition++
-				for {
-					code, n := runeAt(body, position)
-					if position < bodyLength &&
-						code != 0 &&
-						// SourceCharacter but not LineTerminator
-						(code > 0x001F || code == 0x0009) && code != 0x000A && code != 0x000D {
-						position += n
-						runePosition++
-						continue
-					} else {
-						break
-					}
+// or commented character, then returns the byte position of that character for lexing.
+func positionAfterWhitespace(body []byte, startPosition int) int {
+	position := startPosition
+	for position < len(body) {
+		switch c := body[position]; c {
+		// White Space, Line Terminator and Comma
+		case 0x0009, 0x0020, 0x000A, 0x000D, 0x002C:
+			position++
+		// BOM (U+FEFF)
+		case 0xEF:
+			if byteAt(body, position+1) != 0xBB || byteAt(body, position+2) != 0xBF {
+				return position
+			}
+			position += 3
+		// Comment, up to a LineTerminator or another control character
+		case '#':
+			position++
+			for position < len(body) {
+				c := body[position]
+				if c < 0x0020 && c != 0x0009 {
+					break
 				}
-			} else {
-				break
+				position++
 			}
+		default:
+			return position
+		}
+	}
+	return position
+}
+
+// ReadComments returns the comments found in body between the byte positions start and end,
+// which must only hold ignored tokens, e.g. between the end of a token and the start of the next
+// one. The value of the returned COMMENT tokens is their text after the "#".
+func ReadComments(body []byte, start, end int) []Token {
+	if end > len(body) {
+		end = len(body)
+	}
+	var comments []Token
+	position := start
+	for position < end {
+		code, n := runeAt(body, position)
+		if code != '#' {
+			position += n
 			continue
-		} else {
-			break
 		}
+		commentStart := position
+		position += n
+		for position < end {
+			code, n := runeAt(body, position)
+			if code == 0 || code == 0x000A || code == 0x000D || (code <= 0x001F && code != 0x0009) {
+				break
+			}
+			position += n
+		}
+		comments = append(comments, makeToken(COMMENT, commentStart, position, string(body[commentStart+1:position])))
 	}
-	return position, runePosition
+	return comments
 }
 
 func GetTokenDesc(token Token) string {
@@ -90,8 +90,8 @@ func TestLexer_AcceptsBOMHeader(t *testing.T) {
 			Body: "\uFEFF foo",
 			Expected: Token{
 				Kind:  NAME,
-				Start: 2,
-				End:   5,
+				Start: 4,
+				End:   7,
 				Value: "foo",
 			},
 		},
@@ -107,6 +107,30 @@ func TestLexer_AcceptsBOMHeader(t *testing.T) {
 	}
 }
 
+func TestLexer_TokensHaveByteOffsets(t *testing.T) {
+	body := "\uFEFF foo # ça va\n bar \"é\" baz"
+	expected := []Token{
+		{Kind: NAME, Start: 4, End: 7, Value: "foo"},
+		{Kind: NAME, Start: 18, End: 21, Value: "bar"},
+		{Kind: STRING, Start: 22, End: 26, Value: "é"},
+		{Kind: NAME, Start: 27, End: 30, Value: "baz"},
+		{Kind: EOF, Start: 30, End: 30},
+	}
+	lexer := Lex(createSource(body))
+	for _, want := range expected {
+		token, err := lexer(0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(token, want) {
+			t.Fatalf("unexpected token, expected: %v, got: %v", want, token)
+		}
+		if token.Value != "" && token.Kind == NAME && string(body[token.Start:token.End]) != token.Value {
+			t.Fatalf("token %v doesn't match its offsets in the body", token)
+		}
+	}
+}
+
 func TestLexer_SkipsWhiteSpace(t *testing.T) {
 	tests := []Test{
 		{
@@ -570,6 +594,26 @@ func TestLexer_LexesBlockStrings(t *testing.T) {
 	}
 }
 
+func TestLexer_BlockStringValue(t *testing.T) {
+	tests := []struct {
+		Raw      string
+		Expected string
+	}{
+		{Raw: "", Expected: ""},
+		{Raw: "\n  \t \n", Expected: ""},
+		{Raw: "  first line keeps its indentation\n    second\n  third", Expected: "  first line keeps its indentation\n  second\nthird"},
+		{Raw: "\n\t\tTabs\n\t\t\tare\n\t\twhitespace\n\t", Expected: "Tabs\n\tare\nwhitespace"},
+		{Raw: "\n    blank lines\n\n      \n    don't count\n    ", Expected: "blank lines\n\n  \ndon't count"},
+		{Raw: "\n    short\n  \n    blank line", Expected: "short\n\nblank line"},
+		{Raw: "\r\n  crlf\r    cr\n  lf\r\n", Expected: "crlf\n  cr\nlf"},
+	}
+	for _, test := range tests {
+		if value := blockStringValue(test.Raw); value != test.Expected {
+			t.Errorf("unexpected value of %q, expected: %q, got: %q", test.Raw, test.Expected, value)
+		}
+	}
+}
+
 func TestLexer_ReportsUsefulBlockStringErrors(t *testing.T) {
 	tests := []Test{
 		{
@@ -1,35 +1,50 @@
 package location
 
 import (
-	"regexp"
+	"unicode/utf8"
 
 	"github.com/fiatjaf/graphql/language/source"
 )
 
+// SourceLocation is the position of a character in a source, as its line and column, both
+// starting at 1. Columns count the Unicode code points of the line, not its bytes.
 type SourceLocation struct {
 	Line   int `json:"line"`
 	Column int `json:"column"`
 }
 
+// GetLocation returns the line and column of the byte offset position in s. The lines are
+// terminated by "\r\n", "\n" or "\r".
 func GetLocation(s *source.Source, position int) SourceLocation {
 	body := []byte{}
 	if s != nil {
 		body = s.Body
 	}
+	end := position
+	if end > len(body) {
+		end = len(body)
+	}
 	line := 1
-	column := position + 1
-	lineRegexp := regexp.MustCompile("\r\n|[\n\r]")
-	matches := lineRegexp.FindAllIndex(body, -1)
-	for _, match := range matches {
-		matchIndex := match[0]
-		if matchIndex < position {
+	lineStart := 0
+	for i := 0; i < end; i++ {
+		switch body[i] {
+		case '\r':
+			if i+1 < end && body[i+1] == '\n' {
+				i++
+			}
+			line++
+			lineStart = i + 1
+		case '\n':
 			line++
-			l := len(s.Body[match[0]:match[1]])
-			column = position + 1 - (matchIndex + l)
-			continue
-		} else {
-			break
+			lineStart = i + 1
 		}
 	}
+	column := 1
+	if lineStart < end {
+		column += utf8.RuneCount(body[lineStart:end])
+	}
+	if position > end {
+		column += position - end
+	}
 	return SourceLocation{Line: line, Column: column}
 }
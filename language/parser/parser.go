@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/fiatjaf/graphql/gqlerrors"
@@ -16,6 +17,10 @@ type parseDefinitionFn func(parser *Parser) (ast.Node, error)
 
 var tokenDefinitionFn map[string]parseDefinitionFn
 
+// keywordDefinitionFn is kept apart from tokenDefinitionFn, as names such as "String" are also
+// token kinds: looking them up there would parse their definition again and again.
+var keywordDefinitionFn map[string]parseDefinitionFn
+
 func init() {
 	tokenDefinitionFn = make(map[string]parseDefinitionFn)
 	{
@@ -24,26 +29,33 @@ func init() {
 		tokenDefinitionFn[lexer.STRING.String()] = parseTypeSystemDefinition
 		tokenDefinitionFn[lexer.BLOCK_STRING.String()] = parseTypeSystemDefinition
 		tokenDefinitionFn[lexer.NAME.String()] = parseTypeSystemDefinition
+	}
+	keywordDefinitionFn = make(map[string]parseDefinitionFn)
+	{
 		// for NAME
-		tokenDefinitionFn[lexer.FRAGMENT] = parseFragmentDefinition
-		tokenDefinitionFn[lexer.QUERY] = parseOperationDefinition
-		tokenDefinitionFn[lexer.MUTATION] = parseOperationDefinition
-		tokenDefinitionFn[lexer.SUBSCRIPTION] = parseOperationDefinition
-		tokenDefinitionFn[lexer.SCHEMA] = parseSchemaDefinition
-		tokenDefinitionFn[lexer.SCALAR] = parseScalarTypeDefinition
-		tokenDefinitionFn[lexer.TYPE] = parseObjectTypeDefinition
-		tokenDefinitionFn[lexer.INTERFACE] = parseInterfaceTypeDefinition
-		tokenDefinitionFn[lexer.UNION] = parseUnionTypeDefinition
-		tokenDefinitionFn[lexer.ENUM] = parseEnumTypeDefinition
-		tokenDefinitionFn[lexer.INPUT] = parseInputObjectTypeDefinition
-		tokenDefinitionFn[lexer.EXTEND] = parseTypeExtensionDefinition
-		tokenDefinitionFn[lexer.DIRECTIVE] = parseDirectiveDefinition
+		keywordDefinitionFn[lexer.FRAGMENT] = parseFragmentDefinition
+		keywordDefinitionFn[lexer.QUERY] = parseOperationDefinition
+		keywordDefinitionFn[lexer.MUTATION] = parseOperationDefinition
+		keywordDefinitionFn[lexer.SUBSCRIPTION] = parseOperationDefinition
+		keywordDefinitionFn[lexer.SCHEMA] = parseSchemaDefinition
+		keywordDefinitionFn[lexer.SCALAR] = parseScalarTypeDefinition
+		keywordDefinitionFn[lexer.TYPE] = parseObjectTypeDefinition
+		keywordDefinitionFn[lexer.INTERFACE] = parseInterfaceTypeDefinition
+		keywordDefinitionFn[lexer.UNION] = parseUnionTypeDefinition
+		keywordDefinitionFn[lexer.ENUM] = parseEnumTypeDefinition
+		keywordDefinitionFn[lexer.INPUT] = parseInputObjectTypeDefinition
+		keywordDefinitionFn[lexer.EXTEND] = parseTypeExtensionDefinition
+		keywordDefinitionFn[lexer.DIRECTIVE] = parseDirectiveDefinition
 	}
 }
 
 type ParseOptions struct {
 	NoLocation bool
 	NoSource   bool
+	// KeepComments attaches the comments leading the definitions, fields, fragments, arguments
+	// and enum values of the document to their nodes. Comments on the same line as the end of
+	// the previous token are trailing comments, which are discarded.
+	KeepComments bool
 }
 
 type ParseParams struct {
@@ -57,18 +69,17 @@ type Parser struct {
 	Options  ParseOptions
 	PrevEnd  int
 	Token    lexer.Token
+
+	// locations and names are allocated by chunks, as there's one for almost every node
+	locations []ast.Location
+	names     []ast.Name
 }
 
+// nodeChunkSize is the number of locations and names allocated at once by the parser.
+const nodeChunkSize = 64
+
 func Parse(p ParseParams) (*ast.Document, error) {
-	var sourceObj *source.Source
-	switch src := p.Source.(type) {
-	case *source.Source:
-		sourceObj = src
-	default:
-		body, _ := p.Source.(string)
-		sourceObj = source.NewSource(&source.Source{Body: []byte(body)})
-	}
-	parser, err := makeParser(sourceObj, p.Options)
+	parser, err := makeParser(paramsSource(p), p.Options)
 	if err != nil {
 		return nil, err
 	}
@@ -79,38 +90,63 @@ func Parse(p ParseParams) (*ast.Document, error) {
 	return doc, nil
 }
 
-// TODO: test and expose parseValue as a public
-func parseValue(p ParseParams) (ast.Value, error) {
-	var value ast.Value
-	var sourceObj *source.Source
-	switch src := p.Source.(type) {
-	case *source.Source:
-		sourceObj = src
-	default:
-		body, _ := p.Source.(string)
-		sourceObj = source.NewSource(&source.Source{Body: []byte(body)})
-	}
-	parser, err := makeParser(sourceObj, p.Options)
+// ParseValue parses the source of p as a standalone value literal, e.g. a default value or
+// variables stored as GraphQL, like `{a: [1, 2], b: $var}`. The whole source must be the value.
+func ParseValue(p ParseParams) (ast.Value, error) {
+	parser, err := makeParser(paramsSource(p), p.Options)
 	if err != nil {
-		return value, err
+		return nil, err
 	}
-	value, err = parseValueLiteral(parser, false)
+	value, err := parseValueLiteral(parser, false)
 	if err != nil {
-		return value, err
+		return nil, err
+	}
+	if _, err := expect(parser, lexer.EOF); err != nil {
+		return nil, err
 	}
 	return value, nil
 }
 
+// ParseType parses the source of p as a standalone type reference, e.g. `[String!]!`. The whole
+// source must be the type.
+func ParseType(p ParseParams) (ast.Type, error) {
+	parser, err := makeParser(paramsSource(p), p.Options)
+	if err != nil {
+		return nil, err
+	}
+	ttype, err := parseType(parser)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := expect(parser, lexer.EOF); err != nil {
+		return nil, err
+	}
+	return ttype, nil
+}
+
+// paramsSource returns the source of p, given as a *source.Source or as a string.
+func paramsSource(p ParseParams) *source.Source {
+	if src, ok := p.Source.(*source.Source); ok {
+		return src
+	}
+	body, _ := p.Source.(string)
+	return source.NewSource(&source.Source{Body: []byte(body)})
+}
+
 // Converts a name lex token into a name parse node.
 func parseName(parser *Parser) (*ast.Name, error) {
 	token, err := expect(parser, lexer.NAME)
 	if err != nil {
 		return nil, err
 	}
-	return ast.NewName(&ast.Name{
-		Value: token.Value,
-		Loc:   loc(parser, token.Start),
-	}), nil
+	if len(parser.names) == 0 {
+		parser.names = make([]ast.Name, nodeChunkSize)
+	}
+	name := &parser.names[0]
+	parser.names = parser.names[1:]
+	name.Value = token.Value
+	name.Loc = loc(parser, token.Start)
+	return ast.NewName(name), nil
 }
 
 func makeParser(s *source.Source, opts ParseOptions) (*Parser, error) {
@@ -178,12 +214,14 @@ func parseOperationDefinition(parser *Parser) (ast.Node, error) {
 		err                 error
 	)
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	if peek(parser, lexer.BRACE_L) {
 		selectionSet, err := parseSelectionSet(parser)
 		if err != nil {
 			return nil, err
 		}
 		return ast.NewOperationDefinition(&ast.OperationDefinition{
+			Comments:     comments,
 			Operation:    ast.OperationTypeQuery,
 			Directives:   []*ast.Directive{},
 			SelectionSet: selectionSet,
@@ -209,6 +247,7 @@ func parseOperationDefinition(parser *Parser) (ast.Node, error) {
 		return nil, err
 	}
 	return ast.NewOperationDefinition(&ast.OperationDefinition{
+		Comments:            comments,
 		Operation:           operation,
 		Name:                name,
 		VariableDefinitions: variableDefinitions,
@@ -365,6 +404,7 @@ func parseField(parser *Parser) (*ast.Field, error) {
 		err        error
 	)
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	if name, err = parseName(parser); err != nil {
 		return nil, err
 	}
@@ -389,6 +429,7 @@ func parseField(parser *Parser) (*ast.Field, error) {
 		}
 	}
 	return ast.NewField(&ast.Field{
+		Comments:     comments,
 		Alias:        alias,
 		Name:         name,
 		Arguments:    arguments,
@@ -456,6 +497,7 @@ func parseArgument(parser *Parser) (interface{}, error) {
 func parseFragment(parser *Parser) (interface{}, error) {
 	var err error
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	if _, err = expect(parser, lexer.SPREAD); err != nil {
 		return nil, err
 	}
@@ -469,6 +511,7 @@ func parseFragment(parser *Parser) (interface{}, error) {
 			return nil, err
 		}
 		return ast.NewFragmentSpread(&ast.FragmentSpread{
+			Comments:   comments,
 			Name:       name,
 			Directives: directives,
 			Loc:        loc(parser, start),
@@ -495,6 +538,7 @@ func parseFragment(parser *Parser) (interface{}, error) {
 		return nil, err
 	}
 	return ast.NewInlineFragment(&ast.InlineFragment{
+		Comments:      comments,
 		TypeCondition: typeCondition,
 		Directives:    directives,
 		SelectionSet:  selectionSet,
@@ -510,6 +554,7 @@ func parseFragment(parser *Parser) (interface{}, error) {
  */
 func parseFragmentDefinition(parser *Parser) (ast.Node, error) {
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	_, err := expectKeyWord(parser, lexer.FRAGMENT)
 	if err != nil {
 		return nil, err
@@ -535,6 +580,7 @@ func parseFragmentDefinition(parser *Parser) (ast.Node, error) {
 		return nil, err
 	}
 	return ast.NewFragmentDefinition(&ast.FragmentDefinition{
+		Comments:      comments,
 		Name:          name,
 		TypeCondition: typeCondition,
 		Directives:    directives,
@@ -562,12 +608,15 @@ func parseFragmentName(parser *Parser) (*ast.Name, error) {
  *   - FloatValue
  *   - StringValue
  *   - BooleanValue
+ *   - NullValue
  *   - EnumValue
  *   - ListValue[?Const]
  *   - ObjectValue[?Const]
  *
  * BooleanValue : one of `true` `false`
  *
+ * NullValue : `null`
+ *
  * EnumValue : Name but not `true`, `false` or `null`
  */
 func parseValueLiteral(parser *Parser, isConst bool) (ast.Value, error) {
@@ -608,7 +657,14 @@ func parseValueLiteral(parser *Parser, isConst bool) (ast.Value, error) {
 				Value: value,
 				Loc:   loc(parser, token.Start),
 			}), nil
-		} else if token.Value != "null" {
+		} else if token.Value == "null" {
+			if err := advance(parser); err != nil {
+				return nil, err
+			}
+			return ast.NewNullValue(&ast.NullValue{
+				Loc: loc(parser, token.Start),
+			}), nil
+		} else {
 			if err := advance(parser); err != nil {
 				return nil, err
 			}
@@ -775,27 +831,22 @@ func parseDirective(parser *Parser) (*ast.Directive, error) {
 func parseType(parser *Parser) (ttype ast.Type, err error) {
 	token := parser.Token
 	// [ String! ]!
-	switch token.Kind {
-	case lexer.BRACKET_L:
+	if token.Kind == lexer.BRACKET_L {
 		if err = advance(parser); err != nil {
 			return nil, err
 		}
 		if ttype, err = parseType(parser); err != nil {
 			return nil, err
 		}
-		fallthrough
-	case lexer.BRACKET_R:
-		if err = advance(parser); err != nil {
+		if _, err = expect(parser, lexer.BRACKET_R); err != nil {
 			return nil, err
 		}
 		ttype = ast.NewList(&ast.List{
 			Type: ttype,
 			Loc:  loc(parser, token.Start),
 		})
-	case lexer.NAME:
-		if ttype, err = parseNamed(parser); err != nil {
-			return nil, err
-		}
+	} else if ttype, err = parseNamed(parser); err != nil {
+		return nil, err
 	}
 
 	// BANG must be executed
@@ -859,7 +910,7 @@ func parseTypeSystemDefinition(parser *Parser) (ast.Node, error) {
 		return nil, unexpected(parser, keywordToken)
 	}
 	var ok bool
-	if item, ok = tokenDefinitionFn[keywordToken.Value]; !ok {
+	if item, ok = keywordDefinitionFn[keywordToken.Value]; !ok {
 		return nil, unexpected(parser, keywordToken)
 	}
 	return item(parser)
@@ -872,6 +923,7 @@ func parseTypeSystemDefinition(parser *Parser) (ast.Node, error) {
  */
 func parseSchemaDefinition(parser *Parser) (ast.Node, error) {
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	_, err := expectKeyWord(parser, "schema")
 	if err != nil {
 		return nil, err
@@ -895,6 +947,7 @@ func parseSchemaDefinition(parser *Parser) (ast.Node, error) {
 		}
 	}
 	return ast.NewSchemaDefinition(&ast.SchemaDefinition{
+		Comments:       comments,
 		OperationTypes: operationTypes,
 		Directives:     directives,
 		Loc:            loc(parser, start),
@@ -927,6 +980,7 @@ func parseOperationTypeDefinition(parser *Parser) (interface{}, error) {
  */
 func parseScalarTypeDefinition(parser *Parser) (ast.Node, error) {
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	description, err := parseDescription(parser)
 	if err != nil {
 		return nil, err
@@ -944,6 +998,7 @@ func parseScalarTypeDefinition(parser *Parser) (ast.Node, error) {
 		return nil, err
 	}
 	def := ast.NewScalarDefinition(&ast.ScalarDefinition{
+		Comments:    comments,
 		Name:        name,
 		Description: description,
 		Directives:  directives,
@@ -959,6 +1014,7 @@ func parseScalarTypeDefinition(parser *Parser) (ast.Node, error) {
  */
 func parseObjectTypeDefinition(parser *Parser) (ast.Node, error) {
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	description, err := parseDescription(parser)
 	if err != nil {
 		return nil, err
@@ -993,6 +1049,7 @@ func parseObjectTypeDefinition(parser *Parser) (ast.Node, error) {
 		}
 	}
 	return ast.NewObjectDefinition(&ast.ObjectDefinition{
+		Comments:    comments,
 		Name:        name,
 		Description: description,
 		Loc:         loc(parser, start),
@@ -1036,6 +1093,7 @@ func parseImplementsInterfaces(parser *Parser) ([]*ast.Named, error) {
  */
 func parseFieldDefinition(parser *Parser) (interface{}, error) {
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	description, err := parseDescription(parser)
 	if err != nil {
 		return nil, err
@@ -1061,6 +1119,7 @@ func parseFieldDefinition(parser *Parser) (interface{}, error) {
 		return nil, err
 	}
 	return ast.NewFieldDefinition(&ast.FieldDefinition{
+		Comments:    comments,
 		Name:        name,
 		Description: description,
 		Arguments:   args,
@@ -1106,6 +1165,7 @@ func parseInputValueDef(parser *Parser) (interface{}, error) {
 		err         error
 	)
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	if description, err = parseDescription(parser); err != nil {
 		return nil, err
 	}
@@ -1134,6 +1194,7 @@ func parseInputValueDef(parser *Parser) (interface{}, error) {
 		return nil, err
 	}
 	return ast.NewInputValueDefinition(&ast.InputValueDefinition{
+		Comments:     comments,
 		Name:         name,
 		Description:  description,
 		Type:         ttype,
@@ -1150,6 +1211,7 @@ func parseInputValueDef(parser *Parser) (interface{}, error) {
  */
 func parseInterfaceTypeDefinition(parser *Parser) (ast.Node, error) {
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	description, err := parseDescription(parser)
 	if err != nil {
 		return nil, err
@@ -1180,6 +1242,7 @@ func parseInterfaceTypeDefinition(parser *Parser) (ast.Node, error) {
 		}
 	}
 	return ast.NewInterfaceDefinition(&ast.InterfaceDefinition{
+		Comments:    comments,
 		Name:        name,
 		Description: description,
 		Directives:  directives,
@@ -1193,6 +1256,7 @@ func parseInterfaceTypeDefinition(parser *Parser) (ast.Node, error) {
  */
 func parseUnionTypeDefinition(parser *Parser) (ast.Node, error) {
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	description, err := parseDescription(parser)
 	if err != nil {
 		return nil, err
@@ -1218,6 +1282,7 @@ func parseUnionTypeDefinition(parser *Parser) (ast.Node, error) {
 		return nil, err
 	}
 	return ast.NewUnionDefinition(&ast.UnionDefinition{
+		Comments:    comments,
 		Name:        name,
 		Description: description,
 		Directives:  directives,
@@ -1253,6 +1318,7 @@ func parseUnionMembers(parser *Parser) ([]*ast.Named, error) {
  */
 func parseEnumTypeDefinition(parser *Parser) (ast.Node, error) {
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	description, err := parseDescription(parser)
 	if err != nil {
 		return nil, err
@@ -1283,6 +1349,7 @@ func parseEnumTypeDefinition(parser *Parser) (ast.Node, error) {
 		}
 	}
 	return ast.NewEnumDefinition(&ast.EnumDefinition{
+		Comments:    comments,
 		Name:        name,
 		Description: description,
 		Directives:  directives,
@@ -1298,6 +1365,7 @@ func parseEnumTypeDefinition(parser *Parser) (ast.Node, error) {
  */
 func parseEnumValueDefinition(parser *Parser) (interface{}, error) {
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	description, err := parseDescription(parser)
 	if err != nil {
 		return nil, err
@@ -1311,6 +1379,7 @@ func parseEnumValueDefinition(parser *Parser) (interface{}, error) {
 		return nil, err
 	}
 	return ast.NewEnumValueDefinition(&ast.EnumValueDefinition{
+		Comments:    comments,
 		Name:        name,
 		Description: description,
 		Directives:  directives,
@@ -1324,6 +1393,7 @@ func parseEnumValueDefinition(parser *Parser) (interface{}, error) {
  */
 func parseInputObjectTypeDefinition(parser *Parser) (ast.Node, error) {
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	description, err := parseDescription(parser)
 	if err != nil {
 		return nil, err
@@ -1354,6 +1424,7 @@ func parseInputObjectTypeDefinition(parser *Parser) (ast.Node, error) {
 		}
 	}
 	return ast.NewInputObjectDefinition(&ast.InputObjectDefinition{
+		Comments:    comments,
 		Name:        name,
 		Description: description,
 		Directives:  directives,
@@ -1367,6 +1438,7 @@ func parseInputObjectTypeDefinition(parser *Parser) (ast.Node, error) {
  */
 func parseTypeExtensionDefinition(parser *Parser) (ast.Node, error) {
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	_, err := expectKeyWord(parser, lexer.EXTEND)
 	if err != nil {
 		return nil, err
@@ -1377,6 +1449,7 @@ func parseTypeExtensionDefinition(parser *Parser) (ast.Node, error) {
 		return nil, err
 	}
 	return ast.NewTypeExtensionDefinition(&ast.TypeExtensionDefinition{
+		Comments:   comments,
 		Loc:        loc(parser, start),
 		Definition: definition.(*ast.ObjectDefinition),
 	}), nil
@@ -1395,6 +1468,7 @@ func parseDirectiveDefinition(parser *Parser) (ast.Node, error) {
 		locations   []*ast.Name
 	)
 	start := parser.Token.Start
+	comments := leadingComments(parser)
 	if description, err = parseDescription(parser); err != nil {
 		return nil, err
 	}
@@ -1418,6 +1492,7 @@ func parseDirectiveDefinition(parser *Parser) (ast.Node, error) {
 	}
 
 	return ast.NewDirectiveDefinition(&ast.DirectiveDefinition{
+		Comments:    comments,
 		Loc:         loc(parser, start),
 		Name:        name,
 		Description: description,
@@ -1456,6 +1531,7 @@ func parseStringLiteral(parser *Parser) (*ast.StringValue, error) {
 	}
 	return ast.NewStringValue(&ast.StringValue{
 		Value: token.Value,
+		Block: token.Kind == lexer.BLOCK_STRING,
 		Loc:   loc(parser, token.Start),
 	}), nil
 }
@@ -1478,17 +1554,41 @@ func loc(parser *Parser, start int) *ast.Location {
 	if parser.Options.NoLocation {
 		return nil
 	}
-	if parser.Options.NoSource {
-		return ast.NewLocation(&ast.Location{
-			Start: start,
-			End:   parser.PrevEnd,
-		})
+	if len(parser.locations) == 0 {
+		parser.locations = make([]ast.Location, nodeChunkSize)
 	}
-	return ast.NewLocation(&ast.Location{
-		Start:  start,
-		End:    parser.PrevEnd,
-		Source: parser.Source,
-	})
+	location := &parser.locations[0]
+	parser.locations = parser.locations[1:]
+	location.Start = start
+	location.End = parser.PrevEnd
+	if !parser.Options.NoSource {
+		location.Source = parser.Source
+	}
+	return location
+}
+
+// Returns the comments between the previous token and the current one, which lead the node
+// starting at the current token, when the parser keeps comments.
+func leadingComments(parser *Parser) []*ast.Comment {
+	if !parser.Options.KeepComments {
+		return nil
+	}
+	var comments []*ast.Comment
+	body := parser.Source.Body
+	for _, token := range lexer.ReadComments(body, parser.PrevEnd, parser.Token.Start) {
+		if parser.PrevEnd != 0 && !bytes.ContainsAny(body[parser.PrevEnd:token.Start], "\r\n") {
+			continue
+		}
+		comment := &ast.Comment{Value: token.Value}
+		if !parser.Options.NoLocation {
+			comment.Loc = ast.NewLocation(&ast.Location{Start: token.Start, End: token.End})
+			if !parser.Options.NoSource {
+				comment.Loc.Source = parser.Source
+			}
+		}
+		comments = append(comments, comment)
+	}
+	return comments
 }
 
 // Moves the internal parser object to the next lexed token.
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/fiatjaf/graphql/language/lexer"
+	"github.com/fiatjaf/graphql/language/source"
+)
+
+// Benchmark the lexing and parsing of the kitchen sinks, repeated to make large documents.
+func BenchmarkLex_KitchenSink_1(b *testing.B) {
+	lexBenchmark(b, kitchenSinks(b, 1))
+}
+
+func BenchmarkLex_KitchenSink_100(b *testing.B) {
+	lexBenchmark(b, kitchenSinks(b, 100))
+}
+
+func BenchmarkParse_KitchenSink_1(b *testing.B) {
+	parseBenchmark(b, kitchenSinks(b, 1))
+}
+
+func BenchmarkParse_KitchenSink_100(b *testing.B) {
+	parseBenchmark(b, kitchenSinks(b, 100))
+}
+
+func BenchmarkParse_KitchenSink_100_NoLocation(b *testing.B) {
+	body := kitchenSinks(b, 100)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(ParseParams{Source: body, Options: ParseOptions{NoLocation: true}}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func kitchenSinks(b *testing.B, n int) string {
+	var sinks []string
+	for _, file := range []string{"../../kitchen-sink.graphql", "../../schema-kitchen-sink.graphql"} {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			b.Fatalf("unable to load %s", file)
+		}
+		sinks = append(sinks, string(content))
+	}
+	return strings.Repeat(strings.Join(sinks, "\n"), n)
+}
+
+func lexBenchmark(b *testing.B, body string) {
+	s := source.NewSource(&source.Source{Body: []byte(body)})
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lex := lexer.Lex(s)
+		for {
+			token, err := lex(0)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if token.Kind == lexer.EOF {
+				break
+			}
+		}
+	}
+}
+
+func parseBenchmark(b *testing.B, body string) {
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(ParseParams{Source: body}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/fiatjaf/graphql/language/printer"
+)
+
+// FuzzParse checks that no input makes the parser panic, and that the documents it parses are
+// printed to documents it parses again.
+func FuzzParse(f *testing.F) {
+	for _, file := range []string{"../../kitchen-sink.graphql", "../../schema-kitchen-sink.graphql"} {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			f.Fatalf("unable to load %s", file)
+		}
+		f.Add(string(content))
+	}
+	for _, seed := range []string{
+		`{ a }`,
+		`query Q($v: [Int!]! = [1, 2]) { a(b: $v, c: {d: "e", f: """g"""}) @h { ...I } }`,
+		`fragment I on T { j ... on U { k } }`,
+		`"""description""" type T implements A & B @d { f(a: Int = 1): [String!] }`,
+		`extend schema @d { query: Q }`,
+		`{ a(b: "é\n") }`,
+		`{ a(b: 1.5e-3, c: -0, d: null, e: ENUM) }`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, query string) {
+		doc, err := Parse(ParseParams{Source: query})
+		if err != nil {
+			return
+		}
+		printed, ok := printer.Print(doc).(string)
+		if !ok {
+			t.Fatalf("failed to print %q", query)
+		}
+		if _, err := Parse(ParseParams{Source: printed}); err != nil {
+			t.Fatalf("failed to parse %q printed from %q: %v", printed, query, err)
+		}
+	})
+}
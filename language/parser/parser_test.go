@@ -9,6 +9,7 @@ import (
 
 	"github.com/fiatjaf/graphql/gqlerrors"
 	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/kinds"
 	"github.com/fiatjaf/graphql/language/location"
 	"github.com/fiatjaf/graphql/language/printer"
 	"github.com/fiatjaf/graphql/language/source"
@@ -124,6 +125,21 @@ fragment MissingOn Type
 			`Syntax Error GraphQL (1:1) Unexpected ...`,
 			false,
 		},
+		{
+			`String { field }`,
+			`Syntax Error GraphQL (1:1) Unexpected Name "String"`,
+			false,
+		},
+		{
+			`"description" Name`,
+			`Syntax Error GraphQL (1:15) Unexpected Name "Name"`,
+			false,
+		},
+		{
+			`BlockString`,
+			`Syntax Error GraphQL (1:1) Unexpected Name "BlockString"`,
+			false,
+		},
 	}
 	for _, test := range testErrorMessagesTable {
 		if test.skipped != false {
@@ -182,13 +198,17 @@ func TestDoesNotAcceptFragmentsSpreadOfOn(t *testing.T) {
 	testErrorMessage(t, test)
 }
 
-func TestDoesNotAllowNullAsValue(t *testing.T) {
-	test := errorMessageTest{
-		`{ fieldWithNullableStringInput(input: null) }'`,
-		`Syntax Error GraphQL (1:39) Unexpected Name "null"`,
-		false,
+func TestAllowsNullAsValue(t *testing.T) {
+	astDoc := parse(t, `{ fieldWithNullableStringInput(input: null) }`)
+
+	field := astDoc.Definitions[0].(*ast.OperationDefinition).SelectionSet.Selections[0].(*ast.Field)
+	value := field.Arguments[0].Value
+	if value.GetKind() != kinds.NullValue {
+		t.Fatalf("expected a %v, got %v", kinds.NullValue, value.GetKind())
+	}
+	if value.GetValue() != nil {
+		t.Fatalf("expected a nil value, got %v", value.GetValue())
 	}
-	testErrorMessage(t, test)
 }
 
 func TestParsesMultiByteCharacters_Unicode(t *testing.T) {
@@ -792,3 +812,149 @@ func toError(err error) *gqlerrors.Error {
 		return nil
 	}
 }
+
+func TestParseKeepsLeadingComments(t *testing.T) {
+	source := `# the hero query
+# with two lines
+query Hero {
+  # the name
+  name # trailing, discarded
+  ...Friends
+}
+
+type Foo {
+  # bar comment
+  bar(
+    # baz comment
+    baz: Int
+  ): String
+}
+`
+	document, err := Parse(ParseParams{Source: source, Options: ParseOptions{KeepComments: true}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commentValues := func(node ast.CommentedNode) []string {
+		values := []string{}
+		for _, comment := range node.GetComments() {
+			values = append(values, comment.Value)
+		}
+		return values
+	}
+
+	operation := document.Definitions[0].(*ast.OperationDefinition)
+	if values := commentValues(operation); !reflect.DeepEqual(values, []string{" the hero query", " with two lines"}) {
+		t.Fatalf("unexpected operation comments: %q", values)
+	}
+	if loc := operation.Comments[0].Loc; loc.Start != 0 || loc.End != 16 || loc.Source == nil {
+		t.Fatalf("unexpected comment location: %v", loc)
+	}
+	name := operation.SelectionSet.Selections[0].(*ast.Field)
+	if values := commentValues(name); !reflect.DeepEqual(values, []string{" the name"}) {
+		t.Fatalf("unexpected field comments: %q", values)
+	}
+	if spread := operation.SelectionSet.Selections[1].(*ast.FragmentSpread); len(spread.Comments) != 0 {
+		t.Fatalf("expected the trailing comment to be discarded, got %q", commentValues(spread))
+	}
+
+	field := document.Definitions[1].(*ast.ObjectDefinition).Fields[0]
+	if values := commentValues(field); !reflect.DeepEqual(values, []string{" bar comment"}) {
+		t.Fatalf("unexpected field definition comments: %q", values)
+	}
+	if values := commentValues(field.Arguments[0]); !reflect.DeepEqual(values, []string{" baz comment"}) {
+		t.Fatalf("unexpected argument comments: %q", values)
+	}
+
+	document, err = Parse(ParseParams{Source: source})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if comments := document.Definitions[0].(*ast.OperationDefinition).Comments; comments != nil {
+		t.Fatalf("expected no comments without KeepComments, got %v", comments)
+	}
+}
+
+func TestParsesNamesAfterMultiByteComments(t *testing.T) {
+	document, err := Parse(ParseParams{Source: "{ # ça va\n foo bar }"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	selections := document.Definitions[0].(*ast.OperationDefinition).SelectionSet.Selections
+	if len(selections) != 2 {
+		t.Fatalf("expected 2 fields, got %v", len(selections))
+	}
+	if name := selections[0].(*ast.Field).Name; name.Value != "foo" || name.Loc.Start != 12 || name.Loc.End != 15 {
+		t.Fatalf("unexpected name: %v %v", name.Value, name.Loc)
+	}
+}
+
+func TestParseErrorsCountColumnsInCodePoints(t *testing.T) {
+	body := "{\n  hero(name: \"Ça\") {\n    amis(nom: \"Ça\") { }\n  }\n}"
+	_, err := Parse(ParseParams{Source: body})
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+	gqlErr := toError(err)
+	expectedLocations := []location.SourceLocation{{Line: 3, Column: 21}}
+	if !reflect.DeepEqual(gqlErr.Locations, expectedLocations) {
+		t.Fatalf("unexpected locations: %v", gqlErr.Locations)
+	}
+	if position := gqlErr.Positions[0]; body[position] != '{' {
+		t.Fatalf("expected the byte offset of the brace, got %v", position)
+	}
+
+	expectedFrame := `3:21  Unexpected empty IN {}
+
+  2 |   hero(name: "Ça") {
+> 3 |     amis(nom: "Ça") { }
+    |                     ^
+  4 |   }
+`
+	if frame := gqlErr.CodeFrame(); frame != expectedFrame {
+		t.Fatalf("unexpected code frame:\n%s\nexpected:\n%s", frame, expectedFrame)
+	}
+	if frame := gqlerrors.FormatError(gqlErr).CodeFrame(); frame != expectedFrame {
+		t.Fatalf("unexpected code frame of the formatted error:\n%s", frame)
+	}
+	if positions := gqlerrors.FormatError(gqlErr).Positions(); !reflect.DeepEqual(positions, gqlErr.Positions) {
+		t.Fatalf("unexpected positions of the formatted error: %v", positions)
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	value, err := ParseValue(ParseParams{Source: `{a: [1, 2.5, "three", true, null, ENUM], b: $var}`, Options: ParseOptions{NoLocation: true}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if printed := printer.Print(value); printed != `{a: [1, 2.5, "three", true, null, ENUM], b: $var}` {
+		t.Fatalf("unexpected value: %v", printed)
+	}
+	if _, ok := value.(*ast.ObjectValue); !ok {
+		t.Fatalf("expected an object value, got %T", value)
+	}
+
+	for _, source := range []string{"", "1 2", "{a: }", "[1"} {
+		if _, err := ParseValue(ParseParams{Source: source}); err == nil {
+			t.Errorf("expected an error parsing %q", source)
+		}
+	}
+}
+
+func TestParseType(t *testing.T) {
+	ttype, err := ParseType(ParseParams{Source: ` [ [String!]! ] `})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if printed := printer.Print(ttype); printed != "[[String!]!]" {
+		t.Fatalf("unexpected type: %v", printed)
+	}
+	if loc := ttype.GetLoc(); loc == nil || loc.Start != 1 || loc.End != 15 {
+		t.Fatalf("unexpected location: %v", loc)
+	}
+
+	for _, source := range []string{"", "[String", "String!!", "String Int", "1"} {
+		if _, err := ParseType(ParseParams{Source: source}); err == nil {
+			t.Errorf("expected an error parsing %q", source)
+		}
+	}
+}
@@ -3,10 +3,10 @@ package printer
 import (
 	"fmt"
 	"reflect"
-	"strconv"
 	"strings"
 
 	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/kinds"
 	"github.com/fiatjaf/graphql/language/visitor"
 )
 
@@ -85,11 +85,7 @@ func getDescription(raw interface{}) string {
 		desc = getMapValueString(node, "Description.Value")
 	}
 	if desc != "" {
-		sep := ""
-		if strings.ContainsRune(desc, '\n') {
-			sep = "\n"
-		}
-		desc = join([]string{`"""`, desc, `"""`}, sep)
+		desc = printBlockString(desc)
 	}
 	return desc
 }
@@ -135,821 +131,1020 @@ func wrap(start, maybeString, end string) string {
 }
 
 // Given array, print each item on its own line, wrapped in an indented "{ }" block.
-func block(maybeArray interface{}) string {
+func block(maybeArray interface{}, indentation string) string {
 	s := toSliceString(maybeArray)
 	if len(s) == 0 {
 		return "{}"
 	}
-	return indent("{\n"+join(s, "\n")) + "\n}"
+	return indent("{\n"+join(s, "\n"), indentation) + "\n}"
 }
 
-func indent(maybeString interface{}) string {
+func indent(maybeString interface{}, indentation string) string {
 	if maybeString == nil {
 		return ""
 	}
 	switch str := maybeString.(type) {
 	case string:
-		return strings.Replace(str, "\n", "\n  ", -1)
+		return strings.Replace(str, "\n", "\n"+indentation, -1)
 	}
 	return ""
 }
 
-var printDocASTReducer = map[string]visitor.VisitFunc{
-	"Name": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.Name:
-			return visitor.ActionUpdate, node.Value
-		case map[string]interface{}:
-			return visitor.ActionUpdate, getMapValue(node, "Value")
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"Variable": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.Variable:
-			return visitor.ActionUpdate, fmt.Sprintf("$%v", node.Name)
-		case map[string]interface{}:
-			return visitor.ActionUpdate, "$" + getMapValueString(node, "Name")
-		}
-		return visitor.ActionNoChange, nil
-	},
-
-	// Document
-	"Document": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.Document:
-			definitions := toSliceString(node.Definitions)
-			return visitor.ActionUpdate, join(definitions, "\n\n") + "\n"
-		case map[string]interface{}:
-			definitions := toSliceString(getMapValue(node, "Definitions"))
-			return visitor.ActionUpdate, join(definitions, "\n\n") + "\n"
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"OperationDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.OperationDefinition:
-			op := string(node.Operation)
-			name := fmt.Sprintf("%v", node.Name)
-
-			varDefs := wrap("(", join(toSliceString(node.VariableDefinitions), ", "), ")")
-			directives := join(toSliceString(node.Directives), " ")
-			selectionSet := fmt.Sprintf("%v", node.SelectionSet)
-			// Anonymous queries with no directives or variable definitions can use
-			// the query short form.
-			str := ""
-			if name == "" && directives == "" && varDefs == "" && op == ast.OperationTypeQuery {
-				str = selectionSet
+// printString prints value as a string literal, with the escape sequences of GraphQL.
+func printString(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
 			} else {
-				str = join([]string{
-					op,
-					join([]string{name, varDefs}, ""),
-					directives,
-					selectionSet,
-				}, " ")
+				b.WriteRune(r)
 			}
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
 
-			op := getMapValueString(node, "Operation")
-			name := getMapValueString(node, "Name")
+// printBlockString prints value as a block string, between triple quotes, on its own lines if it
+// has many of them or ends with a character that would merge with the closing quotes. The values
+// that the dedenting of block strings wouldn't give back are printed as regular strings.
+func printBlockString(value string) string {
+	if !isPrintableAsBlockString(value) {
+		return printString(value)
+	}
+	escaped := strings.Replace(value, `"""`, `\"""`, -1)
+	if strings.ContainsRune(value, '\n') || strings.HasSuffix(value, `"`) || strings.HasSuffix(value, `\`) {
+		return `"""` + "\n" + escaped + "\n" + `"""`
+	}
+	return `"""` + escaped + `"""`
+}
 
-			varDefs := wrap("(", join(toSliceString(getMapValue(node, "VariableDefinitions")), ", "), ")")
-			directives := join(toSliceString(getMapValue(node, "Directives")), " ")
-			selectionSet := getMapValueString(node, "SelectionSet")
-			str := ""
-			if name == "" && directives == "" && varDefs == "" && op == ast.OperationTypeQuery {
-				str = selectionSet
-			} else {
-				str = join([]string{
-					op,
-					join([]string{name, varDefs}, ""),
-					directives,
-					selectionSet,
-				}, " ")
+// isPrintableAsBlockString tells if value is the value of a block string holding it, as given
+// by the BlockStringValue algorithm of the spec: it has no control characters other than tabs
+// and new lines, no carriage returns, no leading nor trailing blank lines, and its lines aren't
+// all indented.
+func isPrintableAsBlockString(value string) bool {
+	if value == "" {
+		return true
+	}
+	isEmptyLine := true
+	hasIndent := false
+	hasCommonIndent := true
+	seenNonEmptyLine := false
+	for _, r := range value {
+		switch {
+		case r == '\n':
+			if isEmptyLine && !seenNonEmptyLine {
+				// leading blank line
+				return false
 			}
-			return visitor.ActionUpdate, str
+			seenNonEmptyLine = true
+			isEmptyLine = true
+			hasIndent = false
+		case r == ' ' || r == '\t':
+			hasIndent = hasIndent || isEmptyLine
+		case r < 0x20:
+			// carriage returns are normalized, and other control characters can't be in blocks
+			return false
+		default:
+			hasCommonIndent = hasCommonIndent && hasIndent
+			isEmptyLine = false
 		}
-		return visitor.ActionNoChange, nil
-	},
-	"VariableDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.VariableDefinition:
-			variable := fmt.Sprintf("%v", node.Variable)
-			ttype := fmt.Sprintf("%v", node.Type)
-			defaultValue := fmt.Sprintf("%v", node.DefaultValue)
-
-			return visitor.ActionUpdate, variable + ": " + ttype + wrap(" = ", defaultValue, "")
-		case map[string]interface{}:
+	}
+	if isEmptyLine {
+		// trailing blank line
+		return false
+	}
+	return !hasCommonIndent || !seenNonEmptyLine
+}
 
-			variable := getMapValueString(node, "Variable")
-			ttype := getMapValueString(node, "Type")
-			defaultValue := getMapValueString(node, "DefaultValue")
+// newPrintDocASTReducer returns the visit functions printing each kind of node, indenting the
+// blocks with indentation, and printing their comments unless omitComments is set.
+func newPrintDocASTReducer(indentation string, omitComments bool) map[string]visitor.VisitFunc {
+	block := func(maybeArray interface{}) string { return block(maybeArray, indentation) }
+	indent := func(maybeString interface{}) string { return indent(maybeString, indentation) }
 
-			return visitor.ActionUpdate, variable + ": " + ttype + wrap(" = ", defaultValue, "")
+	reducer := map[string]visitor.VisitFunc{
+		"Name": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.Name:
+				return visitor.ActionUpdate, node.Value
+			case map[string]interface{}:
+				return visitor.ActionUpdate, getMapValue(node, "Value")
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"Variable": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.Variable:
+				return visitor.ActionUpdate, fmt.Sprintf("$%v", node.Name)
+			case map[string]interface{}:
+				return visitor.ActionUpdate, "$" + getMapValueString(node, "Name")
+			}
+			return visitor.ActionNoChange, nil
+		},
 
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"SelectionSet": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.SelectionSet:
-			str := block(node.Selections)
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			selections := getMapValue(node, "Selections")
-			str := block(selections)
-			return visitor.ActionUpdate, str
+		// Document
+		"Document": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.Document:
+				definitions := toSliceString(node.Definitions)
+				return visitor.ActionUpdate, join(definitions, "\n\n") + "\n"
+			case map[string]interface{}:
+				definitions := toSliceString(getMapValue(node, "Definitions"))
+				return visitor.ActionUpdate, join(definitions, "\n\n") + "\n"
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"OperationDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.OperationDefinition:
+				op := string(node.Operation)
+				name := fmt.Sprintf("%v", node.Name)
 
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"Field": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.Argument:
-			name := fmt.Sprintf("%v", node.Name)
-			value := fmt.Sprintf("%v", node.Value)
-			return visitor.ActionUpdate, name + ": " + value
-		case map[string]interface{}:
+				varDefs := wrap("(", join(toSliceString(node.VariableDefinitions), ", "), ")")
+				directives := join(toSliceString(node.Directives), " ")
+				selectionSet := fmt.Sprintf("%v", node.SelectionSet)
+				// Anonymous queries with no directives or variable definitions can use
+				// the query short form.
+				str := ""
+				if name == "" && directives == "" && varDefs == "" && op == ast.OperationTypeQuery {
+					str = selectionSet
+				} else {
+					str = join([]string{
+						op,
+						join([]string{name, varDefs}, ""),
+						directives,
+						selectionSet,
+					}, " ")
+				}
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+
+				op := getMapValueString(node, "Operation")
+				name := getMapValueString(node, "Name")
+
+				varDefs := wrap("(", join(toSliceString(getMapValue(node, "VariableDefinitions")), ", "), ")")
+				directives := join(toSliceString(getMapValue(node, "Directives")), " ")
+				selectionSet := getMapValueString(node, "SelectionSet")
+				str := ""
+				if name == "" && directives == "" && varDefs == "" && op == ast.OperationTypeQuery {
+					str = selectionSet
+				} else {
+					str = join([]string{
+						op,
+						join([]string{name, varDefs}, ""),
+						directives,
+						selectionSet,
+					}, " ")
+				}
+				return visitor.ActionUpdate, str
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"VariableDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.VariableDefinition:
+				variable := fmt.Sprintf("%v", node.Variable)
+				ttype := fmt.Sprintf("%v", node.Type)
+				defaultValue := fmt.Sprintf("%v", node.DefaultValue)
+
+				return visitor.ActionUpdate, variable + ": " + ttype + wrap(" = ", defaultValue, "")
+			case map[string]interface{}:
+
+				variable := getMapValueString(node, "Variable")
+				ttype := getMapValueString(node, "Type")
+				defaultValue := getMapValueString(node, "DefaultValue")
+
+				return visitor.ActionUpdate, variable + ": " + ttype + wrap(" = ", defaultValue, "")
+
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"SelectionSet": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.SelectionSet:
+				str := block(node.Selections)
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				selections := getMapValue(node, "Selections")
+				str := block(selections)
+				return visitor.ActionUpdate, str
+
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"Field": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.Argument:
+				name := fmt.Sprintf("%v", node.Name)
+				value := fmt.Sprintf("%v", node.Value)
+				return visitor.ActionUpdate, name + ": " + value
+			case map[string]interface{}:
+
+				alias := getMapValueString(node, "Alias")
+				name := getMapValueString(node, "Name")
+				args := toSliceString(getMapValue(node, "Arguments"))
+				directives := toSliceString(getMapValue(node, "Directives"))
+				selectionSet := getMapValueString(node, "SelectionSet")
+
+				str := join(
+					[]string{
+						wrap("", alias, ": ") + name + wrap("(", join(args, ", "), ")"),
+						join(directives, " "),
+						selectionSet,
+					},
+					" ",
+				)
+				return visitor.ActionUpdate, str
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"Argument": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.FragmentSpread:
+				name := fmt.Sprintf("%v", node.Name)
+				directives := toSliceString(node.Directives)
+				return visitor.ActionUpdate, "..." + name + wrap(" ", join(directives, " "), "")
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				value := getMapValueString(node, "Value")
+				return visitor.ActionUpdate, name + ": " + value
+			}
+			return visitor.ActionNoChange, nil
+		},
+
+		// Fragments
+		"FragmentSpread": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.InlineFragment:
+				typeCondition := fmt.Sprintf("%v", node.TypeCondition)
+				directives := toSliceString(node.Directives)
+				selectionSet := fmt.Sprintf("%v", node.SelectionSet)
+				return visitor.ActionUpdate, "... on " + typeCondition + " " + wrap("", join(directives, " "), " ") + selectionSet
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				directives := toSliceString(getMapValue(node, "Directives"))
+				return visitor.ActionUpdate, "..." + name + wrap(" ", join(directives, " "), "")
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"InlineFragment": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case map[string]interface{}:
+				typeCondition := getMapValueString(node, "TypeCondition")
+				directives := toSliceString(getMapValue(node, "Directives"))
+				selectionSet := getMapValueString(node, "SelectionSet")
+				return visitor.ActionUpdate,
+					join([]string{
+						"...",
+						wrap("on ", typeCondition, ""),
+						join(directives, " "),
+						selectionSet,
+					}, " ")
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"FragmentDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.FragmentDefinition:
+				name := fmt.Sprintf("%v", node.Name)
+				typeCondition := fmt.Sprintf("%v", node.TypeCondition)
+				directives := toSliceString(node.Directives)
+				selectionSet := fmt.Sprintf("%v", node.SelectionSet)
+				return visitor.ActionUpdate, "fragment " + name + " on " + typeCondition + " " + wrap("", join(directives, " "), " ") + selectionSet
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				typeCondition := getMapValueString(node, "TypeCondition")
+				directives := toSliceString(getMapValue(node, "Directives"))
+				selectionSet := getMapValueString(node, "SelectionSet")
+				return visitor.ActionUpdate, "fragment " + name + " on " + typeCondition + " " + wrap("", join(directives, " "), " ") + selectionSet
+			}
+			return visitor.ActionNoChange, nil
+		},
+
+		// Value
+		"IntValue": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.IntValue:
+				return visitor.ActionUpdate, fmt.Sprintf("%v", node.Value)
+			case map[string]interface{}:
+				return visitor.ActionUpdate, getMapValueString(node, "Value")
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"FloatValue": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.FloatValue:
+				return visitor.ActionUpdate, fmt.Sprintf("%v", node.Value)
+			case map[string]interface{}:
+				return visitor.ActionUpdate, getMapValueString(node, "Value")
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"StringValue": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.StringValue:
+				if node.Block {
+					return visitor.ActionUpdate, printBlockString(node.Value)
+				}
+				return visitor.ActionUpdate, printString(node.Value)
+			case map[string]interface{}:
+				if block, _ := getMapValue(node, "Block").(bool); block {
+					return visitor.ActionUpdate, printBlockString(getMapValueString(node, "Value"))
+				}
+				return visitor.ActionUpdate, printString(getMapValueString(node, "Value"))
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"BooleanValue": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.BooleanValue:
+				return visitor.ActionUpdate, fmt.Sprintf("%v", node.Value)
+			case map[string]interface{}:
+				return visitor.ActionUpdate, getMapValueString(node, "Value")
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"NullValue": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch p.Node.(type) {
+			case *ast.NullValue, map[string]interface{}:
+				return visitor.ActionUpdate, "null"
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"EnumValue": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.EnumValue:
+				return visitor.ActionUpdate, fmt.Sprintf("%v", node.Value)
+			case map[string]interface{}:
+				return visitor.ActionUpdate, getMapValueString(node, "Value")
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"ListValue": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.ListValue:
+				return visitor.ActionUpdate, "[" + join(toSliceString(node.Values), ", ") + "]"
+			case map[string]interface{}:
+				return visitor.ActionUpdate, "[" + join(toSliceString(getMapValue(node, "Values")), ", ") + "]"
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"ObjectValue": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.ObjectValue:
+				return visitor.ActionUpdate, "{" + join(toSliceString(node.Fields), ", ") + "}"
+			case map[string]interface{}:
+				return visitor.ActionUpdate, "{" + join(toSliceString(getMapValue(node, "Fields")), ", ") + "}"
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"ObjectField": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.ObjectField:
+				name := fmt.Sprintf("%v", node.Name)
+				value := fmt.Sprintf("%v", node.Value)
+				return visitor.ActionUpdate, name + ": " + value
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				value := getMapValueString(node, "Value")
+				return visitor.ActionUpdate, name + ": " + value
+			}
+			return visitor.ActionNoChange, nil
+		},
+
+		// Directive
+		"Directive": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.Directive:
+				name := fmt.Sprintf("%v", node.Name)
+				args := toSliceString(node.Arguments)
+				return visitor.ActionUpdate, "@" + name + wrap("(", join(args, ", "), ")")
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				args := toSliceString(getMapValue(node, "Arguments"))
+				return visitor.ActionUpdate, "@" + name + wrap("(", join(args, ", "), ")")
+			}
+			return visitor.ActionNoChange, nil
+		},
 
-			alias := getMapValueString(node, "Alias")
-			name := getMapValueString(node, "Name")
-			args := toSliceString(getMapValue(node, "Arguments"))
-			directives := toSliceString(getMapValue(node, "Directives"))
-			selectionSet := getMapValueString(node, "SelectionSet")
+		// Type
+		"Named": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.Named:
+				return visitor.ActionUpdate, fmt.Sprintf("%v", node.Name)
+			case map[string]interface{}:
+				return visitor.ActionUpdate, getMapValueString(node, "Name")
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"List": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.List:
+				return visitor.ActionUpdate, "[" + fmt.Sprintf("%v", node.Type) + "]"
+			case map[string]interface{}:
+				return visitor.ActionUpdate, "[" + getMapValueString(node, "Type") + "]"
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"NonNull": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.NonNull:
+				return visitor.ActionUpdate, fmt.Sprintf("%v", node.Type) + "!"
+			case map[string]interface{}:
+				return visitor.ActionUpdate, getMapValueString(node, "Type") + "!"
+			}
+			return visitor.ActionNoChange, nil
+		},
 
-			str := join(
-				[]string{
-					wrap("", alias, ": ") + name + wrap("(", join(args, ", "), ")"),
+		// Type System Definitions
+		"SchemaDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.SchemaDefinition:
+				directives := []string{}
+				for _, directive := range node.Directives {
+					directives = append(directives, fmt.Sprintf("%v", directive.Name))
+				}
+				str := join([]string{
+					"schema",
 					join(directives, " "),
-					selectionSet,
-				},
-				" ",
-			)
-			return visitor.ActionUpdate, str
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"Argument": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.FragmentSpread:
-			name := fmt.Sprintf("%v", node.Name)
-			directives := toSliceString(node.Directives)
-			return visitor.ActionUpdate, "..." + name + wrap(" ", join(directives, " "), "")
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			value := getMapValueString(node, "Value")
-			return visitor.ActionUpdate, name + ": " + value
-		}
-		return visitor.ActionNoChange, nil
-	},
-
-	// Fragments
-	"FragmentSpread": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.InlineFragment:
-			typeCondition := fmt.Sprintf("%v", node.TypeCondition)
-			directives := toSliceString(node.Directives)
-			selectionSet := fmt.Sprintf("%v", node.SelectionSet)
-			return visitor.ActionUpdate, "... on " + typeCondition + " " + wrap("", join(directives, " "), " ") + selectionSet
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			directives := toSliceString(getMapValue(node, "Directives"))
-			return visitor.ActionUpdate, "..." + name + wrap(" ", join(directives, " "), "")
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"InlineFragment": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case map[string]interface{}:
-			typeCondition := getMapValueString(node, "TypeCondition")
-			directives := toSliceString(getMapValue(node, "Directives"))
-			selectionSet := getMapValueString(node, "SelectionSet")
-			return visitor.ActionUpdate,
-				join([]string{
-					"...",
-					wrap("on ", typeCondition, ""),
+					block(node.OperationTypes),
+				}, " ")
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				operationTypes := toSliceString(getMapValue(node, "OperationTypes"))
+				directives := []string{}
+				for _, directive := range getMapSliceValue(node, "Directives") {
+					directives = append(directives, fmt.Sprintf("%v", directive))
+				}
+				str := join([]string{
+					"schema",
 					join(directives, " "),
-					selectionSet,
+					block(operationTypes),
 				}, " ")
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"FragmentDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.FragmentDefinition:
-			name := fmt.Sprintf("%v", node.Name)
-			typeCondition := fmt.Sprintf("%v", node.TypeCondition)
-			directives := toSliceString(node.Directives)
-			selectionSet := fmt.Sprintf("%v", node.SelectionSet)
-			return visitor.ActionUpdate, "fragment " + name + " on " + typeCondition + " " + wrap("", join(directives, " "), " ") + selectionSet
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			typeCondition := getMapValueString(node, "TypeCondition")
-			directives := toSliceString(getMapValue(node, "Directives"))
-			selectionSet := getMapValueString(node, "SelectionSet")
-			return visitor.ActionUpdate, "fragment " + name + " on " + typeCondition + " " + wrap("", join(directives, " "), " ") + selectionSet
-		}
-		return visitor.ActionNoChange, nil
-	},
-
-	// Value
-	"IntValue": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.IntValue:
-			return visitor.ActionUpdate, fmt.Sprintf("%v", node.Value)
-		case map[string]interface{}:
-			return visitor.ActionUpdate, getMapValueString(node, "Value")
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"FloatValue": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.FloatValue:
-			return visitor.ActionUpdate, fmt.Sprintf("%v", node.Value)
-		case map[string]interface{}:
-			return visitor.ActionUpdate, getMapValueString(node, "Value")
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"StringValue": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.StringValue:
-			return visitor.ActionUpdate, strconv.Quote(node.Value)
-		case map[string]interface{}:
-			return visitor.ActionUpdate, `"` + getMapValueString(node, "Value") + `"`
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"BooleanValue": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.BooleanValue:
-			return visitor.ActionUpdate, fmt.Sprintf("%v", node.Value)
-		case map[string]interface{}:
-			return visitor.ActionUpdate, getMapValueString(node, "Value")
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"EnumValue": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.EnumValue:
-			return visitor.ActionUpdate, fmt.Sprintf("%v", node.Value)
-		case map[string]interface{}:
-			return visitor.ActionUpdate, getMapValueString(node, "Value")
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"ListValue": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.ListValue:
-			return visitor.ActionUpdate, "[" + join(toSliceString(node.Values), ", ") + "]"
-		case map[string]interface{}:
-			return visitor.ActionUpdate, "[" + join(toSliceString(getMapValue(node, "Values")), ", ") + "]"
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"ObjectValue": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.ObjectValue:
-			return visitor.ActionUpdate, "{" + join(toSliceString(node.Fields), ", ") + "}"
-		case map[string]interface{}:
-			return visitor.ActionUpdate, "{" + join(toSliceString(getMapValue(node, "Fields")), ", ") + "}"
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"ObjectField": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.ObjectField:
-			name := fmt.Sprintf("%v", node.Name)
-			value := fmt.Sprintf("%v", node.Value)
-			return visitor.ActionUpdate, name + ": " + value
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			value := getMapValueString(node, "Value")
-			return visitor.ActionUpdate, name + ": " + value
-		}
-		return visitor.ActionNoChange, nil
-	},
-
-	// Directive
-	"Directive": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.Directive:
-			name := fmt.Sprintf("%v", node.Name)
-			args := toSliceString(node.Arguments)
-			return visitor.ActionUpdate, "@" + name + wrap("(", join(args, ", "), ")")
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			args := toSliceString(getMapValue(node, "Arguments"))
-			return visitor.ActionUpdate, "@" + name + wrap("(", join(args, ", "), ")")
-		}
-		return visitor.ActionNoChange, nil
-	},
-
-	// Type
-	"Named": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.Named:
-			return visitor.ActionUpdate, fmt.Sprintf("%v", node.Name)
-		case map[string]interface{}:
-			return visitor.ActionUpdate, getMapValueString(node, "Name")
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"List": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.List:
-			return visitor.ActionUpdate, "[" + fmt.Sprintf("%v", node.Type) + "]"
-		case map[string]interface{}:
-			return visitor.ActionUpdate, "[" + getMapValueString(node, "Type") + "]"
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"NonNull": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.NonNull:
-			return visitor.ActionUpdate, fmt.Sprintf("%v", node.Type) + "!"
-		case map[string]interface{}:
-			return visitor.ActionUpdate, getMapValueString(node, "Type") + "!"
-		}
-		return visitor.ActionNoChange, nil
-	},
-
-	// Type System Definitions
-	"SchemaDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.SchemaDefinition:
-			directives := []string{}
-			for _, directive := range node.Directives {
-				directives = append(directives, fmt.Sprintf("%v", directive.Name))
-			}
-			str := join([]string{
-				"schema",
-				join(directives, " "),
-				block(node.OperationTypes),
-			}, " ")
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			operationTypes := toSliceString(getMapValue(node, "OperationTypes"))
-			directives := []string{}
-			for _, directive := range getMapSliceValue(node, "Directives") {
-				directives = append(directives, fmt.Sprintf("%v", directive))
-			}
-			str := join([]string{
-				"schema",
-				join(directives, " "),
-				block(operationTypes),
-			}, " ")
-			return visitor.ActionUpdate, str
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"OperationTypeDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.OperationTypeDefinition:
-			str := fmt.Sprintf("%v: %v", node.Operation, node.Type)
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			operation := getMapValueString(node, "Operation")
-			ttype := getMapValueString(node, "Type")
-			str := fmt.Sprintf("%v: %v", operation, ttype)
-			return visitor.ActionUpdate, str
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"ScalarDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.ScalarDefinition:
-			directives := []string{}
-			for _, directive := range node.Directives {
-				directives = append(directives, fmt.Sprintf("%v", directive.Name))
-			}
-			str := join([]string{
-				"scalar",
-				fmt.Sprintf("%v", node.Name),
-				join(directives, " "),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			directives := []string{}
-			for _, directive := range getMapSliceValue(node, "Directives") {
-				directives = append(directives, fmt.Sprintf("%v", directive))
-			}
-			str := join([]string{
-				"scalar",
-				name,
-				join(directives, " "),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"ObjectDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.ObjectDefinition:
-			name := fmt.Sprintf("%v", node.Name)
-			interfaces := toSliceString(node.Interfaces)
-			fields := node.Fields
-			directives := []string{}
-			for _, directive := range node.Directives {
-				directives = append(directives, fmt.Sprintf("%v", directive.Name))
-			}
-			str := join([]string{
-				"type",
-				name,
-				wrap("implements ", join(interfaces, " & "), ""),
-				join(directives, " "),
-				block(fields),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			interfaces := toSliceString(getMapValue(node, "Interfaces"))
-			fields := getMapValue(node, "Fields")
-			directives := []string{}
-			for _, directive := range getMapSliceValue(node, "Directives") {
-				directives = append(directives, fmt.Sprintf("%v", directive))
-			}
-			str := join([]string{
-				"type",
-				name,
-				wrap("implements ", join(interfaces, " & "), ""),
-				join(directives, " "),
-				block(fields),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"FieldDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.FieldDefinition:
-			name := fmt.Sprintf("%v", node.Name)
-			ttype := fmt.Sprintf("%v", node.Type)
-			args := toSliceString(node.Arguments)
-			directives := []string{}
-			for _, directive := range node.Directives {
-				directives = append(directives, fmt.Sprintf("%v", directive.Name))
-			}
-			hasArgDesc := false
-			for _, arg := range node.Arguments {
-				if arg.Description != nil && arg.Description.Value != "" {
-					hasArgDesc = true
-					break
-				}
-			}
-			var argsStr string
-			if hasArgDesc {
-				argsStr = wrap("(", indent("\n"+join(args, "\n")), "\n)")
-			} else {
-				argsStr = wrap("(", join(args, ", "), ")")
+				return visitor.ActionUpdate, str
 			}
-			str := name + argsStr + ": " + ttype + wrap(" ", join(directives, " "), "")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("\n%s\n%s", desc, str)
+			return visitor.ActionNoChange, nil
+		},
+		"OperationTypeDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.OperationTypeDefinition:
+				str := fmt.Sprintf("%v: %v", node.Operation, node.Type)
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				operation := getMapValueString(node, "Operation")
+				ttype := getMapValueString(node, "Type")
+				str := fmt.Sprintf("%v: %v", operation, ttype)
+				return visitor.ActionUpdate, str
 			}
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			ttype := getMapValueString(node, "Type")
-			args := toSliceString(getMapValue(node, "Arguments"))
-			directives := []string{}
-			for _, directive := range getMapSliceValue(node, "Directives") {
-				directives = append(directives, fmt.Sprintf("%v", directive))
-			}
-			hasArgDesc := false
-			for _, arg := range args {
-				if strings.HasPrefix(strings.TrimSpace(arg), `"""`) {
-					hasArgDesc = true
-					break
-				}
-			}
-			var argsStr string
-			if hasArgDesc {
-				argsStr = wrap("(", indent("\n"+join(args, "\n")), "\n)")
-			} else {
-				argsStr = wrap("(", join(args, ", "), ")")
+			return visitor.ActionNoChange, nil
+		},
+		"ScalarDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.ScalarDefinition:
+				directives := []string{}
+				for _, directive := range node.Directives {
+					directives = append(directives, fmt.Sprintf("%v", directive.Name))
+				}
+				str := join([]string{
+					"scalar",
+					fmt.Sprintf("%v", node.Name),
+					join(directives, " "),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				directives := []string{}
+				for _, directive := range getMapSliceValue(node, "Directives") {
+					directives = append(directives, fmt.Sprintf("%v", directive))
+				}
+				str := join([]string{
+					"scalar",
+					name,
+					join(directives, " "),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
 			}
-			str := name + argsStr + ": " + ttype + wrap(" ", join(directives, " "), "")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("\n%s\n%s", desc, str)
+			return visitor.ActionNoChange, nil
+		},
+		"ObjectDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.ObjectDefinition:
+				name := fmt.Sprintf("%v", node.Name)
+				interfaces := toSliceString(node.Interfaces)
+				fields := node.Fields
+				directives := []string{}
+				for _, directive := range node.Directives {
+					directives = append(directives, fmt.Sprintf("%v", directive.Name))
+				}
+				str := join([]string{
+					"type",
+					name,
+					wrap("implements ", join(interfaces, " & "), ""),
+					join(directives, " "),
+					block(fields),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				interfaces := toSliceString(getMapValue(node, "Interfaces"))
+				fields := getMapValue(node, "Fields")
+				directives := []string{}
+				for _, directive := range getMapSliceValue(node, "Directives") {
+					directives = append(directives, fmt.Sprintf("%v", directive))
+				}
+				str := join([]string{
+					"type",
+					name,
+					wrap("implements ", join(interfaces, " & "), ""),
+					join(directives, " "),
+					block(fields),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
 			}
-			return visitor.ActionUpdate, str
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"InputValueDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.InputValueDefinition:
-			name := fmt.Sprintf("%v", node.Name)
-			ttype := fmt.Sprintf("%v", node.Type)
-			defaultValue := fmt.Sprintf("%v", node.DefaultValue)
-			directives := []string{}
-			for _, directive := range node.Directives {
-				directives = append(directives, fmt.Sprintf("%v", directive.Name))
-			}
-			str := join([]string{
-				name + ": " + ttype,
-				wrap("= ", defaultValue, ""),
-				join(directives, " "),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("\n%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			ttype := getMapValueString(node, "Type")
-			defaultValue := getMapValueString(node, "DefaultValue")
-			directives := []string{}
-			for _, directive := range getMapSliceValue(node, "Directives") {
-				directives = append(directives, fmt.Sprintf("%v", directive))
-			}
-			str := join([]string{
-				name + ": " + ttype,
-				wrap("= ", defaultValue, ""),
-				join(directives, " "),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("\n%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"InterfaceDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.InterfaceDefinition:
-			name := fmt.Sprintf("%v", node.Name)
-			fields := node.Fields
-			directives := []string{}
-			for _, directive := range node.Directives {
-				directives = append(directives, fmt.Sprintf("%v", directive.Name))
-			}
-			str := join([]string{
-				"interface",
-				name,
-				join(directives, " "),
-				block(fields),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			fields := getMapValue(node, "Fields")
-			directives := []string{}
-			for _, directive := range getMapSliceValue(node, "Directives") {
-				directives = append(directives, fmt.Sprintf("%v", directive))
-			}
-			str := join([]string{
-				"interface",
-				name,
-				join(directives, " "),
-				block(fields),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"UnionDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.UnionDefinition:
-			name := fmt.Sprintf("%v", node.Name)
-			types := toSliceString(node.Types)
-			directives := []string{}
-			for _, directive := range node.Directives {
-				directives = append(directives, fmt.Sprintf("%v", directive.Name))
-			}
-			str := join([]string{
-				"union",
-				name,
-				join(directives, " "),
-				"= " + join(types, " | "),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			types := toSliceString(getMapValue(node, "Types"))
-			directives := []string{}
-			for _, directive := range getMapSliceValue(node, "Directives") {
-				directives = append(directives, fmt.Sprintf("%v", directive))
-			}
-			str := join([]string{
-				"union",
-				name,
-				join(directives, " "),
-				"= " + join(types, " | "),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		}
-		return visitor.ActionNoChange, nil
-	},
-	"EnumDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.EnumDefinition:
-			name := fmt.Sprintf("%v", node.Name)
-			values := node.Values
-			directives := []string{}
-			for _, directive := range node.Directives {
-				directives = append(directives, fmt.Sprintf("%v", directive.Name))
-			}
-			str := join([]string{
-				"enum",
-				name,
-				join(directives, " "),
-				block(values),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			values := getMapValue(node, "Values")
-			directives := []string{}
-			for _, directive := range getMapSliceValue(node, "Directives") {
-				directives = append(directives, fmt.Sprintf("%v", directive))
-			}
-			str := join([]string{
-				"enum",
-				name,
-				join(directives, " "),
-				block(values),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
+			return visitor.ActionNoChange, nil
+		},
+		"FieldDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.FieldDefinition:
+				name := fmt.Sprintf("%v", node.Name)
+				ttype := fmt.Sprintf("%v", node.Type)
+				args := toSliceString(node.Arguments)
+				directives := []string{}
+				for _, directive := range node.Directives {
+					directives = append(directives, fmt.Sprintf("%v", directive.Name))
+				}
+				hasArgDesc := false
+				for _, arg := range node.Arguments {
+					if arg.Description != nil && arg.Description.Value != "" {
+						hasArgDesc = true
+						break
+					}
+				}
+				var argsStr string
+				if hasArgDesc {
+					argsStr = wrap("(", indent("\n"+join(args, "\n")), "\n)")
+				} else {
+					argsStr = wrap("(", join(args, ", "), ")")
+				}
+				str := name + argsStr + ": " + ttype + wrap(" ", join(directives, " "), "")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("\n%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				ttype := getMapValueString(node, "Type")
+				args := toSliceString(getMapValue(node, "Arguments"))
+				directives := []string{}
+				for _, directive := range getMapSliceValue(node, "Directives") {
+					directives = append(directives, fmt.Sprintf("%v", directive))
+				}
+				hasArgDesc := false
+				for _, arg := range args {
+					if strings.HasPrefix(strings.TrimSpace(arg), `"""`) {
+						hasArgDesc = true
+						break
+					}
+				}
+				var argsStr string
+				if hasArgDesc {
+					argsStr = wrap("(", indent("\n"+join(args, "\n")), "\n)")
+				} else {
+					argsStr = wrap("(", join(args, ", "), ")")
+				}
+				str := name + argsStr + ": " + ttype + wrap(" ", join(directives, " "), "")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("\n%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"InputValueDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.InputValueDefinition:
+				name := fmt.Sprintf("%v", node.Name)
+				ttype := fmt.Sprintf("%v", node.Type)
+				defaultValue := fmt.Sprintf("%v", node.DefaultValue)
+				directives := []string{}
+				for _, directive := range node.Directives {
+					directives = append(directives, fmt.Sprintf("%v", directive.Name))
+				}
+				str := join([]string{
+					name + ": " + ttype,
+					wrap("= ", defaultValue, ""),
+					join(directives, " "),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("\n%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				ttype := getMapValueString(node, "Type")
+				defaultValue := getMapValueString(node, "DefaultValue")
+				directives := []string{}
+				for _, directive := range getMapSliceValue(node, "Directives") {
+					directives = append(directives, fmt.Sprintf("%v", directive))
+				}
+				str := join([]string{
+					name + ": " + ttype,
+					wrap("= ", defaultValue, ""),
+					join(directives, " "),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("\n%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"InterfaceDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.InterfaceDefinition:
+				name := fmt.Sprintf("%v", node.Name)
+				fields := node.Fields
+				directives := []string{}
+				for _, directive := range node.Directives {
+					directives = append(directives, fmt.Sprintf("%v", directive.Name))
+				}
+				str := join([]string{
+					"interface",
+					name,
+					join(directives, " "),
+					block(fields),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				fields := getMapValue(node, "Fields")
+				directives := []string{}
+				for _, directive := range getMapSliceValue(node, "Directives") {
+					directives = append(directives, fmt.Sprintf("%v", directive))
+				}
+				str := join([]string{
+					"interface",
+					name,
+					join(directives, " "),
+					block(fields),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"UnionDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.UnionDefinition:
+				name := fmt.Sprintf("%v", node.Name)
+				types := toSliceString(node.Types)
+				directives := []string{}
+				for _, directive := range node.Directives {
+					directives = append(directives, fmt.Sprintf("%v", directive.Name))
+				}
+				str := join([]string{
+					"union",
+					name,
+					join(directives, " "),
+					"= " + join(types, " | "),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				types := toSliceString(getMapValue(node, "Types"))
+				directives := []string{}
+				for _, directive := range getMapSliceValue(node, "Directives") {
+					directives = append(directives, fmt.Sprintf("%v", directive))
+				}
+				str := join([]string{
+					"union",
+					name,
+					join(directives, " "),
+					"= " + join(types, " | "),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"EnumDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.EnumDefinition:
+				name := fmt.Sprintf("%v", node.Name)
+				values := node.Values
+				directives := []string{}
+				for _, directive := range node.Directives {
+					directives = append(directives, fmt.Sprintf("%v", directive.Name))
+				}
+				str := join([]string{
+					"enum",
+					name,
+					join(directives, " "),
+					block(values),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				values := getMapValue(node, "Values")
+				directives := []string{}
+				for _, directive := range getMapSliceValue(node, "Directives") {
+					directives = append(directives, fmt.Sprintf("%v", directive))
+				}
+				str := join([]string{
+					"enum",
+					name,
+					join(directives, " "),
+					block(values),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"EnumValueDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.EnumValueDefinition:
+				name := fmt.Sprintf("%v", node.Name)
+				directives := []string{}
+				for _, directive := range node.Directives {
+					directives = append(directives, fmt.Sprintf("%v", directive.Name))
+				}
+				str := join([]string{
+					name,
+					join(directives, " "),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("\n%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				directives := []string{}
+				for _, directive := range getMapSliceValue(node, "Directives") {
+					directives = append(directives, fmt.Sprintf("%v", directive))
+				}
+				str := join([]string{
+					name,
+					join(directives, " "),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("\n%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"InputObjectDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.InputObjectDefinition:
+				name := fmt.Sprintf("%v", node.Name)
+				fields := node.Fields
+				directives := []string{}
+				for _, directive := range node.Directives {
+					directives = append(directives, fmt.Sprintf("%v", directive.Name))
+				}
+				str := join([]string{
+					"input",
+					name,
+					join(directives, " "),
+					block(fields),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				fields := getMapValue(node, "Fields")
+				directives := []string{}
+				for _, directive := range getMapSliceValue(node, "Directives") {
+					directives = append(directives, fmt.Sprintf("%v", directive))
+				}
+				str := join([]string{
+					"input",
+					name,
+					join(directives, " "),
+					block(fields),
+				}, " ")
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"TypeExtensionDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.TypeExtensionDefinition:
+				definition := fmt.Sprintf("%v", node.Definition)
+				str := "extend " + definition
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				definition := getMapValueString(node, "Definition")
+				str := "extend " + definition
+				return visitor.ActionUpdate, str
+			}
+			return visitor.ActionNoChange, nil
+		},
+		"DirectiveDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
+			switch node := p.Node.(type) {
+			case *ast.DirectiveDefinition:
+				args := toSliceString(node.Arguments)
+				hasArgDesc := false
+				for _, arg := range node.Arguments {
+					if arg.Description != nil && arg.Description.Value != "" {
+						hasArgDesc = true
+						break
+					}
+				}
+				var argsStr string
+				if hasArgDesc {
+					argsStr = wrap("(", indent("\n"+join(args, "\n")), "\n)")
+				} else {
+					argsStr = wrap("(", join(args, ", "), ")")
+				}
+				str := fmt.Sprintf("directive @%v%v on %v", node.Name, argsStr, join(toSliceString(node.Locations), " | "))
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			case map[string]interface{}:
+				name := getMapValueString(node, "Name")
+				locations := toSliceString(getMapValue(node, "Locations"))
+				args := toSliceString(getMapValue(node, "Arguments"))
+				hasArgDesc := false
+				for _, arg := range args {
+					if strings.HasPrefix(strings.TrimSpace(arg), `"""`) {
+						hasArgDesc = true
+						break
+					}
+				}
+				var argsStr string
+				if hasArgDesc {
+					argsStr = wrap("(", indent("\n"+join(args, "\n")), "\n)")
+				} else {
+					argsStr = wrap("(", join(args, ", "), ")")
+				}
+				str := fmt.Sprintf("directive @%v%v on %v", name, argsStr, join(locations, " | "))
+				if desc := getDescription(node); desc != "" {
+					str = fmt.Sprintf("%s\n%s", desc, str)
+				}
+				return visitor.ActionUpdate, str
+			}
+			return visitor.ActionNoChange, nil
+		},
+	}
+	if omitComments {
+		return reducer
+	}
+	for _, kind := range commentedKinds {
+		reducer[kind] = withComments(reducer[kind])
+	}
+	return reducer
+}
+
+// commentedKinds are the kinds of the nodes which can hold comments, as parsed with the
+// KeepComments option.
+var commentedKinds = []string{
+	kinds.OperationDefinition,
+	kinds.FragmentDefinition,
+	kinds.TypeExtensionDefinition,
+	kinds.DirectiveDefinition,
+	kinds.Field,
+	kinds.FragmentSpread,
+	kinds.InlineFragment,
+	kinds.SchemaDefinition,
+	kinds.ScalarDefinition,
+	kinds.ObjectDefinition,
+	kinds.FieldDefinition,
+	kinds.InputValueDefinition,
+	kinds.InterfaceDefinition,
+	kinds.UnionDefinition,
+	kinds.EnumDefinition,
+	kinds.EnumValueDefinition,
+	kinds.InputObjectDefinition,
+}
+
+// withComments wraps fn to print the comments of the node, one per line, before the node and
+// after the blank line separating it from the previous one.
+func withComments(fn visitor.VisitFunc) visitor.VisitFunc {
+	return func(p visitor.VisitFuncParams) (string, interface{}) {
+		comments := getComments(p.Node)
+		action, result := fn(p)
+		str, ok := result.(string)
+		if len(comments) == 0 || action != visitor.ActionUpdate || !ok {
+			return action, result
 		}
-		return visitor.ActionNoChange, nil
-	},
-	"EnumValueDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.EnumValueDefinition:
-			name := fmt.Sprintf("%v", node.Name)
-			directives := []string{}
-			for _, directive := range node.Directives {
-				directives = append(directives, fmt.Sprintf("%v", directive.Name))
-			}
-			str := join([]string{
-				name,
-				join(directives, " "),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("\n%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			directives := []string{}
-			for _, directive := range getMapSliceValue(node, "Directives") {
-				directives = append(directives, fmt.Sprintf("%v", directive))
-			}
-			str := join([]string{
-				name,
-				join(directives, " "),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("\n%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
+		prefix := ""
+		if strings.HasPrefix(str, "\n") {
+			prefix, str = "\n", str[1:]
 		}
-		return visitor.ActionNoChange, nil
-	},
-	"InputObjectDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.InputObjectDefinition:
-			name := fmt.Sprintf("%v", node.Name)
-			fields := node.Fields
-			directives := []string{}
-			for _, directive := range node.Directives {
-				directives = append(directives, fmt.Sprintf("%v", directive.Name))
-			}
-			str := join([]string{
-				"input",
-				name,
-				join(directives, " "),
-				block(fields),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			fields := getMapValue(node, "Fields")
-			directives := []string{}
-			for _, directive := range getMapSliceValue(node, "Directives") {
-				directives = append(directives, fmt.Sprintf("%v", directive))
-			}
-			str := join([]string{
-				"input",
-				name,
-				join(directives, " "),
-				block(fields),
-			}, " ")
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
+		for _, comment := range comments {
+			prefix += "#" + comment + "\n"
 		}
-		return visitor.ActionNoChange, nil
-	},
-	"TypeExtensionDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.TypeExtensionDefinition:
-			definition := fmt.Sprintf("%v", node.Definition)
-			str := "extend " + definition
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			definition := getMapValueString(node, "Definition")
-			str := "extend " + definition
-			return visitor.ActionUpdate, str
+		return action, prefix + str
+	}
+}
+
+func getComments(raw interface{}) []string {
+	comments := []string{}
+	switch node := raw.(type) {
+	case ast.CommentedNode:
+		for _, comment := range node.GetComments() {
+			comments = append(comments, comment.Value)
 		}
-		return visitor.ActionNoChange, nil
-	},
-	"DirectiveDefinition": func(p visitor.VisitFuncParams) (string, interface{}) {
-		switch node := p.Node.(type) {
-		case *ast.DirectiveDefinition:
-			args := toSliceString(node.Arguments)
-			hasArgDesc := false
-			for _, arg := range node.Arguments {
-				if arg.Description != nil && arg.Description.Value != "" {
-					hasArgDesc = true
-					break
-				}
-			}
-			var argsStr string
-			if hasArgDesc {
-				argsStr = wrap("(", indent("\n"+join(args, "\n")), "\n)")
-			} else {
-				argsStr = wrap("(", join(args, ", "), ")")
-			}
-			str := fmt.Sprintf("directive @%v%v on %v", node.Name, argsStr, join(toSliceString(node.Locations), " | "))
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
-			}
-			return visitor.ActionUpdate, str
-		case map[string]interface{}:
-			name := getMapValueString(node, "Name")
-			locations := toSliceString(getMapValue(node, "Locations"))
-			args := toSliceString(getMapValue(node, "Arguments"))
-			hasArgDesc := false
-			for _, arg := range args {
-				if strings.HasPrefix(strings.TrimSpace(arg), `"""`) {
-					hasArgDesc = true
-					break
-				}
-			}
-			var argsStr string
-			if hasArgDesc {
-				argsStr = wrap("(", indent("\n"+join(args, "\n")), "\n)")
-			} else {
-				argsStr = wrap("(", join(args, ", "), ")")
-			}
-			str := fmt.Sprintf("directive @%v%v on %v", name, argsStr, join(locations, " | "))
-			if desc := getDescription(node); desc != "" {
-				str = fmt.Sprintf("%s\n%s", desc, str)
+	case map[string]interface{}:
+		for _, comment := range getMapSliceValue(node, "Comments") {
+			if comment, ok := comment.(map[string]interface{}); ok {
+				comments = append(comments, getMapValueString(comment, "Value"))
 			}
-			return visitor.ActionUpdate, str
 		}
-		return visitor.ActionNoChange, nil
-	},
+	}
+	return comments
 }
 
+var printDocASTReducer = newPrintDocASTReducer("  ", false)
+var uncommentedPrintDocASTReducer = newPrintDocASTReducer("  ", true)
+
+// Options configures how nodes are printed.
+type Options struct {
+	// IndentWidth is the number of spaces indenting each level of blocks, 2 if zero.
+	IndentWidth int
+
+	// OmitComments leaves out the comments of the nodes parsed with the KeepComments option.
+	OmitComments bool
+}
+
+// Print prints any node back to GraphQL, in the canonical format: the result doesn't depend on
+// the formatting nor on the locations of the source the node was parsed from, so parsing it again
+// gives an equal node, as told by ast.Equal.
 func Print(astNode ast.Node) (printed interface{}) {
+	return PrintWithOptions(astNode, Options{})
+}
+
+// PrintWithOptions is Print with the formatting configured by opts.
+func PrintWithOptions(astNode ast.Node, opts Options) (printed interface{}) {
 	defer func() interface{} {
 		if r := recover(); r != nil {
 			return fmt.Sprintf("%v", astNode)
 		}
 		return printed
 	}()
+	reducer := printDocASTReducer
+	if opts.OmitComments {
+		reducer = uncommentedPrintDocASTReducer
+	}
+	if opts.IndentWidth != 0 && opts.IndentWidth != 2 {
+		reducer = newPrintDocASTReducer(strings.Repeat(" ", opts.IndentWidth), opts.OmitComments)
+	}
 	printed = visitor.Visit(astNode, &visitor.VisitorOptions{
-		LeaveKindMap: printDocASTReducer,
+		LeaveKindMap: reducer,
 	}, nil)
 	return printed
 }
@@ -199,3 +199,159 @@ func TestPrinter_CorrectlyPrintsStringArgumentsWithProperQuoting(t *testing.T) {
 		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, results))
 	}
 }
+
+func TestPrinter_RoundTripsAllNodes(t *testing.T) {
+	for _, file := range []string{
+		"../../kitchen-sink.graphql",
+		"../../schema-kitchen-sink.graphql",
+		"../../schema-all-descriptions.graphql",
+	} {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("unable to load %s", file)
+		}
+		astDoc := parse(t, string(b))
+		printed := printer.Print(astDoc).(string)
+		reparsed := parse(t, printed)
+		if !ast.Equal(astDoc, reparsed) {
+			t.Fatalf("%s doesn't round-trip, printed:\n%s", file, printed)
+		}
+		if printedAgain := printer.Print(reparsed); printedAgain != printed {
+			t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(printed, printedAgain))
+		}
+	}
+}
+
+func TestPrinter_PrintsStringsWithEscapes(t *testing.T) {
+	query := `{ foo(a: "line\nbreak \"quoted\" back\\slash tab\t \u0001", b: """
+    block "string"
+      with \""" indentation
+  """, c: """ends with quote" """) }`
+	expected := `{
+  foo(a: "line\nbreak \"quoted\" back\\slash tab\t \u0001", b: """
+  block "string"
+    with \""" indentation
+  """, c: """ends with quote" """)
+}
+`
+	astDoc := parse(t, query)
+	results := printer.Print(astDoc)
+	if !reflect.DeepEqual(expected, results) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, results))
+	}
+	if !ast.Equal(astDoc, parse(t, results.(string))) {
+		t.Fatalf("printed strings don't round-trip:\n%s", results)
+	}
+}
+
+func TestPrinter_PrintWithOptions_IndentWidth(t *testing.T) {
+	astDoc := parse(t, `{ a { b { c } } }`)
+	expected := "{\n    a {\n        b {\n            c\n        }\n    }\n}\n"
+	results := printer.PrintWithOptions(astDoc, printer.Options{IndentWidth: 4})
+	if !reflect.DeepEqual(expected, results) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, results))
+	}
+}
+
+func TestEqual_IgnoresLocations(t *testing.T) {
+	withLocations, err := parser.Parse(parser.ParseParams{Source: `{ a(b: 1) }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ast.Equal(withLocations, parse(t, "{\n  a(b: 1)\n}")) {
+		t.Fatal("expected documents differing only by their locations to be equal")
+	}
+	if ast.Equal(withLocations, parse(t, `{ a(b: 2) }`)) {
+		t.Fatal("expected documents with different values not to be equal")
+	}
+}
+
+func TestPrinter_PrintsComments(t *testing.T) {
+	query := `# the hero query
+query Hero {
+  # the name
+  name
+  friends {
+    # nested
+    ... on Droid { primaryFunction }
+  }
+}
+
+# the enum
+enum Role {
+  # administrator
+  ADMIN
+  USER
+}
+`
+	expected := `# the hero query
+query Hero {
+  # the name
+  name
+  friends {
+    # nested
+    ... on Droid {
+      primaryFunction
+    }
+  }
+}
+
+# the enum
+enum Role {
+  # administrator
+  ADMIN
+  USER
+}
+`
+	astDoc, err := parser.Parse(parser.ParseParams{
+		Source:  query,
+		Options: parser.ParseOptions{NoLocation: true, KeepComments: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := printer.Print(astDoc)
+	if !reflect.DeepEqual(expected, results) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, results))
+	}
+}
+
+func TestPrinter_BlockStringsRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"simple",
+		"  leading whitespace",
+		"multi\nline",
+		"  first line indented\nsecond",
+		"  all lines\n  indented",
+		"\nleading blank line",
+		"trailing blank line\n",
+		"  \n",
+		"blank\n\nlines\n  \ninside",
+		`ends with quote"`,
+		`ends with backslash\`,
+		`contains """ and \""" quotes`,
+		"carriage\r\nreturn",
+		"tab\tand \u0001 control",
+	}
+	for _, value := range values {
+		astDoc := parse(t, `type Foo { bar(baz: String = "x"): String }`)
+		object := astDoc.Definitions[0].(*ast.ObjectDefinition)
+		object.Description = ast.NewStringValue(&ast.StringValue{Value: value, Block: true})
+		object.Fields[0].Description = ast.NewStringValue(&ast.StringValue{Value: value, Block: true})
+		object.Fields[0].Arguments[0].DefaultValue = ast.NewStringValue(&ast.StringValue{Value: value, Block: true})
+
+		printed := printer.Print(astDoc).(string)
+		reparsed := parse(t, printed).Definitions[0].(*ast.ObjectDefinition)
+		got := []string{reparsed.Fields[0].Arguments[0].DefaultValue.(*ast.StringValue).Value}
+		if value != "" {
+			// empty descriptions aren't printed
+			got = append(got, reparsed.Description.Value, reparsed.Fields[0].Description.Value)
+		}
+		for _, got := range got {
+			if got != value {
+				t.Fatalf("%q doesn't round-trip, got %q, printed:\n%s", value, got, printed)
+			}
+		}
+	}
+}
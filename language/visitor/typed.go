@@ -0,0 +1,114 @@
+package visitor
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// TypedVisitFunc is a visit function for the nodes of type T, e.g. *ast.Field. It returns an
+// action and its value like a VisitFunc, which are best built with Continue, Skip, Break, Replace
+// and Remove.
+type TypedVisitFunc[T ast.Node] func(node T, p VisitFuncParams) (string, interface{})
+
+// Typed is a visitor made of functions taking the nodes they visit with their concrete type. The
+// functions are registered with Enter and Leave, and the visitor is run with Apply, or with Visit
+// given its Options.
+//
+//	v := visitor.NewTyped()
+//	visitor.Enter(v, func(field *ast.Field, p visitor.VisitFuncParams) (string, interface{}) {
+//		if field.Name.Value == "password" {
+//			return visitor.Remove()
+//		}
+//		return visitor.Continue()
+//	})
+//	document = visitor.Apply(document, v).(*ast.Document)
+type Typed struct {
+	enter map[string]VisitFunc
+	leave map[string]VisitFunc
+}
+
+// NewTyped returns a typed visitor without functions.
+func NewTyped() *Typed {
+	return &Typed{
+		enter: map[string]VisitFunc{},
+		leave: map[string]VisitFunc{},
+	}
+}
+
+// Enter sets the function called by v when entering the nodes of type T, replacing the previous
+// one. It returns v.
+func Enter[T ast.Node](v *Typed, fn TypedVisitFunc[T]) *Typed {
+	v.enter[nodeKind[T]()] = typedVisitFunc(fn)
+	return v
+}
+
+// Leave sets the function called by v when leaving the nodes of type T, replacing the previous
+// one. It returns v.
+func Leave[T ast.Node](v *Typed, fn TypedVisitFunc[T]) *Typed {
+	v.leave[nodeKind[T]()] = typedVisitFunc(fn)
+	return v
+}
+
+// Options returns the options to give to Visit to run v.
+func (v *Typed) Options() *VisitorOptions {
+	return &VisitorOptions{
+		EnterKindMap: v.enter,
+		LeaveKindMap: v.leave,
+	}
+}
+
+// Apply visits root with v and returns it with the edits made by the functions of v. Like Visit,
+// the nodes of root may be modified in place.
+func Apply(root ast.Node, v *Typed) ast.Node {
+	if edited, ok := Visit(root, v.Options(), nil).(ast.Node); ok {
+		return edited
+	}
+	return root
+}
+
+// nodeKind returns the kind of the nodes of type T, which is the name of their struct.
+func nodeKind[T ast.Node]() string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("visitor: %v is not a pointer to a node struct", t))
+	}
+	return t.Elem().Name()
+}
+
+func typedVisitFunc[T ast.Node](fn TypedVisitFunc[T]) VisitFunc {
+	return func(p VisitFuncParams) (string, interface{}) {
+		node, ok := p.Node.(T)
+		if !ok {
+			return ActionNoChange, nil
+		}
+		return fn(node, p)
+	}
+}
+
+// Continue goes on visiting the node and its children unchanged.
+func Continue() (string, interface{}) {
+	return ActionNoChange, nil
+}
+
+// Skip doesn't visit the children of the node being entered.
+func Skip() (string, interface{}) {
+	return ActionSkip, nil
+}
+
+// Break stops visiting.
+func Break() (string, interface{}) {
+	return ActionBreak, nil
+}
+
+// Replace replaces the node being visited with node. When entering, the new node is visited
+// instead of the old one.
+func Replace(node ast.Node) (string, interface{}) {
+	return ActionUpdate, node
+}
+
+// Remove removes the node being visited from the list holding it, or clears the field holding it.
+func Remove() (string, interface{}) {
+	return ActionUpdate, nil
+}
@@ -66,6 +66,7 @@ var QueryDocumentKeys = KeyMap{
 	"FloatValue":   []string{},
 	"StringValue":  []string{},
 	"BooleanValue": []string{},
+	"NullValue":    []string{},
 	"EnumValue":    []string{},
 	"ListValue":    []string{"Values"},
 	"ObjectValue":  []string{"Fields"},
@@ -232,6 +233,9 @@ Loop:
 						} else {
 							nodeSlice[edit.Key.(int)-editOffset] = edit.Value
 						}
+					} else if edit.Value == nil {
+						// removing a node that isn't in a list clears the field holding it
+						node = clearNodeField(node, edit.Key.(string))
 					} else {
 						var isConvertMap bool
 						// check if edit.Value implements ast.Node or []ast.Node.
@@ -520,6 +524,19 @@ func updateNodeField(src interface{}, targetName string, target interface{}) int
 	return srcVal.Interface()
 }
 
+// clearNodeField sets the field targetName of the struct src to its zero value.
+func clearNodeField(src interface{}, targetName string) interface{} {
+	srcVal := reflect.ValueOf(src)
+	if srcVal.Kind() != reflect.Ptr || srcVal.Elem().Kind() != reflect.Struct {
+		return src
+	}
+	field := srcVal.Elem().FieldByName(targetName)
+	if field.IsValid() && field.CanSet() {
+		field.Set(reflect.Zero(field.Type()))
+	}
+	return src
+}
+
 func toSliceInterfaces(src interface{}) []interface{} {
 	var list []interface{}
 	value := reflect.ValueOf(src)
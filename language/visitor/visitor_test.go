@@ -1725,3 +1725,68 @@ func TestVisitor_VisitWithTypeInfo_MaintainsTypeInfoDuringEdit(t *testing.T) {
 		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedVisited, visited))
 	}
 }
+
+func TestVisitor_Typed_RemovesAndReplacesNodes(t *testing.T) {
+	astDoc := parse(t, `{ a, password, b { x, password } }`)
+
+	v := visitor.NewTyped()
+	visitor.Enter(v, func(field *ast.Field, p visitor.VisitFuncParams) (string, interface{}) {
+		if field.Name.Value == "password" {
+			return visitor.Remove()
+		}
+		return visitor.Continue()
+	})
+	visitor.Leave(v, func(name *ast.Name, p visitor.VisitFuncParams) (string, interface{}) {
+		if name.Value == "x" {
+			return visitor.Replace(ast.NewName(&ast.Name{Value: "y"}))
+		}
+		return visitor.Continue()
+	})
+
+	editedAst := visitor.Apply(astDoc, v)
+	expectedAST := parse(t, `{ a,           b { y            } }`)
+	if !reflect.DeepEqual(expectedAST, editedAst) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedAST, editedAst))
+	}
+}
+
+func TestVisitor_Typed_ClearsRemovedFieldNodes(t *testing.T) {
+	astDoc := parse(t, `{ a { x } b }`)
+
+	v := visitor.NewTyped()
+	visitor.Enter(v, func(selectionSet *ast.SelectionSet, p visitor.VisitFuncParams) (string, interface{}) {
+		if _, ok := p.Parent.(*ast.Field); ok {
+			return visitor.Remove()
+		}
+		return visitor.Continue()
+	})
+
+	editedAst := visitor.Apply(astDoc, v)
+	if printed := printer.Print(editedAst); printed != "{\n  a\n  b\n}\n" {
+		t.Fatalf("Unexpected result: %q", printed)
+	}
+}
+
+func TestVisitor_Typed_SkipsAndBreaks(t *testing.T) {
+	astDoc := parse(t, `{ a { x }, b { y }, c { z } }`)
+
+	visited := []string{}
+	v := visitor.NewTyped()
+	visitor.Enter(v, func(field *ast.Field, p visitor.VisitFuncParams) (string, interface{}) {
+		visited = append(visited, field.Name.Value)
+		switch field.Name.Value {
+		case "a":
+			return visitor.Skip()
+		case "y":
+			return visitor.Break()
+		}
+		return visitor.Continue()
+	})
+
+	if editedAst := visitor.Apply(astDoc, v); editedAst != astDoc {
+		t.Fatalf("expected the document to be unchanged")
+	}
+	if expected := []string{"a", "b", "y"}; !reflect.DeepEqual(visited, expected) {
+		t.Fatalf("expected to visit %v, got %v", expected, visited)
+	}
+}
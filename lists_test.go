@@ -1,6 +1,7 @@
 package graphql_test
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -942,3 +943,145 @@ func TestLists_NullableListOfInt_ReturnsNull(t *testing.T) {
 	}
 	checkList(t, ttype, data, expected)
 }
+
+func TestLists_CoercesListAndInputObjectVariables(t *testing.T) {
+	pointType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "Point",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"labels": &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.String)},
+		},
+	})
+	echo := func(p graphql.ResolveParams) (interface{}, error) {
+		return fmt.Sprintf("%v", p.Args["value"]), nil
+	}
+	fields := graphql.Fields{}
+	for name, ttype := range map[string]graphql.Input{
+		"strings": graphql.NewList(graphql.String),
+		"matrix":  graphql.NewList(graphql.NewList(graphql.Int)),
+		"point":   pointType,
+	} {
+		fields[name] = &graphql.Field{
+			Type:    graphql.String,
+			Args:    graphql.FieldConfigArgument{"value": &graphql.ArgumentConfig{Type: ttype}},
+			Resolve: echo,
+		}
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: fields}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := `query ($strings: [String], $matrix: [[Int]], $single: [[Int]], $point: Point) {
+		strings(value: $strings)
+		matrix(value: $matrix)
+		single: matrix(value: $single)
+		point(value: $point)
+	}`
+
+	result := g(t, graphql.Params{
+		Schema:        schema,
+		RequestString: query,
+		VariableValues: map[string]interface{}{
+			"strings": []string{"a", "b"},
+			"matrix":  [2][]int{{1, 2}, {3}},
+			"single":  7,
+			"point":   map[string][]string{"labels": {"x", "y"}},
+		},
+	})
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"strings": "[a b]",
+			"matrix":  "[[1 2] [3]]",
+			"single":  "[[7]]",
+			"point":   "map[labels:[x y]]",
+		},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+
+	result = g(t, graphql.Params{
+		Schema:         schema,
+		RequestString:  query,
+		VariableValues: map[string]interface{}{"matrix": [][]interface{}{{1, "two"}}},
+	})
+	if len(result.Errors) != 1 || result.Errors[0].Message != `Variable "$matrix[0][1]" expected value of type "Int", found "two".` {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+}
+
+func TestLists_NullsOnlyTheFailingItemsOfNullableLists(t *testing.T) {
+	ttype := graphql.NewList(graphql.NewList(graphql.NewNonNull(graphql.Int)))
+	data := [3][]interface{}{{1, 2}, {3, nil}, {4}}
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"nest": map[string]interface{}{
+				"test": []interface{}{[]interface{}{1, 2}, nil, []interface{}{4}},
+			},
+		},
+		Errors: []gqlerrors.FormattedError{
+			{
+				Message:   "Cannot return null for non-nullable field DataType.test.",
+				Locations: []location.SourceLocation{{Line: 1, Column: 10}},
+				Path:      []interface{}{"nest", "test", 1, 1},
+			},
+		},
+	}
+	checkList(t, ttype, data, expected)
+}
+
+func TestLists_CompletesChannelsAndIterators(t *testing.T) {
+	ttype := graphql.NewList(graphql.NewNonNull(graphql.Int))
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"nest": map[string]interface{}{
+				"test": []interface{}{1, 2, 3},
+			},
+		},
+	}
+
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+	checkList(t, ttype, (<-chan int)(ch), expected)
+
+	iterator := func(yield func(int) bool) {
+		for i := 1; i <= 3; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	checkList(t, ttype, iterator, expected)
+
+	// the iterator is stopped at the first item failing a non-null list
+	yielded := 0
+	failing := func(yield func(interface{}) bool) {
+		for _, item := range []interface{}{1, nil, 3} {
+			yielded++
+			if !yield(item) {
+				return
+			}
+		}
+	}
+	checkList(t, ttype, failing, &graphql.Result{
+		Data: map[string]interface{}{
+			"nest": map[string]interface{}{
+				"test": nil,
+			},
+		},
+		Errors: []gqlerrors.FormattedError{
+			{
+				Message:   "Cannot return null for non-nullable field DataType.test.",
+				Locations: []location.SourceLocation{{Line: 1, Column: 10}},
+				Path:      []interface{}{"nest", "test", 1},
+			},
+		},
+	})
+	if yielded != 2 {
+		t.Fatalf("expected the iterator to stop after 2 items, yielded %v", yielded)
+	}
+}
@@ -0,0 +1,157 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// LiveDirective Used to turn a query into a live query: when run with DoAsync, the query is
+// executed again, and its new result sent, every time one of the dependencies declared by its
+// resolvers with LiveQueryDependsOn is invalidated with InvalidateLiveQueries, until the context
+// of the query is done.
+// It must be added to the directives of the schema to be used.
+var LiveDirective = NewDirective(DirectiveConfig{
+	Name: "live",
+	Description: "Directs the executor to send the result of the query again every time " +
+		"the data it depends on changes.",
+	Locations: []string{
+		DirectiveLocationQuery,
+	},
+})
+
+type liveQueryDependenciesKey struct{}
+
+// liveQueryDependencies collects the keys a live query execution depends on, which are watched
+// as soon as they are declared so that the invalidations made during the execution aren't missed.
+type liveQueryDependencies struct {
+	mutex       sync.Mutex
+	keys        map[string]struct{}
+	invalidated chan struct{}
+	stopped     bool
+}
+
+// LiveQueryDependsOn declares that the result of the live query being executed depends on the
+// data identified by the given keys, e.g. "user:1", so it is executed again when any of them is
+// given to InvalidateLiveQueries. It is meant to be called by resolvers with the context they
+// were given, and does nothing outside of live queries.
+func LiveQueryDependsOn(ctx context.Context, keys ...string) {
+	deps, ok := ctx.Value(liveQueryDependenciesKey{}).(*liveQueryDependencies)
+	if !ok {
+		return
+	}
+	deps.mutex.Lock()
+	defer deps.mutex.Unlock()
+	if deps.stopped {
+		return
+	}
+	for _, key := range keys {
+		if _, ok := deps.keys[key]; !ok {
+			deps.keys[key] = struct{}{}
+			watchLiveQueryKey(key, deps.invalidated)
+		}
+	}
+}
+
+// liveQueries holds the live queries being run, by the keys they depend on.
+var liveQueries = struct {
+	mutex       sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+}{
+	subscribers: map[string]map[chan struct{}]struct{}{},
+}
+
+// InvalidateLiveQueries executes again the live queries that depend on any of the given keys and
+// sends their new results. The live queries are notified without waiting for them to run, and
+// many invalidations happening while a live query is running only make it run once more.
+func InvalidateLiveQueries(ctx context.Context, keys ...string) {
+	liveQueries.mutex.Lock()
+	defer liveQueries.mutex.Unlock()
+	for _, key := range keys {
+		for invalidated := range liveQueries.subscribers[key] {
+			select {
+			case invalidated <- struct{}{}:
+			default:
+				// already invalidated
+			}
+		}
+	}
+}
+
+// watchLiveQueryKey makes invalidated receive when key is invalidated, until unwatchLiveQueryKeys
+// is called with it.
+func watchLiveQueryKey(key string, invalidated chan struct{}) {
+	liveQueries.mutex.Lock()
+	defer liveQueries.mutex.Unlock()
+	if liveQueries.subscribers[key] == nil {
+		liveQueries.subscribers[key] = map[chan struct{}]struct{}{}
+	}
+	liveQueries.subscribers[key][invalidated] = struct{}{}
+}
+
+// unwatchLiveQueryKeys stops the watching of the keys of deps.
+func unwatchLiveQueryKeys(deps *liveQueryDependencies) {
+	deps.mutex.Lock()
+	defer deps.mutex.Unlock()
+	deps.stopped = true
+	liveQueries.mutex.Lock()
+	defer liveQueries.mutex.Unlock()
+	for key := range deps.keys {
+		delete(liveQueries.subscribers[key], deps.invalidated)
+		if len(liveQueries.subscribers[key]) == 0 {
+			delete(liveQueries.subscribers, key)
+		}
+	}
+}
+
+// isLiveQuery tells if the operation of the document selected by operationName is a query with
+// the @live directive.
+func isLiveQuery(document *ast.Document, operationName string) bool {
+	operation := selectedOperation(document, operationName)
+	if operation == nil || operation.Operation != ast.OperationTypeQuery {
+		return false
+	}
+	for _, directive := range operation.Directives {
+		if directive.Name != nil && directive.Name.Value == LiveDirective.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// executeLiveQuery executes a live query and then again every time its dependencies are
+// invalidated, until p.Context is done. The returned channel is closed at that point.
+func executeLiveQuery(p ExecuteParams) chan *Result {
+	resultChannel := make(chan *Result)
+	Go(p.Context, "live query", func() {
+		defer close(resultChannel)
+
+		invalidated := make(chan struct{}, 1)
+		for {
+			// the dependencies are watched while executing, so that the invalidations made by the
+			// resolvers, or by whoever receives the result, run the query again
+			deps := &liveQueryDependencies{keys: map[string]struct{}{}, invalidated: invalidated}
+			stopWatching := func() { unwatchLiveQueryKeys(deps) }
+			params := p
+			params.Context = context.WithValue(p.Context, liveQueryDependenciesKey{}, deps)
+			result := Execute(params)
+
+			select {
+			case resultChannel <- result:
+			case <-p.Context.Done():
+				stopWatching()
+				return
+			}
+
+			select {
+			case <-invalidated:
+				stopWatching()
+			case <-p.Context.Done():
+				stopWatching()
+				return
+			}
+		}
+	})
+	return resultChannel
+}
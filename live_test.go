@@ -0,0 +1,134 @@
+package graphql_test
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+func liveQuerySchema(t *testing.T, counter *int32) graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"counter": &graphql.Field{
+					Type: graphql.Int,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						graphql.LiveQueryDependsOn(p.Context, "counter")
+						return int(atomic.LoadInt32(counter)), nil
+					},
+				},
+			},
+		}),
+		Directives: append(graphql.SpecifiedDirectives, graphql.LiveDirective),
+	})
+	if err != nil {
+		t.Fatalf("wrong result, unexpected errors: %v", err.Error())
+	}
+	return schema
+}
+
+func receiveLiveResult(t *testing.T, ch chan *graphql.Result) *graphql.Result {
+	select {
+	case result := <-ch:
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a live query result")
+		return nil
+	}
+}
+
+func TestLiveQuery_ExecutedAgainWhenInvalidated(t *testing.T) {
+	var counter int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := graphql.DoAsync(graphql.Params{
+		Schema:        liveQuerySchema(t, &counter),
+		RequestString: `query @live { counter }`,
+		Context:       ctx,
+	})
+
+	expected := &graphql.Result{Data: map[string]interface{}{"counter": 0}}
+	if result := receiveLiveResult(t, ch); !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+
+	atomic.AddInt32(&counter, 1)
+	graphql.InvalidateLiveQueries(ctx, "unrelated")
+	graphql.InvalidateLiveQueries(ctx, "counter")
+
+	expected = &graphql.Result{Data: map[string]interface{}{"counter": 1}}
+	if result := receiveLiveResult(t, ch); !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+
+	cancel()
+	select {
+	case _, more := <-ch:
+		if more {
+			t.Fatal("expected no more results after the context is done")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the results channel to be closed after the context is done")
+	}
+}
+
+func TestLiveQuery_DoReturnsASingleResult(t *testing.T) {
+	var counter int32
+	result := graphql.Do(graphql.Params{
+		Schema:        liveQuerySchema(t, &counter),
+		RequestString: `query @live { counter }`,
+	})
+	expected := &graphql.Result{Data: map[string]interface{}{"counter": 0}}
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+}
+
+func TestLiveQuery_ExecutedAgainWhenInvalidatedDuringExecution(t *testing.T) {
+	var counter, executions int32
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"counter": &graphql.Field{
+					Type: graphql.Int,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						graphql.LiveQueryDependsOn(p.Context, "counter")
+						value := int(atomic.LoadInt32(&counter))
+						// the counter changes before the first result is sent
+						if atomic.AddInt32(&executions, 1) == 1 {
+							atomic.AddInt32(&counter, 1)
+							graphql.InvalidateLiveQueries(p.Context, "counter")
+						}
+						return value, nil
+					},
+				},
+			},
+		}),
+		Directives: append(graphql.SpecifiedDirectives, graphql.LiveDirective),
+	})
+	if err != nil {
+		t.Fatalf("wrong result, unexpected errors: %v", err.Error())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := graphql.DoAsync(graphql.Params{
+		Schema:        schema,
+		RequestString: `query @live { counter }`,
+		Context:       ctx,
+	})
+	for i := 0; i < 2; i++ {
+		expected := &graphql.Result{Data: map[string]interface{}{"counter": i}}
+		if result := receiveLiveResult(t, ch); !reflect.DeepEqual(expected, result) {
+			t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+		}
+	}
+}
@@ -0,0 +1,543 @@
+// Package mock builds executable schemas from SDL whose fields all resolve to fake data, so that
+// clients can be developed against an API that isn't implemented yet.
+//
+// The fake data is deterministic: the value of a field only depends on its path in the response.
+// It can be customized for any scalar, enum, object, interface or union type with a MockFn.
+package mock
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/location"
+	"github.com/fiatjaf/graphql/language/parser"
+	"github.com/fiatjaf/graphql/language/source"
+)
+
+// MockFn returns the fake value of a field of the type it is registered for. For object,
+// interface and union types it returns a map whose entries are used as the values of the fields
+// of the object, the other fields being mocked as usual, and which may set "__typename" to choose
+// the type of an abstract value.
+type MockFn func(p graphql.ResolveParams) interface{}
+
+// Config configures the fake data of a mocked schema.
+type Config struct {
+	// Mocks holds the functions generating the values of types, by type name. They replace the
+	// default fake data, including for the built-in scalars.
+	Mocks map[string]MockFn
+
+	// ListLength is the number of items of the lists, 2 if zero.
+	ListLength int
+
+	// Types are built in Go rather than from the SDL, so that a schema can move between the two
+	// one type at a time. They replace the definitions of the SDL with the same names, and the
+	// SDL can refer to them without defining them. The fields of the objects of Types are
+	// resolved by their own resolvers, while the values of their types returned by the fields of
+	// the SDL are mocked like the others, as maps for the objects, unless they have a MockFn.
+	Types []graphql.Type
+}
+
+// NewSchema builds an executable schema from sdl, the type definitions of a schema in the GraphQL
+// schema definition language. The root types are the ones given by its schema definition, or
+// else the types named Query, Mutation and Subscription. The subscriptions send a single event.
+// The cost of the fields with @cost or @listSize directives is computed as told by graphql.Cost,
+// for Params.MaxCost and graphql.QueryCost, and the fields with a @cacheControl(maxAge: Int)
+// directive are hinted as told by graphql.CacheHintFromDirectives, and cached as told by
+// graphql.FieldCacheFromDirectives when they are fields of a root type.
+func NewSchema(sdl string, config Config) (graphql.Schema, error) {
+	return NewSchemaFromSources([]*source.Source{source.NewSource(&source.Source{Body: []byte(sdl)})}, config)
+}
+
+// NewSchemaFromSources builds an executable schema like NewSchema from the type definitions of
+// several sources, e.g. one per SDL file, named after the files. The locations of the definitions
+// keep their source, so the errors tell where each definition comes from:
+//
+//	duplicate type User defined in users.graphql:3 and accounts.graphql:10
+func NewSchemaFromSources(sources []*source.Source, config Config) (graphql.Schema, error) {
+	document := ast.NewDocument(nil)
+	for _, s := range sources {
+		sourceDocument, err := parser.Parse(parser.ParseParams{Source: s})
+		if err != nil {
+			return graphql.Schema{}, err
+		}
+		document.Definitions = append(document.Definitions, sourceDocument.Definitions...)
+	}
+	if config.ListLength == 0 {
+		config.ListLength = 2
+	}
+	b := &builder{
+		config:          config,
+		types:           map[string]graphql.Type{},
+		implementations: map[string][]string{},
+	}
+	return b.build(document)
+}
+
+type builder struct {
+	config Config
+	types  map[string]graphql.Type
+
+	// implementations are the names of the object types implementing each interface
+	implementations map[string][]string
+}
+
+func (b *builder) build(document *ast.Document) (graphql.Schema, error) {
+	rootTypes := map[string]string{
+		ast.OperationTypeQuery:        "Query",
+		ast.OperationTypeMutation:     "Mutation",
+		ast.OperationTypeSubscription: "Subscription",
+	}
+	for _, scalar := range []*graphql.Scalar{graphql.Int, graphql.Float, graphql.String, graphql.Boolean, graphql.ID} {
+		b.types[scalar.Name()] = scalar
+	}
+	built := map[string]bool{}
+	for _, t := range b.config.Types {
+		b.types[t.Name()] = t
+		built[t.Name()] = true
+	}
+
+	// the types are created in an order letting each of them refer to the ones it needs, while
+	// fields are defined by thunks so types can refer to each other
+	var objects []*ast.ObjectDefinition
+	var unions []*ast.UnionDefinition
+	if err := checkDuplicates(document); err != nil {
+		return graphql.Schema{}, err
+	}
+	for _, definition := range document.Definitions {
+		if name := definitionName(definition); name != nil && built[name.Value] {
+			continue
+		}
+		switch def := definition.(type) {
+		case *ast.SchemaDefinition:
+			for _, operationType := range def.OperationTypes {
+				rootTypes[operationType.Operation] = operationType.Type.Name.Value
+			}
+		case *ast.ScalarDefinition:
+			b.types[def.Name.Value] = b.scalar(def)
+		case *ast.EnumDefinition:
+			b.types[def.Name.Value] = b.enum(def)
+		case *ast.InputObjectDefinition:
+			b.types[def.Name.Value] = b.inputObject(def)
+		case *ast.InterfaceDefinition:
+			b.types[def.Name.Value] = b.iface(def)
+		case *ast.ObjectDefinition:
+			objects = append(objects, def)
+			for _, iface := range def.Interfaces {
+				b.implementations[iface.Name.Value] = append(b.implementations[iface.Name.Value], def.Name.Value)
+			}
+		case *ast.UnionDefinition:
+			unions = append(unions, def)
+		}
+	}
+	for _, def := range objects {
+		operation := ""
+		for operationType, name := range rootTypes {
+			if name == def.Name.Value {
+				operation = operationType
+			}
+		}
+		b.types[def.Name.Value] = b.object(def, operation)
+	}
+	for _, def := range unions {
+		b.types[def.Name.Value] = b.union(def)
+	}
+
+	config := graphql.SchemaConfig{}
+	for operation, name := range rootTypes {
+		object, ok := b.types[name].(*graphql.Object)
+		if !ok {
+			continue
+		}
+		switch operation {
+		case ast.OperationTypeQuery:
+			config.Query = object
+		case ast.OperationTypeMutation:
+			config.Mutation = object
+		case ast.OperationTypeSubscription:
+			config.Subscription = object
+		}
+	}
+	if config.Query == nil {
+		return graphql.Schema{}, fmt.Errorf("no query type %q in the SDL", rootTypes[ast.OperationTypeQuery])
+	}
+	for _, t := range b.types {
+		if _, ok := t.(*graphql.Scalar); !ok {
+			config.Types = append(config.Types, t)
+		}
+	}
+	return graphql.NewSchema(config)
+}
+
+// checkDuplicates returns an error for the first type or schema defined twice in document,
+// telling where both definitions are.
+func checkDuplicates(document *ast.Document) error {
+	defined := map[string]ast.Node{}
+	for _, definition := range document.Definitions {
+		name := definitionName(definition)
+		key := "schema"
+		if name != nil {
+			key = "type " + name.Value
+		} else if _, ok := definition.(*ast.SchemaDefinition); !ok {
+			continue
+		}
+		if previous, ok := defined[key]; ok {
+			return gqlerrors.NewError(
+				fmt.Sprintf("duplicate %s defined in %s and %s", key, definitionPosition(previous), definitionPosition(definition)),
+				[]ast.Node{previous, definition},
+				"",
+				nil,
+				nil,
+				nil,
+			)
+		}
+		defined[key] = definition
+	}
+	return nil
+}
+
+// definitionName returns the name of the type defined by definition, nil if it doesn't define a
+// type.
+func definitionName(definition ast.Node) *ast.Name {
+	switch def := definition.(type) {
+	case *ast.ScalarDefinition:
+		return def.Name
+	case *ast.ObjectDefinition:
+		return def.Name
+	case *ast.InterfaceDefinition:
+		return def.Name
+	case *ast.UnionDefinition:
+		return def.Name
+	case *ast.EnumDefinition:
+		return def.Name
+	case *ast.InputObjectDefinition:
+		return def.Name
+	}
+	return nil
+}
+
+// definitionPosition returns the name of the source of node and the line where it's defined,
+// e.g. "users.graphql:3".
+func definitionPosition(node ast.Node) string {
+	loc := node.GetLoc()
+	if loc == nil || loc.Source == nil {
+		return "an unknown source"
+	}
+	return fmt.Sprintf("%s:%d", loc.Source.Name, location.GetLocation(loc.Source, loc.Start).Line)
+}
+
+func (b *builder) typeRef(t ast.Type) graphql.Type {
+	switch t := t.(type) {
+	case *ast.NonNull:
+		return graphql.NewNonNull(b.typeRef(t.Type))
+	case *ast.List:
+		return graphql.NewList(b.typeRef(t.Type))
+	case *ast.Named:
+		if named, ok := b.types[t.Name.Value]; ok {
+			return named
+		}
+		// NewSchema reports the unknown type
+		return graphql.NewObject(graphql.ObjectConfig{Name: t.Name.Value})
+	}
+	return nil
+}
+
+func (b *builder) scalar(def *ast.ScalarDefinition) *graphql.Scalar {
+	identity := func(value interface{}) interface{} { return value }
+	return graphql.NewScalar(graphql.ScalarConfig{
+		Name:         def.Name.Value,
+		Description:  description(def.Description),
+		Serialize:    identity,
+		ParseValue:   identity,
+		ParseLiteral: func(valueAST ast.Value) interface{} { return literalValue(valueAST) },
+	})
+}
+
+func (b *builder) enum(def *ast.EnumDefinition) *graphql.Enum {
+	values := graphql.EnumValueConfigMap{}
+	for _, value := range def.Values {
+		values[value.Name.Value] = &graphql.EnumValueConfig{
+			Value:             value.Name.Value,
+			Description:       description(value.Description),
+			DeprecationReason: deprecationReason(value.Directives),
+		}
+	}
+	return graphql.NewEnum(graphql.EnumConfig{
+		Name:        def.Name.Value,
+		Description: description(def.Description),
+		Values:      values,
+	})
+}
+
+func (b *builder) inputObject(def *ast.InputObjectDefinition) *graphql.InputObject {
+	return graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        def.Name.Value,
+		Description: description(def.Description),
+		Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap {
+			fields := graphql.InputObjectConfigFieldMap{}
+			for _, field := range def.Fields {
+				fields[field.Name.Value] = &graphql.InputObjectFieldConfig{
+					Type:         b.typeRef(field.Type),
+					DefaultValue: field.DefaultValue,
+					Description:  description(field.Description),
+				}
+			}
+			return fields
+		}),
+	})
+}
+
+func (b *builder) iface(def *ast.InterfaceDefinition) *graphql.Interface {
+	return graphql.NewInterface(graphql.InterfaceConfig{
+		Name:        def.Name.Value,
+		Description: description(def.Description),
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return b.fields(def.Fields, "")
+		}),
+		ResolveType: b.resolveType,
+	})
+}
+
+// object builds the type of def, operation being the type of the operations it is the root type
+// of, if any.
+func (b *builder) object(def *ast.ObjectDefinition, operation string) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        def.Name.Value,
+		Description: description(def.Description),
+		Interfaces: graphql.InterfacesThunk(func() []*graphql.Interface {
+			interfaces := []*graphql.Interface{}
+			for _, named := range def.Interfaces {
+				if iface, ok := b.types[named.Name.Value].(*graphql.Interface); ok {
+					interfaces = append(interfaces, iface)
+				}
+			}
+			return interfaces
+		}),
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return b.fields(def.Fields, operation)
+		}),
+	})
+}
+
+func (b *builder) union(def *ast.UnionDefinition) *graphql.Union {
+	types := []*graphql.Object{}
+	for _, named := range def.Types {
+		if object, ok := b.types[named.Name.Value].(*graphql.Object); ok {
+			types = append(types, object)
+		}
+	}
+	return graphql.NewUnion(graphql.UnionConfig{
+		Name:        def.Name.Value,
+		Description: description(def.Description),
+		Types:       types,
+		ResolveType: b.resolveType,
+	})
+}
+
+func (b *builder) fields(definitions []*ast.FieldDefinition, operation string) graphql.Fields {
+	fields := graphql.Fields{}
+	for _, def := range definitions {
+		args := graphql.FieldConfigArgument{}
+		for _, arg := range def.Arguments {
+			args[arg.Name.Value] = &graphql.ArgumentConfig{
+				Type:         b.typeRef(arg.Type),
+				DefaultValue: arg.DefaultValue,
+				Description:  description(arg.Description),
+			}
+		}
+		field := &graphql.Field{
+			Type:              b.typeRef(def.Type),
+			Args:              args,
+			Description:       description(def.Description),
+			DeprecationReason: deprecationReason(def.Directives),
+			Resolve:           b.resolve,
+		}
+		if cost, ok := graphql.CostFromDirectives(def.Directives); ok {
+			field.Complexity = cost.ComplexityFn()
+		}
+		if operation != "" {
+			// the mocked values of the other fields depend on their parent object, which the
+			// key of the cache ignores
+			field.Cache = graphql.FieldCacheFromDirectives(def.Directives)
+		}
+		field.CacheHint = graphql.CacheHintFromDirectives(def.Directives)
+		if operation == ast.OperationTypeSubscription {
+			field.Subscribe = func(p graphql.ResolveParams) (chan interface{}, error) {
+				events := make(chan interface{}, 1)
+				events <- nil
+				close(events)
+				return events, nil
+			}
+		}
+		fields[def.Name.Value] = field
+	}
+	return fields
+}
+
+// resolve returns the value of the field in the map of its parent object when there is one, as
+// returned by a MockFn, or else mocks it.
+func (b *builder) resolve(p graphql.ResolveParams) (interface{}, error) {
+	if source, ok := p.Source.(map[string]interface{}); ok {
+		if value, ok := source[p.Info.FieldName]; ok {
+			return value, nil
+		}
+	}
+	seed := fnv.New32a()
+	fmt.Fprint(seed, p.Info.Path.AsArray()...)
+	return b.mock(p, p.Info.ReturnType, seed.Sum32()), nil
+}
+
+func (b *builder) resolveType(p graphql.ResolveTypeParams) *graphql.Object {
+	value, _ := p.Value.(map[string]interface{})
+	name, _ := value["__typename"].(string)
+	object, _ := b.types[name].(*graphql.Object)
+	return object
+}
+
+// mock generates a value of type t, the seed choosing among the possible fake values.
+func (b *builder) mock(p graphql.ResolveParams, t graphql.Type, seed uint32) interface{} {
+	switch t := t.(type) {
+	case *graphql.NonNull:
+		return b.mock(p, t.OfType, seed)
+	case *graphql.List:
+		items := make([]interface{}, b.config.ListLength)
+		for i := range items {
+			items[i] = b.mock(p, t.OfType, seed*31+uint32(i)+1)
+		}
+		return items
+	}
+
+	var value interface{}
+	if mockFn, ok := b.config.Mocks[t.Name()]; ok {
+		value = mockFn(p)
+	}
+
+	switch t := t.(type) {
+	case *graphql.Scalar:
+		if value != nil {
+			return value
+		}
+		switch t.Name() {
+		case "Int":
+			return int(seed % 100)
+		case "Float":
+			return float64(seed%10000) / 100
+		case "Boolean":
+			return seed%2 == 0
+		case "ID":
+			return strconv.FormatUint(uint64(seed), 36)
+		default:
+			return fmt.Sprintf("%s %d", p.Info.FieldName, seed%100)
+		}
+	case *graphql.Enum:
+		if value != nil {
+			return value
+		}
+		names := []string{}
+		for _, enumValue := range t.Values() {
+			names = append(names, enumValue.Name)
+		}
+		sort.Strings(names)
+		return names[int(seed%uint32(len(names)))]
+	case *graphql.Object:
+		return mockObject(value)
+	case *graphql.Interface:
+		return b.mockAbstract(p, mockObject(value), b.implementations[t.Name()], seed)
+	case *graphql.Union:
+		names := []string{}
+		for _, object := range t.Types() {
+			names = append(names, object.Name())
+		}
+		return b.mockAbstract(p, mockObject(value), names, seed)
+	}
+	return value
+}
+
+// mockAbstract chooses the type of an interface or union value among possibleTypes, unless its
+// mock did, and adds the values of the mock of that type.
+func (b *builder) mockAbstract(p graphql.ResolveParams, value map[string]interface{}, possibleTypes []string, seed uint32) interface{} {
+	if _, ok := value["__typename"]; !ok && len(possibleTypes) != 0 {
+		value["__typename"] = possibleTypes[int(seed%uint32(len(possibleTypes)))]
+	}
+	name, _ := value["__typename"].(string)
+	if mockFn, ok := b.config.Mocks[name]; ok {
+		for key, v := range mockObject(mockFn(p)) {
+			if _, ok := value[key]; !ok {
+				value[key] = v
+			}
+		}
+	}
+	return value
+}
+
+func mockObject(value interface{}) map[string]interface{} {
+	object := map[string]interface{}{}
+	if fields, ok := value.(map[string]interface{}); ok {
+		for key, v := range fields {
+			object[key] = v
+		}
+	}
+	return object
+}
+
+func description(value *ast.StringValue) string {
+	if value == nil {
+		return ""
+	}
+	return value.Value
+}
+
+func deprecationReason(directives []*ast.Directive) string {
+	for _, directive := range directives {
+		if directive.Name == nil || directive.Name.Value != graphql.DeprecatedDirective.Name {
+			continue
+		}
+		for _, arg := range directive.Arguments {
+			if arg.Name != nil && arg.Name.Value == "reason" {
+				if reason, ok := arg.Value.(*ast.StringValue); ok {
+					return reason.Value
+				}
+			}
+		}
+		return graphql.DefaultDeprecationReason
+	}
+	return ""
+}
+
+// literalValue converts a literal, such as the value of a custom scalar, to the Go value it
+// represents.
+func literalValue(valueAST ast.Value) interface{} {
+	switch value := valueAST.(type) {
+	case *ast.IntValue:
+		if i, err := strconv.Atoi(value.Value); err == nil {
+			return i
+		}
+	case *ast.FloatValue:
+		if f, err := strconv.ParseFloat(value.Value, 64); err == nil {
+			return f
+		}
+	case *ast.StringValue:
+		return value.Value
+	case *ast.BooleanValue:
+		return value.Value
+	case *ast.EnumValue:
+		return value.Value
+	case *ast.ListValue:
+		values := make([]interface{}, len(value.Values))
+		for i, item := range value.Values {
+			values[i] = literalValue(item)
+		}
+		return values
+	case *ast.ObjectValue:
+		fields := map[string]interface{}{}
+		for _, field := range value.Fields {
+			fields[field.Name.Value] = literalValue(field.Value)
+		}
+		return fields
+	}
+	return nil
+}
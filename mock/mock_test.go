@@ -0,0 +1,459 @@
+package mock_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/language/location"
+	"github.com/fiatjaf/graphql/language/source"
+	"github.com/fiatjaf/graphql/mock"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+const sdl = `
+scalar DateTime
+
+enum Role { ADMIN USER }
+
+interface Node { id: ID! }
+
+type User implements Node {
+  id: ID!
+  name: String!
+  role: Role!
+  age: Int
+  score: Float
+  active: Boolean
+  createdAt: DateTime
+  friends(first: Int = 10): [User!]!
+}
+
+type Post implements Node {
+  id: ID!
+  title: String!
+  author: User!
+}
+
+union SearchResult = User | Post
+
+input PostInput { title: String! }
+
+type Query {
+  me: User
+  node(id: ID!): Node
+  search(text: String): [SearchResult!]!
+}
+
+type Mutation {
+  createPost(input: PostInput!): Post
+}
+
+type Subscription {
+  postCreated: Post!
+}
+`
+
+func TestNewSchema_MocksDeterministicData(t *testing.T) {
+	schema, err := mock.NewSchema(sdl, mock.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := `{
+		me { id name role age score active createdAt friends { name } }
+		node(id: "1") { __typename id }
+		search(text: "a") { __typename ... on Post { title author { name } } ... on User { name } }
+	}`
+
+	first := graphql.Do(graphql.Params{Schema: schema, RequestString: query})
+	if first.HasErrors() {
+		t.Fatalf("unexpected errors: %v", first.Errors)
+	}
+	second := graphql.Do(graphql.Params{Schema: schema, RequestString: query})
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("expected the same data, Diff: %v", testutil.Diff(first, second))
+	}
+
+	data := first.Data.(map[string]interface{})
+	me := data["me"].(map[string]interface{})
+	if role := me["role"]; role != "ADMIN" && role != "USER" {
+		t.Fatalf("expected a role, got %v", role)
+	}
+	if friends := me["friends"].([]interface{}); len(friends) != 2 {
+		t.Fatalf("expected 2 friends, got %v", friends)
+	}
+	if typename := data["node"].(map[string]interface{})["__typename"]; typename != "User" && typename != "Post" {
+		t.Fatalf("expected a node type, got %v", typename)
+	}
+	if results := data["search"].([]interface{}); len(results) != 2 {
+		t.Fatalf("expected 2 search results, got %v", results)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `mutation { createPost(input: {title: "a"}) { title author { role } } }`,
+	})
+	if result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var events []*graphql.Result
+	for result := range graphql.Subscribe(graphql.Params{
+		Schema:        schema,
+		RequestString: `subscription { postCreated { id } }`,
+		Context:       ctx,
+	}) {
+		events = append(events, result)
+	}
+	if len(events) != 1 || events[0].HasErrors() {
+		t.Fatalf("expected a single event, got %v", events)
+	}
+}
+
+func TestNewSchema_UsesMockFns(t *testing.T) {
+	schema, err := mock.NewSchema(sdl, mock.Config{
+		ListLength: 3,
+		Mocks: map[string]mock.MockFn{
+			"String": func(p graphql.ResolveParams) interface{} { return "Hello World" },
+			"User": func(p graphql.ResolveParams) interface{} {
+				return map[string]interface{}{"name": "Alice", "role": "ADMIN"}
+			},
+			"Node": func(p graphql.ResolveParams) interface{} {
+				return map[string]interface{}{"__typename": "Post"}
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ me { name role friends { name } } node(id: "1") { __typename ... on Post { title author { name } } } }`,
+	})
+	alice := map[string]interface{}{"name": "Alice"}
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"me": map[string]interface{}{
+				"name":    "Alice",
+				"role":    "ADMIN",
+				"friends": []interface{}{alice, alice, alice},
+			},
+			"node": map[string]interface{}{
+				"__typename": "Post",
+				"title":      "Hello World",
+				"author":     alice,
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+}
+
+func TestNewSchema_UsesTypesBuiltInGo(t *testing.T) {
+	money := graphql.NewScalar(graphql.ScalarConfig{
+		Name:      "Money",
+		Serialize: func(value interface{}) interface{} { return fmt.Sprintf("$%.2f", value) },
+	})
+	account := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Account",
+		Fields: graphql.Fields{
+			"owner": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return "Ada", nil },
+			},
+			"balance": &graphql.Field{
+				Type:    money,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return 12.5, nil },
+			},
+		},
+	})
+	schema, err := mock.NewSchema(`
+		type Account { id: ID }
+		type Query { account: Account price: Money }
+	`, mock.Config{
+		Types: []graphql.Type{money, account},
+		Mocks: map[string]mock.MockFn{
+			"Money": func(p graphql.ResolveParams) interface{} { return 3.0 },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ account { owner balance } price }`,
+	})
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"account": map[string]interface{}{"owner": "Ada", "balance": "$12.50"},
+			"price":   "$3.00",
+		},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+
+	// the SDL definition of Account is replaced by the one built in Go
+	result = graphql.Do(graphql.Params{Schema: schema, RequestString: `{ account { id } }`})
+	if len(result.Errors) != 1 || result.Errors[0].Message != `Cannot query field "id" on type "Account".` {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestNewSchema_CostDirectives(t *testing.T) {
+	schema, err := mock.NewSchema(`
+		type Post { title: String tags(ids: [ID]): [String] @cost(complexity: 2, multipliers: "ids") }
+		type Query {
+			posts(first: Int, last: Int): [Post] @listSize(assumedSize: 10, slicingArguments: ["first", "last"])
+			search(text: String): [Post] @cost(complexity: 3)
+		}
+	`, mock.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		cost  int
+	}{
+		{`{ posts { title } }`, 20},
+		{`{ posts(last: 3) { title } }`, 6},
+		{`{ posts(first: 2, last: 3) { title tags(ids: ["1", "2"]) } }`, 12},
+		{`{ search(text: "a") { title } }`, 4},
+	}
+	for _, test := range tests {
+		cost, err := graphql.QueryCost(graphql.ExecuteParams{Schema: schema, AST: testutil.TestParse(t, test.query)})
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", test.query, err)
+		}
+		if cost != test.cost {
+			t.Errorf("expected cost %d for %s, got %d", test.cost, test.query, cost)
+		}
+	}
+}
+
+func TestNewSchema_CostDirectives_CantBeCancelledOrOverflowed(t *testing.T) {
+	schema, err := mock.NewSchema(`
+		type User { id: ID friends(first: Int): [User] @listSize(slicingArguments: ["first"]) }
+		type Query { users(first: Int): [User] @listSize(slicingArguments: ["first"]) }
+	`, mock.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		cost  int
+	}{
+		// a negative size costs nothing, it doesn't cancel the other fields
+		{`{ a: users(first: 100000) { id } b: users(first: -100000) { id } }`, 200000},
+		// the cost is capped rather than wrapping around
+		{`{ users(first: 2147483647) { friends(first: 2147483647) { friends(first: 2147483647) { id } } } }`, math.MaxInt32},
+	}
+	for _, test := range tests {
+		cost, err := graphql.QueryCost(graphql.ExecuteParams{Schema: schema, AST: testutil.TestParse(t, test.query)})
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", test.query, err)
+		}
+		if cost != test.cost {
+			t.Errorf("expected cost %d for %s, got %d", test.cost, test.query, cost)
+		}
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: test.query, MaxCost: 100})
+		if result.Data != nil || len(result.Errors) != 1 {
+			t.Errorf("expected %s to be rejected, got %+v", test.query, result)
+		}
+	}
+}
+
+func TestNewSchema_CacheControlDirectives(t *testing.T) {
+	schema, err := mock.NewSchema(`type Query { rate: Float @cacheControl(maxAge: 60) }`, mock.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ a: rate b: rate }`})
+	if len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+	data := result.Data.(map[string]interface{})
+	expectedStats := &graphql.FieldCacheStats{Hits: 1, Misses: 1}
+	if data["a"] != data["b"] || !reflect.DeepEqual(result.Extensions["fieldCache"], expectedStats) {
+		t.Fatalf("expected the value of a to be reused for b, got %+v", result)
+	}
+}
+
+func TestNewSchema_CacheControlDirectives_OnlyCacheRootFields(t *testing.T) {
+	schema, err := mock.NewSchema(`
+		type Query { users: [User] @cacheControl(maxAge: 60) }
+		type User { id: ID name: String @cacheControl(maxAge: 60) }
+	`, mock.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ users { id name } }`})
+	if len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+	users := result.Data.(map[string]interface{})["users"].([]interface{})
+	if len(users) < 2 {
+		t.Fatalf("expected several users, got %+v", users)
+	}
+	first := users[0].(map[string]interface{})
+	second := users[1].(map[string]interface{})
+	if first["name"] == second["name"] {
+		t.Fatalf("expected the users to have their own name, got %+v", users)
+	}
+	expectedStats := &graphql.FieldCacheStats{Misses: 1}
+	if !reflect.DeepEqual(result.Extensions["fieldCache"], expectedStats) {
+		t.Fatalf("expected only users to be cached, got %+v", result.Extensions["fieldCache"])
+	}
+}
+
+func TestNewSchema_RequiresQueryType(t *testing.T) {
+	if _, err := mock.NewSchema(`type Foo { bar: String }`, mock.Config{}); err == nil {
+		t.Fatal("expected an error without a query type")
+	}
+	if _, err := mock.NewSchema(`type Query { bar: Unknown }`, mock.Config{}); err == nil {
+		t.Fatal("expected an error for an unknown type")
+	}
+}
+
+func TestNewSchema_BlockStringDescriptions(t *testing.T) {
+	schema, err := mock.NewSchema(`
+		"""
+		The query root.
+		  Indented line.
+		"""
+		type Query {
+		  """
+		    Greets someone.
+		  """
+		  hello(
+		    "a name"
+		    name: String
+		  ): String
+		}
+	`, mock.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := schema.QueryType()
+	if expected := "The query root.\n  Indented line."; query.Description() != expected {
+		t.Fatalf("expected type description %q, got %q", expected, query.Description())
+	}
+	hello := query.Fields()["hello"]
+	if expected := "Greets someone."; hello.Description != expected {
+		t.Fatalf("expected field description %q, got %q", expected, hello.Description)
+	}
+	if expected := "a name"; hello.Args[0].Description() != expected {
+		t.Fatalf("expected argument description %q, got %q", expected, hello.Args[0].Description())
+	}
+}
+
+func TestNewSchema_KeepsDefaultValueLiterals(t *testing.T) {
+	printed := `type Query {
+  users(filter: UserFilter = {roles: [ADMIN], name: "a"}, first: Int = 10): [String]
+}
+
+enum Role {
+  ADMIN
+  USER
+}
+
+input UserFilter {
+  name: String
+  roles: [Role!] = [USER]
+}
+`
+	schema, err := mock.NewSchema(printed, mock.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sdl := graphql.PrintSchema(schema); sdl != printed {
+		t.Fatalf("expected the SDL to be printed back as is, got:\n%s", sdl)
+	}
+}
+
+func TestNewSchemaFromSources_ReportsDuplicatesWithTheirSources(t *testing.T) {
+	users := source.NewSource(&source.Source{Name: "users.graphql", Body: []byte(`
+type Query {
+  me: User
+}
+
+type User {
+  id: ID!
+}
+`)})
+	accounts := source.NewSource(&source.Source{Name: "accounts.graphql", Body: []byte(`type Account {
+  id: ID!
+}
+
+type User {
+  account: Account
+}
+`)})
+	_, err := mock.NewSchemaFromSources([]*source.Source{users, accounts}, mock.Config{})
+	if err == nil {
+		t.Fatal("expected an error for the duplicate type")
+	}
+	if expected := "duplicate type User defined in users.graphql:6 and accounts.graphql:5"; err.Error() != expected {
+		t.Fatalf("expected error %q, got %q", expected, err.Error())
+	}
+	expectedLocations := []location.SourceLocation{{Line: 6, Column: 1}, {Line: 5, Column: 1}}
+	if locations := err.(*gqlerrors.Error).Locations; !reflect.DeepEqual(locations, expectedLocations) {
+		t.Fatalf("unexpected locations: %v", locations)
+	}
+
+	accounts.Body = []byte("type Account { id: ID! }")
+	schema, err := mock.NewSchemaFromSources([]*source.Source{users, accounts}, mock.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Type("Account") == nil || schema.Type("User") == nil {
+		t.Fatal("expected the types of both sources")
+	}
+}
+
+func TestNewSchema_InstrumentField(t *testing.T) {
+	schema, err := mock.NewSchema(sdl, mock.Config{
+		Mocks: map[string]mock.MockFn{
+			"User": func(p graphql.ResolveParams) interface{} {
+				return map[string]interface{}{"name": "alice"}
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []string
+	err = schema.InstrumentField("User", "name", func(next graphql.FieldResolveFn) graphql.FieldResolveFn {
+		return func(p graphql.ResolveParams) (interface{}, error) {
+			calls = append(calls, p.Info.ParentType.Name()+"."+p.Info.FieldName)
+			name, err := next(p)
+			return strings.ToUpper(name.(string)), err
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ me { name } }`})
+	expected := &graphql.Result{
+		Data: map[string]interface{}{"me": map[string]interface{}{"name": "ALICE"}},
+	}
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+	if !reflect.DeepEqual([]string{"User.name"}, calls) {
+		t.Fatalf("Unexpected calls: %v", calls)
+	}
+}
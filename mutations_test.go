@@ -1,6 +1,7 @@
 package graphql_test
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -253,3 +254,146 @@ func TestMutations_EvaluatesMutationsCorrectlyInThePresenceOfAFailedMutation(t *
 		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
 	}
 }
+
+type mutationTxKey struct{}
+
+// mutationTxSchema has a mutation field that records the transaction it runs in, and fails if
+// asked to, and another returning a record with a failing field.
+func mutationTxSchema(t *testing.T, beginMutation graphql.BeginMutationFn, executed *[]string) graphql.Schema {
+	record := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Record",
+		Fields: graphql.Fields{
+			"value": &graphql.Field{Type: graphql.String},
+			"broken": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nil, gqlerrors.NewFormattedError("broken field")
+				},
+			},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"write": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{
+						"value": &graphql.ArgumentConfig{Type: graphql.String},
+						"fail":  &graphql.ArgumentConfig{Type: graphql.Boolean},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						tx, _ := p.Context.Value(mutationTxKey{}).(string)
+						*executed = append(*executed, tx+":"+p.Args["value"].(string))
+						if fail, _ := p.Args["fail"].(bool); fail {
+							return nil, gqlerrors.NewFormattedError("write failed")
+						}
+						return p.Args["value"], nil
+					},
+				},
+				"record": &graphql.Field{
+					Type: record,
+					Args: graphql.FieldConfigArgument{
+						"value": &graphql.ArgumentConfig{Type: graphql.String},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return map[string]interface{}{"value": p.Args["value"]}, nil
+					},
+				},
+			},
+		}),
+		BeginMutation: beginMutation,
+	})
+	if err != nil {
+		t.Fatalf("Error in schema %v", err.Error())
+	}
+	return schema
+}
+
+func TestMutations_BeginMutation_CommitsOrRollsBack(t *testing.T) {
+	var executed, committed []string
+	beginMutation := func(ctx context.Context) (context.Context, func(error) error) {
+		executed = append(executed, "begin")
+		return context.WithValue(ctx, mutationTxKey{}, "tx"), func(err error) error {
+			if err != nil {
+				committed = append(committed, "rollback: "+err.Error())
+				return nil
+			}
+			committed = append(committed, "commit")
+			return nil
+		}
+	}
+	schema := mutationTxSchema(t, beginMutation, &executed)
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `mutation { a: write(value: "a") b: write(value: "b") }`,
+	})
+	if len(result.Errors) > 0 {
+		t.Fatalf("wrong result, unexpected errors: %v", result.Errors)
+	}
+	if expected := []string{"begin", "tx:a", "tx:b"}; !reflect.DeepEqual(executed, expected) {
+		t.Fatalf("expected %v, got %v", expected, executed)
+	}
+	if expected := []string{"commit"}; !reflect.DeepEqual(committed, expected) {
+		t.Fatalf("expected %v, got %v", expected, committed)
+	}
+
+	executed, committed = nil, nil
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `mutation { a: write(value: "a", fail: true) b: write(value: "b") }`,
+	})
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected the error of the failed field, got %v", result.Errors)
+	}
+	if expected := []string{"rollback: write failed"}; !reflect.DeepEqual(committed, expected) {
+		t.Fatalf("expected %v, got %v", expected, committed)
+	}
+
+	// the errors of the subfields of a root field that isn't null don't fail the mutation
+	executed, committed = nil, nil
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `mutation { record(value: "a") { value broken } }`,
+	})
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected the error of the broken field, got %v", result.Errors)
+	}
+	if expected := []string{"commit"}; !reflect.DeepEqual(committed, expected) {
+		t.Fatalf("expected %v, got %v", expected, committed)
+	}
+
+	// queries don't run in a transaction
+	executed, committed = nil, nil
+	graphql.Do(graphql.Params{Schema: schema, RequestString: `{ ok }`})
+	if len(executed) != 0 || len(committed) != 0 {
+		t.Fatalf("expected no transaction for queries, got %v %v", executed, committed)
+	}
+}
+
+func TestMutations_BeginMutation_ReportsCommitErrors(t *testing.T) {
+	var executed []string
+	schema := mutationTxSchema(t, nil, &executed)
+	ast := testutil.TestParse(t, `mutation { write(value: "a") }`)
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema: schema,
+		AST:    ast,
+		BeginMutation: func(ctx context.Context) (context.Context, func(error) error) {
+			return context.WithValue(ctx, mutationTxKey{}, "params"), func(err error) error {
+				return gqlerrors.NewFormattedError("commit failed")
+			}
+		},
+	})
+	if expected := []string{"params:a"}; !reflect.DeepEqual(executed, expected) {
+		t.Fatalf("expected %v, got %v", expected, executed)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Message != "commit failed" {
+		t.Fatalf("expected the commit error, got %v", result.Errors)
+	}
+}
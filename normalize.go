@@ -0,0 +1,49 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/printer"
+)
+
+// NormalizeQuery prints document in a canonical form: the ignored tokens (whitespace, commas and
+// comments) of its source and its formatting don't change the result, and the fragments that
+// aren't spread by any operation are left out. The definitions are printed in their order, so
+// documents differing only in formatting normalize to the same string. document isn't modified.
+func NormalizeQuery(document *ast.Document) string {
+	normalized := ast.NewDocument(&ast.Document{Loc: document.Loc})
+	used := usedFragments(document)
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && (fragment.Name == nil || !used[fragment.Name.Value]) {
+			continue
+		}
+		normalized.Definitions = append(normalized.Definitions, definition)
+	}
+	printed, _ := printer.PrintWithOptions(normalized, printer.Options{OmitComments: true}).(string)
+	return printed
+}
+
+// QueryHash returns the hex-encoded sha256 hash of the normalized document, as returned by
+// NormalizeQuery, which is a stable identifier of the query for persisted queries, caching and
+// logging.
+func QueryHash(document *ast.Document) string {
+	hash := sha256.Sum256([]byte(NormalizeQuery(document)))
+	return hex.EncodeToString(hash[:])
+}
+
+// usedFragments returns the names of the fragments spread by the operations of document,
+// directly or through other fragments.
+func usedFragments(document *ast.Document) map[string]bool {
+	dependencies := FragmentDependencies(document)
+	used := map[string]bool{}
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			for name := range OperationFragments(operation, dependencies) {
+				used[name] = true
+			}
+		}
+	}
+	return used
+}
@@ -0,0 +1,89 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/parser"
+)
+
+func parseDocument(t *testing.T, query string) *ast.Document {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return document
+}
+
+func TestNormalizeQuery_CanonicalizesFormattingAndRemovesUnusedFragments(t *testing.T) {
+	document := parseDocument(t, `
+		# the hero
+		query Hero($episode: Episode) {
+			hero(episode: $episode) { ...HeroFields, }
+		}
+		fragment Unused on Character { id }
+		fragment HeroFields on Character { name ...FriendFields }
+		fragment FriendFields on Character { friends { name } }
+	`)
+	expected := `query Hero($episode: Episode) {
+  hero(episode: $episode) {
+    ...HeroFields
+  }
+}
+
+fragment HeroFields on Character {
+  name
+  ...FriendFields
+}
+
+fragment FriendFields on Character {
+  friends {
+    name
+  }
+}
+`
+	if normalized := graphql.NormalizeQuery(document); normalized != expected {
+		t.Fatalf("unexpected normalized query:\n%s", normalized)
+	}
+	if len(document.Definitions) != 4 {
+		t.Fatalf("expected the document not to be modified, got %d definitions", len(document.Definitions))
+	}
+}
+
+func TestQueryHash_IsStableAcrossFormatting(t *testing.T) {
+	hash := graphql.QueryHash(parseDocument(t, `{ hero { name } }`))
+	same := graphql.QueryHash(parseDocument(t, "query {\n  hero {\n    name,\n  }\n} fragment F on Droid { id }"))
+	different := graphql.QueryHash(parseDocument(t, `{ hero { id } }`))
+	if hash != same {
+		t.Fatalf("expected the same hash, got %s and %s", hash, same)
+	}
+	if hash == different {
+		t.Fatalf("expected different queries to have different hashes")
+	}
+	if len(hash) != 64 {
+		t.Fatalf("expected a hex-encoded sha256 hash, got %q", hash)
+	}
+}
+
+func TestQueryHash_IgnoresComments(t *testing.T) {
+	hash := graphql.QueryHash(parseDocument(t, `{ hero { name } }`))
+	document, err := parser.Parse(parser.ParseParams{
+		Source:  "# the hero\n{\n  # their name\n  hero { name }\n}",
+		Options: parser.ParseOptions{KeepComments: true},
+	})
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if commented := graphql.QueryHash(document); commented != hash {
+		t.Fatalf("expected the comments not to change the hash, got %s and %s\n%s", hash, commented, graphql.NormalizeQuery(document))
+	}
+}
+
+func TestNormalizeQuery_SkipsFragmentsWithoutName(t *testing.T) {
+	document := parseDocument(t, `{ hero { name } }`)
+	document.Definitions = append(document.Definitions, ast.NewFragmentDefinition(&ast.FragmentDefinition{}))
+	if normalized := graphql.NormalizeQuery(document); normalized != graphql.NormalizeQuery(parseDocument(t, `{ hero { name } }`)) {
+		t.Fatalf("expected the fragment without name to be left out, got:\n%s", normalized)
+	}
+}
@@ -0,0 +1,101 @@
+package graphql_test
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+type optional[T any] struct {
+	value T
+	set   bool
+}
+
+func (o optional[T]) NullableValue() (interface{}, bool) {
+	return o.value, o.set
+}
+
+func TestNullableValues(t *testing.T) {
+	name := "Alice"
+	petType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Pet",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.String},
+		},
+	})
+	fields := graphql.Fields{}
+	for fieldName, field := range map[string]struct {
+		ttype graphql.Output
+		value interface{}
+	}{
+		"validString":   {graphql.String, sql.NullString{String: "a", Valid: true}},
+		"invalidString": {graphql.String, sql.NullString{String: "a"}},
+		"validInt":      {graphql.Int, &sql.NullInt64{Int64: 42, Valid: true}},
+		"invalidInt":    {graphql.NewNonNull(graphql.Int), sql.NullInt64{}},
+		"validBool":     {graphql.Boolean, sql.NullBool{Bool: true, Valid: true}},
+		"nilPointer":    {graphql.String, (*string)(nil)},
+		"pointer":       {graphql.String, &name},
+		"validPet":      {petType, optional[map[string]interface{}]{value: map[string]interface{}{"name": "Odie"}, set: true}},
+		"unsetPet":      {petType, optional[map[string]interface{}]{}},
+	} {
+		value := field.value
+		fields[fieldName] = &graphql.Field{
+			Type: field.ttype,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return value, nil
+			},
+		}
+	}
+	fields["echo"] = &graphql.Field{
+		Type: graphql.NewList(graphql.Int),
+		Args: graphql.FieldConfigArgument{
+			"values": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.Int)},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Args["values"], nil
+		},
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: fields}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		RequestString: `query ($values: [Int]) {
+			validString invalidString validInt validBool nilPointer pointer
+			validPet { name } unsetPet { name }
+			echo(values: $values)
+		}`,
+		VariableValues: map[string]interface{}{
+			"values": []interface{}{sql.NullInt64{Int64: 1, Valid: true}, sql.NullInt64{}, optional[int]{value: 3, set: true}},
+		},
+	})
+	if len(result.Errors) != 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	expected := map[string]interface{}{
+		"validString":   "a",
+		"invalidString": nil,
+		"validInt":      42,
+		"validBool":     true,
+		"nilPointer":    nil,
+		"pointer":       "Alice",
+		"validPet":      map[string]interface{}{"name": "Odie"},
+		"unsetPet":      nil,
+		"echo":          []interface{}{1, nil, 3},
+	}
+	if !reflect.DeepEqual(expected, result.Data) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result.Data))
+	}
+
+	result = graphql.Do(graphql.Params{Schema: schema, RequestString: `{ invalidInt }`})
+	if len(result.Errors) != 1 || result.Errors[0].Message != "Cannot return null for non-nullable field Query.invalidInt." {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+}
@@ -0,0 +1,76 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// OperationContext describes the operation executed by a request, as parsed and prepared for its
+// execution, so that logs and audits don't need to parse the query again. It is available to the
+// extensions, middlewares, resolvers and callbacks with OperationContextFromContext, and is filled
+// in as the request goes on: the document and operation once it is parsed, the complexity once it
+// is validated, and the variables once they are coerced, before the execution.
+type OperationContext struct {
+	// OperationType is "query", "mutation" or "subscription".
+	OperationType string
+	// OperationName is the name of the executed operation, "" if it is anonymous.
+	OperationName string
+
+	// Document is the parsed document of the request.
+	Document *ast.Document
+	// Operation is the executed operation of the document.
+	Operation *ast.OperationDefinition
+
+	// Variables are the variables of the operation coerced to the types of their definitions,
+	// with their default values.
+	Variables map[string]interface{}
+
+	// Complexity is the cost of the operation, as computed when Params.MaxCost or
+	// Params.RateLimitFn is set, 0 otherwise.
+	Complexity int
+
+	// Started is when the request started being processed.
+	Started time.Time
+}
+
+type operationContextKey struct{}
+
+// WithOperationContext returns a context holding operation, to be filled in with the operation
+// executed with it. Do adds one to the context of the requests which don't have one already.
+func WithOperationContext(ctx context.Context, operation *OperationContext) context.Context {
+	return context.WithValue(ctx, operationContextKey{}, operation)
+}
+
+// OperationContextFromContext returns the OperationContext of the request being executed with ctx,
+// or nil.
+func OperationContextFromContext(ctx context.Context) *OperationContext {
+	operation, _ := ctx.Value(operationContextKey{}).(*OperationContext)
+	return operation
+}
+
+// setDocument fills in the operation of the document executed by the request.
+func (oc *OperationContext) setDocument(document *ast.Document, operationName string) {
+	oc.Document = document
+	oc.Operation = selectedOperation(document, operationName)
+	if oc.Operation == nil {
+		return
+	}
+	oc.OperationName = ""
+	if oc.Operation.Name != nil {
+		oc.OperationName = oc.Operation.Name.Value
+	}
+	oc.OperationType = oc.Operation.Operation
+}
+
+// setVariables fills in the variables of the operation coerced from the values of the request,
+// unless they are invalid, which fails the execution.
+func (oc *OperationContext) setVariables(schema Schema, values map[string]interface{}) {
+	if oc.Operation == nil {
+		return
+	}
+	if variables, err := getVariableValues(schema, oc.Operation.GetVariableDefinitions(), values); err == nil {
+		oc.Variables = variables
+	}
+}
@@ -0,0 +1,91 @@
+package graphql_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+)
+
+func TestOperationContext_AvailableToMiddlewaresAndResolvers(t *testing.T) {
+	var fromMiddleware, fromResolver *graphql.OperationContext
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"greeting": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{
+						"name":  &graphql.ArgumentConfig{Type: graphql.String},
+						"times": &graphql.ArgumentConfig{Type: graphql.Int},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						fromResolver = graphql.OperationContextFromContext(p.Context)
+						return "hello", nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema.InstrumentField("Query", "", func(next graphql.FieldResolveFn) graphql.FieldResolveFn {
+		return func(p graphql.ResolveParams) (interface{}, error) {
+			fromMiddleware = graphql.OperationContextFromContext(p.Context)
+			return next(p)
+		}
+	})
+
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  `query Greet($name: String, $times: Int = 2) { greeting(name: $name, times: $times) }`,
+		VariableValues: map[string]interface{}{"name": "world", "unknown": true},
+	})
+	if result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+
+	if fromMiddleware == nil || fromMiddleware != fromResolver {
+		t.Fatalf("expected the same OperationContext in the middleware and the resolver, got %v and %v", fromMiddleware, fromResolver)
+	}
+	if fromResolver.OperationName != "Greet" || fromResolver.OperationType != "query" {
+		t.Fatalf("unexpected operation %q of type %q", fromResolver.OperationName, fromResolver.OperationType)
+	}
+	expected := map[string]interface{}{"name": "world", "times": 2}
+	if !reflect.DeepEqual(fromResolver.Variables, expected) {
+		t.Fatalf("expected the coerced variables %v, got %v", expected, fromResolver.Variables)
+	}
+	if fromResolver.Complexity != 0 {
+		t.Fatalf("expected no complexity without MaxCost, got %d", fromResolver.Complexity)
+	}
+	if fromResolver.Started.IsZero() {
+		t.Fatal("expected a start time")
+	}
+}
+
+func TestOperationContext_FilledInWhenProvided(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operation := &graphql.OperationContext{}
+	graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `mutation { ok }`,
+		Context:       graphql.WithOperationContext(context.Background(), operation),
+	})
+	if operation.OperationType != "mutation" || operation.Document == nil {
+		t.Fatalf("expected the OperationContext to be filled in, got %+v", operation)
+	}
+	if operation.Started.IsZero() {
+		t.Fatal("expected a start time")
+	}
+}
@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/visitor"
+)
+
+// SeparateOperations splits document into a document per operation, holding the operation and
+// the fragments it spreads, directly or through other fragments, so each operation can be stored
+// or forwarded on its own. The documents are keyed by operation name, the anonymous operation
+// being keyed by "", and keep the definitions in their order in document, which isn't modified.
+func SeparateOperations(document *ast.Document) map[string]*ast.Document {
+	dependencies := FragmentDependencies(document)
+	separated := map[string]*ast.Document{}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		used := transitiveFragments(dependencies, spreadFragments(operation))
+		separatedDocument := ast.NewDocument(&ast.Document{Loc: document.Loc})
+		for _, definition := range document.Definitions {
+			switch definition := definition.(type) {
+			case *ast.OperationDefinition:
+				if definition != operation {
+					continue
+				}
+			case *ast.FragmentDefinition:
+				if definition.Name == nil || !used[definition.Name.Value] {
+					continue
+				}
+			default:
+				continue
+			}
+			separatedDocument.Definitions = append(separatedDocument.Definitions, definition)
+		}
+		separated[operationName(operation)] = separatedDocument
+	}
+	return separated
+}
+
+// FragmentDependencies returns the dependency graph of the fragments of document: the names of
+// the fragments spread directly by each fragment, by fragment name, in the order of their first
+// spread.
+func FragmentDependencies(document *ast.Document) map[string][]string {
+	dependencies := map[string][]string{}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			dependencies[fragment.Name.Value] = spreadFragments(fragment)
+		}
+	}
+	return dependencies
+}
+
+// OperationFragments returns the names of the fragments needed by operation, spread directly or
+// through other fragments, given the dependencies of the fragments of its document as returned by
+// FragmentDependencies.
+func OperationFragments(operation *ast.OperationDefinition, dependencies map[string][]string) map[string]bool {
+	return transitiveFragments(dependencies, spreadFragments(operation))
+}
+
+// spreadFragments returns the names of the fragments spread directly by node, in the order of
+// their first spread.
+func spreadFragments(node ast.Node) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	v := visitor.NewTyped()
+	visitor.Enter(v, func(spread *ast.FragmentSpread, p visitor.VisitFuncParams) (string, interface{}) {
+		if spread.Name != nil && !seen[spread.Name.Value] {
+			seen[spread.Name.Value] = true
+			names = append(names, spread.Name.Value)
+		}
+		return visitor.Continue()
+	})
+	visitor.Visit(node, v.Options(), nil)
+	return names
+}
+
+// transitiveFragments returns the names of the fragments spread, the ones they depend on, and so
+// on, ignoring the cycles.
+func transitiveFragments(dependencies map[string][]string, spread []string) map[string]bool {
+	used := map[string]bool{}
+	toVisit := append([]string{}, spread...)
+	for len(toVisit) != 0 {
+		name := toVisit[0]
+		toVisit = toVisit[1:]
+		if used[name] {
+			continue
+		}
+		used[name] = true
+		toVisit = append(toVisit, dependencies[name]...)
+	}
+	return used
+}
+
+func operationName(operation *ast.OperationDefinition) string {
+	if operation.Name == nil {
+		return ""
+	}
+	return operation.Name.Value
+}
@@ -0,0 +1,93 @@
+package graphql_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/printer"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+const multiOperationQuery = `
+	query Hero { hero { ...HeroFields } }
+	fragment HeroFields on Character { name ...FriendFields }
+	mutation Rename { rename { ...NameFields } }
+	fragment FriendFields on Character { friends { ...NameFields ...HeroFields } }
+	fragment NameFields on Character { name }
+	{ __typename }
+`
+
+func TestSeparateOperations(t *testing.T) {
+	document := parseDocument(t, multiOperationQuery)
+	separated := graphql.SeparateOperations(document)
+
+	expected := map[string]string{
+		"Hero": `query Hero {
+  hero {
+    ...HeroFields
+  }
+}
+
+fragment HeroFields on Character {
+  name
+  ...FriendFields
+}
+
+fragment FriendFields on Character {
+  friends {
+    ...NameFields
+    ...HeroFields
+  }
+}
+
+fragment NameFields on Character {
+  name
+}
+`,
+		"Rename": `mutation Rename {
+  rename {
+    ...NameFields
+  }
+}
+
+fragment NameFields on Character {
+  name
+}
+`,
+		"": `{
+  __typename
+}
+`,
+	}
+	printed := map[string]string{}
+	for name, document := range separated {
+		printed[name] = printer.Print(document).(string)
+	}
+	if !reflect.DeepEqual(expected, printed) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, printed))
+	}
+	if len(document.Definitions) != 6 {
+		t.Fatalf("expected the document to be left unchanged, got %v definitions", len(document.Definitions))
+	}
+}
+
+func TestFragmentDependencies(t *testing.T) {
+	document := parseDocument(t, multiOperationQuery)
+	dependencies := graphql.FragmentDependencies(document)
+	expected := map[string][]string{
+		"HeroFields":   {"FriendFields"},
+		"FriendFields": {"NameFields", "HeroFields"},
+		"NameFields":   {},
+	}
+	if !reflect.DeepEqual(expected, dependencies) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, dependencies))
+	}
+
+	hero := document.Definitions[0].(*ast.OperationDefinition)
+	expectedFragments := map[string]bool{"HeroFields": true, "FriendFields": true, "NameFields": true}
+	if fragments := graphql.OperationFragments(hero, dependencies); !reflect.DeepEqual(expectedFragments, fragments) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedFragments, fragments))
+	}
+}
@@ -0,0 +1,266 @@
+package graphql
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/printer"
+)
+
+// PrintSchema prints the SDL of a schema: its types and directives, other than the introspection
+// types, the specified scalars and directives and the internal fields and types, in the order of
+// their names. The root types are given by a schema definition unless they are named Query,
+// Mutation and Subscription.
+//
+// The output only depends on the definitions of the schema, so it can be hashed to tell if a
+// schema changed.
+func PrintSchema(schema Schema) string {
+	document := ast.NewDocument(nil)
+	if definition := schemaDefinition(&schema); definition != nil {
+		document.Definitions = append(document.Definitions, definition)
+	}
+
+	directives := append([]*Directive(nil), schema.Directives()...)
+	sort.Slice(directives, func(i, j int) bool { return directives[i].Name < directives[j].Name })
+	for _, directive := range directives {
+		if isSpecifiedDirective(directive) {
+			continue
+		}
+		locations := []*ast.Name{}
+		for _, location := range directive.Locations {
+			locations = append(locations, printName(location))
+		}
+		document.Definitions = append(document.Definitions, ast.NewDirectiveDefinition(&ast.DirectiveDefinition{
+			Name:        printName(directive.Name),
+			Description: printDescription(directive.Description),
+			Arguments:   printArguments(directive.Args),
+			Locations:   locations,
+		}))
+	}
+
+	typeMap := schema.TypeMap()
+	names := make([]string, 0, len(typeMap))
+	for name := range typeMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.HasPrefix(name, "__") || isSpecifiedScalarName(name) || isInternalType(typeMap[name]) {
+			continue
+		}
+		if definition := printTypeDefinition(typeMap[name]); definition != nil {
+			document.Definitions = append(document.Definitions, definition)
+		}
+	}
+
+	printed, _ := printer.Print(document).(string)
+	return printed
+}
+
+// schemaDefinition returns the schema definition of a schema, or nil if its root types have the
+// conventional names.
+func schemaDefinition(schema *Schema) *ast.SchemaDefinition {
+	roots := []struct {
+		operation string
+		rootType  *Object
+	}{
+		{ast.OperationTypeQuery, schema.QueryType()},
+		{ast.OperationTypeMutation, schema.MutationType()},
+		{ast.OperationTypeSubscription, schema.SubscriptionType()},
+	}
+	conventional := true
+	definition := ast.NewSchemaDefinition(nil)
+	for _, root := range roots {
+		if root.rootType == nil || isInternalType(root.rootType) {
+			continue
+		}
+		if !strings.EqualFold(root.rootType.Name(), root.operation) {
+			conventional = false
+		}
+		definition.OperationTypes = append(definition.OperationTypes, ast.NewOperationTypeDefinition(&ast.OperationTypeDefinition{
+			Operation: root.operation,
+			Type:      ast.NewNamed(&ast.Named{Name: printName(root.rootType.Name())}),
+		}))
+	}
+	if conventional {
+		return nil
+	}
+	return definition
+}
+
+func isSpecifiedDirective(directive *Directive) bool {
+	for _, specified := range SpecifiedDirectives {
+		if directive.Name == specified.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpecifiedScalarName(name string) bool {
+	switch name {
+	case String.Name(), Int.Name(), Float.Name(), Boolean.Name(), ID.Name():
+		return true
+	}
+	return false
+}
+
+func printTypeDefinition(ttype Type) ast.Node {
+	switch ttype := ttype.(type) {
+	case *Scalar:
+		return ast.NewScalarDefinition(&ast.ScalarDefinition{
+			Name:        printName(ttype.Name()),
+			Description: printDescription(ttype.Description()),
+		})
+	case *Object:
+		interfaces := []*ast.Named{}
+		for _, iface := range ttype.Interfaces() {
+			if isInternalType(iface) {
+				continue
+			}
+			interfaces = append(interfaces, ast.NewNamed(&ast.Named{Name: printName(iface.Name())}))
+		}
+		return ast.NewObjectDefinition(&ast.ObjectDefinition{
+			Name:        printName(ttype.Name()),
+			Description: printDescription(ttype.Description()),
+			Interfaces:  interfaces,
+			Fields:      printFields(ttype.Fields()),
+		})
+	case *Interface:
+		return ast.NewInterfaceDefinition(&ast.InterfaceDefinition{
+			Name:        printName(ttype.Name()),
+			Description: printDescription(ttype.Description()),
+			Fields:      printFields(ttype.Fields()),
+		})
+	case *Union:
+		types := []*ast.Named{}
+		for _, member := range ttype.Types() {
+			if isInternalType(member) {
+				continue
+			}
+			types = append(types, ast.NewNamed(&ast.Named{Name: printName(member.Name())}))
+		}
+		return ast.NewUnionDefinition(&ast.UnionDefinition{
+			Name:        printName(ttype.Name()),
+			Description: printDescription(ttype.Description()),
+			Types:       types,
+		})
+	case *Enum:
+		enumValues := append([]*EnumValueDefinition(nil), ttype.Values()...)
+		sort.Slice(enumValues, func(i, j int) bool { return enumValues[i].Name < enumValues[j].Name })
+		values := []*ast.EnumValueDefinition{}
+		for _, value := range enumValues {
+			values = append(values, ast.NewEnumValueDefinition(&ast.EnumValueDefinition{
+				Name:        printName(value.Name),
+				Description: printDescription(value.Description),
+				Directives:  printDeprecation(value.DeprecationReason),
+			}))
+		}
+		return ast.NewEnumDefinition(&ast.EnumDefinition{
+			Name:        printName(ttype.Name()),
+			Description: printDescription(ttype.Description()),
+			Values:      values,
+		})
+	case *InputObject:
+		fieldMap := ttype.Fields()
+		fields := []*ast.InputValueDefinition{}
+		for _, name := range sortedInputFieldNames(fieldMap) {
+			field := fieldMap[name]
+			fields = append(fields, printInputValue(field.Name(), field.Description(), field.Type, field.DefaultValue, field.defaultLiteral))
+		}
+		return ast.NewInputObjectDefinition(&ast.InputObjectDefinition{
+			Name:        printName(ttype.Name()),
+			Description: printDescription(ttype.Description()),
+			Fields:      fields,
+		})
+	}
+	return nil
+}
+
+func sortedInputFieldNames(fieldMap InputObjectFieldMap) []string {
+	names := make([]string, 0, len(fieldMap))
+	for name := range fieldMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func printFields(fieldMap FieldDefinitionMap) []*ast.FieldDefinition {
+	names := make([]string, 0, len(fieldMap))
+	for name, field := range fieldMap {
+		if !isInternalField(field) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	fields := []*ast.FieldDefinition{}
+	for _, name := range names {
+		field := fieldMap[name]
+		fields = append(fields, ast.NewFieldDefinition(&ast.FieldDefinition{
+			Name:        printName(field.Name),
+			Description: printDescription(field.Description),
+			Arguments:   printArguments(field.Args),
+			Type:        printTypeRef(field.Type),
+			Directives:  printDeprecation(field.DeprecationReason),
+		}))
+	}
+	return fields
+}
+
+func printArguments(args []*Argument) []*ast.InputValueDefinition {
+	args = append([]*Argument(nil), args...)
+	sort.Slice(args, func(i, j int) bool { return args[i].Name() < args[j].Name() })
+	definitions := []*ast.InputValueDefinition{}
+	for _, arg := range args {
+		definitions = append(definitions, printInputValue(arg.Name(), arg.Description(), arg.Type, arg.DefaultValue, arg.defaultLiteral))
+	}
+	return definitions
+}
+
+func printInputValue(name, description string, ttype Input, defaultValue interface{}, literal ast.Value) *ast.InputValueDefinition {
+	return ast.NewInputValueDefinition(&ast.InputValueDefinition{
+		Name:         printName(name),
+		Description:  printDescription(description),
+		Type:         printTypeRef(ttype),
+		DefaultValue: defaultValueLiteral(defaultValue, literal, ttype),
+	})
+}
+
+func printTypeRef(ttype Type) ast.Type {
+	switch ttype := ttype.(type) {
+	case *List:
+		return ast.NewList(&ast.List{Type: printTypeRef(ttype.OfType)})
+	case *NonNull:
+		return ast.NewNonNull(&ast.NonNull{Type: printTypeRef(ttype.OfType)})
+	}
+	return ast.NewNamed(&ast.Named{Name: printName(ttype.Name())})
+}
+
+// printDeprecation returns the @deprecated directive of a deprecated field or enum value, whose
+// reason is left out when it is the default one.
+func printDeprecation(reason string) []*ast.Directive {
+	if reason == "" {
+		return nil
+	}
+	directive := ast.NewDirective(&ast.Directive{Name: printName(DeprecatedDirective.Name)})
+	if reason != DefaultDeprecationReason {
+		directive.Arguments = []*ast.Argument{ast.NewArgument(&ast.Argument{
+			Name:  printName("reason"),
+			Value: ast.NewStringValue(&ast.StringValue{Value: reason}),
+		})}
+	}
+	return []*ast.Directive{directive}
+}
+
+func printDescription(description string) *ast.StringValue {
+	if description == "" {
+		return nil
+	}
+	return ast.NewStringValue(&ast.StringValue{Value: description, Block: true})
+}
+
+func printName(name string) *ast.Name {
+	return ast.NewName(&ast.Name{Value: name})
+}
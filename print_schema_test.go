@@ -0,0 +1,176 @@
+package graphql_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/language/parser"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+func TestPrintSchema(t *testing.T) {
+	color := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Color",
+		Values: graphql.EnumValueConfigMap{
+			"RED":  &graphql.EnumValueConfig{Value: 1},
+			"BLUE": &graphql.EnumValueConfig{Value: 2, DeprecationReason: "Use RED"},
+		},
+	})
+	filter := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "Filter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"limit":  &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 10},
+			"prefix": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+	named := graphql.NewInterface(graphql.InterfaceConfig{
+		Name: "Named",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	user := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "User",
+		Description: "A user\nof the service.",
+		Interfaces:  []*graphql.Interface{named},
+		Fields: graphql.Fields{
+			"name":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"color": &graphql.Field{Type: color, DeprecationReason: graphql.DefaultDeprecationReason},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Root",
+			Fields: graphql.Fields{
+				"users": &graphql.Field{
+					Type: graphql.NewList(graphql.NewNonNull(user)),
+					Args: graphql.FieldConfigArgument{
+						"filter": &graphql.ArgumentConfig{Type: filter},
+						"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 5},
+					},
+				},
+			},
+		}),
+		Directives: append(graphql.SpecifiedDirectives, graphql.LiveDirective),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `schema {
+  query: Root
+}
+
+"""Directs the executor to send the result of the query again every time the data it depends on changes."""
+directive @live on QUERY
+
+enum Color {
+  BLUE @deprecated(reason: "Use RED")
+  RED
+}
+
+input Filter {
+  limit: Int = 10
+  prefix: String
+}
+
+interface Named {
+  name: String!
+}
+
+type Root {
+  users(filter: Filter, first: Int = 5): [User!]
+}
+
+"""
+A user
+of the service.
+"""
+type User implements Named {
+  color: Color @deprecated
+  name: String!
+}
+`
+	if printed := graphql.PrintSchema(schema); printed != expected {
+		t.Fatalf("unexpected SDL, diff: %v", testutil.Diff(expected, printed))
+	}
+	if graphql.PrintSchema(schema) != expected {
+		t.Fatalf("expected the SDL to be the same when printed again")
+	}
+}
+
+func TestPrintSchema_ComplexDefaultValues(t *testing.T) {
+	color := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Color",
+		Values: graphql.EnumValueConfigMap{
+			"RED":  &graphql.EnumValueConfig{Value: 1},
+			"BLUE": &graphql.EnumValueConfig{Value: 2},
+		},
+	})
+	filter := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "Filter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"colors": &graphql.InputObjectFieldConfig{Type: graphql.NewList(color)},
+			"prefix": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+	literal, err := parser.ParseValue(parser.ParseParams{Source: `{prefix: "a", colors: [BLUE]}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"count": &graphql.Field{
+					Type: graphql.Int,
+					Args: graphql.FieldConfigArgument{
+						"filter": &graphql.ArgumentConfig{
+							Type:         filter,
+							DefaultValue: map[string]interface{}{"colors": []interface{}{1, 2}, "prefix": "b"},
+						},
+						"literal": &graphql.ArgumentConfig{Type: filter, DefaultValue: literal},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						colors := p.Args["literal"].(map[string]interface{})["colors"].([]interface{})
+						return colors[0], nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type Query {
+  count(filter: Filter = {colors: [RED, BLUE], prefix: "b"}, literal: Filter = {prefix: "a", colors: [BLUE]}): Int
+}
+`
+	if printed := graphql.PrintSchema(schema); !strings.HasSuffix(printed, expected) {
+		t.Fatalf("unexpected SDL:\n%s", printed)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ count __type(name: "Query") { fields { args { name defaultValue } } } }`,
+	})
+	expectedData := map[string]interface{}{
+		"count": 2,
+		"__type": map[string]interface{}{
+			"fields": []interface{}{
+				map[string]interface{}{
+					"args": []interface{}{
+						map[string]interface{}{"name": "filter", "defaultValue": `{colors: [RED, BLUE], prefix: "b"}`},
+						map[string]interface{}{"name": "literal", "defaultValue": `{prefix: "a", colors: [BLUE]}`},
+					},
+				},
+			},
+		},
+	}
+	if len(result.Errors) != 0 || !reflect.DeepEqual(result.Data, expectedData) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
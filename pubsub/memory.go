@@ -0,0 +1,80 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBroker is a Broker for a single instance, carrying the messages in memory.
+type MemoryBroker struct {
+	mutex       sync.Mutex
+	subscribers map[string]map[*memorySubscriber]struct{}
+}
+
+type memorySubscriber struct {
+	ctx      context.Context
+	messages chan []byte
+}
+
+// NewMemoryBroker returns an in-process Broker.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subscribers: map[string]map[*memorySubscriber]struct{}{}}
+}
+
+// Publish implements Broker. It waits for the subscribers to receive the message.
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, message []byte) error {
+	b.mutex.Lock()
+	subscribers := make([]*memorySubscriber, 0, len(b.subscribers[topic]))
+	for subscriber := range b.subscribers[topic] {
+		subscribers = append(subscribers, subscriber)
+	}
+	b.mutex.Unlock()
+
+	for _, subscriber := range subscribers {
+		select {
+		case subscriber.messages <- message:
+		case <-subscriber.ctx.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Subscribe implements Broker.
+func (b *MemoryBroker) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	subscriber := &memorySubscriber{ctx: ctx, messages: make(chan []byte)}
+	messages := make(chan []byte)
+
+	b.mutex.Lock()
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = map[*memorySubscriber]struct{}{}
+	}
+	b.subscribers[topic][subscriber] = struct{}{}
+	b.mutex.Unlock()
+
+	go func() {
+		defer close(messages)
+		defer func() {
+			b.mutex.Lock()
+			defer b.mutex.Unlock()
+			delete(b.subscribers[topic], subscriber)
+			if len(b.subscribers[topic]) == 0 {
+				delete(b.subscribers, topic)
+			}
+		}()
+		for {
+			select {
+			case message := <-subscriber.messages:
+				select {
+				case messages <- message:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return messages, nil
+}
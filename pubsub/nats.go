@@ -0,0 +1,244 @@
+package pubsub
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// NATSBroker is a Broker carrying the messages with NATS core messaging. A topic is published to
+// the subject made of SubjectPrefix and the hash of the topic, since topics may hold characters
+// subjects can't. A single connection is shared by the publications and subscriptions, it is
+// opened again for the next ones when it is lost, which ends the subscriptions.
+type NATSBroker struct {
+	// Addr is the address of the NATS server, like "localhost:4222".
+	Addr string
+
+	// SubjectPrefix is prepended to the subjects, "graphql." if empty.
+	SubjectPrefix string
+
+	// Token, or User and Password, authenticate the connection when set.
+	Token    string
+	User     string
+	Password string
+
+	// Dial opens the connection to NATS, a TCP connection to Addr if nil.
+	Dial func(ctx context.Context) (net.Conn, error)
+
+	mutex sync.Mutex
+	conn  *natsConn
+}
+
+// Subject returns the subject the messages of topic are published to.
+func (b *NATSBroker) Subject(topic string) string {
+	prefix := b.SubjectPrefix
+	if prefix == "" {
+		prefix = "graphql."
+	}
+	hash := sha256.Sum256([]byte(topic))
+	return prefix + hex.EncodeToString(hash[:])
+}
+
+// Publish implements Broker.
+func (b *NATSBroker) Publish(ctx context.Context, topic string, message []byte) error {
+	conn, err := b.connection(ctx)
+	if err != nil {
+		return err
+	}
+	command := append([]byte("PUB "+b.Subject(topic)+" "+strconv.Itoa(len(message))+"\r\n"), message...)
+	return conn.write(append(command, "\r\n"...))
+}
+
+// Subscribe implements Broker.
+func (b *NATSBroker) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	conn, err := b.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sid, received, err := conn.subscribe(b.Subject(topic))
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make(chan []byte)
+	go func() {
+		defer close(messages)
+		defer conn.unsubscribe(sid)
+		for {
+			select {
+			case message := <-received:
+				select {
+				case messages <- message:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-conn.lost:
+				return
+			}
+		}
+	}()
+	return messages, nil
+}
+
+// connection returns the connection to NATS, opening it if needed.
+func (b *NATSBroker) connection(ctx context.Context) (*natsConn, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.conn != nil {
+		select {
+		case <-b.conn.lost:
+		default:
+			return b.conn, nil
+		}
+	}
+
+	var conn net.Conn
+	var err error
+	if b.Dial != nil {
+		conn, err = b.Dial(ctx)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", b.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	connect, _ := json.Marshal(map[string]interface{}{
+		"verbose":    false,
+		"pedantic":   false,
+		"lang":       "go",
+		"name":       "github.com/fiatjaf/graphql/pubsub",
+		"protocol":   1,
+		"auth_token": b.Token,
+		"user":       b.User,
+		"pass":       b.Password,
+	})
+	if _, err := conn.Write([]byte("CONNECT " + string(connect) + "\r\n")); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	b.conn = &natsConn{
+		conn:          conn,
+		subscriptions: map[string]*natsSubscription{},
+		lost:          make(chan struct{}),
+	}
+	go b.conn.read()
+	return b.conn, nil
+}
+
+// natsConn is a connection speaking the client protocol of NATS.
+type natsConn struct {
+	conn       net.Conn
+	writeMutex sync.Mutex
+
+	mutex         sync.Mutex
+	nextSID       int
+	subscriptions map[string]*natsSubscription
+
+	// lost is closed when the connection is lost.
+	lost chan struct{}
+}
+
+// natsSubscription receives the messages of a subscription from the connection.
+type natsSubscription struct {
+	received chan []byte
+
+	// gone is closed when the subscription is unsubscribed.
+	gone chan struct{}
+}
+
+func (c *natsConn) write(command []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if _, err := c.conn.Write(command); err != nil {
+		c.conn.Close()
+		return err
+	}
+	return nil
+}
+
+func (c *natsConn) subscribe(subject string) (string, chan []byte, error) {
+	c.mutex.Lock()
+	c.nextSID++
+	sid := strconv.Itoa(c.nextSID)
+	subscription := &natsSubscription{received: make(chan []byte), gone: make(chan struct{})}
+	c.subscriptions[sid] = subscription
+	c.mutex.Unlock()
+	if err := c.write([]byte("SUB " + subject + " " + sid + "\r\n")); err != nil {
+		c.unsubscribe(sid)
+		return "", nil, err
+	}
+	return sid, subscription.received, nil
+}
+
+func (c *natsConn) unsubscribe(sid string) {
+	c.mutex.Lock()
+	if subscription, ok := c.subscriptions[sid]; ok {
+		close(subscription.gone)
+		delete(c.subscriptions, sid)
+	}
+	c.mutex.Unlock()
+	c.write([]byte("UNSUB " + sid + "\r\n"))
+}
+
+// read handles the messages of the server until the connection is lost.
+func (c *natsConn) read() {
+	defer close(c.lost)
+	defer c.conn.Close()
+	reader := bufio.NewReader(c.conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			if c.write([]byte("PONG\r\n")) != nil {
+				return
+			}
+		case "-ERR":
+			return
+		case "MSG":
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			if len(fields) < 4 {
+				return
+			}
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			c.deliver(fields[2], payload[:size])
+		}
+	}
+}
+
+// deliver passes a message to its subscription, unless it was unsubscribed.
+func (c *natsConn) deliver(sid string, message []byte) {
+	c.mutex.Lock()
+	subscription, ok := c.subscriptions[sid]
+	c.mutex.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case subscription.received <- message:
+	case <-subscription.gone:
+	}
+}
@@ -0,0 +1,103 @@
+// Package pubsub delivers the events of subscriptions through a Broker, so that an event published
+// by one instance of a server reaches the subscribers connected to all of them. Brokers are
+// provided for NATS and Redis streams, along with an in-process one for a single instance.
+//
+// The events are published to topics named after the subscription fields and their arguments:
+//
+//	events := pubsub.New(&pubsub.RedisBroker{Addr: "localhost:6379"})
+//	subscriptionType := graphql.NewObject(graphql.ObjectConfig{
+//		Name: "Subscription",
+//		Fields: graphql.Fields{
+//			"messageAdded": &graphql.Field{
+//				Type:      messageType,
+//				Args:      graphql.FieldConfigArgument{"room": &graphql.ArgumentConfig{Type: graphql.ID}},
+//				Subscribe: events.SubscribeFn(),
+//			},
+//		},
+//	})
+//	...
+//	events.Publish(ctx, pubsub.Topic("messageAdded", map[string]interface{}{"room": "1"}), message)
+//
+// Topics can also be named with templates of the arguments, see TopicTemplate and
+// PubSub.SubscribeTemplateFn.
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/fiatjaf/graphql"
+)
+
+// Broker carries the messages published to topics to their subscribers, across the instances of
+// a server.
+type Broker interface {
+	// Publish sends message to the subscribers of topic.
+	Publish(ctx context.Context, topic string, message []byte) error
+
+	// Subscribe returns the messages published to topic until ctx is done or the subscription
+	// fails, when the channel is closed.
+	Subscribe(ctx context.Context, topic string) (<-chan []byte, error)
+}
+
+// PubSub publishes the events of subscriptions through a Broker, encoded in JSON.
+type PubSub struct {
+	broker Broker
+}
+
+// New returns a PubSub publishing through broker.
+func New(broker Broker) *PubSub {
+	return &PubSub{broker: broker}
+}
+
+// Topic returns the topic of the events of a subscription field with the given arguments: the name
+// of the field, followed by its arguments, if any, encoded in JSON with their keys sorted.
+func Topic(fieldName string, args map[string]interface{}) string {
+	if len(args) == 0 {
+		return fieldName
+	}
+	b, _ := json.Marshal(args)
+	return fieldName + ":" + string(b)
+}
+
+// Publish sends event to the subscribers of topic, on every instance.
+func (ps *PubSub) Publish(ctx context.Context, topic string, event interface{}) error {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return ps.broker.Publish(ctx, topic, message)
+}
+
+// Subscribe returns the events published to topic until ctx is done. As they are decoded from
+// JSON, the objects are maps and the numbers float64s.
+func (ps *PubSub) Subscribe(ctx context.Context, topic string) (chan interface{}, error) {
+	messages, err := ps.broker.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan interface{})
+	graphql.Go(ctx, "pubsub subscription", func() {
+		defer close(events)
+		for message := range messages {
+			var event interface{}
+			if err := json.Unmarshal(message, &event); err != nil {
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return events, nil
+}
+
+// SubscribeFn returns a Subscribe function for subscription fields, subscribing to the topic of
+// the field and its arguments, see Topic.
+func (ps *PubSub) SubscribeFn() graphql.SubscriptionFieldResolveFn {
+	return func(p graphql.ResolveParams) (chan interface{}, error) {
+		return ps.Subscribe(p.Context, Topic(p.Info.FieldName, p.Args))
+	}
+}
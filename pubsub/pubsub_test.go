@@ -0,0 +1,363 @@
+package pubsub_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/pubsub"
+)
+
+func TestTopic(t *testing.T) {
+	if topic := pubsub.Topic("messageAdded", nil); topic != "messageAdded" {
+		t.Fatalf("unexpected topic %q", topic)
+	}
+	topic := pubsub.Topic("messageAdded", map[string]interface{}{"room": "1", "kind": "text"})
+	if topic != `messageAdded:{"kind":"text","room":"1"}` {
+		t.Fatalf("unexpected topic %q", topic)
+	}
+}
+
+func TestTopicTemplate(t *testing.T) {
+	template := pubsub.MustParseTopicTemplate("messageAdded:{channelId}:{filter.kinds}")
+	topic, err := template.Topic(map[string]interface{}{
+		"channelId": "general",
+		"filter":    map[string]interface{}{"kinds": []interface{}{"text", "image"}},
+	})
+	if err != nil || topic != `messageAdded:general:["text","image"]` {
+		t.Fatalf("unexpected topic %q, error %v", topic, err)
+	}
+
+	_, err = template.Topic(map[string]interface{}{"channelId": "general"})
+	if err == nil || err.Error() != `the argument "filter.kinds" is required by the topic "messageAdded:{channelId}:{filter.kinds}"` {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, invalid := range []string{"messageAdded:{channelId", "messageAdded:channelId}", "messageAdded:{}"} {
+		if _, err := pubsub.ParseTopicTemplate(invalid); err == nil {
+			t.Fatalf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestPubSub_SubscribeTemplateFn_RequiresArguments(t *testing.T) {
+	events := pubsub.New(pubsub.NewMemoryBroker())
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"messageAdded": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{
+						"channelId": &graphql.ArgumentConfig{Type: graphql.ID},
+					},
+					Subscribe: events.SubscribeTemplateFn("messageAdded:{channelId}"),
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatalf("wrong result, unexpected errors: %v", err)
+	}
+
+	result := <-graphql.Subscribe(graphql.Params{
+		Schema:        schema,
+		RequestString: `subscription { messageAdded }`,
+		Context:       context.Background(),
+	})
+	if len(result.Errors) != 1 || result.Errors[0].Message != `the argument "channelId" is required by the topic "messageAdded:{channelId}"` {
+		t.Fatalf("unexpected result %#v", result)
+	}
+}
+
+func TestPubSub_MemoryBroker_DeliversToSubscriptionField(t *testing.T) {
+	events := pubsub.New(pubsub.NewMemoryBroker())
+	testSubscriptionField(t, events, events)
+}
+
+func TestPubSub_RedisBroker_DeliversAcrossInstances(t *testing.T) {
+	server := newFakeRedis()
+	subscriber := pubsub.New(&pubsub.RedisBroker{Prefix: "graphql:", Dial: server.dial})
+	publisher := pubsub.New(&pubsub.RedisBroker{Prefix: "graphql:", Dial: server.dial})
+	testSubscriptionField(t, subscriber, publisher)
+
+	if stream := server.lastStream(); stream != `graphql:messageAdded:{"room":"1"}` {
+		t.Fatalf("unexpected stream %q", stream)
+	}
+}
+
+func TestPubSub_NATSBroker_DeliversAcrossInstances(t *testing.T) {
+	server := newFakeNATS()
+	subscriberBroker := &pubsub.NATSBroker{Dial: server.dial}
+	subscriber := pubsub.New(subscriberBroker)
+	publisher := pubsub.New(&pubsub.NATSBroker{Dial: server.dial})
+	testSubscriptionField(t, subscriber, publisher)
+
+	subject := subscriberBroker.Subject(`messageAdded:{"room":"1"}`)
+	if !strings.HasPrefix(subject, "graphql.") || server.lastSubject() != subject {
+		t.Fatalf("unexpected subject %q, published to %q", subject, server.lastSubject())
+	}
+}
+
+// testSubscriptionField subscribes to a field resolved by subscriber and publishes events for it
+// and for another room through publisher until the subscription receives them.
+func testSubscriptionField(t *testing.T, subscriber, publisher *pubsub.PubSub) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name:   "Query",
+			Fields: graphql.Fields{"ok": &graphql.Field{Type: graphql.Boolean}},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Subscription",
+			Fields: graphql.Fields{
+				"messageAdded": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{
+						"room": &graphql.ArgumentConfig{Type: graphql.ID},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(map[string]interface{})["text"], nil
+					},
+					Subscribe: subscriber.SubscribeFn(),
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatalf("wrong result, unexpected errors: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	results := graphql.Subscribe(graphql.Params{
+		Schema:        schema,
+		RequestString: `subscription { messageAdded(room: "1") }`,
+		Context:       ctx,
+	})
+
+	// the subscription starts in the background, so events are published until one is received
+	received := make(chan struct{})
+	go func() {
+		for {
+			publisher.Publish(ctx, pubsub.Topic("messageAdded", map[string]interface{}{"room": "2"}), map[string]interface{}{"text": "elsewhere"})
+			publisher.Publish(ctx, pubsub.Topic("messageAdded", map[string]interface{}{"room": "1"}), map[string]interface{}{"text": "hello"})
+			select {
+			case <-received:
+				return
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+
+	result, ok := <-results
+	close(received)
+	if !ok {
+		t.Fatalf("subscription ended before any event")
+	}
+	expected := &graphql.Result{Data: map[string]interface{}{"messageAdded": "hello"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("unexpected result %#v", result)
+	}
+
+	cancel()
+	for range results {
+	}
+}
+
+// fakeRedis is a Redis server supporting XADD, XREVRANGE and blocking XREADs of single streams
+// over in-memory connections.
+type fakeRedis struct {
+	mutex   sync.Mutex
+	streams map[string][]string
+	added   chan struct{}
+	stream  string
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{streams: map[string][]string{}, added: make(chan struct{})}
+}
+
+func (s *fakeRedis) lastStream() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.stream
+}
+
+func (s *fakeRedis) dial(ctx context.Context) (net.Conn, error) {
+	client, server := net.Pipe()
+	go s.serve(server)
+	return client, nil
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "XADD":
+			// XADD stream MAXLEN ~ n * message payload
+			s.mutex.Lock()
+			s.streams[args[1]] = append(s.streams[args[1]], args[7])
+			id := fmt.Sprintf("%d-0", len(s.streams[args[1]]))
+			if strings.Contains(args[7], "hello") {
+				s.stream = args[1]
+			}
+			close(s.added)
+			s.added = make(chan struct{})
+			s.mutex.Unlock()
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(id), id)
+		case "XREVRANGE":
+			s.mutex.Lock()
+			entries := s.streams[args[1]]
+			s.mutex.Unlock()
+			if len(entries) == 0 {
+				fmt.Fprintf(conn, "*0\r\n")
+			} else {
+				fmt.Fprintf(conn, "*1\r\n%s", redisEntry(len(entries), entries[len(entries)-1]))
+			}
+		case "XREAD":
+			// XREAD BLOCK 0 STREAMS stream id
+			after, _ := strconv.Atoi(strings.TrimSuffix(args[5], "-0"))
+			for {
+				s.mutex.Lock()
+				entries, added := s.streams[args[4]], s.added
+				s.mutex.Unlock()
+				if len(entries) > after {
+					reply := fmt.Sprintf("*1\r\n*2\r\n$%d\r\n%s\r\n*%d\r\n", len(args[4]), args[4], len(entries)-after)
+					for i := after; i < len(entries); i++ {
+						reply += redisEntry(i+1, entries[i])
+					}
+					if _, err := io.WriteString(conn, reply); err != nil {
+						return
+					}
+					break
+				}
+				<-added
+			}
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command\r\n")
+		}
+	}
+}
+
+func redisEntry(n int, message string) string {
+	id := fmt.Sprintf("%d-0", n)
+	return fmt.Sprintf("*2\r\n$%d\r\n%s\r\n*2\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n", len(id), id, len(message), message)
+}
+
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+// fakeNATS is a NATS server supporting PUB and SUB over in-memory connections.
+type fakeNATS struct {
+	mutex         sync.Mutex
+	subscriptions map[string]map[net.Conn]string
+	subject       string
+}
+
+func newFakeNATS() *fakeNATS {
+	return &fakeNATS{subscriptions: map[string]map[net.Conn]string{}}
+}
+
+func (s *fakeNATS) lastSubject() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.subject
+}
+
+func (s *fakeNATS) dial(ctx context.Context) (net.Conn, error) {
+	client, server := net.Pipe()
+	go s.serve(server)
+	return client, nil
+}
+
+func (s *fakeNATS) serve(conn net.Conn) {
+	defer conn.Close()
+	var writeMutex sync.Mutex
+	write := func(format string, a ...interface{}) {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		fmt.Fprintf(conn, format, a...)
+	}
+	go write("INFO {\"server_id\":\"fake\"}\r\nPING\r\n")
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "SUB":
+			s.mutex.Lock()
+			if s.subscriptions[fields[1]] == nil {
+				s.subscriptions[fields[1]] = map[net.Conn]string{}
+			}
+			s.subscriptions[fields[1]][conn] = fields[2]
+			s.mutex.Unlock()
+		case "PUB":
+			size, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			s.mutex.Lock()
+			if strings.Contains(string(payload), "hello") {
+				s.subject = fields[1]
+			}
+			for subscriber, sid := range s.subscriptions[fields[1]] {
+				subscriber, sid := subscriber, sid
+				go fmt.Fprintf(subscriber, "MSG %s %s %d\r\n%s", fields[1], sid, size, payload)
+			}
+			s.mutex.Unlock()
+		}
+	}
+}
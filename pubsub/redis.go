@@ -0,0 +1,257 @@
+package pubsub
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RedisBroker is a Broker carrying the messages with Redis streams: a topic is published to the
+// stream of its name, prefixed with Prefix, which subscribers read from with blocking XREADs on
+// their own connections. The streams are trimmed to about MaxLen messages.
+type RedisBroker struct {
+	// Addr is the address of the Redis server, like "localhost:6379".
+	Addr string
+
+	// Password, when set, authenticates the connections.
+	Password string
+
+	// Prefix is prepended to the topics to name their streams, e.g. "graphql:".
+	Prefix string
+
+	// MaxLen is the approximate number of messages kept in a stream, 1000 if zero.
+	MaxLen int
+
+	// Dial opens the connections to Redis, TCP connections to Addr if nil.
+	Dial func(ctx context.Context) (net.Conn, error)
+
+	mutex sync.Mutex
+	conn  *redisConn
+}
+
+// Publish implements Broker.
+func (b *RedisBroker) Publish(ctx context.Context, topic string, message []byte) error {
+	maxLen := b.MaxLen
+	if maxLen == 0 {
+		maxLen = 1000
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.conn == nil {
+		conn, err := b.dial(ctx)
+		if err != nil {
+			return err
+		}
+		b.conn = conn
+	}
+	_, err := b.conn.do(ctx, []byte("XADD"), []byte(b.Prefix+topic), []byte("MAXLEN"), []byte("~"),
+		[]byte(strconv.Itoa(maxLen)), []byte("*"), []byte("message"), message)
+	if err != nil {
+		// the connection is opened again for the next message, unless the server refused this one
+		var redisErr redisError
+		if !errors.As(err, &redisErr) {
+			b.conn.Close()
+			b.conn = nil
+		}
+		return err
+	}
+	return nil
+}
+
+// Subscribe implements Broker. Only the messages published after the subscription are received.
+func (b *RedisBroker) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	conn, err := b.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream := []byte(b.Prefix + topic)
+
+	// the stream is read from its last message at the time of the subscription
+	lastID := []byte("0")
+	reply, err := conn.do(ctx, []byte("XREVRANGE"), stream, []byte("+"), []byte("-"), []byte("COUNT"), []byte("1"))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	for _, entry := range streamEntries(reply) {
+		lastID = entry.id
+	}
+
+	messages := make(chan []byte)
+	done := make(chan struct{})
+	go func() {
+		// closing the connection stops the blocking reads
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	go func() {
+		defer close(messages)
+		defer close(done)
+		defer conn.Close()
+		for {
+			reply, err := conn.do(context.Background(), []byte("XREAD"), []byte("BLOCK"), []byte("0"),
+				[]byte("STREAMS"), stream, lastID)
+			if err != nil {
+				return
+			}
+			// replies are [[stream, entries]], or nil when the read timed out
+			streams, _ := reply.([]interface{})
+			for _, s := range streams {
+				parts, ok := s.([]interface{})
+				if !ok || len(parts) != 2 {
+					continue
+				}
+				for _, entry := range streamEntries(parts[1]) {
+					lastID = entry.id
+					select {
+					case messages <- entry.message:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return messages, nil
+}
+
+// streamEntry is an entry of a stream, as published by RedisBroker.
+type streamEntry struct {
+	id      []byte
+	message []byte
+}
+
+// streamEntries returns the entries of a reply of XRANGE or XREAD, which are [id, [field, value...]].
+func streamEntries(reply interface{}) []streamEntry {
+	var entries []streamEntry
+	items, _ := reply.([]interface{})
+	for _, item := range items {
+		parts, ok := item.([]interface{})
+		if !ok || len(parts) != 2 {
+			continue
+		}
+		id, _ := parts[0].([]byte)
+		fields, _ := parts[1].([]interface{})
+		entry := streamEntry{id: id}
+		for i := 0; i+1 < len(fields); i += 2 {
+			if field, _ := fields[i].([]byte); string(field) == "message" {
+				entry.message, _ = fields[i+1].([]byte)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (b *RedisBroker) dial(ctx context.Context) (*redisConn, error) {
+	var conn net.Conn
+	var err error
+	if b.Dial != nil {
+		conn, err = b.Dial(ctx)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", b.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{Conn: conn, reader: bufio.NewReader(conn)}
+	if b.Password != "" {
+		if _, err := c.do(ctx, []byte("AUTH"), []byte(b.Password)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// redisConn is a connection speaking the RESP protocol of Redis.
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// redisError is an error replied by Redis.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// do sends a command and returns its reply, within the deadline of ctx if it has one.
+func (c *redisConn) do(ctx context.Context, args ...[]byte) (interface{}, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.SetDeadline(deadline)
+		defer c.SetDeadline(time.Time{})
+	}
+	command := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		command = append(command, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		command = append(command, arg...)
+		command = append(command, "\r\n"...)
+	}
+	if _, err := c.Write(command); err != nil {
+		return nil, err
+	}
+	reply, err := c.readReply()
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := reply.(redisError); ok {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// readReply reads a reply: a string, an error, an integer, bulk bytes or an array of them.
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: invalid reply %q", line)
+	}
+	kind, value := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return value, nil
+	case '-':
+		return redisError(value), nil
+	case ':':
+		return strconv.ParseInt(value, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(c.reader, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		array := make([]interface{}, n)
+		for i := range array {
+			if array[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	}
+	return nil, fmt.Errorf("redis: invalid reply %q", line)
+}
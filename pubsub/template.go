@@ -0,0 +1,105 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fiatjaf/graphql"
+)
+
+// TopicTemplate names topics after arguments of subscription fields, like "messageAdded:{channelId}"
+// where "{channelId}" stands for the channelId argument. Members of input objects are named by
+// their path, like "{filter.channelId}".
+type TopicTemplate struct {
+	template string
+
+	// literals are the text around the arguments, there is one more literal than arguments
+	literals  []string
+	arguments [][]string
+}
+
+// ParseTopicTemplate parses template, failing if its braces aren't balanced or name no argument.
+func ParseTopicTemplate(template string) (*TopicTemplate, error) {
+	t := &TopicTemplate{template: template}
+	rest := template
+	for {
+		open := strings.IndexAny(rest, "{}")
+		if open == -1 {
+			t.literals = append(t.literals, rest)
+			return t, nil
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("unexpected } in the topic template %q", template)
+		}
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end == -1 || rest[open+1+end] != '}' {
+			return nil, fmt.Errorf("unclosed { in the topic template %q", template)
+		}
+		name := strings.TrimSpace(rest[open+1 : open+1+end])
+		if name == "" {
+			return nil, fmt.Errorf("empty argument in the topic template %q", template)
+		}
+		t.literals = append(t.literals, rest[:open])
+		t.arguments = append(t.arguments, strings.Split(name, "."))
+		rest = rest[open+1+end+1:]
+	}
+}
+
+// MustParseTopicTemplate is ParseTopicTemplate panicking if template is invalid, to be used when
+// defining schemas.
+func MustParseTopicTemplate(template string) *TopicTemplate {
+	t, err := ParseTopicTemplate(template)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// String returns the template.
+func (t *TopicTemplate) String() string {
+	return t.template
+}
+
+// Topic returns the topic named by the template for the given arguments, failing if any of the
+// arguments it names is missing or null. Strings are inserted as they are, the other values
+// encoded in JSON.
+func (t *TopicTemplate) Topic(args map[string]interface{}) (string, error) {
+	var topic strings.Builder
+	for i, path := range t.arguments {
+		topic.WriteString(t.literals[i])
+		var value interface{} = args
+		for _, name := range path {
+			object, _ := value.(map[string]interface{})
+			value = object[name]
+		}
+		switch value := value.(type) {
+		case nil:
+			return "", fmt.Errorf("the argument %q is required by the topic %q", strings.Join(path, "."), t.template)
+		case string:
+			topic.WriteString(value)
+		default:
+			b, err := json.Marshal(value)
+			if err != nil {
+				return "", err
+			}
+			topic.Write(b)
+		}
+	}
+	topic.WriteString(t.literals[len(t.literals)-1])
+	return topic.String(), nil
+}
+
+// SubscribeTemplateFn returns a Subscribe function for subscription fields, subscribing to the
+// topic named by template for the arguments of the field, see TopicTemplate. It panics if
+// template is invalid.
+func (ps *PubSub) SubscribeTemplateFn(template string) graphql.SubscriptionFieldResolveFn {
+	t := MustParseTopicTemplate(template)
+	return func(p graphql.ResolveParams) (chan interface{}, error) {
+		topic, err := t.Topic(p.Args)
+		if err != nil {
+			return nil, err
+		}
+		return ps.Subscribe(p.Context, topic)
+	}
+}
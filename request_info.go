@@ -0,0 +1,48 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// RequestInfo describes a request for logs and metrics: the client that sent it, as told by the
+// transport (e.g. the handler reads the apollographql-client-name and apollographql-client-version
+// headers), and the operation it executes, filled in once the request is parsed. It is available
+// to the extensions, resolvers and callbacks with RequestInfoFromContext.
+type RequestInfo struct {
+	ClientName    string
+	ClientVersion string
+
+	// OperationName is the name of the executed operation, "" if it is anonymous.
+	OperationName string
+	// OperationType is "query", "mutation" or "subscription".
+	OperationType string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a context holding info, to be filled in with the operation of the
+// request executed with it.
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext returns the RequestInfo of the request being executed with ctx, or nil.
+func RequestInfoFromContext(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info
+}
+
+// setOperation fills in the operation of the document executed by the request.
+func (info *RequestInfo) setOperation(document *ast.Document, operationName string) {
+	operation := selectedOperation(document, operationName)
+	if operation == nil {
+		return
+	}
+	info.OperationName = ""
+	if operation.Name != nil {
+		info.OperationName = operation.Name.Value
+	}
+	info.OperationType = operation.Operation
+}
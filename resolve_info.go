@@ -0,0 +1,264 @@
+package graphql
+
+import (
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// GetSelectedFields returns the fields selected below the field being resolved, as paths of
+// field names joined by dots (e.g. "author.name"), in the order they appear in the query.
+// Fragments are expanded regardless of their type condition and fields excluded by @skip or
+// @include are left out. depth limits how many levels are walked, a depth lower than 1 means
+// no limit.
+//
+// This is meant to let resolvers tailor their data fetching (e.g. the columns of a SELECT) to
+// what the client asked for.
+func (info ResolveInfo) GetSelectedFields(depth int) []string {
+	paths := []string{}
+	seen := map[string]bool{}
+
+	var walk func(fieldASTs []*ast.Field, prefix string, level int)
+	walk = func(fieldASTs []*ast.Field, prefix string, level int) {
+		for _, selected := range info.selectedFieldASTs(fieldASTs, nil) {
+			field := selected.Field
+			path := prefix + field.Name.Value
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+			if depth < 1 || level < depth {
+				walk([]*ast.Field{field}, path+".", level+1)
+			}
+		}
+	}
+	walk(info.FieldASTs, "", 1)
+
+	return paths
+}
+
+// LookaheadRequested tells if the field at the given path of field names, relative to the field
+// being resolved, was selected by the query. For example, LookaheadRequested("author", "name")
+// is true for `{ post { author { name } } }` when resolving post.
+func (info ResolveInfo) LookaheadRequested(path ...string) bool {
+	return info.selectsPath(path, func(field *ast.Field, name string) bool {
+		return field.Name.Value == name
+	})
+}
+
+// selectsPath tells if the fields selected below the field being resolved have a field at path,
+// each of its steps being told apart by match.
+func (info ResolveInfo) selectsPath(path []string, match func(field *ast.Field, step string) bool) bool {
+	if len(path) == 0 {
+		return false
+	}
+
+	fieldASTs := info.FieldASTs
+	for _, step := range path {
+		var matches []*ast.Field
+		for _, selected := range info.selectedFieldASTs(fieldASTs, nil) {
+			if match(selected.Field, step) {
+				matches = append(matches, selected.Field)
+			}
+		}
+		if len(matches) == 0 {
+			return false
+		}
+		fieldASTs = matches
+	}
+	return true
+}
+
+// DirectiveArgs returns the arguments of the directive named name applied to the field being
+// resolved, coerced like the arguments of the field with the variables and default values
+// applied, and whether the directive is applied. It lets the resolvers of a field act on custom
+// directives, e.g. `{ price @currency(code: EUR) }`.
+func (info ResolveInfo) DirectiveArgs(name string) (map[string]interface{}, bool) {
+	directive := info.Schema.Directive(name)
+	if directive == nil {
+		return nil, false
+	}
+	for _, fieldAST := range info.FieldASTs {
+		for _, directiveAST := range fieldAST.Directives {
+			if directiveAST.Name != nil && directiveAST.Name.Value == name {
+				return getArgumentValues(directive.Args, directiveAST.Arguments, info.VariableValues), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// WillExecute tells if the field at the given path of response keys (aliases or names), relative
+// to the field being resolved, will be executed: it is selected, and neither it nor the fields
+// and fragments selecting it are excluded by @skip or @include with the variables of the request.
+// The fields selected on the types of an interface or union count whatever the type of its
+// values. For example, WillExecute("writer") is false when resolving post in
+// `query ($full: Boolean!) { post { writer: author @include(if: $full) { name } } }` with $full
+// false.
+func (info ResolveInfo) WillExecute(path ...string) bool {
+	return info.selectsPath(path, func(field *ast.Field, key string) bool {
+		return getFieldEntryKey(field) == key
+	})
+}
+
+// SelectedField is a field selected below the field being resolved, as returned by
+// ResolveInfo.SelectedFieldTree.
+type SelectedField struct {
+	// Name is the name of the field in the schema.
+	Name string
+
+	// Alias is the key of the field in the response, which is its name unless it was aliased.
+	Alias string
+
+	// Args are the coerced argument values of the field, with variables and defaults applied.
+	Args map[string]interface{}
+
+	// Definition is the schema definition of the field, nil if it couldn't be determined
+	// (e.g. for fields selected on a union without a type condition).
+	Definition *FieldDefinition
+
+	// Selections are the fields selected below this one.
+	Selections SelectedFields
+}
+
+// SelectedFields is a list of selected fields in query order.
+type SelectedFields []*SelectedField
+
+// Get returns the selected field at the given path of response keys (aliases or names),
+// or nil if there isn't one.
+func (fields SelectedFields) Get(path ...string) *SelectedField {
+	var found *SelectedField
+	for _, key := range path {
+		found = nil
+		for _, field := range fields {
+			if field.Alias == key {
+				found = field
+				break
+			}
+		}
+		if found == nil {
+			return nil
+		}
+		fields = found.Selections
+	}
+	return found
+}
+
+// SelectedFieldTree returns the tree of fields selected below the field being resolved along with
+// their coerced arguments, so that a resolver can look at what will be asked from its children,
+// e.g. the `first` and `after` arguments of a connection field, and fetch everything at once.
+// Fragments are expanded and fields excluded by @skip or @include are left out; fields selected
+// more than once under the same response key are merged.
+func (info ResolveInfo) SelectedFieldTree() SelectedFields {
+	return info.selectedFieldTree(info.FieldASTs, GetNamed(info.ReturnType))
+}
+
+func (info ResolveInfo) selectedFieldTree(fieldASTs []*ast.Field, parentType Named) SelectedFields {
+	tree := SelectedFields{}
+	groups := map[string][]selectedFieldAST{}
+	for _, selected := range info.selectedFieldASTs(fieldASTs, parentType) {
+		key := getFieldEntryKey(selected.Field)
+		if _, ok := groups[key]; !ok {
+			tree = append(tree, &SelectedField{
+				Name:  selected.Field.Name.Value,
+				Alias: key,
+			})
+		}
+		groups[key] = append(groups[key], selected)
+	}
+
+	for _, field := range tree {
+		group := groups[field.Alias]
+		field.Definition = selectedFieldDefinition(group[0].ParentType, field.Name)
+		if field.Definition == nil {
+			field.Args = map[string]interface{}{}
+			field.Selections = SelectedFields{}
+			continue
+		}
+		field.Args = getArgumentValues(field.Definition.Args, group[0].Field.Arguments, info.VariableValues)
+		if info.Schema.idCodec != nil {
+			// the invalid IDs are reported when the field is resolved, they are left as they are
+			if decoded, err := decodeArgumentIDs(info.Schema.idCodec, field.Definition.Args, field.Args); err == nil {
+				field.Args = decoded
+			}
+		}
+
+		subFieldASTs := make([]*ast.Field, 0, len(group))
+		for _, selected := range group {
+			subFieldASTs = append(subFieldASTs, selected.Field)
+		}
+		field.Selections = info.selectedFieldTree(subFieldASTs, GetNamed(field.Definition.Type))
+	}
+
+	return tree
+}
+
+// selectedFieldDefinition looks up a field definition on an object or interface type.
+func selectedFieldDefinition(parentType Named, fieldName string) *FieldDefinition {
+	if fieldName == TypeNameMetaFieldDef.Name {
+		return TypeNameMetaFieldDef
+	}
+	switch parentType := parentType.(type) {
+	case *Object:
+		return parentType.Fields()[fieldName]
+	case *Interface:
+		return parentType.Fields()[fieldName]
+	}
+	return nil
+}
+
+// selectedFieldAST is a field selected in a selection set along with the type it was selected on.
+type selectedFieldAST struct {
+	Field      *ast.Field
+	ParentType Named
+}
+
+// selectedFieldASTs returns the fields directly selected below the given field ASTs, with the
+// fragments spread into them and the fields excluded by @skip or @include removed. parentType
+// is the type of the given fields, it is refined by the type conditions of the fragments.
+func (info ResolveInfo) selectedFieldASTs(fieldASTs []*ast.Field, parentType Named) []selectedFieldAST {
+	fields := []selectedFieldAST{}
+	visitedFragmentNames := map[string]bool{}
+
+	conditionType := func(typeCondition *ast.Named, parentType Named) Named {
+		if typeCondition == nil || typeCondition.Name == nil {
+			return parentType
+		}
+		return info.Schema.Type(typeCondition.Name.Value)
+	}
+
+	var collect func(selectionSet *ast.SelectionSet, parentType Named)
+	collect = func(selectionSet *ast.SelectionSet, parentType Named) {
+		if selectionSet == nil {
+			return
+		}
+		for _, iSelection := range selectionSet.Selections {
+			switch selection := iSelection.(type) {
+			case *ast.Field:
+				if selection.Name == nil || !info.includes(selection, selection.Directives) {
+					continue
+				}
+				fields = append(fields, selectedFieldAST{Field: selection, ParentType: parentType})
+			case *ast.InlineFragment:
+				if !info.includes(selection, selection.Directives) {
+					continue
+				}
+				collect(selection.SelectionSet, conditionType(selection.TypeCondition, parentType))
+			case *ast.FragmentSpread:
+				if selection.Name == nil || visitedFragmentNames[selection.Name.Value] ||
+					!info.includes(selection, selection.Directives) {
+					continue
+				}
+				visitedFragmentNames[selection.Name.Value] = true
+				if fragment, ok := info.Fragments[selection.Name.Value].(*ast.FragmentDefinition); ok {
+					collect(fragment.SelectionSet, conditionType(fragment.TypeCondition, parentType))
+				}
+			}
+		}
+	}
+	for _, fieldAST := range fieldASTs {
+		if fieldAST != nil {
+			collect(fieldAST.SelectionSet, parentType)
+		}
+	}
+
+	return fields
+}
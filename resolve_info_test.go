@@ -0,0 +1,308 @@
+package graphql_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+var lookaheadAuthorType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Author",
+	Fields: graphql.Fields{
+		"name":  &graphql.Field{Type: graphql.String},
+		"email": &graphql.Field{Type: graphql.String},
+	},
+})
+
+var lookaheadPostType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Post",
+	Fields: graphql.Fields{
+		"title":  &graphql.Field{Type: graphql.String},
+		"body":   &graphql.Field{Type: graphql.String},
+		"author": &graphql.Field{Type: lookaheadAuthorType},
+	},
+})
+
+// lookaheadSchema returns a schema whose post field hands its ResolveInfo to fn.
+func lookaheadSchema(t *testing.T, fn func(info graphql.ResolveInfo)) graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"post": &graphql.Field{
+					Type: lookaheadPostType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						fn(p.Info)
+						return map[string]interface{}{}, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatalf("Error in schema %v", err.Error())
+	}
+	return schema
+}
+
+func TestResolveInfo_GetSelectedFields(t *testing.T) {
+	query := `
+		query Example($withBody: Boolean!) {
+			post {
+				title
+				body @include(if: $withBody)
+				...PostAuthor
+			}
+		}
+		fragment PostAuthor on Post {
+			author {
+				... on Author { name }
+				email @skip(if: true)
+			}
+		}
+	`
+
+	tests := []struct {
+		depth    int
+		withBody bool
+		expected []string
+	}{
+		{depth: 1, withBody: true, expected: []string{"title", "body", "author"}},
+		{depth: 1, withBody: false, expected: []string{"title", "author"}},
+		{depth: 0, withBody: false, expected: []string{"title", "author", "author.name"}},
+		{depth: 2, withBody: true, expected: []string{"title", "body", "author", "author.name"}},
+	}
+	for _, test := range tests {
+		var selected []string
+		schema := lookaheadSchema(t, func(info graphql.ResolveInfo) {
+			selected = info.GetSelectedFields(test.depth)
+		})
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  query,
+			VariableValues: map[string]interface{}{"withBody": test.withBody},
+		})
+		if result.HasErrors() {
+			t.Fatalf("unexpected errors: %v", result.Errors)
+		}
+		if !reflect.DeepEqual(test.expected, selected) {
+			t.Fatalf("Unexpected selected fields, Diff: %v", testutil.Diff(test.expected, selected))
+		}
+	}
+}
+
+func TestResolveInfo_LookaheadRequested(t *testing.T) {
+	var info graphql.ResolveInfo
+	schema := lookaheadSchema(t, func(i graphql.ResolveInfo) {
+		info = i
+	})
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ post { title author { name email @skip(if: true) } } }`,
+	})
+	if result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+
+	tests := []struct {
+		path     []string
+		expected bool
+	}{
+		{path: []string{"title"}, expected: true},
+		{path: []string{"body"}, expected: false},
+		{path: []string{"author", "name"}, expected: true},
+		{path: []string{"author", "email"}, expected: false},
+		{path: []string{"title", "name"}, expected: false},
+		{path: []string{}, expected: false},
+	}
+	for _, test := range tests {
+		if got := info.LookaheadRequested(test.path...); got != test.expected {
+			t.Errorf("LookaheadRequested(%v): expected %v, got %v", test.path, test.expected, got)
+		}
+	}
+}
+
+func TestResolveInfo_WillExecute(t *testing.T) {
+	query := `
+		query Example($full: Boolean!) {
+			post {
+				headline: title
+				body @include(if: $full)
+				writer: author @include(if: $full) { name }
+				... @skip(if: $full) { author { email } }
+			}
+		}
+	`
+
+	tests := []struct {
+		full     bool
+		path     []string
+		expected bool
+	}{
+		{full: true, path: []string{"headline"}, expected: true},
+		{full: true, path: []string{"title"}, expected: false},
+		{full: true, path: []string{"body"}, expected: true},
+		{full: false, path: []string{"body"}, expected: false},
+		{full: true, path: []string{"writer", "name"}, expected: true},
+		{full: false, path: []string{"writer"}, expected: false},
+		{full: true, path: []string{"author", "email"}, expected: false},
+		{full: false, path: []string{"author", "email"}, expected: true},
+		{full: false, path: []string{}, expected: false},
+	}
+	for _, test := range tests {
+		var info graphql.ResolveInfo
+		schema := lookaheadSchema(t, func(i graphql.ResolveInfo) {
+			info = i
+		})
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  query,
+			VariableValues: map[string]interface{}{"full": test.full},
+		})
+		if result.HasErrors() {
+			t.Fatalf("unexpected errors: %v", result.Errors)
+		}
+		if got := info.WillExecute(test.path...); got != test.expected {
+			t.Errorf("WillExecute(%v) with full %v: expected %v, got %v", test.path, test.full, test.expected, got)
+		}
+	}
+}
+
+func TestResolveInfo_SelectedFieldTree(t *testing.T) {
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.String},
+			"posts": &graphql.Field{
+				Type: graphql.NewList(lookaheadPostType),
+				Args: graphql.FieldConfigArgument{
+					"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+					"after": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+			},
+		},
+	})
+	var tree graphql.SelectedFields
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"user": &graphql.Field{
+					Type: userType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						tree = p.Info.SelectedFieldTree()
+						return map[string]interface{}{}, nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatalf("Error in schema %v", err.Error())
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		RequestString: `
+			query Example($after: String) {
+				user {
+					name
+					recent: posts(first: 2, after: $after) { title }
+					... on User { recent: posts(first: 2, after: $after) { author { name } } }
+					posts { title }
+				}
+			}
+		`,
+		VariableValues: map[string]interface{}{"after": "cursor"},
+	})
+	if result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+
+	if len(tree) != 3 {
+		t.Fatalf("expected 3 selected fields, got %d", len(tree))
+	}
+	recent := tree.Get("recent")
+	if recent == nil || recent.Name != "posts" {
+		t.Fatalf("expected recent to be an alias of posts, got %+v", recent)
+	}
+	expectedArgs := map[string]interface{}{"first": 2, "after": "cursor"}
+	if !reflect.DeepEqual(expectedArgs, recent.Args) {
+		t.Fatalf("Unexpected args, Diff: %v", testutil.Diff(expectedArgs, recent.Args))
+	}
+	if tree.Get("recent", "title") == nil || tree.Get("recent", "author", "name") == nil {
+		t.Fatalf("expected the selections of both recent fields to be merged")
+	}
+	expectedArgs = map[string]interface{}{"first": 10}
+	if posts := tree.Get("posts"); !reflect.DeepEqual(expectedArgs, posts.Args) {
+		t.Fatalf("Unexpected args, Diff: %v", testutil.Diff(expectedArgs, posts.Args))
+	}
+	if tree.Get("posts", "author") != nil {
+		t.Fatalf("expected posts.author not to be selected")
+	}
+}
+
+func TestResolveInfo_DirectiveArgs(t *testing.T) {
+	currency := graphql.NewDirective(graphql.DirectiveConfig{
+		Name:      "currency",
+		Locations: []string{graphql.DirectiveLocationField},
+		Args: graphql.FieldConfigArgument{
+			"code":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			"decimals": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 2},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"price": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						args, ok := p.Info.DirectiveArgs("currency")
+						if !ok {
+							return "10.00 USD", nil
+						}
+						return fmt.Sprintf("10.%0*d %v", args["decimals"], 0, args["code"]), nil
+					},
+				},
+			},
+		}),
+		Directives: append(graphql.SpecifiedDirectives, currency),
+	})
+	if err != nil {
+		t.Fatalf("Error in schema %v", err.Error())
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  `query ($code: String!) { price plain: price @skip(if: false) euros: price @currency(code: $code, decimals: 3) pounds: price @currency(code: "GBP") }`,
+		VariableValues: map[string]interface{}{"code": "EUR"},
+	})
+	expected := map[string]interface{}{
+		"price":  "10.00 USD",
+		"plain":  "10.00 USD",
+		"euros":  "10.000 EUR",
+		"pounds": "10.00 GBP",
+	}
+	if result.HasErrors() || !reflect.DeepEqual(expected, result.Data) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result.Data))
+	}
+
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ price @currency(code: "EUR") @currency(code: "GBP") }`,
+	})
+	if len(result.Errors) != 1 || result.Errors[0].Message != `The directive "currency" can only be used once at this location.` {
+		t.Fatalf("expected the repeated directive to be invalid, got %v", result.Errors)
+	}
+	result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ price @currency(code: 1) }`,
+	})
+	if len(result.Errors) != 1 || result.Errors[0].Message != "Argument \"code\" has invalid value 1.\nExpected type \"String\", found 1." {
+		t.Fatalf("expected the directive argument of the wrong type to be invalid, got %v", result.Errors)
+	}
+}
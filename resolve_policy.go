@@ -0,0 +1,146 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fiatjaf/graphql/gqlerrors"
+)
+
+// RetryPolicy makes the resolver of a field be called again when it fails, see Field.Retry.
+type RetryPolicy struct {
+	// Attempts is the maximum number of calls of the resolver, including the first one.
+	Attempts int
+
+	// Backoff is how long to wait before the second call, the wait being doubled before each
+	// following one. The waits end early when the context of the request is done.
+	Backoff time.Duration
+
+	// Retryable, when set, tells if the resolver may be called again after it returned err. By
+	// default all the errors are retried, timeouts included.
+	Retryable func(err error) bool
+}
+
+// ErrResolverTimeout is wrapped by the errors of the resolvers which didn't return before the
+// Timeout of their field.
+var ErrResolverTimeout = errors.New("resolver timed out")
+
+// resolverTimeoutError is the error of a call of a resolver which timed out.
+type resolverTimeoutError struct {
+	timeout time.Duration
+}
+
+func (e resolverTimeoutError) Error() string {
+	return fmt.Sprintf("%v after %v", ErrResolverTimeout, e.timeout)
+}
+
+func (e resolverTimeoutError) Unwrap() error {
+	return ErrResolverTimeout
+}
+
+// attemptsError is the error of the last call of a resolver with a RetryPolicy, reporting the
+// number of calls under the "attempts" key of its extensions.
+type attemptsError struct {
+	err      error
+	attempts int
+}
+
+func (e *attemptsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *attemptsError) Unwrap() error {
+	return e.err
+}
+
+// Extensions implements gqlerrors.ExtendedError.
+func (e *attemptsError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{}
+	var extended gqlerrors.ExtendedError
+	if errors.As(e.err, &extended) {
+		for key, value := range extended.Extensions() {
+			extensions[key] = value
+		}
+	}
+	extensions["attempts"] = e.attempts
+	return extensions
+}
+
+// withResolvePolicy wraps resolveFn with the Timeout and the RetryPolicy of a field, if it has any.
+func withResolvePolicy(fieldDef *FieldDefinition, resolveFn FieldResolveFn) FieldResolveFn {
+	if fieldDef.Timeout > 0 {
+		resolveFn = withTimeout(fieldDef.Timeout, resolveFn)
+	}
+	if retry := fieldDef.Retry; retry != nil && retry.Attempts > 1 {
+		resolveFn = withRetries(retry, resolveFn)
+	}
+	return resolveFn
+}
+
+// withTimeout returns the result of resolveFn, or an error if it doesn't return within timeout.
+// The context of resolveFn is done then, and its result is discarded once it returns.
+func withTimeout(timeout time.Duration, resolveFn FieldResolveFn) FieldResolveFn {
+	type resolved struct {
+		value interface{}
+		err   error
+		panic interface{}
+	}
+	return func(p ResolveParams) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(p.Context, timeout)
+		defer cancel()
+		p.Context = ctx
+
+		results := make(chan resolved, 1)
+		Go(ctx, "resolver timeout", func() {
+			// a panic of the resolver is raised again by the execution
+			defer func() {
+				if r := recover(); r != nil {
+					results <- resolved{panic: r}
+				}
+			}()
+			value, err := resolveFn(p)
+			results <- resolved{value: value, err: err}
+		})
+		select {
+		case result := <-results:
+			if result.panic != nil {
+				panic(result.panic)
+			}
+			return result.value, result.err
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, resolverTimeoutError{timeout}
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// withRetries calls resolveFn again while it fails with a retryable error, up to the attempts of
+// retry, and reports the number of calls in the extensions of the last error.
+func withRetries(retry *RetryPolicy, resolveFn FieldResolveFn) FieldResolveFn {
+	return func(p ResolveParams) (interface{}, error) {
+		backoff := retry.Backoff
+		for attempt := 1; ; attempt++ {
+			value, err := resolveFn(p)
+			if err == nil {
+				return value, nil
+			}
+			if attempt >= retry.Attempts || (retry.Retryable != nil && !retry.Retryable(err)) {
+				return value, &attemptsError{err: err, attempts: attempt}
+			}
+			if backoff > 0 {
+				timer := time.NewTimer(backoff)
+				select {
+				case <-timer.C:
+				case <-p.Context.Done():
+					timer.Stop()
+					return nil, &attemptsError{err: err, attempts: attempt}
+				}
+				backoff *= 2
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package graphql_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+type unavailableError struct{}
+
+func (unavailableError) Error() string { return "unavailable" }
+
+func (unavailableError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "UNAVAILABLE"}
+}
+
+func TestResolvePolicy_Timeout(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"slow": &graphql.Field{
+					Type:    graphql.String,
+					Timeout: 10 * time.Millisecond,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						<-p.Context.Done()
+						return "late", nil
+					},
+				},
+				"stuck": &graphql.Field{
+					Type:    graphql.String,
+					Timeout: 10 * time.Millisecond,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						// ignores its context
+						time.Sleep(time.Second)
+						return "late", nil
+					},
+				},
+				"fast": &graphql.Field{
+					Type:    graphql.String,
+					Timeout: time.Second,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return "fast", nil
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatalf("wrong result, unexpected errors: %v", err.Error())
+	}
+
+	start := time.Now()
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ slow stuck fast }`})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the resolvers to time out, took %v", elapsed)
+	}
+	expected := map[string]interface{}{"slow": nil, "stuck": nil, "fast": "fast"}
+	if !reflect.DeepEqual(result.Data, expected) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result.Data))
+	}
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %v", result.Errors)
+	}
+	for _, err := range result.Errors {
+		located, ok := err.OriginalError().(*gqlerrors.Error)
+		if !ok || !errors.Is(located.OriginalError, graphql.ErrResolverTimeout) || err.Message != "resolver timed out after 10ms" {
+			t.Fatalf("expected a timeout error, got %v", err)
+		}
+	}
+}
+
+func TestResolvePolicy_Retry(t *testing.T) {
+	calls := map[string]int{}
+	failing := func(name string, failures int, err error) graphql.FieldResolveFn {
+		return func(p graphql.ResolveParams) (interface{}, error) {
+			calls[name]++
+			if calls[name] <= failures {
+				return nil, err
+			}
+			return name, nil
+		}
+	}
+	retry := &graphql.RetryPolicy{
+		Attempts: 3,
+		Backoff:  time.Millisecond,
+		Retryable: func(err error) bool {
+			return !errors.Is(err, errNotRetryable)
+		},
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"flaky":     &graphql.Field{Type: graphql.String, Retry: retry, Resolve: failing("flaky", 2, unavailableError{})},
+				"down":      &graphql.Field{Type: graphql.String, Retry: retry, Resolve: failing("down", 5, unavailableError{})},
+				"forbidden": &graphql.Field{Type: graphql.String, Retry: retry, Resolve: failing("forbidden", 5, errNotRetryable)},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatalf("wrong result, unexpected errors: %v", err.Error())
+	}
+
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ flaky down forbidden }`})
+	expected := map[string]interface{}{"flaky": "flaky", "down": nil, "forbidden": nil}
+	if !reflect.DeepEqual(result.Data, expected) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result.Data))
+	}
+	expectedExtensions := map[string]map[string]interface{}{
+		"down":      {"code": "UNAVAILABLE", "attempts": 3},
+		"forbidden": {"attempts": 1},
+	}
+	extensions := map[string]map[string]interface{}{}
+	for _, err := range result.Errors {
+		extensions[err.Path[0].(string)] = err.Extensions
+	}
+	if !reflect.DeepEqual(extensions, expectedExtensions) {
+		t.Fatalf("Unexpected extensions, Diff: %v", testutil.Diff(expectedExtensions, extensions))
+	}
+	if expectedCalls := map[string]int{"flaky": 3, "down": 3, "forbidden": 1}; !reflect.DeepEqual(calls, expectedCalls) {
+		t.Fatalf("expected the calls %v, got %v", expectedCalls, calls)
+	}
+}
+
+var errNotRetryable = errors.New("not retryable")
@@ -34,6 +34,7 @@ var SpecifiedRules = []ValidationRuleFn{
 	ProvidedNonNullArgumentsRule,
 	ScalarLeafsRule,
 	UniqueArgumentNamesRule,
+	UniqueDirectivesPerLocationRule,
 	UniqueFragmentNamesRule,
 	UniqueInputFieldNamesRule,
 	UniqueOperationNamesRule,
@@ -242,6 +243,12 @@ func FieldsOnCorrectTypeRule(context *ValidationContext) *ValidationRuleInstance
 							if len(suggestedTypeNames) == 0 {
 								suggestedFieldNames = getSuggestedFieldNames(context.Schema(), ttype, nodeName)
 							}
+
+							// the fields that aren't visible must not be suggested either
+							if context.visibility != nil {
+								suggestedTypeNames = context.visibility.filterSuggestedTypes(context.Schema(), suggestedTypeNames, nodeName)
+								suggestedFieldNames = context.visibility.filterSuggestedFields(ttype, suggestedFieldNames)
+							}
 							reportError(
 								context,
 								UndefinedFieldMessage(nodeName, ttype.Name(), suggestedTypeNames, suggestedFieldNames),
@@ -752,10 +759,12 @@ func KnownTypeNamesRule(context *ValidationContext) *ValidationRuleInstance {
 							typeNameValue = typeName.Value
 						}
 						ttype := context.Schema().Type(typeNameValue)
-						if ttype == nil {
+						if ttype == nil || !context.visibility.typeVisible(typeNameValue) {
 							suggestedTypes := []string{}
 							for key := range context.Schema().TypeMap() {
-								suggestedTypes = append(suggestedTypes, key)
+								if context.visibility.typeVisible(key) {
+									suggestedTypes = append(suggestedTypes, key)
+								}
 							}
 							reportError(
 								context,
@@ -1427,6 +1436,57 @@ func UniqueArgumentNamesRule(context *ValidationContext) *ValidationRuleInstance
 	}
 }
 
+// UniqueDirectivesPerLocationRule Unique directives per location
+//
+// A GraphQL document is only valid if each directive is used at most once per location, e.g.
+// a field can't be marked @cached twice.
+func UniqueDirectivesPerLocationRule(context *ValidationContext) *ValidationRuleInstance {
+	checkDirectives := func(p visitor.VisitFuncParams) (string, interface{}) {
+		var directives []*ast.Directive
+		switch node := p.Node.(type) {
+		case *ast.OperationDefinition:
+			directives = node.Directives
+		case *ast.FragmentDefinition:
+			directives = node.Directives
+		case *ast.Field:
+			directives = node.Directives
+		case *ast.FragmentSpread:
+			directives = node.Directives
+		case *ast.InlineFragment:
+			directives = node.Directives
+		}
+		knownDirectives := map[string]*ast.Directive{}
+		for _, directive := range directives {
+			if directive.Name == nil {
+				continue
+			}
+			if previous, ok := knownDirectives[directive.Name.Value]; ok {
+				reportError(
+					context,
+					fmt.Sprintf(`The directive "%v" can only be used once at this location.`, directive.Name.Value),
+					[]ast.Node{previous, directive},
+				)
+			} else {
+				knownDirectives[directive.Name.Value] = directive
+			}
+		}
+		return visitor.ActionNoChange, nil
+	}
+
+	visitorOpts := &visitor.VisitorOptions{
+		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+			kinds.OperationDefinition: {Kind: checkDirectives},
+			kinds.FragmentDefinition:  {Kind: checkDirectives},
+			kinds.Field:               {Kind: checkDirectives},
+			kinds.FragmentSpread:      {Kind: checkDirectives},
+			kinds.InlineFragment:      {Kind: checkDirectives},
+		},
+	}
+	return &ValidationRuleInstance{
+		VisitorOpts: visitorOpts,
+	}
+}
+
 // UniqueFragmentNamesRule Unique fragment names
 //
 // A GraphQL document is only valid if all defined fragments have unique names.
@@ -1721,6 +1781,10 @@ func VariablesInAllowedPositionRule(context *ValidationContext) *ValidationRuleI
 // Note that this only validates literal values, variables are assumed to
 // provide values of the correct type.
 func isValidLiteralValue(ttype Input, valueAST ast.Value) (bool, []string) {
+	// a null literal is valid wherever the absence of a value is
+	if _, ok := valueAST.(*ast.NullValue); ok {
+		valueAST = nil
+	}
 	if _, ok := ttype.(*NonNull); !ok {
 		if valueAST == nil {
 			return true, nil
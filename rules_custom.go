@@ -0,0 +1,88 @@
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/kinds"
+	"github.com/fiatjaf/graphql/language/visitor"
+)
+
+// NoDeprecatedCustomRule No deprecated
+//
+// A GraphQL document is only valid if all selected fields and all used enum values have not been
+// deprecated.
+//
+// This rule isn't part of SpecifiedRules, add it to Params.ValidationRules to use it.
+func NoDeprecatedCustomRule(context *ValidationContext) *ValidationRuleInstance {
+	visitorOpts := &visitor.VisitorOptions{
+		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+			kinds.Field: {
+				Kind: func(p visitor.VisitFuncParams) (string, interface{}) {
+					fieldDef := context.FieldDef()
+					parentType := context.ParentType()
+					if fieldDef != nil && fieldDef.DeprecationReason != "" && parentType != nil {
+						if node, ok := p.Node.(*ast.Field); ok {
+							reportError(
+								context,
+								fmt.Sprintf(`The field %v.%v is deprecated. %v`, parentType.Name(), fieldDef.Name, fieldDef.DeprecationReason),
+								[]ast.Node{node},
+							)
+						}
+					}
+					return visitor.ActionNoChange, nil
+				},
+			},
+			kinds.EnumValue: {
+				Kind: func(p visitor.VisitFuncParams) (string, interface{}) {
+					enumValue := context.EnumValue()
+					if enumValue != nil && enumValue.DeprecationReason != "" {
+						if node, ok := p.Node.(*ast.EnumValue); ok {
+							reportError(
+								context,
+								fmt.Sprintf(`The enum value "%v.%v" is deprecated. %v`, GetNamed(context.InputType()), enumValue.Name, enumValue.DeprecationReason),
+								[]ast.Node{node},
+							)
+						}
+					}
+					return visitor.ActionNoChange, nil
+				},
+			},
+		},
+	}
+	return &ValidationRuleInstance{
+		VisitorOpts: visitorOpts,
+	}
+}
+
+// NoSchemaIntrospectionCustomRule No schema introspection
+//
+// A GraphQL document is only valid if it doesn't select the __schema or __type fields, or any
+// other field returning the schema or its types. __typename is still allowed.
+//
+// This rule isn't part of SpecifiedRules, add it to Params.ValidationRules to use it.
+func NoSchemaIntrospectionCustomRule(context *ValidationContext) *ValidationRuleInstance {
+	visitorOpts := &visitor.VisitorOptions{
+		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+			kinds.Field: {
+				Kind: func(p visitor.VisitFuncParams) (string, interface{}) {
+					node, ok := p.Node.(*ast.Field)
+					if !ok || node.Name == nil {
+						return visitor.ActionNoChange, nil
+					}
+					if ttype := GetNamed(context.Type()); ttype == SchemaType || ttype == TypeType {
+						return reportError(
+							context,
+							fmt.Sprintf(`GraphQL introspection has been disabled, but the requested query contained the field "%v".`, node.Name.Value),
+							[]ast.Node{node},
+						)
+					}
+					return visitor.ActionNoChange, nil
+				},
+			},
+		},
+	}
+	return &ValidationRuleInstance{
+		VisitorOpts: visitorOpts,
+	}
+}
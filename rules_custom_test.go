@@ -0,0 +1,153 @@
+package graphql_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+var deprecationSchema = func() *graphql.Schema {
+	color := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Color",
+		Values: graphql.EnumValueConfigMap{
+			"RED":     &graphql.EnumValueConfig{Value: 0},
+			"MAGENTA": &graphql.EnumValueConfig{Value: 1, DeprecationReason: "Use RED."},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"name":    &graphql.Field{Type: graphql.String},
+				"oldName": &graphql.Field{Type: graphql.String, DeprecationReason: "Use name."},
+				"paint": &graphql.Field{
+					Type: graphql.String,
+					Args: graphql.FieldConfigArgument{
+						"colors": &graphql.ArgumentConfig{Type: graphql.NewList(color)},
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return &schema
+}()
+
+func TestValidate_NoDeprecated_AllowsUndeprecatedFieldsAndEnumValues(t *testing.T) {
+	testutil.ExpectPassesRuleWithSchema(t, deprecationSchema, graphql.NoDeprecatedCustomRule, `
+      {
+        name
+        paint(colors: [RED])
+        __typename
+      }
+    `)
+}
+
+func TestValidate_NoDeprecated_ReportsDeprecatedFieldsAndEnumValues(t *testing.T) {
+	testutil.ExpectFailsRuleWithSchema(t, deprecationSchema, graphql.NoDeprecatedCustomRule, `
+      {
+        oldName
+        paint(colors: [RED, MAGENTA])
+      }
+    `, []gqlerrors.FormattedError{
+		testutil.RuleError(`The field Query.oldName is deprecated. Use name.`, 3, 9),
+		testutil.RuleError(`The enum value "Color.MAGENTA" is deprecated. Use RED.`, 4, 29),
+	})
+}
+
+func TestValidate_NoSchemaIntrospection_AllowsTypename(t *testing.T) {
+	testutil.ExpectPassesRule(t, graphql.NoSchemaIntrospectionCustomRule, `
+      {
+        __typename
+        dog {
+          __typename
+          name
+        }
+      }
+    `)
+}
+
+func TestValidate_NoSchemaIntrospection_ReportsSchemaAndTypeFields(t *testing.T) {
+	testutil.ExpectFailsRule(t, graphql.NoSchemaIntrospectionCustomRule, `
+      {
+        __schema {
+          queryType {
+            name
+          }
+        }
+        __type(name: "Dog") {
+          name
+        }
+      }
+    `, []gqlerrors.FormattedError{
+		testutil.RuleError(`GraphQL introspection has been disabled, but the requested query contained the field "__schema".`, 3, 9),
+		testutil.RuleError(`GraphQL introspection has been disabled, but the requested query contained the field "queryType".`, 4, 11),
+		testutil.RuleError(`GraphQL introspection has been disabled, but the requested query contained the field "__type".`, 8, 9),
+	})
+}
+
+func TestValidate_NoSchemaIntrospection_IsPluggableInParams(t *testing.T) {
+	result := graphql.Do(graphql.Params{
+		Schema:          *deprecationSchema,
+		RequestString:   `{ __schema { types { name } } }`,
+		ValidationRules: []graphql.ValidationRuleFn{graphql.NoSchemaIntrospectionCustomRule},
+	})
+	expectedErrors := []gqlerrors.FormattedError{
+		testutil.RuleError(`GraphQL introspection has been disabled, but the requested query contained the field "__schema".`, 1, 3),
+		testutil.RuleError(`GraphQL introspection has been disabled, but the requested query contained the field "types".`, 1, 14),
+	}
+	if !testutil.EqualFormattedErrors(expectedErrors, result.Errors) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedErrors, result.Errors))
+	}
+}
+
+func TestValidate_WithSeverity_ReportsWarningsWithoutFailing(t *testing.T) {
+	result := graphql.Do(graphql.Params{
+		Schema:        *deprecationSchema,
+		RequestString: `{ name oldName }`,
+		ValidationRules: []graphql.ValidationRuleFn{
+			graphql.WithSeverity(graphql.SeverityWarning, graphql.NoDeprecatedCustomRule),
+		},
+	})
+	if len(result.Errors) != 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	expectedData := map[string]interface{}{"name": nil, "oldName": nil}
+	if !reflect.DeepEqual(expectedData, result.Data) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedData, result.Data))
+	}
+	expectedWarnings := []gqlerrors.FormattedError{
+		testutil.RuleError(`The field Query.oldName is deprecated. Use name.`, 1, 8),
+	}
+	warnings, _ := result.Extensions["warnings"].([]gqlerrors.FormattedError)
+	if !testutil.EqualFormattedErrors(expectedWarnings, warnings) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedWarnings, result.Extensions))
+	}
+}
+
+func TestValidate_WithSeverity_KeepsWarningsOnInvalidDocuments(t *testing.T) {
+	result := graphql.ValidateDocument(deprecationSchema, testutil.TestParse(t, `{ oldName unknown }`), []graphql.ValidationRuleFn{
+		graphql.FieldsOnCorrectTypeRule,
+		graphql.WithSeverity(graphql.SeverityWarning, graphql.NoDeprecatedCustomRule),
+	})
+	if result.IsValid {
+		t.Fatal("expected the document to be invalid")
+	}
+	expectedErrors := []gqlerrors.FormattedError{
+		testutil.RuleError(`Cannot query field "unknown" on type "Query".`, 1, 11),
+	}
+	if !testutil.EqualFormattedErrors(expectedErrors, result.Errors) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedErrors, result.Errors))
+	}
+	expectedWarnings := []gqlerrors.FormattedError{
+		testutil.RuleError(`The field Query.oldName is deprecated. Use name.`, 1, 3),
+	}
+	if !testutil.EqualFormattedErrors(expectedWarnings, result.Warnings) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedWarnings, result.Warnings))
+	}
+}
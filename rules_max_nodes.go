@@ -0,0 +1,202 @@
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/kinds"
+	"github.com/fiatjaf/graphql/language/visitor"
+)
+
+// PageSizeArgumentNames are the arguments used by MaxNodesRule to find how many nodes a field
+// returns, the first one present on the field is used.
+var PageSizeArgumentNames = []string{"first", "last", "limit"}
+
+// MaxNodesRule Max nodes
+//
+// A GraphQL document is only valid if the number of nodes its operations could return is not
+// above maxNodes. Fields with one of the PageSizeArgumentNames arguments return as many nodes as
+// the value of that argument, or of its default value when it isn't given, for each node of their
+// parent; the estimate is the sum of that for all those fields. Variables are read from
+// variableValues, falling back to their default values.
+func MaxNodesRule(maxNodes int, variableValues map[string]interface{}) ValidationRuleFn {
+	return func(context *ValidationContext) *ValidationRuleInstance {
+		visitorOpts := &visitor.VisitorOptions{
+			KindFuncMap: map[string]visitor.NamedVisitFuncs{
+				kinds.OperationDefinition: {
+					Kind: func(p visitor.VisitFuncParams) (string, interface{}) {
+						operation, ok := p.Node.(*ast.OperationDefinition)
+						if !ok {
+							return visitor.ActionSkip, nil
+						}
+						estimate := &maxNodesEstimate{
+							context:        context,
+							operation:      operation,
+							variableValues: variableValues,
+							spreading:      map[string]bool{},
+							limit:          maxNodes + 1,
+						}
+						nodes := estimate.selectionSetNodes(operation.SelectionSet, operationRootType(context.Schema(), operation), 1)
+						if nodes > maxNodes {
+							reportError(
+								context,
+								fmt.Sprintf(`Operation could return more than %d nodes, which is the maximum.`, maxNodes),
+								[]ast.Node{operation},
+							)
+						}
+						return visitor.ActionSkip, nil
+					},
+				},
+			},
+		}
+		return &ValidationRuleInstance{
+			VisitorOpts: visitorOpts,
+		}
+	}
+}
+
+// operationRootType returns the root type of the operation in the schema, or nil if it has none.
+func operationRootType(schema *Schema, operation *ast.OperationDefinition) Named {
+	var rootType *Object
+	switch operation.Operation {
+	case ast.OperationTypeQuery:
+		rootType = schema.QueryType()
+	case ast.OperationTypeMutation:
+		rootType = schema.MutationType()
+	case ast.OperationTypeSubscription:
+		rootType = schema.SubscriptionType()
+	}
+	if rootType == nil {
+		return nil
+	}
+	return rootType
+}
+
+type maxNodesEstimate struct {
+	context        *ValidationContext
+	operation      *ast.OperationDefinition
+	variableValues map[string]interface{}
+
+	// spreading holds the fragments being spread, so that cycles (reported by
+	// NoFragmentCyclesRule) don't make the estimate loop forever.
+	spreading map[string]bool
+
+	// limit caps the counts, so that they can't overflow: any count above the maximum is as bad
+	limit int
+}
+
+// add returns a+b, capped at the limit of the estimate.
+func (e *maxNodesEstimate) add(a, b int) int {
+	if a >= e.limit-b {
+		return e.limit
+	}
+	return a + b
+}
+
+// multiply returns a*b, capped at the limit of the estimate.
+func (e *maxNodesEstimate) multiply(a, b int) int {
+	if b != 0 && a > e.limit/b {
+		return e.limit
+	}
+	return a * b
+}
+
+// selectionSetNodes returns the number of nodes returned by the fields of selectionSet, when it is
+// selected on parentCount nodes of parentType.
+func (e *maxNodesEstimate) selectionSetNodes(selectionSet *ast.SelectionSet, parentType Named, parentCount int) int {
+	if selectionSet == nil || parentType == nil {
+		return 0
+	}
+	nodes := 0
+	for _, selection := range selectionSet.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Name == nil {
+				continue
+			}
+			fieldDef := selectedFieldDefinition(parentType, selection.Name.Value)
+			if fieldDef == nil {
+				continue
+			}
+			count := parentCount
+			if pageSize, ok := e.pageSize(fieldDef, selection); ok {
+				count = e.multiply(parentCount, pageSize)
+				nodes = e.add(nodes, count)
+			}
+			nodes = e.add(nodes, e.selectionSetNodes(selection.SelectionSet, GetNamed(fieldDef.Type), count))
+		case *ast.InlineFragment:
+			nodes = e.add(nodes, e.selectionSetNodes(selection.SelectionSet, e.conditionType(selection.TypeCondition, parentType), parentCount))
+		case *ast.FragmentSpread:
+			if selection.Name == nil || e.spreading[selection.Name.Value] {
+				continue
+			}
+			fragment := e.context.Fragment(selection.Name.Value)
+			if fragment == nil {
+				continue
+			}
+			e.spreading[selection.Name.Value] = true
+			nodes = e.add(nodes, e.selectionSetNodes(fragment.SelectionSet, e.conditionType(fragment.TypeCondition, parentType), parentCount))
+			delete(e.spreading, selection.Name.Value)
+		}
+	}
+	return nodes
+}
+
+func (e *maxNodesEstimate) conditionType(typeCondition *ast.Named, parentType Named) Named {
+	if typeCondition == nil || typeCondition.Name == nil {
+		return parentType
+	}
+	return e.context.Schema().Type(typeCondition.Name.Value)
+}
+
+// pageSize returns the value of the first page size argument defined on the field, as given in
+// the document or by its default value. Negative page sizes count as 0, they return no nodes.
+func (e *maxNodesEstimate) pageSize(fieldDef *FieldDefinition, field *ast.Field) (int, bool) {
+	for _, name := range PageSizeArgumentNames {
+		var argDef *Argument
+		for _, arg := range fieldDef.Args {
+			if arg.PrivateName == name {
+				argDef = arg
+			}
+		}
+		if argDef == nil {
+			continue
+		}
+		var value interface{}
+		for _, arg := range field.Arguments {
+			if arg.Name != nil && arg.Name.Value == name {
+				value = e.argumentValue(arg.Value)
+			}
+		}
+		if value == nil {
+			value = argDef.DefaultValue
+		}
+		if pageSize, ok := Int.ParseValue(value).(int); ok {
+			if pageSize < 0 {
+				pageSize = 0
+			}
+			return pageSize, true
+		}
+	}
+	return 0, false
+}
+
+func (e *maxNodesEstimate) argumentValue(valueAST ast.Value) interface{} {
+	variable, ok := valueAST.(*ast.Variable)
+	if !ok {
+		return valueFromAST(valueAST, Int, nil)
+	}
+	if variable.Name == nil {
+		return nil
+	}
+	if value, ok := e.variableValues[variable.Name.Value]; ok {
+		return value
+	}
+	for _, definition := range e.operation.VariableDefinitions {
+		if definition.Variable != nil && definition.Variable.Name != nil &&
+			definition.Variable.Name.Value == variable.Name.Value {
+			return valueFromAST(definition.DefaultValue, Int, nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+var maxNodesTestSchema = func() graphql.Schema {
+	var userType *graphql.Object
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"name": &graphql.Field{Type: graphql.String},
+				"followers": &graphql.Field{
+					Type: graphql.NewList(userType),
+					Args: graphql.FieldConfigArgument{
+						"first": &graphql.ArgumentConfig{Type: graphql.Int},
+						"last":  &graphql.ArgumentConfig{Type: graphql.Int},
+					},
+				},
+				"repositories": &graphql.Field{
+					Type: graphql.NewList(graphql.String),
+					Args: graphql.FieldConfigArgument{
+						"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 30},
+					},
+				},
+			}
+		}),
+	})
+	schema, _ := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"viewer": &graphql.Field{Type: userType},
+				"users": &graphql.Field{
+					Type: graphql.NewList(userType),
+					Args: graphql.FieldConfigArgument{
+						"first": &graphql.ArgumentConfig{Type: graphql.Int},
+					},
+				},
+			},
+		}),
+	})
+	return schema
+}()
+
+func TestValidate_MaxNodes_AllowsQueriesBelowTheLimit(t *testing.T) {
+	testutil.ExpectPassesRuleWithSchema(t, &maxNodesTestSchema, graphql.MaxNodesRule(1000, nil), `
+      {
+        users(first: 10) {
+          followers(first: 50) {
+            name
+          }
+          repositories
+        }
+      }
+    `)
+}
+
+func TestValidate_MaxNodes_MultipliesNestedPageSizes(t *testing.T) {
+	testutil.ExpectFailsRuleWithSchema(t, &maxNodesTestSchema, graphql.MaxNodesRule(1000, nil), `
+      {
+        users(first: 10) {
+          followers(first: 100) {
+            name
+          }
+        }
+      }
+    `, []gqlerrors.FormattedError{
+		testutil.RuleError(`Operation could return more than 1000 nodes, which is the maximum.`, 2, 7),
+	})
+}
+
+func TestValidate_MaxNodes_UsesArgumentDefaultValues(t *testing.T) {
+	testutil.ExpectFailsRuleWithSchema(t, &maxNodesTestSchema, graphql.MaxNodesRule(100, nil), `
+      {
+        viewer {
+          followers(last: 5) {
+            repositories
+          }
+        }
+      }
+    `, []gqlerrors.FormattedError{
+		testutil.RuleError(`Operation could return more than 100 nodes, which is the maximum.`, 2, 7),
+	})
+}
+
+func TestValidate_MaxNodes_ExpandsFragments(t *testing.T) {
+	testutil.ExpectFailsRuleWithSchema(t, &maxNodesTestSchema, graphql.MaxNodesRule(50, nil), `
+      {
+        users(first: 10) {
+          ...userFields
+          ... on User {
+            followers(first: 2) {
+              name
+            }
+          }
+        }
+      }
+      fragment userFields on User {
+        followers(first: 5) {
+          name
+        }
+      }
+    `, []gqlerrors.FormattedError{
+		testutil.RuleError(`Operation could return more than 50 nodes, which is the maximum.`, 2, 7),
+	})
+}
+
+func TestValidate_MaxNodes_UsesVariables(t *testing.T) {
+	query := `
+      query Q($first: Int = 10) {
+        users(first: $first) {
+          name
+        }
+      }
+    `
+	testutil.ExpectPassesRuleWithSchema(t, &maxNodesTestSchema, graphql.MaxNodesRule(50, nil), query)
+	testutil.ExpectPassesRuleWithSchema(t, &maxNodesTestSchema,
+		graphql.MaxNodesRule(50, map[string]interface{}{"first": float64(50)}), query)
+	testutil.ExpectFailsRuleWithSchema(t, &maxNodesTestSchema,
+		graphql.MaxNodesRule(50, map[string]interface{}{"first": 51}), query, []gqlerrors.FormattedError{
+			testutil.RuleError(`Operation could return more than 50 nodes, which is the maximum.`, 2, 7),
+		})
+}
+
+func TestValidate_MaxNodes_RejectedByDo(t *testing.T) {
+	result := graphql.Do(graphql.Params{
+		Schema:        maxNodesTestSchema,
+		RequestString: `{ users(first: 100) { followers(first: 100) { name } } }`,
+		MaxNodes:      500,
+	})
+	if len(result.Errors) != 1 || result.Errors[0].Message != `Operation could return more than 500 nodes, which is the maximum.` {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+}
+
+func TestValidate_MaxNodes_NegativePageSizesCountAsZero(t *testing.T) {
+	testutil.ExpectFailsRuleWithSchema(t, &maxNodesTestSchema, graphql.MaxNodesRule(1000, nil), `
+      {
+        a: users(first: 100000) { name }
+        b: users(first: -100000) { name }
+      }
+    `, []gqlerrors.FormattedError{
+		testutil.RuleError(`Operation could return more than 1000 nodes, which is the maximum.`, 2, 7),
+	})
+}
+
+func TestValidate_MaxNodes_DoesNotOverflow(t *testing.T) {
+	testutil.ExpectFailsRuleWithSchema(t, &maxNodesTestSchema, graphql.MaxNodesRule(1000, nil), `
+      {
+        users(first: 2147483647) {
+          followers(first: 2147483647) {
+            followers(first: 2147483647) {
+              name
+            }
+          }
+        }
+      }
+    `, []gqlerrors.FormattedError{
+		testutil.RuleError(`Operation could return more than 1000 nodes, which is the maximum.`, 2, 7),
+	})
+}
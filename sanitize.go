@@ -0,0 +1,81 @@
+package graphql
+
+import (
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/printer"
+)
+
+// SanitizedRequest is a representation of a request that is safe to log: its query is normalized
+// and the values of its variables are scrubbed, so that passwords and personal data sent as
+// variables don't end up in the logs while the shape of the operation is kept.
+type SanitizedRequest struct {
+	OperationName string                 `json:"operationName,omitempty"`
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// ScrubVariableFn returns what is logged instead of the value of the variable name, whose type
+// is typ as declared by the operation (e.g. "[ID!]"), or "" when the operation doesn't declare
+// it. Returning value logs the value as is.
+type ScrubVariableFn func(name, typ string, value interface{}) interface{}
+
+// PlaceholderVariable is the ScrubVariableFn used by default: it replaces the values by their
+// type, as "<String!>", or by "<redacted>" when the variable isn't declared.
+func PlaceholderVariable(name, typ string, value interface{}) interface{} {
+	if typ == "" {
+		return "<redacted>"
+	}
+	return "<" + typ + ">"
+}
+
+// KeepVariables returns a ScrubVariableFn logging the values of the variables names as they are,
+// and scrubbing the others with PlaceholderVariable.
+func KeepVariables(names ...string) ScrubVariableFn {
+	kept := map[string]bool{}
+	for _, name := range names {
+		kept[name] = true
+	}
+	return func(name, typ string, value interface{}) interface{} {
+		if kept[name] {
+			return value
+		}
+		return PlaceholderVariable(name, typ, value)
+	}
+}
+
+// SanitizeRequest returns the log-safe representation of the request executing the operation
+// operationName of document with variables: the query is the operation normalized with the
+// fragments it spreads, as by NormalizeQuery, and every variable is scrubbed with scrub, or
+// PlaceholderVariable if it is nil. The whole document is kept when the operation isn't found.
+// The values written inline in the query are kept, so secrets must be sent as variables.
+func SanitizeRequest(document *ast.Document, operationName string, variables map[string]interface{}, scrub ScrubVariableFn) *SanitizedRequest {
+	if scrub == nil {
+		scrub = PlaceholderVariable
+	}
+	sanitized := &SanitizedRequest{OperationName: operationName}
+
+	types := map[string]string{}
+	if operation := selectedOperation(document, operationName); operation != nil {
+		name := ""
+		if operation.Name != nil {
+			name = operation.Name.Value
+		}
+		sanitized.Query = NormalizeQuery(SeparateOperations(document)[name])
+		for _, definition := range operation.VariableDefinitions {
+			if definition.Variable != nil && definition.Variable.Name != nil && definition.Type != nil {
+				typ, _ := printer.Print(definition.Type).(string)
+				types[definition.Variable.Name.Value] = typ
+			}
+		}
+	} else {
+		sanitized.Query = NormalizeQuery(document)
+	}
+
+	if len(variables) > 0 {
+		sanitized.Variables = make(map[string]interface{}, len(variables))
+		for name, value := range variables {
+			sanitized.Variables[name] = scrub(name, types[name], value)
+		}
+	}
+	return sanitized
+}
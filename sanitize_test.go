@@ -0,0 +1,60 @@
+package graphql_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+)
+
+func TestSanitizeRequest_ReplacesVariablesByTheirType(t *testing.T) {
+	document := parseDocument(t, `
+		query Other { viewer { id } }
+		mutation Login($user: String!, $password: String!, $tags: [ID!]) {
+			login(user: $user, password: $password, tags: $tags) { ...Session }
+		}
+		fragment Session on Session { token }
+	`)
+	sanitized := graphql.SanitizeRequest(document, "Login", map[string]interface{}{
+		"user":     "alice",
+		"password": "hunter2",
+		"tags":     []interface{}{"1"},
+		"extra":    "secret",
+	}, nil)
+
+	expected := &graphql.SanitizedRequest{
+		OperationName: "Login",
+		Query: `mutation Login($user: String!, $password: String!, $tags: [ID!]) {
+  login(user: $user, password: $password, tags: $tags) {
+    ...Session
+  }
+}
+
+fragment Session on Session {
+  token
+}
+`,
+		Variables: map[string]interface{}{
+			"user":     "<String!>",
+			"password": "<String!>",
+			"tags":     "<[ID!]>",
+			"extra":    "<redacted>",
+		},
+	}
+	if !reflect.DeepEqual(expected, sanitized) {
+		t.Fatalf("expected %#v, got %#v", expected, sanitized)
+	}
+}
+
+func TestSanitizeRequest_KeepVariables(t *testing.T) {
+	document := parseDocument(t, `query Search($term: String, $page: Int) { search(term: $term, page: $page) }`)
+	sanitized := graphql.SanitizeRequest(document, "", map[string]interface{}{
+		"term": "my address",
+		"page": 2,
+	}, graphql.KeepVariables("page"))
+
+	expected := map[string]interface{}{"term": "<String>", "page": 2}
+	if !reflect.DeepEqual(expected, sanitized.Variables) {
+		t.Fatalf("expected the variables %v, got %v", expected, sanitized.Variables)
+	}
+}
@@ -1,5 +1,10 @@
 package graphql
 
+import (
+	"fmt"
+	"sort"
+)
+
 type SchemaConfig struct {
 	Query        *Object
 	Mutation     *Object
@@ -7,6 +12,44 @@ type SchemaConfig struct {
 	Types        []Type
 	Directives   []*Directive
 	Extensions   []Extension
+
+	// BeginMutation, when set, is called around the execution of the root fields of every
+	// mutation, see BeginMutationFn. ExecuteParams.BeginMutation takes precedence over it.
+	BeginMutation BeginMutationFn
+
+	// StrictAbstractTypes makes NewSchema fail if there is an interface whose runtime types can't
+	// be resolved: without a ResolveType function while some of its implementations don't have an
+	// IsTypeOf function. Such interfaces otherwise fail when they are executed.
+	StrictAbstractTypes bool
+
+	// CollectFieldsCacheSize, when positive, is the number of collections of the sub-fields of the
+	// selections kept by the schema across executions, so the documents executed repeatedly, like
+	// persisted queries parsed once, don't collect the fields of their fragments for each object of
+	// each request again. They are looked up by the AST nodes of the document, the runtime type and
+	// the variables of the @skip and @include directives. The cache is emptied when it is full.
+	CollectFieldsCacheSize int
+
+	// ExecutionBudget, when set, bounds the number of executions of the schema running at the
+	// same time, whether they come from Do, DoAsync, the events of subscriptions or the handler:
+	// the others wait for one to finish, or fail when their context is done first.
+	ExecutionBudget *Budget
+
+	// FieldBudget, when set, bounds the number of resolvers of the schema running at the same time
+	// across all its executions, the same way.
+	FieldBudget *Budget
+
+	// Descriptions, when set, gives their descriptions to the definitions of the schema that don't
+	// have one, see Descriptions. The definitions are shared by the schemas using them, so they
+	// get these descriptions in all of them.
+	Descriptions Descriptions
+
+	// FieldCacheStore, when set, keeps the values of the fields with a FieldCache and a TTL across
+	// requests, see NewFieldCacheStore. Without it they are only reused within a request.
+	FieldCacheStore FieldCacheStore
+
+	// IDCodec, when set, encodes the values of the ID fields before they are sent and decodes the
+	// ID arguments before they are given to the resolvers, see IDCodec.
+	IDCodec IDCodec
 }
 
 type TypeMap map[string]Type
@@ -41,6 +84,13 @@ type Schema struct {
 	implementations  map[string][]*Object
 	possibleTypeMap  map[string]map[string]bool
 	extensions       []Extension
+	beginMutation    BeginMutationFn
+
+	collectFieldsCache *collectFieldsCache
+	executionBudget    *Budget
+	fieldBudget        *Budget
+	fieldCacheStore    FieldCacheStore
+	idCodec            IDCodec
 }
 
 func NewSchema(config SchemaConfig) (Schema, error) {
@@ -63,6 +113,14 @@ func NewSchema(config SchemaConfig) (Schema, error) {
 	schema.queryType = config.Query
 	schema.mutationType = config.Mutation
 	schema.subscriptionType = config.Subscription
+	schema.beginMutation = config.BeginMutation
+	schema.executionBudget = config.ExecutionBudget
+	schema.fieldBudget = config.FieldBudget
+	schema.fieldCacheStore = config.FieldCacheStore
+	schema.idCodec = config.IDCodec
+	if config.CollectFieldsCacheSize > 0 {
+		schema.collectFieldsCache = newCollectFieldsCache(config.CollectFieldsCacheSize)
+	}
 
 	// Provide specified directives (e.g. @include and @skip) by default.
 	schema.directives = config.Directives
@@ -137,14 +195,53 @@ func NewSchema(config SchemaConfig) (Schema, error) {
 		}
 	}
 
+	if config.StrictAbstractTypes {
+		if err := assertAbstractTypesResolvable(&schema); err != nil {
+			return schema, err
+		}
+	}
+
 	// Add extensions from config
 	if len(config.Extensions) != 0 {
 		schema.extensions = config.Extensions
 	}
 
+	if config.Descriptions != nil {
+		config.Descriptions.describe(&schema)
+	}
+
 	return schema, nil
 }
 
+// assertAbstractTypesResolvable checks that the runtime type of every interface of the schema can
+// be resolved, either by its ResolveType function or by the IsTypeOf functions of all its
+// implementations. Unions are checked when they are defined.
+func assertAbstractTypesResolvable(schema *Schema) error {
+	names := []string{}
+	for name := range schema.typeMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		iface, ok := schema.typeMap[name].(*Interface)
+		if !ok || iface.ResolveType != nil {
+			continue
+		}
+		for _, impl := range schema.PossibleTypes(iface) {
+			if err := invariantf(
+				impl.IsTypeOf != nil,
+				`Interface Type %v does not provide a "resolveType" function `+
+					`and implementing Type %v does not provide a "isTypeOf" `+
+					`function. There is no way to resolve this implementing type `+
+					`during execution.`, iface, impl,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Added Check implementation of interfaces at runtime..
 // Add Implementations at Runtime..
 func (gq *Schema) AddImplementation() error {
@@ -267,6 +364,39 @@ func (gq *Schema) AddExtensions(e ...Extension) {
 	gq.extensions = append(gq.extensions, e...)
 }
 
+// FieldMiddleware wraps the resolver of a field, see Schema.InstrumentField. It returns the
+// resolver to use instead of next, which it usually calls.
+type FieldMiddleware func(next FieldResolveFn) FieldResolveFn
+
+// InstrumentField wraps the resolver of the field fieldName of the object type typeName with
+// middleware, or the resolvers of all its fields if fieldName is empty. Fields without a resolver
+// have DefaultResolveFn wrapped. Middlewares added later wrap the ones added before them.
+//
+// The field definitions are updated in place, so this applies to every schema sharing the type
+// and must be done before executing queries with it.
+func (gq *Schema) InstrumentField(typeName, fieldName string, middleware FieldMiddleware) error {
+	object, ok := gq.Type(typeName).(*Object)
+	if !ok {
+		return fmt.Errorf(`Cannot instrument fields of "%v": not an object type of the schema.`, typeName)
+	}
+	fields := object.Fields()
+	if fieldName != "" {
+		field, ok := fields[fieldName]
+		if !ok {
+			return fmt.Errorf(`Cannot instrument "%v.%v": no such field.`, typeName, fieldName)
+		}
+		fields = FieldDefinitionMap{fieldName: field}
+	}
+	for _, field := range fields {
+		resolve := field.Resolve
+		if resolve == nil {
+			resolve = DefaultResolveFn
+		}
+		field.Resolve = middleware(resolve)
+	}
+	return nil
+}
+
 // map-reduce
 func typeMapReducer(schema *Schema, typeMap TypeMap, objectType Type) (TypeMap, error) {
 	var err error
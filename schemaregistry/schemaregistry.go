@@ -0,0 +1,251 @@
+// Package schemaregistry publishes the SDL of a schema, as printed by graphql.PrintSchema, along
+// with its hash to a schema registry, typically when a server starts:
+//
+//	publisher := schemaregistry.New(schemaregistry.Config{
+//		Format:   schemaregistry.Apollo,
+//		APIKey:   key,
+//		GraphRef: "my-graph@current",
+//	})
+//	if _, err := publisher.Publish(ctx, schema); err != nil {
+//		log.Printf("the schema wasn't published: %v", err)
+//	}
+//
+// A schema is only published when it changed since the last version published, as told by its
+// hash, which is kept in a file with Config.StateFile so the restarts of a server with the same
+// schema publish nothing.
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/client"
+)
+
+// Format is the protocol spoken with a registry.
+type Format int
+
+const (
+	// Generic registries are sent a POST request with the JSON object
+	// {"sdl": ..., "hash": ..., "graphRef": ..., "version": ...}, and answer with a 2xx status.
+	Generic Format = iota
+
+	// Apollo registries speak the schema reporting protocol of Apollo Studio.
+	Apollo
+)
+
+// DefaultApolloEndpoint is where the schemas are published with the Apollo format by default.
+const DefaultApolloEndpoint = "https://schema-reporting.api.apollographql.com/api/graphql"
+
+// libraryVersion identifies this package in the reports sent to Apollo.
+const libraryVersion = "github.com/fiatjaf/graphql/schemaregistry"
+
+// Config configures a Publisher.
+type Config struct {
+	// Endpoint is the URL of the registry, DefaultApolloEndpoint if empty with the Apollo format.
+	Endpoint string
+
+	// Format is the protocol of the registry, Generic by default.
+	Format Format
+
+	// APIKey is the Apollo API key of the graph, sent in the X-Api-Key header.
+	APIKey string
+
+	// GraphRef is the graph and variant the schema is published for, like "my-graph@current".
+	GraphRef string
+
+	// Version is the version of the server the schema is published by, e.g. its git commit.
+	Version string
+
+	// Header is added to every request, e.g. for the "Authorization" header of a generic registry.
+	Header http.Header
+
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	// StateFile, when set, is the file where the hash of the last schema published is kept.
+	StateFile string
+}
+
+// Publisher publishes schemas to a registry.
+type Publisher struct {
+	config Config
+	bootID string
+
+	mutex    sync.Mutex
+	lastHash string
+}
+
+// New returns a Publisher publishing with config.
+func New(config Config) *Publisher {
+	if config.Endpoint == "" && config.Format == Apollo {
+		config.Endpoint = DefaultApolloEndpoint
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &Publisher{config: config, bootID: hex.EncodeToString(id)}
+}
+
+// Hash returns the hash of an SDL identifying it in the registry, the hexadecimal SHA-256 of it.
+func Hash(sdl string) string {
+	hash := sha256.Sum256([]byte(sdl))
+	return hex.EncodeToString(hash[:])
+}
+
+// Publish publishes the SDL of schema, unless it is the last one published. It tells if the schema
+// was published.
+func (p *Publisher) Publish(ctx context.Context, schema graphql.Schema) (bool, error) {
+	return p.PublishSDL(ctx, graphql.PrintSchema(schema))
+}
+
+// PublishSDL publishes an SDL, unless it is the last one published. It tells if the SDL was
+// published.
+func (p *Publisher) PublishSDL(ctx context.Context, sdl string) (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	hash := Hash(sdl)
+	if hash == p.lastPublishedHash() {
+		return false, nil
+	}
+	var err error
+	if p.config.Format == Apollo {
+		err = p.publishApollo(ctx, sdl, hash)
+	} else {
+		err = p.publishGeneric(ctx, sdl, hash)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	p.lastHash = hash
+	if p.config.StateFile != "" {
+		if err := ioutil.WriteFile(p.config.StateFile, []byte(hash+"\n"), 0o644); err != nil {
+			return true, fmt.Errorf("the schema was published but its hash couldn't be saved: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// lastPublishedHash returns the hash of the last schema published, read from the state file if
+// none was published since the Publisher was created.
+func (p *Publisher) lastPublishedHash() string {
+	if p.lastHash == "" && p.config.StateFile != "" {
+		if b, err := ioutil.ReadFile(p.config.StateFile); err == nil {
+			p.lastHash = strings.TrimSpace(string(b))
+		}
+	}
+	return p.lastHash
+}
+
+func (p *Publisher) publishGeneric(ctx context.Context, sdl, hash string) error {
+	body, err := json.Marshal(map[string]string{
+		"sdl":      sdl,
+		"hash":     hash,
+		"graphRef": p.config.GraphRef,
+		"version":  p.config.Version,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range p.config.Header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		req.Header.Set("X-Api-Key", p.config.APIKey)
+	}
+	resp, err := p.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("schema rejected with status %v", resp.Status)
+	}
+	return nil
+}
+
+const reportSchemaMutation = `mutation SchemaReport($report: SchemaReport!, $coreSchema: String) {
+  reportSchema(report: $report, coreSchema: $coreSchema) {
+    __typename
+    ... on ReportSchemaError {
+      message
+      code
+    }
+    ... on ReportSchemaResponse {
+      inSeconds
+      withCoreSchema
+    }
+  }
+}`
+
+type reportSchemaResponse struct {
+	ReportSchema struct {
+		Typename       string `json:"__typename"`
+		Message        string `json:"message"`
+		Code           string `json:"code"`
+		WithCoreSchema bool   `json:"withCoreSchema"`
+	} `json:"reportSchema"`
+}
+
+// publishApollo reports the schema to Apollo, which first gets its hash and asks for the SDL when
+// it doesn't know it yet.
+func (p *Publisher) publishApollo(ctx context.Context, sdl, hash string) error {
+	c := client.New(p.config.Endpoint)
+	c.HTTPClient = p.config.HTTPClient
+	for key, values := range p.config.Header {
+		c.Header[key] = append([]string(nil), values...)
+	}
+	c.Header.Set("X-Api-Key", p.config.APIKey)
+	c.Header.Set("Apollographql-Client-Name", libraryVersion)
+
+	hostname, _ := os.Hostname()
+	variables := map[string]interface{}{
+		"report": map[string]interface{}{
+			"bootId":         p.bootID,
+			"coreSchemaHash": hash,
+			"graphRef":       p.config.GraphRef,
+			"libraryVersion": libraryVersion,
+			"platform":       runtime.GOOS,
+			"runtimeVersion": runtime.Version(),
+			"serverId":       hostname,
+			"userVersion":    p.config.Version,
+		},
+	}
+	for {
+		var resp reportSchemaResponse
+		if err := c.Mutate(ctx, reportSchemaMutation, variables, &resp); err != nil {
+			return err
+		}
+		switch {
+		case resp.ReportSchema.Typename == "ReportSchemaError":
+			return fmt.Errorf("schema rejected: %s (%s)", resp.ReportSchema.Message, resp.ReportSchema.Code)
+		case resp.ReportSchema.WithCoreSchema && variables["coreSchema"] == nil:
+			variables["coreSchema"] = sdl
+		case resp.ReportSchema.WithCoreSchema:
+			return fmt.Errorf("schema rejected: the SDL was asked for again")
+		default:
+			return nil
+		}
+	}
+}
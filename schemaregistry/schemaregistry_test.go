@@ -0,0 +1,133 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/schemaregistry"
+)
+
+func testSchema(t *testing.T, fieldName string) graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				fieldName: &graphql.Field{Type: graphql.String},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestPublisher_Generic(t *testing.T) {
+	published := []map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		published = append(published, body)
+	}))
+	defer server.Close()
+
+	config := schemaregistry.Config{
+		Endpoint:  server.URL,
+		GraphRef:  "graph@current",
+		Header:    http.Header{"Authorization": {"Bearer token"}},
+		StateFile: filepath.Join(t.TempDir(), "schema.hash"),
+	}
+	publish := func(publisher *schemaregistry.Publisher, schema graphql.Schema, expected bool) {
+		t.Helper()
+		ok, err := publisher.Publish(context.Background(), schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != expected {
+			t.Fatalf("expected published to be %v", expected)
+		}
+	}
+
+	publisher := schemaregistry.New(config)
+	publish(publisher, testSchema(t, "hello"), true)
+	publish(publisher, testSchema(t, "hello"), false)
+	// the state file tells the schema was already published after a restart
+	publish(schemaregistry.New(config), testSchema(t, "hello"), false)
+	publish(schemaregistry.New(config), testSchema(t, "goodbye"), true)
+
+	if len(published) != 2 {
+		t.Fatalf("expected 2 schemas published, got %v", published)
+	}
+	sdl := "type Query {\n  hello: String\n}\n"
+	expected := map[string]string{"sdl": sdl, "hash": schemaregistry.Hash(sdl), "graphRef": "graph@current", "version": ""}
+	for key, value := range expected {
+		if published[0][key] != value {
+			t.Fatalf("expected %v to be %q, got %q", key, value, published[0][key])
+		}
+	}
+
+	config.Header = nil
+	if _, err := schemaregistry.New(config).Publish(context.Background(), testSchema(t, "rejected")); err == nil {
+		t.Fatalf("expected the rejection of the registry to be an error")
+	}
+}
+
+func TestPublisher_Apollo(t *testing.T) {
+	requests := []map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "service:key" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, body.Variables)
+		// the SDL is asked for when it wasn't sent yet
+		_, withSDL := body.Variables["coreSchema"]
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"reportSchema": map[string]interface{}{
+					"__typename":     "ReportSchemaResponse",
+					"inSeconds":      3600,
+					"withCoreSchema": !withSDL,
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	publisher := schemaregistry.New(schemaregistry.Config{
+		Endpoint: server.URL,
+		Format:   schemaregistry.Apollo,
+		APIKey:   "service:key",
+		GraphRef: "graph@current",
+	})
+	if ok, err := publisher.Publish(context.Background(), testSchema(t, "hello")); err != nil || !ok {
+		t.Fatalf("expected the schema to be published, got %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected a report and then the SDL, got %v", requests)
+	}
+	sdl := "type Query {\n  hello: String\n}\n"
+	report := requests[0]["report"].(map[string]interface{})
+	if report["coreSchemaHash"] != schemaregistry.Hash(sdl) || report["graphRef"] != "graph@current" {
+		t.Fatalf("unexpected report %v", report)
+	}
+	if requests[1]["coreSchema"] != sdl {
+		t.Fatalf("expected the SDL, got %v", requests[1]["coreSchema"])
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"fmt"
 
 	"github.com/fiatjaf/graphql/gqlerrors"
-	"github.com/fiatjaf/graphql/language/parser"
-	"github.com/fiatjaf/graphql/language/source"
 )
 
 // SubscribeParams parameters for subscribing
@@ -23,41 +21,36 @@ type SubscribeParams struct {
 
 // Subscribe performs a subscribe operation on the given query and schema
 // To finish a subscription you can simply close the channel from inside the `Subscribe` function
-// currently does not support extensions hooks
 func Subscribe(p Params) chan *Result {
-	source := source.NewSource(&source.Source{
-		Body: []byte(p.RequestString),
-		Name: "GraphQL request",
-	})
-
-	// TODO run extensions hooks
-
-	// parse the source
-	AST, err := parser.Parse(parser.ParseParams{Source: source})
-	if err != nil {
-		// merge the errors from extensions and the original error from parser
-		return sendOneResultAndClose(&Result{
-			Errors: gqlerrors.FormatErrors(err),
-		})
+	AST, warnings, errs := parseAndValidate(&p)
+	if len(errs) != 0 {
+		result := &Result{
+			Errors: errs,
+		}
+		addWarningsExtension(result, warnings)
+		return sendOneResultAndClose(result)
 	}
 
-	// validate document
-	validationResult := ValidateDocument(&p.Schema, AST, nil)
-
-	if !validationResult.IsValid {
-		// run validation finish functions for extensions
-		return sendOneResultAndClose(&Result{
-			Errors: validationResult.Errors,
-		})
-	}
-	return ExecuteSubscription(ExecuteParams{
+	return warnFirstResult(p.Context, ExecuteSubscription(ExecuteParams{
 		Schema:        p.Schema,
 		Root:          p.RootObject,
 		AST:           AST,
 		OperationName: p.OperationName,
 		Args:          p.VariableValues,
 		Context:       p.Context,
-	})
+		Redact:        p.Redact,
+
+		PreserveFieldOrder: p.PreserveFieldOrder,
+	}), warnings)
+}
+
+type initialEventKey struct{}
+
+// IsInitialEvent tells if a subscription field is resolved with ctx for the first result of a
+// subscription, synthesized because of Field.ResolveOnSubscribe, rather than for an event.
+func IsInitialEvent(ctx context.Context) bool {
+	initial, _ := ctx.Value(initialEventKey{}).(bool)
+	return initial
 }
 
 func sendOneResultAndClose(res *Result) chan *Result {
@@ -67,15 +60,16 @@ func sendOneResultAndClose(res *Result) chan *Result {
 	return resultChannel
 }
 
-// ExecuteSubscription is similar to graphql.Execute but returns a channel instead of a Result
-// currently does not support extensions
+// ExecuteSubscription is similar to graphql.Execute but returns a channel instead of a Result.
+// The extensions are notified through ResolveFieldDidStart when the subscription field's Subscribe
+// function is called, and then go through the usual execution hooks for every event.
 func ExecuteSubscription(p ExecuteParams) chan *Result {
 	if p.Context == nil {
 		p.Context = context.Background()
 	}
 
 	resultChannel := make(chan *Result)
-	go func() {
+	Go(p.Context, "subscription", func() {
 		defer close(resultChannel)
 		defer func() {
 			if err := recover(); err != nil {
@@ -100,7 +94,7 @@ func ExecuteSubscription(p ExecuteParams) chan *Result {
 		})
 		if err != nil {
 			resultChannel <- &Result{
-				Errors: gqlerrors.FormatErrors(err),
+				Errors: formatVariableErrors(err),
 			}
 
 			return
@@ -152,6 +146,15 @@ func ExecuteSubscription(p ExecuteParams) chan *Result {
 		}
 
 		args := getArgumentValues(fieldDef.Args, fieldNode.Arguments, exeContext.VariableValues)
+		if p.Schema.idCodec != nil {
+			var err error
+			if args, err = decodeArgumentIDs(p.Schema.idCodec, fieldDef.Args, args); err != nil {
+				resultChannel <- &Result{
+					Errors: gqlerrors.FormatErrors(NewLocatedErrorWithPath(err, FieldASTsToNodeASTs(fieldNodes), fieldPath.AsArray())),
+				}
+				return
+			}
+		}
 		info := ResolveInfo{
 			FieldName:      fieldName,
 			FieldASTs:      fieldNodes,
@@ -163,14 +166,44 @@ func ExecuteSubscription(p ExecuteParams) chan *Result {
 			RootValue:      exeContext.Root,
 			Operation:      exeContext.Operation,
 			VariableValues: exeContext.VariableValues,
+			inclusions:     exeContext.inclusions,
 		}
 
-		fieldResult, err := resolveFn(ResolveParams{
+		if err := authorize(p.Context, fieldDef, ResolveParams{
 			Source:  p.Root,
 			Args:    args,
 			Info:    info,
 			Context: p.Context,
+		}); err != nil {
+			resultChannel <- &Result{
+				Errors: gqlerrors.FormatErrors(NewLocatedErrorWithPath(err, FieldASTsToNodeASTs(fieldNodes), fieldPath.AsArray())),
+			}
+
+			return
+		}
+
+		extErrs, fieldCtx, resolveFieldFinishFn := handleExtensionsResolveFieldDidStart(p.Schema.extensions, p.Context, &info)
+		if len(extErrs) != 0 {
+			resultChannel <- &Result{
+				Errors: extErrs,
+			}
+
+			return
+		}
+
+		fieldResult, err := resolveFn(ResolveParams{
+			Source:  p.Root,
+			Args:    args,
+			Info:    info,
+			Context: fieldCtx,
 		})
+		if extErrs := resolveFieldFinishFn(fieldResult, err); len(extErrs) != 0 {
+			resultChannel <- &Result{
+				Errors: extErrs,
+			}
+
+			return
+		}
 		if err != nil {
 			resultChannel <- &Result{
 				Errors: gqlerrors.FormatErrors(err),
@@ -187,6 +220,26 @@ func ExecuteSubscription(p ExecuteParams) chan *Result {
 			return
 		}
 
+		if fieldDef.ResolveOnSubscribe {
+			// the first result is synthesized from the root value, before any event
+			result := Execute(ExecuteParams{
+				Schema:        p.Schema,
+				Root:          p.Root,
+				AST:           p.AST,
+				OperationName: p.OperationName,
+				Args:          p.Args,
+				Context:       context.WithValue(p.Context, initialEventKey{}, true),
+				Redact:        p.Redact,
+
+				PreserveFieldOrder: p.PreserveFieldOrder,
+			})
+			select {
+			case <-p.Context.Done():
+				return
+			case resultChannel <- result:
+			}
+		}
+
 		for {
 			select {
 			case <-p.Context.Done():
@@ -203,10 +256,13 @@ func ExecuteSubscription(p ExecuteParams) chan *Result {
 					OperationName: p.OperationName,
 					Args:          p.Args,
 					Context:       p.Context,
+					Redact:        p.Redact,
+
+					PreserveFieldOrder: p.PreserveFieldOrder,
 				})
 			}
 		}
-	}()
+	})
 
 	// return a result channel
 	return resultChannel
@@ -3,6 +3,7 @@ package graphql_test
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/fiatjaf/graphql"
@@ -68,6 +69,35 @@ func TestSchemaSubscribe(t *testing.T) {
 				{Data: `{ "sub_with_resolver": "c" }`},
 			},
 		},
+		{
+			Name: "subscribe with initial value",
+			Schema: makeSubscriptionSchema(t, graphql.ObjectConfig{
+				Name: "Subscription",
+				Fields: graphql.Fields{
+					"sub_with_initial_value": &graphql.Field{
+						Type: graphql.String,
+						Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+							if graphql.IsInitialEvent(p.Context) {
+								return "current", nil
+							}
+							return p.Source, nil
+						},
+						Subscribe:          makeSubscribeToStringFunction([]string{"a", "b"}),
+						ResolveOnSubscribe: true,
+					},
+				},
+			}),
+			Query: `
+				subscription {
+					sub_with_initial_value
+				}
+			`,
+			ExpectedResults: []testutil.TestResponse{
+				{Data: `{ "sub_with_initial_value": "current" }`},
+				{Data: `{ "sub_with_initial_value": "a" }`},
+				{Data: `{ "sub_with_initial_value": "b" }`},
+			},
+		},
 		{
 			Name: "receive query validation error",
 			Schema: makeSubscriptionSchema(t, graphql.ObjectConfig{
@@ -96,7 +126,7 @@ func TestSchemaSubscribe(t *testing.T) {
 				Fields: graphql.Fields{
 					"should_error": &graphql.Field{
 						Type: graphql.String,
-						Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+						Subscribe: func(p graphql.ResolveParams) (chan any, error) {
 							panic(errors.New("got a panic error"))
 						},
 					},
@@ -189,7 +219,7 @@ func TestSchemaSubscribe(t *testing.T) {
 				Fields: graphql.Fields{
 					"should_error": &graphql.Field{
 						Type: graphql.String,
-						Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+						Subscribe: func(p graphql.ResolveParams) (chan any, error) {
 							return nil, errors.New("got a subscribe error")
 						},
 					},
@@ -230,9 +260,9 @@ func TestSchemaSubscribe(t *testing.T) {
 	})
 }
 
-func makeSubscribeToStringFunction(elements []string) func(p graphql.ResolveParams) (interface{}, error) {
-	return func(p graphql.ResolveParams) (interface{}, error) {
-		c := make(chan interface{})
+func makeSubscribeToStringFunction(elements []string) graphql.SubscriptionFieldResolveFn {
+	return func(p graphql.ResolveParams) (chan any, error) {
+		c := make(chan any)
 		go func() {
 			for _, r := range elements {
 				select {
@@ -248,9 +278,9 @@ func makeSubscribeToStringFunction(elements []string) func(p graphql.ResolvePara
 	}
 }
 
-func makeSubscribeToMapFunction(elements []map[string]interface{}) func(p graphql.ResolveParams) (interface{}, error) {
-	return func(p graphql.ResolveParams) (interface{}, error) {
-		c := make(chan interface{})
+func makeSubscribeToMapFunction(elements []map[string]interface{}) graphql.SubscriptionFieldResolveFn {
+	return func(p graphql.ResolveParams) (chan any, error) {
+		c := make(chan any)
 		go func() {
 			for _, r := range elements {
 				select {
@@ -283,3 +313,51 @@ var dummyQuery = graphql.NewObject(graphql.ObjectConfig{
 		"hello": &graphql.Field{Type: graphql.String},
 	},
 })
+
+func TestDoAsync_SelectsSubscriptionByOperationName(t *testing.T) {
+	schema := makeSubscriptionSchema(t, graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"sub": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+				Subscribe: makeSubscribeToStringFunction([]string{"a", "b"}),
+			},
+		},
+	})
+	query := `
+		fragment F on Subscription { sub }
+		query Hello { hello }
+		subscription Sub { ...F }
+	`
+
+	var results []*graphql.Result
+	for result := range graphql.DoAsync(graphql.Params{
+		Schema:        schema,
+		RequestString: query,
+		OperationName: "Sub",
+	}) {
+		results = append(results, result)
+	}
+	expected := []*graphql.Result{
+		{Data: map[string]interface{}{"sub": "a"}},
+		{Data: map[string]interface{}{"sub": "b"}},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("wrong result, graphql result diff: %v", testutil.Diff(expected, results))
+	}
+
+	results = nil
+	for result := range graphql.DoAsync(graphql.Params{
+		Schema:        schema,
+		RequestString: query,
+	}) {
+		results = append(results, result)
+	}
+	if len(results) != 1 || len(results[0].Errors) != 1 ||
+		results[0].Errors[0].Message != "Must provide operation name if query contains multiple operations." {
+		t.Fatalf("expected a single error result, got %#v", results)
+	}
+}
@@ -0,0 +1,46 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+)
+
+// goroutinesGracePeriod is how long CheckGoroutines lets the goroutines end after the test.
+const goroutinesGracePeriod = time.Second
+
+// CheckGoroutines enables goroutine tracking for the rest of the test, and makes it fail if
+// goroutines spawned by the package or the handler during the test are still running a moment
+// after it ended, listing their labels, ages and operations. As the tracking is global, it must
+// not be used by parallel tests.
+func CheckGoroutines(t testing.TB) {
+	t.Helper()
+	started := time.Now()
+	graphql.SetGoroutineTracking(true)
+	t.Cleanup(func() {
+		defer graphql.SetGoroutineTracking(false)
+
+		deadline := time.Now().Add(goroutinesGracePeriod)
+		for {
+			var leaked []string
+			for _, goroutine := range graphql.Goroutines() {
+				if goroutine.Started.Before(started) {
+					continue
+				}
+				leaked = append(leaked, fmt.Sprintf("%q running for %v (operation %q)",
+					goroutine.Label, goroutine.Age().Round(time.Millisecond), goroutine.OperationName))
+			}
+			if len(leaked) == 0 {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Errorf("%d goroutines outlived the test:\n%s", len(leaked), strings.Join(leaked, "\n"))
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
@@ -5,23 +5,37 @@ import (
 	"github.com/fiatjaf/graphql/language/kinds"
 )
 
-// TODO: can move TypeInfo to a utils package if there ever is one
-/**
- * TypeInfo is a utility class which, given a GraphQL schema, can keep track
- * of the current field and type definitions at any point in a GraphQL document
- * AST during a recursive descent by calling `enter(node)` and `leave(node)`.
- */
-type fieldDefFn func(schema *Schema, parentType Type, fieldAST *ast.Field) *FieldDefinition
+// FieldDefFn returns the definition of the field selected by fieldAST on parentType, or nil if
+// it has none.
+type FieldDefFn func(schema *Schema, parentType Type, fieldAST *ast.Field) *FieldDefinition
 
+// TypeInfo keeps track of the types and definitions of the schema at each point of a document
+// while it's walked: it's entered and left with each node, usually by visiting the document with
+// visitor.VisitWithTypeInfo, and tells the parent type, field definition, input type, directive
+// and so on of the node being visited. It's the type information used by validation, exposed to
+// build tools such as linters, cost analyzers and auto-completion.
+//
+//	typeInfo := graphql.NewTypeInfo(&graphql.TypeInfoConfig{Schema: &schema})
+//	visitor.Visit(document, visitor.VisitWithTypeInfo(typeInfo, &visitor.VisitorOptions{
+//		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+//			kinds.Field: {Kind: func(p visitor.VisitFuncParams) (string, interface{}) {
+//				fmt.Println(typeInfo.ParentType(), typeInfo.FieldDef().Name)
+//				return visitor.ActionNoChange, nil
+//			}},
+//		},
+//	}), nil)
 type TypeInfo struct {
-	schema          *Schema
-	typeStack       []Output
-	parentTypeStack []Composite
-	inputTypeStack  []Input
-	fieldDefStack   []*FieldDefinition
-	directive       *Directive
-	argument        *Argument
-	getFieldDef     fieldDefFn
+	schema               *Schema
+	typeStack            []Output
+	parentTypeStack      []Composite
+	inputTypeStack       []Input
+	parentInputTypeStack []Input
+	fieldDefStack        []*FieldDefinition
+	defaultValueStack    []interface{}
+	directive            *Directive
+	argument             *Argument
+	enumValue            *EnumValueDefinition
+	getFieldDef          FieldDefFn
 }
 
 type TypeInfoConfig struct {
@@ -30,7 +44,7 @@ type TypeInfoConfig struct {
 	// NOTE: this experimental optional second parameter is only needed in order
 	// to support non-spec-compliant codebases. You should never need to use it.
 	// It may disappear in the future.
-	FieldDefFn fieldDefFn
+	FieldDefFn FieldDefFn
 }
 
 func NewTypeInfo(opts *TypeInfoConfig) *TypeInfo {
@@ -44,6 +58,8 @@ func NewTypeInfo(opts *TypeInfoConfig) *TypeInfo {
 	}
 }
 
+// Type returns the output type of the node: the type of the field or the operation type, or the
+// type condition of a fragment.
 func (ti *TypeInfo) Type() Output {
 	if len(ti.typeStack) > 0 {
 		return ti.typeStack[len(ti.typeStack)-1]
@@ -51,6 +67,7 @@ func (ti *TypeInfo) Type() Output {
 	return nil
 }
 
+// ParentType returns the composite type whose fields are selected by the current selection set.
 func (ti *TypeInfo) ParentType() Composite {
 	if len(ti.parentTypeStack) > 0 {
 		return ti.parentTypeStack[len(ti.parentTypeStack)-1]
@@ -58,6 +75,8 @@ func (ti *TypeInfo) ParentType() Composite {
 	return nil
 }
 
+// InputType returns the expected type of the current argument, variable definition, list item or
+// input object field.
 func (ti *TypeInfo) InputType() Input {
 	if len(ti.inputTypeStack) > 0 {
 		return ti.inputTypeStack[len(ti.inputTypeStack)-1]
@@ -65,6 +84,16 @@ func (ti *TypeInfo) InputType() Input {
 	return nil
 }
 
+// ParentInputType returns the input type holding the current input type: the list of an item,
+// or the input object of a field.
+func (ti *TypeInfo) ParentInputType() Input {
+	if len(ti.parentInputTypeStack) > 0 {
+		return ti.parentInputTypeStack[len(ti.parentInputTypeStack)-1]
+	}
+	return nil
+}
+
+// FieldDef returns the definition of the current field.
 func (ti *TypeInfo) FieldDef() *FieldDefinition {
 	if len(ti.fieldDefStack) > 0 {
 		return ti.fieldDefStack[len(ti.fieldDefStack)-1]
@@ -72,14 +101,31 @@ func (ti *TypeInfo) FieldDef() *FieldDefinition {
 	return nil
 }
 
+// DefaultValue returns the default value of the current argument or input object field, nil if
+// it has none.
+func (ti *TypeInfo) DefaultValue() interface{} {
+	if len(ti.defaultValueStack) > 0 {
+		return ti.defaultValueStack[len(ti.defaultValueStack)-1]
+	}
+	return nil
+}
+
+// Directive returns the definition of the current directive.
 func (ti *TypeInfo) Directive() *Directive {
 	return ti.directive
 }
 
+// Argument returns the definition of the current argument, of a field or directive.
 func (ti *TypeInfo) Argument() *Argument {
 	return ti.argument
 }
 
+// EnumValue returns the definition of the current enum value.
+func (ti *TypeInfo) EnumValue() *EnumValueDefinition {
+	return ti.enumValue
+}
+
+// Enter updates the type information with node, when it's entered by the visitor.
 func (ti *TypeInfo) Enter(node ast.Node) {
 	schema := ti.schema
 	var ttype Type
@@ -110,12 +156,17 @@ func (ti *TypeInfo) Enter(node ast.Node) {
 		}
 		ti.directive = schema.Directive(nameVal)
 	case *ast.OperationDefinition:
+		var operationType *Object
 		if node.Operation == ast.OperationTypeQuery {
-			ttype = schema.QueryType()
+			operationType = schema.QueryType()
 		} else if node.Operation == ast.OperationTypeMutation {
-			ttype = schema.MutationType()
+			operationType = schema.MutationType()
 		} else if node.Operation == ast.OperationTypeSubscription {
-			ttype = schema.SubscriptionType()
+			operationType = schema.SubscriptionType()
+		}
+		// a schema without the operation type mustn't give a nil *Object as the type
+		if operationType != nil {
+			ttype = operationType
 		}
 		ti.typeStack = append(ti.typeStack, ttype)
 	case *ast.InlineFragment:
@@ -159,13 +210,19 @@ func (ti *TypeInfo) Enter(node ast.Node) {
 				}
 			}
 		}
+		var defaultValue interface{}
 		if argDef != nil {
 			argType = argDef.Type
+			defaultValue = argDef.DefaultValue
 		}
 		ti.argument = argDef
+		ti.defaultValueStack = append(ti.defaultValueStack, defaultValue)
 		ti.inputTypeStack = append(ti.inputTypeStack, argType)
 	case *ast.ListValue:
 		listType := GetNullable(ti.InputType())
+		ti.parentInputTypeStack = append(ti.parentInputTypeStack, ti.InputType())
+		// the items of a list have no default value
+		ti.defaultValueStack = append(ti.defaultValueStack, nil)
 		if list, ok := listType.(*List); ok {
 			ti.inputTypeStack = append(ti.inputTypeStack, list.OfType)
 		} else {
@@ -173,6 +230,7 @@ func (ti *TypeInfo) Enter(node ast.Node) {
 		}
 	case *ast.ObjectField:
 		var fieldType Input
+		var defaultValue interface{}
 		objectType := GetNamed(ti.InputType())
 
 		if objectType, ok := objectType.(*InputObject); ok {
@@ -182,12 +240,25 @@ func (ti *TypeInfo) Enter(node ast.Node) {
 			}
 			if inputField, ok := objectType.Fields()[nameVal]; ok {
 				fieldType = inputField.Type
+				defaultValue = inputField.DefaultValue
 			}
 		}
+		ti.parentInputTypeStack = append(ti.parentInputTypeStack, ti.InputType())
+		ti.defaultValueStack = append(ti.defaultValueStack, defaultValue)
 		ti.inputTypeStack = append(ti.inputTypeStack, fieldType)
+	case *ast.EnumValue:
+		ti.enumValue = nil
+		if enum, ok := GetNamed(ti.InputType()).(*Enum); ok {
+			for _, value := range enum.Values() {
+				if value.Name == node.Value {
+					ti.enumValue = value
+				}
+			}
+		}
 	}
 }
 
+// Leave updates the type information with node, when it's left by the visitor.
 func (ti *TypeInfo) Leave(node ast.Node) {
 	kind := node.GetKind()
 	switch kind {
@@ -219,15 +290,28 @@ func (ti *TypeInfo) Leave(node ast.Node) {
 		}
 	case kinds.Argument:
 		ti.argument = nil
+		// pop ti.defaultValueStack
+		if len(ti.defaultValueStack) > 0 {
+			ti.defaultValueStack = ti.defaultValueStack[:len(ti.defaultValueStack)-1]
+		}
 		// pop ti.inputTypeStack
 		if len(ti.inputTypeStack) > 0 {
 			_, ti.inputTypeStack = ti.inputTypeStack[len(ti.inputTypeStack)-1], ti.inputTypeStack[:len(ti.inputTypeStack)-1]
 		}
 	case kinds.ListValue, kinds.ObjectField:
+		// pop ti.parentInputTypeStack and ti.defaultValueStack
+		if len(ti.parentInputTypeStack) > 0 {
+			ti.parentInputTypeStack = ti.parentInputTypeStack[:len(ti.parentInputTypeStack)-1]
+		}
+		if len(ti.defaultValueStack) > 0 {
+			ti.defaultValueStack = ti.defaultValueStack[:len(ti.defaultValueStack)-1]
+		}
 		// pop ti.inputTypeStack
 		if len(ti.inputTypeStack) > 0 {
 			_, ti.inputTypeStack = ti.inputTypeStack[len(ti.inputTypeStack)-1], ti.inputTypeStack[:len(ti.inputTypeStack)-1]
 		}
+	case kinds.EnumValue:
+		ti.enumValue = nil
 	}
 }
 
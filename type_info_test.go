@@ -0,0 +1,101 @@
+package graphql_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/kinds"
+	"github.com/fiatjaf/graphql/language/visitor"
+	"github.com/fiatjaf/graphql/testutil"
+)
+
+func TestTypeInfo_TracksTypesWhileVisiting(t *testing.T) {
+	color := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Color",
+		Values: graphql.EnumValueConfigMap{
+			"RED":  &graphql.EnumValueConfig{Value: 0},
+			"BLUE": &graphql.EnumValueConfig{Value: 1},
+		},
+	})
+	filter := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "Filter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"colors": &graphql.InputObjectFieldConfig{Type: graphql.NewList(color)},
+			"limit":  &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 10},
+		},
+	})
+	item := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Item",
+		Fields: graphql.Fields{"name": &graphql.Field{Type: graphql.String}},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"items": &graphql.Field{
+					Type: graphql.NewList(item),
+					Args: graphql.FieldConfigArgument{
+						"filter": &graphql.ArgumentConfig{Type: filter},
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	document := parseDocument(t, `{ items(filter: {colors: [RED], limit: 5}) { name @skip(if: false) } }`)
+
+	var visited []string
+	typeInfo := graphql.NewTypeInfo(&graphql.TypeInfoConfig{Schema: &schema})
+	record := func(p visitor.VisitFuncParams) (string, interface{}) {
+		node := p.Node.(ast.Node)
+		entry := fmt.Sprintf("%s parent=%v type=%v input=%v parentInput=%v default=%v",
+			node.GetKind(), typeInfo.ParentType(), typeInfo.Type(), typeInfo.InputType(), typeInfo.ParentInputType(), typeInfo.DefaultValue())
+		if fieldDef := typeInfo.FieldDef(); fieldDef != nil {
+			entry += " field=" + fieldDef.Name
+		}
+		if directive := typeInfo.Directive(); directive != nil {
+			entry += " directive=" + directive.Name
+		}
+		if enumValue := typeInfo.EnumValue(); enumValue != nil {
+			entry += " enumValue=" + enumValue.Name
+		}
+		visited = append(visited, entry)
+		return visitor.ActionNoChange, nil
+	}
+	visitor.Visit(document, visitor.VisitWithTypeInfo(typeInfo, &visitor.VisitorOptions{
+		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+			kinds.Field:       {Kind: record},
+			kinds.ObjectField: {Kind: record},
+			kinds.EnumValue:   {Kind: record},
+			kinds.IntValue:    {Kind: record},
+			kinds.Directive:   {Kind: record},
+		},
+	}), nil)
+
+	expected := []string{
+		"Field parent=Query type=[Item] input=<nil> parentInput=<nil> default=<nil> field=items",
+		"ObjectField parent=Query type=[Item] input=[Color] parentInput=Filter default=<nil> field=items",
+		"EnumValue parent=Query type=[Item] input=Color parentInput=[Color] default=<nil> field=items enumValue=RED",
+		"ObjectField parent=Query type=[Item] input=Int parentInput=Filter default=10 field=items",
+		"IntValue parent=Query type=[Item] input=Int parentInput=Filter default=10 field=items",
+		"Field parent=Item type=String input=<nil> parentInput=<nil> default=<nil> field=name",
+		"Directive parent=Item type=String input=<nil> parentInput=<nil> default=<nil> field=name directive=skip",
+	}
+	if !reflect.DeepEqual(expected, visited) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, visited))
+	}
+}
+
+func TestTypeInfo_HasNoTypeForMissingOperationType(t *testing.T) {
+	typeInfo := graphql.NewTypeInfo(&graphql.TypeInfoConfig{Schema: &testutil.StarWarsSchema})
+	document := parseDocument(t, `subscription { hero { name } }`)
+	typeInfo.Enter(document.Definitions[0])
+	if ttype := typeInfo.Type(); ttype != nil {
+		t.Fatalf("expected no type, got %#v", ttype)
+	}
+}
@@ -1,19 +1,305 @@
 package graphql
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+
 	"github.com/fiatjaf/graphql/gqlerrors"
 )
 
 // type Schema interface{}
 
-// Result has the response, errors and extensions from the resolved schema
+// Result has the response, errors and extensions from the resolved schema.
+// A Result may be shared, e.g. when the same subscription event is sent to many connections, so it
+// must be treated as read-only once it was delivered: use Clone to get a copy that can be changed.
 type Result struct {
 	Data       interface{}                `json:"data"`
 	Errors     []gqlerrors.FormattedError `json:"errors,omitempty"`
 	Extensions map[string]interface{}     `json:"extensions,omitempty"`
+
+	// fieldOrder, when set, is the order in which the fields of the data are encoded
+	fieldOrder *fieldOrder
 }
 
 // HasErrors just a simple function to help you decide if the result has errors or not
 func (r *Result) HasErrors() bool {
 	return len(r.Errors) > 0
 }
+
+// ResultEncoding configures how the results are encoded in JSON, see SetResultEncoding.
+type ResultEncoding struct {
+	// OmitDataOnRequestErrors leaves "data" out of the results of the requests that failed before
+	// being executed, as the spec requires, instead of it being null. These are the results with
+	// errors but no data, none of whose errors has a path.
+	OmitDataOnRequestErrors bool
+
+	// ErrorsFirst puts "errors" before "data", which the spec recommends so that they are noticed.
+	ErrorsFirst bool
+
+	// Marshal encodes the values of the results, json.Marshal if nil. A faster encoder compatible
+	// with encoding/json may be plugged in here, such as jsoniter's.
+	Marshal func(v interface{}) ([]byte, error)
+}
+
+var resultEncoding atomic.Value
+
+func init() {
+	resultEncoding.Store(ResultEncoding{})
+}
+
+// SetResultEncoding changes how all the results are encoded, by json.Marshal, MarshalJSONTo and
+// StreamJSONTo. It is meant to be called when the program starts.
+func SetResultEncoding(encoding ResultEncoding) {
+	resultEncoding.Store(encoding)
+}
+
+func currentResultEncoding() ResultEncoding {
+	encoding := resultEncoding.Load().(ResultEncoding)
+	if encoding.Marshal == nil {
+		encoding.Marshal = json.Marshal
+	}
+	return encoding
+}
+
+// MarshalJSON encodes the result as configured with SetResultEncoding: "errors" and "extensions"
+// are only present when they aren't empty.
+func (r Result) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.encodeJSON(&buf, currentResultEncoding(), false); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalJSONTo writes the JSON encoding of the result to w, the same as json.Marshal would produce,
+// using a pooled buffer so encoding many results doesn't allocate a new one each time.
+func (r *Result) MarshalJSONTo(w io.Writer) error {
+	buf := encodeBufferPool.Get().(*bytes.Buffer)
+	defer putEncodeBuffer(buf)
+
+	if err := r.encodeJSON(buf, currentResultEncoding(), false); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// StreamJSONTo writes the same JSON encoding of the result as MarshalJSONTo, but the lists and
+// objects of the data are encoded one value at a time as they are written, so the encoding of the
+// whole result is never held in memory. w should be buffered.
+func (r *Result) StreamJSONTo(w io.Writer) error {
+	return r.encodeJSON(w, currentResultEncoding(), true)
+}
+
+// encodeJSON writes the members of the result to w, the data being streamed if stream is set.
+func (r *Result) encodeJSON(w io.Writer, encoding ResultEncoding, stream bool) error {
+	members := []string{"data", "errors", "extensions"}
+	if encoding.ErrorsFirst {
+		members = []string{"errors", "data", "extensions"}
+	}
+	separator := "{"
+	for _, member := range members {
+		var value interface{}
+		switch member {
+		case "data":
+			if encoding.OmitDataOnRequestErrors && r.IsRequestError() {
+				continue
+			}
+			value = r.Data
+		case "errors":
+			if len(r.Errors) == 0 {
+				continue
+			}
+			value = r.Errors
+		case "extensions":
+			if len(r.Extensions) == 0 {
+				continue
+			}
+			value = r.Extensions
+		}
+		if _, err := io.WriteString(w, separator+`"`+member+`":`); err != nil {
+			return err
+		}
+		separator = ","
+		var err error
+		if member == "data" && (stream || r.fieldOrder != nil) {
+			err = streamJSON(w, encoding.Marshal, value, r.fieldOrder)
+		} else {
+			err = writeJSON(w, encoding.Marshal, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if separator == "{" {
+		_, err := io.WriteString(w, "{}")
+		return err
+	}
+	_, err := io.WriteString(w, "}")
+	return err
+}
+
+// IsRequestError tells if the result is that of a request that failed before being executed: it
+// has no data and none of its errors has a path, unlike the errors raised by the fields.
+func (r *Result) IsRequestError() bool {
+	if r.Data != nil || len(r.Errors) == 0 {
+		return false
+	}
+	for _, err := range r.Errors {
+		if len(err.Path) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w io.Writer, marshal func(v interface{}) ([]byte, error), value interface{}) error {
+	b, err := marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// streamJSON writes the JSON encoding of the maps and slices of a result value element by element,
+// the other values are encoded by marshal. The keys of the maps are in the given order, if any.
+func streamJSON(w io.Writer, marshal func(v interface{}) ([]byte, error), value interface{}, order *fieldOrder) error {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if value == nil {
+			break
+		}
+		var keys []string
+		if order != nil {
+			keys = order.sortKeys(value)
+		} else {
+			keys = make([]string, 0, len(value))
+			for key := range value {
+				keys = append(keys, key)
+			}
+			// encoding/json sorts the keys of maps too
+			sort.Strings(keys)
+		}
+		for i, key := range keys {
+			separator := ","
+			if i == 0 {
+				separator = "{"
+			}
+			encodedKey, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, separator+string(encodedKey)+":"); err != nil {
+				return err
+			}
+			if err := streamJSON(w, marshal, value[key], order.child(key)); err != nil {
+				return err
+			}
+		}
+		if len(keys) == 0 {
+			_, err := io.WriteString(w, "{}")
+			return err
+		}
+		_, err := io.WriteString(w, "}")
+		return err
+	case []interface{}:
+		if value == nil {
+			break
+		}
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		for i, item := range value {
+			if i > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			if err := streamJSON(w, marshal, item, order); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "]")
+		return err
+	}
+	return writeJSON(w, marshal, value)
+}
+
+// maxPooledBufferSize is the size above which encoding buffers are dropped instead of pooled, so a
+// single huge result doesn't keep its memory around.
+const maxPooledBufferSize = 64 << 10
+
+var encodeBufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func putEncodeBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	encodeBufferPool.Put(buf)
+}
+
+// Clone returns a deep copy of the result: the maps and slices of the data, the errors and the
+// extensions are copied, so the copy can be changed without affecting the original. Leaf values
+// are copied as they are.
+func (r *Result) Clone() *Result {
+	if r == nil {
+		return nil
+	}
+	clone := &Result{
+		Data:       cloneValue(r.Data),
+		fieldOrder: r.fieldOrder,
+	}
+	if r.Errors != nil {
+		clone.Errors = make([]gqlerrors.FormattedError, len(r.Errors))
+		for i, err := range r.Errors {
+			if err.Locations != nil {
+				err.Locations = append(err.Locations[:0:0], err.Locations...)
+			}
+			if err.Path != nil {
+				err.Path = append(err.Path[:0:0], err.Path...)
+			}
+			if err.Extensions != nil {
+				err.Extensions = cloneValue(err.Extensions).(map[string]interface{})
+			}
+			clone.Errors[i] = err
+		}
+	}
+	if r.Extensions != nil {
+		clone.Extensions = cloneValue(r.Extensions).(map[string]interface{})
+	}
+	return clone
+}
+
+// cloneValue deep copies the maps and slices produced by the execution.
+func cloneValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if value == nil {
+			return value
+		}
+		clone := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			clone[k] = cloneValue(v)
+		}
+		return clone
+	case []interface{}:
+		if value == nil {
+			return value
+		}
+		clone := make([]interface{}, len(value))
+		for i, v := range value {
+			clone[i] = cloneValue(v)
+		}
+		return clone
+	default:
+		return value
+	}
+}
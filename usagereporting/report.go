@@ -0,0 +1,231 @@
+package usagereporting
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// The latencies are counted in the buckets of Apollo's duration histograms: bucket i counts the
+// durations up to 1.1^i microseconds.
+const histogramBuckets = 384
+
+var histogramExponentLog = math.Log(1.1)
+
+// histogram counts durations by bucket. It only grows up to the highest bucket used.
+type histogram []int64
+
+func (h *histogram) add(duration time.Duration) {
+	bucket := 0
+	if duration > 0 {
+		unbounded := math.Ceil(math.Log(float64(duration.Nanoseconds())/1000) / histogramExponentLog)
+		if unbounded > 0 {
+			bucket = int(math.Min(unbounded, histogramBuckets-1))
+		}
+	}
+	for len(*h) <= bucket {
+		*h = append(*h, 0)
+	}
+	(*h)[bucket]++
+}
+
+// compressed returns the counts the way Apollo expects them: a run of several empty buckets is
+// replaced by its length, negated, and the trailing empty buckets are left out.
+func (h histogram) compressed() []int64 {
+	compressed := []int64{}
+	zeros := int64(0)
+	for _, count := range h {
+		if count == 0 {
+			zeros++
+			continue
+		}
+		if zeros == 1 {
+			compressed = append(compressed, 0)
+		} else if zeros > 1 {
+			compressed = append(compressed, -zeros)
+		}
+		compressed = append(compressed, count)
+		zeros = 0
+	}
+	return compressed
+}
+
+// reportHeader is the header of a report.
+type reportHeader struct {
+	graphRef       string
+	hostname       string
+	agentVersion   string
+	serviceVersion string
+	runtimeVersion string
+	uname          string
+	schemaID       string
+}
+
+// encodeReport encodes the Report message of Apollo's usage reporting protocol.
+func encodeReport(header reportHeader, stats map[string]*operationStats, operations uint64, end time.Time) []byte {
+	var report protoBuffer
+	report.message(1, func(b *protoBuffer) {
+		b.string(5, header.hostname)
+		b.string(6, header.agentVersion)
+		b.string(7, header.serviceVersion)
+		b.string(8, header.runtimeVersion)
+		b.string(9, header.uname)
+		b.string(11, header.schemaID)
+		b.string(12, header.graphRef)
+	})
+	report.message(2, func(b *protoBuffer) {
+		b.uint64(1, uint64(end.Unix()))
+		b.uint64(2, uint64(end.Nanosecond()))
+	})
+	for _, key := range sortedKeys(stats) {
+		operation := stats[key]
+		// traces_per_query, a map of TracesAndStats
+		report.message(5, func(b *protoBuffer) {
+			b.string(1, key)
+			b.message(2, func(b *protoBuffer) { encodeTracesAndStats(b, operation) })
+		})
+	}
+	report.uint64(6, operations)
+	return report
+}
+
+func encodeTracesAndStats(b *protoBuffer, operation *operationStats) {
+	contexts := make([]statsContext, 0, len(operation.contexts))
+	for context := range operation.contexts {
+		contexts = append(contexts, context)
+	}
+	sort.Slice(contexts, func(i, j int) bool {
+		if contexts[i].clientName != contexts[j].clientName {
+			return contexts[i].clientName < contexts[j].clientName
+		}
+		return contexts[i].clientVersion < contexts[j].clientVersion
+	})
+	for _, context := range contexts {
+		stats := operation.contexts[context]
+		// stats_with_context, ContextualizedStats
+		b.message(2, func(b *protoBuffer) {
+			b.message(1, func(b *protoBuffer) {
+				b.string(2, context.clientName)
+				b.string(3, context.clientVersion)
+			})
+			b.message(2, func(b *protoBuffer) {
+				b.sint64s(13, stats.latency.compressed())
+				b.uint64(2, stats.requestCount)
+				b.uint64(8, stats.requestsWithErrors)
+			})
+			for _, typeName := range sortedKeys(stats.fields) {
+				fields := stats.fields[typeName]
+				// per_type_stat, a map of TypeStat
+				b.message(3, func(b *protoBuffer) {
+					b.string(1, typeName)
+					b.message(2, func(b *protoBuffer) {
+						for _, fieldName := range sortedKeys(fields) {
+							field := fields[fieldName]
+							// per_field_stat, a map of FieldStat
+							b.message(3, func(b *protoBuffer) {
+								b.string(1, fieldName)
+								b.message(2, func(b *protoBuffer) {
+									b.string(3, field.returnType)
+									b.uint64(4, field.errorsCount)
+									b.uint64(5, field.observedCount)
+									b.uint64(6, field.requestsWithErrors)
+									b.sint64s(9, field.latency.compressed())
+									b.uint64(10, field.observedCount)
+								})
+							})
+						}
+					})
+				})
+			}
+		})
+	}
+	for _, typeName := range sortedKeys(operation.referencedFields) {
+		fields := operation.referencedFields[typeName]
+		// referenced_fields_by_type, a map of ReferencedFieldsForType
+		b.message(4, func(b *protoBuffer) {
+			b.string(1, typeName)
+			b.message(2, func(b *protoBuffer) {
+				for _, fieldName := range fields.fieldNames {
+					b.tag(1, wireBytes)
+					b.bytes([]byte(fieldName))
+				}
+				if fields.isInterface {
+					b.uint64(2, 1)
+				}
+			})
+		})
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// The wire types of protobuf used by the reports.
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// protoBuffer is a minimal protobuf encoder, enough for the messages of the reports. Like protobuf
+// does for proto3 messages, the fields with a zero value are left out.
+type protoBuffer []byte
+
+func (b *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		*b = append(*b, byte(v)|0x80)
+		v >>= 7
+	}
+	*b = append(*b, byte(v))
+}
+
+func (b *protoBuffer) tag(field int, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (b *protoBuffer) bytes(v []byte) {
+	b.varint(uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+func (b *protoBuffer) uint64(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.tag(field, wireVarint)
+	b.varint(v)
+}
+
+func (b *protoBuffer) string(field int, v string) {
+	if v == "" {
+		return
+	}
+	b.tag(field, wireBytes)
+	b.bytes([]byte(v))
+}
+
+// sint64s encodes a packed repeated sint64 field.
+func (b *protoBuffer) sint64s(field int, values []int64) {
+	if len(values) == 0 {
+		return
+	}
+	var packed protoBuffer
+	for _, v := range values {
+		packed.varint(uint64(v<<1) ^ uint64(v>>63))
+	}
+	b.tag(field, wireBytes)
+	b.bytes(packed)
+}
+
+// message encodes an embedded message, written by encode.
+func (b *protoBuffer) message(field int, encode func(b *protoBuffer)) {
+	var embedded protoBuffer
+	encode(&embedded)
+	b.tag(field, wireBytes)
+	b.bytes(embedded)
+}
@@ -0,0 +1,474 @@
+// Package usagereporting reports the usage of a schema to Apollo Studio: the latency and errors of
+// its operations and fields and the fields they reference, aggregated by operation and client, in
+// the protobuf format of Apollo's usage reporting, so the schema shows up in Studio insights.
+//
+// A Reporter is an extension of the schema, which reports every Interval once started:
+//
+//	reporter := usagereporting.New(usagereporting.Config{APIKey: key, GraphRef: "graph@current"})
+//	schema, _ := graphql.NewSchema(graphql.SchemaConfig{
+//		Query:      queryType,
+//		Extensions: []graphql.Extension{reporter},
+//	})
+//	reporter.Start()
+//	defer reporter.Stop()
+//
+// The clients are told by the graphql.RequestInfo of the requests, which the handler reads from
+// the apollographql-client-name and apollographql-client-version headers.
+package usagereporting
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/gqlerrors"
+	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/parser"
+	"github.com/fiatjaf/graphql/language/source"
+	"github.com/fiatjaf/graphql/language/visitor"
+)
+
+// DefaultEndpoint is where the reports are sent by default.
+const DefaultEndpoint = "https://usage-reporting.api.apollographql.com/api/ingress/traces"
+
+// DefaultInterval is the default interval between two reports.
+const DefaultInterval = 20 * time.Second
+
+// agentVersion identifies this package in the reports.
+const agentVersion = "github.com/fiatjaf/graphql/usagereporting"
+
+// maxSignatures is the number of operation signatures kept between two reports.
+const maxSignatures = 1000
+
+// The keys under which the requests failing before their execution are reported.
+const (
+	parseFailureKey      = "## GraphQLParseFailure\n"
+	validationFailureKey = "## GraphQLValidationFailure\n"
+)
+
+// Config configures a Reporter.
+type Config struct {
+	// APIKey is the Apollo API key of the graph, sent with the reports.
+	APIKey string
+
+	// GraphRef is the graph and variant the usage is reported for, like "my-graph@current".
+	GraphRef string
+
+	// Endpoint is the URL the reports are sent to, DefaultEndpoint if empty.
+	Endpoint string
+
+	// Interval is the interval between two reports once the Reporter is started, DefaultInterval
+	// if zero.
+	Interval time.Duration
+
+	// HTTPClient sends the reports, http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	// ServiceVersion is the version of the service reported in the reports' header.
+	ServiceVersion string
+
+	// SchemaID identifies the schema in the reports' header, e.g. the hash of its SDL.
+	SchemaID string
+
+	// ErrorFn, when set, is called with the errors of the reports sent in the background.
+	ErrorFn func(err error)
+}
+
+// Reporter is a graphql.Extension collecting the usage of the schemas it is an extension of and
+// reporting it to Apollo.
+type Reporter struct {
+	config Config
+
+	mutex      sync.Mutex
+	stats      map[string]*operationStats
+	operations uint64
+	signatures map[signatureKey]*signature
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// New returns a Reporter reporting with config.
+func New(config Config) *Reporter {
+	if config.Endpoint == "" {
+		config.Endpoint = DefaultEndpoint
+	}
+	if config.Interval == 0 {
+		config.Interval = DefaultInterval
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &Reporter{
+		config:     config,
+		stats:      map[string]*operationStats{},
+		signatures: map[signatureKey]*signature{},
+	}
+}
+
+// Start sends the usage every Interval in the background, until Stop is called.
+func (r *Reporter) Start() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.stop != nil {
+		return
+	}
+	r.stop = make(chan struct{})
+	r.stopped = make(chan struct{})
+	go func(stop, stopped chan struct{}) {
+		defer close(stopped)
+		ticker := time.NewTicker(r.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.reportInBackground()
+			case <-stop:
+				return
+			}
+		}
+	}(r.stop, r.stopped)
+}
+
+// Stop stops reporting in the background and sends the usage collected since the last report.
+func (r *Reporter) Stop() {
+	r.mutex.Lock()
+	stop, stopped := r.stop, r.stopped
+	r.stop, r.stopped = nil, nil
+	r.mutex.Unlock()
+	if stop != nil {
+		close(stop)
+		<-stopped
+	}
+	r.reportInBackground()
+}
+
+func (r *Reporter) reportInBackground() {
+	if err := r.Flush(context.Background()); err != nil && r.config.ErrorFn != nil {
+		r.config.ErrorFn(err)
+	}
+}
+
+// Flush sends the usage collected since the last report, if any.
+func (r *Reporter) Flush(ctx context.Context) error {
+	r.mutex.Lock()
+	stats, operations := r.stats, r.operations
+	r.stats, r.operations = map[string]*operationStats{}, 0
+	r.signatures = map[signatureKey]*signature{}
+	r.mutex.Unlock()
+	if operations == 0 {
+		return nil
+	}
+
+	hostname, _ := os.Hostname()
+	report := encodeReport(reportHeader{
+		graphRef:       r.config.GraphRef,
+		hostname:       hostname,
+		agentVersion:   agentVersion,
+		serviceVersion: r.config.ServiceVersion,
+		runtimeVersion: runtime.Version(),
+		uname:          runtime.GOOS + ", " + runtime.GOARCH,
+		schemaID:       r.config.SchemaID,
+	}, stats, operations, time.Now())
+
+	var body bytes.Buffer
+	compressor := gzip.NewWriter(&body)
+	compressor.Write(report)
+	compressor.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.config.Endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/protobuf")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", agentVersion)
+	req.Header.Set("X-Api-Key", r.config.APIKey)
+	resp, err := r.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("usage report rejected with status %v", resp.Status)
+	}
+	return nil
+}
+
+// operationStats is the usage of an operation, by client.
+type operationStats struct {
+	referencedFields map[string]*referencedFields
+	contexts         map[statsContext]*contextStats
+}
+
+// referencedFields are the fields of a type referenced by an operation.
+type referencedFields struct {
+	fieldNames  []string
+	isInterface bool
+}
+
+type statsContext struct {
+	clientName    string
+	clientVersion string
+}
+
+// contextStats is the usage of an operation by a client.
+type contextStats struct {
+	latency            histogram
+	requestCount       uint64
+	requestsWithErrors uint64
+	fields             map[string]map[string]*fieldStats // by parent type and field name
+}
+
+type fieldStats struct {
+	returnType         string
+	errorsCount        uint64
+	observedCount      uint64
+	requestsWithErrors uint64
+	latency            histogram
+}
+
+type signatureKey struct {
+	query         string
+	operationName string
+}
+
+// signature is the key under which an operation is reported and the fields it references.
+type signature struct {
+	key              string
+	referencedFields map[string]*referencedFields
+}
+
+// signature returns the signature of the operation of a query.
+func (r *Reporter) signature(schema *graphql.Schema, query, operationName string) *signature {
+	key := signatureKey{query, operationName}
+	r.mutex.Lock()
+	cached, ok := r.signatures[key]
+	r.mutex.Unlock()
+	if ok {
+		return cached
+	}
+
+	name := operationName
+	if name == "" {
+		name = "-"
+	}
+	computed := &signature{key: "# " + name + "\n", referencedFields: map[string]*referencedFields{}}
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(query)})})
+	if err == nil {
+		if operation, ok := graphql.SeparateOperations(document)[operationName]; ok {
+			computed.key += strings.TrimSpace(graphql.NormalizeQuery(operation))
+			computed.referencedFields = referenceFields(schema, operation)
+		}
+	}
+
+	r.mutex.Lock()
+	if len(r.signatures) >= maxSignatures {
+		r.signatures = map[signatureKey]*signature{}
+	}
+	r.signatures[key] = computed
+	r.mutex.Unlock()
+	return computed
+}
+
+// referenceFields returns the fields referenced by an operation, by parent type.
+func referenceFields(schema *graphql.Schema, document *ast.Document) map[string]*referencedFields {
+	referenced := map[string]*referencedFields{}
+	seen := map[[2]string]bool{}
+	typeInfo := graphql.NewTypeInfo(&graphql.TypeInfoConfig{Schema: schema})
+	visitor.Visit(document, visitor.VisitWithTypeInfo(typeInfo, &visitor.VisitorOptions{
+		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+			"Field": {
+				Kind: func(p visitor.VisitFuncParams) (string, interface{}) {
+					field, ok := p.Node.(*ast.Field)
+					parentType := typeInfo.ParentType()
+					if !ok || field.Name == nil || parentType == nil {
+						return visitor.ActionNoChange, nil
+					}
+					typeName, fieldName := parentType.Name(), field.Name.Value
+					if seen[[2]string{typeName, fieldName}] {
+						return visitor.ActionNoChange, nil
+					}
+					seen[[2]string{typeName, fieldName}] = true
+					fields, ok := referenced[typeName]
+					if !ok {
+						_, isInterface := parentType.(*graphql.Interface)
+						fields = &referencedFields{isInterface: isInterface}
+						referenced[typeName] = fields
+					}
+					fields.fieldNames = append(fields.fieldNames, fieldName)
+					return visitor.ActionNoChange, nil
+				},
+			},
+		},
+	}), nil)
+	return referenced
+}
+
+type requestKey struct{}
+
+// request collects the usage of a request until it is recorded.
+type request struct {
+	mutex  sync.Mutex
+	start  time.Time
+	schema graphql.Schema
+	query  string
+	fields []fieldObservation
+}
+
+// fieldObservation is the resolution of a field.
+type fieldObservation struct {
+	parentType string
+	fieldName  string
+	returnType string
+	duration   time.Duration
+	failed     bool
+}
+
+// record adds a request to the stats of its operation.
+func (r *Reporter) record(ctx context.Context, req *request, signature *signature, hasErrors bool) {
+	duration := time.Since(req.start)
+	client := statsContext{}
+	if info := graphql.RequestInfoFromContext(ctx); info != nil {
+		client = statsContext{info.ClientName, info.ClientVersion}
+	}
+
+	req.mutex.Lock()
+	fields := req.fields
+	req.fields = nil
+	req.mutex.Unlock()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.operations++
+	stats, ok := r.stats[signature.key]
+	if !ok {
+		stats = &operationStats{
+			referencedFields: signature.referencedFields,
+			contexts:         map[statsContext]*contextStats{},
+		}
+		r.stats[signature.key] = stats
+	}
+	clientStats, ok := stats.contexts[client]
+	if !ok {
+		clientStats = &contextStats{fields: map[string]map[string]*fieldStats{}}
+		stats.contexts[client] = clientStats
+	}
+	clientStats.requestCount++
+	if hasErrors {
+		clientStats.requestsWithErrors++
+	}
+	clientStats.latency.add(duration)
+
+	failedFields := map[*fieldStats]bool{}
+	for _, observation := range fields {
+		typeFields, ok := clientStats.fields[observation.parentType]
+		if !ok {
+			typeFields = map[string]*fieldStats{}
+			clientStats.fields[observation.parentType] = typeFields
+		}
+		field, ok := typeFields[observation.fieldName]
+		if !ok {
+			field = &fieldStats{returnType: observation.returnType}
+			typeFields[observation.fieldName] = field
+		}
+		field.observedCount++
+		field.latency.add(observation.duration)
+		if observation.failed {
+			field.errorsCount++
+			if !failedFields[field] {
+				failedFields[field] = true
+				field.requestsWithErrors++
+			}
+		}
+	}
+}
+
+// Name implements graphql.Extension.
+func (r *Reporter) Name() string {
+	return "UsageReporting"
+}
+
+// Init implements graphql.Extension.
+func (r *Reporter) Init(ctx context.Context, p *graphql.Params) context.Context {
+	return context.WithValue(ctx, requestKey{}, &request{
+		start:  time.Now(),
+		schema: p.Schema,
+		query:  p.RequestString,
+	})
+}
+
+// ParseDidStart implements graphql.Extension.
+func (r *Reporter) ParseDidStart(ctx context.Context) (context.Context, graphql.ParseFinishFunc) {
+	return ctx, func(err error) {
+		if req, ok := ctx.Value(requestKey{}).(*request); ok && err != nil {
+			r.record(ctx, req, &signature{key: parseFailureKey}, true)
+		}
+	}
+}
+
+// ValidationDidStart implements graphql.Extension.
+func (r *Reporter) ValidationDidStart(ctx context.Context) (context.Context, graphql.ValidationFinishFunc) {
+	return ctx, func(errs []gqlerrors.FormattedError) {
+		if req, ok := ctx.Value(requestKey{}).(*request); ok && len(errs) > 0 {
+			r.record(ctx, req, &signature{key: validationFailureKey}, true)
+		}
+	}
+}
+
+// ExecutionDidStart implements graphql.Extension.
+func (r *Reporter) ExecutionDidStart(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+	return ctx, func(result *graphql.Result) {
+		req, ok := ctx.Value(requestKey{}).(*request)
+		if !ok {
+			return
+		}
+		operationName := ""
+		if info := graphql.RequestInfoFromContext(ctx); info != nil {
+			operationName = info.OperationName
+		}
+		r.record(ctx, req, r.signature(&req.schema, req.query, operationName), result.HasErrors())
+	}
+}
+
+// ResolveFieldDidStart implements graphql.Extension.
+func (r *Reporter) ResolveFieldDidStart(ctx context.Context, info *graphql.ResolveInfo) (context.Context, graphql.ResolveFieldFinishFunc) {
+	req, ok := ctx.Value(requestKey{}).(*request)
+	if !ok {
+		return ctx, func(interface{}, error) {}
+	}
+	start := time.Now()
+	observation := fieldObservation{
+		fieldName:  info.FieldName,
+		returnType: info.ReturnType.String(),
+	}
+	if info.ParentType != nil {
+		observation.parentType = info.ParentType.Name()
+	}
+	return ctx, func(_ interface{}, err error) {
+		observation.duration = time.Since(start)
+		observation.failed = err != nil
+		req.mutex.Lock()
+		req.fields = append(req.fields, observation)
+		req.mutex.Unlock()
+	}
+}
+
+// HasResult implements graphql.Extension.
+func (r *Reporter) HasResult() bool {
+	return false
+}
+
+// GetResult implements graphql.Extension.
+func (r *Reporter) GetResult(context.Context) interface{} {
+	return nil
+}
@@ -0,0 +1,189 @@
+package usagereporting_test
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+	"github.com/fiatjaf/graphql/usagereporting"
+)
+
+// protoMessage holds the fields of a decoded protobuf message: the varints and the
+// length-delimited values, by field number.
+type protoMessage struct {
+	varints map[int][]uint64
+	bytes   map[int][][]byte
+}
+
+func decodeProto(t *testing.T, b []byte) protoMessage {
+	message := protoMessage{varints: map[int][]uint64{}, bytes: map[int][][]byte{}}
+	varint := func() uint64 {
+		var v uint64
+		for shift := 0; ; shift += 7 {
+			if len(b) == 0 {
+				t.Fatalf("truncated message")
+			}
+			c := b[0]
+			b = b[1:]
+			v |= uint64(c&0x7f) << shift
+			if c < 0x80 {
+				return v
+			}
+		}
+	}
+	for len(b) > 0 {
+		tag := varint()
+		field := int(tag >> 3)
+		switch tag & 7 {
+		case 0:
+			message.varints[field] = append(message.varints[field], varint())
+		case 2:
+			n := varint()
+			message.bytes[field] = append(message.bytes[field], b[:n])
+			b = b[n:]
+		default:
+			t.Fatalf("unexpected wire type %v", tag&7)
+		}
+	}
+	return message
+}
+
+// entries decodes the entries of a map field with message values.
+func (m protoMessage) entries(t *testing.T, field int) map[string]protoMessage {
+	entries := map[string]protoMessage{}
+	for _, b := range m.bytes[field] {
+		entry := decodeProto(t, b)
+		entries[string(entry.bytes[1][0])] = decodeProto(t, entry.bytes[2][0])
+	}
+	return entries
+}
+
+func (m protoMessage) message(t *testing.T, field int) protoMessage {
+	return decodeProto(t, m.bytes[field][0])
+}
+
+func (m protoMessage) strings(field int) []string {
+	strings := []string{}
+	for _, b := range m.bytes[field] {
+		strings = append(strings, string(b))
+	}
+	return strings
+}
+
+func TestReporter(t *testing.T) {
+	var report []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, _ = ioutil.ReadAll(body)
+	}))
+	defer server.Close()
+
+	reporter := usagereporting.New(usagereporting.Config{
+		APIKey:   "service:key",
+		GraphRef: "graph@current",
+		Endpoint: server.URL,
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"hello": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return "world", nil
+					},
+				},
+				"fail": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return nil, errors.New("failed")
+					},
+				},
+			},
+		}),
+		Extensions: []graphql.Extension{reporter},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := graphql.WithRequestInfo(context.Background(), &graphql.RequestInfo{ClientName: "web", ClientVersion: "1.0"})
+	for _, query := range []string{`query Hello { hello }`, `query Hello { hello  }`, `{ hello fail }`, `{ hello`, `{ unknown }`} {
+		graphql.Do(graphql.Params{Schema: schema, RequestString: query, Context: ctx})
+	}
+	if err := reporter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("X-Api-Key") != "service:key" || header.Get("Content-Type") != "application/protobuf" {
+		t.Fatalf("unexpected headers %v", header)
+	}
+
+	decoded := decodeProto(t, report)
+	if !reflect.DeepEqual(decoded.varints[6], []uint64{5}) {
+		t.Fatalf("expected an operation count of 5, got %v", decoded.varints[6])
+	}
+	if graphRef := decoded.message(t, 1).strings(12); !reflect.DeepEqual(graphRef, []string{"graph@current"}) {
+		t.Fatalf("expected the graph ref in the header, got %v", graphRef)
+	}
+
+	operations := decoded.entries(t, 5)
+	keys := []string{}
+	for key := range operations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	expectedKeys := []string{
+		"# -\n{\n  hello\n  fail\n}",
+		"# Hello\nquery Hello {\n  hello\n}",
+		"## GraphQLParseFailure\n",
+		"## GraphQLValidationFailure\n",
+	}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Fatalf("expected the operations %q, got %q", expectedKeys, keys)
+	}
+
+	// the two requests of Hello are aggregated, with their client
+	stats := decodeProto(t, operations["# Hello\nquery Hello {\n  hello\n}"].bytes[2][0])
+	if client := stats.message(t, 1); !reflect.DeepEqual(client.strings(2), []string{"web"}) || !reflect.DeepEqual(client.strings(3), []string{"1.0"}) {
+		t.Fatalf("expected the client web 1.0, got %v", client)
+	}
+	if requests := stats.message(t, 2).varints[2]; !reflect.DeepEqual(requests, []uint64{2}) {
+		t.Fatalf("expected 2 requests, got %v", requests)
+	}
+	hello := stats.entries(t, 3)["Query"].entries(t, 3)["hello"]
+	if !reflect.DeepEqual(hello.strings(3), []string{"String"}) || !reflect.DeepEqual(hello.varints[5], []uint64{2}) {
+		t.Fatalf("expected the field hello of type String resolved twice, got %v", hello)
+	}
+	referenced := operations["# Hello\nquery Hello {\n  hello\n}"].entries(t, 4)["Query"]
+	if !reflect.DeepEqual(referenced.strings(1), []string{"hello"}) {
+		t.Fatalf("expected Query.hello to be referenced, got %v", referenced.strings(1))
+	}
+
+	// the errors of the requests and fields are counted
+	stats = decodeProto(t, operations["# -\n{\n  hello\n  fail\n}"].bytes[2][0])
+	if errors := stats.message(t, 2).varints[8]; !reflect.DeepEqual(errors, []uint64{1}) {
+		t.Fatalf("expected 1 request with errors, got %v", errors)
+	}
+	fail := stats.entries(t, 3)["Query"].entries(t, 3)["fail"]
+	if !reflect.DeepEqual(fail.varints[4], []uint64{1}) || !reflect.DeepEqual(fail.varints[6], []uint64{1}) {
+		t.Fatalf("expected the field fail to have failed once, got %v", fail)
+	}
+
+	// nothing is sent when there is no usage
+	report = nil
+	if err := reporter.Flush(context.Background()); err != nil || report != nil {
+		t.Fatalf("expected no report, got %v and %v", err, report)
+	}
+}
@@ -10,6 +10,29 @@ import (
 type ValidationResult struct {
 	IsValid bool
 	Errors  []gqlerrors.FormattedError
+	// Warnings are reported by rules with SeverityWarning, they don't make the document invalid.
+	Warnings []gqlerrors.FormattedError
+}
+
+// Severity tells what happens to what a validation rule reports.
+type Severity int
+
+const (
+	// SeverityError rules report errors, that make the document invalid.
+	SeverityError Severity = iota
+	// SeverityWarning rules report warnings, that are returned under extensions.warnings by Do
+	// without failing the request.
+	SeverityWarning
+)
+
+// WithSeverity returns rule reporting with the given severity, e.g. to only warn about the usage
+// of deprecated fields with WithSeverity(SeverityWarning, NoDeprecatedCustomRule).
+func WithSeverity(severity Severity, rule ValidationRuleFn) ValidationRuleFn {
+	return func(context *ValidationContext) *ValidationRuleInstance {
+		ruleContext := *context
+		ruleContext.severity = severity
+		return rule(&ruleContext)
+	}
 }
 
 /**
@@ -27,6 +50,12 @@ type ValidationResult struct {
  */
 
 func ValidateDocument(schema *Schema, astDoc *ast.Document, rules []ValidationRuleFn) (vr ValidationResult) {
+	return validateDocument(schema, astDoc, rules, nil)
+}
+
+// validateDocument is ValidateDocument for a request the fields and types of the schema aren't all
+// visible to, see VisibilityFn.
+func validateDocument(schema *Schema, astDoc *ast.Document, rules []ValidationRuleFn, visibility *visibility) (vr ValidationResult) {
 	if len(rules) == 0 {
 		rules = SpecifiedRules
 	}
@@ -41,9 +70,12 @@ func ValidateDocument(schema *Schema, astDoc *ast.Document, rules []ValidationRu
 	}
 
 	typeInfo := NewTypeInfo(&TypeInfoConfig{
-		Schema: schema,
+		Schema:     schema,
+		FieldDefFn: visibility.fieldDefFn(),
 	})
-	vr.Errors = VisitUsingRules(schema, typeInfo, astDoc, rules)
+	context := visitUsingRules(schema, typeInfo, astDoc, rules, visibility)
+	vr.Errors = context.Errors()
+	vr.Warnings = context.Warnings()
 	if len(vr.Errors) == 0 {
 		vr.IsValid = true
 	}
@@ -51,18 +83,20 @@ func ValidateDocument(schema *Schema, astDoc *ast.Document, rules []ValidationRu
 }
 
 // VisitUsingRules This uses a specialized visitor which runs multiple visitors in parallel,
-// while maintaining the visitor skip and break API.
-//
-// @internal
-// Had to expose it to unit test experimental customizable validation feature,
-// but not meant for public consumption
+// while maintaining the visitor skip and break API. typeInfo is kept up to date during the walk
+// and is the one returned by ValidationContext.TypeInfo.
 func VisitUsingRules(
 	schema *Schema,
 	typeInfo *TypeInfo,
 	astDoc *ast.Document,
 	rules []ValidationRuleFn,
 ) []gqlerrors.FormattedError {
+	return visitUsingRules(schema, typeInfo, astDoc, rules, nil).Errors()
+}
+
+func visitUsingRules(schema *Schema, typeInfo *TypeInfo, astDoc *ast.Document, rules []ValidationRuleFn, visibility *visibility) *ValidationContext {
 	context := NewValidationContext(schema, astDoc, typeInfo)
+	context.visibility = visibility
 	visitors := []*visitor.VisitorOptions{}
 
 	for _, rule := range rules {
@@ -72,7 +106,7 @@ func VisitUsingRules(
 
 	// Visit the whole document with each instance of all provided rules.
 	visitor.Visit(astDoc, visitor.VisitWithTypeInfo(typeInfo, visitor.VisitInParallel(visitors...)), nil)
-	return context.Errors()
+	return context
 }
 
 type HasSelectionSet interface {
@@ -86,21 +120,43 @@ var (
 	_ HasSelectionSet = (*ast.FragmentDefinition)(nil)
 )
 
+// Rule is implemented by custom validation rules, like rules that reject the usage of deprecated
+// fields or that require some field on every selection. Visitor is called once per validated
+// document and returns the visitor walking it, which reports errors on context. Use
+// NewValidationRule to pass a Rule where a ValidationRuleFn is expected.
+type Rule interface {
+	Visitor(context *ValidationContext) *visitor.VisitorOptions
+}
+
+// NewValidationRule returns the ValidationRuleFn running rule.
+func NewValidationRule(rule Rule) ValidationRuleFn {
+	return func(context *ValidationContext) *ValidationRuleInstance {
+		return &ValidationRuleInstance{
+			VisitorOpts: rule.Visitor(context),
+		}
+	}
+}
+
 type VariableUsage struct {
 	Node *ast.Variable
 	Type Input
 }
 
+// ValidationContext is shared by the rules validating a document: it gives them access to the
+// schema, the document and the TypeInfo tracking the current position in the document, and
+// collects the errors they report.
 type ValidationContext struct {
 	schema                         *Schema
 	astDoc                         *ast.Document
 	typeInfo                       *TypeInfo
-	errors                         []gqlerrors.FormattedError
+	severity                       Severity
+	reported                       *validationReports
 	fragments                      map[string]*ast.FragmentDefinition
 	variableUsages                 map[HasSelectionSet][]*VariableUsage
 	recursiveVariableUsages        map[*ast.OperationDefinition][]*VariableUsage
 	recursivelyReferencedFragments map[*ast.OperationDefinition][]*ast.FragmentDefinition
 	fragmentSpreads                map[*ast.SelectionSet][]*ast.FragmentSpread
+	visibility                     *visibility
 }
 
 func NewValidationContext(schema *Schema, astDoc *ast.Document, typeInfo *TypeInfo) *ValidationContext {
@@ -108,6 +164,7 @@ func NewValidationContext(schema *Schema, astDoc *ast.Document, typeInfo *TypeIn
 		schema:                         schema,
 		astDoc:                         astDoc,
 		typeInfo:                       typeInfo,
+		reported:                       &validationReports{},
 		fragments:                      map[string]*ast.FragmentDefinition{},
 		variableUsages:                 map[HasSelectionSet][]*VariableUsage{},
 		recursiveVariableUsages:        map[*ast.OperationDefinition][]*VariableUsage{},
@@ -116,13 +173,42 @@ func NewValidationContext(schema *Schema, astDoc *ast.Document, typeInfo *TypeIn
 	}
 }
 
+// validationReports are shared by the contexts given to rules with different severities.
+type validationReports struct {
+	errors   []gqlerrors.FormattedError
+	warnings []gqlerrors.FormattedError
+}
+
+// ReportError adds err to the validation errors, or to the warnings when the rule reporting it
+// has SeverityWarning.
 func (ctx *ValidationContext) ReportError(err error) {
+	if ctx.severity == SeverityWarning {
+		ctx.ReportWarning(err)
+		return
+	}
+	formattedErr := gqlerrors.FormatError(err)
+	ctx.reported.errors = append(ctx.reported.errors, formattedErr)
+}
+
+// ReportWarning adds err to the validation warnings, whatever the severity of the rule.
+func (ctx *ValidationContext) ReportWarning(err error) {
 	formattedErr := gqlerrors.FormatError(err)
-	ctx.errors = append(ctx.errors, formattedErr)
+	ctx.reported.warnings = append(ctx.reported.warnings, formattedErr)
+}
+
+// ReportNodeError reports a validation error with message, located at nodes.
+func (ctx *ValidationContext) ReportNodeError(message string, nodes ...ast.Node) {
+	ctx.ReportError(newValidationError(message, nodes))
 }
 
+// Errors returns the errors reported so far.
 func (ctx *ValidationContext) Errors() []gqlerrors.FormattedError {
-	return ctx.errors
+	return ctx.reported.errors
+}
+
+// Warnings returns the warnings reported so far.
+func (ctx *ValidationContext) Warnings() []gqlerrors.FormattedError {
+	return ctx.reported.warnings
 }
 
 func (ctx *ValidationContext) Schema() *Schema {
@@ -133,6 +219,12 @@ func (ctx *ValidationContext) Document() *ast.Document {
 	return ctx.astDoc
 }
 
+// TypeInfo returns the TypeInfo following the visit of the document.
+func (ctx *ValidationContext) TypeInfo() *TypeInfo {
+	return ctx.typeInfo
+}
+
+// Fragment returns the fragment of the document with the given name, or nil.
 func (ctx *ValidationContext) Fragment(name string) *ast.FragmentDefinition {
 	if len(ctx.fragments) == 0 {
 		if ctx.Document() == nil {
@@ -155,6 +247,8 @@ func (ctx *ValidationContext) Fragment(name string) *ast.FragmentDefinition {
 	return f
 }
 
+// FragmentSpreads returns the fragment spreads of node and of its nested selection sets, without
+// following the spread fragments.
 func (ctx *ValidationContext) FragmentSpreads(node *ast.SelectionSet) []*ast.FragmentSpread {
 	if spreads, ok := ctx.fragmentSpreads[node]; ok && spreads != nil {
 		return spreads
@@ -306,3 +400,11 @@ func (ctx *ValidationContext) Directive() *Directive {
 func (ctx *ValidationContext) Argument() *Argument {
 	return ctx.typeInfo.Argument()
 }
+
+func (ctx *ValidationContext) ParentInputType() Input {
+	return ctx.typeInfo.ParentInputType()
+}
+
+func (ctx *ValidationContext) EnumValue() *EnumValueDefinition {
+	return ctx.typeInfo.EnumValue()
+}
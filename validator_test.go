@@ -1,14 +1,17 @@
 package graphql_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/fiatjaf/graphql"
 	"github.com/fiatjaf/graphql/gqlerrors"
 	"github.com/fiatjaf/graphql/language/ast"
+	"github.com/fiatjaf/graphql/language/kinds"
 	"github.com/fiatjaf/graphql/language/location"
 	"github.com/fiatjaf/graphql/language/parser"
 	"github.com/fiatjaf/graphql/language/source"
+	"github.com/fiatjaf/graphql/language/visitor"
 	"github.com/fiatjaf/graphql/testutil"
 )
 
@@ -92,3 +95,60 @@ func TestValidator_SupportsFullValidation_ValidatesUsingACustomTypeInfo(t *testi
 		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedErrors, errors))
 	}
 }
+
+type noDeprecatedFieldsRule struct {
+	allowed map[string]bool
+}
+
+func (rule noDeprecatedFieldsRule) Visitor(context *graphql.ValidationContext) *visitor.VisitorOptions {
+	return &visitor.VisitorOptions{
+		KindFuncMap: map[string]visitor.NamedVisitFuncs{
+			kinds.Field: {
+				Kind: func(p visitor.VisitFuncParams) (string, interface{}) {
+					fieldDef := context.TypeInfo().FieldDef()
+					if fieldDef != nil && fieldDef.DeprecationReason != "" && !rule.allowed[fieldDef.Name] {
+						context.ReportNodeError(
+							fmt.Sprintf(`Field "%v.%v" is deprecated: %v`, context.ParentType().Name(), fieldDef.Name, fieldDef.DeprecationReason),
+							p.Node.(ast.Node),
+						)
+					}
+					return visitor.ActionNoChange, nil
+				},
+			},
+		},
+	}
+}
+
+func TestValidator_SupportsCustomRules(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"name":     &graphql.Field{Type: graphql.String},
+				"oldName":  &graphql.Field{Type: graphql.String, DeprecationReason: "Use name."},
+				"nickname": &graphql.Field{Type: graphql.String, DeprecationReason: "Unused."},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: "{\n  name\n  oldName\n  nickname\n}",
+		ValidationRules: []graphql.ValidationRuleFn{
+			graphql.NewValidationRule(noDeprecatedFieldsRule{allowed: map[string]bool{"nickname": true}}),
+		},
+	})
+	expectedErrors := []gqlerrors.FormattedError{
+		{
+			Message: `Field "Query.oldName" is deprecated: Use name.`,
+			Locations: []location.SourceLocation{
+				{Line: 3, Column: 3},
+			},
+		},
+	}
+	if !testutil.EqualFormattedErrors(expectedErrors, result.Errors) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expectedErrors, result.Errors))
+	}
+}
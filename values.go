@@ -1,6 +1,7 @@
 package graphql
 
 import (
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -16,29 +17,60 @@ import (
 
 // Prepares an object map of variableValues of the correct type based on the
 // provided variable definitions and arbitrary input. If the input cannot be
-// parsed to match the variable definitions, a GraphQLError will be returned.
+// parsed to match the variable definitions, a variableCoercionErrors holding
+// one GraphQLError per invalid value will be returned.
 func getVariableValues(
 	schema Schema,
 	definitionASTs []*ast.VariableDefinition,
 	inputs map[string]interface{},
 ) (map[string]interface{}, error) {
 	values := map[string]interface{}{}
+	errs := variableCoercionErrors{}
 	for _, defAST := range definitionASTs {
 		if defAST == nil || defAST.Variable == nil || defAST.Variable.Name == nil {
 			continue
 		}
 		varName := defAST.Variable.Name.Value
-		if varValue, err := getVariableValue(schema, defAST, inputs[varName]); err != nil {
-			return values, err
-		} else {
+		input, provided := inputs[varName]
+		if varValue, varErrs := getVariableValue(schema, defAST, input, provided); len(varErrs) != 0 {
+			errs = append(errs, varErrs...)
+		} else if provided || defAST.DefaultValue != nil {
+			// variables that were omitted and have no default are left out, so that the
+			// arguments using them can tell them apart from an explicit null
 			values[varName] = varValue
 		}
 	}
+	if len(errs) != 0 {
+		return values, errs
+	}
 	return values, nil
 }
 
+// variableCoercionErrors holds all the errors found while coercing the variable values of a request.
+type variableCoercionErrors []error
+
+func (errs variableCoercionErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// formatVariableErrors formats err, expanding it into one formatted error per invalid value if
+// it comes from the coercion of variables.
+func formatVariableErrors(err error) []gqlerrors.FormattedError {
+	if errs, ok := err.(variableCoercionErrors); ok {
+		return gqlerrors.FormatErrors(errs...)
+	}
+	return gqlerrors.FormatErrors(err)
+}
+
 // Prepares an object map of argument values given a list of argument
 // definitions and list of argument AST nodes.
+// Arguments that were not provided, or that were given a variable that was not
+// provided, get their default value if they have one and are otherwise left out.
+// Arguments explicitly set to null are present in the map with a nil value.
 func getArgumentValues(
 	argDefs []*Argument, argASTs []*ast.Argument,
 	variableValues map[string]interface{},
@@ -51,14 +83,24 @@ func getArgumentValues(
 	}
 	results := map[string]interface{}{}
 	for _, argDef := range argDefs {
-		var (
-			tmp   interface{}
-			value ast.Value
-		)
-		if tmpValue, ok := argASTMap[argDef.PrivateName]; ok {
-			value = tmpValue.Value
+		var value ast.Value
+		if argAST, ok := argASTMap[argDef.PrivateName]; ok {
+			value = argAST.Value
+		}
+		if !isValueProvided(value, variableValues) {
+			if !isNullish(argDef.DefaultValue) {
+				results[argDef.PrivateName] = argDef.DefaultValue
+			}
+			continue
+		}
+
+		tmp := valueFromAST(value, argDef.Type, variableValues)
+		switch value.(type) {
+		case *ast.Variable, *ast.NullValue:
+			results[argDef.PrivateName] = tmp
+			continue
 		}
-		if tmp = valueFromAST(value, argDef.Type, variableValues); isNullish(tmp) {
+		if isNullish(tmp) {
 			tmp = argDef.DefaultValue
 		}
 		if !isNullish(tmp) {
@@ -68,17 +110,35 @@ func getArgumentValues(
 	return results
 }
 
+// isValueProvided tells if an argument or input field was given a value: it is
+// missing if there is no value AST or if it is a variable that wasn't provided.
+func isValueProvided(valueAST ast.Value, variables map[string]interface{}) bool {
+	if valueAST == nil {
+		return false
+	}
+	if variable, ok := valueAST.(*ast.Variable); ok {
+		if variable.Name == nil {
+			return false
+		}
+		_, ok := variables[variable.Name.Value]
+		return ok
+	}
+	return true
+}
+
 // Given a variable definition, and any value of input, return a value which
-// adheres to the variable definition, or throw an error.
-func getVariableValue(schema Schema, definitionAST *ast.VariableDefinition, input interface{}) (interface{}, error) {
+// adheres to the variable definition, or the errors found in the input.
+// provided tells if the input was present in the request at all: the default
+// value of the variable only applies if it wasn't, an explicit null is kept.
+func getVariableValue(schema Schema, definitionAST *ast.VariableDefinition, input interface{}, provided bool) (interface{}, []error) {
 	ttype, err := typeFromAST(schema, definitionAST.Type)
 	if err != nil {
-		return nil, err
+		return nil, []error{err}
 	}
 	variable := definitionAST.Variable
 
 	if ttype == nil || !IsInputType(ttype) {
-		return "", gqlerrors.NewError(
+		return "", []error{gqlerrors.NewError(
 			fmt.Sprintf(`Variable "$%v" expected value of type `+
 				`"%v" which cannot be used as an input type.`, variable.Name.Value, printer.Print(definitionAST.Type)),
 			[]ast.Node{definitionAST},
@@ -86,52 +146,51 @@ func getVariableValue(schema Schema, definitionAST *ast.VariableDefinition, inpu
 			nil,
 			[]int{},
 			nil,
-		)
+		)}
 	}
 
-	isValid, messages := isValidInputValue(input, ttype)
-	if isValid {
-		if isNullish(input) {
-			if definitionAST.DefaultValue != nil {
-				return valueFromAST(definitionAST.DefaultValue, ttype, nil), nil
-			}
-		}
+	if !provided && definitionAST.DefaultValue != nil {
+		return valueFromAST(definitionAST.DefaultValue, ttype, nil), nil
+	}
+	if _, ok := ttype.(*NonNull); ok && isNullish(input) {
+		message := fmt.Sprintf(`Variable "$%v" of required type "%v" was not provided.`,
+			variable.Name.Value, printer.Print(definitionAST.Type))
+		if provided {
+			message = fmt.Sprintf(`Variable "$%v" of non-null type "%v" must not be null.`,
+				variable.Name.Value, printer.Print(definitionAST.Type))
+		}
+		return "", []error{gqlerrors.NewError(
+			message,
+			[]ast.Node{definitionAST},
+			"",
+			nil,
+			[]int{},
+			nil,
+		)}
+	}
+
+	messages := inputValueErrors(input, ttype, "$"+variable.Name.Value)
+	if len(messages) == 0 {
 		return coerceValue(ttype, input), nil
 	}
-	if isNullish(input) {
-		return "", gqlerrors.NewError(
-			fmt.Sprintf(`Variable "$%v" of required type `+
-				`"%v" was not provided.`, variable.Name.Value, printer.Print(definitionAST.Type)),
+
+	errs := make([]error, 0, len(messages))
+	for _, message := range messages {
+		errs = append(errs, gqlerrors.NewError(
+			message,
 			[]ast.Node{definitionAST},
 			"",
 			nil,
 			[]int{},
 			nil,
-		)
+		))
 	}
-	// convert input interface into string for error message
-	bts, _ := json.Marshal(input)
-	var (
-		inputStr = string(bts)
-		msg      string
-	)
-	if len(messages) > 0 {
-		msg = "\n" + strings.Join(messages, "\n")
-	}
-
-	return "", gqlerrors.NewError(
-		fmt.Sprintf(`Variable "$%v" got invalid value `+
-			`%v.%v`, variable.Name.Value, inputStr, msg),
-		[]ast.Node{definitionAST},
-		"",
-		nil,
-		[]int{},
-		nil,
-	)
+	return "", errs
 }
 
 // Given a type and any value, return a runtime value coerced to match the type.
 func coerceValue(ttype Input, value interface{}) interface{} {
+	value = unwrapNullable(value)
 	if isNullish(value) {
 		return nil
 	}
@@ -140,30 +199,31 @@ func coerceValue(ttype Input, value interface{}) interface{} {
 		return coerceValue(ttype.OfType, value)
 	case *List:
 		values := []interface{}{}
-		valType := reflect.ValueOf(value)
-		if valType.Kind() == reflect.Slice {
-			for i := 0; i < valType.Len(); i++ {
-				val := valType.Index(i).Interface()
+		if items, ok := inputListItems(value); ok {
+			for i := 0; i < items.Len(); i++ {
+				val := items.Index(i).Interface()
 				values = append(values, coerceValue(ttype.OfType, val))
 			}
 			return values
 		}
+		// a single value is coerced into a list of one, at any nesting level
 		return append(values, coerceValue(ttype.OfType, value))
 	case *InputObject:
 		obj := map[string]interface{}{}
-		valueMap, _ := value.(map[string]interface{})
+		valueMap, _ := inputObjectFields(value)
 		if valueMap == nil {
 			valueMap = map[string]interface{}{}
 		}
 
 		for name, field := range ttype.Fields() {
-			fieldValue := coerceValue(field.Type, valueMap[name])
-			if isNullish(fieldValue) {
-				fieldValue = field.DefaultValue
-			}
-			if !isNullish(fieldValue) {
-				obj[name] = fieldValue
+			fieldInput, provided := valueMap[name]
+			if !provided {
+				if !isNullish(field.DefaultValue) {
+					obj[name] = field.DefaultValue
+				}
+				continue
 			}
+			obj[name] = coerceValue(field.Type, fieldInput)
 		}
 		return obj
 	case *Scalar:
@@ -208,49 +268,42 @@ func typeFromAST(schema Schema, inputTypeAST ast.Type) (Type, error) {
 	}
 }
 
-// isValidInputValue alias isValidJSValue
-// Given a value and a GraphQL type, determine if the value will be
-// accepted for that type. This is primarily useful for validating the
-// runtime values of query variables.
-func isValidInputValue(value interface{}, ttype Input) (bool, []string) {
+// inputValueErrors returns the reasons why value will not be accepted for the input type ttype,
+// one for each invalid value found at any depth, or nil if it is valid. path is the JSON path to
+// value, starting with the variable name, which points at the offending values in the messages.
+// It is primarily useful for validating the runtime values of query variables.
+func inputValueErrors(value interface{}, ttype Input, path string) []string {
+	value = unwrapNullable(value)
 	if isNullish(value) {
-		if ttype, ok := ttype.(*NonNull); ok {
-			if ttype.OfType.Name() != "" {
-				return false, []string{fmt.Sprintf(`Expected "%v!", found null.`, ttype.OfType.Name())}
-			}
-			return false, []string{"Expected non-null value, found null."}
+		if _, ok := ttype.(*NonNull); ok {
+			return []string{fmt.Sprintf(`Variable "%v" expected value of type "%v", found null.`, path, ttype)}
 		}
-		return true, nil
+		return nil
 	}
-	switch ttype := ttype.(type) {
-	case *NonNull:
-		return isValidInputValue(value, ttype.OfType)
+
+	namedType := ttype
+	if nonNull, ok := ttype.(*NonNull); ok {
+		namedType = nonNull.OfType
+	}
+	switch namedType := namedType.(type) {
 	case *List:
-		valType := reflect.ValueOf(value)
-		if valType.Kind() == reflect.Ptr {
-			valType = valType.Elem()
-		}
-		if valType.Kind() == reflect.Slice {
-			messagesReduce := []string{}
-			for i := 0; i < valType.Len(); i++ {
-				val := valType.Index(i).Interface()
-				_, messages := isValidInputValue(val, ttype.OfType)
-				for idx, message := range messages {
-					messagesReduce = append(messagesReduce, fmt.Sprintf(`In element #%v: %v`, idx+1, message))
-				}
+		if items, ok := inputListItems(value); ok {
+			messages := []string{}
+			for i := 0; i < items.Len(); i++ {
+				val := items.Index(i).Interface()
+				messages = append(messages, inputValueErrors(val, namedType.OfType, fmt.Sprintf("%v[%v]", path, i))...)
 			}
-			return (len(messagesReduce) == 0), messagesReduce
+			return messages
 		}
-		return isValidInputValue(value, ttype.OfType)
+		// a single value is accepted as a list of one element
+		return inputValueErrors(value, namedType.OfType, path)
 
 	case *InputObject:
-		messagesReduce := []string{}
-
-		valueMap, ok := value.(map[string]interface{})
+		valueMap, ok := inputObjectFields(value)
 		if !ok {
-			return false, []string{fmt.Sprintf(`Expected "%v", found not an object.`, ttype.Name())}
+			return []string{fmt.Sprintf(`Variable "%v" expected value of type "%v", found %v.`, path, ttype, inputValueString(value))}
 		}
-		fields := ttype.Fields()
+		fields := namedType.Fields()
 
 		// to ensure stable order of field evaluation
 		fieldNames := []string{}
@@ -266,34 +319,71 @@ func isValidInputValue(value interface{}, ttype Input) (bool, []string) {
 		}
 		sort.Strings(valueMapFieldNames)
 
+		messages := []string{}
+
 		// Ensure every provided field is defined.
 		for _, fieldName := range valueMapFieldNames {
 			if _, ok := fields[fieldName]; !ok {
-				messagesReduce = append(messagesReduce, fmt.Sprintf(`In field "%v": Unknown field.`, fieldName))
+				messages = append(messages, fmt.Sprintf(`Variable "%v.%v" is not defined by type "%v".`, path, fieldName, namedType))
 			}
 		}
 
 		// Ensure every defined field is valid.
 		for _, fieldName := range fieldNames {
-			_, messages := isValidInputValue(valueMap[fieldName], fields[fieldName].Type)
-			if messages != nil {
-				for _, message := range messages {
-					messagesReduce = append(messagesReduce, fmt.Sprintf(`In field "%v": %v`, fieldName, message))
-				}
-			}
+			messages = append(messages, inputValueErrors(valueMap[fieldName], fields[fieldName].Type, path+"."+fieldName)...)
 		}
-		return (len(messagesReduce) == 0), messagesReduce
+		return messages
 	case *Scalar:
-		if parsedVal := ttype.ParseValue(value); isNullish(parsedVal) {
-			return false, []string{fmt.Sprintf(`Expected type "%v", found "%v".`, ttype.Name(), value)}
+		if parsedVal := namedType.ParseValue(value); isNullish(parsedVal) {
+			return []string{fmt.Sprintf(`Variable "%v" expected value of type "%v", found %v.`, path, ttype, inputValueString(value))}
 		}
 	case *Enum:
-		if parsedVal := ttype.ParseValue(value); isNullish(parsedVal) {
-			return false, []string{fmt.Sprintf(`Expected type "%v", found "%v".`, ttype.Name(), value)}
+		if parsedVal := namedType.ParseValue(value); isNullish(parsedVal) {
+			return []string{fmt.Sprintf(`Variable "%v" expected value of type "%v", found %v.`, path, ttype, inputValueString(value))}
 		}
 	}
 
-	return true, nil
+	return nil
+}
+
+// inputListItems returns the items of a runtime list value, which may be a slice or an array of
+// any type, or a pointer to one.
+func inputListItems(value interface{}) (reflect.Value, bool) {
+	items := reflect.ValueOf(value)
+	if items.Kind() == reflect.Ptr {
+		items = items.Elem()
+	}
+	return items, items.Kind() == reflect.Slice || items.Kind() == reflect.Array
+}
+
+// inputObjectFields returns the fields of a runtime input object value, which may be a map of any
+// type with string keys, or a pointer to one.
+func inputObjectFields(value interface{}) (map[string]interface{}, bool) {
+	if fields, ok := value.(map[string]interface{}); ok {
+		return fields, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	fields := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		fields[iter.Key().String()] = iter.Value().Interface()
+	}
+	return fields, true
+}
+
+// inputValueString prints a runtime input value as JSON for error messages.
+func inputValueString(value interface{}) string {
+	bts, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(bts)
 }
 
 // Returns true if a value is null, undefined, or NaN.
@@ -322,6 +412,50 @@ func isNullish(src interface{}) bool {
 	return false
 }
 
+// NullableValuer is implemented by the Go types that may hold no value, like optional types. Results
+// and variable values implementing it are null when NullableValue returns false, and are
+// replaced with the value it returns otherwise.
+//
+// The Null types of database/sql (sql.NullString, sql.NullInt64, sql.NullTime, ...) are handled
+// the same way, through their driver.Valuer implementation. Nil pointers are null, and pointers
+// to values are completed and coerced as the values they point to.
+type NullableValuer interface {
+	NullableValue() (value interface{}, valid bool)
+}
+
+// unwrapNullable returns the value held by a NullableValuer or by one of the database/sql Null types,
+// or by a pointer to one of them, nil if it holds none. Other values are returned untouched.
+func unwrapNullable(value interface{}) interface{} {
+	switch nullable := value.(type) {
+	case nil:
+		return nil
+	case NullableValuer:
+		if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil
+		}
+		if value, valid := nullable.NullableValue(); valid {
+			return value
+		}
+		return nil
+	case driver.Valuer:
+		ttype := reflect.TypeOf(value)
+		if ttype.Kind() == reflect.Ptr {
+			if reflect.ValueOf(value).IsNil() {
+				return nil
+			}
+			ttype = ttype.Elem()
+		}
+		if ttype.PkgPath() != "database/sql" {
+			return value
+		}
+		if value, err := nullable.Value(); err == nil {
+			return value
+		}
+		return nil
+	}
+	return value
+}
+
 // Returns true if src is a slice or an array
 func isIterable(src interface{}) bool {
 	if src == nil {
@@ -353,6 +487,9 @@ func valueFromAST(valueAST ast.Value, ttype Input, variables map[string]interfac
 	if valueAST == nil {
 		return nil
 	}
+	if _, ok := valueAST.(*ast.NullValue); ok {
+		return nil
+	}
 	// precedence: value > type
 	if valueAST, ok := valueAST.(*ast.Variable); ok {
 		if valueAST.Name == nil || variables == nil {
@@ -393,11 +530,17 @@ func valueFromAST(valueAST ast.Value, ttype Input, variables map[string]interfac
 		}
 		obj := map[string]interface{}{}
 		for name, field := range ttype.Fields() {
-			var value interface{}
-			if of, ok = fieldASTs[name]; ok {
-				value = valueFromAST(of.Value, field.Type, variables)
-			} else {
-				value = field.DefaultValue
+			if of, ok = fieldASTs[name]; !ok || !isValueProvided(of.Value, variables) {
+				if !isNullish(field.DefaultValue) {
+					obj[name] = field.DefaultValue
+				}
+				continue
+			}
+			value := valueFromAST(of.Value, field.Type, variables)
+			switch of.Value.(type) {
+			case *ast.Variable, *ast.NullValue:
+				obj[name] = value
+				continue
 			}
 			if !isNullish(value) {
 				obj[name] = value
@@ -423,8 +423,7 @@ func TestVariables_ObjectsAndNullability_UsingVariables_ErrorsOnNullForNestedNon
 		Data: nil,
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: `Variable "$input" got invalid value {"a":"foo","b":"bar","c":null}.` +
-					"\nIn field \"c\": Expected \"String!\", found null.",
+				Message: `Variable "$input.c" expected value of type "String!", found null.`,
 				Locations: []location.SourceLocation{
 					{
 						Line: 2, Column: 17,
@@ -456,7 +455,7 @@ func TestVariables_ObjectsAndNullability_UsingVariables_ErrorsOnIncorrectType(t
 		Data: nil,
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: "Variable \"$input\" got invalid value \"foo bar\".\nExpected \"TestInputObject\", found not an object.",
+				Message: `Variable "$input" expected value of type "TestInputObject", found "foo bar".`,
 				Locations: []location.SourceLocation{
 					{
 						Line: 2, Column: 17,
@@ -491,8 +490,7 @@ func TestVariables_ObjectsAndNullability_UsingVariables_ErrorsOnOmissionOfNested
 		Data: nil,
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: `Variable "$input" got invalid value {"a":"foo","b":"bar"}.` +
-					"\nIn field \"c\": Expected \"String!\", found null.",
+				Message: `Variable "$input.c" expected value of type "String!", found null.`,
 				Locations: []location.SourceLocation{
 					{
 						Line: 2, Column: 17,
@@ -528,9 +526,15 @@ func TestVariables_ObjectsAndNullability_UsingVariables_ErrorsOnDeepNestedErrors
 		Data: nil,
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: `Variable "$input" got invalid value {"na":{"a":"foo"}}.` +
-					"\nIn field \"na\": In field \"c\": Expected \"String!\", found null." +
-					"\nIn field \"nb\": Expected \"String!\", found null.",
+				Message: `Variable "$input.na.c" expected value of type "String!", found null.`,
+				Locations: []location.SourceLocation{
+					{
+						Line: 2, Column: 19,
+					},
+				},
+			},
+			{
+				Message: `Variable "$input.nb" expected value of type "String!", found null.`,
 				Locations: []location.SourceLocation{
 					{
 						Line: 2, Column: 19,
@@ -559,6 +563,62 @@ func TestVariables_ObjectsAndNullability_UsingVariables_ErrorsOnDeepNestedErrors
 	}
 }
 
+func TestVariables_ObjectsAndNullability_UsingVariables_ErrorsOnEveryInvalidVariable(t *testing.T) {
+	params := map[string]interface{}{
+		"input": map[string]interface{}{
+			"a":     "foo",
+			"extra": "dog",
+		},
+	}
+	expected := &graphql.Result{
+		Data: nil,
+		Errors: []gqlerrors.FormattedError{
+			{
+				Message: `Variable "$input.extra" is not defined by type "TestInputObject".`,
+				Locations: []location.SourceLocation{
+					{
+						Line: 2, Column: 19,
+					},
+				},
+			},
+			{
+				Message: `Variable "$input.c" expected value of type "String!", found null.`,
+				Locations: []location.SourceLocation{
+					{
+						Line: 2, Column: 19,
+					},
+				},
+			},
+			{
+				Message: `Variable "$value" of required type "String!" was not provided.`,
+				Locations: []location.SourceLocation{
+					{
+						Line: 2, Column: 44,
+					},
+				},
+			},
+		},
+	}
+	doc := `
+          query q($input: TestInputObject, $value: String!) {
+            fieldWithObjectInput(input: $input)
+            fieldWithNonNullableStringInput(input: $value)
+          }
+        `
+	ast := testutil.TestParse(t, doc)
+
+	// execute
+	ep := graphql.ExecuteParams{
+		Schema: variablesTestSchema,
+		AST:    ast,
+		Args:   params,
+	}
+	result := testutil.TestExecute(t, ep)
+	if !testutil.EqualResults(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+}
+
 func TestVariables_ObjectsAndNullability_UsingVariables_ErrorsOnAdditionOfUnknownInputField(t *testing.T) {
 	params := map[string]interface{}{
 		"input": map[string]interface{}{
@@ -572,8 +632,7 @@ func TestVariables_ObjectsAndNullability_UsingVariables_ErrorsOnAdditionOfUnknow
 		Data: nil,
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: `Variable "$input" got invalid value {"a":"foo","b":"bar","c":"baz","extra":"dog"}.` +
-					"\nIn field \"extra\": Unknown field.",
+				Message: `Variable "$input.extra" is not defined by type "TestInputObject".`,
 				Locations: []location.SourceLocation{
 					{
 						Line: 2, Column: 17,
@@ -692,7 +751,7 @@ func TestVariables_NullableScalars_AllowsNullableInputsToBeSetToNullInAVariable(
 	}
 	expected := &graphql.Result{
 		Data: map[string]interface{}{
-			"fieldWithNullableStringInput": nil,
+			"fieldWithNullableStringInput": "null",
 		},
 	}
 
@@ -821,7 +880,7 @@ func TestVariables_NonNullableScalars_DoesNotAllowNonNullableInputsToBeSetToNull
 		Data: nil,
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: `Variable "$value" of required type "String!" was not provided.`,
+				Message: `Variable "$value" of non-null type "String!" must not be null.`,
 				Locations: []location.SourceLocation{
 					{
 						Line: 2, Column: 31,
@@ -958,7 +1017,7 @@ func TestVariables_ListsAndNullability_AllowsListsToBeNull(t *testing.T) {
 
 	expected := &graphql.Result{
 		Data: map[string]interface{}{
-			"list": nil,
+			"list": "null",
 		},
 	}
 	ast := testutil.TestParse(t, doc)
@@ -1147,7 +1206,7 @@ func TestVariables_ListsAndNullability_AllowsListsOfNonNullsToBeNull(t *testing.
 	}
 	expected := &graphql.Result{
 		Data: map[string]interface{}{
-			"listNN": nil,
+			"listNN": "null",
 		},
 	}
 	ast := testutil.TestParse(t, doc)
@@ -1211,9 +1270,7 @@ func TestVariables_ListsAndNullability_DoesNotAllowListOfNonNullsToContainNull(t
 		Data: nil,
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: `Variable "$input" got invalid value ` +
-					`["A",null,"B"].` +
-					"\nIn element #1: Expected \"String!\", found null.",
+				Message: `Variable "$input[1]" expected value of type "String!", found null.`,
 				Locations: []location.SourceLocation{
 					{
 						Line: 2, Column: 17,
@@ -1249,7 +1306,7 @@ func TestVariables_ListsAndNullability_DoesNotAllowNonNullListOfNonNullsToBeNull
 		Data: nil,
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: `Variable "$input" of required type "[String!]!" was not provided.`,
+				Message: `Variable "$input" of non-null type "[String!]!" must not be null.`,
 				Locations: []location.SourceLocation{
 					{
 						Line: 2, Column: 17,
@@ -1316,9 +1373,7 @@ func TestVariables_ListsAndNullability_DoesNotAllowNonNullListOfNonNullsToContai
 		Data: nil,
 		Errors: []gqlerrors.FormattedError{
 			{
-				Message: `Variable "$input" got invalid value ` +
-					`["A",null,"B"].` +
-					"\nIn element #1: Expected \"String!\", found null.",
+				Message: `Variable "$input[1]" expected value of type "String!", found null.`,
 				Locations: []location.SourceLocation{
 					{
 						Line: 2, Column: 17,
@@ -1498,3 +1553,91 @@ func TestVariables_UsesArgumentDefaultValues_WhenArgumentProvidedCannotBeParsed(
 		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
 	}
 }
+
+func TestVariables_UsesArgumentDefaultValues_NotWhenNullableVariableExplicitlyNull(t *testing.T) {
+	doc := `
+	query optionalVariable($optional: String) {
+        fieldWithDefaultArgumentValue(input: $optional)
+    }
+	`
+	params := map[string]interface{}{
+		"optional": nil,
+	}
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"fieldWithDefaultArgumentValue": "null",
+		},
+	}
+	ast := testutil.TestParse(t, doc)
+
+	// execute
+	ep := graphql.ExecuteParams{
+		Schema: variablesTestSchema,
+		AST:    ast,
+		Args:   params,
+	}
+	result := testutil.TestExecute(t, ep)
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+}
+
+func TestVariables_UsesArgumentDefaultValues_NotWhenArgumentIsNullLiteral(t *testing.T) {
+	doc := `
+	{
+		fieldWithDefaultArgumentValue(input: null)
+	}
+	`
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"fieldWithDefaultArgumentValue": "null",
+		},
+	}
+	ast := testutil.TestParse(t, doc)
+
+	// execute
+	ep := graphql.ExecuteParams{
+		Schema: variablesTestSchema,
+		AST:    ast,
+	}
+	result := testutil.TestExecute(t, ep)
+	if !reflect.DeepEqual(expected, result) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, result))
+	}
+}
+
+func TestVariables_UsesVariableDefaultValues_OnlyWhenVariableOmitted(t *testing.T) {
+	doc := `
+	query q($value: String = "Default") {
+        fieldWithNullableStringInput(input: $value)
+    }
+	`
+	ast := testutil.TestParse(t, doc)
+
+	omitted := testutil.TestExecute(t, graphql.ExecuteParams{
+		Schema: variablesTestSchema,
+		AST:    ast,
+	})
+	expected := &graphql.Result{
+		Data: map[string]interface{}{
+			"fieldWithNullableStringInput": `"Default"`,
+		},
+	}
+	if !reflect.DeepEqual(expected, omitted) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, omitted))
+	}
+
+	explicitNull := testutil.TestExecute(t, graphql.ExecuteParams{
+		Schema: variablesTestSchema,
+		AST:    ast,
+		Args:   map[string]interface{}{"value": nil},
+	})
+	expected = &graphql.Result{
+		Data: map[string]interface{}{
+			"fieldWithNullableStringInput": "null",
+		},
+	}
+	if !reflect.DeepEqual(expected, explicitNull) {
+		t.Fatalf("Unexpected result, Diff: %v", testutil.Diff(expected, explicitNull))
+	}
+}
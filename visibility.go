@@ -0,0 +1,199 @@
+package graphql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fiatjaf/graphql/language/ast"
+)
+
+// VisibilityFn tells if the field fieldName of the type typeName, or the type itself when fieldName
+// is "", is visible to the request of ctx, see Params.VisibilityFn. The fields and types that
+// aren't visible are left out of the introspection, and the queries selecting them fail the
+// validation as if they didn't exist. The fields whose type isn't visible aren't visible either, so
+// hiding a type hides every field of that type, and hiding an operation type hides the operations
+// of that type. The introspection types are always visible.
+type VisibilityFn func(ctx context.Context, typeName, fieldName string) bool
+
+type visibilityKey struct{}
+
+type internalAccessKey struct{}
+
+// WithInternalAccess returns a context for the requests of trusted callers, like a gateway, which
+// can query the internal fields and types, see Field.Internal. They are still left out of the
+// introspection.
+func WithInternalAccess(ctx context.Context) context.Context {
+	return context.WithValue(ctx, internalAccessKey{}, true)
+}
+
+// HasInternalAccess tells if the request of ctx can query the internal fields and types.
+func HasInternalAccess(ctx context.Context) bool {
+	access, _ := ctx.Value(internalAccessKey{}).(bool)
+	return access
+}
+
+// visibility is what of a schema a request sees: not the internal fields and types unless it has
+// internal access, nor what its VisibilityFn hides. A nil visibility sees everything.
+type visibility struct {
+	ctx            context.Context
+	fn             VisibilityFn
+	schema         *Schema
+	internalAccess bool
+}
+
+// withVisibility returns a context carrying fn for the introspection of the request of ctx.
+func withVisibility(ctx context.Context, fn VisibilityFn) context.Context {
+	return context.WithValue(ctx, visibilityKey{}, fn)
+}
+
+// requestVisibility returns the visibility of the request of ctx validated against schema.
+func requestVisibility(ctx context.Context, schema *Schema) *visibility {
+	fn, _ := ctx.Value(visibilityKey{}).(VisibilityFn)
+	internalAccess := HasInternalAccess(ctx)
+	if fn == nil && internalAccess {
+		return nil
+	}
+	return &visibility{ctx: ctx, fn: fn, schema: schema, internalAccess: internalAccess}
+}
+
+// introspectionVisibility returns the visibility of the introspection of the request of ctx, which
+// never includes the internal fields and types.
+func introspectionVisibility(ctx context.Context, schema Schema) *visibility {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fn, _ := ctx.Value(visibilityKey{}).(VisibilityFn)
+	return &visibility{ctx: ctx, fn: fn, schema: &schema}
+}
+
+// typeVisible tells if the type named typeName is visible.
+func (v *visibility) typeVisible(typeName string) bool {
+	if v == nil || strings.HasPrefix(typeName, "__") {
+		return true
+	}
+	if !v.internalAccess && isInternalType(v.schema.Type(typeName)) {
+		return false
+	}
+	return v.fn == nil || v.fn(v.ctx, typeName, "")
+}
+
+// fieldVisible tells if field of the type named typeName is visible: the type, the field and the
+// type of the field must all be visible.
+func (v *visibility) fieldVisible(typeName string, field *FieldDefinition) bool {
+	if v == nil || strings.HasPrefix(typeName, "__") || strings.HasPrefix(field.Name, "__") {
+		return true
+	}
+	if !v.internalAccess && field.Internal {
+		return false
+	}
+	if !v.typeVisible(typeName) || (v.fn != nil && !v.fn(v.ctx, typeName, field.Name)) {
+		return false
+	}
+	if named, ok := GetNamed(field.Type).(Type); ok {
+		return v.typeVisible(named.Name())
+	}
+	return true
+}
+
+// isInternalType tells if ttype is internal, see ObjectConfig.Internal.
+func isInternalType(ttype Type) bool {
+	switch ttype := ttype.(type) {
+	case *Object:
+		return ttype != nil && ttype.typeConfig.Internal
+	case *Interface:
+		return ttype != nil && ttype.typeConfig.Internal
+	case *Union:
+		return ttype != nil && ttype.typeConfig.Internal
+	}
+	return false
+}
+
+// isInternalField tells if field, or its type, is internal.
+func isInternalField(field *FieldDefinition) bool {
+	if field.Internal {
+		return true
+	}
+	named, _ := GetNamed(field.Type).(Type)
+	return isInternalType(named)
+}
+
+// filterObjects returns the visible objects of objects.
+func (v *visibility) filterObjects(objects []*Object) []*Object {
+	if v == nil {
+		return objects
+	}
+	visible := []*Object{}
+	for _, object := range objects {
+		if v.typeVisible(object.Name()) {
+			visible = append(visible, object)
+		}
+	}
+	return visible
+}
+
+// filterInterfaces returns the visible interfaces of interfaces.
+func (v *visibility) filterInterfaces(interfaces []*Interface) []*Interface {
+	if v == nil {
+		return interfaces
+	}
+	visible := []*Interface{}
+	for _, iface := range interfaces {
+		if v.typeVisible(iface.Name()) {
+			visible = append(visible, iface)
+		}
+	}
+	return visible
+}
+
+// filterSuggestedTypes returns the types of typeNames whose field fieldName is visible.
+func (v *visibility) filterSuggestedTypes(schema *Schema, typeNames []string, fieldName string) []string {
+	visible := []string{}
+	for _, typeName := range typeNames {
+		field := fieldsOf(schema.Type(typeName))[fieldName]
+		if field != nil && v.fieldVisible(typeName, field) {
+			visible = append(visible, typeName)
+		}
+	}
+	return visible
+}
+
+// filterSuggestedFields returns the visible fields of ttype named by fieldNames.
+func (v *visibility) filterSuggestedFields(ttype Type, fieldNames []string) []string {
+	fields := fieldsOf(ttype)
+	visible := []string{}
+	for _, fieldName := range fieldNames {
+		if field := fields[fieldName]; field != nil && v.fieldVisible(ttype.Name(), field) {
+			visible = append(visible, fieldName)
+		}
+	}
+	return visible
+}
+
+// fieldsOf returns the fields of an object or interface, nil for the other types.
+func fieldsOf(ttype Type) FieldDefinitionMap {
+	switch ttype := ttype.(type) {
+	case *Object:
+		return ttype.Fields()
+	case *Interface:
+		return ttype.Fields()
+	}
+	return nil
+}
+
+// fieldDefFn returns the FieldDefFn of the TypeInfo of the validation, which doesn't find the
+// fields that aren't visible.
+func (v *visibility) fieldDefFn() FieldDefFn {
+	if v == nil {
+		return nil
+	}
+	return func(schema *Schema, parentType Type, fieldAST *ast.Field) *FieldDefinition {
+		fieldDef := DefaultTypeInfoFieldDef(schema, parentType, fieldAST)
+		if fieldDef == nil || strings.HasPrefix(fieldDef.Name, "__") {
+			return fieldDef
+		}
+		if !v.fieldVisible(parentType.Name(), fieldDef) {
+			return nil
+		}
+		return fieldDef
+	}
+}
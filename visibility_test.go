@@ -0,0 +1,223 @@
+package graphql_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/fiatjaf/graphql"
+)
+
+func visibilityTestSchema(t *testing.T) graphql.Schema {
+	salary := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Salary",
+		Fields: graphql.Fields{
+			"amount": &graphql.Field{Type: graphql.Int},
+		},
+	})
+	employee := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Employee",
+		Fields: graphql.Fields{
+			"name":   &graphql.Field{Type: graphql.String},
+			"email":  &graphql.Field{Type: graphql.String},
+			"salary": &graphql.Field{Type: salary},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"employee": &graphql.Field{
+					Type: employee,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return map[string]interface{}{
+							"name":   "Ada",
+							"email":  "ada@example.com",
+							"salary": map[string]interface{}{"amount": 100},
+						}, nil
+					},
+				},
+			},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"fire": &graphql.Field{Type: graphql.Boolean},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+// adminsOnly hides the emails, the salaries and the mutations from the users who aren't admins.
+func adminsOnly(ctx context.Context, typeName, fieldName string) bool {
+	if ctx.Value(roleKey{}) == "admin" {
+		return true
+	}
+	return !(typeName == "Employee" && fieldName == "email") && typeName != "Salary" && typeName != "Mutation"
+}
+
+func TestVisibilityFn_FailsValidationOfHiddenFields(t *testing.T) {
+	schema := visibilityTestSchema(t)
+	cache := graphql.NewDocumentCache(10)
+	do := func(role, query string) *graphql.Result {
+		return graphql.Do(graphql.Params{
+			Schema:        schema,
+			RequestString: query,
+			Context:       context.WithValue(context.Background(), roleKey{}, role),
+			VisibilityFn:  adminsOnly,
+			DocumentCache: cache,
+		})
+	}
+
+	query := `{ employee { name email salary { amount } } }`
+	result := do("admin", query)
+	expected := map[string]interface{}{
+		"employee": map[string]interface{}{
+			"name":   "Ada",
+			"email":  "ada@example.com",
+			"salary": map[string]interface{}{"amount": 100},
+		},
+	}
+	if len(result.Errors) != 0 || !reflect.DeepEqual(result.Data, expected) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// the document validated for the admin isn't valid for the others
+	result = do("user", query)
+	var messages []string
+	for _, err := range result.Errors {
+		messages = append(messages, err.Message)
+	}
+	expectedMessages := []string{
+		`Cannot query field "email" on type "Employee".`,
+		`Cannot query field "salary" on type "Employee".`,
+	}
+	if result.Data != nil || !reflect.DeepEqual(messages, expectedMessages) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// hidden fields aren't suggested
+	result = do("user", `{ employee { emai } }`)
+	if len(result.Errors) != 1 || result.Errors[0].Message != `Cannot query field "emai" on type "Employee".` {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	result = do("admin", `{ employee { emai } }`)
+	if len(result.Errors) != 1 || result.Errors[0].Message != `Cannot query field "emai" on type "Employee". Did you mean "email"?` {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	result = do("user", `{ employee { name } } fragment pay on Salary { amount }`)
+	if len(result.Errors) == 0 || result.Errors[0].Message != `Unknown type "Salary".` {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	result = do("user", `mutation { fire }`)
+	if len(result.Errors) != 1 || result.Errors[0].Message != `Cannot query field "fire" on type "Mutation".` {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestVisibilityFn_HidesFieldsAndTypesFromIntrospection(t *testing.T) {
+	schema := visibilityTestSchema(t)
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		RequestString: `{
+			__schema { mutationType { name } types { name } }
+			employee: __type(name: "Employee") { fields { name } }
+			salary: __type(name: "Salary") { name }
+		}`,
+		Context:      context.WithValue(context.Background(), roleKey{}, "user"),
+		VisibilityFn: adminsOnly,
+	})
+	if len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+	data := result.Data.(map[string]interface{})
+
+	introspected := data["__schema"].(map[string]interface{})
+	if introspected["mutationType"] != nil {
+		t.Fatalf("expected the mutation type to be hidden, got %v", introspected["mutationType"])
+	}
+	for _, ttype := range introspected["types"].([]interface{}) {
+		if name := ttype.(map[string]interface{})["name"]; name == "Salary" || name == "Mutation" {
+			t.Fatalf("expected %s to be hidden", name)
+		}
+	}
+	expectedFields := map[string]interface{}{"fields": []interface{}{map[string]interface{}{"name": "name"}}}
+	if !reflect.DeepEqual(data["employee"], expectedFields) {
+		t.Fatalf("expected the hidden fields to be left out, got %v", data["employee"])
+	}
+	if data["salary"] != nil {
+		t.Fatalf("expected the hidden type not to be found, got %v", data["salary"])
+	}
+}
+
+func TestInternal_OnlyExecutedWithInternalAccess(t *testing.T) {
+	legacy := graphql.NewObject(graphql.ObjectConfig{
+		Name:     "Legacy",
+		Internal: true,
+		Fields: graphql.Fields{
+			"id": &graphql.Field{Type: graphql.ID},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"name": &graphql.Field{
+					Type:    graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return "Ada", nil },
+				},
+				"fullName": &graphql.Field{
+					Type:     graphql.String,
+					Internal: true,
+					Resolve:  func(p graphql.ResolveParams) (interface{}, error) { return "Ada Lovelace", nil },
+				},
+				"legacy": &graphql.Field{
+					Type:    legacy,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) { return map[string]interface{}{"id": "1"}, nil },
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	do := func(ctx context.Context, query string) *graphql.Result {
+		return graphql.Do(graphql.Params{Schema: schema, RequestString: query, Context: ctx})
+	}
+
+	query := `{ name fullName legacy { id } }`
+	result := do(context.Background(), query)
+	if len(result.Errors) != 2 || result.Errors[0].Message != `Cannot query field "fullName" on type "Query".` ||
+		result.Errors[1].Message != `Cannot query field "legacy" on type "Query".` {
+		t.Fatalf("expected the internal fields not to exist, got %+v", result)
+	}
+
+	trusted := graphql.WithInternalAccess(context.Background())
+	result = do(trusted, query)
+	expected := map[string]interface{}{
+		"name":     "Ada",
+		"fullName": "Ada Lovelace",
+		"legacy":   map[string]interface{}{"id": "1"},
+	}
+	if len(result.Errors) != 0 || !reflect.DeepEqual(result.Data, expected) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	result = do(trusted, `{ __type(name: "Query") { fields { name } } legacy: __type(name: "Legacy") { name } }`)
+	expected = map[string]interface{}{
+		"__type": map[string]interface{}{"fields": []interface{}{map[string]interface{}{"name": "name"}}},
+		"legacy": nil,
+	}
+	if len(result.Errors) != 0 || !reflect.DeepEqual(result.Data, expected) {
+		t.Fatalf("expected the introspection to leave the internal fields out, got %+v", result)
+	}
+
+	if sdl := graphql.PrintSchema(schema); sdl != "type Query {\n  name: String\n}\n" {
+		t.Fatalf("expected PrintSchema to leave the internal fields out, got %q", sdl)
+	}
+}